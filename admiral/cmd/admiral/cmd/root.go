@@ -12,12 +12,18 @@ import (
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/routes"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/server"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/introspection"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/logging"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/notifier"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"gopkg.in/natefinch/lumberjack.v2"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -46,10 +52,20 @@ func GetRootCmd(args []string) *cobra.Command {
 			if len(args) > 0 {
 				return fmt.Errorf("%q is an invalid argument", args[0])
 			}
+			// the client certificates and bearer tokens of the callers must not be sent in clear text
+			if len(params.AuthMethods) > 0 && params.APICertFile == "" {
+				return fmt.Errorf("auth_methods %v require the api to be served over TLS, api_cert_file is not set", params.AuthMethods)
+			}
 			return nil
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			log.SetLevel(log.Level(params.LogLevel))
+			if err := logging.Init(log.Level(params.LogLevel), params.ModuleLogLevels); err != nil {
+				log.Error("error setting the module log levels: " + err.Error())
+			}
+			if err := logging.InitSampling(params.LogSampling); err != nil {
+				log.Error("error setting the log sampling: " + err.Error())
+			}
 			if params.LogToFile {
 				// open a file and rotate it at a certain size
 				_, err := os.OpenFile(params.LogFilePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
@@ -65,6 +81,12 @@ func GetRootCmd(args []string) *cobra.Command {
 				}
 			}
 			log.Info("Starting Admiral")
+			if err := audit.Initialize(params.AuditSink); err != nil {
+				log.Fatalf("Error initializing audit sink: %v", err)
+			}
+			if err := notifier.Initialize(params.NotifierType, params.NotifierEndpoint, params.NotificationInterval); err != nil {
+				log.Fatalf("Error initializing notifier: %v", err)
+			}
 			var (
 				err            error
 				remoteRegistry *clusters.RemoteRegistry
@@ -96,6 +118,20 @@ func GetRootCmd(args []string) *cobra.Command {
 			metricsService := server.Service{}
 			opts.RemoteRegistry = remoteRegistry
 
+			var authClient kubernetes.Interface
+			if len(params.AuthMethods) > 0 {
+				var clientErr error
+				authClient, clientErr = remoteRegistry.ClientLoader.LoadKubeClientFromPath(params.KubeconfigPath)
+				if clientErr != nil {
+					log.Fatalf("Error loading the kubernetes client of the auth: %v", clientErr)
+				}
+			}
+			apiAuth, authErr := auth.New(params.AuthMethods, params.AuthRoleBindings, authClient)
+			if authErr != nil {
+				log.Fatalf("Error setting up the auth: %v", authErr)
+			}
+			opts.Auth = apiAuth
+
 			mainRoutes := routes.NewAdmiralAPIServer(&opts)
 			metricRoutes := routes.NewMetricsServer()
 
@@ -110,9 +146,43 @@ func GetRootCmd(args []string) *cobra.Command {
 				wg.Done()
 			}()
 			go func() {
-				service.Start(ctx, 8080, mainRoutes, routes.Filter, remoteRegistry)
+				if params.APICertFile != "" && params.APIClientCAFile != "" {
+					if err := service.StartMutualTLS(ctx, 8080, mainRoutes, routes.Filter, remoteRegistry, params.APICertFile, params.APIKeyFile, params.APIClientCAFile); err != nil {
+						log.Fatalf("Error serving the api over mutual TLS: %v", err)
+					}
+				} else if params.APICertFile != "" {
+					if err := service.StartTLS(ctx, 8080, mainRoutes, routes.Filter, remoteRegistry, params.APICertFile, params.APIKeyFile); err != nil {
+						log.Fatalf("Error serving the api over TLS: %v", err)
+					}
+				} else {
+					service.Start(ctx, 8080, mainRoutes, routes.Filter, remoteRegistry)
+				}
 				wg.Done()
 			}()
+			if params.EnableAdmissionWebhook {
+				webhookService := server.Service{}
+				webhookRoutes := routes.NewAdmissionWebhookServer(&opts)
+				wg.Add(1)
+				go func() {
+					// admiral keeps running without the webhooks, the api server applies their failure policy
+					if err := webhookService.StartTLS(ctx, params.AdmissionWebhookPort, webhookRoutes, routes.Filter, remoteRegistry,
+						params.AdmissionWebhookCertFile, params.AdmissionWebhookKeyFile); err != nil {
+						log.Errorf("Error serving the admission webhooks: %v", err)
+					}
+					wg.Done()
+				}()
+			}
+			if params.EnableIntrospectionServer {
+				introspectionServer := introspection.NewServer(remoteRegistry)
+				wg.Add(1)
+				go func() {
+					if err := introspection.Start(ctx, params.IntrospectionPort, introspectionServer, params.IntrospectionTokenFile,
+						params.IntrospectionCertFile, params.IntrospectionKeyFile, params.APIClientCAFile, opts.Auth); err != nil {
+						log.Fatalf("Error starting introspection server: %v", err)
+					}
+					wg.Done()
+				}()
+			}
 			wg.Wait()
 
 			log.WithFields(log.Fields{
@@ -135,6 +205,12 @@ func GetRootCmd(args []string) *cobra.Command {
 		"Path to log file. If not specified, defaults to /app/logs/admiral.log")
 	rootCmd.PersistentFlags().IntVar(&params.LogFileSizeInMBs, "log_file_size_in_MBs", 200,
 		"Size of the log file in Mbs. If not specified, defaults to 200 Mbs")
+	// Usage: --module_log_levels clusters=debug,clusters/serviceentry=info
+	rootCmd.PersistentFlags().StringToStringVar(&params.ModuleLogLevels, "module_log_levels", map[string]string{},
+		"Log level per module, a module is a package or a file under pkg, e.g. clusters or clusters/virtualservice_handler. The levels can be changed at runtime through the /loglevels endpoint")
+	// Usage: --log_sampling clusters=100,clusters/serviceentry_handler=1m
+	rootCmd.PersistentFlags().StringToStringVar(&params.LogSampling, "log_sampling", map[string]string{},
+		"Sampling of the info, debug and trace logs per module, either one of every N logs of each log site or one log of each log site per duration. The logs carry the number of logs suppressed before them")
 	rootCmd.PersistentFlags().StringVar(&params.KubeconfigPath, "kube_config", "",
 		"Use a Kubernetes configuration file instead of in-cluster configuration")
 	rootCmd.PersistentFlags().BoolVar(&params.ArgoRolloutsEnabled, "argo_rollouts", true,
@@ -142,6 +218,9 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().StringVar(&params.SecretFilterTags, "secret_filter_tags", "admiral/sync", "Filter tags for the specific admiral namespace secret to watch")
 	rootCmd.PersistentFlags().StringVar(&params.ClusterRegistriesNamespace, "secret_namespace", "admiral",
 		"Namespace to monitor for secrets defaults to admiral-secrets")
+	rootCmd.PersistentFlags().IntVar(&params.ClusterWarmupParallelism, "cluster_warmup_parallelism", 1,
+		"Number of clusters whose caches are warmed in parallel, trading a shorter startup for more load on the api servers. "+
+			"The clusters are warmed in the descending order of the admiral.io/warmup-priority label of their secret")
 	rootCmd.PersistentFlags().StringVar(&params.DependenciesNamespace, "dependency_namespace", "admiral",
 		"Namespace to monitor for changes to dependency objects")
 	rootCmd.PersistentFlags().StringVar(&params.SyncNamespace, "sync_namespace", "admiral-sync",
@@ -150,6 +229,22 @@ func GetRootCmd(args []string) *cobra.Command {
 		"Interval for syncing Kubernetes resources, defaults to 5 min")
 	rootCmd.PersistentFlags().DurationVar(&params.SeAndDrCacheReconcileDuration, "se_dr_sync_period", 5*time.Minute,
 		"Interval for syncing ServiceEntries and DestinationRules resources, defaults to 5 min")
+	rootCmd.PersistentFlags().DurationVar(&params.DeploymentReconcileDuration, "deployment_sync_period", 0,
+		"Interval for syncing Deployments, defaults to sync_period when 0, a negative interval disables the resync")
+	rootCmd.PersistentFlags().DurationVar(&params.RolloutReconcileDuration, "rollout_sync_period", 0,
+		"Interval for syncing Rollouts, defaults to sync_period when 0, a negative interval disables the resync")
+	rootCmd.PersistentFlags().DurationVar(&params.NodeReconcileDuration, "node_sync_period", 0,
+		"Interval for syncing Nodes, defaults to sync_period when 0, a negative interval disables the resync")
+	rootCmd.PersistentFlags().DurationVar(&params.ServiceReconcileDuration, "service_sync_period", 0,
+		"Interval for syncing Services, not resynced when 0 or negative")
+	rootCmd.PersistentFlags().DurationVar(&params.ServiceEntryReconcileDuration, "se_sync_period", 0,
+		"Interval for syncing ServiceEntries, defaults to se_dr_sync_period when 0, a negative interval disables the resync")
+	rootCmd.PersistentFlags().DurationVar(&params.DestinationRuleReconcileDuration, "dr_sync_period", 0,
+		"Interval for syncing DestinationRules, defaults to se_dr_sync_period when 0, a negative interval disables the resync")
+	rootCmd.PersistentFlags().DurationVar(&params.VirtualServiceReconcileDuration, "vs_sync_period", 0,
+		"Interval for syncing VirtualServices, not resynced when 0 or negative")
+	rootCmd.PersistentFlags().DurationVar(&params.SidecarReconcileDuration, "sidecar_sync_period", 0,
+		"Interval for syncing Sidecars, not resynced when 0 or negative")
 	rootCmd.PersistentFlags().BoolVar(&params.EnableSAN, "enable_san", false,
 		"If SAN should be enabled for created Service Entries")
 	rootCmd.PersistentFlags().StringVar(&params.SANPrefix, "san_prefix", "",
@@ -186,6 +281,7 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().StringVar(&params.AdmiralConfig, "secret_resolver_config_path", "/etc/config/resolver_config.yaml",
 		"Path to the secret resolver config")
 	rootCmd.PersistentFlags().BoolVar(&params.MetricsEnabled, "metrics", true, "Enable prometheus metrics collections")
+	rootCmd.PersistentFlags().DurationVar(&params.CacheMetricsInterval, "cache_metrics_interval", time.Minute, "Interval at which the size and composition of the admiral caches are exported as metrics")
 	rootCmd.PersistentFlags().StringVar(&params.AdmiralStateCheckerName, "admiral_state_checker_name", "NoOPStateChecker", "The value of the admiral_state_checker_name label to configure the DR Strategy for Admiral")
 	rootCmd.PersistentFlags().StringVar(&params.DRStateStoreConfigPath, "dr_state_store_config_path", "", "Location of config file which has details for data store. Ex:- Dynamo DB connection details")
 	rootCmd.PersistentFlags().StringVar(&params.ServiceEntryIPPrefix, "se_ip_prefix", "240.0", "IP prefix for the auto generated IPs for service entries. Only the first two octets:  Eg- 240.0")
@@ -214,6 +310,7 @@ func GetRootCmd(args []string) *cobra.Command {
 		"The annotation on a deployment/rollout spec, which will be used to divide an asset based on user-specified partition. Defaults to `admiral.io/identityPartition`.")
 	rootCmd.PersistentFlags().StringSliceVar(&params.ExportToIdentityList, "exportto_identity_list", []string{"*"}, "List of identities to write ExportTo field for")
 	rootCmd.PersistentFlags().IntVar(&params.ExportToMaxNamespaces, "exportto_max_namespaces", 35, "Max number of namespaces to write in ExportTo field before just replacing with *")
+	rootCmd.PersistentFlags().StringVar(&params.ExportToFallbackNamespace, "exportto_fallback_namespace", "", "Shared namespace to write in ExportTo field instead of * when the max number of namespaces is exceeded")
 
 	// Admiral HA flags
 	rootCmd.PersistentFlags().IntVar(&params.DNSRetries, "dns_retries", 3, "number of retries for dns resolution")
@@ -251,6 +348,8 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().BoolVar(&params.ClientInitiatedProcessingEnabledForDynamicConfig, "client_initiated_processing_enabled_for_dynamic_config", false, "Enable/Disable Client Initiated Processing via DB")
 	rootCmd.PersistentFlags().StringSliceVar(&params.InitiateClientInitiatedProcessingFor, "initiate_client_initiated_processing_for", []string{}, "List of identities for which client initiated processing should be initiated")
 	rootCmd.PersistentFlags().BoolVar(&params.PreventSplitBrain, "prevent_split_brain", true, "Enable/Disable Explicit Split Brain prevention logic")
+	rootCmd.PersistentFlags().StringVar(&params.VSNameStrategy, "vs_name_strategy", common.VSNameStrategyLegacy,
+		"Strategy used to name the VirtualServices synced to other clusters, one of legacy (namespace-name), hash-suffix (name-hash) or identity (identity-name). Synced VirtualServices are renamed when it changes")
 	rootCmd.PersistentFlags().StringSliceVar(&params.IgnoreLabelsAnnotationsVSCopyList, "ignore_labels_annotations_vs_copy_list", []string{"applications.argoproj.io/app-name", "app.kubernetes.io/instance", "argocd.argoproj.io/tracking-id"}, "Labels and annotations that should not be preserved during VS copy")
 
 	//Admiral 2.0 flags
@@ -275,6 +374,8 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().StringSliceVar(&params.VSRoutingDisabledClusters, "vs_routing_disabled_clusters", []string{}, "The source clusters to disable VS based routing on")
 	rootCmd.PersistentFlags().StringSliceVar(&params.VSRoutingSlowStartEnabledClusters, "vs_routing_slow_start_enabled_clusters", []string{}, "The source clusters to where VS routing is enabled and require slow start")
 	rootCmd.PersistentFlags().StringSliceVar(&params.VSRoutingGateways, "vs_routing_gateways", []string{}, "The PASSTHROUGH gateways to use for VS based routing")
+	rootCmd.PersistentFlags().StringToStringVar(&params.VSRoutingClusterGateways, "vs_routing_cluster_gateways", map[string]string{}, "The PASSTHROUGH gateways, separated by |, to use for VS based routing per cluster. Clusters without an entry use vs_routing_gateways")
+	rootCmd.PersistentFlags().StringToStringVar(&params.VSRoutingIdentityGateways, "vs_routing_identity_gateways", map[string]string{}, "The PASSTHROUGH gateways, separated by |, to use for VS based routing per identity. Takes precedence over vs_routing_cluster_gateways")
 	rootCmd.PersistentFlags().StringSliceVar(&params.IngressVSExportToNamespaces, "ingress_vs_export_to_namespaces", []string{"istio-system"}, "List of namespaces where the ingress VS should be exported")
 	rootCmd.PersistentFlags().StringVar(&params.IngressLBPolicy, "ingress_lb_policy", "round_robin", "loadbalancer policy for ingress destination rule (round_robin/random/passthrough/least_request)")
 
@@ -288,7 +389,7 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().StringVar(&params.ProcessVSCreatedBy, "process_vs_created_by", "", "process the VS that was createdBy. Add createdBy label and value provided here for admiral to process this VS")
 
 	rootCmd.PersistentFlags().BoolVar(&params.EnableClientDiscovery, "enable_client_discovery", true, "Enable/Disable Client (mesh egress) Discovery")
-	rootCmd.PersistentFlags().StringSliceVar(&params.ClientDiscoveryClustersForJobs, "client_discovery_clusters_for_jobs", []string{}, "List of clusters for client discovery for k8s jobs")
+	rootCmd.PersistentFlags().StringSliceVar(&params.ClientDiscoveryClustersForJobs, "client_discovery_clusters_for_jobs", []string{}, "List of clusters for client discovery for k8s jobs and cron jobs")
 	rootCmd.PersistentFlags().StringSliceVar(&params.DiscoveryClustersForNumaflow, "client_discovery_clusters_for_numaflow", []string{}, "List of clusters for client discovery for numaflow types")
 
 	//Parameter for DynamicConfigPush
@@ -301,10 +402,260 @@ func GetRootCmd(args []string) *cobra.Command {
 	rootCmd.PersistentFlags().StringSliceVar(&params.NLBEnabledIdentityList, "nlb_enabled_identity_list", []string{}, "Comma seperated list of enabled idenity list to be enabled for NLB")
 	rootCmd.PersistentFlags().StringSliceVar(&params.CLBEnabledClusters, "clb_enabled_clusters", []string{}, "Comma seperated list of enabled clusters to be enabled for CLB")
 	rootCmd.PersistentFlags().StringVar(&params.NLBIngressLabel, "nlb_ingress_label", common.NLBIstioIngressGatewayLabelValue, "The value of the `app` label to use to match and find the service that represents the NLB ingress for cross cluster traffic")
+	rootCmd.PersistentFlags().DurationVar(&params.LBMigrationSoakDuration, "lb_migration_soak_duration", 0, "Duration the endpoints of both the old and the new load balancer are kept in the service entries when a cluster switches between NLB and CLB. The old endpoint is removed right away when 0")
+
+	//Parameters for cross trust domain federation
+	rootCmd.PersistentFlags().StringToStringVar(&params.ClusterTrustDomains, "cluster_trust_domains", map[string]string{}, "Istio trust domain of each cluster, as cluster=trustDomain. Clusters without an entry use san_prefix")
+	rootCmd.PersistentFlags().StringToStringVar(&params.TrustDomainMappings, "trust_domain_mappings", map[string]string{}, "Trust domain the workloads of a server cluster are verified with in a client cluster, as clientCluster:serverCluster=trustDomain. Takes precedence over cluster_trust_domains")
+	rootCmd.PersistentFlags().StringSliceVar(&params.SpiffeIdentityClusters, "spiffe_identity_clusters", []string{}, "Clusters where the identity of the workloads without the workload identifier label is resolved from their SPIFFE ID, the spiffe.io/spiffe-id annotation of the pod template or else its service account. Use * for all clusters")
+
+	//Parameters for ingress health checks
+	rootCmd.PersistentFlags().DurationVar(&params.IngressHealthCheckInterval, "ingress_health_check_interval", 0, "Interval at which the ingress load balancer of each cluster is probed. Health checks are disabled when 0")
+	rootCmd.PersistentFlags().DurationVar(&params.IngressHealthCheckTimeout, "ingress_health_check_timeout", 5*time.Second, "Timeout of a single probe of the ingress load balancer")
+	rootCmd.PersistentFlags().IntVar(&params.IngressHealthCheckFailureThreshold, "ingress_health_check_failure_threshold", 3, "Number of consecutive failed (or successful) probes after which a cluster fails over to (or back from) its secondary ingress endpoint")
+	rootCmd.PersistentFlags().BoolVar(&params.IngressHealthCheckTLS, "ingress_health_check_tls", false, "Complete a TLS handshake with the ingress load balancer instead of only opening a TCP connection")
+	rootCmd.PersistentFlags().StringToStringVar(&params.IngressSecondaryEndpoints, "ingress_secondary_endpoints", map[string]string{}, "Secondary ingress endpoint of each cluster, as cluster=host[:port], used by the service entries of the dependent clusters while the primary ingress load balancer is unhealthy")
+
+	//Parameters for federation with other meshes
+	rootCmd.PersistentFlags().StringVar(&params.MeshFederationName, "mesh_federation_name", "admiral", "Name of the mesh in the services exported to the other meshes")
+	rootCmd.PersistentFlags().StringToStringVar(&params.MeshFederationImports, "mesh_federation_imports", map[string]string{}, "Federation endpoint of each mesh the services are imported from, as mesh=url. The endpoint returns the services exported by /federation/export or a list of exported ServiceEntries")
+	rootCmd.PersistentFlags().DurationVar(&params.MeshFederationImportInterval, "mesh_federation_import_interval", 5*time.Minute, "Interval at which the services of the other meshes are imported")
+
+	//Parameters for discovery sources
+	rootCmd.PersistentFlags().StringVar(&params.DiscoverySourceType, "discovery_source_type", "", "Type of the source the services outside of the mesh clusters (e.g. VM workloads) are discovered from. Supported: consul, eureka. Disabled when empty")
+	rootCmd.PersistentFlags().StringVar(&params.DiscoverySourceAddress, "discovery_source_address", "", "Address of the discovery source, e.g. the http address of the Consul agent or the base url of the Eureka REST api")
+	rootCmd.PersistentFlags().DurationVar(&params.DiscoverySourceSyncInterval, "discovery_source_sync_interval", time.Minute, "Interval at which the services of the discovery source are synced")
+
+	//Parameters for dns records of the generated cnames
+	rootCmd.PersistentFlags().StringVar(&params.DNSProvider, "dns_provider", "", "Provider the dns records of the generated cnames are written to, so that the clients outside of the mesh resolve them to the ingress of the clusters serving them. Supported: route53, external-dns. Disabled when empty")
+	rootCmd.PersistentFlags().StringVar(&params.DNSRoute53HostedZoneID, "dns_route53_hosted_zone_id", "", "Route53 hosted zone the dns records are written to")
+	rootCmd.PersistentFlags().StringVar(&params.DNSRoute53Role, "dns_route53_role", "", "Arn of the role assumed to write to the Route53 hosted zone, the default aws credentials are used when empty")
+	rootCmd.PersistentFlags().StringVar(&params.DNSRoute53Region, "dns_route53_region", "us-east-1", "Aws region of the Route53 client")
+	rootCmd.PersistentFlags().StringVar(&params.DNSEndpointNamespace, "dns_endpoint_namespace", "admiral", "Namespace of the admiral cluster the DNSEndpoint resources of external-dns are written to")
+	rootCmd.PersistentFlags().Int64Var(&params.DNSRecordTTL, "dns_record_ttl", 60, "Ttl in seconds of the dns records")
+	rootCmd.PersistentFlags().DurationVar(&params.DNSRecordSyncInterval, "dns_record_sync_interval", time.Minute, "Interval at which the dns records of the generated cnames are synced")
+
+	//Parameters for wildcard and selector based dependency destinations
+	rootCmd.PersistentFlags().IntVar(&params.MaxDestinationExpansion, "max_destination_expansion", 100, "Maximum number of identities a wildcard or selector destination of a dependency is expanded to, a destination matching more identities is not expanded")
+	rootCmd.PersistentFlags().DurationVar(&params.DestinationResolutionInterval, "destination_resolution_interval", time.Minute, "Interval at which the wildcard and selector destinations of the dependencies are resolved against the identities known to admiral")
+
+	//Parameters for dependency discovery from the mesh telemetry
+	rootCmd.PersistentFlags().StringVar(&params.DependencyDiscoverySourceType, "dependency_discovery_source_type", "", "Telemetry source the calls between the workloads are read from to discover the dependencies missing from the dependency records. Supported: prometheus. Disabled when empty")
+	rootCmd.PersistentFlags().StringVar(&params.DependencyDiscoveryAddress, "dependency_discovery_address", "", "Address of the telemetry source, e.g. the http address of the Prometheus server scraping the Istio standard metrics")
+	rootCmd.PersistentFlags().StringVar(&params.DependencyDiscoveryMode, "dependency_discovery_mode", common.DependencyDiscoveryModeReport, "Whether the discovered dependencies are only reported, or also written as dependency records pending approval. Supported: report, pending")
+	rootCmd.PersistentFlags().DurationVar(&params.DependencyDiscoveryWindow, "dependency_discovery_window", time.Hour, "Window of the telemetry the calls between the workloads are read from")
+	rootCmd.PersistentFlags().DurationVar(&params.DependencyDiscoveryInterval, "dependency_discovery_interval", 10*time.Minute, "Interval at which the dependencies are discovered from the telemetry")
+
+	//Parameters for coalescing of the workload events of an identity
+	rootCmd.PersistentFlags().DurationVar(&params.EventCoalescingWindow, "event_coalescing_window", 0, "Window during which the deployment and rollout events of an identity and environment following a processed event are collapsed into a single processing pass at the end of the window. Disabled when 0")
+	rootCmd.PersistentFlags().DurationVar(&params.IdentityBatchWindow, "identity_batch_window", 0, "Window the first deployment or rollout event of an identity and environment is deferred by, so the events of an identity changing in several clusters at once are processed in a single pass. Disabled when 0")
 
 	//Parameters for slow start
 	rootCmd.PersistentFlags().BoolVar(&params.EnableTrafficConfigProcessingForSlowStart, "enable_traffic_config_processing_for_slow_start", false, "Enable/Disable TrafficConfig Processing for slowStart support")
 
+	//Parameters for throttling from the TrafficConfig quotas
+	rootCmd.PersistentFlags().BoolVar(&params.EnableTrafficConfigThrottling, "enable_traffic_config_throttling", false, "Enable/Disable rendering the quota groups of the TrafficConfigs into local rate limit EnvoyFilters of the workloads of their identity")
+	rootCmd.PersistentFlags().StringVar(&params.ThrottlingClientIdentityHeader, "throttling_client_identity_header", "", "Request header carrying the identity of the client the app quota groups of the TrafficConfigs are matched against. The app quota groups are skipped when empty")
+
+	//Parameters for cluster tier enforcement
+	rootCmd.PersistentFlags().BoolVar(&params.EnableClusterTierEnforcement, "enable_cluster_tier_enforcement", false, "Enable/Disable blocking resources from being synced to clusters of a different tier. Only new writes are blocked, the resources already synced to a cluster of a different tier are kept and have to be removed manually")
+	// Usage: --cluster_tiers cluster1=prod,cluster2=nonprod OR --cluster_tiers *=nonprod,cluster1=prod
+	rootCmd.PersistentFlags().StringToStringVar(&params.ClusterTiers, "cluster_tiers", map[string]string{}, "The tier each cluster belongs to")
+	// Usage: --environment_tiers prd=prod,e2e=nonprod,qal=nonprod
+	rootCmd.PersistentFlags().StringToStringVar(&params.EnvironmentTiers, "environment_tiers", map[string]string{}, "The tier each workload environment belongs to")
+
+	//Parameters for progressive rollout per cluster cohort
+	// Usage: --cluster_cohorts cluster1=canary,cluster2=canary OR --cluster_cohorts *=canary,cluster1=stable
+	rootCmd.PersistentFlags().StringToStringVar(&params.ClusterCohorts, "cluster_cohorts", map[string]string{}, "The cohort each cluster belongs to, clusters without a cohort belong to the stable cohort")
+	// Usage: --cohort_features exportTo=canary,vsRouting=canary|stable
+	rootCmd.PersistentFlags().StringToStringVar(&params.CohortFeatures, "cohort_features", map[string]string{}, "The cohorts, separated by |, each feature is enabled for. Features without an entry are enabled for every cohort")
+
+	//Parameters for namespace filtering, these can be overridden at runtime using dynamic config
+	// A filter change only applies to the events processed after it, the resources generated before are not removed
+	// Usage: --namespace_allow_list *=payments-.*|orders,cluster1=.*
+	rootCmd.PersistentFlags().StringToStringVar(&params.NamespaceAllowList, "namespace_allow_list", map[string]string{}, "Regex of namespaces to process per cluster, * applies to all clusters without an entry")
+	// Usage: --namespace_deny_list *=test-.*|.*-perf
+	rootCmd.PersistentFlags().StringToStringVar(&params.NamespaceDenyList, "namespace_deny_list", map[string]string{}, "Regex of namespaces to ignore per cluster, * applies to all clusters without an entry")
+	// Usage: --namespace_deny_selectors *=admiral.io/noisy=true
+	rootCmd.PersistentFlags().StringToStringVar(&params.NamespaceDenySelectors, "namespace_deny_selectors", map[string]string{}, "Label selector of namespaces to ignore per cluster, * applies to all clusters without an entry")
+
+	//Parameters for per cluster sync namespaces
+	// Usage: --cluster_sync_namespaces cluster1=admiral-sync,cluster2=mesh-sync
+	rootCmd.PersistentFlags().StringToStringVar(&params.ClusterSyncNamespaces, "cluster_sync_namespaces", map[string]string{}, "Sync namespace per cluster, clusters without an entry use the sync_namespace")
+
+	//Parameters for per identity sync namespaces
+	rootCmd.PersistentFlags().BoolVar(&params.EnableIdentitySyncNamespace, "enable_identity_sync_namespace", false, "Enable/Disable writing the generated resources of an identity to the namespace set in its admiral.io/sync-namespace annotation")
+	rootCmd.PersistentFlags().StringSliceVar(&params.AllowedIdentitySyncNamespaces, "allowed_identity_sync_namespaces", []string{},
+		"The namespaces identities are allowed to request through the admiral.io/sync-namespace annotation, the identities requesting another namespace use the sync namespace")
+
+	//Parameters for per identity TLS settings
+	rootCmd.PersistentFlags().BoolVar(&params.EnableIdentityClientTLSSettings, "enable_identity_client_tls_settings", false, "Enable/Disable overriding the TLS mode, SNI and credential name of the DestinationRules of an identity through its admiral.io/tls-* annotations")
+
+	//Parameters for per identity warmup durations
+	rootCmd.PersistentFlags().BoolVar(&params.EnableIdentityWarmupDuration, "enable_identity_warmup_duration", false, "Enable/Disable overriding the warmup duration of the DestinationRules of an identity per env through the admiral.io/warmup-duration annotation of its workloads")
+
+	//Parameters for locality failover priority lists
+	rootCmd.PersistentFlags().BoolVar(&params.EnableFailoverPriority, "enable_failover_priority", false, "Enable/Disable generating the locality failover of the DestinationRules of an identity from the ordered regions of the admiral.io/failover-priority annotation of its GTP or of its workloads, e.g. us-west-2,us-east-2,eu-west-1")
+
+	//Parameters for admission webhooks
+	rootCmd.PersistentFlags().BoolVar(&params.EnableAdmissionWebhook, "enable_admission_webhook", false, "Enable/Disable the validating admission webhook server")
+	rootCmd.PersistentFlags().IntVar(&params.AdmissionWebhookPort, "admission_webhook_port", 8443, "Port the validating admission webhook server listens on")
+	rootCmd.PersistentFlags().StringVar(&params.AdmissionWebhookCertFile, "admission_webhook_cert_file", "/etc/admiral/webhook/tls.crt", "Path to the TLS certificate of the admission webhook server")
+	rootCmd.PersistentFlags().StringVar(&params.AdmissionWebhookKeyFile, "admission_webhook_key_file", "/etc/admiral/webhook/tls.key", "Path to the TLS key of the admission webhook server")
+
+	//Parameters for resource policies
+	rootCmd.PersistentFlags().StringVar(&params.ResourcePolicyEvaluator, "resource_policy_evaluator", "", "Name of the policy evaluator generated ServiceEntries, VirtualServices and DestinationRules are passed to before they are applied, supported: wildcard-exportto")
+	rootCmd.PersistentFlags().StringSliceVar(&params.WildcardExportToDeniedClusters, "wildcard_exportto_denied_clusters", []string{}, "Clusters in which the wildcard-exportto policy rejects generated resources exported to *")
+	rootCmd.PersistentFlags().BoolVar(&params.EnableGeneratedResourceValidation, "enable_generated_resource_validation", false, "Enable/Disable the validation of the generated ServiceEntries, VirtualServices and DestinationRules before they are applied, invalid resources are rejected instead of being written to the clusters")
+
+	//Parameters for the audit log
+	rootCmd.PersistentFlags().StringVar(&params.AuditSink, "audit_sink", "", "Sink the audit records of the resources admiral creates, updates and deletes are written to, either file:///path or an http(s):// endpoint. Auditing is disabled when empty")
+
+	//Parameters for kubernetes events
+	rootCmd.PersistentFlags().BoolVar(&params.EnableK8sEvents, "enable_k8s_events", false, "Enable/Disable emitting kubernetes events against the source resources for sync failures, dead cluster skips and exportTo truncation")
+
+	//Parameters for readiness
+	rootCmd.PersistentFlags().BoolVar(&params.ReadinessRequiresCacheSync, "readiness_requires_cache_sync", false, "Fail the readiness check until the informer caches of all the registered clusters have synced")
+
+	//Parameters for the startup barrier
+	rootCmd.PersistentFlags().DurationVar(&params.StartupBarrierTimeout, "startup_barrier_timeout", 0, "Maximum time the writes to the clusters are held at startup until the identity and dependency caches are built. The barrier is disabled when 0")
+
+	//Parameters for spec interning
+	rootCmd.PersistentFlags().BoolVar(&params.EnableSpecInterning, "enable_spec_interning", false, "Enable/Disable sharing a single copy of the identical ServiceEntry, VirtualService and DestinationRule specs of the clusters in the informer caches, to reduce the memory used when watching many clusters")
+
+	//Parameters for merge patch updates
+	rootCmd.PersistentFlags().BoolVar(&params.EnableMergePatchUpdates, "enable_merge_patch_updates", false, "Enable/Disable updating the existing ServiceEntries, VirtualServices and DestinationRules with a JSON merge patch of the changes instead of a full update")
+
+	//Parameters for the retries of the writes to the clusters
+	rootCmd.PersistentFlags().IntVar(&params.WriteRetryAttempts, "write_retry_attempts", 5, "Number of total write attempts to a cluster, including the first one, for writes failing with a conflict or a transient error before the event is added back to the controller queue, 1 disables the retries")
+	rootCmd.PersistentFlags().DurationVar(&params.WriteRetryInitialBackoff, "write_retry_initial_backoff", 100*time.Millisecond, "Delay before the first retry of a write to a cluster, doubled on every following retry")
+	rootCmd.PersistentFlags().DurationVar(&params.WriteRetryMaxBackoff, "write_retry_max_backoff", 2*time.Second, "Maximum delay between the retries of a write to a cluster")
+
+	//Parameters for the lazy istio controllers
+	rootCmd.PersistentFlags().BoolVar(&params.EnableLazyIstioControllers, "enable_lazy_istio_controllers", false, "Enable/Disable starting the ServiceEntry, DestinationRule, VirtualService and Sidecar informers of a cluster only once the first workload with an identity appears in it")
+	rootCmd.PersistentFlags().DurationVar(&params.IstioControllersIdleTimeout, "istio_controllers_idle_timeout", time.Hour, "Time after which the istio informers of a cluster with no workloads with an identity are stopped, when the lazy istio controllers are enabled. The informers are never stopped when 0")
+
+	//Parameters for the dead cluster prober
+	rootCmd.PersistentFlags().DurationVar(&params.DeadClusterProbeInterval, "dead_cluster_probe_interval", 30*time.Second, "Interval at which the unreachable clusters the writes to were skipped for are probed, the skipped writes are replayed once a cluster is reachable again. The clusters are not probed when 0")
+
+	//Parameters for the ownership of the written resources
+	rootCmd.PersistentFlags().BoolVar(&params.EnableOwnershipLabels, "enable_ownership_labels", false, "Enable/Disable labeling the resources admiral writes with the admiral instance, source cluster and source identity, VirtualServices owned by something else are only overwritten when annotated with admiral.io/adopt=true")
+	rootCmd.PersistentFlags().StringVar(&params.AdmiralInstanceName, "admiral_instance_name", "admiral", "Name of the admiral instance the resources it writes are labeled with when the ownership labels are enabled")
+
+	//Parameters for the finalizers of the source resources
+	rootCmd.PersistentFlags().BoolVar(&params.EnableSourceFinalizers, "enable_source_finalizers", false, "Enable/Disable adding the admiral.io/cleanup finalizer to the source VirtualServices and GlobalTrafficPolicies, so that their deletion waits until admiral has removed their copies from the dependent clusters")
+
+	//Parameters for the deferred deletion of the ServiceEntries
+	rootCmd.PersistentFlags().DurationVar(&params.ServiceEntryDeletionGracePeriod, "se_deletion_grace_period", 0, "Time the ServiceEntry of a workload which is gone is kept for, annotated with admiral.io/delete-after, before it is deleted. It is deleted only if the workload did not come back in the meantime. The ServiceEntry is deleted right away when 0")
+
+	//Parameters for the pruning of the orphaned resources
+	rootCmd.PersistentFlags().DurationVar(&params.OrphanPruningInterval, "orphan_pruning_interval", 0, "Interval at which the resources admiral generated in the sync namespaces which no longer have a source, as their identity is gone or no longer needed in the cluster, are pruned. The resources are not pruned when 0")
+	rootCmd.PersistentFlags().BoolVar(&params.OrphanPruningDryRun, "orphan_pruning_dry_run", true, "Only log the orphaned resources the pruning would delete")
+
+	//Parameters for the output to a GitOps repository
+	rootCmd.PersistentFlags().StringVar(&params.GitOpsRepoPath, "gitops_repo_path", "", "Path of the local clone of a git repository the generated resources are rendered to, one directory per cluster, instead of being applied to the clusters. The resources are applied when empty")
+	rootCmd.PersistentFlags().DurationVar(&params.GitOpsCommitInterval, "gitops_commit_interval", time.Minute, "Interval at which the resources rendered to the GitOps repository are committed")
+	rootCmd.PersistentFlags().StringVar(&params.GitOpsPushBranch, "gitops_push_branch", "", "Branch of the origin of the GitOps repository the commits are pushed to. The commits are not pushed when empty")
+
+	//Parameters for the coexistence with GitOps controllers
+	rootCmd.PersistentFlags().StringToStringVar(&params.GeneratedResourceAnnotations, "generated_resource_annotations", map[string]string{}, "Annotations stamped on every generated resource, as key=value, to keep a GitOps controller managing the sync namespaces from reverting or pruning them, e.g. argocd.argoproj.io/compare-options=IgnoreExtraneous, argocd.argoproj.io/sync-options=Prune=false or kustomize.toolkit.fluxcd.io/reconcile=disabled")
+	rootCmd.PersistentFlags().IntVar(&params.ExternalRevertThreshold, "external_revert_threshold", 3, "Number of times a generated resource can be changed by something other than admiral before a notification is sent. The changes are not tracked when 0")
+
+	//Parameters for the diff-only mode
+	rootCmd.PersistentFlags().BoolVar(&params.DiffOnlyMode, "diff_only_mode", false, "Record the creates, updates and deletes of the generated resources admiral would apply, served by /diffs and sent to the audit sink, without applying them. Meant for a shadow instance, holding its own lease, run against production to validate an upgrade")
+
+	//Parameters for the configuration history of the identities
+	rootCmd.PersistentFlags().IntVar(&params.ConfigHistorySize, "config_history_size", 0, "Number of snapshots of the configuration generated for each identity kept in memory, which the identity can be rolled back to through /identity/{identity}/rollback. No history is kept when 0")
+	rootCmd.PersistentFlags().BoolVar(&params.PersistConfigHistory, "persist_config_history", false, "Persist the configuration history of the identities to the registry")
+
+	//Parameters for the identity locks and their watchdog
+	rootCmd.PersistentFlags().BoolVar(&params.EnableIdentityLock, "enable_identity_lock", false, "Serialize the processing of each identity, the processings of the same identity received from different clusters wait for each other instead of running concurrently")
+	rootCmd.PersistentFlags().DurationVar(&params.IdentityLockHoldThreshold, "identity_lock_hold_threshold", 5*time.Minute, "Time the lock serializing the processing of an identity can be held before it is reported as stuck, the holders are served by /identitylocks. The watchdog is disabled when 0 or when the identity lock is disabled")
+
+	//Parameters for the full refreshes of the resources of a cluster
+	rootCmd.PersistentFlags().DurationVar(&params.FullRefreshInterval, "full_refresh_interval", 0, "Interval at which the resources of every identity are refreshed, so that the resources which drifted in the clusters are brought back in line. The periodic full refresh is disabled when 0")
+	rootCmd.PersistentFlags().IntVar(&params.FullRefreshConcurrency, "full_refresh_concurrency", 4, "Number of identities processed in parallel when the resources of a cluster are refreshed, the identities which depend on each other or share a host are always processed one after the other")
+
+	//Parameters for the eviction of the stale cache entries
+	rootCmd.PersistentFlags().DurationVar(&params.CacheEntryTTL, "cache_entry_ttl", 0, "Time the cache entries of an identity, and of its cnames, are kept once the identity is gone from all the clusters. The entries of the identities last seen in a cluster which is unreachable or no longer monitored are kept. The entries are never evicted when 0")
+
+	//Parameters for the bound of the resource caches
+	rootCmd.PersistentFlags().IntVar(&params.ResourceCacheMaxSize, "resource_cache_max_size", 0, "Maximum number of resources the ServiceEntry, DestinationRule and VirtualService caches of each cluster hold, the least recently used resources are evicted beyond. The caches are not bounded when 0")
+
+	//Parameters for the persistence of the dependent clusters
+	rootCmd.PersistentFlags().DurationVar(&params.DependentClusterPersistInterval, "dependent_cluster_persist_interval", 0, "Interval at which the dependent clusters of the cnames which changed are written to the registry in state syncer mode, so other admiral instances can bootstrap the dependencies from it. They are not written when 0")
+
+	//Parameters for the consistency checks of the cache
+	rootCmd.PersistentFlags().DurationVar(&params.CacheConsistencyCheckInterval, "cache_consistency_check_interval", 0, "Interval at which the clusters of the identities and of the cnames held by the cache are cross checked against the workloads and the services of the clusters, the report is also served by /cacheconsistency. The cache is not checked when 0")
+	rootCmd.PersistentFlags().BoolVar(&params.CacheConsistencyRepair, "cache_consistency_repair", false, "Drop the clusters the workloads of the identity no longer run in from the cache on the periodic consistency checks, rather than only reporting them")
+
+	//Parameters for the read API of the cache state scoped by tenant
+	rootCmd.PersistentFlags().StringVar(&params.TenantTokenFile, "tenant_token_file", "", "Path to the file with the tenants allowed to read the cache state of their identities on /tenant, one per line as the name, the bearer token and the comma separated glob patterns of the identities of the tenant. A tenant is also served the identities of the team named after it. The /tenant routes are not served when empty")
+
+	//Parameters for the tenancy of the identities to teams
+	rootCmd.PersistentFlags().StringVar(&params.TeamLabel, "team_label", "", "The label, or annotation, of the workloads naming the team their identity belongs to. It takes precedence over the identity prefixes")
+	rootCmd.PersistentFlags().StringToStringVar(&params.TeamIdentityPrefixes, "team_identity_prefixes", map[string]string{}, "The team the identities starting with each prefix belong to, the longest prefix wins. Identities without a team belong to the unassigned team")
+
+	//Parameters for the quotas on the resources generated per identity
+	rootCmd.PersistentFlags().IntVar(&params.QuotaMaxHostsPerIdentity, "quota_max_hosts_per_identity", 0, "Max hosts generated for an identity across its environments, the hosts beyond it are not generated and reported as a quota violation. The hosts are not limited when 0")
+	rootCmd.PersistentFlags().IntVar(&params.QuotaMaxCustomVirtualServices, "quota_max_custom_virtualservices", 0, "Max custom VirtualServices merged for an identity and environment, the ones of the lowest priority beyond it are not merged and reported as a quota violation. The custom VirtualServices are not limited when 0")
+
+	//Parameters for the per tenant rate limits of the event processing
+	rootCmd.PersistentFlags().Float64Var(&params.TenantEventRateLimit, "tenant_event_rate_limit", 0, "Events per second each team of the team_identity_prefixes can have processed by the queue of a controller, the events of the other teams wait for their turn. The events of the teams are served in turns regardless, the events are not rate limited when 0")
+	rootCmd.PersistentFlags().IntVar(&params.TenantEventBurst, "tenant_event_burst", 10, "Events each team can have processed at once beyond the tenant_event_rate_limit")
+
+	//Parameters for the authentication and authorization of the callers of the APIs
+	rootCmd.PersistentFlags().StringSliceVar(&params.AuthMethods, "auth_methods", []string{}, "Methods the callers of the admiral and introspection APIs are authenticated with, supported: client-cert, token-review. Requires api_cert_file. The API callers are not authenticated when empty, the introspection callers then need one of the introspection tokens")
+	rootCmd.PersistentFlags().StringToStringVar(&params.AuthRoleBindings, "auth_role_bindings", map[string]string{}, "The role, viewer or operator, bound to each user:<name> or group:<name>. Viewers can call the endpoints which read the state of admiral, operators can also call the ones which change it such as pausing writes")
+	rootCmd.PersistentFlags().StringVar(&params.APICertFile, "api_cert_file", "", "Path to the TLS certificate of the admiral API server, the API is served without TLS when empty")
+	rootCmd.PersistentFlags().StringVar(&params.APIKeyFile, "api_key_file", "", "Path to the TLS key of the admiral API server")
+	rootCmd.PersistentFlags().StringVar(&params.APIClientCAFile, "api_client_ca_file", "", "Path to the CAs the client certificates presented to the admiral and introspection API servers are verified against, required by the client-cert auth method")
+
+	//Parameters for the weighted distribution of the traffic between the regions of the GTPs
+	rootCmd.PersistentFlags().BoolVar(&params.EnableGTPWeightedEndpoints, "enable_gtp_weighted_endpoints", false, "Enable/Disable weighting the endpoints of the ServiceEntries by the weights of the regions of the GTP targets, so every client cluster distributes the traffic the same way whatever its locality")
+
+	//Parameters for the gradual shifts of the traffic of the GTPs
+	rootCmd.PersistentFlags().DurationVar(&params.TrafficShiftCheckInterval, "traffic_shift_check_interval", 0, "Interval at which the GTPs annotated with a traffic shift target are checked, and their weights stepped towards it. The traffic is not shifted when 0")
+	rootCmd.PersistentFlags().DurationVar(&params.TrafficShiftStepInterval, "traffic_shift_step_interval", 10*time.Minute, "Default interval between the steps of a traffic shift, overridden by the traffic shift interval annotation of the GTP")
+
+	//Parameters for the activation windows of the GTPs
+	rootCmd.PersistentFlags().DurationVar(&params.GtpWindowCheckInterval, "gtp_window_check_interval", time.Minute, "Interval at which the GTPs with an activation window are checked, the GTPs whose window opened or closed are applied again and their status updated. The windows are then only checked on the next event of the GTPs when 0")
+
+	//Parameters for the dry-run of the GTPs on admission
+	rootCmd.PersistentFlags().BoolVar(&params.EnableGTPAdmissionDryRun, "enable_gtp_admission_dry_run", false, "Enable/Disable simulating the GTPs on admission, the DestinationRules, ServiceEntries and VirtualServices the GTP changes in each cluster are returned as warnings of the admission response")
+
+	//Parameters for the default traffic policies of the org
+	rootCmd.PersistentFlags().StringVar(&params.OrgTrafficPolicyNamespace, "org_traffic_policy_namespace", "", "Namespace of the GTPs annotated with the org traffic policy scope, which every identity without a GTP of its own inherits. Only the default GTPs of the namespaces are inherited when empty")
+
+	rootCmd.PersistentFlags().StringVar(&params.NotifierType, "notifier_type", "", "Notifier high severity conditions are sent to, supported: slack, webhook. Notifications are disabled when empty")
+	rootCmd.PersistentFlags().StringVar(&params.NotifierEndpoint, "notifier_endpoint", "", "Slack incoming webhook or generic webhook endpoint notifications are posted to")
+	rootCmd.PersistentFlags().DurationVar(&params.NotificationInterval, "notification_interval", 30*time.Minute, "Minimum interval between notifications for the same condition")
+	rootCmd.PersistentFlags().DurationVar(&params.ClusterUnreachableAlertDuration, "cluster_unreachable_alert_duration", 10*time.Minute, "Duration a cluster has to be unreachable before a notification is sent")
+	rootCmd.PersistentFlags().IntVar(&params.GarbageCollectionAlertThreshold, "garbage_collection_alert_threshold", 50, "Number of resources deleted from a cluster in one clean up above which a notification is sent")
+
+	//Parameters for the introspection API
+	rootCmd.PersistentFlags().BoolVar(&params.EnableIntrospectionServer, "enable_introspection_server", false, "Enable/Disable the gRPC introspection API server")
+	rootCmd.PersistentFlags().IntVar(&params.IntrospectionPort, "introspection_port", 9090, "Port the gRPC introspection API server listens on")
+	rootCmd.PersistentFlags().StringVar(&params.IntrospectionTokenFile, "introspection_token_file", "/etc/admiral/introspection/tokens", "Path to the file with the bearer tokens, one per line, allowed to call the introspection API. A token followed by comma separated teams is only served the identities of those teams")
+	rootCmd.PersistentFlags().StringVar(&params.IntrospectionCertFile, "introspection_cert_file", "", "Path to the TLS certificate of the introspection API server, the API is served without TLS when empty")
+	rootCmd.PersistentFlags().StringVar(&params.IntrospectionKeyFile, "introspection_key_file", "", "Path to the TLS key of the introspection API server")
+	rootCmd.PersistentFlags().IntVar(&params.IdentityChangelogSize, "identity_changelog_size", 100, "Number of the latest changes admiral made for each identity kept for the introspection API, no changelog is kept when 0")
+
+	//Parameters for maintenance mode
+	rootCmd.PersistentFlags().IntVar(&params.MaintenanceJournalSize, "maintenance_journal_size", 10000, "Max number of events buffered while admiral is in maintenance mode, the oldest events are dropped beyond it")
+
+	//Parameters for workload stabilization
+	rootCmd.PersistentFlags().DurationVar(&params.WorkloadStabilizationWindow, "workload_stabilization_window", 0, "Duration the service entry updates of a deployment or rollout being rolled out are deferred while it is not fully available, disabled when zero")
+
+	//Parameters for multi port service entries
+	rootCmd.PersistentFlags().BoolVar(&params.EnableMultiPortServiceEntry, "enable_multi_port_service_entry", false, "Enable/Disable generating service entries with all the mesh ports of a service, the ports other than the first one are served on their service port number")
+	rootCmd.PersistentFlags().BoolVar(&params.EnableMultipleIngressEndpoints, "enable_multiple_ingress_endpoints", false, "Enable/Disable generating an endpoint for each load balancer of a cluster ingress which exposes more than one, weighted with the admiral.io/ingress-weight annotation of their service")
+
+	//Parameters for east-west gateway discovery
+	rootCmd.PersistentFlags().StringToStringVar(&params.EastWestGatewaySelector, "east_west_gateway_selector", map[string]string{}, "Labels of the east-west gateway service whose load balancer is used as the endpoint of the service entries, e.g. istio=eastwestgateway. Disabled when empty")
+	rootCmd.PersistentFlags().StringVar(&params.EastWestGatewayNamespace, "east_west_gateway_namespace", common.NamespaceIstioSystem, "Namespace the east-west gateway service is discovered in")
+	rootCmd.PersistentFlags().DurationVar(&params.EastWestGatewayCacheTTL, "east_west_gateway_cache_ttl", 5*time.Minute, "Duration a discovered east-west gateway is used before it is discovered again")
+
 	return rootCmd
 }
 
@@ -0,0 +1,39 @@
+package filters
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
+)
+
+// Authenticate returns a filter which rejects the requests whose caller cannot be authenticated, or is not
+// bound to the role, and passes the principal of the caller to the inner handler through the context of the
+// request. Every request is let through when the auth is nil
+func Authenticate(a *auth.Auth, role string) func(inner http.Handler, name string) http.Handler {
+	return func(inner http.Handler, name string) http.Handler {
+		if a == nil {
+			return inner
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credentials := auth.Credentials{}
+			if value := r.Header.Get(authorizationHeader); strings.HasPrefix(value, bearerPrefix) {
+				credentials.BearerToken = strings.TrimPrefix(value, bearerPrefix)
+			}
+			if r.TLS != nil {
+				credentials.VerifiedChains = r.TLS.VerifiedChains
+			}
+			principal, err := a.Authenticate(r.Context(), credentials)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if !principal.HasRole(role) {
+				http.Error(w, "user "+principal.User+" is not bound to the "+role+" role", http.StatusForbidden)
+				return
+			}
+			inner.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
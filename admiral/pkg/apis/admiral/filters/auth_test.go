@@ -0,0 +1,63 @@
+package filters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenAuthenticator authenticates the bearer tokens as the users they are mapped to
+type tokenAuthenticator map[string]string
+
+func (a tokenAuthenticator) Authenticate(ctx context.Context, credentials auth.Credentials) (*auth.Principal, error) {
+	if user, ok := a[credentials.BearerToken]; ok {
+		return &auth.Principal{User: user}, nil
+	}
+	return nil, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := auth.NewWithAuthenticators([]auth.Authenticator{tokenAuthenticator{"viewer-token": "alice", "operator-token": "bob", "unbound-token": "eve"}},
+		map[string]string{"user:alice": auth.RoleViewer, "user:bob": auth.RoleOperator})
+	var principal *auth.Principal
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = auth.PrincipalFromContext(r.Context())
+	})
+
+	testCases := []struct {
+		name           string
+		auth           *auth.Auth
+		role           string
+		token          string
+		expectedStatus int
+		expectedUser   string
+	}{
+		{name: "Given no auth, Then every request is let through", role: auth.RoleOperator, expectedStatus: http.StatusOK},
+		{name: "Given no credentials, Then the request is rejected", auth: a, role: auth.RoleViewer, expectedStatus: http.StatusUnauthorized},
+		{name: "Given a caller without a role, Then the request is forbidden", auth: a, role: auth.RoleViewer, token: "unbound-token", expectedStatus: http.StatusForbidden},
+		{name: "Given a viewer on an operator route, Then the request is forbidden", auth: a, role: auth.RoleOperator, token: "viewer-token", expectedStatus: http.StatusForbidden},
+		{name: "Given a viewer on a viewer route, Then the request is passed as the viewer", auth: a, role: auth.RoleViewer, token: "viewer-token", expectedStatus: http.StatusOK, expectedUser: "alice"},
+		{name: "Given an operator on a viewer route, Then the request is passed as the operator", auth: a, role: auth.RoleViewer, token: "operator-token", expectedStatus: http.StatusOK, expectedUser: "bob"},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			principal = nil
+			r := httptest.NewRequest("GET", "/clusters", nil)
+			if c.token != "" {
+				r.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			w := httptest.NewRecorder()
+			Authenticate(c.auth, c.role)(inner, "test").ServeHTTP(w, r)
+			assert.Equal(t, c.expectedStatus, w.Result().StatusCode)
+			if c.expectedUser == "" {
+				assert.Nil(t, principal)
+			} else {
+				assert.Equal(t, c.expectedUser, principal.User)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package filters
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+type tenantContextKey struct{}
+
+// Tenant is a team allowed to read the cache state of the identities matching its patterns
+type Tenant struct {
+	Name       string
+	Token      string
+	Identities []string
+}
+
+// Allows checks if the identity matches one of the glob patterns of the tenant, ignoring case
+func (t *Tenant) Allows(identity string) bool {
+	if t == nil {
+		return false
+	}
+	identity = strings.ToLower(identity)
+	for _, pattern := range t.Identities {
+		if matched, err := path.Match(strings.ToLower(pattern), identity); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTenants reads the tenants from the file, one per line as the name, the bearer token and the comma separated
+// glob patterns of the identities of the tenant, separated by spaces. Empty lines and lines starting with # are skipped
+func LoadTenants(tenantFile string) ([]Tenant, error) {
+	if tenantFile == "" {
+		return nil, fmt.Errorf("tenant token file is required")
+	}
+	content, err := os.ReadFile(tenantFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant token file %s: %v", tenantFile, err)
+	}
+	var tenants []Tenant
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d of tenant token file %s should have a name, a token and identity patterns", i+1, tenantFile)
+		}
+		tenant := Tenant{Name: fields[0], Token: fields[1]}
+		for _, pattern := range strings.Split(fields[2], ",") {
+			if pattern = strings.TrimSpace(pattern); pattern == "" {
+				continue
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("line %d of tenant token file %s has an invalid identity pattern %s", i+1, tenantFile, pattern)
+			}
+			tenant.Identities = append(tenant.Identities, pattern)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("tenant token file %s has no tenants", tenantFile)
+	}
+	return tenants, nil
+}
+
+// TenantAuth returns a filter which rejects the requests without the bearer token of one of the tenants, and
+// passes the tenant of the token to the inner handler through the context of the request
+func TenantAuth(tenants []Tenant) func(inner http.Handler, name string) http.Handler {
+	return func(inner http.Handler, name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := authenticateTenant(r, tenants)
+			if tenant == nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "invalid or missing bearer token", http.StatusUnauthorized)
+				return
+			}
+			inner.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tenant)))
+		})
+	}
+}
+
+// TenantFromRequest returns the tenant the request was authenticated as, or nil when it was not
+func TenantFromRequest(r *http.Request) *Tenant {
+	tenant, _ := r.Context().Value(tenantContextKey{}).(*Tenant)
+	return tenant
+}
+
+// authenticateTenant returns the tenant whose token is the bearer token of the request
+func authenticateTenant(r *http.Request, tenants []Tenant) *Tenant {
+	value := r.Header.Get(authorizationHeader)
+	if !strings.HasPrefix(value, bearerPrefix) {
+		return nil
+	}
+	presented := []byte(strings.TrimPrefix(value, bearerPrefix))
+	var authenticated *Tenant
+	for i := range tenants {
+		if subtle.ConstantTimeCompare(presented, []byte(tenants[i].Token)) == 1 && authenticated == nil {
+			authenticated = &tenants[i]
+		}
+	}
+	return authenticated
+}
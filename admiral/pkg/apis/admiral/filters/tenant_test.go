@@ -0,0 +1,76 @@
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTenants(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		assert.Nil(t, os.WriteFile(file, []byte(content), 0600))
+		return file
+	}
+
+	tenants, err := LoadTenants(write("valid", "# tenants\nteam token1 team.*,Shared.Foo\n\nother token2 other.*\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []Tenant{
+		{Name: "team", Token: "token1", Identities: []string{"team.*", "Shared.Foo"}},
+		{Name: "other", Token: "token2", Identities: []string{"other.*"}},
+	}, tenants)
+	assert.True(t, tenants[0].Allows("Team.Foo"))
+	assert.True(t, tenants[0].Allows("shared.foo"))
+	assert.False(t, tenants[0].Allows("other.foo"))
+
+	_, err = LoadTenants("")
+	assert.NotNil(t, err)
+	_, err = LoadTenants(write("missing-token", "team team.*\n"))
+	assert.NotNil(t, err)
+	_, err = LoadTenants(write("bad-pattern", "team token1 team.[\n"))
+	assert.NotNil(t, err)
+	_, err = LoadTenants(write("empty", "# no tenants\n"))
+	assert.NotNil(t, err)
+}
+
+func TestTenantAuth(t *testing.T) {
+	tenants := []Tenant{{Name: "team", Token: "token1"}, {Name: "other", Token: "token2"}}
+	var authenticated *Tenant
+	handler := TenantAuth(tenants)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated = TenantFromRequest(r)
+	}), "test")
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedTenant string
+	}{
+		{name: "Given no authorization header, Then the request is rejected", expectedStatus: http.StatusUnauthorized},
+		{name: "Given an unknown token, Then the request is rejected", authorization: "Bearer token3", expectedStatus: http.StatusUnauthorized},
+		{name: "Given a token without the bearer prefix, Then the request is rejected", authorization: "token1", expectedStatus: http.StatusUnauthorized},
+		{name: "Given the token of a tenant, Then the request is passed as the tenant", authorization: "Bearer token2", expectedStatus: http.StatusOK, expectedTenant: "other"},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			authenticated = nil
+			r := httptest.NewRequest("GET", "/tenant/identities", nil)
+			if c.authorization != "" {
+				r.Header.Set("Authorization", c.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, c.expectedStatus, w.Result().StatusCode)
+			if c.expectedTenant == "" {
+				assert.Nil(t, authenticated)
+			} else {
+				assert.Equal(t, c.expectedTenant, authenticated.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package introspection contains the gRPC API admiral serves its state and sync results on.
+package introspection
+
+//go:generate protoc -I . introspection.proto --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:.
@@ -0,0 +1,402 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: introspection.proto
+
+package introspection
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Introspection_GetIdentityState_FullMethodName      = "/admiral.introspection.v1.Introspection/GetIdentityState"
+	Introspection_ListDependentClusters_FullMethodName = "/admiral.introspection.v1.Introspection/ListDependentClusters"
+	Introspection_GetLastSyncResult_FullMethodName     = "/admiral.introspection.v1.Introspection/GetLastSyncResult"
+	Introspection_StreamSyncEvents_FullMethodName      = "/admiral.introspection.v1.Introspection/StreamSyncEvents"
+	Introspection_StreamEvents_FullMethodName          = "/admiral.introspection.v1.Introspection/StreamEvents"
+	Introspection_GetDependencyGraph_FullMethodName    = "/admiral.introspection.v1.Introspection/GetDependencyGraph"
+	Introspection_GetIdentityChangelog_FullMethodName  = "/admiral.introspection.v1.Introspection/GetIdentityChangelog"
+)
+
+// IntrospectionClient is the client API for Introspection service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IntrospectionClient interface {
+	// Returns the clusters, envs, regions and dependents of an identity
+	GetIdentityState(ctx context.Context, in *GetIdentityStateRequest, opts ...grpc.CallOption) (*IdentityState, error)
+	// Returns the clusters the resources of a host are synced to
+	ListDependentClusters(ctx context.Context, in *ListDependentClustersRequest, opts ...grpc.CallOption) (*ListDependentClustersResponse, error)
+	// Returns the result of the last create, update or delete of a resource in a cluster
+	GetLastSyncResult(ctx context.Context, in *GetLastSyncResultRequest, opts ...grpc.CallOption) (*SyncResult, error)
+	// Streams the result of every create, update or delete admiral performs from now on
+	StreamSyncEvents(ctx context.Context, in *StreamSyncEventsRequest, opts ...grpc.CallOption) (Introspection_StreamSyncEventsClient, error)
+	// Streams the sync events matching the filters of the request from now on, along with
+	// the identity and the event they were triggered by, for external systems to react to
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Introspection_StreamEventsClient, error)
+	// Returns the identity dependency graph, or the part of it walked from an identity
+	GetDependencyGraph(ctx context.Context, in *GetDependencyGraphRequest, opts ...grpc.CallOption) (*DependencyGraph, error)
+	// Returns the latest changes admiral made to the resources of an identity, the latest first
+	GetIdentityChangelog(ctx context.Context, in *GetIdentityChangelogRequest, opts ...grpc.CallOption) (*IdentityChangelog, error)
+}
+
+type introspectionClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIntrospectionClient(cc grpc.ClientConnInterface) IntrospectionClient {
+	return &introspectionClient{cc}
+}
+
+func (c *introspectionClient) GetIdentityState(ctx context.Context, in *GetIdentityStateRequest, opts ...grpc.CallOption) (*IdentityState, error) {
+	out := new(IdentityState)
+	err := c.cc.Invoke(ctx, Introspection_GetIdentityState_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *introspectionClient) ListDependentClusters(ctx context.Context, in *ListDependentClustersRequest, opts ...grpc.CallOption) (*ListDependentClustersResponse, error) {
+	out := new(ListDependentClustersResponse)
+	err := c.cc.Invoke(ctx, Introspection_ListDependentClusters_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *introspectionClient) GetLastSyncResult(ctx context.Context, in *GetLastSyncResultRequest, opts ...grpc.CallOption) (*SyncResult, error) {
+	out := new(SyncResult)
+	err := c.cc.Invoke(ctx, Introspection_GetLastSyncResult_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *introspectionClient) StreamSyncEvents(ctx context.Context, in *StreamSyncEventsRequest, opts ...grpc.CallOption) (Introspection_StreamSyncEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Introspection_ServiceDesc.Streams[0], Introspection_StreamSyncEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &introspectionStreamSyncEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Introspection_StreamSyncEventsClient interface {
+	Recv() (*SyncResult, error)
+	grpc.ClientStream
+}
+
+type introspectionStreamSyncEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *introspectionStreamSyncEventsClient) Recv() (*SyncResult, error) {
+	m := new(SyncResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *introspectionClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Introspection_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Introspection_ServiceDesc.Streams[1], Introspection_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &introspectionStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Introspection_StreamEventsClient interface {
+	Recv() (*SyncEvent, error)
+	grpc.ClientStream
+}
+
+type introspectionStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *introspectionStreamEventsClient) Recv() (*SyncEvent, error) {
+	m := new(SyncEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *introspectionClient) GetDependencyGraph(ctx context.Context, in *GetDependencyGraphRequest, opts ...grpc.CallOption) (*DependencyGraph, error) {
+	out := new(DependencyGraph)
+	err := c.cc.Invoke(ctx, Introspection_GetDependencyGraph_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *introspectionClient) GetIdentityChangelog(ctx context.Context, in *GetIdentityChangelogRequest, opts ...grpc.CallOption) (*IdentityChangelog, error) {
+	out := new(IdentityChangelog)
+	err := c.cc.Invoke(ctx, Introspection_GetIdentityChangelog_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IntrospectionServer is the server API for Introspection service.
+// All implementations must embed UnimplementedIntrospectionServer
+// for forward compatibility
+type IntrospectionServer interface {
+	// Returns the clusters, envs, regions and dependents of an identity
+	GetIdentityState(context.Context, *GetIdentityStateRequest) (*IdentityState, error)
+	// Returns the clusters the resources of a host are synced to
+	ListDependentClusters(context.Context, *ListDependentClustersRequest) (*ListDependentClustersResponse, error)
+	// Returns the result of the last create, update or delete of a resource in a cluster
+	GetLastSyncResult(context.Context, *GetLastSyncResultRequest) (*SyncResult, error)
+	// Streams the result of every create, update or delete admiral performs from now on
+	StreamSyncEvents(*StreamSyncEventsRequest, Introspection_StreamSyncEventsServer) error
+	// Streams the sync events matching the filters of the request from now on, along with
+	// the identity and the event they were triggered by, for external systems to react to
+	StreamEvents(*StreamEventsRequest, Introspection_StreamEventsServer) error
+	// Returns the identity dependency graph, or the part of it walked from an identity
+	GetDependencyGraph(context.Context, *GetDependencyGraphRequest) (*DependencyGraph, error)
+	// Returns the latest changes admiral made to the resources of an identity, the latest first
+	GetIdentityChangelog(context.Context, *GetIdentityChangelogRequest) (*IdentityChangelog, error)
+	mustEmbedUnimplementedIntrospectionServer()
+}
+
+// UnimplementedIntrospectionServer must be embedded to have forward compatible implementations.
+type UnimplementedIntrospectionServer struct {
+}
+
+func (UnimplementedIntrospectionServer) GetIdentityState(context.Context, *GetIdentityStateRequest) (*IdentityState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIdentityState not implemented")
+}
+func (UnimplementedIntrospectionServer) ListDependentClusters(context.Context, *ListDependentClustersRequest) (*ListDependentClustersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDependentClusters not implemented")
+}
+func (UnimplementedIntrospectionServer) GetLastSyncResult(context.Context, *GetLastSyncResultRequest) (*SyncResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLastSyncResult not implemented")
+}
+func (UnimplementedIntrospectionServer) StreamSyncEvents(*StreamSyncEventsRequest, Introspection_StreamSyncEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSyncEvents not implemented")
+}
+func (UnimplementedIntrospectionServer) StreamEvents(*StreamEventsRequest, Introspection_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedIntrospectionServer) GetDependencyGraph(context.Context, *GetDependencyGraphRequest) (*DependencyGraph, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDependencyGraph not implemented")
+}
+func (UnimplementedIntrospectionServer) GetIdentityChangelog(context.Context, *GetIdentityChangelogRequest) (*IdentityChangelog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIdentityChangelog not implemented")
+}
+func (UnimplementedIntrospectionServer) mustEmbedUnimplementedIntrospectionServer() {}
+
+// UnsafeIntrospectionServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IntrospectionServer will
+// result in compilation errors.
+type UnsafeIntrospectionServer interface {
+	mustEmbedUnimplementedIntrospectionServer()
+}
+
+func RegisterIntrospectionServer(s grpc.ServiceRegistrar, srv IntrospectionServer) {
+	s.RegisterService(&Introspection_ServiceDesc, srv)
+}
+
+func _Introspection_GetIdentityState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIdentityStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntrospectionServer).GetIdentityState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Introspection_GetIdentityState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntrospectionServer).GetIdentityState(ctx, req.(*GetIdentityStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Introspection_ListDependentClusters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDependentClustersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntrospectionServer).ListDependentClusters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Introspection_ListDependentClusters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntrospectionServer).ListDependentClusters(ctx, req.(*ListDependentClustersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Introspection_GetLastSyncResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLastSyncResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntrospectionServer).GetLastSyncResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Introspection_GetLastSyncResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntrospectionServer).GetLastSyncResult(ctx, req.(*GetLastSyncResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Introspection_StreamSyncEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSyncEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IntrospectionServer).StreamSyncEvents(m, &introspectionStreamSyncEventsServer{stream})
+}
+
+type Introspection_StreamSyncEventsServer interface {
+	Send(*SyncResult) error
+	grpc.ServerStream
+}
+
+type introspectionStreamSyncEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *introspectionStreamSyncEventsServer) Send(m *SyncResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Introspection_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IntrospectionServer).StreamEvents(m, &introspectionStreamEventsServer{stream})
+}
+
+type Introspection_StreamEventsServer interface {
+	Send(*SyncEvent) error
+	grpc.ServerStream
+}
+
+type introspectionStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *introspectionStreamEventsServer) Send(m *SyncEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Introspection_GetDependencyGraph_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDependencyGraphRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntrospectionServer).GetDependencyGraph(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Introspection_GetDependencyGraph_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntrospectionServer).GetDependencyGraph(ctx, req.(*GetDependencyGraphRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Introspection_GetIdentityChangelog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIdentityChangelogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntrospectionServer).GetIdentityChangelog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Introspection_GetIdentityChangelog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntrospectionServer).GetIdentityChangelog(ctx, req.(*GetIdentityChangelogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Introspection_ServiceDesc is the grpc.ServiceDesc for Introspection service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Introspection_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admiral.introspection.v1.Introspection",
+	HandlerType: (*IntrospectionServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetIdentityState",
+			Handler:    _Introspection_GetIdentityState_Handler,
+		},
+		{
+			MethodName: "ListDependentClusters",
+			Handler:    _Introspection_ListDependentClusters_Handler,
+		},
+		{
+			MethodName: "GetLastSyncResult",
+			Handler:    _Introspection_GetLastSyncResult_Handler,
+		},
+		{
+			MethodName: "GetDependencyGraph",
+			Handler:    _Introspection_GetDependencyGraph_Handler,
+		},
+		{
+			MethodName: "GetIdentityChangelog",
+			Handler:    _Introspection_GetIdentityChangelog_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSyncEvents",
+			Handler:       _Introspection_StreamSyncEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Introspection_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "introspection.proto",
+}
@@ -4,21 +4,34 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/filters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/server"
 	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/secret"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/logging"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestReturnSuccessGET(t *testing.T) {
@@ -32,6 +45,66 @@ func TestReturnSuccessGET(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode)
 }
 
+func TestReturnSuccessGETWithUnsyncedCaches(t *testing.T) {
+	unsyncedInformer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(opts metaV1.ListOptions) (runtime.Object, error) {
+			return nil, errors.New("cluster unreachable")
+		},
+		WatchFunc: func(opts metaV1.ListOptions) (watch.Interface, error) {
+			return nil, errors.New("cluster unreachable")
+		},
+	}, &coreV1.Pod{}, 0, cache.Indexers{})
+	stop := make(chan struct{})
+	defer close(stop)
+	admiral.NewController("deployment-ctrl", "https://warming.cluster", stop, nil, unsyncedInformer)
+	assert.Eventually(t, func() bool {
+		return len(admiral.GetUnsyncedControllers("https://warming.cluster")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rr := clusters.NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.PutRemoteController("warming", &clusters.RemoteController{ClusterID: "warming", ApiServer: "https://warming.cluster"})
+	rr.PutRemoteController("synced", &clusters.RemoteController{ClusterID: "synced", ApiServer: "https://synced.cluster"})
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	testCases := []struct {
+		name               string
+		requireCacheSync   bool
+		expectedStatusCode int
+	}{
+		{
+			name: "Given a cluster whose caches are warming, " +
+				"When the health check is called and readiness does not require the caches to be synced, " +
+				"Then it should succeed and report the warming cluster",
+			requireCacheSync:   false,
+			expectedStatusCode: 200,
+		},
+		{
+			name: "Given a cluster whose caches are warming, " +
+				"When the health check is called and readiness requires the caches to be synced, " +
+				"Then it should fail and report the warming cluster",
+			requireCacheSync:   true,
+			expectedStatusCode: 503,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{ReadinessRequiresCacheSync: c.requireCacheSync})
+			r := httptest.NewRequest("GET", "https://admiral.com/health/ready", strings.NewReader(""))
+			w := httptest.NewRecorder()
+
+			opts.ReturnSuccessGET(w, r)
+			resp := w.Result()
+			assert.Equal(t, c.expectedStatusCode, resp.StatusCode)
+			var status ReadinessStatus
+			assert.Nil(t, json.NewDecoder(resp.Body).Decode(&status))
+			assert.False(t, status.CacheSynced)
+			assert.Equal(t, c.expectedStatusCode == 200, status.Ready)
+			assert.Equal(t, map[string][]string{"warming": {"deployment-ctrl"}}, status.UnsyncedClusters)
+		})
+	}
+}
+
 func TestReturnSuccessMetrics(t *testing.T) {
 	url := "https://admiral.com/metrics"
 	r := httptest.NewRequest("GET", url, strings.NewReader(""))
@@ -373,3 +446,395 @@ func (*mockGlobalTrafficCache) Put(*v1.GlobalTrafficPolicy) error {
 func (*mockGlobalTrafficCache) Delete(string, string) error {
 	return nil
 }
+
+func TestPauseAndResumeClusterWrites(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &clusters.RemoteController{ClusterID: "cluster1"})
+	opts := RouteOpts{RemoteRegistry: rr}
+	testCases := []struct {
+		name           string
+		method         string
+		clusterName    string
+		statusCode     int
+		expectedPaused bool
+	}{
+		{
+			name: "Given a cluster admiral does not monitor, " +
+				"When writes to it are paused, " +
+				"Then it should return 404",
+			method:      "PUT",
+			clusterName: "bar",
+			statusCode:  404,
+		},
+		{
+			name: "Given no cluster name, " +
+				"When writes are paused, " +
+				"Then it should return 400",
+			method:     "PUT",
+			statusCode: 400,
+		},
+		{
+			name: "Given a monitored cluster, " +
+				"When writes to it are paused, " +
+				"Then it should return 200 and the cluster should be paused",
+			method:         "PUT",
+			clusterName:    "cluster1",
+			statusCode:     200,
+			expectedPaused: true,
+		},
+		{
+			name: "Given a paused cluster, " +
+				"When writes to it are resumed, " +
+				"Then it should return 200 and the cluster should not be paused",
+			method:         "DELETE",
+			clusterName:    "cluster1",
+			statusCode:     200,
+			expectedPaused: false,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(c.method, "https://admiral.com/cluster/"+c.clusterName+"/writepause", nil)
+			r = mux.SetURLVars(r, map[string]string{"clustername": c.clusterName})
+			w := httptest.NewRecorder()
+			if c.method == "PUT" {
+				opts.PauseClusterWrites(w, r)
+			} else {
+				opts.ResumeClusterWrites(w, r)
+			}
+			assert.Equal(t, c.statusCode, w.Result().StatusCode)
+			assert.Equal(t, c.expectedPaused, clusters.IsClusterWritePaused("cluster1"))
+		})
+	}
+}
+
+func TestEnterAndExitMaintenanceMode(t *testing.T) {
+	opts := RouteOpts{RemoteRegistry: clusters.NewRemoteRegistry(nil, common.AdmiralParams{})}
+
+	w := httptest.NewRecorder()
+	opts.ExitMaintenanceMode(w, httptest.NewRequest("DELETE", "https://admiral.com/maintenance", nil))
+	assert.Equal(t, 409, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	opts.EnterMaintenanceMode(w, httptest.NewRequest("PUT", "https://admiral.com/maintenance", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	assert.Equal(t, admiral.MaintenancePaused, admiral.GetMaintenanceState())
+
+	w = httptest.NewRecorder()
+	opts.GetMaintenanceStatus(w, httptest.NewRequest("GET", "https://admiral.com/maintenance", nil))
+	var status admiral.MaintenanceStatus
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&status))
+	assert.Equal(t, admiral.MaintenancePaused, status.State)
+
+	w = httptest.NewRecorder()
+	opts.ExitMaintenanceMode(w, httptest.NewRequest("DELETE", "https://admiral.com/maintenance", nil))
+	assert.Equal(t, 202, w.Result().StatusCode)
+	assert.Eventually(t, func() bool { return admiral.GetMaintenanceState() == admiral.MaintenanceOff }, time.Second, 10*time.Millisecond)
+}
+
+func TestModuleLogLevels(t *testing.T) {
+	opts := RouteOpts{}
+	defer logging.Init(logrus.InfoLevel, nil)
+
+	w := httptest.NewRecorder()
+	opts.SetModuleLogLevel(w, httptest.NewRequest("PUT", "https://admiral.com/loglevels?level=debug", nil))
+	assert.Equal(t, 400, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	opts.SetModuleLogLevel(w, httptest.NewRequest("PUT", "https://admiral.com/loglevels?module=clusters&level=verbose", nil))
+	assert.Equal(t, 400, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	opts.SetModuleLogLevel(w, httptest.NewRequest("PUT", "https://admiral.com/loglevels?module=clusters/virtualservice_handler&level=debug", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	opts.GetLogLevels(w, httptest.NewRequest("GET", "https://admiral.com/loglevels", nil))
+	var levels logging.Levels
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&levels))
+	assert.Equal(t, map[string]string{"clusters/virtualservice_handler": "debug"}, levels.Modules)
+
+	w = httptest.NewRecorder()
+	opts.ResetModuleLogLevel(w, httptest.NewRequest("DELETE", "https://admiral.com/loglevels?module=clusters/virtualservice_handler", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	assert.Empty(t, logging.GetLevels().Modules)
+}
+
+func TestSimulate(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &clusters.RemoteController{ClusterID: "cluster1"})
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	testCases := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "Given a malformed request, When Simulate is called, Then it should return 400",
+			body:         "{",
+			expectedCode: 400,
+		},
+		{
+			name:         "Given a request without a change, When Simulate is called, Then it should return 400",
+			body:         "{}",
+			expectedCode: 400,
+		},
+		{
+			name:         "Given the removal of a watched cluster, When Simulate is called, Then it should return 200",
+			body:         `{"removedCluster": "cluster1"}`,
+			expectedCode: 200,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			opts.Simulate(w, httptest.NewRequest("POST", "https://admiral.com/simulate", strings.NewReader(tc.body)))
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func TestGetDependencyGraph(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.IdentityDependencyCache.Put("foo", "bar", "bar")
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedGraph string
+	}{
+		{
+			name:          "Given an identity with a dependent, When GetDependencyGraph is called, Then it should return the graph",
+			query:         "?identity=foo&depth=1",
+			expectedCode:  200,
+			expectedGraph: `{"nodes":[{"identity":"bar","clusters":[]},{"identity":"foo","clusters":[]}],"edges":[{"source":"bar","destination":"foo"}]}`,
+		},
+		{
+			name:         "Given an invalid depth, When GetDependencyGraph is called, Then it should return 400",
+			query:        "?depth=two",
+			expectedCode: 400,
+		},
+		{
+			name:         "Given an unknown direction, When GetDependencyGraph is called, Then it should return 400",
+			query:        "?direction=sideways",
+			expectedCode: 400,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			opts.GetDependencyGraph(w, httptest.NewRequest("GET", "https://admiral.com/dependencygraph"+tc.query, nil))
+			assert.Equal(t, tc.expectedCode, w.Result().StatusCode)
+			if tc.expectedGraph != "" {
+				body, _ := ioutil.ReadAll(w.Result().Body)
+				assert.JSONEq(t, tc.expectedGraph, string(body))
+			}
+		})
+	}
+}
+
+func TestGetOrphanReport(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	w := httptest.NewRecorder()
+	opts.GetOrphanReport(w, httptest.NewRequest("GET", "https://admiral.com/orphans", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var report clusters.OrphanReport
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&report))
+	assert.Empty(t, report.Orphans)
+
+	w = httptest.NewRecorder()
+	opts.GetOrphanReport(w, httptest.NewRequest("GET", "https://admiral.com/orphans?cluster=cluster1", nil))
+	assert.Equal(t, 404, w.Result().StatusCode)
+}
+
+func TestGetCacheConsistencyReport(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.CnameClusterCache.Put("stage.foo.global", "cluster1", "cluster1")
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	w := httptest.NewRecorder()
+	opts.GetCacheConsistencyReport(w, httptest.NewRequest("GET", "https://admiral.com/cacheconsistency", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var report clusters.CacheConsistencyReport
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&report))
+	assert.Equal(t, []clusters.CacheInconsistency{
+		{Cache: "cnameCluster", Key: "stage.foo.global", Reason: clusters.InconsistencyNoIdentity},
+	}, report.Inconsistencies)
+	// the report does not repair the cache
+	assert.NotNil(t, rr.AdmiralCache.CnameClusterCache.Get("stage.foo.global"))
+}
+
+func TestGetResourceDiffs(t *testing.T) {
+	opts := RouteOpts{}
+
+	w := httptest.NewRecorder()
+	opts.GetResourceDiffs(w, httptest.NewRequest("GET", "https://admiral.com/diffs?cluster=cluster1", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var diffs []clusters.ResourceDiff
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&diffs))
+	assert.Empty(t, diffs)
+
+	w = httptest.NewRecorder()
+	opts.GetResourceDiffs(w, httptest.NewRequest("GET", "https://admiral.com/diffs?cluster=cluster1&format=text", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	assert.Equal(t, "text/plain", w.Result().Header.Get("Content-Type"))
+}
+
+func TestConfigHistoryAndRollback(t *testing.T) {
+	opts := RouteOpts{RemoteRegistry: clusters.NewRemoteRegistry(nil, common.AdmiralParams{})}
+
+	w := httptest.NewRecorder()
+	r := mux.SetURLVars(httptest.NewRequest("GET", "https://admiral.com/identity/unknown/history", nil), map[string]string{"identity": "unknown"})
+	opts.GetConfigHistory(w, r)
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var history []clusters.ConfigSnapshot
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&history))
+	assert.Empty(t, history)
+
+	w = httptest.NewRecorder()
+	r = mux.SetURLVars(httptest.NewRequest("POST", "https://admiral.com/identity/unknown/rollback?version=latest", nil), map[string]string{"identity": "unknown"})
+	opts.RollbackIdentity(w, r)
+	assert.Equal(t, 400, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	r = mux.SetURLVars(httptest.NewRequest("POST", "https://admiral.com/identity/unknown/rollback?version=1", nil), map[string]string{"identity": "unknown"})
+	opts.RollbackIdentity(w, r)
+	assert.Equal(t, 400, w.Result().StatusCode)
+}
+
+func TestGetIdentitySnapshot(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.CnameIdentityCache.Store("qa.foo.global", "foo")
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	w := httptest.NewRecorder()
+	r := mux.SetURLVars(httptest.NewRequest("GET", "https://admiral.com/identity/foo/snapshot", nil), map[string]string{"identity": "foo"})
+	opts.GetIdentitySnapshot(w, r)
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var snapshot clusters.IdentitySnapshot
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&snapshot))
+	assert.Equal(t, []string{"qa.foo.global"}, snapshot.Hosts)
+
+	w = httptest.NewRecorder()
+	r = mux.SetURLVars(httptest.NewRequest("GET", "https://admiral.com/identity/unknown/snapshot", nil), map[string]string{"identity": "unknown"})
+	opts.GetIdentitySnapshot(w, r)
+	assert.Equal(t, 404, w.Result().StatusCode)
+}
+
+func TestTenantCacheState(t *testing.T) {
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("team.foo", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.team.foo.global", "team.foo")
+	rr.AdmiralCache.CnameClusterCache.Put("stage.team.foo.global", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameDependentClusterCache.Put("stage.team.foo.global", "cluster2", "cluster2")
+	rr.AdmiralCache.IdentityClusterCache.Put("other.bar", "cluster3", "cluster3")
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.other.bar.global", "other.bar")
+	opts := RouteOpts{RemoteRegistry: rr}
+	tenants := []filters.Tenant{{Name: "team", Token: "secret", Identities: []string{"team.*"}}}
+	tenantAuth := filters.TenantAuth(tenants)
+
+	request := func(path string, vars map[string]string, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "https://admiral.com"+path, nil)
+		if vars != nil {
+			r = mux.SetURLVars(r, vars)
+		}
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler := opts.GetTenantCacheState
+		if vars != nil {
+			handler = opts.GetTenantIdentityCacheState
+		}
+		tenantAuth(http.HandlerFunc(handler), "tenant").ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, 401, request("/tenant/identities", nil, "").Result().StatusCode)
+	assert.Equal(t, 401, request("/tenant/identities", nil, "wrong").Result().StatusCode)
+
+	w := request("/tenant/identities", nil, "secret")
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var summary clusters.CacheStateSummary
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&summary))
+	assert.Len(t, summary.Identities, 1)
+	assert.Equal(t, "team.foo", summary.Identities[0].Identity)
+	assert.Equal(t, []string{"cluster1"}, summary.Clusters)
+	assert.Equal(t, 1, summary.Hosts)
+	assert.Equal(t, map[string]int{common.NotProcessed: 2}, summary.SyncStatus)
+
+	w = request("/tenant/identity/team.foo", map[string]string{"identity": "team.foo"}, "secret")
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var state clusters.IdentityCacheState
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&state))
+	assert.Equal(t, []string{"stage.team.foo.global"}, state.Hosts)
+	assert.Equal(t, []string{"cluster2"}, state.DependentClusters["stage.team.foo.global"])
+	assert.Equal(t, map[string]string{"cluster1": common.NotProcessed, "cluster2": common.NotProcessed}, state.SyncStatus["stage.team.foo.global"])
+
+	assert.Equal(t, 403, request("/tenant/identity/other.bar", map[string]string{"identity": "other.bar"}, "secret").Result().StatusCode)
+	assert.Equal(t, 404, request("/tenant/identity/team.unknown", map[string]string{"identity": "team.unknown"}, "secret").Result().StatusCode)
+}
+
+func TestGetTeams(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, TeamIdentityPrefixes: map[string]string{"payments.": "payments"}})
+	rr := clusters.NewRemoteRegistry(nil, common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("payments.checkout", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("orders.cart", "cluster1", "cluster1")
+	opts := RouteOpts{RemoteRegistry: rr}
+
+	w := httptest.NewRecorder()
+	opts.GetTeams(w, httptest.NewRequest("GET", "https://admiral.com/teams", nil))
+	assert.Equal(t, 200, w.Result().StatusCode)
+	var teams []clusters.TeamSummary
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(&teams))
+	assert.Len(t, teams, 2)
+	assert.Equal(t, "payments", teams[0].Team)
+	assert.Equal(t, []string{"payments.checkout"}, teams[0].Identities)
+
+	// a tenant is served the identities of the team named after it
+	tenant := &filters.Tenant{Name: "payments", Identities: []string{"none"}}
+	assert.True(t, tenantAllows(tenant, "payments.checkout"))
+	assert.False(t, tenantAllows(tenant, "orders.cart"))
+}
+
+func TestAuthenticateRoutes(t *testing.T) {
+	routes := server.Routes{
+		{Name: "health", Method: "GET", Pattern: "/health/ready"},
+		{Name: "read", Method: "GET", Pattern: "/clusters"},
+		{Name: "pause", Method: "PUT", Pattern: "/cluster/{clustername}/writepause"},
+		{Name: "simulate", Method: "POST", Pattern: "/simulate"},
+	}
+	assert.Equal(t, routes, authenticateRoutes(nil, routes))
+
+	a := auth.NewWithAuthenticators(nil, nil)
+	authenticated := authenticateRoutes(a, routes)
+	assert.Empty(t, authenticated[0].FilterChain)
+	for _, route := range authenticated[1:] {
+		assert.Len(t, route.FilterChain, 1)
+	}
+
+	// without credentials every route but the health check is rejected
+	router := mux.NewRouter()
+	for _, route := range authenticated {
+		var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		for _, filter := range route.FilterChain {
+			handler = filter.HandlerFunc(handler, route.Name)
+		}
+		router.Methods(route.Method).Path(route.Pattern).Handler(handler)
+	}
+	for _, route := range authenticated {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.Method, route.Pattern, nil))
+		if route.Pattern == "/health/ready" {
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		} else {
+			assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+		}
+	}
+}
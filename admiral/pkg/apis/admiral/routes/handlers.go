@@ -11,8 +11,12 @@ import (
 	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
 
 	"github.com/gorilla/mux"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/filters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/logging"
 	"github.com/sirupsen/logrus"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 )
@@ -20,6 +24,7 @@ import (
 type RouteOpts struct {
 	KubeconfigPath string
 	RemoteRegistry *clusters.RemoteRegistry
+	Auth           *auth.Auth
 }
 
 //type ClusterServiceEntries struct {
@@ -31,10 +36,20 @@ type IdentityServiceEntry struct {
 	ClusterNames []string `json:"Clusters,omitempty"`
 }
 
+// ReadinessStatus is the body of the health check, it lists the controllers of each registered cluster
+// whose informer caches are still warming
+type ReadinessStatus struct {
+	Ready            bool                `json:"ready"`
+	CacheSynced      bool                `json:"cacheSynced"`
+	UnsyncedClusters map[string][]string `json:"unsyncedClusters,omitempty"`
+}
+
 /*
 We expect the DNS health checker to include the query param checkifreadonly with value set to true.
 The query param is used to check if the current Admiral instance is running in Active Mode or Passive Mode (also called read only mode).
 If Running in passive  mode, the health check returns 502 which forces DNS lookup to always return reference to Admiral in Active state.
+The health check also reports the clusters whose informer caches have not synced yet, and fails until they have
+when readiness_requires_cache_sync is set.
 */
 
 func (opts *RouteOpts) ReturnSuccessGET(w http.ResponseWriter, r *http.Request) {
@@ -44,29 +59,46 @@ func (opts *RouteOpts) ReturnSuccessGET(w http.ResponseWriter, r *http.Request)
 	checkIfReadOnlyStringVal = strings.ReplaceAll(checkIfReadOnlyStringVal, " ", "")
 	// checkIfReadOnlyStringVal will be empty in case ""checkifreadonly" query param is not sent in the request. checkIfReadOnlyBoolVal will be false
 	checkIfReadOnlyBoolVal, err := strconv.ParseBool(checkIfReadOnlyStringVal)
-	var response string
-
-	if len(checkIfReadOnlyStringVal) == 0 || nil == err {
-		if checkIfReadOnlyBoolVal {
 
-			if commonUtil.IsAdmiralReadOnly() {
-				//Force fail health check if Admiral is in Readonly mode
-				w.WriteHeader(503)
-			} else {
-				w.WriteHeader(200)
-			}
-		} else {
-			w.WriteHeader(200)
+	if len(checkIfReadOnlyStringVal) != 0 && err != nil {
+		w.WriteHeader(400)
+		response := fmt.Sprintf("Health check method called with bad query param value %v for checkifreadonly", checkIfReadOnlyStringVal)
+		_, writeErr := w.Write([]byte(response))
+		if writeErr != nil {
+			logrus.Printf("Error writing body: %v", writeErr)
+			http.Error(w, "can't write body", http.StatusInternalServerError)
 		}
-		response = fmt.Sprintf("Heath check method called: %v, URI: %v, Method: %v\n", r.Host, r.RequestURI, r.Method)
+		return
+	}
+
+	status := ReadinessStatus{Ready: true, CacheSynced: true}
+	if opts.RemoteRegistry != nil {
+		status.UnsyncedClusters = opts.RemoteRegistry.GetUnsyncedControllers()
+		status.CacheSynced = len(status.UnsyncedClusters) == 0
+	}
+	//Force fail health check if Admiral is in Readonly mode
+	if checkIfReadOnlyBoolVal && commonUtil.IsAdmiralReadOnly() {
+		status.Ready = false
+	}
+	if !status.CacheSynced && common.ReadinessRequiresCacheSync() {
+		status.Ready = false
+	}
+
+	out, err := json.Marshal(status)
+	if err != nil {
+		logrus.Printf("Failed to marshall response for health check call")
+		http.Error(w, "Failed to marshall response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if status.Ready {
+		w.WriteHeader(200)
 	} else {
-		w.WriteHeader(400)
-		response = fmt.Sprintf("Health check method called with bad query param value %v for checkifreadonly", checkIfReadOnlyStringVal)
+		w.WriteHeader(503)
 	}
-	_, writeErr := w.Write([]byte(response))
+	_, writeErr := w.Write(out)
 	if writeErr != nil {
 		logrus.Printf("Error writing body: %v", writeErr)
-		http.Error(w, "can't write body", http.StatusInternalServerError)
 	}
 }
 
@@ -238,6 +270,342 @@ func (opts *RouteOpts) GetServiceEntriesByIdentity(w http.ResponseWriter, r *htt
 	}
 }
 
+// GetClusterWritePauses handler returns the clusters writes are paused for along with the
+// sources which paused them and the number of writes queued
+func (opts *RouteOpts) GetClusterWritePauses(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.GetClusterWritePauseStates())
+}
+
+// PauseClusterWrites handler stops admiral from writing to the cluster until writes are resumed
+// through the API. Writes attempted while the cluster is paused are queued
+func (opts *RouteOpts) PauseClusterWrites(w http.ResponseWriter, r *http.Request) {
+	clusterName, ok := opts.getWatchedClusterName(w, r)
+	if !ok {
+		return
+	}
+	clusters.PauseClusterWrites(clusterName, clusters.WritePauseSourceAPI)
+	generateResponseJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("writes to cluster %s paused", clusterName)})
+}
+
+// ResumeClusterWrites handler clears the pause set through the API and replays the writes queued
+// while the cluster was paused, unless the cluster secret keeps it paused
+func (opts *RouteOpts) ResumeClusterWrites(w http.ResponseWriter, r *http.Request) {
+	clusterName, ok := opts.getWatchedClusterName(w, r)
+	if !ok {
+		return
+	}
+	clusters.ResumeClusterWrites(opts.RemoteRegistry, clusterName, clusters.WritePauseSourceAPI)
+	if clusters.IsClusterWritePaused(clusterName) {
+		generateResponseJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("writes to cluster %s are still paused by its secret", clusterName)})
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("writes to cluster %s resumed", clusterName)})
+}
+
+// GetMaintenanceStatus handler returns the maintenance mode state along with the number of
+// events buffered and dropped
+func (opts *RouteOpts) GetMaintenanceStatus(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, admiral.GetMaintenanceStatus())
+}
+
+// EnterMaintenanceMode handler stops admiral from writing to any cluster, the events received
+// in maintenance mode are buffered until it is exited
+func (opts *RouteOpts) EnterMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	clusters.EnterMaintenanceMode()
+	generateResponseJSON(w, http.StatusOK, admiral.GetMaintenanceStatus())
+}
+
+// ExitMaintenanceMode handler starts replaying the writes and events buffered in maintenance mode.
+// The replay continues in the background, its progress is returned by GetMaintenanceStatus
+func (opts *RouteOpts) ExitMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	if admiral.GetMaintenanceState() != admiral.MaintenancePaused {
+		generateErrorResponse(w, http.StatusConflict, "admiral is not in maintenance mode")
+		return
+	}
+	go clusters.ExitMaintenanceMode(opts.RemoteRegistry)
+	generateResponseJSON(w, http.StatusAccepted, map[string]string{"message": "exiting maintenance mode, buffered events are being replayed"})
+}
+
+// GetLogLevels handler returns the default log level and the log levels of the modules
+func (opts *RouteOpts) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, logging.GetLevels())
+}
+
+// SetModuleLogLevel handler changes the log level of the module, e.g. module=clusters/virtualservice_handler
+// and level=debug, so a module can be debugged without raising the log level of the others
+func (opts *RouteOpts) SetModuleLogLevel(w http.ResponseWriter, r *http.Request) {
+	module := r.FormValue("module")
+	if module == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "module not provided as part of the query params")
+		return
+	}
+	if err := logging.SetModuleLevel(module, r.FormValue("level")); err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, logging.GetLevels())
+}
+
+// ResetModuleLogLevel handler removes the log level of the module, the default level applies to it again
+func (opts *RouteOpts) ResetModuleLogLevel(w http.ResponseWriter, r *http.Request) {
+	module := r.FormValue("module")
+	if module == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "module not provided as part of the query params")
+		return
+	}
+	logging.ResetModuleLevel(module)
+	generateResponseJSON(w, http.StatusOK, logging.GetLevels())
+}
+
+// GetHostConflicts handler returns the claims on hosts admiral refused because two identities
+// generated the same cname, or a custom VirtualService of another identity routed an admiral host
+func (opts *RouteOpts) GetHostConflicts(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.GetHostConflicts(opts.RemoteRegistry))
+}
+
+// GetQuotaViolations handler returns the quotas on the generated resources the identities exceed, along with
+// what was left out of the resources generated for them
+func (opts *RouteOpts) GetQuotaViolations(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.GetQuotaViolations(opts.RemoteRegistry))
+}
+
+// GetOrphanReport handler returns the resources admiral generated in the sync namespace of the cluster query
+// param, or of all the clusters, which no longer have a source
+func (opts *RouteOpts) GetOrphanReport(w http.ResponseWriter, r *http.Request) {
+	report, err := clusters.GetOrphanReport(r.Context(), opts.RemoteRegistry, r.FormValue("cluster"))
+	if err != nil {
+		generateErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, report)
+}
+
+// GetCacheConsistencyReport handler cross checks the cache against the informer stores of the clusters
+// and returns the entries they do not back, without repairing them
+func (opts *RouteOpts) GetCacheConsistencyReport(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.CheckCacheConsistency(opts.RemoteRegistry, false))
+}
+
+// GetTenantCacheState handler returns the cache state of every identity of the tenant the request was
+// authenticated as, along with the clusters they run in and the processing status of their ServiceEntries
+func (opts *RouteOpts) GetTenantCacheState(w http.ResponseWriter, r *http.Request) {
+	tenant := filters.TenantFromRequest(r)
+	if tenant == nil {
+		generateErrorResponse(w, http.StatusUnauthorized, "request is not authenticated as a tenant")
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, clusters.GetCacheStateSummary(opts.RemoteRegistry, func(identity string) bool {
+		return tenantAllows(tenant, identity)
+	}))
+}
+
+// GetTenantIdentityCacheState handler returns the clusters, the hosts, the dependent clusters and the sync status
+// the cache holds for the identity, when it belongs to the tenant the request was authenticated as
+func (opts *RouteOpts) GetTenantIdentityCacheState(w http.ResponseWriter, r *http.Request) {
+	tenant := filters.TenantFromRequest(r)
+	if tenant == nil {
+		generateErrorResponse(w, http.StatusUnauthorized, "request is not authenticated as a tenant")
+		return
+	}
+	identity := strings.TrimSpace(mux.Vars(r)["identity"])
+	if identity == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "identity not provided as part of the path param")
+		return
+	}
+	if !tenantAllows(tenant, identity) {
+		generateErrorResponse(w, http.StatusForbidden, fmt.Sprintf("identity %s does not belong to tenant %s", identity, tenant.Name))
+		return
+	}
+	state, err := clusters.GetIdentityCacheState(opts.RemoteRegistry, identity)
+	if err != nil {
+		generateErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, state)
+}
+
+// GetTeams handler returns the identities of the cache grouped by the team they belong to
+func (opts *RouteOpts) GetTeams(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.GetTeamSummaries(opts.RemoteRegistry))
+}
+
+// tenantAllows checks if the identity matches the identity patterns of the tenant or belongs to the team
+// named after the tenant
+func tenantAllows(tenant *filters.Tenant, identity string) bool {
+	return tenant.Allows(identity) || strings.EqualFold(clusters.GetIdentityTeam(identity), tenant.Name)
+}
+
+// GetIdentityLockHolders handler returns the processings holding the lock of an identity, along with the
+// transaction they belong to and the number of processings waiting, the longest held first
+func (opts *RouteOpts) GetIdentityLockHolders(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.GetIdentityLockHolders())
+}
+
+// GetIdentitySnapshot handler returns the hosts, the generated resources by cluster, the dependent clusters
+// and the traffic policies admiral currently wants for the identity as a single document
+func (opts *RouteOpts) GetIdentitySnapshot(w http.ResponseWriter, r *http.Request) {
+	identity := strings.TrimSpace(mux.Vars(r)["identity"])
+	if identity == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "identity not provided as part of the path param")
+		return
+	}
+	snapshot, err := clusters.GetIdentitySnapshot(opts.RemoteRegistry, identity)
+	if err != nil {
+		generateErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, snapshot)
+}
+
+// GetConfigHistory handler returns the snapshots of the configuration admiral generated for the identity, the latest first
+func (opts *RouteOpts) GetConfigHistory(w http.ResponseWriter, r *http.Request) {
+	identity := strings.TrimSpace(mux.Vars(r)["identity"])
+	if identity == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "identity not provided as part of the path param")
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, clusters.GetConfigHistory(identity))
+}
+
+// RollbackIdentity handler rolls the configuration admiral generated for the identity back to the snapshot
+// with the version query param, across all the clusters
+func (opts *RouteOpts) RollbackIdentity(w http.ResponseWriter, r *http.Request) {
+	identity := strings.TrimSpace(mux.Vars(r)["identity"])
+	if identity == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "identity not provided as part of the path param")
+		return
+	}
+	version, err := strconv.Atoi(r.FormValue("version"))
+	if err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, "invalid version: "+err.Error())
+		return
+	}
+	result, err := clusters.RollbackIdentity(r.Context(), opts.RemoteRegistry, identity, version)
+	if err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, result)
+}
+
+// GetResourceDiffs handler returns the changes admiral would apply to the generated resources of the cluster
+// query param, or of all the clusters, recorded in the diff-only mode. The human-readable summaries are
+// returned as plain text when the format query param is text
+func (opts *RouteOpts) GetResourceDiffs(w http.ResponseWriter, r *http.Request) {
+	diffs := clusters.GetResourceDiffs(r.FormValue("cluster"))
+	if r.FormValue("format") == "text" {
+		summaries := make([]string, 0, len(diffs))
+		for _, diff := range diffs {
+			summaries = append(summaries, diff.Summary)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(strings.Join(summaries, "\n"))); err != nil {
+			logrus.Println("Failed to write message: ", err)
+		}
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, diffs)
+}
+
+// ExportBackup handler returns the resources admiral generated in the sync namespace of the cluster query
+// param, or of all the clusters, as a versioned archive which can be restored
+func (opts *RouteOpts) ExportBackup(w http.ResponseWriter, r *http.Request) {
+	archive, err := clusters.ExportBackup(r.Context(), opts.RemoteRegistry, r.FormValue("cluster"))
+	if err != nil {
+		generateErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, archive)
+}
+
+// RestoreBackup handler writes the resources of the source query param cluster of the archive in the
+// request body, or of the same cluster by default, to the cluster. The resources already present are
+// only replaced when the overwrite query param is true
+func (opts *RouteOpts) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	clusterName, ok := opts.getWatchedClusterName(w, r)
+	if !ok {
+		return
+	}
+	var archive clusters.BackupArchive
+	if err := json.NewDecoder(r.Body).Decode(&archive); err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, "invalid backup archive: "+err.Error())
+		return
+	}
+	request := clusters.RestoreRequest{SourceCluster: r.FormValue("source"), TargetCluster: clusterName}
+	if overwrite := r.FormValue("overwrite"); overwrite != "" {
+		var err error
+		if request.Overwrite, err = strconv.ParseBool(overwrite); err != nil {
+			generateErrorResponse(w, http.StatusBadRequest, "invalid overwrite: "+err.Error())
+			return
+		}
+	}
+	result, err := clusters.RestoreBackup(r.Context(), opts.RemoteRegistry, archive, request)
+	if err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, result)
+}
+
+// Simulate handler returns the resources admiral would create, update or delete in each cluster
+// for the hypothetical change in the request body, without applying anything
+func (opts *RouteOpts) Simulate(w http.ResponseWriter, r *http.Request) {
+	var request clusters.SimulationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, "invalid simulation request: "+err.Error())
+		return
+	}
+	result, err := clusters.Simulate(opts.RemoteRegistry, request)
+	if err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, result)
+}
+
+// GetMeshFederationExport handler returns the services of the mesh along with the endpoints through
+// which other meshes, admiral or not, reach them
+func (opts *RouteOpts) GetMeshFederationExport(w http.ResponseWriter, r *http.Request) {
+	generateResponseJSON(w, http.StatusOK, clusters.GetMeshFederationExport(opts.RemoteRegistry))
+}
+
+// GetDependencyGraph handler returns the identity dependency graph, optionally walked from the identity,
+// direction and depth query params and filtered down to the identities deployed in the cluster query param
+func (opts *RouteOpts) GetDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	query := clusters.DependencyGraphQuery{
+		Identity:  r.FormValue("identity"),
+		Direction: r.FormValue("direction"),
+		Cluster:   r.FormValue("cluster"),
+	}
+	if depth := r.FormValue("depth"); depth != "" {
+		var err error
+		if query.Depth, err = strconv.Atoi(depth); err != nil {
+			generateErrorResponse(w, http.StatusBadRequest, "invalid depth: "+err.Error())
+			return
+		}
+	}
+	graph, err := clusters.GetDependencyGraph(opts.RemoteRegistry, query)
+	if err != nil {
+		generateErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	generateResponseJSON(w, http.StatusOK, graph)
+}
+
+func (opts *RouteOpts) getWatchedClusterName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	clusterName := strings.Trim(mux.Vars(r)["clustername"], " ")
+	if clusterName == "" {
+		generateErrorResponse(w, http.StatusBadRequest, "cluster name not provided as part of the request")
+		return "", false
+	}
+	if opts.RemoteRegistry == nil || opts.RemoteRegistry.GetRemoteController(clusterName) == nil {
+		generateErrorResponse(w, http.StatusNotFound, fmt.Sprintf("admiral is not monitoring cluster %s", clusterName))
+		return "", false
+	}
+	return clusterName, true
+}
+
 func generateErrorResponse(w http.ResponseWriter, code int, message string) {
 	generateResponseJSON(w, code, map[string]string{"error": message})
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/filters"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/server"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -21,7 +22,7 @@ func NewAdmiralAPIServer(opts *RouteOpts) server.Routes {
 		log.Printf("could not retrieve kubeconfig: %v", err)
 	}
 
-	return server.Routes{
+	routes := server.Routes{
 
 		server.Route{
 			Name:        "Success health check",
@@ -54,6 +55,227 @@ func NewAdmiralAPIServer(opts *RouteOpts) server.Routes {
 			Query:       "env",
 			HandlerFunc: opts.GetGlobalTrafficPolicyByIdentityAndEnv,
 		},
+		server.Route{
+			Name:        "Get list of clusters writes are paused for",
+			Method:      "GET",
+			Pattern:     "/clusters/writepause",
+			HandlerFunc: opts.GetClusterWritePauses,
+		},
+		server.Route{
+			Name:        "Pause writes to a given cluster",
+			Method:      "PUT",
+			Pattern:     "/cluster/{clustername}/writepause",
+			HandlerFunc: opts.PauseClusterWrites,
+		},
+		server.Route{
+			Name:        "Resume writes to a given cluster",
+			Method:      "DELETE",
+			Pattern:     "/cluster/{clustername}/writepause",
+			HandlerFunc: opts.ResumeClusterWrites,
+		},
+		server.Route{
+			Name:        "Get the maintenance mode state",
+			Method:      "GET",
+			Pattern:     "/maintenance",
+			HandlerFunc: opts.GetMaintenanceStatus,
+		},
+		server.Route{
+			Name:        "Enter maintenance mode",
+			Method:      "PUT",
+			Pattern:     "/maintenance",
+			HandlerFunc: opts.EnterMaintenanceMode,
+		},
+		server.Route{
+			Name:        "Exit maintenance mode",
+			Method:      "DELETE",
+			Pattern:     "/maintenance",
+			HandlerFunc: opts.ExitMaintenanceMode,
+		},
+		server.Route{
+			Name:        "Get the claims on hosts refused because the host was owned by another identity or virtualservice",
+			Method:      "GET",
+			Pattern:     "/hostconflicts",
+			HandlerFunc: opts.GetHostConflicts,
+		},
+		server.Route{
+			Name:        "Get the quotas on the generated resources the identities exceed",
+			Method:      "GET",
+			Pattern:     "/quotas",
+			HandlerFunc: opts.GetQuotaViolations,
+		},
+		server.Route{
+			Name:        "Get the resources generated in the sync namespaces which no longer have a source",
+			Method:      "GET",
+			Pattern:     "/orphans",
+			HandlerFunc: opts.GetOrphanReport,
+		},
+		server.Route{
+			Name:        "Get the entries of the cache the informer stores of the clusters do not back",
+			Method:      "GET",
+			Pattern:     "/cacheconsistency",
+			HandlerFunc: opts.GetCacheConsistencyReport,
+		},
+		server.Route{
+			Name:        "Get the identities grouped by the team they belong to",
+			Method:      "GET",
+			Pattern:     "/teams",
+			HandlerFunc: opts.GetTeams,
+		},
+		server.Route{
+			Name:        "Get the processings holding the lock of an identity",
+			Method:      "GET",
+			Pattern:     "/identitylocks",
+			HandlerFunc: opts.GetIdentityLockHolders,
+		},
+		server.Route{
+			Name:        "Get the complete state admiral currently wants for an identity",
+			Method:      "GET",
+			Pattern:     "/identity/{identity}/snapshot",
+			HandlerFunc: opts.GetIdentitySnapshot,
+		},
+		server.Route{
+			Name:        "Get the snapshots of the configuration generated for an identity",
+			Method:      "GET",
+			Pattern:     "/identity/{identity}/history",
+			HandlerFunc: opts.GetConfigHistory,
+		},
+		server.Route{
+			Name:        "Roll the configuration generated for an identity back to a snapshot",
+			Method:      "POST",
+			Pattern:     "/identity/{identity}/rollback",
+			HandlerFunc: opts.RollbackIdentity,
+		},
+		server.Route{
+			Name:        "Get the changes to the generated resources recorded in the diff-only mode",
+			Method:      "GET",
+			Pattern:     "/diffs",
+			HandlerFunc: opts.GetResourceDiffs,
+		},
+		server.Route{
+			Name:        "Export the resources generated in the sync namespaces into a backup archive",
+			Method:      "GET",
+			Pattern:     "/backup",
+			HandlerFunc: opts.ExportBackup,
+		},
+		server.Route{
+			Name:        "Restore the resources of a cluster of a backup archive to a given cluster",
+			Method:      "POST",
+			Pattern:     "/cluster/{clustername}/restore",
+			HandlerFunc: opts.RestoreBackup,
+		},
+		server.Route{
+			Name:        "Simulate the resources a dependency, GTP or cluster removal would change",
+			Method:      "POST",
+			Pattern:     "/simulate",
+			HandlerFunc: opts.Simulate,
+		},
+		server.Route{
+			Name:        "Export the services of the mesh for federation with other meshes",
+			Method:      "GET",
+			Pattern:     "/federation/export",
+			HandlerFunc: opts.GetMeshFederationExport,
+		},
+		server.Route{
+			Name:        "Get the identity dependency graph",
+			Method:      "GET",
+			Pattern:     "/dependencygraph",
+			HandlerFunc: opts.GetDependencyGraph,
+		},
+		server.Route{
+			Name:        "Get the log levels of the modules",
+			Method:      "GET",
+			Pattern:     "/loglevels",
+			HandlerFunc: opts.GetLogLevels,
+		},
+		server.Route{
+			Name:        "Set the log level of a given module",
+			Method:      "PUT",
+			Pattern:     "/loglevels",
+			HandlerFunc: opts.SetModuleLogLevel,
+		},
+		server.Route{
+			Name:        "Reset the log level of a given module to the default level",
+			Method:      "DELETE",
+			Pattern:     "/loglevels",
+			HandlerFunc: opts.ResetModuleLogLevel,
+		},
+	}
+
+	return append(authenticateRoutes(opts.Auth, routes), newTenantRoutes(opts)...)
+}
+
+// readOnlyRoutes are the routes which do not change the state of admiral, though not served on GET
+var readOnlyRoutes = map[string]bool{
+	"/simulate": true,
+}
+
+// authenticateRoutes requires the callers of the routes to be bound to the viewer role, or to the operator role
+// for the routes which change the state of admiral. The health check is left out for the probes to reach it
+func authenticateRoutes(a *auth.Auth, routes server.Routes) server.Routes {
+	if a == nil {
+		return routes
+	}
+	for i := range routes {
+		if routes[i].Pattern == "/health/ready" {
+			continue
+		}
+		role := auth.RoleViewer
+		if routes[i].Method != "GET" && !readOnlyRoutes[routes[i].Pattern] {
+			role = auth.RoleOperator
+		}
+		routes[i].FilterChain = append(routes[i].FilterChain, server.Filter{HandlerFunc: filters.Authenticate(a, role)})
+	}
+	return routes
+}
+
+// newTenantRoutes returns the routes a tenant can read the cache state of its own identities on, authenticated
+// with the bearer tokens of the tenant token file. No route is returned when the file is not set or not valid
+func newTenantRoutes(opts *RouteOpts) server.Routes {
+	tenantFile := common.GetTenantTokenFile()
+	if tenantFile == "" {
+		return nil
+	}
+	tenants, err := filters.LoadTenants(tenantFile)
+	if err != nil {
+		log.Printf("not serving the tenant routes: %v", err)
+		return nil
+	}
+	tenantAuth := server.Filters{
+		server.Filter{HandlerFunc: filters.TenantAuth(tenants)},
+	}
+	return server.Routes{
+		server.Route{
+			Name:        "Get the cache state of the identities of the tenant aggregated across all the clusters",
+			Method:      "GET",
+			Pattern:     "/tenant/identities",
+			HandlerFunc: opts.GetTenantCacheState,
+			FilterChain: tenantAuth,
+		},
+		server.Route{
+			Name:        "Get the cache state of a given identity of the tenant",
+			Method:      "GET",
+			Pattern:     "/tenant/identity/{identity}",
+			HandlerFunc: opts.GetTenantIdentityCacheState,
+			FilterChain: tenantAuth,
+		},
+	}
+}
+
+// NewAdmissionWebhookServer returns the routes of the validating admission webhooks
+func NewAdmissionWebhookServer(opts *RouteOpts) server.Routes {
+	return server.Routes{
+		server.Route{
+			Name:        "Validate GlobalTrafficPolicy admission requests",
+			Method:      "POST",
+			Pattern:     "/validate/globaltrafficpolicy",
+			HandlerFunc: opts.ValidateGlobalTrafficPolicy,
+		},
+		server.Route{
+			Name:        "Validate custom VirtualService admission requests",
+			Method:      "POST",
+			Pattern:     "/validate/virtualservice",
+			HandlerFunc: opts.ValidateVirtualService,
+		},
 	}
 }
 
@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	admissionV1 "k8s.io/api/admission/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxDryRunWarnings is the number of changes of a GTP listed in the warnings of its admission,
+// the api server truncates the warnings beyond a few kilobytes
+const maxDryRunWarnings = 10
+
+// validateFunc returns the reasons the raw object of an admission request should be rejected,
+// along with the warnings returned to the client when it is admitted
+type validateFunc func(raw []byte) (violations []string, warnings []string, err error)
+
+// ValidateGlobalTrafficPolicy is the validating admission webhook for GlobalTrafficPolicies.
+// When the dry-run of the GTPs is enabled, the resources the admitted GTP changes are returned as warnings
+func (opts *RouteOpts) ValidateGlobalTrafficPolicy(w http.ResponseWriter, r *http.Request) {
+	reviewAdmission(w, r, func(raw []byte) ([]string, []string, error) {
+		gtp := &v1.GlobalTrafficPolicy{}
+		if err := json.Unmarshal(raw, gtp); err != nil {
+			return nil, nil, err
+		}
+		violations := clusters.ValidateGlobalTrafficPolicy(opts.RemoteRegistry, gtp)
+		if len(violations) > 0 || !common.EnableGTPAdmissionDryRun() {
+			return violations, nil, nil
+		}
+		return nil, getDryRunWarnings(opts.RemoteRegistry, gtp), nil
+	})
+}
+
+// getDryRunWarnings simulates the GTP against the caches of admiral and returns the resources it changes
+// in each cluster, the full list is returned by the simulate endpoint
+func getDryRunWarnings(rr *clusters.RemoteRegistry, gtp *v1.GlobalTrafficPolicy) []string {
+	result, err := clusters.Simulate(rr, clusters.SimulationRequest{GlobalTrafficPolicy: gtp})
+	if err != nil {
+		logrus.Warnf("failed to simulate globaltrafficpolicy %s/%s: %v", gtp.Namespace, gtp.Name, err)
+		return nil
+	}
+	warnings := make([]string, 0, maxDryRunWarnings+1)
+	for i, change := range result.Changes {
+		if i == maxDryRunWarnings {
+			warnings = append(warnings, fmt.Sprintf("dry-run: %d more changes, simulate the globaltrafficpolicy for the full list", len(result.Changes)-i))
+			break
+		}
+		warnings = append(warnings, "dry-run: "+change.String())
+	}
+	for _, warning := range result.Warnings {
+		warnings = append(warnings, "dry-run: "+warning)
+	}
+	return warnings
+}
+
+// ValidateVirtualService is the validating admission webhook for custom VirtualServices
+// which admiral merges into the in-cluster VirtualServices
+func (opts *RouteOpts) ValidateVirtualService(w http.ResponseWriter, r *http.Request) {
+	reviewAdmission(w, r, func(raw []byte) ([]string, []string, error) {
+		vs := &v1alpha3.VirtualService{}
+		if err := json.Unmarshal(raw, vs); err != nil {
+			return nil, nil, err
+		}
+		return clusters.ValidateCustomVirtualService(vs), nil, nil
+	})
+}
+
+// reviewAdmission decodes the AdmissionReview sent by the api server, validates the object of
+// create and update requests and writes back the AdmissionReview with the decision and the warnings
+func reviewAdmission(w http.ResponseWriter, r *http.Request, validate validateFunc) {
+	defer r.Body.Close()
+	review := &admissionV1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		logrus.Printf("failed to decode admission review: %v", err)
+		generateErrorResponse(w, http.StatusBadRequest, "invalid admission review")
+		return
+	}
+	request := review.Request
+	response := &admissionV1.AdmissionResponse{UID: request.UID, Allowed: true}
+	if request.Operation == admissionV1.Create || request.Operation == admissionV1.Update {
+		violations, warnings, err := validate(request.Object.Raw)
+		if err != nil {
+			violations = []string{fmt.Sprintf("failed to decode %s: %v", request.Kind.Kind, err)}
+		}
+		response.Warnings = warnings
+		if len(violations) > 0 {
+			logrus.Infof("rejected %s %s/%s: %s", request.Kind.Kind, request.Namespace, request.Name, strings.Join(violations, "; "))
+			response.Allowed = false
+			response.Result = &metaV1.Status{
+				Status:  metaV1.StatusFailure,
+				Code:    http.StatusUnprocessableEntity,
+				Reason:  metaV1.StatusReasonInvalid,
+				Message: strings.Join(violations, "; "),
+			}
+		}
+	}
+	generateResponseJSON(w, http.StatusOK, &admissionV1.AdmissionReview{
+		TypeMeta: review.TypeMeta,
+		Response: response,
+	})
+}
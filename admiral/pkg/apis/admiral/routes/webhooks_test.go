@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	admissionV1 "k8s.io/api/admission/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidateGlobalTrafficPolicy(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{AdmiralCRDIdentityLabel: "identity"},
+	})
+	newReview := func(operation admissionV1.Operation, weight int32) []byte {
+		gtp, _ := json.Marshal(&v1.GlobalTrafficPolicy{
+			ObjectMeta: metaV1.ObjectMeta{Name: "gtp", Labels: map[string]string{"identity": "foo"}},
+			Spec: model.GlobalTrafficPolicy{Policy: []*model.TrafficPolicy{{
+				LbType: model.TrafficPolicy_FAILOVER,
+				Target: []*model.TrafficGroup{{Region: "us-west-2", Weight: weight}},
+			}}},
+		})
+		review, _ := json.Marshal(&admissionV1.AdmissionReview{
+			TypeMeta: metaV1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+			Request: &admissionV1.AdmissionRequest{
+				UID:       "uid",
+				Operation: operation,
+				Object:    runtime.RawExtension{Raw: gtp},
+			},
+		})
+		return review
+	}
+	testCases := []struct {
+		name            string
+		body            []byte
+		expectedCode    int
+		expectedAllowed bool
+	}{
+		{
+			name: "Given a body which is not an admission review, " +
+				"When the webhook is called, " +
+				"Then it should return bad request",
+			body:         []byte("{"),
+			expectedCode: 400,
+		},
+		{
+			name: "Given a valid GTP is created, " +
+				"When the webhook is called, " +
+				"Then it should be allowed",
+			body:            newReview(admissionV1.Create, 100),
+			expectedCode:    200,
+			expectedAllowed: true,
+		},
+		{
+			name: "Given an invalid GTP is updated, " +
+				"When the webhook is called, " +
+				"Then it should be denied",
+			body:            newReview(admissionV1.Update, 50),
+			expectedCode:    200,
+			expectedAllowed: false,
+		},
+		{
+			name: "Given an invalid GTP is deleted, " +
+				"When the webhook is called, " +
+				"Then it should be allowed",
+			body:            newReview(admissionV1.Delete, 50),
+			expectedCode:    200,
+			expectedAllowed: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			opts := RouteOpts{}
+			r := httptest.NewRequest("POST", "https://admiral.com/validate/globaltrafficpolicy", bytes.NewReader(c.body))
+			w := httptest.NewRecorder()
+			opts.ValidateGlobalTrafficPolicy(w, r)
+			resp := w.Result()
+			assert.Equal(t, c.expectedCode, resp.StatusCode)
+			if c.expectedCode != 200 {
+				return
+			}
+			review := &admissionV1.AdmissionReview{}
+			assert.Nil(t, json.NewDecoder(resp.Body).Decode(review))
+			assert.Equal(t, "uid", string(review.Response.UID))
+			assert.Equal(t, c.expectedAllowed, review.Response.Allowed)
+		})
+	}
+}
+
+func TestValidateGlobalTrafficPolicyDryRun(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                 &common.LabelSet{AdmiralCRDIdentityLabel: "identity", EnvKey: "admiral.io/env"},
+		SyncNamespace:            "admiral-sync",
+		EnableGTPAdmissionDryRun: true,
+	})
+	defer common.ResetSync()
+	rr := clusters.NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.foo.global", "foo")
+	rr.AdmiralCache.CnameClusterCache.Put("stage.foo.global", "cluster1", "cluster1")
+	gtp, _ := json.Marshal(&v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{Name: "gtp", Labels: map[string]string{"identity": "foo", "admiral.io/env": "stage"}},
+		Spec: model.GlobalTrafficPolicy{Policy: []*model.TrafficPolicy{{
+			LbType:    model.TrafficPolicy_FAILOVER,
+			DnsPrefix: "default",
+			Target:    []*model.TrafficGroup{{Region: "us-west-2", Weight: 100}},
+		}}},
+	})
+	body, _ := json.Marshal(&admissionV1.AdmissionReview{
+		TypeMeta: metaV1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+		Request: &admissionV1.AdmissionRequest{
+			UID:       "uid",
+			Operation: admissionV1.Create,
+			Object:    runtime.RawExtension{Raw: gtp},
+		},
+	})
+
+	opts := RouteOpts{RemoteRegistry: rr}
+	r := httptest.NewRequest("POST", "https://admiral.com/validate/globaltrafficpolicy", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	opts.ValidateGlobalTrafficPolicy(w, r)
+
+	review := &admissionV1.AdmissionReview{}
+	assert.Nil(t, json.NewDecoder(w.Result().Body).Decode(review))
+	assert.True(t, review.Response.Allowed)
+	assert.Equal(t, []string{
+		"dry-run: update DestinationRule admiral-sync/stage.foo.global-default-dr in cluster cluster1: us-west-2 0->100",
+	}, review.Response.Warnings)
+}
+
+func TestValidateVirtualService(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		HostnameSuffix:     "global",
+		ProcessVSCreatedBy: "testCreatedBy",
+		LabelSet:           &common.LabelSet{},
+	})
+	newReview := func(createdFor string) []byte {
+		vs, _ := json.Marshal(&v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        "custom-vs",
+				Labels:      map[string]string{common.CreatedBy: "testCreatedBy", common.CreatedFor: createdFor},
+				Annotations: map[string]string{common.CreatedForEnv: "stage"},
+			},
+			Spec: networkingV1Alpha3.VirtualService{
+				Hosts: []string{"stage.foo.global"},
+				Http: []*networkingV1Alpha3.HTTPRoute{{
+					Name: "stage.foo.global",
+					Route: []*networkingV1Alpha3.HTTPRouteDestination{{
+						Destination: &networkingV1Alpha3.Destination{Host: "stage.foo.global"},
+					}},
+				}},
+			},
+		})
+		review, _ := json.Marshal(&admissionV1.AdmissionReview{
+			TypeMeta: metaV1.TypeMeta{Kind: "AdmissionReview", APIVersion: "admission.k8s.io/v1"},
+			Request: &admissionV1.AdmissionRequest{
+				UID:       "uid",
+				Operation: admissionV1.Create,
+				Object:    runtime.RawExtension{Raw: vs},
+			},
+		})
+		return review
+	}
+	testCases := []struct {
+		name            string
+		body            []byte
+		expectedAllowed bool
+	}{
+		{
+			name: "Given a valid custom VS is created, " +
+				"When the webhook is called, " +
+				"Then it should be allowed",
+			body:            newReview("foo"),
+			expectedAllowed: true,
+		},
+		{
+			name: "Given a custom VS without the createdFor label is created, " +
+				"When the webhook is called, " +
+				"Then it should be denied",
+			body:            newReview(""),
+			expectedAllowed: false,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			opts := RouteOpts{}
+			r := httptest.NewRequest("POST", "https://admiral.com/validate/virtualservice", bytes.NewReader(c.body))
+			w := httptest.NewRecorder()
+			opts.ValidateVirtualService(w, r)
+			resp := w.Result()
+			assert.Equal(t, 200, resp.StatusCode)
+			review := &admissionV1.AdmissionReview{}
+			assert.Nil(t, json.NewDecoder(resp.Body).Decode(review))
+			assert.Equal(t, c.expectedAllowed, review.Response.Allowed)
+		})
+	}
+}
@@ -2,8 +2,12 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 
@@ -61,6 +65,68 @@ func (s *Service) Start(ctx context.Context, port int, routes Routes, filter []F
 
 }
 
+// StartTLS starts serving the routes over TLS, which is required for the routes to be
+// called by the kubernetes api server such as admission webhooks. It returns the error
+// the server stopped with, which is nil when it is stopped through the context
+func (s *Service) StartTLS(ctx context.Context, port int, routes Routes, filter []Filter, remoteRegistry *clusters.RemoteRegistry, certFile, keyFile string) error {
+
+	s.ctx = ctx
+	s.Port = port
+	s.remoteRegistry = remoteRegistry
+
+	go waitForStop(s)
+
+	router := s.newRouter(routes, filter)
+
+	s.server = http.Server{Addr: ":" + strconv.Itoa(port), Handler: router}
+
+	log.Printf("Starting TLS server on port=%d", port)
+	if err := s.server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// StartMutualTLS starts serving the routes over TLS, verifying the client certificates against the CAs of
+// the client CA file. The clients without a certificate are still served, so that they can authenticate
+// with a bearer token instead. It returns the error the server stopped with, which is nil when it is
+// stopped through the context
+func (s *Service) StartMutualTLS(ctx context.Context, port int, routes Routes, filter []Filter, remoteRegistry *clusters.RemoteRegistry, certFile, keyFile, clientCAFile string) error {
+
+	s.ctx = ctx
+	s.Port = port
+	s.remoteRegistry = remoteRegistry
+
+	clientCAs := x509.NewCertPool()
+	clientCAPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read client CA file %s: %v", clientCAFile, err)
+	}
+	if !clientCAs.AppendCertsFromPEM(clientCAPEM) {
+		return fmt.Errorf("client CA file %s has no certificates", clientCAFile)
+	}
+
+	go waitForStop(s)
+
+	router := s.newRouter(routes, filter)
+
+	s.server = http.Server{
+		Addr:    ":" + strconv.Itoa(port),
+		Handler: router,
+		TLSConfig: &tls.Config{
+			ClientCAs:  clientCAs,
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+
+	log.Printf("Starting mutual TLS server on port=%d", port)
+	if err := s.server.ListenAndServeTLS(certFile, keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
 func (s *Service) newRouter(routes Routes, filter []Filter) *mux.Router {
 
 	router := mux.NewRouter().StrictSlash(true)
@@ -69,6 +135,10 @@ func (s *Service) newRouter(routes Routes, filter []Filter) *mux.Router {
 		var handler http.Handler
 		handler = route.HandlerFunc
 
+		for _, filter := range route.FilterChain {
+			handler = filter.HandlerFunc(handler, route.Name)
+		}
+
 		for _, filter := range filter {
 			handler = filter.HandlerFunc(handler, route.Name)
 		}
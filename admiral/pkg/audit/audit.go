@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	OperationCreate = "Create"
+	OperationUpdate = "Update"
+	OperationDelete = "Delete"
+
+	recordBufferSize = 1000
+	httpSinkTimeout  = 5 * time.Second
+)
+
+// Record is a single mutation admiral performed against a cluster
+type Record struct {
+	Timestamp       time.Time `json:"timestamp"`
+	TxId            string    `json:"txId,omitempty"`
+	Operation       string    `json:"operation"`
+	Kind            string    `json:"kind"`
+	Name            string    `json:"name"`
+	Namespace       string    `json:"namespace"`
+	Cluster         string    `json:"cluster"`
+	Identity        string    `json:"identity,omitempty"`
+	Diff            string    `json:"diff,omitempty"`
+	TriggeringEvent string    `json:"triggeringEvent,omitempty"`
+	TriggeringKind  string    `json:"triggeringKind,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	DryRun          bool      `json:"dryRun,omitempty"`
+}
+
+// Sink is the destination audit records are written to
+type Sink interface {
+	Write(record Record) error
+}
+
+// NewSink returns the sink for the target, which is either a file:// path the records are
+// appended to as JSON lines or an http(s):// endpoint each record is POSTed to as JSON.
+// Kafka topics can be written to through a REST proxy endpoint.
+func NewSink(target string) (Sink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit sink %s: %v", target, err)
+	}
+	switch u.Scheme {
+	case "file":
+		f, err := os.OpenFile(u.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit file %s: %v", u.Path, err)
+		}
+		return &fileSink{encoder: json.NewEncoder(f)}, nil
+	case "http", "https":
+		return &httpSink{endpoint: target, client: &http.Client{Timeout: httpSinkTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported audit sink scheme %q in %s", u.Scheme, target)
+	}
+}
+
+type fileSink struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+func (s *fileSink) Write(record Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.encoder.Encode(record)
+}
+
+type httpSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (s *httpSink) Write(record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("audit endpoint %s returned %d", s.endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// Listener is called with every record passed to Log, whether or not a sink is configured.
+// Listeners are called synchronously and must not block.
+type Listener func(record Record)
+
+var (
+	recorderLock sync.RWMutex
+	records      chan Record
+	listeners    []Listener
+)
+
+// AddListener registers the listener to be called with every record passed to Log
+func AddListener(listener Listener) {
+	recorderLock.Lock()
+	defer recorderLock.Unlock()
+	listeners = append(listeners, listener)
+}
+
+// Initialize starts writing the records passed to Log to the sink for the target.
+// Auditing stays disabled when the target is empty.
+func Initialize(target string) error {
+	if target == "" {
+		return nil
+	}
+	sink, err := NewSink(target)
+	if err != nil {
+		return err
+	}
+	start(sink)
+	return nil
+}
+
+func start(sink Sink) {
+	recorderLock.Lock()
+	defer recorderLock.Unlock()
+	records = make(chan Record, recordBufferSize)
+	go func(records <-chan Record) {
+		for record := range records {
+			if err := sink.Write(record); err != nil {
+				log.Errorf("failed to write audit record for %s %s/%s in cluster %s: %v",
+					record.Kind, record.Namespace, record.Name, record.Cluster, err)
+			}
+		}
+	}(records)
+}
+
+// Log passes the record to the listeners and queues it to be written to the sink without
+// blocking the caller. Records are dropped when auditing is disabled or the sink cannot keep up.
+func Log(record Record) {
+	recorderLock.RLock()
+	defer recorderLock.RUnlock()
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now().UTC()
+	}
+	for _, listener := range listeners {
+		listener(record)
+	}
+	if records == nil {
+		return
+	}
+	select {
+	case records <- record:
+	default:
+		log.Warnf("audit buffer is full, dropping record for %s %s/%s in cluster %s",
+			record.Kind, record.Namespace, record.Name, record.Cluster)
+	}
+}
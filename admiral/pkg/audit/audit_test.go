@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSink(t *testing.T) {
+	testCases := []struct {
+		name        string
+		target      string
+		expectedErr bool
+	}{
+		{
+			name: "Given a file target, " +
+				"When NewSink is called, " +
+				"Then it should return a file sink",
+			target: "file://" + filepath.Join(t.TempDir(), "audit.log"),
+		},
+		{
+			name: "Given an http target, " +
+				"When NewSink is called, " +
+				"Then it should return an http sink",
+			target: "https://audit.example.com/records",
+		},
+		{
+			name: "Given a target with an unsupported scheme, " +
+				"When NewSink is called, " +
+				"Then it should return an error",
+			target:      "kafka://broker:9092/audit",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, err := NewSink(c.target)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.NotNil(t, sink)
+		})
+	}
+}
+
+func TestSinkWrite(t *testing.T) {
+	record := Record{Operation: OperationUpdate, Kind: "ServiceEntry", Name: "foo.global-se", Cluster: "cluster1", TxId: "abc"}
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	fileSink, err := NewSink("file://" + path)
+	assert.Nil(t, err)
+	assert.Nil(t, fileSink.Write(record))
+	f, err := os.Open(path)
+	assert.Nil(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	assert.True(t, scanner.Scan())
+	written := Record{}
+	assert.Nil(t, json.Unmarshal(scanner.Bytes(), &written))
+	assert.Equal(t, record, written)
+
+	received := make(chan Record, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posted := Record{}
+		_ = json.Unmarshal(body, &posted)
+		received <- posted
+	}))
+	defer server.Close()
+	httpSink, err := NewSink(server.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, httpSink.Write(record))
+	assert.Equal(t, record, <-received)
+}
+
+func TestAddListener(t *testing.T) {
+	var received []Record
+	AddListener(func(record Record) { received = append(received, record) })
+	Log(Record{Operation: OperationDelete, Kind: "VirtualService", Name: "foo-vs", Cluster: "cluster1"})
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, "foo-vs", received[0].Name)
+	assert.False(t, received[0].Timestamp.IsZero())
+}
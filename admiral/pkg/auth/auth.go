@@ -0,0 +1,211 @@
+// Package auth authenticates the callers of the APIs admiral serves, with their client certificates or
+// their bearer tokens, and authorizes them with the role bound to them.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationV1 "k8s.io/api/authentication/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// RoleViewer can call the endpoints which only read the state of admiral
+	RoleViewer = "viewer"
+	// RoleOperator can also call the endpoints which change the state of admiral, such as pausing writes
+	RoleOperator = "operator"
+
+	// MethodClientCert authenticates the callers with the client certificates the TLS handshake verified
+	MethodClientCert = "client-cert"
+	// MethodTokenReview authenticates the callers with bearer tokens reviewed by the kubernetes api server
+	MethodTokenReview = "token-review"
+
+	tokenReviewCacheTTL = time.Minute
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleOperator: 2}
+
+type principalContextKey struct{}
+
+// Principal is an authenticated caller along with the role bound to it
+type Principal struct {
+	User   string
+	Groups []string
+	Role   string
+}
+
+// HasRole checks if the role of the principal grants the role, the operator role grants the viewer role
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && roleRanks[p.Role] > 0 && roleRanks[p.Role] >= roleRanks[role]
+}
+
+// Credentials are what a caller presented to authenticate
+type Credentials struct {
+	BearerToken string
+	// VerifiedChains are the certificate chains of the client the TLS handshake verified
+	VerifiedChains [][]*x509.Certificate
+}
+
+// Authenticator returns the principal of the credentials, or nil when the credentials are not of its method
+type Authenticator interface {
+	Authenticate(ctx context.Context, credentials Credentials) (*Principal, error)
+}
+
+// ClientCertAuthenticator authenticates the callers as the common name of their verified client certificate,
+// in the groups of its organizations
+type ClientCertAuthenticator struct{}
+
+func (ClientCertAuthenticator) Authenticate(ctx context.Context, credentials Credentials) (*Principal, error) {
+	if len(credentials.VerifiedChains) == 0 || len(credentials.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	leaf := credentials.VerifiedChains[0][0]
+	if leaf.Subject.CommonName == "" {
+		return nil, fmt.Errorf("client certificate has no common name")
+	}
+	return &Principal{User: leaf.Subject.CommonName, Groups: leaf.Subject.Organization}, nil
+}
+
+type tokenReview struct {
+	principal *Principal
+	expiresAt time.Time
+}
+
+// TokenReviewAuthenticator authenticates the callers with the bearer tokens the kubernetes api server reviews,
+// keeping the reviews for a minute so that every call does not reach the api server
+type TokenReviewAuthenticator struct {
+	client  kubernetes.Interface
+	lock    sync.Mutex
+	reviews map[[sha256.Size]byte]tokenReview
+}
+
+func NewTokenReviewAuthenticator(client kubernetes.Interface) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{client: client, reviews: make(map[[sha256.Size]byte]tokenReview)}
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(ctx context.Context, credentials Credentials) (*Principal, error) {
+	if credentials.BearerToken == "" {
+		return nil, nil
+	}
+	key := sha256.Sum256([]byte(credentials.BearerToken))
+	now := time.Now()
+	a.lock.Lock()
+	review, ok := a.reviews[key]
+	a.lock.Unlock()
+	if ok && now.Before(review.expiresAt) {
+		return review.principal, nil
+	}
+	result, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationV1.TokenReview{
+		Spec: authenticationV1.TokenReviewSpec{Token: credentials.BearerToken},
+	}, metaV1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review bearer token: %v", err)
+	}
+	if !result.Status.Authenticated {
+		return nil, fmt.Errorf("bearer token is not authenticated: %s", result.Status.Error)
+	}
+	principal := &Principal{User: result.Status.User.Username, Groups: result.Status.User.Groups}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for k, r := range a.reviews {
+		if now.After(r.expiresAt) {
+			delete(a.reviews, k)
+		}
+	}
+	a.reviews[key] = tokenReview{principal: principal, expiresAt: now.Add(tokenReviewCacheTTL)}
+	return principal, nil
+}
+
+// Auth authenticates the callers with the first of its authenticators their credentials are for, and binds
+// the roles to them. A nil Auth authenticates nothing, every caller is let through
+type Auth struct {
+	authenticators []Authenticator
+	roleBindings   map[string]string
+}
+
+// New returns the Auth of the methods, with the roles bound to the subjects, which are either user:<name> or
+// group:<name>. It returns nil, which lets every caller through, when no method is set
+func New(methods []string, roleBindings map[string]string, client kubernetes.Interface) (*Auth, error) {
+	if len(methods) == 0 {
+		return nil, nil
+	}
+	a := &Auth{roleBindings: make(map[string]string)}
+	for _, method := range methods {
+		switch strings.TrimSpace(method) {
+		case MethodClientCert:
+			a.authenticators = append(a.authenticators, ClientCertAuthenticator{})
+		case MethodTokenReview:
+			if client == nil {
+				return nil, fmt.Errorf("auth method %s requires a kubernetes client", MethodTokenReview)
+			}
+			a.authenticators = append(a.authenticators, NewTokenReviewAuthenticator(client))
+		default:
+			return nil, fmt.Errorf("unsupported auth method %q, supported: %s, %s", method, MethodClientCert, MethodTokenReview)
+		}
+	}
+	for subject, role := range roleBindings {
+		if !strings.HasPrefix(subject, "user:") && !strings.HasPrefix(subject, "group:") {
+			return nil, fmt.Errorf("role binding subject %s is neither user:<name> nor group:<name>", subject)
+		}
+		if _, ok := roleRanks[role]; !ok {
+			return nil, fmt.Errorf("role %s of subject %s is neither %s nor %s", role, subject, RoleViewer, RoleOperator)
+		}
+		a.roleBindings[subject] = role
+	}
+	return a, nil
+}
+
+// NewWithAuthenticators returns the Auth of the authenticators with the roles bound to the subjects
+func NewWithAuthenticators(authenticators []Authenticator, roleBindings map[string]string) *Auth {
+	return &Auth{authenticators: authenticators, roleBindings: roleBindings}
+}
+
+// Authenticate returns the principal of the credentials along with the highest role bound to its user or its groups
+func (a *Auth) Authenticate(ctx context.Context, credentials Credentials) (*Principal, error) {
+	var errs []string
+	for _, authenticator := range a.authenticators {
+		principal, err := authenticator.Authenticate(ctx, credentials)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if principal == nil {
+			continue
+		}
+		authenticated := *principal
+		authenticated.Role = a.roleOf(&authenticated)
+		return &authenticated, nil
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("authentication failed: %s", strings.Join(errs, "; "))
+	}
+	return nil, fmt.Errorf("no credentials presented")
+}
+
+func (a *Auth) roleOf(principal *Principal) string {
+	role := a.roleBindings["user:"+principal.User]
+	for _, group := range principal.Groups {
+		if groupRole := a.roleBindings["group:"+group]; roleRanks[groupRole] > roleRanks[role] {
+			role = groupRole
+		}
+	}
+	return role
+}
+
+// WithPrincipal returns a copy of the context carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal the context carries, nil when it carries none
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationV1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name         string
+		methods      []string
+		roleBindings map[string]string
+		expectedNil  bool
+		expectedErr  bool
+	}{
+		{
+			name:        "Given no method, When New is called, Then it should return nil to let every caller through",
+			expectedNil: true,
+		},
+		{
+			name:         "Given supported methods and valid role bindings, When New is called, Then it should return the auth",
+			methods:      []string{MethodClientCert, MethodTokenReview},
+			roleBindings: map[string]string{"user:alice": RoleOperator, "group:sre": RoleViewer},
+		},
+		{
+			name:        "Given an unsupported method, When New is called, Then it should return an error",
+			methods:     []string{"basic"},
+			expectedErr: true,
+		},
+		{
+			name:         "Given a role binding of an unknown subject kind, When New is called, Then it should return an error",
+			methods:      []string{MethodClientCert},
+			roleBindings: map[string]string{"alice": RoleOperator},
+			expectedErr:  true,
+		},
+		{
+			name:         "Given a role binding of an unknown role, When New is called, Then it should return an error",
+			methods:      []string{MethodClientCert},
+			roleBindings: map[string]string{"user:alice": "admin"},
+			expectedErr:  true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			a, err := New(c.methods, c.roleBindings, fake.NewSimpleClientset())
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectedNil, a == nil)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	reviews := 0
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationV1.TokenReview)
+		if review.Spec.Token != "valid" {
+			review.Status = authenticationV1.TokenReviewStatus{Error: "invalid token"}
+			return true, review, nil
+		}
+		review.Status = authenticationV1.TokenReviewStatus{
+			Authenticated: true,
+			User:          authenticationV1.UserInfo{Username: "system:serviceaccount:tools:debugger", Groups: []string{"system:serviceaccounts"}},
+		}
+		return true, review, nil
+	})
+	a, err := New([]string{MethodClientCert, MethodTokenReview},
+		map[string]string{"user:alice": RoleViewer, "group:sre": RoleOperator, "group:system:serviceaccounts": RoleViewer}, client)
+	assert.Nil(t, err)
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "alice", Organization: []string{"sre"}}}
+
+	testCases := []struct {
+		name         string
+		credentials  Credentials
+		expectedUser string
+		expectedRole string
+		expectedErr  bool
+	}{
+		{
+			name:         "Given a verified client certificate, Then the caller is its common name with the highest role of its user and groups",
+			credentials:  Credentials{VerifiedChains: [][]*x509.Certificate{{certificate}}},
+			expectedUser: "alice",
+			expectedRole: RoleOperator,
+		},
+		{
+			name:         "Given a bearer token the api server authenticates, Then the caller is the user of the review",
+			credentials:  Credentials{BearerToken: "valid"},
+			expectedUser: "system:serviceaccount:tools:debugger",
+			expectedRole: RoleViewer,
+		},
+		{
+			name:        "Given a bearer token the api server does not authenticate, Then it should return an error",
+			credentials: Credentials{BearerToken: "invalid"},
+			expectedErr: true,
+		},
+		{
+			name:        "Given no credentials, Then it should return an error",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			principal, err := a.Authenticate(context.Background(), c.credentials)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectedUser, principal.User)
+			assert.Equal(t, c.expectedRole, principal.Role)
+		})
+	}
+
+	// the review of the valid token is reused
+	reviewsBefore := reviews
+	_, err = a.Authenticate(context.Background(), Credentials{BearerToken: "valid"})
+	assert.Nil(t, err)
+	assert.Equal(t, reviewsBefore, reviews)
+}
+
+func TestHasRole(t *testing.T) {
+	assert.True(t, (&Principal{Role: RoleOperator}).HasRole(RoleViewer))
+	assert.True(t, (&Principal{Role: RoleViewer}).HasRole(RoleViewer))
+	assert.False(t, (&Principal{Role: RoleViewer}).HasRole(RoleOperator))
+	assert.False(t, (&Principal{}).HasRole(RoleViewer))
+	assert.False(t, (*Principal)(nil).HasRole(RoleViewer))
+}
@@ -6,6 +6,7 @@ import (
 	admiral "github.com/istio-ecosystem/admiral/admiral/pkg/client/clientset/versioned"
 	numaflow "github.com/numaproj/numaflow/pkg/client/clientset/versioned"
 	istio "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -30,4 +31,7 @@ type ClientLoader interface {
 
 	LoadNumaflowClientFromPath(path string) (numaflow.Interface, error)
 	LoadNumaflowClientFromConfig(config *rest.Config) (numaflow.Interface, error)
+
+	LoadDynamicClientFromPath(path string) (dynamic.Interface, error)
+	LoadDynamicClientFromConfig(config *rest.Config) (dynamic.Interface, error)
 }
@@ -11,6 +11,9 @@ import (
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
 	numaflow "github.com/numaproj/numaflow/pkg/client/clientset/versioned"
 	numaflowfake "github.com/numaproj/numaflow/pkg/client/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -25,6 +28,7 @@ var FakeIstioClient istio.Interface = istiofake.NewSimpleClientset()
 var FakeKubeClient kubernetes.Interface = kubefake.NewSimpleClientset()
 var FakeArgoClient argo.Interface = argofake.NewSimpleClientset()
 var FakeNumaflowClient numaflow.Interface = numaflowfake.NewSimpleClientset()
+var FakeDynamicClient dynamic.Interface = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
 
 // fake clients for dependent clusters
 var FakeAdmiralClientMap map[string]admiral.Interface = make(map[string]admiral.Interface)
@@ -33,6 +37,7 @@ var FakeIstioClientMap map[string]istio.Interface = make(map[string]istio.Interf
 var FakeKubeClientMap map[string]kubernetes.Interface = make(map[string]kubernetes.Interface)
 var FakeArgoClientMap map[string]argo.Interface = make(map[string]argo.Interface)
 var FakeNumaflowClientMap map[string]numaflow.Interface = make(map[string]numaflow.Interface)
+var FakeDynamicClientMap map[string]dynamic.Interface = make(map[string]dynamic.Interface)
 
 type FakeClientLoader struct{}
 
@@ -120,3 +125,16 @@ func (loader *FakeClientLoader) LoadKubeClientFromConfig(config *rest.Config) (k
 	}
 	return kubeClient, nil
 }
+
+func (loader *FakeClientLoader) LoadDynamicClientFromPath(path string) (dynamic.Interface, error) {
+	return FakeDynamicClient, nil
+}
+
+func (loader *FakeClientLoader) LoadDynamicClientFromConfig(config *rest.Config) (dynamic.Interface, error) {
+	dynamicClient, ok := FakeDynamicClientMap[config.Host]
+	if !ok {
+		dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+		FakeDynamicClientMap[config.Host] = dynamicClient
+	}
+	return dynamicClient, nil
+}
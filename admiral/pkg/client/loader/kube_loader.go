@@ -8,6 +8,7 @@ import (
 	numaflow "github.com/numaproj/numaflow/pkg/client/clientset/versioned"
 	log "github.com/sirupsen/logrus"
 	istio "istio.io/client-go/pkg/clientset/versioned"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -99,6 +100,19 @@ func (loader *KubeClientLoader) LoadNumaflowClientFromConfig(config *rest.Config
 	return numaflow.NewForConfig(config)
 }
 
+func (loader *KubeClientLoader) LoadDynamicClientFromPath(kubeConfigPath string) (dynamic.Interface, error) {
+	config, err := getConfig(kubeConfigPath)
+	if err != nil || config == nil {
+		return nil, err
+	}
+
+	return loader.LoadDynamicClientFromConfig(config)
+}
+
+func (loader *KubeClientLoader) LoadDynamicClientFromConfig(config *rest.Config) (dynamic.Interface, error) {
+	return dynamic.NewForConfig(config)
+}
+
 func getConfig(kubeConfigPath string) (*rest.Config, error) {
 	log.Infof("getting kubeconfig from: %#v", kubeConfigPath)
 	// create the config from the path
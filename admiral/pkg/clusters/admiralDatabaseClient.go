@@ -227,6 +227,10 @@ func ReadAndUpdateSyncAdmiralConfig(rr *RemoteRegistry) error {
 		ctx := context.Background()
 		//Process NLB Cluster
 		processLBMigration(ctx, rr, common.GetAdmiralParams().NLBEnabledClusters, &rr.AdmiralCache.NLBEnabledCluster, common.GetAdmiralParams().NLBIngressLabel)
+		//Process CLB Cluster
+		processLBMigration(ctx, rr, common.GetAdmiralParams().CLBEnabledClusters, &rr.AdmiralCache.CLBEnabledCluster, common.GetAdmiralParams().LabelSet.GatewayApp)
+		//Process ClusterSyncNamespaces
+		migrateChangedSyncNamespaces(ctx, rr)
 		// Process InitiateClientInitiatedProcessingFor
 		var c ClientDependencyRecordProcessor
 		err := triggerClientInitiatedProcessing(ctx, c, rr, common.GetInitiateClientInitiatedProcessingFor())
@@ -247,7 +251,7 @@ func triggerClientInitiatedProcessing(ctx context.Context, c ProcessClientDepend
 
 	txId := uuid.NewString()
 	ctxLogger := log.WithField("task", common.ClientInitiatedProcessing)
-	ctx = context.WithValue(ctx, "txId", txId)
+	ctx = common.WithTxId(ctx, txId)
 	bypassPrefix := "bypass:"
 
 	var processedGlobalIdentifiers []string
@@ -402,6 +406,25 @@ func UpdateSyncAdmiralConfig(configData DynamicConfigData) {
 		if len(configData.InitiateClientInitiatedProcessingFor) > 0 {
 			newAdmiralConfig.InitiateClientInitiatedProcessingFor = configData.InitiateClientInitiatedProcessingFor
 		}
+
+		// Namespace filters are replaced whenever present, so that they can be cleared with an empty map
+		if configData.NamespaceAllowList != nil {
+			newAdmiralConfig.NamespaceAllowList = configData.NamespaceAllowList
+		}
+
+		if configData.NamespaceDenyList != nil {
+			newAdmiralConfig.NamespaceDenyList = configData.NamespaceDenyList
+		}
+
+		if configData.NamespaceDenySelectors != nil {
+			newAdmiralConfig.NamespaceDenySelectors = configData.NamespaceDenySelectors
+		}
+
+		// The sync namespaces of the clusters are replaced whenever present, the resources are then
+		// moved by migrateChangedSyncNamespaces
+		if configData.ClusterSyncNamespaces != nil {
+			newAdmiralConfig.ClusterSyncNamespaces = configData.ClusterSyncNamespaces
+		}
 		common.UpdateAdmiralParams(newAdmiralConfig)
 	}
 }
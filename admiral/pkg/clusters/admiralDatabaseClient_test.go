@@ -482,11 +482,15 @@ func TestUpdateSyncAdmiralConfig(t *testing.T) {
 	configUpdated.NLBEnabledClusters = []string{"cluster1", "cluster2"}
 	configUpdated.NLBEnabledIdentityList = []string{"identity1", "identity2"}
 	configUpdated.CLBEnabledClusters = []string{"cluster1", "cluster2"}
+	configUpdated.NamespaceDenyList = map[string]string{"*": "test-.*"}
+	configUpdated.ClusterSyncNamespaces = map[string]string{"cluster1": "mesh-sync"}
 
 	expectedAdmiralConfig := common.GetAdmiralParams()
 	expectedAdmiralConfig.NLBEnabledClusters = []string{"cluster1", "cluster2"}
 	expectedAdmiralConfig.CLBEnabledClusters = []string{"cluster1", "cluster2"}
 	expectedAdmiralConfig.NLBEnabledIdentityList = []string{"identity1", "identity2"}
+	expectedAdmiralConfig.NamespaceDenyList = map[string]string{"*": "test-.*"}
+	expectedAdmiralConfig.ClusterSyncNamespaces = map[string]string{"cluster1": "mesh-sync"}
 
 	emptyConfig := DynamicConfigData{}
 	expectedEmptyConfig := common.GetAdmiralParams()
@@ -0,0 +1,101 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// auditMutation records a create, update or delete admiral performed against the cluster
+// along with the transaction and the event which triggered it. Mutations are also counted
+// per cohort of the cluster, so that features rolled out to a cohort can be compared with the others,
+// and per team of the identity the resource belongs to
+func auditMutation(ctx context.Context, operation string, kind common.ResourceType, clusterID string,
+	obj metaV1.Object, namespace, diff string, err error) {
+	record := audit.Record{
+		Operation: operation,
+		Kind:      string(kind),
+		Name:      obj.GetName(),
+		Namespace: namespace,
+		Cluster:   clusterID,
+		Identity:  getOwnerIdentity(obj),
+		Diff:      diff,
+		TxId:      common.GetTxId(ctx),
+	}
+	if eventType := ctx.Value(common.EventType); eventType != nil {
+		record.TriggeringEvent = fmt.Sprint(eventType)
+	}
+	if eventResourceType, ok := ctx.Value(common.EventResourceType).(string); ok {
+		record.TriggeringKind = eventResourceType
+	}
+	result := "success"
+	if err != nil {
+		record.Error = err.Error()
+		result = "failure"
+	}
+	cohortMutations.Increment(api.WithAttributes(
+		attribute.Key("cohort").String(common.GetClusterCohort(clusterID)),
+		attribute.Key("operation").String(operation),
+		attribute.Key("resourceType").String(string(kind)),
+		attribute.Key("result").String(result),
+	))
+	teamMutations.Increment(api.WithAttributes(
+		attribute.Key("team").String(GetIdentityTeam(record.Identity)),
+		attribute.Key("operation").String(operation),
+		attribute.Key("resourceType").String(string(kind)),
+		attribute.Key("result").String(result),
+	))
+	audit.Log(record)
+}
+
+// summarizeChanges returns the comma separated parts of the resource which differ between the
+// existing and the new version, or an empty string when nothing changed
+func summarizeChanges(exist, new metaV1.Object, existSpec, newSpec proto.Message) string {
+	var changes []string
+	if !reflect.DeepEqual(exist.GetLabels(), new.GetLabels()) {
+		changes = append(changes, "labels")
+	}
+	if !reflect.DeepEqual(withoutTxId(exist.GetAnnotations()), withoutTxId(new.GetAnnotations())) {
+		changes = append(changes, "annotations")
+	}
+	if !proto.Equal(existSpec, newSpec) {
+		changes = append(changes, "spec")
+	}
+	return strings.Join(changes, ",")
+}
+
+// stampTxId records the transaction id of the event which triggered the write on the resource
+func stampTxId(ctx context.Context, obj metaV1.Object) {
+	txId := common.GetTxId(ctx)
+	if txId == "" {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[common.AdmiralTxIdAnnotation] = txId
+	obj.SetAnnotations(annotations)
+}
+
+// withoutTxId returns the annotations without the transaction id, which differs on every write
+func withoutTxId(annotations map[string]string) map[string]string {
+	if _, ok := annotations[common.AdmiralTxIdAnnotation]; !ok {
+		return annotations
+	}
+	filtered := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k != common.AdmiralTxIdAnnotation {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
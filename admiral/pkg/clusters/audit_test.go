@@ -0,0 +1,101 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSummarizeChanges(t *testing.T) {
+	newDR := func(labels map[string]string, host string) *v1alpha3.DestinationRule {
+		return &v1alpha3.DestinationRule{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr", Labels: labels},
+			Spec:       networkingV1Alpha3.DestinationRule{Host: host},
+		}
+	}
+	testCases := []struct {
+		name            string
+		exist           *v1alpha3.DestinationRule
+		new             *v1alpha3.DestinationRule
+		expectedSummary string
+	}{
+		{
+			name: "Given an unchanged DR, " +
+				"When summarizeChanges is called, " +
+				"Then it should return an empty summary",
+			exist:           newDR(map[string]string{"identity": "foo"}, "foo.global"),
+			new:             newDR(map[string]string{"identity": "foo"}, "foo.global"),
+			expectedSummary: "",
+		},
+		{
+			name: "Given a DR with changed labels and spec, " +
+				"When summarizeChanges is called, " +
+				"Then it should return both of them",
+			exist:           newDR(map[string]string{"identity": "foo"}, "foo.global"),
+			new:             newDR(map[string]string{"identity": "bar"}, "bar.global"),
+			expectedSummary: "labels,spec",
+		},
+		{
+			name: "Given a DR written by another transaction, " +
+				"When summarizeChanges is called, " +
+				"Then the transaction id annotation should not be reported as a change",
+			exist: &v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{
+				Annotations: map[string]string{common.AdmiralTxIdAnnotation: "tx-1", "app.kubernetes.io/created-by": "admiral"},
+			}},
+			new: &v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{
+				Annotations: map[string]string{common.AdmiralTxIdAnnotation: "tx-2", "app.kubernetes.io/created-by": "admiral"},
+			}},
+			expectedSummary: "",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedSummary, summarizeChanges(c.exist, c.new, &c.exist.Spec, &c.new.Spec))
+		})
+	}
+}
+
+func TestStampTxId(t *testing.T) {
+	testCases := []struct {
+		name                string
+		ctx                 context.Context
+		annotations         map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "Given a context carrying a transaction id, " +
+				"When stampTxId is called, " +
+				"Then the transaction id should be added to the annotations",
+			ctx:                 common.WithTxId(context.Background(), "tx-1"),
+			annotations:         map[string]string{"app.kubernetes.io/created-by": "admiral"},
+			expectedAnnotations: map[string]string{"app.kubernetes.io/created-by": "admiral", common.AdmiralTxIdAnnotation: "tx-1"},
+		},
+		{
+			name: "Given a resource without annotations, " +
+				"When stampTxId is called, " +
+				"Then the annotations should be created",
+			ctx:                 common.WithTxId(context.Background(), "tx-1"),
+			expectedAnnotations: map[string]string{common.AdmiralTxIdAnnotation: "tx-1"},
+		},
+		{
+			name: "Given a context without a transaction id, " +
+				"When stampTxId is called, " +
+				"Then the annotations should not be changed",
+			ctx:                 context.Background(),
+			annotations:         map[string]string{"app.kubernetes.io/created-by": "admiral"},
+			expectedAnnotations: map[string]string{"app.kubernetes.io/created-by": "admiral"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			se := &v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Annotations: c.annotations}}
+			stampTxId(c.ctx, se)
+			assert.Equal(t, c.expectedAnnotations, se.Annotations)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupArchiveVersion is the version of the format of the backup archives, archives of other versions are not restored
+const BackupArchiveVersion = "v1"
+
+// BackupArchive holds the resources admiral generated in the sync namespace of each cluster
+type BackupArchive struct {
+	Version   string                   `json:"version"`
+	CreatedAt time.Time                `json:"createdAt"`
+	Clusters  map[string]ClusterBackup `json:"clusters"`
+}
+
+// ClusterBackup holds the resources admiral generated in the sync namespace of a cluster
+type ClusterBackup struct {
+	ServiceEntries   []*v1alpha3.ServiceEntry    `json:"serviceEntries"`
+	DestinationRules []*v1alpha3.DestinationRule `json:"destinationRules"`
+	VirtualServices  []*v1alpha3.VirtualService  `json:"virtualServices"`
+}
+
+// RestoreRequest selects the cluster of the archive restored and the cluster it is restored to, which may be
+// a rebuilt cluster under another name. The resources already present are only replaced when overwrite is set
+type RestoreRequest struct {
+	SourceCluster string
+	TargetCluster string
+	Overwrite     bool
+}
+
+// RestoreResult counts the resources restored, skipped and deleted, and lists the ones which failed by kind/name
+type RestoreResult struct {
+	Restored int               `json:"restored"`
+	Skipped  int               `json:"skipped"`
+	Deleted  int               `json:"deleted,omitempty"`
+	Failed   map[string]string `json:"failed,omitempty"`
+}
+
+// ExportBackup returns the resources admiral generated in the sync namespace of the cluster, or of all the
+// clusters when empty. The server set metadata is left out so the resources can be created again
+func ExportBackup(ctx context.Context, rr *RemoteRegistry, cluster string) (BackupArchive, error) {
+	archive := BackupArchive{Version: BackupArchiveVersion, CreatedAt: time.Now().UTC(), Clusters: make(map[string]ClusterBackup)}
+	if rr == nil {
+		return archive, nil
+	}
+	clusters := rr.GetClusterIds()
+	if cluster != "" {
+		if rr.GetRemoteController(cluster) == nil {
+			return archive, fmt.Errorf("admiral is not monitoring cluster %s", cluster)
+		}
+		clusters = []string{cluster}
+	}
+	sort.Strings(clusters)
+	for _, clusterID := range clusters {
+		backup, err := exportClusterBackup(ctx, rr.GetRemoteController(clusterID))
+		if err != nil {
+			return archive, fmt.Errorf("failed to export cluster %s: %v", clusterID, err)
+		}
+		archive.Clusters[clusterID] = backup
+	}
+	return archive, nil
+}
+
+func exportClusterBackup(ctx context.Context, rc *RemoteController) (ClusterBackup, error) {
+	backup := ClusterBackup{
+		ServiceEntries:   make([]*v1alpha3.ServiceEntry, 0),
+		DestinationRules: make([]*v1alpha3.DestinationRule, 0),
+		VirtualServices:  make([]*v1alpha3.VirtualService, 0),
+	}
+	if rc == nil {
+		return backup, fmt.Errorf("remote controller not initialized")
+	}
+	namespace := common.GetSyncNamespaceForCluster(rc.ClusterID)
+	if rc.ServiceEntryController != nil && rc.ServiceEntryController.IstioClient != nil {
+		seList, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(ctx, metaV1.ListOptions{})
+		if err != nil {
+			return backup, err
+		}
+		for _, se := range seList.Items {
+			if isGeneratedByAdmiral(se.Annotations) {
+				se = se.DeepCopy()
+				clearServerMetadata(&se.ObjectMeta)
+				backup.ServiceEntries = append(backup.ServiceEntries, se)
+			}
+		}
+	}
+	if rc.DestinationRuleController != nil && rc.DestinationRuleController.IstioClient != nil {
+		drList, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).List(ctx, metaV1.ListOptions{})
+		if err != nil {
+			return backup, err
+		}
+		for _, dr := range drList.Items {
+			if isGeneratedByAdmiral(dr.Annotations) {
+				dr = dr.DeepCopy()
+				clearServerMetadata(&dr.ObjectMeta)
+				backup.DestinationRules = append(backup.DestinationRules, dr)
+			}
+		}
+	}
+	if rc.VirtualServiceController != nil && rc.VirtualServiceController.IstioClient != nil {
+		vsList, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+		if err != nil {
+			return backup, err
+		}
+		for _, vs := range vsList.Items {
+			if isGeneratedByAdmiral(vs.Annotations) {
+				vs = vs.DeepCopy()
+				clearServerMetadata(&vs.ObjectMeta)
+				backup.VirtualServices = append(backup.VirtualServices, vs)
+			}
+		}
+	}
+	return backup, nil
+}
+
+// clearServerMetadata drops the metadata set by the api server of the cluster the resource was exported from
+func clearServerMetadata(meta *metaV1.ObjectMeta) {
+	meta.ResourceVersion = ""
+	meta.UID = ""
+	meta.Generation = 0
+	meta.CreationTimestamp = metaV1.Time{}
+	meta.ManagedFields = nil
+	meta.OwnerReferences = nil
+}
+
+// RestoreBackup writes the resources of the source cluster of the archive to the sync namespace of the target
+// cluster, through the same path as the generated resources, so paused clusters, validation and ownership apply
+func RestoreBackup(ctx context.Context, rr *RemoteRegistry, archive BackupArchive, request RestoreRequest) (RestoreResult, error) {
+	result := RestoreResult{}
+	if commonUtil.IsAdmiralReadOnly() {
+		return result, fmt.Errorf("backups are not restored as Admiral is in Read-only mode")
+	}
+	if archive.Version != BackupArchiveVersion {
+		return result, fmt.Errorf("unsupported backup archive version %q, expected %q", archive.Version, BackupArchiveVersion)
+	}
+	if request.SourceCluster == "" {
+		request.SourceCluster = request.TargetCluster
+	}
+	backup, ok := archive.Clusters[request.SourceCluster]
+	if !ok {
+		return result, fmt.Errorf("backup archive has no cluster %s", request.SourceCluster)
+	}
+	if rr == nil || rr.GetRemoteController(request.TargetCluster) == nil {
+		return result, fmt.Errorf("admiral is not monitoring cluster %s", request.TargetCluster)
+	}
+	rc := rr.GetRemoteController(request.TargetCluster)
+	if rc.ServiceEntryController == nil || rc.DestinationRuleController == nil || rc.VirtualServiceController == nil {
+		return result, fmt.Errorf("istio controllers not initialized for cluster %s", request.TargetCluster)
+	}
+	var (
+		namespace = common.GetSyncNamespaceForCluster(request.TargetCluster)
+		ctxLogger = log.WithFields(log.Fields{"op": "RestoreBackup", "cluster": request.TargetCluster})
+	)
+	restoreClusterBackup(ctxLogger, ctx, rr, rc, backup, namespace, request.Overwrite, &result)
+	ctxLogger.Infof(LogFormat, "RestoreBackup", "", "", request.TargetCluster,
+		fmt.Sprintf("restored=%d skipped=%d failed=%d from cluster=%s", result.Restored, result.Skipped, len(result.Failed), request.SourceCluster))
+	return result, nil
+}
+
+// restoreClusterBackup writes the resources of the backup to the cluster, in the given namespace or in their own
+// namespace when empty, and counts them in the result. The resources already present are only replaced on overwrite
+func restoreClusterBackup(ctxLogger *log.Entry, ctx context.Context, rr *RemoteRegistry, rc *RemoteController,
+	backup ClusterBackup, namespace string, overwrite bool, result *RestoreResult) {
+	record := func(kind common.ResourceType, name string, restored bool, err error) {
+		switch {
+		case err != nil:
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[string(kind)+"/"+name] = err.Error()
+		case restored:
+			result.Restored++
+		default:
+			result.Skipped++
+		}
+	}
+	targetNamespace := func(obj metaV1.Object) string {
+		if namespace != "" {
+			return namespace
+		}
+		return obj.GetNamespace()
+	}
+	for _, se := range backup.ServiceEntries {
+		seNamespace := targetNamespace(se)
+		exist, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(seNamespace).Get(ctx, se.Name, metaV1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			exist, err = nil, nil
+		}
+		if err != nil || (exist != nil && !overwrite) {
+			record(common.ServiceEntryResourceType, se.Name, false, err)
+			continue
+		}
+		restored := se.DeepCopy()
+		restored.Namespace = seNamespace
+		record(common.ServiceEntryResourceType, se.Name, true, addUpdateServiceEntry(ctxLogger, ctx, restored, exist, seNamespace, rc))
+	}
+	for _, dr := range backup.DestinationRules {
+		drNamespace := targetNamespace(dr)
+		exist, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(drNamespace).Get(ctx, dr.Name, metaV1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			exist, err = nil, nil
+		}
+		if err != nil || (exist != nil && !overwrite) {
+			record(common.DestinationRuleResourceType, dr.Name, false, err)
+			continue
+		}
+		restored := dr.DeepCopy()
+		restored.Namespace = drNamespace
+		record(common.DestinationRuleResourceType, dr.Name, true, addUpdateDestinationRule(ctxLogger, ctx, restored, exist, drNamespace, rc, rr))
+	}
+	for _, vs := range backup.VirtualServices {
+		vsNamespace := targetNamespace(vs)
+		exist, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(vsNamespace).Get(ctx, vs.Name, metaV1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			exist, err = nil, nil
+		}
+		if err != nil || (exist != nil && !overwrite) {
+			record(common.VirtualServiceResourceType, vs.Name, false, err)
+			continue
+		}
+		restored := vs.DeepCopy()
+		restored.Namespace = vsNamespace
+		record(common.VirtualServiceResourceType, vs.Name, true, addUpdateVirtualService(ctxLogger, ctx, restored, exist, vsNamespace, rc, rr))
+	}
+}
@@ -0,0 +1,94 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExportAndRestoreBackup(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns"})
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+	ctx := context.Background()
+	generated := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	sourceClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se", Namespace: "ns", Annotations: generated, ResourceVersion: "42"},
+			Spec: networkingV1Alpha3.ServiceEntry{
+				Hosts:     []string{"foo.global"},
+				Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "internal-lb.com"}},
+			},
+		},
+		&v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "custom.global-se", Namespace: "ns"},
+			Spec:       networkingV1Alpha3.ServiceEntry{Hosts: []string{"custom.global"}},
+		},
+		&v1alpha3.DestinationRule{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr", Namespace: "ns", Annotations: generated},
+			Spec:       networkingV1Alpha3.DestinationRule{Host: "foo.global"},
+		},
+	)
+	targetClient := istioFake.NewSimpleClientset()
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	for cluster, client := range map[string]*istioFake.Clientset{"cluster1": sourceClient, "cluster2": targetClient} {
+		rr.PutRemoteController(cluster, &RemoteController{
+			ClusterID:                 cluster,
+			ServiceEntryController:    &istio.ServiceEntryController{IstioClient: client},
+			DestinationRuleController: &istio.DestinationRuleController{IstioClient: client},
+			VirtualServiceController:  &istio.VirtualServiceController{IstioClient: client},
+		})
+	}
+
+	// only the generated resources are exported, without their server set metadata
+	archive, err := ExportBackup(ctx, rr, "cluster1")
+	assert.Nil(t, err)
+	assert.Equal(t, BackupArchiveVersion, archive.Version)
+	assert.Len(t, archive.Clusters["cluster1"].ServiceEntries, 1)
+	assert.Empty(t, archive.Clusters["cluster1"].ServiceEntries[0].ResourceVersion)
+	assert.Len(t, archive.Clusters["cluster1"].DestinationRules, 1)
+	assert.Empty(t, archive.Clusters["cluster1"].VirtualServices)
+
+	// the archive survives a round trip through its JSON form
+	encoded, err := json.Marshal(archive)
+	assert.Nil(t, err)
+	var decoded BackupArchive
+	assert.Nil(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, []string{"foo.global"}, decoded.Clusters["cluster1"].ServiceEntries[0].Spec.Hosts)
+
+	// the resources are restored to the rebuilt cluster
+	result, err := RestoreBackup(ctx, rr, decoded, RestoreRequest{SourceCluster: "cluster1", TargetCluster: "cluster2"})
+	assert.Nil(t, err)
+	assert.Equal(t, RestoreResult{Restored: 2}, result)
+	restored, err := targetClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, "foo.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "internal-lb.com", restored.Spec.Endpoints[0].Address)
+	_, err = targetClient.NetworkingV1alpha3().DestinationRules("ns").Get(ctx, "foo.global-default-dr", metaV1.GetOptions{})
+	assert.Nil(t, err)
+
+	// the resources present are kept unless overwritten
+	result, err = RestoreBackup(ctx, rr, decoded, RestoreRequest{SourceCluster: "cluster1", TargetCluster: "cluster2"})
+	assert.Nil(t, err)
+	assert.Equal(t, RestoreResult{Skipped: 2}, result)
+
+	// nothing is restored in read-only mode
+	commonUtil.CurrentAdmiralState.ReadOnly = true
+	_, err = RestoreBackup(ctx, rr, decoded, RestoreRequest{SourceCluster: "cluster1", TargetCluster: "cluster2", Overwrite: true})
+	assert.NotNil(t, err)
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+
+	// archives of another version, or without the source cluster, are refused
+	_, err = RestoreBackup(ctx, rr, BackupArchive{Version: "v0"}, RestoreRequest{TargetCluster: "cluster2"})
+	assert.NotNil(t, err)
+	_, err = RestoreBackup(ctx, rr, decoded, RestoreRequest{TargetCluster: "cluster2"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,84 @@
+package clusters
+
+import (
+	"context"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isBlueGreenPreviewPromoted checks if the blue-green rollout promoted the replica set its preview
+// service was serving, after which the preview and the active service select the same replica set
+func isBlueGreenPreviewPromoted(rollout *argo.Rollout) bool {
+	if !isBlueGreenStrategy(rollout) {
+		return false
+	}
+	status := rollout.Status.BlueGreen
+	return status.ActiveSelector != "" && status.PreviewSelector == status.ActiveSelector
+}
+
+// isBlueGreenPreviewPromotedInAllClusters checks if the blue-green rollouts of the identity promoted
+// their preview replica set in every cluster, as the preview host is shared by all the clusters
+func isBlueGreenPreviewPromotedInAllClusters(rollouts map[string]*argo.Rollout) bool {
+	if len(rollouts) == 0 {
+		return false
+	}
+	for _, rollout := range rollouts {
+		if !isBlueGreenPreviewPromoted(rollout) {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteBlueGreenPreviewResources deletes the ServiceEntry and DestinationRule admiral generated for the
+// preview host of the rollout from the clusters running the rollout and the clusters of its dependents.
+// The preview host is forgotten once it is deleted from every cluster, so that it is only torn down once
+// after a promotion and generated again for the next preview
+func deleteBlueGreenPreviewResources(ctx context.Context, ctxLogger *log.Entry, rr *RemoteRegistry, rollout *argo.Rollout) error {
+	previewFqdn, err := getPreviewFQDNFromRollout(rollout)
+	if err != nil {
+		return err
+	}
+	sourceClusters := rr.AdmiralCache.CnameClusterCache.Get(previewFqdn)
+	if sourceClusters == nil {
+		return nil
+	}
+	clusters := sourceClusters.GetValues()
+	cname := common.GetCnameForRollout(rollout, common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
+	if dependentClusters := rr.AdmiralCache.CnameDependentClusterCache.Get(cname); dependentClusters != nil {
+		clusters = append(clusters, dependentClusters.GetValues()...)
+	}
+	var deleteErr error
+	for _, clusterID := range clusters {
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil || rc.ServiceEntryController == nil || rc.DestinationRuleController == nil {
+			continue
+		}
+		syncNamespace := common.GetSyncNamespaceForCluster(clusterID)
+		se, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(syncNamespace).Get(ctx, getIstioResourceName(previewFqdn, "-se"), metaV1.GetOptions{})
+		if err == nil && isGeneratedByAdmiral(se.Annotations) {
+			err = deleteServiceEntry(ctx, se, syncNamespace, rc)
+		}
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			deleteErr = common.AppendError(deleteErr, err)
+		}
+		dr, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Get(ctx, getIstioResourceName(previewFqdn, "-default-dr"), metaV1.GetOptions{})
+		if err == nil && isGeneratedByAdmiral(dr.Annotations) {
+			err = deleteDestinationRule(ctx, dr, syncNamespace, rc)
+		}
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			deleteErr = common.AppendError(deleteErr, err)
+		}
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	rr.AdmiralCache.CnameClusterCache.Delete(previewFqdn)
+	rr.AdmiralCache.CnameIdentityCache.Delete(previewFqdn)
+	ctxLogger.Infof(common.CtxLogFormat, "DeleteBlueGreenPreview", rollout.Name, rollout.Namespace, "", "deleted resources of promoted preview host="+previewFqdn)
+	return nil
+}
@@ -0,0 +1,116 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newBlueGreenTestRollout(activeSelector, previewSelector string) *argo.Rollout {
+	rollout := makeTestRollout("foo", "foo-ns", "foo")
+	rollout.Spec.Strategy = argo.RolloutStrategy{
+		BlueGreen: &argo.BlueGreenStrategy{ActiveService: "foo-active", PreviewService: "foo-preview"},
+	}
+	rollout.Status.BlueGreen = argo.BlueGreenStatus{ActiveSelector: activeSelector, PreviewSelector: previewSelector}
+	return &rollout
+}
+
+func TestIsBlueGreenPreviewPromoted(t *testing.T) {
+	canary := makeTestRollout("foo", "foo-ns", "foo")
+	testCases := []struct {
+		name     string
+		rollout  *argo.Rollout
+		expected bool
+	}{
+		{
+			name: "Given a canary rollout, " +
+				"When isBlueGreenPreviewPromoted is called, " +
+				"Then it should return false",
+			rollout: &canary,
+		},
+		{
+			name: "Given a blue-green rollout without status, " +
+				"When isBlueGreenPreviewPromoted is called, " +
+				"Then it should return false",
+			rollout: newBlueGreenTestRollout("", ""),
+		},
+		{
+			name: "Given a blue-green rollout previewing a new replica set, " +
+				"When isBlueGreenPreviewPromoted is called, " +
+				"Then it should return false",
+			rollout: newBlueGreenTestRollout("abc", "def"),
+		},
+		{
+			name: "Given a blue-green rollout which promoted its preview replica set, " +
+				"When isBlueGreenPreviewPromoted is called, " +
+				"Then it should return true",
+			rollout:  newBlueGreenTestRollout("def", "def"),
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isBlueGreenPreviewPromoted(tc.rollout))
+		})
+	}
+}
+
+func TestDeleteBlueGreenPreviewResources(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey:     "identity",
+			AdmiralCRDIdentityLabel: "identity",
+			EnvKey:                  "env",
+		},
+		HostnameSuffix: "global",
+		SyncNamespace:  "admiral-sync",
+	})
+	ctx := context.Background()
+	ctxLogger := log.WithFields(log.Fields{"type": "BlueGreenPreview"})
+	rollout := newBlueGreenTestRollout("def", "def")
+	previewFqdn, err := getPreviewFQDNFromRollout(rollout)
+	assert.Nil(t, err)
+	cname := common.GetCnameForRollout(rollout, common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
+	admiralAnnotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	clients := map[string]*istioFake.Clientset{}
+	for _, cluster := range []string{"cluster1", "cluster2"} {
+		client := istioFake.NewSimpleClientset(
+			&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: previewFqdn + "-se", Namespace: "admiral-sync", Annotations: admiralAnnotations}},
+			&v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{Name: previewFqdn + "-default-dr", Namespace: "admiral-sync"}},
+		)
+		clients[cluster] = client
+		rr.PutRemoteController(cluster, &RemoteController{
+			ClusterID:                 cluster,
+			ServiceEntryController:    &istio.ServiceEntryController{IstioClient: client},
+			DestinationRuleController: &istio.DestinationRuleController{IstioClient: client},
+		})
+	}
+	rr.AdmiralCache.CnameClusterCache.Put(previewFqdn, "cluster1", "cluster1")
+	rr.AdmiralCache.CnameDependentClusterCache.Put(cname, "cluster2", "cluster2")
+	rr.AdmiralCache.CnameIdentityCache.Store(previewFqdn, "foo")
+
+	assert.Nil(t, deleteBlueGreenPreviewResources(ctx, ctxLogger, rr, rollout))
+	for cluster, client := range clients {
+		_, err := client.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, previewFqdn+"-se", metaV1.GetOptions{})
+		assert.NotNil(t, err, "preview SE should be deleted from "+cluster)
+		_, err = client.NetworkingV1alpha3().DestinationRules("admiral-sync").Get(ctx, previewFqdn+"-default-dr", metaV1.GetOptions{})
+		assert.Nil(t, err, "DR not generated by admiral should be kept in "+cluster)
+	}
+	assert.Nil(t, rr.AdmiralCache.CnameClusterCache.Get(previewFqdn))
+	_, ok := rr.AdmiralCache.CnameIdentityCache.Load(previewFqdn)
+	assert.False(t, ok)
+
+	// the preview host is forgotten, so the next call has nothing to tear down
+	assert.Nil(t, deleteBlueGreenPreviewResources(ctx, ctxLogger, rr, rollout))
+}
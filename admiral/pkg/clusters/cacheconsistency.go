@@ -0,0 +1,212 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+const (
+	InconsistencyNoWorkload = "no workload of the identity runs in the cluster"
+	InconsistencyNoService  = "no service in the namespaces of the workloads of the identity in the cluster"
+	InconsistencyNoIdentity = "the identity of the cname is not known"
+)
+
+// CacheInconsistency is an entry of the admiral cache which the informer stores of the cluster do not back
+type CacheInconsistency struct {
+	Cache    string `json:"cache"`
+	Key      string `json:"key"`
+	Cluster  string `json:"cluster"`
+	Reason   string `json:"reason"`
+	Repaired bool   `json:"repaired"`
+}
+
+// CacheConsistencyReport lists the inconsistencies found, along with the clusters which could not be checked
+// as they are unreachable or no longer monitored
+type CacheConsistencyReport struct {
+	CheckedAt       time.Time            `json:"checkedAt"`
+	CacheWarmup     bool                 `json:"cacheWarmup"`
+	Inconsistencies []CacheInconsistency `json:"inconsistencies"`
+	SkippedClusters []string             `json:"skippedClusters,omitempty"`
+}
+
+// startCacheConsistencyChecker periodically cross checks the admiral cache against the informer stores of the
+// clusters once the cache warm up is over, repairing the inconsistencies when configured to
+func startCacheConsistencyChecker(ctx context.Context, rr *RemoteRegistry) {
+	interval := common.GetCacheConsistencyCheckInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if IsCacheWarmupTime(rr) {
+				continue
+			}
+			CheckCacheConsistency(rr, common.IsCacheConsistencyRepairEnabled())
+		}
+	}
+}
+
+// CheckCacheConsistency cross checks the clusters of the identities and of the cnames held by the admiral cache
+// against the workloads and the services of the informer stores of those clusters, and reports every entry they
+// do not back. The clusters of the entries without a workload are dropped from the cache when repair is set, the
+// other inconsistencies are only reported. The clusters which are unreachable or no longer monitored are skipped,
+// as their stores may only be missing the workloads because of the outage
+func CheckCacheConsistency(rr *RemoteRegistry, repair bool) CacheConsistencyReport {
+	report := CacheConsistencyReport{CheckedAt: time.Now(), Inconsistencies: make([]CacheInconsistency, 0)}
+	if rr == nil || rr.AdmiralCache == nil {
+		return report
+	}
+	report.CacheWarmup = IsCacheWarmupTime(rr)
+	cache := rr.AdmiralCache
+	skipped := make(map[string]bool)
+	checkable := func(cluster string) *RemoteController {
+		rc := rr.GetRemoteController(cluster)
+		unreachable := false
+		if cache.UnreachableClusterCache != nil {
+			_, unreachable = cache.UnreachableClusterCache.Load(cluster)
+		}
+		if rc == nil || unreachable {
+			skipped[cluster] = true
+			return nil
+		}
+		return rc
+	}
+
+	if cache.IdentityClusterCache != nil {
+		identities := cache.IdentityClusterCache.GetKeys()
+		sort.Strings(identities)
+		for _, identity := range identities {
+			for _, cluster := range getCacheKeys(cache.IdentityClusterCache, identity) {
+				rc := checkable(cluster)
+				if rc == nil || hasIdentityWorkload(rc, identity) {
+					continue
+				}
+				inconsistency := CacheInconsistency{Cache: "identityCluster", Key: identity, Cluster: cluster, Reason: InconsistencyNoWorkload}
+				if repair {
+					cache.IdentityClusterCache.DeleteMap(identity, cluster)
+					inconsistency.Repaired = true
+				}
+				report.Inconsistencies = append(report.Inconsistencies, inconsistency)
+			}
+		}
+	}
+
+	if cache.CnameClusterCache != nil {
+		cnames := cache.CnameClusterCache.GetKeys()
+		sort.Strings(cnames)
+		for _, cname := range cnames {
+			var (
+				value interface{}
+				ok    bool
+			)
+			if cache.CnameIdentityCache != nil {
+				value, ok = cache.CnameIdentityCache.Load(cname)
+			}
+			if !ok {
+				report.Inconsistencies = append(report.Inconsistencies, CacheInconsistency{Cache: "cnameCluster", Key: cname, Reason: InconsistencyNoIdentity})
+				continue
+			}
+			identity := fmt.Sprint(value)
+			for _, cluster := range getCacheKeys(cache.CnameClusterCache, cname) {
+				rc := checkable(cluster)
+				if rc == nil {
+					continue
+				}
+				if !hasIdentityWorkload(rc, identity) {
+					inconsistency := CacheInconsistency{Cache: "cnameCluster", Key: cname, Cluster: cluster, Reason: InconsistencyNoWorkload}
+					if repair {
+						cache.CnameClusterCache.DeleteMap(cname, cluster)
+						inconsistency.Repaired = true
+					}
+					report.Inconsistencies = append(report.Inconsistencies, inconsistency)
+					continue
+				}
+				if !hasIdentityService(rc, identity) {
+					report.Inconsistencies = append(report.Inconsistencies, CacheInconsistency{Cache: "cnameCluster", Key: cname, Cluster: cluster, Reason: InconsistencyNoService})
+				}
+			}
+		}
+	}
+
+	for cluster := range skipped {
+		report.SkippedClusters = append(report.SkippedClusters, cluster)
+	}
+	sort.Strings(report.SkippedClusters)
+	for _, inconsistency := range report.Inconsistencies {
+		cacheInconsistencies.Increment(api.WithAttributes(
+			attribute.Key("cache").String(inconsistency.Cache),
+			attribute.Key("repaired").Bool(inconsistency.Repaired),
+		))
+		log.Warnf(LogFormat, common.CacheConsistencyCheck, inconsistency.Cache, inconsistency.Key, inconsistency.Cluster,
+			fmt.Sprintf("%s, repaired=%v", inconsistency.Reason, inconsistency.Repaired))
+	}
+	return report
+}
+
+// getIdentityWorkloadNamespaces returns the namespaces the deployments and the rollouts of the identity run in in the cluster
+func getIdentityWorkloadNamespaces(rc *RemoteController, identity string) []string {
+	var namespaces []string
+	if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+		for _, item := range rc.DeploymentController.Cache.GetByIdentity(identity) {
+			if item != nil && item.Deployment != nil {
+				namespaces = append(namespaces, item.Deployment.Namespace)
+			}
+		}
+	}
+	if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+		for _, item := range rc.RolloutController.Cache.GetByIdentity(identity) {
+			if item != nil && item.Rollout != nil {
+				namespaces = append(namespaces, item.Rollout.Namespace)
+			}
+		}
+	}
+	return namespaces
+}
+
+// hasIdentityWorkload checks if a deployment or a rollout of the identity, or a client of the mesh discovered from
+// its jobs or vertices, runs in the cluster. The workloads of any identity are looked up when identity is empty
+func hasIdentityWorkload(rc *RemoteController, identity string) bool {
+	if identity == "" {
+		return (rc.DeploymentController != nil && rc.DeploymentController.Cache != nil && rc.DeploymentController.Cache.HasWorkloads()) ||
+			(rc.RolloutController != nil && rc.RolloutController.Cache != nil && rc.RolloutController.Cache.HasWorkloads()) ||
+			(rc.JobController != nil && rc.JobController.Cache != nil && rc.JobController.Cache.HasWorkloads()) ||
+			(rc.CronJobController != nil && rc.CronJobController.Cache != nil && rc.CronJobController.Cache.HasWorkloads()) ||
+			(rc.VertexController != nil && rc.VertexController.Cache != nil && rc.VertexController.Cache.HasWorkloads()) ||
+			(rc.MonoVertexController != nil && rc.MonoVertexController.Cache != nil && rc.MonoVertexController.Cache.HasWorkloads())
+	}
+	return len(getIdentityWorkloadNamespaces(rc, identity)) > 0 ||
+		(rc.JobController != nil && rc.JobController.Cache != nil && len(rc.JobController.Cache.GetByIdentity(identity)) > 0) ||
+		(rc.CronJobController != nil && rc.CronJobController.Cache != nil && len(rc.CronJobController.Cache.GetByIdentity(identity)) > 0) ||
+		(rc.VertexController != nil && rc.VertexController.Cache != nil && len(rc.VertexController.Cache.GetByIdentity(identity)) > 0) ||
+		(rc.MonoVertexController != nil && rc.MonoVertexController.Cache != nil && len(rc.MonoVertexController.Cache.GetByIdentity(identity)) > 0)
+}
+
+// hasIdentityService checks if a service runs in the namespaces of the deployments and the rollouts of the identity
+// in the cluster. It is assumed to when the services of the cluster are not watched
+func hasIdentityService(rc *RemoteController, identity string) bool {
+	if rc.ServiceController == nil || rc.ServiceController.Cache == nil {
+		return true
+	}
+	namespaces := getIdentityWorkloadNamespaces(rc, identity)
+	if len(namespaces) == 0 {
+		return true
+	}
+	for _, namespace := range namespaces {
+		if len(rc.ServiceController.Cache.Get(namespace)) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	k8sV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestCheckCacheConsistency(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	cache := rr.AdmiralCache
+
+	// foo runs in cluster1 along with its service, bar runs in cluster1 without a service,
+	// baz no longer runs in cluster1 and cluster2 is unreachable
+	deployments := admiral.NewDeploymentCache()
+	deployments.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-stage"}})
+	deployments.UpdateDeploymentToClusterCache("bar", &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "bar-stage"}})
+	serviceController, err := admiral.NewServiceController(make(chan struct{}), &test.MockServiceHandler{},
+		&rest.Config{Host: "localhost"}, time.Second*time.Duration(300), loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	serviceController.Cache.Put(&k8sV1.Service{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-stage"}})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: deployments},
+		ServiceController:    serviceController,
+	})
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:            "cluster2",
+		DeploymentController: &admiral.DeploymentController{Cache: admiral.NewDeploymentCache()},
+	})
+	cache.UnreachableClusterCache.Store("cluster2", time.Now())
+	for _, identity := range []string{"foo", "bar", "baz"} {
+		cache.IdentityClusterCache.Put(identity, "cluster1", "cluster1")
+		cache.CnameClusterCache.Put("stage."+identity+".global", "cluster1", "cluster1")
+		cache.CnameIdentityCache.Store("stage."+identity+".global", identity)
+	}
+	cache.IdentityClusterCache.Put("baz", "cluster2", "cluster2")
+	cache.CnameClusterCache.Put("stage.qux.global", "cluster1", "cluster1")
+
+	report := CheckCacheConsistency(rr, false)
+	expected := []CacheInconsistency{
+		{Cache: "identityCluster", Key: "baz", Cluster: "cluster1", Reason: InconsistencyNoWorkload},
+		{Cache: "cnameCluster", Key: "stage.bar.global", Cluster: "cluster1", Reason: InconsistencyNoService},
+		{Cache: "cnameCluster", Key: "stage.baz.global", Cluster: "cluster1", Reason: InconsistencyNoWorkload},
+		{Cache: "cnameCluster", Key: "stage.qux.global", Reason: InconsistencyNoIdentity},
+	}
+	assert.Equal(t, expected, report.Inconsistencies)
+	assert.Equal(t, []string{"cluster2"}, report.SkippedClusters)
+	assert.True(t, cache.IdentityClusterCache.Get("baz").CheckIfPresent("cluster1"))
+
+	// the clusters without a workload are dropped on repair, the cluster hidden by the outage is kept
+	report = CheckCacheConsistency(rr, true)
+	assert.True(t, report.Inconsistencies[0].Repaired)
+	assert.False(t, report.Inconsistencies[1].Repaired)
+	assert.True(t, report.Inconsistencies[2].Repaired)
+	assert.False(t, cache.IdentityClusterCache.Get("baz").CheckIfPresent("cluster1"))
+	assert.True(t, cache.IdentityClusterCache.Get("baz").CheckIfPresent("cluster2"))
+	assert.False(t, cache.CnameClusterCache.Get("stage.baz.global").CheckIfPresent("cluster1"))
+	assert.True(t, cache.CnameClusterCache.Get("stage.foo.global").CheckIfPresent("cluster1"))
+
+	report = CheckCacheConsistency(rr, false)
+	assert.Len(t, report.Inconsistencies, 2)
+}
+
+func TestHasIdentityWorkload(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{WorkloadIdentityKey: "identity"}})
+	jobs := admiral.NewJobCache()
+	jobs.Put(&common.K8sObject{Name: "client", Namespace: "client-ns", Labels: map[string]string{"identity": "client"}})
+	rc := &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: admiral.NewDeploymentCache()},
+		JobController:        &admiral.JobController{Cache: jobs},
+	}
+
+	assert.True(t, hasIdentityWorkload(rc, "client"))
+	assert.False(t, hasIdentityWorkload(rc, "gone"))
+	// the workloads of any identity
+	assert.True(t, hasIdentityWorkload(rc, ""))
+	assert.False(t, hasIdentityWorkload(&RemoteController{ClusterID: "cluster1"}, ""))
+}
@@ -0,0 +1,170 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+var (
+	cacheMetricsOnce       sync.Once
+	cacheSize              common.GaugeVec
+	cnameDependentClusters common.GaugeVec
+	clusterIdentities      common.GaugeVec
+	clusterIdentitiesMutex sync.Mutex
+	reportedClusters       = make(map[string]bool)
+)
+
+func initCacheMetrics() {
+	cacheMetricsOnce.Do(func() {
+		cacheSize = common.NewGaugeVecFrom(common.CacheSizeMetricName,
+			"Gauge for the number of entries of each admiral cache", "cache")
+		cnameDependentClusters = common.NewGaugeVecFrom(common.CnameDependentClustersMetricName,
+			"Gauge for the max and mean number of dependent clusters of the cnames", "stat")
+		clusterIdentities = common.NewGaugeVecFrom(common.ClusterIdentitiesMetricName,
+			"Gauge for the number of identities in each cluster", "cluster")
+	})
+}
+
+// startCacheMetrics periodically exports the size and composition of the admiral caches
+func startCacheMetrics(ctx context.Context, rr *RemoteRegistry) {
+	interval := common.GetCacheMetricsInterval()
+	if !common.GetMetricsEnabled() || rr == nil || rr.AdmiralCache == nil || interval <= 0 {
+		return
+	}
+	initCacheMetrics()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			exportCacheMetrics(rr.AdmiralCache)
+		}
+	}
+}
+
+// exportCacheMetrics sets the cache gauges from the current content of the caches
+func exportCacheMetrics(cache *AdmiralCache) {
+	for name, size := range getCacheSizes(cache) {
+		cacheSize.With(name).Set(float64(size))
+	}
+
+	max, mean := getCnameDependentClustersStats(cache)
+	cnameDependentClusters.With("max").Set(float64(max))
+	cnameDependentClusters.With("mean").Set(mean)
+
+	identitiesPerCluster := getIdentitiesPerCluster(cache)
+	clusterIdentitiesMutex.Lock()
+	defer clusterIdentitiesMutex.Unlock()
+	for cluster := range reportedClusters {
+		if _, ok := identitiesPerCluster[cluster]; !ok {
+			clusterIdentities.With(cluster).Set(0)
+			delete(reportedClusters, cluster)
+		}
+	}
+	for cluster, identities := range identitiesPerCluster {
+		clusterIdentities.With(cluster).Set(float64(identities))
+		reportedClusters[cluster] = true
+	}
+}
+
+// getCacheSizes returns the number of entries of each of the caches which are set
+func getCacheSizes(cache *AdmiralCache) map[string]int {
+	sizes := make(map[string]int)
+	mapOfMaps := map[string]*common.MapOfMaps{
+		"cnameCluster":          cache.CnameClusterCache,
+		"cnameDependentCluster": cache.CnameDependentClusterCache,
+		"identityCluster":       cache.IdentityClusterCache,
+		"identityDependency":    cache.IdentityDependencyCache,
+		"seCluster":             cache.SeClusterCache,
+		"clusterLocality":       cache.ClusterLocalityCache,
+	}
+	for name, m := range mapOfMaps {
+		if m != nil {
+			sizes[name] = m.Len()
+		}
+	}
+	mapOfMapOfMaps := map[string]*common.MapOfMapOfMaps{
+		"identityClusterNamespace":       cache.IdentityClusterNamespaceCache,
+		"cnameDependentClusterNamespace": cache.CnameDependentClusterNamespaceCache,
+		"clientClusterNamespaceServer":   cache.ClientClusterNamespaceServerCache,
+	}
+	for name, m := range mapOfMapOfMaps {
+		if m != nil {
+			sizes[name] = m.Len()
+		}
+	}
+	syncMaps := map[string]*sync.Map{
+		"cnameIdentity":                     cache.CnameIdentityCache,
+		"identitiesWithAdditionalEndpoints": cache.IdentitiesWithAdditionalEndpoints,
+		"dynamoDbEndpointUpdate":            cache.DynamoDbEndpointUpdateCache,
+	}
+	for name, m := range syncMaps {
+		if m != nil {
+			sizes[name] = syncMapLen(m)
+		}
+	}
+	if cache.PartitionIdentityCache != nil {
+		sizes["partitionIdentity"] = cache.PartitionIdentityCache.Len()
+	}
+	if cache.DependencyNamespaceCache != nil {
+		size := 0
+		cache.DependencyNamespaceCache.Range(func(string, map[string]common.SidecarEgress) {
+			size++
+		})
+		sizes["dependencyNamespace"] = size
+	}
+	if cache.SourceToDestinations != nil {
+		cache.SourceToDestinations.mutex.Lock()
+		sizes["sourceToDestinations"] = len(cache.SourceToDestinations.sourceDestinations)
+		cache.SourceToDestinations.mutex.Unlock()
+	}
+	return sizes
+}
+
+// getCnameDependentClustersStats returns the max and mean number of dependent clusters of the cnames
+func getCnameDependentClustersStats(cache *AdmiralCache) (int, float64) {
+	if cache.CnameDependentClusterCache == nil {
+		return 0, 0
+	}
+	var max, total, cnames int
+	cache.CnameDependentClusterCache.Range(func(cname string, clusters *common.Map) {
+		count := clusters.Len()
+		if count > max {
+			max = count
+		}
+		total += count
+		cnames++
+	})
+	if cnames == 0 {
+		return 0, 0
+	}
+	return max, float64(total) / float64(cnames)
+}
+
+// getIdentitiesPerCluster returns the number of identities in each cluster
+func getIdentitiesPerCluster(cache *AdmiralCache) map[string]int {
+	identitiesPerCluster := make(map[string]int)
+	if cache.IdentityClusterCache == nil {
+		return identitiesPerCluster
+	}
+	cache.IdentityClusterCache.Range(func(identity string, clusters *common.Map) {
+		for _, cluster := range clusters.GetKeys() {
+			identitiesPerCluster[cluster]++
+		}
+	})
+	return identitiesPerCluster
+}
+
+func syncMapLen(m *sync.Map) int {
+	size := 0
+	m.Range(func(key, value interface{}) bool {
+		size++
+		return true
+	})
+	return size
+}
@@ -0,0 +1,70 @@
+package clusters
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCacheMetricsAdmiralCache() *AdmiralCache {
+	cache := &AdmiralCache{
+		CnameDependentClusterCache: common.NewMapOfMaps(),
+		IdentityClusterCache:       common.NewMapOfMaps(),
+		CnameIdentityCache:         &sync.Map{},
+		SourceToDestinations: &sourceToDestinations{
+			sourceDestinations: map[string][]string{"payments": {"ledger"}},
+			mutex:              &sync.Mutex{},
+		},
+	}
+	cache.CnameDependentClusterCache.Put("qa.payments.global", "cluster1", "cluster1")
+	cache.CnameDependentClusterCache.Put("qa.payments.global", "cluster2", "cluster2")
+	cache.CnameDependentClusterCache.Put("qa.payments.global", "cluster3", "cluster3")
+	cache.CnameDependentClusterCache.Put("qa.ledger.global", "cluster1", "cluster1")
+	cache.IdentityClusterCache.Put("payments", "cluster1", "cluster1")
+	cache.IdentityClusterCache.Put("payments", "cluster2", "cluster2")
+	cache.IdentityClusterCache.Put("ledger", "cluster1", "cluster1")
+	cache.CnameIdentityCache.Store("qa.payments.global", "payments")
+	cache.CnameIdentityCache.Store("qa.ledger.global", "ledger")
+	return cache
+}
+
+func TestGetCacheSizes(t *testing.T) {
+	sizes := getCacheSizes(newCacheMetricsAdmiralCache())
+	assert.Equal(t, map[string]int{
+		"cnameDependentCluster": 2,
+		"identityCluster":       2,
+		"cnameIdentity":         2,
+		"sourceToDestinations":  1,
+	}, sizes)
+}
+
+func TestGetCnameDependentClustersStats(t *testing.T) {
+	max, mean := getCnameDependentClustersStats(newCacheMetricsAdmiralCache())
+	assert.Equal(t, 3, max)
+	assert.Equal(t, 2.0, mean)
+
+	max, mean = getCnameDependentClustersStats(&AdmiralCache{CnameDependentClusterCache: common.NewMapOfMaps()})
+	assert.Equal(t, 0, max)
+	assert.Equal(t, 0.0, mean)
+}
+
+func TestGetIdentitiesPerCluster(t *testing.T) {
+	assert.Equal(t, map[string]int{"cluster1": 2, "cluster2": 1}, getIdentitiesPerCluster(newCacheMetricsAdmiralCache()))
+	assert.Empty(t, getIdentitiesPerCluster(&AdmiralCache{}))
+}
+
+func TestExportCacheMetrics(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	initCacheMetrics()
+	cache := newCacheMetricsAdmiralCache()
+	exportCacheMetrics(cache)
+	assert.True(t, reportedClusters["cluster2"])
+
+	cache.IdentityClusterCache.DeleteMap("payments", "cluster2")
+	exportCacheMetrics(cache)
+	assert.False(t, reportedClusters["cluster2"])
+	assert.True(t, reportedClusters["cluster1"])
+}
@@ -134,11 +134,11 @@ func callRegistryForClientConnectionConfig(ctx context.Context, event admiral.Ev
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutCustomData(clusterName, clientConnectionSettings.Namespace, clientConnectionSettings.Name, common.ClientConnectionConfig, ctx.Value("txId").(string), clientConnectionSettings)
+			err = registry.RegistryClient.PutCustomData(clusterName, clientConnectionSettings.Namespace, clientConnectionSettings.Name, common.ClientConnectionConfig, common.GetTxId(ctx), clientConnectionSettings)
 		case admiral.Update:
-			err = registry.RegistryClient.PutCustomData(clusterName, clientConnectionSettings.Namespace, clientConnectionSettings.Name, common.ClientConnectionConfig, ctx.Value("txId").(string), clientConnectionSettings)
+			err = registry.RegistryClient.PutCustomData(clusterName, clientConnectionSettings.Namespace, clientConnectionSettings.Name, common.ClientConnectionConfig, common.GetTxId(ctx), clientConnectionSettings)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteCustomData(clusterName, clientConnectionSettings.Namespace, clientConnectionSettings.Name, common.ClientConnectionConfig, ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteCustomData(clusterName, clientConnectionSettings.Namespace, clientConnectionSettings.Name, common.ClientConnectionConfig, common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, common.ClientConnectionConfig, clientConnectionSettings.Name, clusterName, "failed to "+string(event)+" "+common.ClientConnectionConfig+" with err: "+err.Error())
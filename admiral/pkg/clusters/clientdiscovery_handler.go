@@ -85,11 +85,11 @@ func callRegistryForClientDiscovery(ctx context.Context, event admiral.EventType
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, obj.Type, ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, obj.Type, common.GetTxId(ctx), obj)
 		case admiral.Update:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, obj.Type, ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, obj.Type, common.GetTxId(ctx), obj)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, obj.Type, ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, obj.Type, common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, obj.Type, obj.Name, clusterName, "failed to "+string(event)+" "+obj.Type+" with err: "+err.Error())
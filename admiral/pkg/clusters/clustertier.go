@@ -0,0 +1,84 @@
+package clusters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+// isClusterTierAllowed checks if a resource belonging to resourceTier can be
+// written to the passed cluster. Resources or clusters which are not tagged
+// with a tier are always allowed. Only the writes are checked, the resources
+// written before a tier was changed are not deleted from the cluster
+func isClusterTierAllowed(resourceTier string, cluster string) bool {
+	if !common.IsClusterTierEnforcementEnabled() {
+		return true
+	}
+	clusterTier := common.GetClusterTier(cluster)
+	if resourceTier == "" || clusterTier == "" {
+		return true
+	}
+	return strings.EqualFold(resourceTier, clusterTier)
+}
+
+// reportClusterTierViolation logs and records a metric for a resource
+// which was blocked from being written to a cluster of a different tier
+func reportClusterTierViolation(ctxLogger *log.Entry, resourceType common.ResourceType, name, resourceTier, cluster string) {
+	clusterTierViolations.Increment(api.WithAttributes(
+		attribute.Key("resourceType").String(string(resourceType)),
+		attribute.Key("resourceTier").String(resourceTier),
+		attribute.Key("clusterTier").String(common.GetClusterTier(cluster)),
+		attribute.Key("cluster").String(cluster),
+	))
+	ctxLogger.Errorf(LogErrFormat, "ClusterTierCheck", resourceType, name, cluster,
+		fmt.Sprintf("blocked writing %s tier resource to %s tier cluster", resourceTier, common.GetClusterTier(cluster)))
+}
+
+// filterClustersByTier returns the clusters to which a resource of resourceTier
+// can be written. Blocked clusters are reported
+func filterClustersByTier(ctxLogger *log.Entry, resourceType common.ResourceType, name, resourceTier string, clusters []string) []string {
+	if !common.IsClusterTierEnforcementEnabled() {
+		return clusters
+	}
+	allowedClusters := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		if !isClusterTierAllowed(resourceTier, cluster) {
+			reportClusterTierViolation(ctxLogger, resourceType, name, resourceTier, cluster)
+			continue
+		}
+		allowedClusters = append(allowedClusters, cluster)
+	}
+	return allowedClusters
+}
+
+// getVirtualServiceTier returns the tier of the environment the VirtualService
+// was created for. When the environment cannot be determined, the tier of the
+// source cluster is used instead
+func getVirtualServiceTier(virtualService *v1alpha3.VirtualService, sourceCluster string) string {
+	var env string
+	if virtualService != nil {
+		if virtualService.Annotations != nil {
+			env = virtualService.Annotations[common.CreatedForEnv]
+			if env != "" {
+				env = strings.Split(env, "_")[0]
+			}
+			if env == "" {
+				env = virtualService.Annotations[common.GetEnvKey()]
+			}
+		}
+		if env == "" && virtualService.Labels != nil {
+			env = virtualService.Labels[common.GetEnvKey()]
+		}
+	}
+	if env != "" {
+		if tier := common.GetEnvironmentTier(env); tier != "" {
+			return tier
+		}
+	}
+	return common.GetClusterTier(sourceCluster)
+}
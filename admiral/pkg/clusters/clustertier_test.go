@@ -0,0 +1,142 @@
+package clusters
+
+import (
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func setupForClusterTierTests(enabled bool) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			EnvKey: "admiral.io/env",
+		},
+		EnableClusterTierEnforcement: enabled,
+		ClusterTiers: map[string]string{
+			"prod-cluster":  "prod",
+			"dev-cluster":   "nonprod",
+			"other-cluster": "",
+		},
+		EnvironmentTiers: map[string]string{
+			"prd": "prod",
+			"e2e": "nonprod",
+			"qal": "nonprod",
+		},
+	})
+}
+
+func TestFilterClustersByTier(t *testing.T) {
+	ctxLogger := log.WithFields(log.Fields{"type": "test"})
+	clusters := []string{"prod-cluster", "dev-cluster", "other-cluster", "untagged-cluster"}
+	testCases := []struct {
+		name         string
+		enabled      bool
+		resourceTier string
+		expected     []string
+	}{
+		{
+			name: "Given cluster tier enforcement is disabled, " +
+				"When filterClustersByTier is called, " +
+				"Then all the clusters should be returned",
+			enabled:      false,
+			resourceTier: "prod",
+			expected:     clusters,
+		},
+		{
+			name: "Given cluster tier enforcement is enabled, " +
+				"When filterClustersByTier is called for a prod resource, " +
+				"Then the nonprod clusters should be filtered out",
+			enabled:      true,
+			resourceTier: "prod",
+			expected:     []string{"prod-cluster", "other-cluster", "untagged-cluster"},
+		},
+		{
+			name: "Given cluster tier enforcement is enabled, " +
+				"When filterClustersByTier is called for a nonprod resource, " +
+				"Then the prod clusters should be filtered out",
+			enabled:      true,
+			resourceTier: "nonprod",
+			expected:     []string{"dev-cluster", "other-cluster", "untagged-cluster"},
+		},
+		{
+			name: "Given cluster tier enforcement is enabled, " +
+				"When filterClustersByTier is called for a resource without a tier, " +
+				"Then all the clusters should be returned",
+			enabled:      true,
+			resourceTier: "",
+			expected:     clusters,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupForClusterTierTests(tc.enabled)
+			actual := filterClustersByTier(ctxLogger, common.VirtualServiceResourceType, "test-vs", tc.resourceTier, clusters)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetVirtualServiceTier(t *testing.T) {
+	setupForClusterTierTests(true)
+	testCases := []struct {
+		name           string
+		virtualService *v1alpha3.VirtualService
+		sourceCluster  string
+		expected       string
+	}{
+		{
+			name: "Given a VirtualService with createdForEnv annotation, " +
+				"When getVirtualServiceTier is called, " +
+				"Then the tier of the first env should be returned",
+			virtualService: &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{
+					Annotations: map[string]string{common.CreatedForEnv: "prd_e2e"},
+				},
+			},
+			sourceCluster: "dev-cluster",
+			expected:      "prod",
+		},
+		{
+			name: "Given a VirtualService with env label, " +
+				"When getVirtualServiceTier is called, " +
+				"Then the tier of the env should be returned",
+			virtualService: &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{
+					Labels: map[string]string{"admiral.io/env": "qal"},
+				},
+			},
+			sourceCluster: "prod-cluster",
+			expected:      "nonprod",
+		},
+		{
+			name: "Given a VirtualService without env, " +
+				"When getVirtualServiceTier is called, " +
+				"Then the tier of the source cluster should be returned",
+			virtualService: &v1alpha3.VirtualService{},
+			sourceCluster:  "prod-cluster",
+			expected:       "prod",
+		},
+		{
+			name: "Given a VirtualService with an env that has no tier, " +
+				"When getVirtualServiceTier is called, " +
+				"Then the tier of the source cluster should be returned",
+			virtualService: &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{
+					Labels: map[string]string{"admiral.io/env": "stage"},
+				},
+			},
+			sourceCluster: "dev-cluster",
+			expected:      "nonprod",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getVirtualServiceTier(tc.virtualService, tc.sourceCluster))
+		})
+	}
+}
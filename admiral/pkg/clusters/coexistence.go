@@ -0,0 +1,156 @@
+package clusters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/notifier"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// writtenSpecHistory is the number of specs admiral wrote which are remembered per resource, so that a
+// resource read from an informer which has not caught up with the latest write is not taken for a revert
+const writtenSpecHistory = 3
+
+// writtenSpec keeps the hashes of the latest specs admiral wrote to a resource, along with the
+// number of times the resource was found reverted to something else
+type writtenSpec struct {
+	hashes  []string
+	reverts int
+}
+
+// writtenSpecCache keeps track of the specs admiral wrote, by cluster and kind/namespace/name
+type writtenSpecCache struct {
+	lock    sync.Mutex
+	written map[string]map[string]*writtenSpec
+}
+
+var writtenSpecs = &writtenSpecCache{written: make(map[string]map[string]*writtenSpec)}
+
+// stampCoexistenceAnnotations annotates the resource admiral is about to write with the configured annotations,
+// which keep a GitOps controller managing the same namespace, like ArgoCD or Flux, from reverting or pruning it
+func stampCoexistenceAnnotations(obj metaV1.Object) {
+	configured := common.GetGeneratedResourceAnnotations()
+	if len(configured) == 0 {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range configured {
+		annotations[key] = value
+	}
+	obj.SetAnnotations(annotations)
+}
+
+// recordWrittenSpec remembers the spec admiral wrote to the resource, to detect the resource being reverted later
+func recordWrittenSpec(cluster string, kind common.ResourceType, namespace, name string, spec proto.Message) {
+	if common.GetExternalRevertThreshold() <= 0 {
+		return
+	}
+	hash, err := hashSpec(spec)
+	if err != nil {
+		return
+	}
+	writtenSpecs.lock.Lock()
+	defer writtenSpecs.lock.Unlock()
+	if writtenSpecs.written[cluster] == nil {
+		writtenSpecs.written[cluster] = make(map[string]*writtenSpec)
+	}
+	key := writtenSpecKey(kind, namespace, name)
+	written := writtenSpecs.written[cluster][key]
+	if written == nil {
+		written = &writtenSpec{}
+		writtenSpecs.written[cluster][key] = written
+	}
+	written.hashes = append(written.hashes, hash)
+	if len(written.hashes) > writtenSpecHistory {
+		written.hashes = written.hashes[len(written.hashes)-writtenSpecHistory:]
+	}
+}
+
+// detectExternalRevert checks if the resource read back from the cluster holds a spec admiral did not write,
+// meaning something else, usually a GitOps controller, changed it since. A resource already holding the spec
+// admiral is about to write is left alone. Every revert is counted, and a notification is sent once a resource
+// was reverted as many times as the configured threshold
+func detectExternalRevert(ctxLogger *log.Entry, cluster string, kind common.ResourceType, exist metaV1.Object, existSpec, newSpec proto.Message) bool {
+	threshold := common.GetExternalRevertThreshold()
+	if threshold <= 0 || proto.Equal(existSpec, newSpec) {
+		return false
+	}
+	hash, err := hashSpec(existSpec)
+	if err != nil {
+		return false
+	}
+	writtenSpecs.lock.Lock()
+	written := writtenSpecs.written[cluster][writtenSpecKey(kind, exist.GetNamespace(), exist.GetName())]
+	if written == nil {
+		writtenSpecs.lock.Unlock()
+		return false
+	}
+	for _, writtenHash := range written.hashes {
+		if writtenHash == hash {
+			writtenSpecs.lock.Unlock()
+			return false
+		}
+	}
+	written.reverts++
+	reverts := written.reverts
+	writtenSpecs.lock.Unlock()
+
+	externalReverts.Increment(api.WithAttributes(
+		attribute.Key("kind").String(string(kind)),
+		attribute.Key("cluster").String(cluster),
+	))
+	ctxLogger.Warnf(common.CtxLogFormat, "DetectExternalRevert", exist.GetName(), exist.GetNamespace(), cluster,
+		fmt.Sprintf("%s was changed by something other than admiral, %d time(s) so far", kind, reverts))
+	if reverts >= threshold {
+		notifier.Send(notifier.Notification{
+			Key:      "external-revert/" + cluster + "/" + writtenSpecKey(kind, exist.GetNamespace(), exist.GetName()),
+			Severity: notifier.SeverityWarning,
+			Title:    "Generated resource reverted",
+			Message: fmt.Sprintf("%s %s in namespace %s of cluster %s was reverted %d times by something other than admiral, check the GitOps controllers managing the namespace",
+				kind, exist.GetName(), exist.GetNamespace(), cluster, reverts),
+		})
+	}
+	return true
+}
+
+// forgetWrittenSpec drops the specs admiral wrote to the resource which was deleted
+func forgetWrittenSpec(cluster string, kind common.ResourceType, namespace, name string) {
+	writtenSpecs.lock.Lock()
+	defer writtenSpecs.lock.Unlock()
+	delete(writtenSpecs.written[cluster], writtenSpecKey(kind, namespace, name))
+	if len(writtenSpecs.written[cluster]) == 0 {
+		delete(writtenSpecs.written, cluster)
+	}
+}
+
+// clearWrittenSpecs drops the specs admiral wrote to a cluster it no longer watches
+func clearWrittenSpecs(cluster string) {
+	writtenSpecs.lock.Lock()
+	defer writtenSpecs.lock.Unlock()
+	delete(writtenSpecs.written, cluster)
+}
+
+func writtenSpecKey(kind common.ResourceType, namespace, name string) string {
+	return strings.Join([]string{string(kind), namespace, strings.ToLower(name)}, "/")
+}
+
+func hashSpec(spec proto.Message) (string, error) {
+	content, err := proto.MarshalOptions{Deterministic: true}.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,77 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGitOpsControllerCoexistence(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                     &common.LabelSet{},
+		SyncNamespace:                "ns",
+		GeneratedResourceAnnotations: map[string]string{"argocd.argoproj.io/compare-options": "IgnoreExtraneous"},
+		ExternalRevertThreshold:      2,
+	})
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+	ctx := context.Background()
+	ctxLogger := log.WithField("op", "test")
+	istioClient := istioFake.NewSimpleClientset()
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	newServiceEntry := func() *v1alpha3.ServiceEntry {
+		return &v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se", Namespace: "ns"},
+			Spec: networkingV1Alpha3.ServiceEntry{
+				Hosts:     []string{"foo.global"},
+				Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "internal-lb.com"}},
+			},
+		}
+	}
+	getServiceEntry := func() *v1alpha3.ServiceEntry {
+		se, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, "foo.global-se", metaV1.GetOptions{})
+		assert.Nil(t, err)
+		return se
+	}
+	reverts := func() int {
+		writtenSpecs.lock.Lock()
+		defer writtenSpecs.lock.Unlock()
+		return writtenSpecs.written["cluster1"][writtenSpecKey(common.ServiceEntryResourceType, "ns", "foo.global-se")].reverts
+	}
+	defer clearWrittenSpecs("cluster1")
+
+	// the configured annotations are stamped on the generated resource
+	assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, newServiceEntry(), nil, "ns", rc))
+	assert.Equal(t, "IgnoreExtraneous", getServiceEntry().Annotations["argocd.argoproj.io/compare-options"])
+
+	// a resource holding the spec admiral wrote is not a revert
+	assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, newServiceEntry(), getServiceEntry(), "ns", rc))
+	assert.Equal(t, 0, reverts())
+
+	// a resource changed by something else is
+	reverted := getServiceEntry()
+	reverted.Spec.Endpoints[0].Address = "other-lb.com"
+	_, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Update(ctx, reverted, metaV1.UpdateOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, newServiceEntry(), getServiceEntry(), "ns", rc))
+	assert.Equal(t, 1, reverts())
+	assert.Equal(t, "internal-lb.com", getServiceEntry().Spec.Endpoints[0].Address)
+
+	// the specs written are forgotten once the resource is deleted
+	assert.Nil(t, deleteServiceEntry(ctx, getServiceEntry(), "ns", rc))
+	writtenSpecs.lock.Lock()
+	assert.Empty(t, writtenSpecs.written["cluster1"])
+	writtenSpecs.lock.Unlock()
+}
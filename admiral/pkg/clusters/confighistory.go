@@ -0,0 +1,310 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// configHistoryResourceType is the type of the custom data the history of an identity is persisted as in the registry
+const configHistoryResourceType = "ConfigurationHistory"
+
+// ConfigSnapshot is the configuration admiral generated for an identity across all the clusters, as it was
+// after an event was processed. The version increases with every snapshot of the identity
+type ConfigSnapshot struct {
+	Version   int                      `json:"version"`
+	TxId      string                   `json:"txId,omitempty"`
+	CreatedAt time.Time                `json:"createdAt"`
+	Clusters  map[string]ClusterBackup `json:"clusters"`
+}
+
+// historyResource is a resource of the current configuration of an identity
+type historyResource struct {
+	cluster string
+	object  runtime.Object
+}
+
+// identityHistory holds the current configuration of an identity, by cluster/kind/namespace/name,
+// along with its latest snapshots, the oldest first
+type identityHistory struct {
+	current   map[string]historyResource
+	snapshots []ConfigSnapshot
+	version   int
+	dirty     bool
+}
+
+// configHistoryCache keeps the history of the configuration generated for each identity, along with the
+// identity each resource belongs to, as the deletes only know the name of the resource
+type configHistoryCache struct {
+	lock       sync.Mutex
+	identities map[string]*identityHistory
+	owners     map[string]string
+}
+
+var configHistory = &configHistoryCache{
+	identities: make(map[string]*identityHistory),
+	owners:     make(map[string]string),
+}
+
+// recordConfigHistory adds the resource admiral wrote to the current configuration of its identity and
+// snapshots it. The writes of the same transaction update the same snapshot, so a snapshot holds the
+// configuration as it was once the event was processed across all the clusters
+func recordConfigHistory(ctx context.Context, cluster string, kind common.ResourceType, obj runtime.Object) {
+	if common.GetConfigHistorySize() <= 0 {
+		return
+	}
+	object := obj.DeepCopyObject()
+	meta, ok := object.(metaV1.Object)
+	if !ok {
+		return
+	}
+	identity := strings.ToLower(getOwnerIdentity(meta))
+	if identity == "" {
+		return
+	}
+	meta.SetResourceVersion("")
+	meta.SetUID("")
+	meta.SetGeneration(0)
+	meta.SetCreationTimestamp(metaV1.Time{})
+	meta.SetManagedFields(nil)
+	key := cluster + "/" + writtenSpecKey(kind, meta.GetNamespace(), meta.GetName())
+
+	configHistory.lock.Lock()
+	defer configHistory.lock.Unlock()
+	history := configHistory.identities[identity]
+	if history == nil {
+		history = &identityHistory{current: make(map[string]historyResource)}
+		configHistory.identities[identity] = history
+	}
+	history.current[key] = historyResource{cluster: cluster, object: object}
+	configHistory.owners[key] = identity
+	takeConfigSnapshot(history, common.GetTxId(ctx))
+}
+
+// forgetConfigHistory removes the resource admiral deleted from the current configuration of its identity
+// and snapshots it
+func forgetConfigHistory(ctx context.Context, cluster string, kind common.ResourceType, namespace, name string) {
+	if common.GetConfigHistorySize() <= 0 {
+		return
+	}
+	key := cluster + "/" + writtenSpecKey(kind, namespace, name)
+	configHistory.lock.Lock()
+	defer configHistory.lock.Unlock()
+	identity, ok := configHistory.owners[key]
+	if !ok {
+		return
+	}
+	delete(configHistory.owners, key)
+	history := configHistory.identities[identity]
+	delete(history.current, key)
+	takeConfigSnapshot(history, common.GetTxId(ctx))
+}
+
+// takeConfigSnapshot snapshots the current configuration of the identity, replacing the latest snapshot when it
+// was taken in the same transaction, and drops the oldest snapshots beyond the configured size
+func takeConfigSnapshot(history *identityHistory, txId string) {
+	snapshot := ConfigSnapshot{TxId: txId, CreatedAt: time.Now().UTC(), Clusters: make(map[string]ClusterBackup)}
+	for _, resource := range history.current {
+		backup, ok := snapshot.Clusters[resource.cluster]
+		if !ok {
+			backup = ClusterBackup{
+				ServiceEntries:   make([]*v1alpha3.ServiceEntry, 0),
+				DestinationRules: make([]*v1alpha3.DestinationRule, 0),
+				VirtualServices:  make([]*v1alpha3.VirtualService, 0),
+			}
+		}
+		switch object := resource.object.(type) {
+		case *v1alpha3.ServiceEntry:
+			backup.ServiceEntries = append(backup.ServiceEntries, object)
+		case *v1alpha3.DestinationRule:
+			backup.DestinationRules = append(backup.DestinationRules, object)
+		case *v1alpha3.VirtualService:
+			backup.VirtualServices = append(backup.VirtualServices, object)
+		}
+		snapshot.Clusters[resource.cluster] = backup
+	}
+	for cluster, backup := range snapshot.Clusters {
+		sort.Slice(backup.ServiceEntries, func(i, j int) bool { return backup.ServiceEntries[i].Name < backup.ServiceEntries[j].Name })
+		sort.Slice(backup.DestinationRules, func(i, j int) bool { return backup.DestinationRules[i].Name < backup.DestinationRules[j].Name })
+		sort.Slice(backup.VirtualServices, func(i, j int) bool { return backup.VirtualServices[i].Name < backup.VirtualServices[j].Name })
+		snapshot.Clusters[cluster] = backup
+	}
+	history.dirty = true
+	latest := len(history.snapshots) - 1
+	if txId != "" && latest >= 0 && history.snapshots[latest].TxId == txId {
+		snapshot.Version = history.snapshots[latest].Version
+		history.snapshots[latest] = snapshot
+		return
+	}
+	history.version++
+	snapshot.Version = history.version
+	history.snapshots = append(history.snapshots, snapshot)
+	if size := common.GetConfigHistorySize(); len(history.snapshots) > size {
+		history.snapshots = history.snapshots[len(history.snapshots)-size:]
+	}
+}
+
+// GetConfigHistory returns the snapshots of the configuration generated for the identity, the latest first
+func GetConfigHistory(identity string) []ConfigSnapshot {
+	configHistory.lock.Lock()
+	defer configHistory.lock.Unlock()
+	snapshots := make([]ConfigSnapshot, 0)
+	history := configHistory.identities[strings.ToLower(identity)]
+	if history == nil {
+		return snapshots
+	}
+	for i := len(history.snapshots) - 1; i >= 0; i-- {
+		snapshots = append(snapshots, history.snapshots[i])
+	}
+	return snapshots
+}
+
+// RollbackIdentity writes the configuration of the snapshot of the identity with the given version back to all the
+// clusters, and deletes the resources generated for the identity since. The rollback is meant to mitigate a bad
+// GlobalTrafficPolicy or VirtualService while it is being fixed, as the next event of the identity generates the
+// configuration from its sources again
+func RollbackIdentity(ctx context.Context, rr *RemoteRegistry, identity string, version int) (RestoreResult, error) {
+	result := RestoreResult{}
+	if commonUtil.IsAdmiralReadOnly() {
+		return result, fmt.Errorf("identities are not rolled back as Admiral is in Read-only mode")
+	}
+	snapshots := GetConfigHistory(identity)
+	if len(snapshots) == 0 {
+		return result, fmt.Errorf("no configuration history for identity %s", identity)
+	}
+	var target *ConfigSnapshot
+	for i := range snapshots {
+		if snapshots[i].Version == version {
+			target = &snapshots[i]
+		}
+	}
+	if target == nil {
+		return result, fmt.Errorf("identity %s has no snapshot with version %d", identity, version)
+	}
+	var (
+		latest    = snapshots[0]
+		ctxLogger = log.WithFields(log.Fields{"op": "RollbackIdentity", "identity": identity})
+	)
+	ctx = common.WithNewTxId(ctx)
+	clusters := make([]string, 0, len(target.Clusters))
+	for cluster := range target.Clusters {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil || rc.ServiceEntryController == nil || rc.DestinationRuleController == nil || rc.VirtualServiceController == nil {
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[cluster] = "admiral is not monitoring the cluster"
+			continue
+		}
+		restoreClusterBackup(ctxLogger, ctx, rr, rc, target.Clusters[cluster], "", true, &result)
+	}
+	for cluster, backup := range latest.Clusters {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			continue
+		}
+		kept := getSnapshotKeys(target.Clusters[cluster])
+		for _, se := range backup.ServiceEntries {
+			if !kept[writtenSpecKey(common.ServiceEntryResourceType, se.Namespace, se.Name)] {
+				countRollbackDelete(&result, common.ServiceEntryResourceType, se, deleteServiceEntry(ctx, se, se.Namespace, rc))
+			}
+		}
+		for _, dr := range backup.DestinationRules {
+			if !kept[writtenSpecKey(common.DestinationRuleResourceType, dr.Namespace, dr.Name)] {
+				countRollbackDelete(&result, common.DestinationRuleResourceType, dr, deleteDestinationRule(ctx, dr, dr.Namespace, rc))
+			}
+		}
+		for _, vs := range backup.VirtualServices {
+			if !kept[writtenSpecKey(common.VirtualServiceResourceType, vs.Namespace, vs.Name)] {
+				countRollbackDelete(&result, common.VirtualServiceResourceType, vs, deleteVirtualService(ctx, vs.Name, vs.Namespace, rc))
+			}
+		}
+	}
+	ctxLogger.Infof(LogFormat, "RollbackIdentity", "", identity, "",
+		fmt.Sprintf("rolled back to version=%d restored=%d deleted=%d failed=%d", version, result.Restored, result.Deleted, len(result.Failed)))
+	return result, nil
+}
+
+// getSnapshotKeys returns the kind/namespace/name of the resources of the cluster in the snapshot
+func getSnapshotKeys(backup ClusterBackup) map[string]bool {
+	keys := make(map[string]bool)
+	for _, se := range backup.ServiceEntries {
+		keys[writtenSpecKey(common.ServiceEntryResourceType, se.Namespace, se.Name)] = true
+	}
+	for _, dr := range backup.DestinationRules {
+		keys[writtenSpecKey(common.DestinationRuleResourceType, dr.Namespace, dr.Name)] = true
+	}
+	for _, vs := range backup.VirtualServices {
+		keys[writtenSpecKey(common.VirtualServiceResourceType, vs.Namespace, vs.Name)] = true
+	}
+	return keys
+}
+
+func countRollbackDelete(result *RestoreResult, kind common.ResourceType, obj metaV1.Object, err error) {
+	if _, ok := err.(*IsVSAlreadyDeletedErr); ok {
+		err = nil
+	}
+	if err != nil {
+		if result.Failed == nil {
+			result.Failed = make(map[string]string)
+		}
+		result.Failed[string(kind)+"/"+obj.GetName()] = err.Error()
+		return
+	}
+	result.Deleted++
+}
+
+// startConfigHistoryPersister periodically persists the history of the identities which changed since to the registry
+func startConfigHistoryPersister(ctx context.Context, rr *RemoteRegistry) {
+	if common.GetConfigHistorySize() <= 0 || !common.IsConfigHistoryPersisted() || rr.RegistryClient == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			persistConfigHistory(rr)
+		}
+	}
+}
+
+// persistConfigHistory writes the history of every identity which changed since it was last persisted to the registry
+func persistConfigHistory(rr *RemoteRegistry) {
+	changed := make(map[string][]ConfigSnapshot)
+	configHistory.lock.Lock()
+	for identity, history := range configHistory.identities {
+		if history.dirty {
+			changed[identity] = append([]ConfigSnapshot(nil), history.snapshots...)
+			history.dirty = false
+		}
+	}
+	configHistory.lock.Unlock()
+	for identity, snapshots := range changed {
+		err := rr.RegistryClient.PutCustomData(common.GetAdmiralInstanceName(), common.GetSyncNamespace(), identity, configHistoryResourceType, "", snapshots)
+		if err != nil {
+			log.Errorf(LogErrFormat, "PersistConfigHistory", configHistoryResourceType, identity, "", err)
+			configHistory.lock.Lock()
+			if history := configHistory.identities[identity]; history != nil {
+				history.dirty = true
+			}
+			configHistory.lock.Unlock()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRollbackIdentity(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:          &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SyncNamespace:     "ns",
+		ConfigHistorySize: 2,
+	})
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+	ctx := context.Background()
+	ctxLogger := log.WithField("op", "test")
+	istioClient := istioFake.NewSimpleClientset()
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient},
+		VirtualServiceController:  &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	rr.PutRemoteController("cluster1", rc)
+	seClient := istioClient.NetworkingV1alpha3().ServiceEntries("ns")
+	writeServiceEntry := func(txId, address string) {
+		se := &v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se", Namespace: "ns", Annotations: map[string]string{"identity": "foo"}},
+			Spec: networkingV1Alpha3.ServiceEntry{
+				Hosts:     []string{"foo.global"},
+				Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: address}},
+			},
+		}
+		exist, _ := seClient.Get(ctx, se.Name, metaV1.GetOptions{})
+		if exist != nil && exist.Name == "" {
+			exist = nil
+		}
+		assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, se, exist, "ns", rc))
+	}
+
+	// the writes of a transaction end up in the same snapshot
+	ctx = common.WithTxId(context.Background(), "tx1")
+	writeServiceEntry("tx1", "lb-1.com")
+	assert.Nil(t, addUpdateDestinationRule(ctxLogger, ctx, &v1alpha3.DestinationRule{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr", Namespace: "ns", Annotations: map[string]string{"identity": "foo"}},
+		Spec:       networkingV1Alpha3.DestinationRule{Host: "foo.global"},
+	}, nil, "ns", rc, rr))
+	ctx = common.WithTxId(context.Background(), "tx2")
+	writeServiceEntry("tx2", "lb-2.com")
+	ctx = common.WithTxId(context.Background(), "tx3")
+	writeServiceEntry("tx3", "lb-3.com")
+	assert.Nil(t, addUpdateVirtualService(ctxLogger, ctx, &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-vs", Namespace: "ns", Annotations: map[string]string{"identity": "foo"}},
+		Spec:       networkingV1Alpha3.VirtualService{Hosts: []string{"foo.global"}},
+	}, nil, "ns", rc, rr))
+
+	// only the latest snapshots are kept
+	history := GetConfigHistory("foo")
+	assert.Len(t, history, 2)
+	assert.Equal(t, 3, history[0].Version)
+	assert.Equal(t, "tx3", history[0].TxId)
+	assert.Len(t, history[0].Clusters["cluster1"].VirtualServices, 1)
+	assert.Equal(t, 2, history[1].Version)
+	assert.Equal(t, "lb-2.com", history[1].Clusters["cluster1"].ServiceEntries[0].Spec.Endpoints[0].Address)
+	assert.Len(t, history[1].Clusters["cluster1"].DestinationRules, 1)
+
+	// the rollback restores the snapshot and deletes the resources generated since
+	result, err := RollbackIdentity(context.Background(), rr, "foo", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, RestoreResult{Restored: 2, Deleted: 1}, result)
+	se, err := seClient.Get(ctx, "foo.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "lb-2.com", se.Spec.Endpoints[0].Address)
+	_, err = istioClient.NetworkingV1alpha3().VirtualServices("ns").Get(ctx, "foo.global-vs", metaV1.GetOptions{})
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, GetConfigHistory("foo")[0].Version)
+
+	// the snapshots dropped can not be rolled back to
+	_, err = RollbackIdentity(context.Background(), rr, "foo", 1)
+	assert.NotNil(t, err)
+	_, err = RollbackIdentity(context.Background(), rr, "bar", 1)
+	assert.NotNil(t, err)
+}
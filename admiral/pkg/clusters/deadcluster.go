@@ -0,0 +1,152 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+// skippedWrite is a write to an unreachable cluster which was skipped, and is replayed once the cluster is reachable again
+type skippedWrite struct {
+	key   string
+	write func(ctx context.Context) error
+}
+
+// skippedWriteCache keeps track of the writes skipped for the unreachable clusters. A write skipped
+// again for the same resource replaces the one skipped before, so only the latest state is replayed
+type skippedWriteCache struct {
+	lock    sync.Mutex
+	skipped map[string][]skippedWrite
+}
+
+var skippedWrites = &skippedWriteCache{skipped: make(map[string][]skippedWrite)}
+
+// virtualServiceSyncFunc syncs a VirtualService of the source cluster to a cluster
+type virtualServiceSyncFunc func(ctx context.Context, cluster string, remoteRegistry *RemoteRegistry, virtualService *v1alpha3.VirtualService,
+	event common.Event, syncNamespace string, vSName string, sourceCluster string) error
+
+// recordSkippedWrite records the write skipped for the unreachable cluster
+func recordSkippedWrite(cluster, key string, write func(ctx context.Context) error) {
+	skippedWrites.lock.Lock()
+	defer skippedWrites.lock.Unlock()
+	writes := skippedWrites.skipped[cluster]
+	for i := range writes {
+		if writes[i].key == key {
+			writes = append(writes[:i], writes[i+1:]...)
+			break
+		}
+	}
+	skippedWrites.skipped[cluster] = append(writes, skippedWrite{key: key, write: write})
+}
+
+// recordSkippedVirtualServiceSync records the sync of the VirtualService skipped for the unreachable cluster.
+// The sync renames the VirtualService it is passed, so a copy named after its source is replayed
+func recordSkippedVirtualServiceSync(sync virtualServiceSyncFunc, cluster string, remoteRegistry *RemoteRegistry, virtualService *v1alpha3.VirtualService,
+	sourceName string, event common.Event, syncNamespace string, vSName string, sourceCluster string) {
+	skipped := virtualService.DeepCopy()
+	skipped.Name = sourceName
+	recordSkippedWrite(cluster, getSkippedVirtualServiceSyncKey(sourceCluster, skipped.Namespace, sourceName), func(ctx context.Context) error {
+		return sync(ctx, cluster, remoteRegistry, skipped.DeepCopy(), event, syncNamespace, vSName, sourceCluster)
+	})
+}
+
+// forgetSkippedWrite drops the write skipped for the resource once it was written to the cluster since, so
+// that the skipped write does not replace the more recent one when it is replayed
+func forgetSkippedWrite(cluster, key string) {
+	skippedWrites.lock.Lock()
+	defer skippedWrites.lock.Unlock()
+	writes := skippedWrites.skipped[cluster]
+	for i := range writes {
+		if writes[i].key == key {
+			writes = append(writes[:i], writes[i+1:]...)
+			break
+		}
+	}
+	if len(writes) == 0 {
+		delete(skippedWrites.skipped, cluster)
+		return
+	}
+	skippedWrites.skipped[cluster] = writes
+}
+
+// getSkippedVirtualServiceSyncKey returns the key of the skipped syncs of a VirtualService of the source cluster
+func getSkippedVirtualServiceSyncKey(sourceCluster, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", common.VirtualServiceResourceType, sourceCluster, namespace, name)
+}
+
+// clearSkippedWrites drops the writes skipped for a cluster admiral no longer watches
+func clearSkippedWrites(cluster string) {
+	skippedWrites.lock.Lock()
+	defer skippedWrites.lock.Unlock()
+	delete(skippedWrites.skipped, cluster)
+}
+
+// getClustersWithSkippedWrites returns the clusters writes were skipped for
+func getClustersWithSkippedWrites() []string {
+	skippedWrites.lock.Lock()
+	defer skippedWrites.lock.Unlock()
+	clusters := make([]string, 0, len(skippedWrites.skipped))
+	for cluster := range skippedWrites.skipped {
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// startDeadClusterProber periodically probes the clusters writes were skipped for as they were
+// unreachable, and replays the skipped writes once a cluster is reachable again
+func startDeadClusterProber(ctx context.Context, rr *RemoteRegistry) {
+	interval := common.GetDeadClusterProbeInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, cluster := range getClustersWithSkippedWrites() {
+				probeDeadCluster(ctx, rr, cluster)
+			}
+		}
+	}
+}
+
+// probeDeadCluster replays the writes skipped for the cluster when its API server responds again
+func probeDeadCluster(ctx context.Context, rr *RemoteRegistry, cluster string) {
+	rc := rr.GetRemoteController(cluster)
+	if rc == nil || rc.VirtualServiceController == nil || rc.VirtualServiceController.IstioClient == nil {
+		return
+	}
+	_, err := rc.VirtualServiceController.IstioClient.Discovery().ServerVersion()
+	if err != nil {
+		log.Debugf(LogErrFormat, "Probe", common.DeadClusterProbe, cluster, cluster, err)
+		return
+	}
+	markClusterReachable(rr, cluster)
+
+	skippedWrites.lock.Lock()
+	writes := skippedWrites.skipped[cluster]
+	delete(skippedWrites.skipped, cluster)
+	skippedWrites.lock.Unlock()
+	if len(writes) == 0 {
+		return
+	}
+	log.Infof(LogFormat, "Resync", "cluster", cluster, cluster, fmt.Sprintf("cluster is reachable again, replaying %d skipped writes", len(writes)))
+	clusterCtx := context.WithValue(ctx, common.ClusterName, cluster)
+	for _, w := range writes {
+		// a write which is skipped again records itself once more, to be replayed on the next recovery
+		err := common.CallWithRecovery(clusterCtx, common.DeadClusterProbe, func() error {
+			return w.write(clusterCtx)
+		})
+		if err != nil {
+			log.Errorf(LogErrFormat, "Resync", w.key, cluster, cluster, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSkippedWrites(t *testing.T) {
+	defer clearSkippedWrites("cluster1")
+	var replayed []string
+	write := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			replayed = append(replayed, name)
+			return nil
+		}
+	}
+
+	recordSkippedWrite("cluster1", "a", write("a1"))
+	recordSkippedWrite("cluster1", "b", write("b1"))
+	// the write skipped again for the same resource replaces the earlier one
+	recordSkippedWrite("cluster1", "a", write("a2"))
+	assert.Equal(t, []string{"cluster1"}, getClustersWithSkippedWrites())
+	for _, w := range skippedWrites.skipped["cluster1"] {
+		w.write(context.Background())
+	}
+	assert.Equal(t, []string{"b1", "a2"}, replayed)
+
+	forgetSkippedWrite("cluster1", "b")
+	assert.Len(t, skippedWrites.skipped["cluster1"], 1)
+	forgetSkippedWrite("cluster1", "a")
+	assert.Empty(t, getClustersWithSkippedWrites())
+}
+
+func TestProbeDeadCluster(t *testing.T) {
+	defer clearSkippedWrites("cluster1")
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	rr.AdmiralCache.UnreachableClusterCache.Store("cluster1", time.Now())
+
+	var (
+		lock   sync.Mutex
+		synced []*v1alpha3.VirtualService
+	)
+	sync := func(ctx context.Context, cluster string, remoteRegistry *RemoteRegistry, virtualService *v1alpha3.VirtualService,
+		event common.Event, syncNamespace string, vSName string, sourceCluster string) error {
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, "cluster1", cluster)
+		assert.Equal(t, "source-cluster", sourceCluster)
+		assert.Equal(t, "sync-ns", syncNamespace)
+		assert.Equal(t, "foo-vs-synced", vSName)
+		synced = append(synced, virtualService)
+		return nil
+	}
+	// the sync renames the VirtualService before it finds the cluster unreachable
+	vs := &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs-synced", Namespace: "foo-ns"}}
+	recordSkippedVirtualServiceSync(sync, "cluster1", rr, vs, "foo-vs", common.Add, "sync-ns", "foo-vs-synced", "source-cluster")
+
+	// the writes are kept while the cluster has no remote controller
+	probeDeadCluster(context.Background(), rr, "cluster1")
+	assert.Empty(t, synced)
+	assert.Equal(t, []string{"cluster1"}, getClustersWithSkippedWrites())
+
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioFake.NewSimpleClientset()},
+	})
+	probeDeadCluster(context.Background(), rr, "cluster1")
+	assert.Len(t, synced, 1)
+	assert.Equal(t, "foo-vs", synced[0].Name)
+	assert.Equal(t, "foo-ns", synced[0].Namespace)
+	assert.Empty(t, getClustersWithSkippedWrites())
+	_, unreachable := rr.AdmiralCache.UnreachableClusterCache.Load("cluster1")
+	assert.False(t, unreachable)
+}
@@ -0,0 +1,275 @@
+package clusters
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// serviceEntryCleanupFunc removes what was generated along with a ServiceEntry, once the ServiceEntry is deleted
+type serviceEntryCleanupFunc func(ctx context.Context, rc *RemoteController) error
+
+// markedServiceEntryCache keeps track of the ServiceEntries marked for deletion, by cluster and namespace/name,
+// and of the cleanup to run once they are deleted
+type markedServiceEntryCache struct {
+	lock     sync.Mutex
+	marked   map[string]map[string]string
+	cleanups map[string]map[string]serviceEntryCleanupFunc
+}
+
+var markedServiceEntries = &markedServiceEntryCache{
+	marked:   make(map[string]map[string]string),
+	cleanups: make(map[string]map[string]serviceEntryCleanupFunc),
+}
+
+// deleteServiceEntryAfterGracePeriod marks the ServiceEntry of a workload which is gone for deletion once the
+// grace period is over, instead of deleting it right away, so that a workload which is only gone for a
+// brief moment does not black-hole its traffic. The cleanup, which removes the DestinationRule, the
+// VirtualServices and the cache entries of the ServiceEntry, is deferred along with it, and dropped when the
+// workload comes back. Without a grace period the ServiceEntry is deleted and cleaned up right away
+func deleteServiceEntryAfterGracePeriod(ctx context.Context, serviceEntry *v1alpha3.ServiceEntry, namespace string, rc *RemoteController, cleanup serviceEntryCleanupFunc) error {
+	gracePeriod := common.GetServiceEntryDeletionGracePeriod()
+	if gracePeriod <= 0 || serviceEntry == nil || common.IsGitOpsOutputMode() {
+		err := deleteServiceEntry(ctx, serviceEntry, namespace, rc)
+		return common.AppendError(err, runServiceEntryCleanup(ctx, rc, cleanup))
+	}
+	seCopy := serviceEntry.DeepCopy()
+	if queueWriteIfPaused(ctx, rc.ClusterID, common.ServiceEntryResourceType, namespace, serviceEntry.Name, func(ctx context.Context, rc *RemoteController) error {
+		return deleteServiceEntryAfterGracePeriod(ctx, seCopy, namespace, rc, cleanup)
+	}) {
+		return nil
+	}
+	client := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace)
+	current, err := client.Get(ctx, serviceEntry.Name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		log.Infof(LogFormat, "Delete", "ServiceEntry", serviceEntry.Name, rc.ClusterID, "Either ServiceEntry was already deleted, or it never existed")
+		return runServiceEntryCleanup(ctx, rc, cleanup)
+	}
+	if err != nil {
+		return err
+	}
+	// the deletion time of a ServiceEntry marked before is kept
+	if deleteAfter, marked := current.Annotations[common.AdmiralDeleteAfterAnnotation]; marked {
+		trackMarkedServiceEntry(rc.ClusterID, namespace, current.Name, deleteAfter)
+		trackServiceEntryCleanup(rc.ClusterID, namespace, current.Name, cleanup)
+		return nil
+	}
+	deleteAfter := time.Now().Add(gracePeriod).UTC().Format(time.RFC3339)
+	if current.Annotations == nil {
+		current.Annotations = map[string]string{}
+	}
+	current.Annotations[common.AdmiralDeleteAfterAnnotation] = deleteAfter
+	_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+	auditMutation(ctx, audit.OperationUpdate, common.ServiceEntryResourceType, rc.ClusterID, current, namespace, "annotated "+common.AdmiralDeleteAfterAnnotation+"="+deleteAfter, err)
+	if err != nil {
+		log.Errorf(LogErrFormat, "Delete", "ServiceEntry", serviceEntry.Name, rc.ClusterID, "failed to mark for deletion: "+err.Error())
+		return err
+	}
+	trackMarkedServiceEntry(rc.ClusterID, namespace, current.Name, deleteAfter)
+	trackServiceEntryCleanup(rc.ClusterID, namespace, current.Name, cleanup)
+	log.Infof(LogFormat, "Delete", "ServiceEntry", serviceEntry.Name, rc.ClusterID, "marked for deletion after "+deleteAfter)
+	return nil
+}
+
+// runServiceEntryCleanup runs the cleanup of a deleted ServiceEntry, if any
+func runServiceEntryCleanup(ctx context.Context, rc *RemoteController, cleanup serviceEntryCleanupFunc) error {
+	if cleanup == nil {
+		return nil
+	}
+	return cleanup(ctx, rc)
+}
+
+// trackMarkedServiceEntry records the ServiceEntry marked for deletion after the given time
+func trackMarkedServiceEntry(cluster, namespace, name, deleteAfter string) {
+	markedServiceEntries.lock.Lock()
+	defer markedServiceEntries.lock.Unlock()
+	if markedServiceEntries.marked[cluster] == nil {
+		markedServiceEntries.marked[cluster] = make(map[string]string)
+	}
+	markedServiceEntries.marked[cluster][namespace+"/"+name] = deleteAfter
+}
+
+// trackServiceEntryCleanup records the cleanup to run once the ServiceEntry marked for deletion is deleted.
+// The cleanup of a ServiceEntry marked before admiral started is not known, what was generated along with it
+// is left to the orphaned resources pruning
+func trackServiceEntryCleanup(cluster, namespace, name string, cleanup serviceEntryCleanupFunc) {
+	if cleanup == nil {
+		return
+	}
+	markedServiceEntries.lock.Lock()
+	defer markedServiceEntries.lock.Unlock()
+	if markedServiceEntries.cleanups[cluster] == nil {
+		markedServiceEntries.cleanups[cluster] = make(map[string]serviceEntryCleanupFunc)
+	}
+	markedServiceEntries.cleanups[cluster][namespace+"/"+name] = cleanup
+}
+
+// forgetMarkedServiceEntry drops the ServiceEntry which was deleted, or is no longer marked for deletion, along
+// with its cleanup, which is returned
+func forgetMarkedServiceEntry(cluster, key string) serviceEntryCleanupFunc {
+	markedServiceEntries.lock.Lock()
+	defer markedServiceEntries.lock.Unlock()
+	delete(markedServiceEntries.marked[cluster], key)
+	if len(markedServiceEntries.marked[cluster]) == 0 {
+		delete(markedServiceEntries.marked, cluster)
+	}
+	cleanup := markedServiceEntries.cleanups[cluster][key]
+	delete(markedServiceEntries.cleanups[cluster], key)
+	if len(markedServiceEntries.cleanups[cluster]) == 0 {
+		delete(markedServiceEntries.cleanups, cluster)
+	}
+	return cleanup
+}
+
+// unmarkServiceEntry drops the mark of a ServiceEntry written again as its workload came back, so that
+// neither the ServiceEntry nor what was generated along with it is deleted
+func unmarkServiceEntry(cluster, namespace, name string) {
+	if forgetMarkedServiceEntry(cluster, namespace+"/"+name) != nil {
+		log.Infof(LogFormat, "Delete", "ServiceEntry", name, cluster, "deletion canceled as the workload came back during the grace period")
+	}
+}
+
+// clearMarkedServiceEntries drops the ServiceEntries marked for deletion of a cluster admiral no longer watches
+func clearMarkedServiceEntries(cluster string) {
+	markedServiceEntries.lock.Lock()
+	defer markedServiceEntries.lock.Unlock()
+	delete(markedServiceEntries.marked, cluster)
+	delete(markedServiceEntries.cleanups, cluster)
+}
+
+// getMarkedServiceEntries returns the ServiceEntries of the cluster marked for deletion, by namespace/name
+func getMarkedServiceEntries(cluster string) map[string]string {
+	markedServiceEntries.lock.Lock()
+	defer markedServiceEntries.lock.Unlock()
+	marked := make(map[string]string, len(markedServiceEntries.marked[cluster]))
+	for key, deleteAfter := range markedServiceEntries.marked[cluster] {
+		marked[key] = deleteAfter
+	}
+	return marked
+}
+
+// startDeferredServiceEntryDeleter periodically deletes the ServiceEntries whose grace period is over. The
+// ServiceEntries marked before admiral started are looked up in the sync namespaces on the first run
+func startDeferredServiceEntryDeleter(ctx context.Context, rr *RemoteRegistry) {
+	gracePeriod := common.GetServiceEntryDeletionGracePeriod()
+	if gracePeriod <= 0 {
+		return
+	}
+	interval := gracePeriod
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	recovered := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, cluster := range rr.GetClusterIds() {
+				if !recovered {
+					trackServiceEntriesMarkedBefore(ctx, rr, cluster)
+				}
+				deleteExpiredServiceEntries(ctx, rr, cluster, time.Now())
+			}
+			recovered = true
+		}
+	}
+}
+
+// trackServiceEntriesMarkedBefore records the ServiceEntries marked for deletion in the sync namespace of the
+// cluster and in the sync namespaces requested by the identities
+func trackServiceEntriesMarkedBefore(ctx context.Context, rr *RemoteRegistry, cluster string) {
+	rc := rr.GetRemoteController(cluster)
+	if rc == nil || rc.ServiceEntryController == nil || rc.ServiceEntryController.IstioClient == nil {
+		return
+	}
+	for _, syncNamespace := range getSyncNamespacesForCluster(rr.AdmiralCache, cluster) {
+		seList, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(syncNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Warnf(LogErrFormat, "List", "ServiceEntry", syncNamespace, cluster, "failed to look up the ServiceEntries marked for deletion: "+err.Error())
+			continue
+		}
+		for _, se := range seList.Items {
+			if deleteAfter, marked := se.Annotations[common.AdmiralDeleteAfterAnnotation]; marked {
+				trackMarkedServiceEntry(cluster, se.Namespace, se.Name, deleteAfter)
+			}
+		}
+	}
+}
+
+// deleteExpiredServiceEntries deletes the ServiceEntries of the cluster whose grace period is over. A ServiceEntry
+// written again since it was marked, as its workload came back, is no longer marked and is kept. The deletion
+// is conditional on the version which was checked, so a ServiceEntry written again meanwhile is kept as well
+func deleteExpiredServiceEntries(ctx context.Context, rr *RemoteRegistry, cluster string, now time.Time) {
+	rc := rr.GetRemoteController(cluster)
+	if rc == nil || rc.ServiceEntryController == nil || rc.ServiceEntryController.IstioClient == nil || IsClusterWritePaused(cluster) {
+		return
+	}
+	for key, deleteAfter := range getMarkedServiceEntries(cluster) {
+		if !isGracePeriodOver(deleteAfter, now) {
+			continue
+		}
+		namespace, name, _ := strings.Cut(key, "/")
+		client := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace)
+		current, err := client.Get(ctx, name, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			runExpiredServiceEntryCleanup(ctx, rc, name, forgetMarkedServiceEntry(cluster, key))
+			continue
+		}
+		if err != nil {
+			log.Warnf(LogErrFormat, "Delete", "ServiceEntry", name, cluster, err.Error())
+			continue
+		}
+		currentDeleteAfter, marked := current.Annotations[common.AdmiralDeleteAfterAnnotation]
+		if !marked {
+			log.Infof(LogFormat, "Delete", "ServiceEntry", name, cluster, "kept as the workload came back during the grace period")
+			forgetMarkedServiceEntry(cluster, key)
+			continue
+		}
+		if !isGracePeriodOver(currentDeleteAfter, now) {
+			trackMarkedServiceEntry(cluster, namespace, name, currentDeleteAfter)
+			continue
+		}
+		resourceVersion := current.ResourceVersion
+		err = client.Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion}})
+		if k8sErrors.IsConflict(err) {
+			// written again since it was checked, it is checked again on the next run
+			continue
+		}
+		if !k8sErrors.IsNotFound(err) {
+			auditMutation(ctx, audit.OperationDelete, common.ServiceEntryResourceType, cluster, current, namespace, "", err)
+		}
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			log.Errorf(LogErrFormat, "Delete", "ServiceEntry", name, cluster, err)
+			continue
+		}
+		log.Infof(LogFormat, "Delete", "ServiceEntry", name, cluster, "deleted after the grace period was over")
+		runExpiredServiceEntryCleanup(ctx, rc, name, forgetMarkedServiceEntry(cluster, key))
+	}
+}
+
+// runExpiredServiceEntryCleanup runs the cleanup of a ServiceEntry deleted after its grace period. A failed
+// cleanup is not retried, what is left is pruned as orphaned resources
+func runExpiredServiceEntryCleanup(ctx context.Context, rc *RemoteController, name string, cleanup serviceEntryCleanupFunc) {
+	err := runServiceEntryCleanup(ctx, rc, cleanup)
+	if err != nil {
+		log.Errorf(LogErrFormat, "Delete", "ServiceEntry", name, rc.ClusterID, "failed to clean up after the deletion: "+err.Error())
+	}
+}
+
+// isGracePeriodOver checks if the deletion time the ServiceEntry was marked with has passed. A deletion
+// time which cannot be parsed is considered passed
+func isGracePeriodOver(deleteAfter string, now time.Time) bool {
+	deadline, err := time.Parse(time.RFC3339, deleteAfter)
+	return err != nil || !now.Before(deadline)
+}
@@ -0,0 +1,262 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeleteServiceEntryAfterGracePeriod(t *testing.T) {
+	defer clearMarkedServiceEntries("cluster1")
+	ctx := context.Background()
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "ns"},
+		Spec:       networkingV1Alpha3.ServiceEntry{Hosts: []string{"stage.foo.global"}},
+	}
+	istioClient := istioFake.NewSimpleClientset(se)
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", rc)
+	getServiceEntry := func() (*v1alpha3.ServiceEntry, error) {
+		return istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, se.Name, metaV1.GetOptions{})
+	}
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, ServiceEntryDeletionGracePeriod: time.Minute})
+
+	cleanups := 0
+	cleanUp := func(context.Context, *RemoteController) error {
+		cleanups++
+		return nil
+	}
+
+	// the ServiceEntry is marked for deletion instead of being deleted, and is not cleaned up yet
+	assert.Nil(t, deleteServiceEntryAfterGracePeriod(ctx, se, "ns", rc, cleanUp))
+	marked, err := getServiceEntry()
+	assert.Nil(t, err)
+	deleteAfter := marked.Annotations[common.AdmiralDeleteAfterAnnotation]
+	assert.NotEmpty(t, deleteAfter)
+	assert.Equal(t, map[string]string{"ns/stage.foo.global-se": deleteAfter}, getMarkedServiceEntries("cluster1"))
+	assert.Equal(t, 0, cleanups)
+
+	// marking it again keeps the deletion time
+	assert.Nil(t, deleteServiceEntryAfterGracePeriod(ctx, se, "ns", rc, cleanUp))
+	marked, err = getServiceEntry()
+	assert.Nil(t, err)
+	assert.Equal(t, deleteAfter, marked.Annotations[common.AdmiralDeleteAfterAnnotation])
+
+	// it is kept during the grace period
+	deleteExpiredServiceEntries(ctx, rr, "cluster1", time.Now())
+	_, err = getServiceEntry()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cleanups)
+
+	// it is deleted and cleaned up once the grace period is over
+	deleteExpiredServiceEntries(ctx, rr, "cluster1", time.Now().Add(2*time.Minute))
+	_, err = getServiceEntry()
+	assert.NotNil(t, err)
+	assert.Empty(t, getMarkedServiceEntries("cluster1"))
+	assert.Equal(t, 1, cleanups)
+
+	// without a grace period it is deleted and cleaned up right away
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("ns").Create(ctx, se, metaV1.CreateOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, deleteServiceEntryAfterGracePeriod(ctx, se, "ns", rc, cleanUp))
+	_, err = getServiceEntry()
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, cleanups)
+}
+
+func TestServiceEntryWrittenAgainIsUnmarked(t *testing.T) {
+	defer clearMarkedServiceEntries("cluster1")
+	ctx := context.Background()
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "ns"},
+		Spec: networkingV1Alpha3.ServiceEntry{
+			Hosts:     []string{"stage.foo.global"},
+			Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "foo.ns.svc.cluster.local", Locality: "us-west-2"}},
+		},
+	}
+	istioClient := istioFake.NewSimpleClientset(se)
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", rc)
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", ServiceEntryDeletionGracePeriod: time.Minute})
+	cleanups := 0
+	cleanUp := func(context.Context, *RemoteController) error {
+		cleanups++
+		return nil
+	}
+
+	assert.Nil(t, deleteServiceEntryAfterGracePeriod(ctx, se, "ns", rc, cleanUp))
+	marked, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, se.Name, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, marked.Annotations, common.AdmiralDeleteAfterAnnotation)
+
+	// the identity came back and its ServiceEntry is written again, without the mark
+	err = addUpdateServiceEntry(log.WithField("test", t.Name()), ctx, se.DeepCopy(), marked, "ns", rc)
+	assert.Nil(t, err)
+	assert.Empty(t, getMarkedServiceEntries("cluster1"))
+	written, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, se.Name, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotContains(t, written.Annotations, common.AdmiralDeleteAfterAnnotation)
+
+	// neither the ServiceEntry nor what was generated along with it is deleted
+	deleteExpiredServiceEntries(ctx, rr, "cluster1", time.Now().Add(2*time.Minute))
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, se.Name, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, cleanups)
+}
+
+func TestDeleteExpiredServiceEntriesKeepsUnmarkedServiceEntry(t *testing.T) {
+	defer clearMarkedServiceEntries("cluster1")
+	ctx := context.Background()
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        "stage.foo.global-se",
+			Namespace:   "ns",
+			Annotations: map[string]string{common.AdmiralDeleteAfterAnnotation: "2024-01-01T00:00:00Z"},
+		},
+	}
+	istioClient := istioFake.NewSimpleClientset(se)
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	})
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", ServiceEntryDeletionGracePeriod: time.Minute})
+
+	// the ServiceEntries marked before admiral started are tracked
+	trackServiceEntriesMarkedBefore(ctx, rr, "cluster1")
+	assert.Equal(t, map[string]string{"ns/stage.foo.global-se": "2024-01-01T00:00:00Z"}, getMarkedServiceEntries("cluster1"))
+
+	// the workload came back and the ServiceEntry was written again without the mark
+	se.Annotations = nil
+	_, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Update(ctx, se, metaV1.UpdateOptions{})
+	assert.Nil(t, err)
+	deleteExpiredServiceEntries(ctx, rr, "cluster1", time.Now())
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, se.Name, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, getMarkedServiceEntries("cluster1"))
+}
+
+func TestTrackServiceEntriesMarkedBeforeInSyncNamespaces(t *testing.T) {
+	defer clearMarkedServiceEntries("cluster1")
+	ctx := context.Background()
+	markedServiceEntry := func(namespace string) *v1alpha3.ServiceEntry {
+		return &v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "stage.foo.global-se",
+			Namespace:   namespace,
+			Annotations: map[string]string{common.AdmiralDeleteAfterAnnotation: "2024-01-01T00:00:00Z"},
+		}}
+	}
+	istioClient := istioFake.NewSimpleClientset(markedServiceEntry("ns"), markedServiceEntry("cluster1-ns"), markedServiceEntry("team-sync"))
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.AdmiralCache.IdentitySyncNamespaceCache.Put("foo", "team-sync")
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	})
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "ns",
+		ClusterSyncNamespaces:         map[string]string{"cluster1": "cluster1-ns"},
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	})
+
+	// the ServiceEntries marked in the sync namespace of the cluster and of the identities are tracked
+	trackServiceEntriesMarkedBefore(ctx, rr, "cluster1")
+	assert.Equal(t, map[string]string{
+		"cluster1-ns/stage.foo.global-se": "2024-01-01T00:00:00Z",
+		"team-sync/stage.foo.global-se":   "2024-01-01T00:00:00Z",
+	}, getMarkedServiceEntries("cluster1"))
+}
+
+func TestIsGracePeriodOver(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.False(t, isGracePeriodOver("2024-01-01T00:01:00Z", now))
+	assert.True(t, isGracePeriodOver("2024-01-01T00:00:00Z", now))
+	assert.True(t, isGracePeriodOver("invalid", now))
+}
+
+func TestAddServiceEntriesWithDrWorkerDefersTheCleanup(t *testing.T) {
+	defer clearMarkedServiceEntries("cluster1")
+	ctx := context.WithValue(context.Background(), common.EventResourceType, common.Deployment)
+	generated := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "ns", Annotations: generated},
+			Spec: networkingV1Alpha3.ServiceEntry{
+				Hosts:     []string{"stage.foo.global"},
+				Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "foo.ns.svc.cluster.local", Locality: "us-west-2"}},
+			},
+		},
+		&v1alpha3.DestinationRule{
+			ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-default-dr", Namespace: "ns", Annotations: generated},
+			Spec:       networkingV1Alpha3.DestinationRule{Host: "stage.foo.global"},
+		},
+	)
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient, Cache: istio.NewServiceEntryCache()},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient, Cache: istio.NewDestinationRuleCache()},
+		VirtualServiceController:  &istio.VirtualServiceController{IstioClient: istioClient},
+		NodeController:            &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}},
+	}
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                        &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SyncNamespace:                   "ns",
+		ServiceEntryDeletionGracePeriod: time.Minute,
+	})
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", rc)
+	rr.AdmiralCache.SeClusterCache.Put("stage.foo.global", "cluster1", "cluster1")
+
+	// the workload is gone, its ServiceEntry is written without endpoints
+	clusters := make(chan string, 1)
+	errors := make(chan error, 1)
+	clusters <- "cluster1"
+	close(clusters)
+	AddServiceEntriesWithDrWorker(log.WithField("test", t.Name()), ctx, rr, false, false, "foo", "stage", "",
+		&networkingV1Alpha3.ServiceEntry{Hosts: []string{"stage.foo.global"}}, clusters, errors)
+	assert.Nil(t, <-errors)
+
+	// the DestinationRule and the cache entries are kept along with the marked ServiceEntry
+	se, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, "stage.foo.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, se.Annotations, common.AdmiralDeleteAfterAnnotation)
+	_, err = istioClient.NetworkingV1alpha3().DestinationRules("ns").Get(ctx, "stage.foo.global-default-dr", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotNil(t, rr.AdmiralCache.SeClusterCache.Get("stage.foo.global"))
+
+	// they are removed with the ServiceEntry once its grace period is over
+	deleteExpiredServiceEntries(ctx, rr, "cluster1", time.Now().Add(2*time.Minute))
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, "stage.foo.global-se", metaV1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = istioClient.NetworkingV1alpha3().DestinationRules("ns").Get(ctx, "stage.foo.global-default-dr", metaV1.GetOptions{})
+	assert.NotNil(t, err)
+	assert.Nil(t, rr.AdmiralCache.SeClusterCache.Get("stage.foo.global"))
+}
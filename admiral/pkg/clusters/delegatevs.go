@@ -0,0 +1,328 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getDelegateReferences returns the delegate VirtualServices the http routes of the VirtualService
+// delegate to, with the namespace of the VirtualService when the delegate does not set one
+func getDelegateReferences(vs *v1alpha3.VirtualService) []*networking.Delegate {
+	delegates := make([]*networking.Delegate, 0)
+	seen := make(map[string]bool)
+	for _, httpRoute := range vs.Spec.Http {
+		if httpRoute == nil || httpRoute.Delegate == nil || httpRoute.Delegate.Name == "" {
+			continue
+		}
+		namespace := httpRoute.Delegate.Namespace
+		if namespace == "" {
+			namespace = vs.Namespace
+		}
+		if seen[namespace+"/"+httpRoute.Delegate.Name] {
+			continue
+		}
+		seen[namespace+"/"+httpRoute.Delegate.Name] = true
+		delegates = append(delegates, &networking.Delegate{Name: httpRoute.Delegate.Name, Namespace: namespace})
+	}
+	return delegates
+}
+
+// isDelegatingTo checks if an http route of the VirtualService delegates to the given VirtualService
+func isDelegatingTo(vs *v1alpha3.VirtualService, namespace, name string) bool {
+	for _, delegate := range getDelegateReferences(vs) {
+		if delegate.Namespace == namespace && delegate.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getRootVirtualServices returns the VirtualServices of the cluster delegating to the given VirtualService
+func getRootVirtualServices(ctx context.Context, ctxLogger *log.Entry, rc *RemoteController, namespace, name string) ([]*v1alpha3.VirtualService, error) {
+	virtualServices, err := getAllVirtualServices(ctxLogger, ctx, rc, metaV1.NamespaceAll, metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]*v1alpha3.VirtualService, 0)
+	if virtualServices == nil {
+		return roots, nil
+	}
+	for _, vs := range virtualServices.Items {
+		if len(vs.Spec.Hosts) > 0 && isDelegatingTo(vs, namespace, name) {
+			roots = append(roots, vs)
+		}
+	}
+	return roots, nil
+}
+
+// getDelegateVirtualService fetches the delegate VirtualService from the cluster, it returns nil
+// when the delegate does not exist
+func getDelegateVirtualService(ctx context.Context, rc *RemoteController, delegate *networking.Delegate) (*v1alpha3.VirtualService, error) {
+	if rc == nil || rc.VirtualServiceController == nil || rc.VirtualServiceController.IstioClient == nil {
+		return nil, fmt.Errorf("virtualservice controller is not initialized")
+	}
+	vs, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(delegate.Namespace).Get(ctx, delegate.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return vs, err
+}
+
+// resolveDelegateRoutes returns a copy of the VirtualService where the http routes delegating to
+// another VirtualService are replaced by the http routes of the delegate, whose match conditions
+// are merged with the ones of the route delegating to them. Routes of a delegate which does not
+// exist are dropped, as istio ignores them
+func resolveDelegateRoutes(ctx context.Context, ctxLogger *log.Entry, rc *RemoteController, vs *v1alpha3.VirtualService) (*v1alpha3.VirtualService, error) {
+	if len(getDelegateReferences(vs)) == 0 {
+		return vs, nil
+	}
+	resolved := vs.DeepCopy()
+	resolvedRoutes := make([]*networking.HTTPRoute, 0, len(vs.Spec.Http))
+	for _, httpRoute := range resolved.Spec.Http {
+		if httpRoute.Delegate == nil {
+			resolvedRoutes = append(resolvedRoutes, httpRoute)
+			continue
+		}
+		delegate := &networking.Delegate{Name: httpRoute.Delegate.Name, Namespace: httpRoute.Delegate.Namespace}
+		if delegate.Namespace == "" {
+			delegate.Namespace = vs.Namespace
+		}
+		delegateVS, err := getDelegateVirtualService(ctx, rc, delegate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch delegate virtualservice %s/%s due to %w", delegate.Namespace, delegate.Name, err)
+		}
+		if delegateVS == nil {
+			ctxLogger.Warnf(common.CtxLogFormat, "resolveDelegateRoutes", vs.Name, vs.Namespace, rc.ClusterID,
+				fmt.Sprintf("delegate virtualservice %s/%s not found, dropping its routes", delegate.Namespace, delegate.Name))
+			continue
+		}
+		for _, delegateRoute := range delegateVS.Spec.Http {
+			// istio does not support delegation chains longer than one level
+			if delegateRoute.Delegate != nil {
+				continue
+			}
+			mergedRoute := delegateRoute.DeepCopy()
+			mergedRoute.Match = mergeDelegateHTTPMatchRequests(httpRoute.Match, delegateRoute.Match)
+			if mergedRoute.Name == "" {
+				mergedRoute.Name = httpRoute.Name
+			}
+			mergeHTTPRoutePolicies(mergedRoute, httpRoute)
+			resolvedRoutes = append(resolvedRoutes, mergedRoute)
+		}
+	}
+	resolved.Spec.Http = resolvedRoutes
+	return resolved, nil
+}
+
+// mergeDelegateHTTPMatchRequests merges the match conditions of a route delegating to another
+// VirtualService with the ones of a route of the delegate the way istio does, so that the header,
+// query param and URI matches of both routes are kept. Every condition of the delegate route gets
+// the fields it does not set from every condition of the route delegating to it
+func mergeDelegateHTTPMatchRequests(parentMatches, delegateMatches []*networking.HTTPMatchRequest) []*networking.HTTPMatchRequest {
+	if len(parentMatches) == 0 {
+		return delegateMatches
+	}
+	if len(delegateMatches) == 0 {
+		mergedMatches := make([]*networking.HTTPMatchRequest, 0, len(parentMatches))
+		for _, parentMatch := range parentMatches {
+			mergedMatches = append(mergedMatches, parentMatch.DeepCopy())
+		}
+		return mergedMatches
+	}
+	mergedMatches := make([]*networking.HTTPMatchRequest, 0, len(parentMatches)*len(delegateMatches))
+	for _, parentMatch := range parentMatches {
+		for _, delegateMatch := range delegateMatches {
+			merged := delegateMatch.DeepCopy()
+			if merged.Uri == nil {
+				merged.Uri = parentMatch.Uri
+			}
+			if merged.Scheme == nil {
+				merged.Scheme = parentMatch.Scheme
+			}
+			if merged.Method == nil {
+				merged.Method = parentMatch.Method
+			}
+			if merged.Authority == nil {
+				merged.Authority = parentMatch.Authority
+			}
+			if merged.Port == 0 {
+				merged.Port = parentMatch.Port
+			}
+			if merged.SourceNamespace == "" {
+				merged.SourceNamespace = parentMatch.SourceNamespace
+			}
+			merged.Headers = mergeStringMatches(parentMatch.Headers, merged.Headers)
+			merged.QueryParams = mergeStringMatches(parentMatch.QueryParams, merged.QueryParams)
+			merged.WithoutHeaders = mergeStringMatches(parentMatch.WithoutHeaders, merged.WithoutHeaders)
+			if len(merged.SourceLabels) == 0 {
+				merged.SourceLabels = parentMatch.SourceLabels
+			}
+			if len(merged.Gateways) == 0 {
+				merged.Gateways = parentMatch.Gateways
+			}
+			mergedMatches = append(mergedMatches, merged.DeepCopy())
+		}
+	}
+	return mergedMatches
+}
+
+// mergeStringMatches returns the string matches of the delegate, along with the ones of the parent it does not set
+func mergeStringMatches(parent, delegate map[string]*networking.StringMatch) map[string]*networking.StringMatch {
+	if len(parent) == 0 {
+		return delegate
+	}
+	merged := make(map[string]*networking.StringMatch, len(parent)+len(delegate))
+	for key, match := range parent {
+		merged[key] = match
+	}
+	for key, match := range delegate {
+		merged[key] = match
+	}
+	return merged
+}
+
+// rewriteDelegateReferences points the delegate routes of a VirtualService synced to syncNamespace
+// to the copies of the delegates synced along with it
+func rewriteDelegateReferences(vs *v1alpha3.VirtualService, sourceNamespace, syncNamespace string, delegateSyncNames map[string]string) {
+	for _, httpRoute := range vs.Spec.Http {
+		if httpRoute == nil || httpRoute.Delegate == nil {
+			continue
+		}
+		namespace := httpRoute.Delegate.Namespace
+		if namespace == "" {
+			namespace = sourceNamespace
+		}
+		if syncName, ok := delegateSyncNames[namespace+"/"+httpRoute.Delegate.Name]; ok {
+			httpRoute.Delegate = &networking.Delegate{Name: syncName, Namespace: syncNamespace}
+		}
+	}
+}
+
+// getDelegateSyncNames returns the names the delegates of the VirtualService are synced with, keyed by namespace/name
+func getDelegateSyncNames(ctx context.Context, sourceRC *RemoteController, delegates []*networking.Delegate) map[string]string {
+	syncNames := make(map[string]string, len(delegates))
+	for _, delegate := range delegates {
+		var identity string
+		if delegateVS, err := getDelegateVirtualService(ctx, sourceRC, delegate); err == nil && delegateVS != nil {
+			identity = getVirtualServiceIdentity(delegateVS)
+		}
+		syncNames[delegate.Namespace+"/"+delegate.Name] = common.GenerateNameForVS(common.GetVSNameStrategy(), delegate.Namespace, delegate.Name, identity)
+	}
+	return syncNames
+}
+
+// syncDelegateVirtualServices copies the delegates of a VirtualService to the cluster it is synced to,
+// so that the copy of the VirtualService delegates to copies which are in the same sync namespace.
+// When host is set, the local destinations of the delegates are rewritten to it.
+// The copy of a delegate is deleted when the delegate no longer exists, or when the VirtualService is
+// deleted and no other VirtualService of the source cluster delegates to it
+func syncDelegateVirtualServices(
+	ctx context.Context,
+	ctxLogger *log.Entry,
+	remoteRegistry *RemoteRegistry,
+	sourceRC *RemoteController,
+	rc *RemoteController,
+	delegates []*networking.Delegate,
+	delegateSyncNames map[string]string,
+	host string,
+	event common.Event,
+	syncNamespace string) error {
+	var allErrors error
+	for _, delegate := range delegates {
+		source := delegate.Namespace + "/" + delegate.Name
+		syncName := delegateSyncNames[source]
+		delegateVS, err := getDelegateVirtualService(ctx, sourceRC, delegate)
+		if err != nil {
+			allErrors = common.AppendError(allErrors, err)
+			continue
+		}
+		if event == common.Delete && delegateVS != nil {
+			roots, err := getRootVirtualServices(ctx, ctxLogger, sourceRC, delegate.Namespace, delegate.Name)
+			if err != nil || len(roots) > 0 {
+				continue
+			}
+		}
+		exist, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, syncName, metaV1.GetOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			allErrors = common.AppendError(allErrors, err)
+			continue
+		}
+		if k8sErrors.IsNotFound(err) {
+			exist = nil
+		}
+		if isVirtualServiceNameCollision(exist, source) {
+			reportVirtualServiceNameCollision(ctxLogger, syncName, getVirtualServiceSource(exist), source, rc.ClusterID)
+			continue
+		}
+		if event == common.Delete || delegateVS == nil {
+			if exist == nil || !isGeneratedByAdmiral(exist.Annotations) {
+				continue
+			}
+			if err := deleteVirtualService(ctx, syncName, syncNamespace, rc); err != nil {
+				allErrors = common.AppendError(allErrors, err)
+			}
+			continue
+		}
+		annotations := make(map[string]string, len(delegateVS.Annotations)+1)
+		for key, value := range delegateVS.Annotations {
+			annotations[key] = value
+		}
+		annotations[common.AdmiralSourceVSAnnotation] = source
+		delegateCopy := &v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        syncName,
+				Namespace:   syncNamespace,
+				Labels:      delegateVS.Labels,
+				Annotations: annotations,
+			},
+			Spec: *delegateVS.Spec.DeepCopy(),
+		}
+		// the destinations of a delegate are rewritten like the ones of the VirtualService delegating to it
+		for _, httpRoute := range delegateCopy.Spec.Http {
+			for _, destination := range httpRoute.Route {
+				if host != "" && strings.HasSuffix(destination.Destination.Host, common.DotLocalDomainSuffix) {
+					destination.Destination.Host = host
+				}
+			}
+		}
+		if err := addUpdateVirtualService(ctxLogger, ctx, delegateCopy, exist, syncNamespace, rc, remoteRegistry); err != nil {
+			allErrors = common.AppendError(allErrors, err)
+		}
+	}
+	return allErrors
+}
+
+// handleDelegateVirtualServiceEvent syncs the VirtualServices delegating to a VirtualService without
+// hosts, which syncs the delegate along with them
+func (vh *VirtualServiceHandler) handleDelegateVirtualServiceEvent(ctx context.Context, virtualService *v1alpha3.VirtualService, event common.Event) error {
+	ctxLogger := log.WithFields(log.Fields{"type": "VirtualService", "identity": virtualService.Name})
+	rc := vh.remoteRegistry.GetRemoteController(vh.clusterID)
+	var roots []*v1alpha3.VirtualService
+	if rc != nil && rc.VirtualServiceController != nil {
+		var err error
+		roots, err = getRootVirtualServices(ctx, ctxLogger, rc, virtualService.Namespace, virtualService.Name)
+		if err != nil {
+			return err
+		}
+	}
+	if len(roots) == 0 {
+		log.Infof(LogFormat, "Event", common.VirtualServiceResourceType, virtualService.Name, vh.clusterID, "No hosts found in VirtualService, will not sync to other clusters")
+		return nil
+	}
+	for _, root := range roots {
+		log.Infof(LogFormat, event, common.VirtualServiceResourceType, virtualService.Name, vh.clusterID,
+			fmt.Sprintf("syncing virtualservice %s/%s delegating to it", root.Namespace, root.Name))
+		if err := vh.handleVirtualServiceEvent(ctx, root, common.Update); err != nil {
+			log.Errorf(LogErrFormat, event, common.VirtualServiceResourceType, root.Name, vh.clusterID, err)
+		}
+	}
+	return nil
+}
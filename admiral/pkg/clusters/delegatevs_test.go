@@ -0,0 +1,232 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDelegateTestVirtualServices() (*v1alpha3.VirtualService, *v1alpha3.VirtualService) {
+	root := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "root-vs", Namespace: "foo-ns"},
+		Spec: networking.VirtualService{
+			Hosts: []string{"stage.foo.global"},
+			Http: []*networking.HTTPRoute{
+				{
+					Name:     "api",
+					Match:    []*networking.HTTPMatchRequest{{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/api"}}}},
+					Delegate: &networking.Delegate{Name: "delegate-vs"},
+				},
+				{
+					Name:  "default",
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "foo.foo-ns.svc.cluster.local"}}},
+				},
+			},
+		},
+	}
+	delegate := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "delegate-vs", Namespace: "foo-ns"},
+		Spec: networking.VirtualService{
+			Http: []*networking.HTTPRoute{
+				{
+					Name:  "api-v2",
+					Match: []*networking.HTTPMatchRequest{{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/api/v2"}}}},
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "foo-v2.foo-ns.svc.cluster.local"}}},
+				},
+				{
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "foo-v1.foo-ns.svc.cluster.local"}}},
+				},
+			},
+		},
+	}
+	return root, delegate
+}
+
+func TestResolveDelegateRoutes(t *testing.T) {
+	root, delegate := newDelegateTestVirtualServices()
+	testCases := []struct {
+		name                 string
+		existing             []*v1alpha3.VirtualService
+		expectedRouteNames   []string
+		expectedRouteMatches []string
+	}{
+		{
+			name: "Given a virtualservice delegating to an existing delegate, " +
+				"When resolveDelegateRoutes is called, " +
+				"Then the delegating route should be replaced by the routes of the delegate",
+			existing:             []*v1alpha3.VirtualService{root, delegate},
+			expectedRouteNames:   []string{"api-v2", "api", "default"},
+			expectedRouteMatches: []string{"/api/v2", "/api", ""},
+		},
+		{
+			name: "Given a virtualservice delegating to a missing delegate, " +
+				"When resolveDelegateRoutes is called, " +
+				"Then the delegating route should be dropped",
+			existing:             []*v1alpha3.VirtualService{root},
+			expectedRouteNames:   []string{"default"},
+			expectedRouteMatches: []string{""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			istioClient := istioFake.NewSimpleClientset()
+			for _, vs := range tc.existing {
+				_, err := istioClient.NetworkingV1alpha3().VirtualServices(vs.Namespace).Create(context.Background(), vs, metaV1.CreateOptions{})
+				assert.Nil(t, err)
+			}
+			rc := &RemoteController{
+				ClusterID:                "cluster1",
+				VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+			}
+
+			resolved, err := resolveDelegateRoutes(context.Background(), log.WithField("type", "VirtualService"), rc, root)
+
+			assert.Nil(t, err)
+			routeNames := make([]string, 0)
+			routeMatches := make([]string, 0)
+			for _, route := range resolved.Spec.Http {
+				assert.Nil(t, route.Delegate)
+				routeNames = append(routeNames, route.Name)
+				match := ""
+				if len(route.Match) > 0 {
+					match = route.Match[0].Uri.GetPrefix()
+				}
+				routeMatches = append(routeMatches, match)
+			}
+			assert.Equal(t, tc.expectedRouteNames, routeNames)
+			assert.Equal(t, tc.expectedRouteMatches, routeMatches)
+			assert.NotNil(t, root.Spec.Http[0].Delegate)
+		})
+	}
+}
+
+func TestSyncVirtualServiceWithDelegates(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{},
+		SyncNamespace: "admiral-sync",
+	})
+	root, delegate := newDelegateTestVirtualServices()
+	ctx := context.Background()
+	sourceClient := istioFake.NewSimpleClientset(root, delegate)
+	dependentClient := istioFake.NewSimpleClientset()
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: sourceClient},
+	})
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:                "cluster2",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: dependentClient},
+	})
+
+	err := syncVirtualServiceToDependentCluster(ctx, "cluster2", rr, root.DeepCopy(), common.Add, "admiral-sync", "foo-ns-root-vs", "cluster1")
+	assert.Nil(t, err)
+
+	rootCopy, err := dependentClient.NetworkingV1alpha3().VirtualServices("admiral-sync").Get(ctx, "foo-ns-root-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, &networking.Delegate{Name: "foo-ns-delegate-vs", Namespace: "admiral-sync"}, rootCopy.Spec.Http[0].Delegate)
+	delegateCopy, err := dependentClient.NetworkingV1alpha3().VirtualServices("admiral-sync").Get(ctx, "foo-ns-delegate-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "foo-ns/delegate-vs", delegateCopy.Annotations[common.AdmiralSourceVSAnnotation])
+	assert.Equal(t, "stage.foo.global", delegateCopy.Spec.Http[0].Route[0].Destination.Host)
+
+	// the delegate copy is deleted along with the root, as no other virtualservice delegates to it
+	err = sourceClient.NetworkingV1alpha3().VirtualServices("foo-ns").Delete(ctx, "root-vs", metaV1.DeleteOptions{})
+	assert.Nil(t, err)
+	err = syncVirtualServiceToDependentCluster(ctx, "cluster2", rr, root.DeepCopy(), common.Delete, "admiral-sync", "foo-ns-root-vs", "cluster1")
+	assert.Nil(t, err)
+	virtualServices, err := dependentClient.NetworkingV1alpha3().VirtualServices("admiral-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, virtualServices.Items, 0)
+}
+
+func TestGetRootVirtualServices(t *testing.T) {
+	root, delegate := newDelegateTestVirtualServices()
+	other := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "other-vs", Namespace: "bar-ns"},
+		Spec: networking.VirtualService{
+			Hosts: []string{"stage.bar.global"},
+			Http:  []*networking.HTTPRoute{{Delegate: &networking.Delegate{Name: "delegate-vs", Namespace: "bar-ns"}}},
+		},
+	}
+	rc := &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioFake.NewSimpleClientset(root, delegate, other)},
+	}
+
+	roots, err := getRootVirtualServices(context.Background(), log.WithField("type", "VirtualService"), rc, "foo-ns", "delegate-vs")
+
+	assert.Nil(t, err)
+	assert.Len(t, roots, 1)
+	assert.Equal(t, "root-vs", roots[0].Name)
+}
+
+func TestMergeDelegateHTTPMatchRequests(t *testing.T) {
+	prefix := func(p string) *networking.StringMatch {
+		return &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: p}}
+	}
+	exact := func(e string) *networking.StringMatch {
+		return &networking.StringMatch{MatchType: &networking.StringMatch_Exact{Exact: e}}
+	}
+	parentMatches := []*networking.HTTPMatchRequest{{
+		Uri:         prefix("/api"),
+		Headers:     map[string]*networking.StringMatch{"x-team": exact("a")},
+		QueryParams: map[string]*networking.StringMatch{"debug": exact("true")},
+	}}
+	testCases := []struct {
+		name            string
+		parentMatches   []*networking.HTTPMatchRequest
+		delegateMatches []*networking.HTTPMatchRequest
+		expectedMatches []*networking.HTTPMatchRequest
+	}{
+		{
+			name: "Given a delegate route without match, " +
+				"When mergeDelegateHTTPMatchRequests is called, " +
+				"Then the match of the parent route should be used",
+			parentMatches:   parentMatches,
+			expectedMatches: parentMatches,
+		},
+		{
+			name: "Given a parent route without match, " +
+				"When mergeDelegateHTTPMatchRequests is called, " +
+				"Then the match of the delegate route should be used",
+			delegateMatches: []*networking.HTTPMatchRequest{{Uri: prefix("/api/v2")}},
+			expectedMatches: []*networking.HTTPMatchRequest{{Uri: prefix("/api/v2")}},
+		},
+		{
+			name: "Given a parent and a delegate route with matches, " +
+				"When mergeDelegateHTTPMatchRequests is called, " +
+				"Then the header and query param matches of both should be kept and the uri of the delegate preferred",
+			parentMatches: parentMatches,
+			delegateMatches: []*networking.HTTPMatchRequest{{
+				Uri:     prefix("/api/v2"),
+				Headers: map[string]*networking.StringMatch{"x-version": exact("2")},
+			}},
+			expectedMatches: []*networking.HTTPMatchRequest{{
+				Uri:         prefix("/api/v2"),
+				Headers:     map[string]*networking.StringMatch{"x-team": exact("a"), "x-version": exact("2")},
+				QueryParams: map[string]*networking.StringMatch{"debug": exact("true")},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged := mergeDelegateHTTPMatchRequests(tc.parentMatches, tc.delegateMatches)
+			assert.Equal(t, len(tc.expectedMatches), len(merged))
+			for i := range tc.expectedMatches {
+				assert.Equal(t, tc.expectedMatches[i].String(), merged[i].String())
+			}
+		})
+	}
+}
@@ -49,6 +49,13 @@ func (dh *DependencyHandler) HandleDependencyRecord(ctx context.Context, obj *v1
 	if len(sourceIdentity) == 0 {
 		return nil
 	}
+	if isPendingDependency(obj) {
+		log.Infof(LogFormat, string(eventType), common.DependencyResourceType, obj.Name, "", "skipped processing the dependency pending approval")
+		return nil
+	}
+
+	// expand the wildcard and selector destinations into the identities they match
+	obj = resolveDependencyDestinations(log.WithFields(log.Fields{"task": common.DestinationResolution}), remoteRegistry, obj)
 
 	err := updateIdentityDependencyCache(sourceIdentity, remoteRegistry.AdmiralCache.IdentityDependencyCache, obj)
 	if err != nil {
@@ -79,6 +86,15 @@ func (dh *DependencyHandler) HandleDependencyRecord(ctx context.Context, obj *v1
 		log.Errorf(LogErrFormat, string(eventType), common.DependencyResourceType, obj.Name, "", fmt.Sprintf("error processing routing policies %v", err))
 	}
 
+	// drop the destinations removed from the record from the dependent clusters, resyncing only the ones which changed
+	if eventType == admiral.Update {
+		err = processRemovedDestinations(ctx, remoteRegistry, obj, modifyServiceEntryForNewServiceOrPod)
+		if err != nil {
+			log.Errorf(LogErrFormat, string(eventType), common.DependencyResourceType, obj.Name, "", fmt.Sprintf("error processing removed destinations %v", err))
+			handleDepRecordErrors = common.AppendError(handleDepRecordErrors, err)
+		}
+	}
+
 	remoteRegistry.AdmiralCache.SourceToDestinations.put(obj)
 
 	return handleDepRecordErrors
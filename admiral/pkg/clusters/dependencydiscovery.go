@@ -0,0 +1,178 @@
+package clusters
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/telemetry"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const discoveredDependencySuffix = "-discovered"
+
+// isPendingDependency returns true when the dependency was discovered from the mesh telemetry and
+// is pending approval, the label has to be removed for it to be processed
+func isPendingDependency(dependency *v1.Dependency) bool {
+	return dependency != nil && dependency.Labels[common.DependencyStatusLabel] == common.DependencyStatusPending
+}
+
+// startDependencyDiscovery periodically discovers the dependencies missing from the dependency records
+// from the calls observed in the mesh telemetry
+func startDependencyDiscovery(ctx context.Context, rr *RemoteRegistry, source telemetry.Source) {
+	interval := common.GetDependencyDiscoveryInterval()
+	if common.IsAdmiralOperatorMode() || source == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			discoverDependencies(common.WithNewTxId(ctx), rr, source)
+		}
+	}
+}
+
+// discoverDependencies reports the identities called by an identity which are missing from its dependency
+// record. In pending mode they are also written to a dependency record of the identity pending approval,
+// unless it already has a dependency record which was not discovered
+func discoverDependencies(ctx context.Context, rr *RemoteRegistry, source telemetry.Source) {
+	ctxLogger := log.WithFields(log.Fields{
+		"task":   common.DependencyDiscovery,
+		"source": source.Name(),
+	})
+	edges, err := source.Edges(ctx)
+	if err != nil {
+		ctxLogger.Errorf(common.CtxLogFormat, common.DependencyDiscovery, source.Name(), "", "", "failed to read the telemetry: "+err.Error())
+		return
+	}
+	missing := getMissingDependencies(rr, edges)
+	sources := make([]string, 0, len(missing))
+	for identity := range missing {
+		sources = append(sources, identity)
+	}
+	sort.Strings(sources)
+
+	for _, identity := range sources {
+		destinations := missing[identity]
+		sort.Strings(destinations)
+		for _, destination := range destinations {
+			missingDependencies.Increment(api.WithAttributes(
+				attribute.Key("source").String(identity),
+				attribute.Key("destination").String(destination),
+			))
+		}
+		ctxLogger.Warnf(common.CtxLogFormat, common.DependencyDiscovery, identity, "", "",
+			"calls observed to identities missing from the dependency record: "+strings.Join(destinations, ","))
+		if common.GetDependencyDiscoveryMode() != common.DependencyDiscoveryModePending {
+			continue
+		}
+		if err := writePendingDependency(ctx, rr, identity, destinations); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DependencyDiscovery, identity, "", "", "failed to write the pending dependency record: "+err.Error())
+		}
+	}
+}
+
+// getMissingDependencies returns the identities called by each identity which are not destinations of its
+// dependency record. The calling identity is that of the workload in the telemetry, and the called identity
+// that of the host, so the calls to hosts not generated by admiral are ignored
+func getMissingDependencies(rr *RemoteRegistry, edges []telemetry.Edge) map[string][]string {
+	workloads := make(map[string]string)
+	rr.RangeRemoteControllers(func(cluster string, rc *RemoteController) {
+		if rc == nil {
+			return
+		}
+		if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+			for _, deployment := range rc.DeploymentController.Cache.List() {
+				deployment := deployment
+				workloads[deployment.Namespace+"/"+deployment.Name] = common.GetDeploymentGlobalIdentifier(&deployment)
+			}
+		}
+		if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+			for _, rollout := range rc.RolloutController.Cache.List() {
+				rollout := rollout
+				workloads[rollout.Namespace+"/"+rollout.Name] = common.GetRolloutGlobalIdentifier(&rollout)
+			}
+		}
+	})
+
+	missing := make(map[string][]string)
+	for _, edge := range edges {
+		identity := workloads[edge.SourceNamespace+"/"+edge.SourceWorkload]
+		value, ok := rr.AdmiralCache.CnameIdentityCache.Load(strings.ToLower(edge.DestinationHost))
+		if identity == "" || !ok {
+			continue
+		}
+		destination, _ := value.(string)
+		if destination == "" || strings.EqualFold(destination, identity) || common.IsPresent(missing[identity], destination) {
+			continue
+		}
+		if common.IsPresent(rr.AdmiralCache.SourceToDestinations.Get(identity), destination) {
+			continue
+		}
+		missing[identity] = append(missing[identity], destination)
+	}
+	return missing
+}
+
+// writePendingDependency adds the destinations to the pending dependency record of the identity, which is
+// created when the identity has no dependency record
+func writePendingDependency(ctx context.Context, rr *RemoteRegistry, identity string, destinations []string) error {
+	if rr.DependencyController == nil || rr.DependencyController.DepCrdClient == nil {
+		return nil
+	}
+	if existing := rr.DependencyController.Cache.Get(identity); existing != nil && !isPendingDependency(existing) {
+		return nil
+	}
+	client := rr.DependencyController.DepCrdClient.AdmiralV1alpha1().Dependencies(common.GetDependenciesNamespace())
+	name := strings.ToLower(identity) + discoveredDependencySuffix
+	dependency, err := client.Get(ctx, name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &v1.Dependency{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      name,
+				Namespace: common.GetDependenciesNamespace(),
+				Labels:    map[string]string{common.DependencyStatusLabel: common.DependencyStatusPending},
+			},
+			Spec: model.Dependency{
+				Source:        identity,
+				IdentityLabel: common.GetWorkloadIdentifier(),
+				Destinations:  destinations,
+			},
+		}, metaV1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !isPendingDependency(dependency) {
+		return nil
+	}
+	updated := false
+	for _, destination := range destinations {
+		if !common.IsPresent(dependency.Spec.Destinations, destination) {
+			dependency.Spec.Destinations = append(dependency.Spec.Destinations, destination)
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	_, err = client.Update(ctx, dependency, metaV1.UpdateOptions{})
+	return err
+}
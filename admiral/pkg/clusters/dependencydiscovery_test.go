@@ -0,0 +1,194 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	admiralFake "github.com/istio-ecosystem/admiral/admiral/pkg/client/clientset/versioned/fake"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/telemetry"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeTelemetrySource struct {
+	edges []telemetry.Edge
+	err   error
+}
+
+func (f *fakeTelemetrySource) Name() string {
+	return "prometheus"
+}
+
+func (f *fakeTelemetrySource) Edges(context.Context) ([]telemetry.Edge, error) {
+	return f.edges, f.err
+}
+
+func newDependencyDiscoveryRegistry(t *testing.T) *RemoteRegistry {
+	deploymentCache := admiral.NewDeploymentCache()
+	for _, identity := range []string{"payments", "billing", "orders"} {
+		deploymentCache.UpdateDeploymentToClusterCache(identity, &k8sAppsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{Name: identity, Namespace: identity + "-ns", Annotations: map[string]string{"env": "qa"}},
+			Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": identity}},
+			}},
+		})
+	}
+	cnameIdentityCache := &sync.Map{}
+	cnameIdentityCache.Store("qa.ledger.global", "ledger")
+	cnameIdentityCache.Store("qa.users.global", "users")
+	cnameIdentityCache.Store("qa.payments.global", "payments")
+
+	depController, err := admiral.NewDependencyController(make(chan struct{}), &test.MockDependencyHandler{}, loader.FakeKubeconfigPath, "", 0, loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	depController.DepCrdClient = admiralFake.NewSimpleClientset()
+	depController.Cache.Put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "billing"},
+		Spec:       model.Dependency{Source: "billing", Destinations: []string{"ledger"}},
+	})
+
+	rr := &RemoteRegistry{
+		StartTime:            time.Now().Add(-time.Hour),
+		DependencyController: depController,
+		AdmiralCache: &AdmiralCache{
+			CnameIdentityCache: cnameIdentityCache,
+			SourceToDestinations: &sourceToDestinations{
+				sourceDestinations: map[string][]string{"billing": {"ledger"}},
+				mutex:              &sync.Mutex{},
+			},
+		},
+		remoteControllers: map[string]*RemoteController{
+			"cluster1": {DeploymentController: &admiral.DeploymentController{Cache: deploymentCache}},
+		},
+	}
+	return rr
+}
+
+func TestGetMissingDependencies(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{WorkloadIdentityKey: "identity"}})
+	rr := newDependencyDiscoveryRegistry(t)
+
+	missing := getMissingDependencies(rr, []telemetry.Edge{
+		{SourceWorkload: "billing", SourceNamespace: "billing-ns", DestinationHost: "qa.ledger.global"},
+		{SourceWorkload: "billing", SourceNamespace: "billing-ns", DestinationHost: "QA.Users.global"},
+		{SourceWorkload: "payments", SourceNamespace: "payments-ns", DestinationHost: "qa.ledger.global"},
+		{SourceWorkload: "payments", SourceNamespace: "payments-ns", DestinationHost: "qa.payments.global"},
+		{SourceWorkload: "payments", SourceNamespace: "other-ns", DestinationHost: "qa.users.global"},
+		{SourceWorkload: "orders", SourceNamespace: "orders-ns", DestinationHost: "external.com"},
+	})
+	assert.Equal(t, map[string][]string{"billing": {"users"}, "payments": {"ledger"}}, missing)
+}
+
+func TestDiscoverDependencies(t *testing.T) {
+	edges := []telemetry.Edge{
+		{SourceWorkload: "billing", SourceNamespace: "billing-ns", DestinationHost: "qa.users.global"},
+		{SourceWorkload: "payments", SourceNamespace: "payments-ns", DestinationHost: "qa.ledger.global"},
+	}
+	testCases := []struct {
+		name                 string
+		mode                 string
+		source               *fakeTelemetrySource
+		existing             *v1.Dependency
+		expectedDependencies map[string][]string
+	}{
+		{
+			name: "Given the report mode, " +
+				"When discoverDependencies is called, " +
+				"Then no dependency record should be written",
+			mode:                 common.DependencyDiscoveryModeReport,
+			source:               &fakeTelemetrySource{edges: edges},
+			expectedDependencies: map[string][]string{},
+		},
+		{
+			name: "Given the pending mode, " +
+				"When discoverDependencies is called, " +
+				"Then a pending dependency record should be created for the identity without one only",
+			mode:                 common.DependencyDiscoveryModePending,
+			source:               &fakeTelemetrySource{edges: edges},
+			expectedDependencies: map[string][]string{"payments-discovered": {"ledger"}},
+		},
+		{
+			name: "Given the pending mode and an existing pending dependency record, " +
+				"When discoverDependencies is called, " +
+				"Then the missing destinations should be added to it",
+			mode:   common.DependencyDiscoveryModePending,
+			source: &fakeTelemetrySource{edges: edges},
+			existing: &v1.Dependency{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:      "payments-discovered",
+					Namespace: "admiral",
+					Labels:    map[string]string{common.DependencyStatusLabel: common.DependencyStatusPending},
+				},
+				Spec: model.Dependency{Source: "payments", Destinations: []string{"orders"}},
+			},
+			expectedDependencies: map[string][]string{"payments-discovered": {"orders", "ledger"}},
+		},
+		{
+			name: "Given the telemetry cannot be read, " +
+				"When discoverDependencies is called, " +
+				"Then no dependency record should be written",
+			mode:                 common.DependencyDiscoveryModePending,
+			source:               &fakeTelemetrySource{err: errors.New("connection refused")},
+			expectedDependencies: map[string][]string{},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{
+				DependenciesNamespace:   "admiral",
+				DependencyDiscoveryMode: c.mode,
+				LabelSet:                &common.LabelSet{WorkloadIdentityKey: "identity"},
+			})
+			ctx := context.Background()
+			rr := newDependencyDiscoveryRegistry(t)
+			client := rr.DependencyController.DepCrdClient.AdmiralV1alpha1().Dependencies("admiral")
+			if c.existing != nil {
+				_, err := client.Create(ctx, c.existing, metaV1.CreateOptions{})
+				assert.Nil(t, err)
+			}
+
+			discoverDependencies(ctx, rr, c.source)
+
+			list, err := client.List(ctx, metaV1.ListOptions{})
+			assert.Nil(t, err)
+			dependencies := make(map[string][]string)
+			for _, dependency := range list.Items {
+				assert.True(t, isPendingDependency(&dependency))
+				dependencies[dependency.Name] = dependency.Spec.Destinations
+			}
+			assert.Equal(t, c.expectedDependencies, dependencies)
+		})
+	}
+}
+
+func TestHandleDependencyRecordSkipsPendingDependency(t *testing.T) {
+	processor := &MockDestinationServiceProcessor{}
+	rr := &RemoteRegistry{AdmiralCache: &AdmiralCache{IdentityDependencyCache: common.NewMapOfMaps()}}
+	dh := &DependencyHandler{
+		RemoteRegistry:              rr,
+		DestinationServiceProcessor: processor,
+		RoutingPolicyProcessor:      &MockPolicyProcessor{},
+	}
+	err := dh.HandleDependencyRecord(context.Background(), &v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   "payments-discovered",
+			Labels: map[string]string{common.DependencyStatusLabel: common.DependencyStatusPending},
+		},
+		Spec: model.Dependency{Source: "payments", Destinations: []string{"ledger"}},
+	}, rr, admiral.Add)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, processor.invocation)
+	assert.Nil(t, rr.AdmiralCache.IdentityDependencyCache.Get("ledger"))
+}
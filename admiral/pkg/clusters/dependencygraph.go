@@ -0,0 +1,134 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+const (
+	DependencyGraphDependencies = "dependencies"
+	DependencyGraphDependents   = "dependents"
+	DependencyGraphBoth         = "both"
+)
+
+// DependencyGraphQuery selects the part of the dependency graph returned by GetDependencyGraph
+type DependencyGraphQuery struct {
+	// Identity the graph is walked from, the whole graph is returned when empty
+	Identity string
+	// Direction the graph is walked from the identity, one of dependencies, dependents or both
+	Direction string
+	// Depth is the number of hops walked from the identity, unlimited when 0
+	Depth int
+	// Cluster only keeps the identities deployed in the cluster, other than the identity the graph is walked from
+	Cluster string
+}
+
+// DependencyGraph is the graph of the identities admiral knows about, an edge goes from an identity
+// to an identity it depends on
+type DependencyGraph struct {
+	Nodes []DependencyGraphNode `json:"nodes"`
+	Edges []DependencyGraphEdge `json:"edges"`
+}
+
+type DependencyGraphNode struct {
+	Identity string   `json:"identity"`
+	Clusters []string `json:"clusters"`
+}
+
+type DependencyGraphEdge struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// GetDependencyGraph returns the part of the dependency graph selected by the query, built from the
+// dependency cache and the clusters each identity is deployed in
+func GetDependencyGraph(rr *RemoteRegistry, query DependencyGraphQuery) (DependencyGraph, error) {
+	graph := DependencyGraph{Nodes: make([]DependencyGraphNode, 0), Edges: make([]DependencyGraphEdge, 0)}
+	if query.Direction == "" {
+		query.Direction = DependencyGraphBoth
+	}
+	if query.Direction != DependencyGraphDependencies && query.Direction != DependencyGraphDependents && query.Direction != DependencyGraphBoth {
+		return graph, fmt.Errorf("invalid direction %q, expected one of %s, %s or %s", query.Direction,
+			DependencyGraphDependencies, DependencyGraphDependents, DependencyGraphBoth)
+	}
+	if query.Depth < 0 {
+		return graph, fmt.Errorf("invalid depth %d, expected 0 or more", query.Depth)
+	}
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityDependencyCache == nil {
+		return graph, nil
+	}
+
+	dependencies := make(map[string][]string)
+	dependents := make(map[string][]string)
+	identities := make(map[string]bool)
+	rr.AdmiralCache.IdentityDependencyCache.Range(func(destination string, sources *common.Map) {
+		for _, source := range sources.GetKeys() {
+			dependencies[source] = append(dependencies[source], destination)
+			dependents[destination] = append(dependents[destination], source)
+			identities[source] = true
+			identities[destination] = true
+		}
+	})
+
+	selected := identities
+	if query.Identity != "" {
+		selected = map[string]bool{query.Identity: true}
+		frontier := []string{query.Identity}
+		for hops := 0; len(frontier) > 0 && (query.Depth == 0 || hops < query.Depth); hops++ {
+			next := make([]string, 0)
+			for _, identity := range frontier {
+				neighbours := make([]string, 0)
+				if query.Direction != DependencyGraphDependents {
+					neighbours = append(neighbours, dependencies[identity]...)
+				}
+				if query.Direction != DependencyGraphDependencies {
+					neighbours = append(neighbours, dependents[identity]...)
+				}
+				for _, neighbour := range neighbours {
+					if !selected[neighbour] {
+						selected[neighbour] = true
+						next = append(next, neighbour)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+
+	for identity := range selected {
+		node := DependencyGraphNode{Identity: identity, Clusters: make([]string, 0)}
+		if rr.AdmiralCache.IdentityClusterCache != nil {
+			if clusters := rr.AdmiralCache.IdentityClusterCache.Get(identity); clusters != nil {
+				node.Clusters = clusters.GetKeys()
+				sort.Strings(node.Clusters)
+			}
+		}
+		if query.Cluster != "" && identity != query.Identity && !common.IsPresent(node.Clusters, query.Cluster) {
+			delete(selected, identity)
+			continue
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for source, destinations := range dependencies {
+		if !selected[source] {
+			continue
+		}
+		for _, destination := range destinations {
+			if selected[destination] {
+				graph.Edges = append(graph.Edges, DependencyGraphEdge{Source: source, Destination: destination})
+			}
+		}
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Identity < graph.Nodes[j].Identity
+	})
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].Source != graph.Edges[j].Source {
+			return graph.Edges[i].Source < graph.Edges[j].Source
+		}
+		return graph.Edges[i].Destination < graph.Edges[j].Destination
+	})
+	return graph, nil
+}
@@ -0,0 +1,100 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDependencyGraph(t *testing.T) {
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	// checkout -> cart -> inventory -> db, and payments -> db
+	rr.AdmiralCache.IdentityDependencyCache.Put("cart", "checkout", "checkout")
+	rr.AdmiralCache.IdentityDependencyCache.Put("inventory", "cart", "cart")
+	rr.AdmiralCache.IdentityDependencyCache.Put("db", "inventory", "inventory")
+	rr.AdmiralCache.IdentityDependencyCache.Put("db", "payments", "payments")
+	rr.AdmiralCache.IdentityClusterCache.Put("cart", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("inventory", "cluster2", "cluster2")
+	rr.AdmiralCache.IdentityClusterCache.Put("inventory", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("db", "cluster2", "cluster2")
+
+	node := func(identity string, clusters ...string) DependencyGraphNode {
+		if clusters == nil {
+			clusters = []string{}
+		}
+		return DependencyGraphNode{Identity: identity, Clusters: clusters}
+	}
+	testCases := []struct {
+		name          string
+		query         DependencyGraphQuery
+		expectedGraph DependencyGraph
+		expectedErr   bool
+	}{
+		{
+			name: "Given no identity, " +
+				"When GetDependencyGraph is called, " +
+				"Then the whole graph should be returned",
+			expectedGraph: DependencyGraph{
+				Nodes: []DependencyGraphNode{node("cart", "cluster1"), node("checkout"), node("db", "cluster2"),
+					node("inventory", "cluster1", "cluster2"), node("payments")},
+				Edges: []DependencyGraphEdge{{"cart", "inventory"}, {"checkout", "cart"}, {"inventory", "db"}, {"payments", "db"}},
+			},
+		},
+		{
+			name: "Given an identity and a depth, " +
+				"When GetDependencyGraph is called for its dependencies, " +
+				"Then the dependencies within the depth should be returned",
+			query: DependencyGraphQuery{Identity: "cart", Direction: DependencyGraphDependencies, Depth: 1},
+			expectedGraph: DependencyGraph{
+				Nodes: []DependencyGraphNode{node("cart", "cluster1"), node("inventory", "cluster1", "cluster2")},
+				Edges: []DependencyGraphEdge{{"cart", "inventory"}},
+			},
+		},
+		{
+			name: "Given an identity, " +
+				"When GetDependencyGraph is called for its dependents, " +
+				"Then all its transitive dependents should be returned",
+			query: DependencyGraphQuery{Identity: "db", Direction: DependencyGraphDependents},
+			expectedGraph: DependencyGraph{
+				Nodes: []DependencyGraphNode{node("cart", "cluster1"), node("checkout"), node("db", "cluster2"),
+					node("inventory", "cluster1", "cluster2"), node("payments")},
+				Edges: []DependencyGraphEdge{{"cart", "inventory"}, {"checkout", "cart"}, {"inventory", "db"}, {"payments", "db"}},
+			},
+		},
+		{
+			name: "Given an identity and a cluster, " +
+				"When GetDependencyGraph is called, " +
+				"Then only the identities deployed in the cluster should be returned along with the identity",
+			query: DependencyGraphQuery{Identity: "checkout", Cluster: "cluster2"},
+			expectedGraph: DependencyGraph{
+				Nodes: []DependencyGraphNode{node("checkout"), node("db", "cluster2"), node("inventory", "cluster1", "cluster2")},
+				Edges: []DependencyGraphEdge{{"inventory", "db"}},
+			},
+		},
+		{
+			name: "Given an unknown direction, " +
+				"When GetDependencyGraph is called, " +
+				"Then an error should be returned",
+			query:       DependencyGraphQuery{Direction: "sideways"},
+			expectedErr: true,
+		},
+		{
+			name: "Given a negative depth, " +
+				"When GetDependencyGraph is called, " +
+				"Then an error should be returned",
+			query:       DependencyGraphQuery{Identity: "cart", Depth: -1},
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			graph, err := GetDependencyGraph(rr, c.query)
+			assert.Equal(t, c.expectedErr, err != nil)
+			if !c.expectedErr {
+				assert.Equal(t, c.expectedGraph, graph)
+			}
+		})
+	}
+}
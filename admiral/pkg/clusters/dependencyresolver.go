@@ -0,0 +1,229 @@
+package clusters
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// isWildcardDestination returns true when the destination is a pattern, e.g. intuit.payments.*, matched
+// against the identities instead of being an identity
+func isWildcardDestination(destination string) bool {
+	return strings.ContainsAny(destination, "*?[")
+}
+
+// hasDynamicDestinations returns true when the dependency has a wildcard destination or a destination selector
+func hasDynamicDestinations(dependency *v1.Dependency) bool {
+	if dependency == nil {
+		return false
+	}
+	if dependency.Annotations[common.DestinationSelectorAnnotation] != "" {
+		return true
+	}
+	for _, destination := range dependency.Spec.Destinations {
+		if isWildcardDestination(destination) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveDependencyDestinations returns a copy of the dependency whose wildcard destinations are replaced by
+// the identities they match, and to which the identities whose workloads match the destination selector
+// annotation are added. The identities are those of the identity cluster cache, the source is never added.
+// A wildcard or selector matching more identities than the max destination expansion is not expanded. The
+// dependency is returned as it is when it has no wildcard destination or destination selector
+func resolveDependencyDestinations(ctxLogger *log.Entry, rr *RemoteRegistry, dependency *v1.Dependency) *v1.Dependency {
+	if !hasDynamicDestinations(dependency) {
+		return dependency
+	}
+	identities := rr.AdmiralCache.IdentityClusterCache.GetKeys()
+	sort.Strings(identities)
+
+	destinations := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(destination string) {
+		if destination == dependency.Spec.Source || seen[destination] {
+			return
+		}
+		seen[destination] = true
+		destinations = append(destinations, destination)
+	}
+	for _, destination := range dependency.Spec.Destinations {
+		if !isWildcardDestination(destination) {
+			add(destination)
+			continue
+		}
+		matches, err := matchWildcardDestination(destination, identities)
+		if err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DestinationResolution, dependency.Name, dependency.Namespace, "",
+				"invalid wildcard destination "+destination+": "+err.Error())
+			continue
+		}
+		for _, identity := range limitDestinationExpansion(ctxLogger, dependency, destination, matches) {
+			add(identity)
+		}
+	}
+
+	if value := dependency.Annotations[common.DestinationSelectorAnnotation]; value != "" {
+		selector, err := labels.Parse(value)
+		if err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DestinationResolution, dependency.Name, dependency.Namespace, "",
+				"invalid destination selector "+value+": "+err.Error())
+		} else {
+			matches := make([]string, 0)
+			for _, identity := range identities {
+				if identity != dependency.Spec.Source && matchesWorkloadLabels(rr, identity, selector) {
+					matches = append(matches, identity)
+				}
+			}
+			for _, identity := range limitDestinationExpansion(ctxLogger, dependency, value, matches) {
+				add(identity)
+			}
+		}
+	}
+
+	resolved := dependency.DeepCopy()
+	resolved.Spec.Destinations = destinations
+	return resolved
+}
+
+// matchWildcardDestination returns the identities matching the pattern, ignoring case
+func matchWildcardDestination(pattern string, identities []string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	matches := make([]string, 0)
+	for _, identity := range identities {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(identity)); ok {
+			matches = append(matches, identity)
+		}
+	}
+	return matches, nil
+}
+
+// limitDestinationExpansion returns no identity when the wildcard or selector matches more identities than
+// the max destination expansion, so that a too broad one does not create service entries for the whole mesh
+func limitDestinationExpansion(ctxLogger *log.Entry, dependency *v1.Dependency, expression string, matches []string) []string {
+	max := common.GetMaxDestinationExpansion()
+	if max > 0 && len(matches) > max {
+		ctxLogger.Warnf(common.CtxLogFormat, common.DestinationResolution, dependency.Name, dependency.Namespace, "",
+			"skipped expanding "+expression+" matching "+strconv.Itoa(len(matches))+" identities, more than the max of "+strconv.Itoa(max))
+		return nil
+	}
+	return matches
+}
+
+// matchesWorkloadLabels returns true when a deployment or rollout of the identity, in any of its clusters,
+// has labels matching the selector
+func matchesWorkloadLabels(rr *RemoteRegistry, identity string, selector labels.Selector) bool {
+	clusters := rr.AdmiralCache.IdentityClusterCache.Get(identity)
+	if clusters == nil {
+		return false
+	}
+	for _, cluster := range clusters.GetKeys() {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			continue
+		}
+		if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+			for _, item := range rc.DeploymentController.Cache.GetByIdentity(identity) {
+				if item != nil && item.Deployment != nil &&
+					selector.Matches(labels.Merge(item.Deployment.Spec.Template.Labels, item.Deployment.Labels)) {
+					return true
+				}
+			}
+		}
+		if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+			for _, item := range rc.RolloutController.Cache.GetByIdentity(identity) {
+				if item != nil && item.Rollout != nil &&
+					selector.Matches(labels.Merge(item.Rollout.Spec.Template.Labels, item.Rollout.Labels)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// startDestinationResolution periodically resolves the wildcard and selector destinations of the dependencies
+// again, so that the identities onboarded or removed since they were last resolved are taken into account
+func (dh *DependencyHandler) startDestinationResolution(ctx context.Context) {
+	interval := common.GetDestinationResolutionInterval()
+	if common.IsAdmiralOperatorMode() || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(dh.RemoteRegistry) {
+				continue
+			}
+			dh.resolveDestinations(common.WithNewTxId(ctx))
+		}
+	}
+}
+
+// resolveDestinations processes again the dependencies whose wildcard and selector destinations resolve to
+// other identities than those they were processed with. The identities no longer matched are removed from
+// the dependency cache
+func (dh *DependencyHandler) resolveDestinations(ctx context.Context) {
+	ctxLogger := log.WithFields(log.Fields{
+		"task": common.DestinationResolution,
+	})
+	if dh.DepController == nil || dh.DepController.Cache == nil {
+		return
+	}
+	cache := dh.RemoteRegistry.AdmiralCache
+	for _, dependency := range dh.DepController.Cache.List() {
+		if !hasDynamicDestinations(dependency) || dependency.Spec.Source == "" {
+			continue
+		}
+		resolved := resolveDependencyDestinations(ctxLogger, dh.RemoteRegistry, dependency)
+		existing := cache.SourceToDestinations.Get(dependency.Spec.Source)
+		if sameDestinations(existing, resolved.Spec.Destinations) {
+			continue
+		}
+		for _, destination := range existing {
+			if !common.IsPresent(resolved.Spec.Destinations, destination) {
+				cache.IdentityDependencyCache.DeleteMap(destination, dependency.Spec.Source)
+			}
+		}
+		if len(resolved.Spec.Destinations) == 0 {
+			cache.SourceToDestinations.delete(dependency.Spec.Source)
+			continue
+		}
+		ctxLogger.Infof(common.CtxLogFormat, common.DestinationResolution, dependency.Name, dependency.Namespace, "",
+			"destinations resolved to "+strings.Join(resolved.Spec.Destinations, ","))
+		if err := dh.HandleDependencyRecord(ctx, dependency, dh.RemoteRegistry, admiral.Update); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DestinationResolution, dependency.Name, dependency.Namespace, "",
+				"failed to process the resolved destinations: "+err.Error())
+		}
+	}
+}
+
+func sameDestinations(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, destination := range b {
+		if !common.IsPresent(a, destination) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newDestinationResolutionRegistry() *RemoteRegistry {
+	identityClusterCache := common.NewMapOfMaps()
+	for _, identity := range []string{"intuit.payments.api", "intuit.payments.ledger", "intuit.billing.api", "intuit.payments.client"} {
+		identityClusterCache.Put(identity, "cluster1", "cluster1")
+	}
+	deploymentCache := admiral.NewDeploymentCache()
+	deploymentCache.UpdateDeploymentToClusterCache("intuit.payments.ledger", &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"team": "payments"}},
+	})
+	deploymentCache.UpdateDeploymentToClusterCache("intuit.billing.api", &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"team": "billing"}},
+	})
+	return &RemoteRegistry{
+		StartTime: time.Now().Add(-time.Hour),
+		AdmiralCache: &AdmiralCache{
+			IdentityClusterCache:    identityClusterCache,
+			IdentityDependencyCache: common.NewMapOfMaps(),
+			SourceToDestinations: &sourceToDestinations{
+				sourceDestinations: make(map[string][]string),
+				mutex:              &sync.Mutex{},
+			},
+		},
+		remoteControllers: map[string]*RemoteController{
+			"cluster1": {DeploymentController: &admiral.DeploymentController{Cache: deploymentCache}},
+		},
+	}
+}
+
+func TestResolveDependencyDestinations(t *testing.T) {
+	rr := newDestinationResolutionRegistry()
+	ctxLogger := log.WithFields(log.Fields{"task": common.DestinationResolution})
+
+	testCases := []struct {
+		name                 string
+		maxExpansion         int
+		dependency           *v1.Dependency
+		expectedDestinations []string
+	}{
+		{
+			name: "Given a dependency with explicit destinations only, " +
+				"When resolveDependencyDestinations is called, " +
+				"Then the destinations should be returned as they are",
+			dependency: &v1.Dependency{
+				Spec: model.Dependency{Source: "intuit.payments.client", Destinations: []string{"intuit.billing.api"}},
+			},
+			expectedDestinations: []string{"intuit.billing.api"},
+		},
+		{
+			name: "Given a dependency with a wildcard destination, " +
+				"When resolveDependencyDestinations is called, " +
+				"Then the wildcard should be replaced by the identities it matches other than the source",
+			dependency: &v1.Dependency{
+				Spec: model.Dependency{Source: "intuit.payments.client", Destinations: []string{"intuit.billing.api", "Intuit.Payments.*"}},
+			},
+			expectedDestinations: []string{"intuit.billing.api", "intuit.payments.api", "intuit.payments.ledger"},
+		},
+		{
+			name: "Given a dependency with a wildcard destination matching more identities than the max expansion, " +
+				"When resolveDependencyDestinations is called, " +
+				"Then the wildcard should not be expanded",
+			maxExpansion: 2,
+			dependency: &v1.Dependency{
+				Spec: model.Dependency{Source: "intuit.billing.api", Destinations: []string{"intuit.*"}},
+			},
+			expectedDestinations: []string{},
+		},
+		{
+			name: "Given a dependency with a destination selector, " +
+				"When resolveDependencyDestinations is called, " +
+				"Then the identities whose workloads match the selector should be added",
+			dependency: &v1.Dependency{
+				ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.DestinationSelectorAnnotation: "team in (payments,billing)"}},
+				Spec:       model.Dependency{Source: "intuit.payments.client", Destinations: []string{"intuit.payments.api"}},
+			},
+			expectedDestinations: []string{"intuit.payments.api", "intuit.billing.api", "intuit.payments.ledger"},
+		},
+		{
+			name: "Given a dependency with an invalid destination selector, " +
+				"When resolveDependencyDestinations is called, " +
+				"Then the selector should be ignored",
+			dependency: &v1.Dependency{
+				ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.DestinationSelectorAnnotation: "team in payments"}},
+				Spec:       model.Dependency{Source: "intuit.payments.client", Destinations: []string{"intuit.payments.api"}},
+			},
+			expectedDestinations: []string{"intuit.payments.api"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{MaxDestinationExpansion: c.maxExpansion})
+			resolved := resolveDependencyDestinations(ctxLogger, rr, c.dependency)
+			assert.Equal(t, c.expectedDestinations, resolved.Spec.Destinations)
+		})
+	}
+}
+
+func TestResolveDestinations(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{MaxDestinationExpansion: 10})
+	rr := newDestinationResolutionRegistry()
+	rr.AdmiralCache.SourceToDestinations.sourceDestinations["intuit.payments.client"] = []string{"intuit.payments.api", "intuit.payments.old"}
+	rr.AdmiralCache.IdentityDependencyCache.Put("intuit.payments.api", "intuit.payments.client", "intuit.payments.client")
+	rr.AdmiralCache.IdentityDependencyCache.Put("intuit.payments.old", "intuit.payments.client", "intuit.payments.client")
+	rr.AdmiralCache.SourceToDestinations.sourceDestinations["intuit.billing.api"] = []string{"intuit.payments.old"}
+	rr.AdmiralCache.IdentityDependencyCache.Put("intuit.payments.old", "intuit.billing.api", "intuit.billing.api")
+
+	processor := &MockDestinationServiceProcessor{}
+	dh := &DependencyHandler{
+		RemoteRegistry:              rr,
+		DestinationServiceProcessor: processor,
+		RoutingPolicyProcessor:      &MockPolicyProcessor{},
+	}
+	depController, err := admiral.NewDependencyController(make(chan struct{}), &test.MockDependencyHandler{}, loader.FakeKubeconfigPath, "", 0, loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	dh.DepController = depController
+	dh.DepController.Cache.Put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "client"},
+		Spec:       model.Dependency{Source: "intuit.payments.client", Destinations: []string{"intuit.payments.*"}},
+	})
+	dh.DepController.Cache.Put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "billing"},
+		Spec:       model.Dependency{Source: "intuit.billing.api", Destinations: []string{"intuit.unknown.*"}},
+	})
+	dh.DepController.Cache.Put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "ledger"},
+		Spec:       model.Dependency{Source: "intuit.payments.ledger", Destinations: []string{"intuit.payments.api"}},
+	})
+
+	dh.resolveDestinations(context.Background())
+
+	assert.Equal(t, 1, processor.invocation)
+	assert.ElementsMatch(t, []string{"intuit.payments.api", "intuit.payments.ledger"}, rr.AdmiralCache.SourceToDestinations.Get("intuit.payments.client"))
+	assert.Empty(t, rr.AdmiralCache.SourceToDestinations.Get("intuit.billing.api"))
+	assert.Equal(t, []string{}, rr.AdmiralCache.IdentityDependencyCache.Get("intuit.payments.old").GetKeys())
+	assert.Equal(t, "intuit.payments.client", rr.AdmiralCache.IdentityDependencyCache.Get("intuit.payments.ledger").Get("intuit.payments.client"))
+	assert.Empty(t, rr.AdmiralCache.SourceToDestinations.Get("intuit.payments.ledger"))
+}
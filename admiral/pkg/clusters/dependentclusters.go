@@ -0,0 +1,191 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// getRemovedDestinations returns the destinations the dependency record of the source held before
+// the update which it no longer holds
+func getRemovedDestinations(cache *AdmiralCache, dependency *v1.Dependency) []string {
+	var removed []string
+	if cache == nil || cache.SourceToDestinations == nil {
+		return removed
+	}
+	for _, destination := range cache.SourceToDestinations.Get(dependency.Spec.Source) {
+		found := false
+		for _, current := range dependency.Spec.Destinations {
+			if strings.EqualFold(current, destination) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, destination)
+		}
+	}
+	return removed
+}
+
+// removeDependent drops the source from the dependents of the destination, and the clusters only the source
+// brought from the dependent clusters of the cnames of the destination. It returns whether the dependent
+// clusters of any cname of the destination changed, the cnames of the other destinations are left untouched
+func removeDependent(ctxLogger *log.Entry, cache *AdmiralCache, destination, source string) bool {
+	if cache.IdentityDependencyCache == nil || cache.IdentityClusterCache == nil || cache.CnameDependentClusterCache == nil {
+		return false
+	}
+	cache.IdentityDependencyCache.DeleteMap(destination, source)
+	sourceClusters := cache.IdentityClusterCache.Get(source)
+	if sourceClusters == nil {
+		return false
+	}
+	// the clusters the destination and its remaining dependents run in stay dependent clusters
+	remaining := make(map[string]bool)
+	identities := []string{destination}
+	if dependents := cache.IdentityDependencyCache.Get(destination); dependents != nil {
+		identities = append(identities, dependents.GetKeys()...)
+	}
+	for _, identity := range identities {
+		if clusters := cache.IdentityClusterCache.Get(identity); clusters != nil {
+			for _, cluster := range clusters.GetKeys() {
+				remaining[cluster] = true
+			}
+		}
+	}
+	changed := false
+	for _, cname := range getIdentityHosts(cache, destination, "") {
+		dependentClusters := cache.CnameDependentClusterCache.Get(cname)
+		if dependentClusters == nil {
+			continue
+		}
+		for _, cluster := range sourceClusters.GetKeys() {
+			if remaining[cluster] || !dependentClusters.CheckIfPresent(cluster) {
+				continue
+			}
+			cache.CnameDependentClusterCache.DeleteMap(cname, cluster)
+			changed = true
+			ctxLogger.Infof(common.CtxLogFormat, "DependentClusters", destination, "", cluster,
+				"cname="+cname+" removed dependent cluster as dependent="+source+" was removed")
+		}
+	}
+	return changed
+}
+
+// resyncIdentity runs modifySE for every env of the identity, so that its resources follow its dependent clusters
+func resyncIdentity(ctx context.Context, rr *RemoteRegistry, identity string, modifySE ModifySEFunc) error {
+	sourceCluster, resourceType, envs := getIdentitySourceEnvs(rr, identity)
+	var resyncErrors error
+	for _, env := range envs {
+		envCtx := context.WithValue(ctx, common.ClusterName, sourceCluster)
+		envCtx = context.WithValue(envCtx, common.EventResourceType, resourceType)
+		envCtx = context.WithValue(envCtx, common.EventType, admiral.Update)
+		if _, err := modifySE(envCtx, admiral.Update, env, identity, rr); err != nil {
+			resyncErrors = common.AppendError(resyncErrors, fmt.Errorf("failed to resync identity %s in env %s: %v", identity, env, err))
+		}
+	}
+	return resyncErrors
+}
+
+// processRemovedDestinations updates the dependent clusters of the destinations the dependency record no longer
+// holds, and resyncs only the destinations whose dependent clusters changed, so that their resources are removed
+// from the clusters of the source which no other dependent runs in
+func processRemovedDestinations(ctx context.Context, rr *RemoteRegistry, dependency *v1.Dependency, modifySE ModifySEFunc) error {
+	removed := getRemovedDestinations(rr.AdmiralCache, dependency)
+	if len(removed) == 0 {
+		return nil
+	}
+	ctxLogger := common.GetCtxLogger(ctx, dependency.Spec.Source, "-")
+	var processingErrors error
+	for _, destination := range removed {
+		if !removeDependent(ctxLogger, rr.AdmiralCache, destination, dependency.Spec.Source) {
+			ctxLogger.Infof(common.CtxLogFormat, "DependentClusters", destination, "", "",
+				"skipped resync as the dependent clusters did not change")
+			continue
+		}
+		if !common.IsDependencyProcessingEnabled() || IsCacheWarmupTimeForDependency(rr) {
+			continue
+		}
+		processingErrors = common.AppendError(processingErrors, resyncIdentity(ctx, rr, destination, modifySE))
+	}
+	return processingErrors
+}
+
+// dependentClustersResourceType is the type of the custom data the dependent clusters of a cname are persisted as in the registry
+const dependentClustersResourceType = "DependentClusters"
+
+// dependentClusterPersister writes the dependent clusters of the cnames to the registry, keeping track of the ones
+// last written so that only the cnames which changed since are written again
+type dependentClusterPersister struct {
+	persisted map[string]string // cname to its dependent clusters last written, joined
+}
+
+// startDependentClusterPersister periodically writes the dependent clusters of the cnames which changed to the
+// registry in state syncer mode, once the cache warm up is over
+func startDependentClusterPersister(ctx context.Context, rr *RemoteRegistry) {
+	interval := common.GetDependentClusterPersistInterval()
+	if interval <= 0 || !common.IsAdmiralStateSyncerMode() || rr.RegistryClient == nil {
+		return
+	}
+	persister := &dependentClusterPersister{persisted: make(map[string]string)}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if IsCacheWarmupTime(rr) {
+				continue
+			}
+			persister.persist(rr)
+		}
+	}
+}
+
+// persist writes the dependent clusters of the cnames which changed since they were last written, and deletes
+// the ones of the cnames which no longer have any. A failed write is retried on the next call
+func (p *dependentClusterPersister) persist(rr *RemoteRegistry) {
+	if rr.AdmiralCache == nil || rr.AdmiralCache.CnameDependentClusterCache == nil {
+		return
+	}
+	current := make(map[string][]string)
+	rr.AdmiralCache.CnameDependentClusterCache.Range(func(cname string, clusters *common.Map) {
+		if clusters == nil || clusters.Len() == 0 {
+			return
+		}
+		keys := clusters.GetKeys()
+		sort.Strings(keys)
+		current[cname] = keys
+	})
+	for cname, clusters := range current {
+		joined := strings.Join(clusters, ",")
+		if p.persisted[cname] == joined {
+			continue
+		}
+		err := rr.RegistryClient.PutCustomData(common.GetAdmiralInstanceName(), common.GetSyncNamespace(), cname, dependentClustersResourceType, "", clusters)
+		if err != nil {
+			log.Errorf(LogErrFormat, common.DependentClusterPersist, dependentClustersResourceType, cname, "", err)
+			continue
+		}
+		p.persisted[cname] = joined
+	}
+	for cname := range p.persisted {
+		if _, ok := current[cname]; ok {
+			continue
+		}
+		err := rr.RegistryClient.DeleteCustomData(common.GetAdmiralInstanceName(), common.GetSyncNamespace(), cname, dependentClustersResourceType, "")
+		if err != nil {
+			log.Errorf(LogErrFormat, common.DependentClusterPersist, dependentClustersResourceType, cname, "", err)
+			continue
+		}
+		delete(p.persisted, cname)
+	}
+}
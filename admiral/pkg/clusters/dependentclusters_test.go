@@ -0,0 +1,144 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	admiralV1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessRemovedDestinations(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, EnableDependencyProcessing: true})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	cache := rr.AdmiralCache
+	deployments := admiral.NewDeploymentCache()
+	deployments.UpdateDeploymentToClusterCache("c", &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "c", Namespace: "c-stage"}})
+	rr.PutRemoteController("cluster3", &RemoteController{
+		ClusterID:            "cluster3",
+		DeploymentController: &admiral.DeploymentController{Cache: deployments},
+	})
+	// a runs in cluster1 and depends on b and c, d runs in cluster2 and depends on c,
+	// b and c run in cluster3
+	cache.IdentityClusterCache.Put("a", "cluster1", "cluster1")
+	cache.IdentityClusterCache.Put("d", "cluster2", "cluster2")
+	cache.IdentityClusterCache.Put("b", "cluster3", "cluster3")
+	cache.IdentityClusterCache.Put("c", "cluster3", "cluster3")
+	cache.IdentityDependencyCache.Put("b", "a", "a")
+	cache.IdentityDependencyCache.Put("c", "a", "a")
+	cache.IdentityDependencyCache.Put("c", "d", "d")
+	cache.CnameIdentityCache.Store("stage.b.global", "b")
+	cache.CnameIdentityCache.Store("stage.c.global", "c")
+	cache.CnameDependentClusterCache.Put("stage.b.global", "cluster1", "cluster1")
+	cache.CnameDependentClusterCache.Put("stage.c.global", "cluster1", "cluster1")
+	cache.CnameDependentClusterCache.Put("stage.c.global", "cluster2", "cluster2")
+	cache.SourceToDestinations.put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "a"},
+		Spec:       admiralV1.Dependency{Source: "a", Destinations: []string{"b", "c"}},
+	})
+
+	var resynced []string
+	modifySE := func(ctx context.Context, event admiral.EventType, env string, sourceIdentity string,
+		remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+		resynced = append(resynced, sourceIdentity+"/"+env)
+		return nil, nil
+	}
+	// a no longer depends on c, which d still depends on from another cluster
+	err := processRemovedDestinations(context.Background(), rr, &v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "a"},
+		Spec:       admiralV1.Dependency{Source: "a", Destinations: []string{"b"}},
+	}, modifySE)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cluster2"}, cache.CnameDependentClusterCache.Get("stage.c.global").GetKeys())
+	assert.True(t, cache.CnameDependentClusterCache.Get("stage.b.global").CheckIfPresent("cluster1"))
+	assert.False(t, cache.IdentityDependencyCache.Get("c").CheckIfPresent("a"))
+	assert.True(t, cache.IdentityDependencyCache.Get("c").CheckIfPresent("d"))
+	assert.Equal(t, []string{"c/stage"}, resynced)
+
+	// a dependent removed from a cluster another dependent runs in changes nothing to resync
+	resynced = nil
+	cache.IdentityClusterCache.Put("e", "cluster2", "cluster2")
+	cache.IdentityDependencyCache.Put("c", "e", "e")
+	cache.SourceToDestinations.put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "e"},
+		Spec:       admiralV1.Dependency{Source: "e", Destinations: []string{"c"}},
+	})
+	err = processRemovedDestinations(context.Background(), rr, &v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "e"},
+		Spec:       admiralV1.Dependency{Source: "e", Destinations: []string{}},
+	}, modifySE)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cluster2"}, cache.CnameDependentClusterCache.Get("stage.c.global").GetKeys())
+	assert.False(t, cache.IdentityDependencyCache.Get("c").CheckIfPresent("e"))
+	assert.Empty(t, resynced)
+}
+
+// customDataRegistryClient records the custom data written to and deleted from the registry
+type customDataRegistryClient struct {
+	registry.ClientAPI
+	data    map[string]interface{}
+	puts    int
+	putErr  error
+	deletes int
+}
+
+func (c *customDataRegistryClient) PutCustomData(cluster, namespace, name, resourceType, tid string, value interface{}) error {
+	if c.putErr != nil {
+		return c.putErr
+	}
+	c.puts++
+	c.data[resourceType+"/"+name] = value
+	return nil
+}
+
+func (c *customDataRegistryClient) DeleteCustomData(cluster, namespace, name, resourceType, tid string) error {
+	c.deletes++
+	delete(c.data, resourceType+"/"+name)
+	return nil
+}
+
+func TestPersistDependentClusters(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	client := &customDataRegistryClient{data: make(map[string]interface{})}
+	rr.RegistryClient = client
+	cache := rr.AdmiralCache
+	cache.CnameDependentClusterCache.Put("stage.foo.global", "cluster2", "cluster2")
+	cache.CnameDependentClusterCache.Put("stage.foo.global", "cluster1", "cluster1")
+	cache.CnameDependentClusterCache.Put("stage.bar.global", "cluster1", "cluster1")
+	persister := &dependentClusterPersister{persisted: make(map[string]string)}
+
+	persister.persist(rr)
+	assert.Equal(t, 2, client.puts)
+	assert.Equal(t, []string{"cluster1", "cluster2"}, client.data["DependentClusters/stage.foo.global"])
+	assert.Equal(t, []string{"cluster1"}, client.data["DependentClusters/stage.bar.global"])
+
+	// only the cnames which changed are written again, the ones without dependent clusters are deleted
+	cache.CnameDependentClusterCache.DeleteMap("stage.foo.global", "cluster2")
+	cache.CnameDependentClusterCache.Delete("stage.bar.global")
+	persister.persist(rr)
+	assert.Equal(t, 3, client.puts)
+	assert.Equal(t, 1, client.deletes)
+	assert.Equal(t, []string{"cluster1"}, client.data["DependentClusters/stage.foo.global"])
+	assert.NotContains(t, client.data, "DependentClusters/stage.bar.global")
+
+	// a failed write is retried on the next call
+	cache.CnameDependentClusterCache.Put("stage.baz.global", "cluster3", "cluster3")
+	client.putErr = fmt.Errorf("registry unavailable")
+	persister.persist(rr)
+	assert.NotContains(t, client.data, "DependentClusters/stage.baz.global")
+	client.putErr = nil
+	persister.persist(rr)
+	assert.Equal(t, []string{"cluster3"}, client.data["DependentClusters/stage.baz.global"])
+	assert.Equal(t, 4, client.puts)
+}
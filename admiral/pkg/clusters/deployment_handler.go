@@ -14,6 +14,10 @@ type DeploymentHandler struct {
 	ClusterID      string
 }
 
+func (pc *DeploymentHandler) GetClusterID() string {
+	return pc.ClusterID
+}
+
 func (pc *DeploymentHandler) Added(ctx context.Context, obj *k8sAppsV1.Deployment) error {
 	err := HandleEventForDeployment(ctx, admiral.Add, obj, pc.RemoteRegistry, pc.ClusterID)
 	if err != nil {
@@ -46,6 +50,11 @@ func HandleEventForDeployment(ctx context.Context, event admiral.EventType, obj
 		return nil
 	}
 
+	if isNamespaceFiltered(remoteRegistry, clusterName, obj.Namespace) {
+		log.Infof(LogFormat, event, common.DeploymentResourceType, obj.Name, clusterName, "skipped as namespace="+obj.Namespace+" is filtered")
+		return nil
+	}
+
 	env := common.GetEnv(obj)
 
 	ctx = context.WithValue(ctx, common.ClusterName, clusterName)
@@ -53,6 +62,11 @@ func HandleEventForDeployment(ctx context.Context, event admiral.EventType, obj
 
 	_ = callRegistryForDeployment(ctx, event, remoteRegistry, globalIdentifier, clusterName, obj)
 
+	if event != admiral.Delete {
+		remoteRegistry.startLazyIstioControllers(clusterName)
+		recordIdentityTeam(globalIdentifier, obj.Spec.Template.Annotations, obj.Spec.Template.Labels)
+	}
+
 	if remoteRegistry.AdmiralCache != nil {
 		if remoteRegistry.AdmiralCache.IdentityClusterCache != nil {
 			remoteRegistry.AdmiralCache.IdentityClusterCache.Put(globalIdentifier, clusterName, clusterName)
@@ -68,7 +82,7 @@ func HandleEventForDeployment(ctx context.Context, event admiral.EventType, obj
 	}
 
 	// Use the same function as added deployment function to update and put new service entry in place to replace old one
-	_, err := modifyServiceEntryForNewServiceOrPod(ctx, event, env, globalIdentifier, remoteRegistry)
+	_, err := modifyServiceEntryForWorkloadEvent(ctx, event, env, globalIdentifier, remoteRegistry)
 	if common.ClientInitiatedProcessingEnabledForControllers() {
 		var c ClientDependencyRecordProcessor
 		log.Infof(LogFormat, event, common.DeploymentResourceType, obj.Name, clusterName, "Client initiated processing started for "+globalIdentifier)
@@ -85,11 +99,11 @@ func callRegistryForDeployment(ctx context.Context, event admiral.EventType, reg
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Deployment, ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Deployment, common.GetTxId(ctx), obj)
 		case admiral.Update:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Deployment, ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Deployment, common.GetTxId(ctx), obj)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Deployment, ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Deployment, common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, common.Deployment, obj.Name, clusterName, "failed to "+string(event)+" "+common.Deployment+" with err: "+err.Error())
@@ -17,6 +17,7 @@ import (
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
@@ -346,7 +347,7 @@ func (dh *DestinationRuleHandler) Added(ctx context.Context, obj *v1alpha3.Desti
 	if commonUtil.IsAdmiralReadOnly() {
 		return nil
 	}
-	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
+	if IgnoreIstioResource(dh.RemoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		return nil
 	}
 	txId := common.FetchTxIdOrGenNew(ctx)
@@ -362,7 +363,7 @@ func (dh *DestinationRuleHandler) Updated(ctx context.Context, obj *v1alpha3.Des
 	if commonUtil.IsAdmiralReadOnly() {
 		return nil
 	}
-	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
+	if IgnoreIstioResource(dh.RemoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		return nil
 	}
 	txId := common.FetchTxIdOrGenNew(ctx)
@@ -378,7 +379,7 @@ func (dh *DestinationRuleHandler) Deleted(ctx context.Context, obj *v1alpha3.Des
 	if commonUtil.IsAdmiralReadOnly() {
 		return nil
 	}
-	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
+	if IgnoreIstioResource(dh.RemoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) {
 		return nil
 	}
 	txId := common.FetchTxIdOrGenNew(ctx)
@@ -395,12 +396,16 @@ func handleDestinationRuleEvent(ctxLogger *log.Entry, ctx context.Context, obj *
 		//nolint
 		destinationRule      = obj.Spec
 		clusterId            = dh.ClusterID
-		syncNamespace        = common.GetSyncNamespace()
 		r                    = dh.RemoteRegistry
 		dependentClusters    = r.AdmiralCache.CnameDependentClusterCache.Get(destinationRule.Host).Copy()
 		allDependentClusters = make(map[string]string)
 	)
 
+	if isNamespaceFiltered(r, clusterId, obj.Namespace) {
+		ctxLogger.Infof(LogFormat, event, resourceType, obj.Name, clusterId, "skipped as namespace="+obj.Namespace+" is filtered")
+		return nil
+	}
+
 	if len(dependentClusters) > 0 {
 		util.MapCopy(allDependentClusters, dependentClusters)
 		allDependentClusters[clusterId] = clusterId
@@ -412,6 +417,7 @@ func handleDestinationRuleEvent(ctxLogger *log.Entry, ctx context.Context, obj *
 			if rc.DestinationRuleController == nil {
 				return fmt.Errorf(LogFormat, "Event", resourceType, obj.Name, dependentCluster, "DestinationRule controller not initialized for cluster")
 			}
+			syncNamespace := common.GetSyncNamespaceForCluster(dependentCluster)
 			if event == common.Delete {
 				err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Delete(ctx, obj.Name, metaV1.DeleteOptions{})
 				if err != nil {
@@ -447,6 +453,7 @@ func handleDestinationRuleEvent(ctxLogger *log.Entry, ctx context.Context, obj *
 			if rc.DestinationRuleController == nil {
 				return fmt.Errorf(LogFormat, "Event", resourceType, obj.Name, ClusterID, "DestinationRule controller not initialized for cluster")
 			}
+			syncNamespace := common.GetSyncNamespaceForCluster(ClusterID)
 			if event == common.Delete {
 				err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Delete(ctx, obj.Name, metaV1.DeleteOptions{})
 				if err != nil {
@@ -478,11 +485,21 @@ func addUpdateDestinationRule(
 	var err error
 	var op string
 	var drAlreadyExists bool
+	drCopy, existCopy := dr.DeepCopy(), exist.DeepCopy()
+	if queueWriteIfPaused(ctx, rc.ClusterID, common.DestinationRuleResourceType, namespace, dr.Name, func(ctx context.Context, rc *RemoteController) error {
+		return addUpdateDestinationRule(ctxLogger, ctx, drCopy, existCopy, namespace, rc, rr)
+	}) {
+		return nil
+	}
 	obj := copyDestinationRule(dr)
 	if obj.Annotations == nil {
 		obj.Annotations = map[string]string{}
 	}
 	obj.Annotations["app.kubernetes.io/created-by"] = "admiral"
+	stampTxId(ctx, obj)
+	stampOwnerLabels(ctx, obj)
+	stampSyncNamespaceLabel(obj, rc.ClusterID, namespace)
+	stampCoexistenceAnnotations(obj)
 
 	//Check if DR has the admiral.io/vs-routing label
 	// If it does, skip adding ExportTo since it is already set to "istio-system" only
@@ -496,10 +513,26 @@ func addUpdateDestinationRule(
 	// This is because there are two ways to enter this function
 	// 1. Through modifyse, in which case obj will already have exportTo filled and we don't want to do a repeat call of getSortedDependentNamespaces
 	// 2. Through the flow where we copy customer created DRs to other clusters, in which case it shouldn't have exportTo set and we need to calculate it here.
-	if common.EnableExportTo(obj.Spec.Host) && len(obj.Spec.ExportTo) == 0 && !skipAddingExportTo {
+	if common.EnableExportToForCluster(obj.Spec.Host, rc.ClusterID) && len(obj.Spec.ExportTo) == 0 && !skipAddingExportTo {
 		sortedDependentNamespaces := getSortedDependentNamespaces(rr.AdmiralCache, obj.Spec.Host, rc.ClusterID, ctxLogger, false)
 		obj.Spec.ExportTo = sortedDependentNamespaces
 	}
+	if err = evaluateResourcePolicy(ctxLogger, ctx, common.DestinationRuleResourceType, rc.ClusterID, obj); err != nil {
+		return err
+	}
+	if err = validateGeneratedResource(ctxLogger, ctx, common.DestinationRuleResourceType, rc, obj); err != nil {
+		return err
+	}
+	if common.IsGitOpsOutputMode() {
+		return renderGitOpsResource(ctxLogger, rc.ClusterID, common.DestinationRuleResourceType, namespace, obj)
+	}
+	if common.IsDiffOnlyMode() {
+		var existState *resourceState
+		if exist != nil && exist.Name != "" && exist.Spec.Host != "" {
+			existState = newResourceState(exist, &exist.Spec)
+		}
+		return recordResourceDiff(ctx, rc.ClusterID, common.DestinationRuleResourceType, namespace, obj.Name, existState, newResourceState(obj, &obj.Spec))
+	}
 	drIsNew := exist == nil || exist.Name == "" || exist.Spec.Host == ""
 	if drIsNew {
 		obj.Namespace = namespace
@@ -510,11 +543,18 @@ func addUpdateDestinationRule(
 			ctxLogger.Infof(common.CtxLogFormat, "addUpdateDestinationRule", obj.Name, obj.Namespace, rc.ClusterID, "object already exists. Will update instead")
 			drAlreadyExists = true
 		} else {
+			auditMutation(ctx, audit.OperationCreate, common.DestinationRuleResourceType, rc.ClusterID, obj, namespace, "", err)
+			if err == nil {
+				recordWrittenSpec(rc.ClusterID, common.DestinationRuleResourceType, namespace, obj.Name, &obj.Spec)
+				recordConfigHistory(ctx, rc.ClusterID, common.DestinationRuleResourceType, obj)
+			}
 			return err
 		}
 		op = "Add"
 	}
 	if !drIsNew || drAlreadyExists {
+		// the changes are sent as a merge patch when enabled, unless the existing destinationrule could not be fetched
+		patch := common.EnableMergePatchUpdates()
 		if drAlreadyExists {
 			exist, err = rc.DestinationRuleController.IstioClient.
 				NetworkingV1alpha3().
@@ -525,24 +565,35 @@ func addUpdateDestinationRule(
 				// which will fail in the update operation, but will be retried
 				// in the retry logic
 				exist = obj
+				patch = false
 				ctxLogger.Warnf(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, rc.ClusterID, "got error on fetching destinationrule, will retry updating")
 			}
 		}
+		detectExternalRevert(ctxLogger, rc.ClusterID, common.DestinationRuleResourceType, exist, &exist.Spec, &obj.Spec)
+		diff := summarizeChanges(exist, obj, &exist.Spec, &obj.Spec)
+		original := exist.DeepCopy()
 		exist.Labels = obj.Labels
 		exist.Annotations = obj.Annotations
 		//nolint
 		exist.Spec = obj.Spec
 		op = "Update"
-		_, err = rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Update(ctx, exist, metaV1.UpdateOptions{})
+		if patch {
+			err = patchDestinationRule(ctx, rc, namespace, original, exist)
+		} else {
+			_, err = rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Update(ctx, exist, metaV1.UpdateOptions{})
+		}
 		if err != nil {
 			err = retryUpdatingDR(ctxLogger, ctx, exist, namespace, rc, err)
 		}
+		auditMutation(ctx, audit.OperationUpdate, common.DestinationRuleResourceType, rc.ClusterID, obj, namespace, diff, err)
 	}
 
 	if err != nil {
 		ctxLogger.Errorf(LogErrFormat, op, "DestinationRule", obj.Name, rc.ClusterID, err)
 		return err
 	} else {
+		recordWrittenSpec(rc.ClusterID, common.DestinationRuleResourceType, namespace, obj.Name, &obj.Spec)
+		recordConfigHistory(ctx, rc.ClusterID, common.DestinationRuleResourceType, obj)
 		ctxLogger.Infof(LogFormat, op, "DestinationRule", obj.Name, rc.ClusterID, "Success")
 	}
 	return nil
@@ -550,7 +601,24 @@ func addUpdateDestinationRule(
 
 func deleteDestinationRule(ctx context.Context, exist *v1alpha3.DestinationRule, namespace string, rc *RemoteController) error {
 	if exist != nil {
+		existCopy := exist.DeepCopy()
+		if queueWriteIfPaused(ctx, rc.ClusterID, common.DestinationRuleResourceType, namespace, exist.Name, func(ctx context.Context, rc *RemoteController) error {
+			return deleteDestinationRule(ctx, existCopy, namespace, rc)
+		}) {
+			return nil
+		}
+		if common.IsGitOpsOutputMode() {
+			return removeGitOpsResource(rc.ClusterID, common.DestinationRuleResourceType, namespace, exist.Name)
+		}
+		if common.IsDiffOnlyMode() {
+			return recordResourceDiff(ctx, rc.ClusterID, common.DestinationRuleResourceType, namespace, exist.Name, newResourceState(exist, &exist.Spec), nil)
+		}
+		forgetWrittenSpec(rc.ClusterID, common.DestinationRuleResourceType, namespace, exist.Name)
+		forgetConfigHistory(ctx, rc.ClusterID, common.DestinationRuleResourceType, namespace, exist.Name)
 		err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Delete(ctx, exist.Name, metaV1.DeleteOptions{})
+		if !k8sErrors.IsNotFound(err) {
+			auditMutation(ctx, audit.OperationDelete, common.DestinationRuleResourceType, rc.ClusterID, exist, namespace, "", err)
+		}
 		if err != nil {
 			if k8sErrors.IsNotFound(err) {
 				log.Infof(LogFormat, "Delete", "DestinationRule", exist.Name, rc.ClusterID, "Either DestinationRule was already deleted, or it never existed")
@@ -574,38 +642,36 @@ func retryUpdatingDR(
 	ctxLogger *log.Entry, ctx context.Context,
 	exist *v1alpha3.DestinationRule, namespace string,
 	rc *RemoteController, err error) error {
-	numRetries := 5
-	if err != nil {
-		if k8sErrors.IsConflict(err) {
-			for i := 1; i <= numRetries; i++ {
-				ctxLogger.Errorf(common.CtxLogFormat, "Update",
-					exist.Name, exist.Namespace, rc.ClusterID, fmt.Sprintf("error=%v retrying=%d/%d", err.Error(), i, numRetries))
-				updatedDR, err := rc.DestinationRuleController.IstioClient.
-					NetworkingV1alpha3().
-					DestinationRules(namespace).
-					Get(ctx, exist.Name, metav1.GetOptions{})
-				if err != nil {
-					ctxLogger.Errorf(common.CtxLogFormat, "Update",
-						exist.Name, exist.Namespace, rc.ClusterID, fmt.Sprintf("error=%v", err.Error()))
-					continue
-				}
-				ctxLogger.Infof(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, rc.ClusterID,
-					fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s", updatedDR.ResourceVersion, exist.ResourceVersion))
-				//nolint
-				updatedDR.Spec = exist.Spec
-				updatedDR.Labels = exist.Labels
-				updatedDR.Annotations = exist.Annotations
-				_, err = rc.DestinationRuleController.IstioClient.
-					NetworkingV1alpha3().
-					DestinationRules(namespace).
-					Update(ctx, updatedDR, metaV1.UpdateOptions{})
-				if err == nil {
-					return nil
-				}
-			}
-		} else {
-			ctxLogger.Errorf(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, rc.ClusterID, "Not retrying error="+err.Error())
-		}
+	if err == nil {
+		return nil
+	}
+	if !common.IsRetryableWriteError(err) {
+		ctxLogger.Errorf(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, rc.ClusterID, "Not retrying error="+err.Error())
+		return err
 	}
-	return err
+	policy := common.GetWriteRetryPolicy()
+	return common.RetryAfterFirstAttempt(ctx, policy, err, func(attempt int) error {
+		ctxLogger.Errorf(common.CtxLogFormat, "Update",
+			exist.Name, exist.Namespace, rc.ClusterID, fmt.Sprintf("error=%v retrying=%d/%d", err.Error(), attempt, policy.Attempts))
+		updatedDR, getErr := rc.DestinationRuleController.IstioClient.
+			NetworkingV1alpha3().
+			DestinationRules(namespace).
+			Get(ctx, exist.Name, metav1.GetOptions{})
+		if getErr != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, "Update",
+				exist.Name, exist.Namespace, rc.ClusterID, fmt.Sprintf("error=%v", getErr.Error()))
+			return err
+		}
+		ctxLogger.Infof(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, rc.ClusterID,
+			fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s", updatedDR.ResourceVersion, exist.ResourceVersion))
+		//nolint
+		updatedDR.Spec = exist.Spec
+		updatedDR.Labels = exist.Labels
+		updatedDR.Annotations = exist.Annotations
+		_, err = rc.DestinationRuleController.IstioClient.
+			NetworkingV1alpha3().
+			DestinationRules(namespace).
+			Update(ctx, updatedDR, metaV1.UpdateOptions{})
+		return err
+	})
 }
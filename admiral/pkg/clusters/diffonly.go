@@ -0,0 +1,232 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// resourceState is the part of a resource admiral writes, which the diff-only mode compares
+type resourceState struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Spec        proto.Message     `json:"spec,omitempty"`
+}
+
+// FieldChange is a field of a resource admiral would change, by its path in the resource.
+// Before is empty for the fields admiral would add, and After for the ones it would remove
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// ResourceDiff is a create, update or delete admiral would have applied to a cluster in the diff-only mode
+type ResourceDiff struct {
+	Timestamp time.Time     `json:"timestamp"`
+	TxId      string        `json:"txId,omitempty"`
+	Operation string        `json:"operation"`
+	Cluster   string        `json:"cluster"`
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+	Summary   string        `json:"summary"`
+}
+
+// plannedDiffCache keeps the latest diff admiral would apply to each resource, by cluster and kind/namespace/name.
+// As nothing is applied, the same diff is computed again on every event of the resource and replaces the previous one
+type plannedDiffCache struct {
+	lock  sync.Mutex
+	diffs map[string]map[string]ResourceDiff
+}
+
+var plannedDiffs = &plannedDiffCache{diffs: make(map[string]map[string]ResourceDiff)}
+
+func newResourceState(obj metaV1.Object, spec proto.Message) *resourceState {
+	return &resourceState{Labels: obj.GetLabels(), Annotations: withoutTxId(obj.GetAnnotations()), Spec: spec}
+}
+
+// recordResourceDiff records the diff between the existing state of the resource and the state admiral would
+// write, instead of writing it. The resource is created when there is no existing state, and deleted when there
+// is no state to write. The diff is logged, sent to the audit sink and kept until the resource no longer differs
+func recordResourceDiff(ctx context.Context, cluster string, kind common.ResourceType, namespace, name string, exist, desired *resourceState) error {
+	operation := audit.OperationUpdate
+	switch {
+	case exist == nil:
+		operation = audit.OperationCreate
+	case desired == nil:
+		operation = audit.OperationDelete
+	}
+	before, err := toComparable(exist)
+	if err != nil {
+		return err
+	}
+	after, err := toComparable(desired)
+	if err != nil {
+		return err
+	}
+	changes := diffValues("", before, after, nil)
+	key := writtenSpecKey(kind, namespace, name)
+	plannedDiffs.lock.Lock()
+	defer plannedDiffs.lock.Unlock()
+	if len(changes) == 0 && operation == audit.OperationUpdate {
+		delete(plannedDiffs.diffs[cluster], key)
+		return nil
+	}
+	diff := ResourceDiff{
+		Timestamp: time.Now().UTC(),
+		TxId:      common.GetTxId(ctx),
+		Operation: operation,
+		Cluster:   cluster,
+		Kind:      string(kind),
+		Namespace: namespace,
+		Name:      name,
+		Changes:   changes,
+	}
+	diff.Summary = summarizeResourceDiff(diff)
+	if plannedDiffs.diffs[cluster] == nil {
+		plannedDiffs.diffs[cluster] = make(map[string]ResourceDiff)
+	}
+	plannedDiffs.diffs[cluster][key] = diff
+	log.Infof(LogFormat, "DiffOnly", kind, name, cluster, diff.Summary)
+	audit.Log(audit.Record{
+		Timestamp: diff.Timestamp,
+		TxId:      diff.TxId,
+		Operation: operation,
+		Kind:      string(kind),
+		Name:      name,
+		Namespace: namespace,
+		Cluster:   cluster,
+		Diff:      diff.Summary,
+		DryRun:    true,
+	})
+	return nil
+}
+
+// GetResourceDiffs returns the latest diff admiral would apply to each resource of the cluster, or of all
+// the clusters when empty, ordered by cluster, kind, namespace and name
+func GetResourceDiffs(cluster string) []ResourceDiff {
+	plannedDiffs.lock.Lock()
+	defer plannedDiffs.lock.Unlock()
+	diffs := make([]ResourceDiff, 0)
+	for clusterID, clusterDiffs := range plannedDiffs.diffs {
+		if cluster != "" && clusterID != cluster {
+			continue
+		}
+		for _, diff := range clusterDiffs {
+			diffs = append(diffs, diff)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		a, b := diffs[i], diffs[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		return writtenSpecKey(common.ResourceType(a.Kind), a.Namespace, a.Name) < writtenSpecKey(common.ResourceType(b.Kind), b.Namespace, b.Name)
+	})
+	return diffs
+}
+
+// clearResourceDiffs drops the diffs of a cluster admiral no longer watches
+func clearResourceDiffs(cluster string) {
+	plannedDiffs.lock.Lock()
+	defer plannedDiffs.lock.Unlock()
+	delete(plannedDiffs.diffs, cluster)
+}
+
+// toComparable converts the state of the resource to its generic JSON form, so that it can be compared field by field
+func toComparable(state *resourceState) (interface{}, error) {
+	if state == nil {
+		return nil, nil
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var comparable interface{}
+	err = json.Unmarshal(content, &comparable)
+	return comparable, err
+}
+
+// diffValues appends the fields which differ between before and after to changes, descending into the
+// objects and lists both sides have in common
+func diffValues(path string, before, after interface{}, changes []FieldChange) []FieldChange {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		if a, ok := after.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(a)+len(b))
+			for key := range b {
+				keys = append(keys, key)
+			}
+			for key := range a {
+				if _, ok := b[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				changes = diffValues(childPath, b[key], a[key], changes)
+			}
+			return changes
+		}
+	case []interface{}:
+		if a, ok := after.([]interface{}); ok {
+			for i := 0; i < len(a) || i < len(b); i++ {
+				var beforeItem, afterItem interface{}
+				if i < len(b) {
+					beforeItem = b[i]
+				}
+				if i < len(a) {
+					afterItem = a[i]
+				}
+				changes = diffValues(fmt.Sprintf("%s[%d]", path, i), beforeItem, afterItem, changes)
+			}
+			return changes
+		}
+	}
+	if !reflect.DeepEqual(before, after) {
+		changes = append(changes, FieldChange{Path: path, Before: before, After: after})
+	}
+	return changes
+}
+
+// summarizeResourceDiff returns the human-readable form of the diff, one line per changed field
+func summarizeResourceDiff(diff ResourceDiff) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s %s/%s in cluster %s", diff.Operation, diff.Kind, diff.Namespace, diff.Name, diff.Cluster)
+	for _, change := range diff.Changes {
+		path := change.Path
+		if path == "" {
+			path = "."
+		}
+		fmt.Fprintf(&builder, "\n  %s: %s -> %s", path, formatDiffValue(change.Before), formatDiffValue(change.After))
+	}
+	return builder.String()
+}
+
+func formatDiffValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
@@ -0,0 +1,76 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffOnlyMode(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", DiffOnlyMode: true})
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+	defer clearResourceDiffs("cluster1")
+	ctx := context.Background()
+	ctxLogger := log.WithField("op", "test")
+	exist := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        "foo.global-se",
+			Namespace:   "ns",
+			Annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+			Labels:      map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
+		},
+		Spec: networkingV1Alpha3.ServiceEntry{
+			Hosts:     []string{"foo.global"},
+			Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "internal-lb.com"}},
+		},
+	}
+	istioClient := istioFake.NewSimpleClientset(exist)
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	var records []audit.Record
+	audit.AddListener(func(record audit.Record) {
+		if record.DryRun && record.Cluster == "cluster1" {
+			records = append(records, record)
+		}
+	})
+
+	// the update is recorded, field by field, instead of being applied
+	desired := exist.DeepCopy()
+	desired.Spec.Endpoints[0].Address = "other-lb.com"
+	assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, desired, exist, "ns", rc))
+	current, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, "foo.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "internal-lb.com", current.Spec.Endpoints[0].Address)
+	diffs := GetResourceDiffs("cluster1")
+	assert.Len(t, diffs, 1)
+	assert.Equal(t, audit.OperationUpdate, diffs[0].Operation)
+	assert.Equal(t, []FieldChange{{Path: "spec.endpoints[0].address", Before: "internal-lb.com", After: "other-lb.com"}}, diffs[0].Changes)
+	assert.Contains(t, diffs[0].Summary, `spec.endpoints[0].address: "internal-lb.com" -> "other-lb.com"`)
+	assert.Len(t, records, 1)
+	assert.Equal(t, diffs[0].Summary, records[0].Diff)
+
+	// the diff is dropped once the resource no longer differs
+	assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, exist.DeepCopy(), exist, "ns", rc))
+	assert.Empty(t, GetResourceDiffs("cluster1"))
+
+	// deletes are recorded instead of being applied
+	assert.Nil(t, deleteServiceEntry(ctx, exist, "ns", rc))
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("ns").Get(ctx, "foo.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	diffs = GetResourceDiffs("")
+	assert.Len(t, diffs, 1)
+	assert.Equal(t, audit.OperationDelete, diffs[0].Operation)
+}
@@ -0,0 +1,198 @@
+package clusters
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	admiralV1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/discovery"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// startDiscoverySourceSync periodically syncs the services of the discovery source into the mesh clusters
+func startDiscoverySourceSync(ctx context.Context, rr *RemoteRegistry, source discovery.Source) {
+	interval := common.GetDiscoverySourceSyncInterval()
+	if common.IsAdmiralOperatorMode() || source == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	dependencies := make(map[string][]string)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			dependencies = syncDiscoverySource(common.WithNewTxId(ctx), rr, source, dependencies)
+		}
+	}
+}
+
+// syncDiscoverySource records the services of the discovery source as identities in the admiral cache
+// and writes a service entry for each of them to the clusters of its dependents. The dependencies of the
+// services are added to the dependency cache, and those dropped since the previous sync are removed.
+// The dependencies of the synced services are returned so they can be passed to the next sync. The
+// service entries of the services no longer registered in the source are deleted, unless the source
+// cannot be read
+func syncDiscoverySource(ctx context.Context, rr *RemoteRegistry, source discovery.Source, previous map[string][]string) map[string][]string {
+	ctxLogger := log.WithFields(log.Fields{
+		"task":   common.DiscoverySourceSync,
+		"source": source.Name(),
+	})
+	services, err := source.Services(ctx)
+	if err != nil {
+		ctxLogger.Errorf(common.CtxLogFormat, common.DiscoverySourceSync, source.Name(), "", "", "failed to read the services: "+err.Error())
+		return previous
+	}
+
+	cache := rr.AdmiralCache
+	dependencies := make(map[string][]string)
+	serviceEntries := make(map[string][]*v1alpha3.ServiceEntry)
+	for _, service := range services {
+		if service.Identity == "" {
+			continue
+		}
+		if clusters := cache.IdentityClusterCache.Get(service.Identity); clusters != nil && clusters.Len() > 0 {
+			ctxLogger.Warnf(common.CtxLogFormat, common.DiscoverySourceSync, service.Identity, "", "",
+				"skipped as the identity is already registered in the mesh clusters")
+			continue
+		}
+		if len(service.Dependencies) > 0 {
+			dependencies[service.Identity] = service.Dependencies
+			dependency := &admiralV1.Dependency{
+				ObjectMeta: metaV1.ObjectMeta{Name: service.Identity},
+				Spec:       model.Dependency{Source: service.Identity, Destinations: service.Dependencies},
+			}
+			_ = updateIdentityDependencyCache(service.Identity, cache.IdentityDependencyCache, dependency)
+			cache.SourceToDestinations.put(dependency)
+		}
+		if len(service.Endpoints) == 0 {
+			continue
+		}
+		host := strings.ToLower(common.GetCnameVal([]string{service.Env, service.Identity, common.GetHostnameSuffix()}))
+		cache.CnameIdentityCache.Store(host, service.Identity)
+		dependents := cache.IdentityDependencyCache.Get(service.Identity)
+		if dependents == nil {
+			continue
+		}
+		for _, dependent := range dependents.GetKeys() {
+			clusters := cache.IdentityClusterCache.Get(dependent)
+			if clusters == nil {
+				continue
+			}
+			for _, cluster := range clusters.GetKeys() {
+				if containsServiceEntry(serviceEntries[cluster], host) {
+					continue
+				}
+				cache.CnameDependentClusterCache.Put(host, cluster, cluster)
+				serviceEntries[cluster] = append(serviceEntries[cluster],
+					buildDiscoveredServiceEntry(source.Name(), host, service, common.GetSyncNamespaceForCluster(cluster)))
+			}
+		}
+	}
+	for identity, destinations := range previous {
+		for _, destination := range destinations {
+			if !common.IsPresent(dependencies[identity], destination) {
+				cache.IdentityDependencyCache.DeleteMap(destination, identity)
+			}
+		}
+	}
+
+	for _, cluster := range rr.GetClusterIds() {
+		writeDiscoveredServiceEntries(ctx, ctxLogger, rr.GetRemoteController(cluster), source.Name(), serviceEntries[cluster])
+	}
+	return dependencies
+}
+
+// writeDiscoveredServiceEntries writes the service entries of the discovery source to the cluster and
+// deletes those of the source the cluster no longer needs
+func writeDiscoveredServiceEntries(ctx context.Context, ctxLogger *log.Entry, rc *RemoteController,
+	sourceName string, serviceEntries []*v1alpha3.ServiceEntry) {
+	if rc == nil || rc.ServiceEntryController == nil {
+		return
+	}
+	namespace := common.GetSyncNamespaceForCluster(rc.ClusterID)
+	written := make(map[string]bool)
+	for _, se := range serviceEntries {
+		written[se.Name] = true
+		exist := rc.ServiceEntryController.Cache.Get(se.Name, rc.ClusterID)
+		if err := addUpdateServiceEntry(ctxLogger, ctx, se, exist, namespace, rc); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DiscoverySourceSync, se.Name, namespace, rc.ClusterID, err.Error())
+		}
+	}
+
+	existing, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: common.AdmiralDiscoverySourceLabel + "=" + sourceName,
+	})
+	if err != nil {
+		ctxLogger.Errorf(common.CtxLogFormat, common.DiscoverySourceSync, "", namespace, rc.ClusterID, "failed to list the service entries: "+err.Error())
+		return
+	}
+	for _, se := range existing.Items {
+		if written[se.Name] {
+			continue
+		}
+		ctxLogger.Infof(common.CtxLogFormat, common.DiscoverySourceSync, se.Name, namespace, rc.ClusterID, "deleting the service entry no longer needed")
+		if err := deleteServiceEntry(ctx, se, namespace, rc); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DiscoverySourceSync, se.Name, namespace, rc.ClusterID, err.Error())
+		}
+	}
+}
+
+// buildDiscoveredServiceEntry returns the service entry through which the workloads of a cluster reach
+// a service of the discovery source. The service is outside of the mesh, so the endpoints are reached
+// directly on the port they are registered with
+func buildDiscoveredServiceEntry(sourceName string, host string, service discovery.Service, namespace string) *v1alpha3.ServiceEntry {
+	protocol := strings.ToLower(service.Protocol)
+	if protocol == "" {
+		protocol = commonUtil.Http
+	}
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      getIstioResourceName(host, "-se"),
+			Namespace: namespace,
+			Labels:    map[string]string{common.AdmiralDiscoverySourceLabel: sourceName},
+		},
+		Spec: networking.ServiceEntry{
+			Hosts:      []string{host},
+			Ports:      []*networking.ServicePort{{Number: common.DefaultServiceEntryPort, Name: protocol, Protocol: protocol}},
+			Location:   networking.ServiceEntry_MESH_EXTERNAL,
+			Resolution: networking.ServiceEntry_STATIC,
+		},
+	}
+	for _, ep := range service.Endpoints {
+		if net.ParseIP(ep.Address) == nil {
+			se.Spec.Resolution = networking.ServiceEntry_DNS
+		}
+		se.Spec.Endpoints = append(se.Spec.Endpoints, &networking.WorkloadEntry{
+			Address:  ep.Address,
+			Locality: ep.Locality,
+			Ports:    map[string]uint32{protocol: ep.Port},
+		})
+	}
+	sort.Slice(se.Spec.Endpoints, func(i, j int) bool {
+		return se.Spec.Endpoints[i].Address < se.Spec.Endpoints[j].Address
+	})
+	return se
+}
+
+func containsServiceEntry(serviceEntries []*v1alpha3.ServiceEntry, host string) bool {
+	for _, se := range serviceEntries {
+		if se.Spec.Hosts[0] == host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/discovery"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeDiscoverySource struct {
+	services []discovery.Service
+	err      error
+}
+
+func (f *fakeDiscoverySource) Name() string {
+	return "consul"
+}
+
+func (f *fakeDiscoverySource) Services(context.Context) ([]discovery.Service, error) {
+	return f.services, f.err
+}
+
+func TestBuildDiscoveredServiceEntry(t *testing.T) {
+	testCases := []struct {
+		name               string
+		service            discovery.Service
+		expectedResolution networking.ServiceEntry_Resolution
+		expectedPort       *networking.ServicePort
+	}{
+		{
+			name: "Given a service registered with ip addresses, " +
+				"When buildDiscoveredServiceEntry is called, " +
+				"Then a static service entry should be returned",
+			service: discovery.Service{
+				Identity:  "payments",
+				Endpoints: []discovery.Endpoint{{Address: "10.0.0.2", Port: 8080}, {Address: "10.0.0.1", Port: 8080}},
+			},
+			expectedResolution: networking.ServiceEntry_STATIC,
+			expectedPort:       &networking.ServicePort{Number: common.DefaultServiceEntryPort, Name: "http", Protocol: "http"},
+		},
+		{
+			name: "Given a service registered with a hostname, " +
+				"When buildDiscoveredServiceEntry is called, " +
+				"Then a service entry resolved with dns should be returned",
+			service: discovery.Service{
+				Identity:  "payments",
+				Protocol:  "GRPC",
+				Endpoints: []discovery.Endpoint{{Address: "10.0.0.1", Port: 8080}, {Address: "payments.vm.com", Port: 8080}},
+			},
+			expectedResolution: networking.ServiceEntry_DNS,
+			expectedPort:       &networking.ServicePort{Number: common.DefaultServiceEntryPort, Name: "grpc", Protocol: "grpc"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			se := buildDiscoveredServiceEntry("consul", "qa.payments.global", c.service, "admiral-sync")
+			assert.Equal(t, "qa.payments.global-se", se.Name)
+			assert.Equal(t, "consul", se.Labels[common.AdmiralDiscoverySourceLabel])
+			assert.Equal(t, networking.ServiceEntry_MESH_EXTERNAL, se.Spec.Location)
+			assert.Equal(t, c.expectedResolution, se.Spec.Resolution)
+			assert.Equal(t, []*networking.ServicePort{c.expectedPort}, se.Spec.Ports)
+			assert.Equal(t, "10.0.0.1", se.Spec.Endpoints[0].Address)
+			assert.Equal(t, map[string]uint32{c.expectedPort.Name: 8080}, se.Spec.Endpoints[0].Ports)
+		})
+	}
+}
+
+func TestSyncDiscoverySource(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{SyncNamespace: "admiral-sync", HostnameSuffix: "global"})
+
+	ctx := context.Background()
+	istioClient := istioFake.NewSimpleClientset(&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+		Name:      "qa.removed.global-se",
+		Namespace: "admiral-sync",
+		Labels:    map[string]string{common.AdmiralDiscoverySourceLabel: "consul"},
+	}})
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient, Cache: istio.NewServiceEntryCache()},
+	})
+	rr.AdmiralCache.IdentityClusterCache.Put("orders", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityDependencyCache.Put("payments", "orders", "orders")
+
+	source := &fakeDiscoverySource{services: []discovery.Service{
+		{
+			Identity:     "payments",
+			Env:          "qa",
+			Endpoints:    []discovery.Endpoint{{Address: "10.0.0.1", Port: 8080, Locality: "us-west-2"}},
+			Dependencies: []string{"ledger"},
+		},
+		{
+			Identity:  "orders",
+			Env:       "qa",
+			Endpoints: []discovery.Endpoint{{Address: "10.0.0.2", Port: 8080}},
+		},
+	}}
+	dependencies := syncDiscoverySource(ctx, rr, source, map[string][]string{})
+
+	se, err := istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "qa.payments.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1", se.Spec.Endpoints[0].Address)
+	assert.Equal(t, "us-west-2", se.Spec.Endpoints[0].Locality)
+	identity, _ := rr.AdmiralCache.CnameIdentityCache.Load("qa.payments.global")
+	assert.Equal(t, "payments", identity)
+	assert.True(t, rr.AdmiralCache.IdentityDependencyCache.Get("ledger").CheckIfPresent("payments"))
+	assert.Equal(t, []string{"ledger"}, rr.AdmiralCache.SourceToDestinations.Get("payments"))
+	assert.Equal(t, map[string][]string{"payments": {"ledger"}}, dependencies)
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "qa.orders.global-se", metaV1.GetOptions{})
+	assert.NotNil(t, err, "the identities registered in the mesh clusters should not be synced")
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "qa.removed.global-se", metaV1.GetOptions{})
+	assert.NotNil(t, err, "the service entries of the services no longer registered should be deleted")
+
+	source.err = errors.New("connection refused")
+	assert.Equal(t, dependencies, syncDiscoverySource(ctx, rr, source, dependencies))
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "qa.payments.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err, "the service entries should be kept when the source cannot be read")
+
+	source.err = nil
+	source.services = source.services[:1]
+	source.services[0].Dependencies = nil
+	syncDiscoverySource(ctx, rr, source, dependencies)
+	assert.False(t, rr.AdmiralCache.IdentityDependencyCache.Get("ledger").CheckIfPresent("payments"))
+}
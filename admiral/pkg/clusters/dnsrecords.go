@@ -0,0 +1,121 @@
+package clusters
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/dns"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// newDNSProvider returns the provider the dns records of the generated cnames are written to
+func newDNSProvider(params common.AdmiralParams, rr *RemoteRegistry) (dns.Provider, error) {
+	config := dns.Config{
+		HostedZoneID: params.DNSRoute53HostedZoneID,
+		Role:         params.DNSRoute53Role,
+		Region:       params.DNSRoute53Region,
+		Namespace:    params.DNSEndpointNamespace,
+	}
+	if params.DNSProvider == "external-dns" {
+		client, err := rr.ClientLoader.LoadDynamicClientFromPath(params.KubeconfigPath)
+		if err != nil {
+			return nil, err
+		}
+		config.Client = client
+	}
+	return dns.NewProvider(params.DNSProvider, config)
+}
+
+// startDNSRecordSync periodically syncs the dns records of the generated cnames
+func startDNSRecordSync(ctx context.Context, rr *RemoteRegistry, provider dns.Provider) {
+	interval := common.GetDNSRecordSyncInterval()
+	if common.IsAdmiralOperatorMode() || provider == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			syncDNSRecords(common.WithNewTxId(ctx), rr, provider)
+		}
+	}
+}
+
+// syncDNSRecords writes a dns record per generated cname resolving to the ingress load balancers of the
+// clusters the cname is served from, and deletes the records of the cnames no longer generated. The
+// record of a cname none of whose clusters has a valid load balancer is left as it is
+func syncDNSRecords(ctx context.Context, rr *RemoteRegistry, provider dns.Provider) {
+	ctxLogger := log.WithFields(log.Fields{
+		"task":     common.DNSRecordSync,
+		"provider": provider.Name(),
+	})
+	records, hosts := getDNSRecords(ctxLogger, rr)
+	for _, record := range records {
+		if err := provider.Upsert(ctx, record); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DNSRecordSync, record.Name, "", "", "failed to write the record: "+err.Error())
+		}
+	}
+
+	names, err := provider.Records(ctx)
+	if err != nil {
+		ctxLogger.Errorf(common.CtxLogFormat, common.DNSRecordSync, "", "", "", "failed to list the records: "+err.Error())
+		return
+	}
+	for _, name := range names {
+		if hosts[name] {
+			continue
+		}
+		ctxLogger.Infof(common.CtxLogFormat, common.DNSRecordSync, name, "", "", "deleting the record of the cname no longer generated")
+		if err := provider.Delete(ctx, name); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.DNSRecordSync, name, "", "", "failed to delete the record: "+err.Error())
+		}
+	}
+}
+
+// getDNSRecords returns the records of the cnames which have at least one cluster with a valid load
+// balancer, along with all the generated cnames
+func getDNSRecords(ctxLogger *log.Entry, rr *RemoteRegistry) ([]dns.Record, map[string]bool) {
+	targets := make(map[string]dns.Target)
+	for _, cluster := range rr.GetClusterIds() {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			continue
+		}
+		endpoint, port, err := getOverwrittenLoadBalancer(ctxLogger, rc, cluster, rr.AdmiralCache)
+		if err != nil || !isValidLoadBalancer(endpoint, port) {
+			continue
+		}
+		region, _ := getClusterRegion(rr, cluster, rc)
+		targets[cluster] = dns.Target{Address: endpoint, Region: region}
+	}
+
+	records := make([]dns.Record, 0)
+	hosts := make(map[string]bool)
+	rr.AdmiralCache.CnameClusterCache.Range(func(host string, clusters *common.Map) {
+		hosts[host] = true
+		record := dns.Record{Name: host, TTL: common.GetDNSRecordTTL()}
+		clusterIDs := clusters.GetKeys()
+		sort.Strings(clusterIDs)
+		for _, cluster := range clusterIDs {
+			if target, ok := targets[cluster]; ok {
+				record.Targets = append(record.Targets, target)
+			}
+		}
+		if len(record.Targets) > 0 {
+			records = append(records, record)
+		}
+	})
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	return records, hosts
+}
@@ -0,0 +1,98 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/dns"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+type fakeDNSProvider struct {
+	records    map[string]dns.Record
+	recordsErr error
+}
+
+func (f *fakeDNSProvider) Name() string {
+	return "fake"
+}
+
+func (f *fakeDNSProvider) Records(context.Context) ([]string, error) {
+	names := make([]string, 0, len(f.records))
+	for name := range f.records {
+		names = append(names, name)
+	}
+	return names, f.recordsErr
+}
+
+func (f *fakeDNSProvider) Upsert(_ context.Context, record dns.Record) error {
+	f.records[record.Name] = record
+	return nil
+}
+
+func (f *fakeDNSProvider) Delete(_ context.Context, name string) error {
+	delete(f.records, name)
+	return nil
+}
+
+func TestSyncDNSRecords(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:     &common.LabelSet{GatewayApp: common.IstioIngressGatewayLabelValue},
+		DNSRecordTTL: 60,
+	})
+	ctx := context.Background()
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	for cluster, lb := range map[string]string{"cluster1": "lb1.example.com", "cluster2": "lb2.example.com", "cluster3": ""} {
+		serviceController, err := admiral.NewServiceController(make(chan struct{}), &test.MockServiceHandler{},
+			&rest.Config{Host: cluster}, time.Second*time.Duration(300), loader.GetFakeClientLoader())
+		assert.Nil(t, err)
+		if lb != "" {
+			serviceController.Cache.Put(&coreV1.Service{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:      "istio-ingressgateway",
+					Namespace: common.NamespaceIstioSystem,
+					Labels:    map[string]string{common.App: common.IstioIngressGatewayLabelValue},
+				},
+				Status: coreV1.ServiceStatus{LoadBalancer: coreV1.LoadBalancerStatus{
+					Ingress: []coreV1.LoadBalancerIngress{{Hostname: lb}},
+				}},
+			})
+		}
+		rr.PutRemoteController(cluster, &RemoteController{
+			ClusterID:         cluster,
+			ServiceController: serviceController,
+			NodeController:    &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}},
+		})
+	}
+	rr.AdmiralCache.CnameClusterCache.Put("qa.foo.global", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameClusterCache.Put("qa.foo.global", "cluster2", "cluster2")
+	rr.AdmiralCache.CnameClusterCache.Put("qa.bar.global", "cluster3", "cluster3")
+
+	provider := &fakeDNSProvider{records: map[string]dns.Record{
+		"qa.bar.global":     {Name: "qa.bar.global"},
+		"qa.removed.global": {Name: "qa.removed.global"},
+	}}
+	provider.recordsErr = errors.New("throttled")
+	syncDNSRecords(ctx, rr, provider)
+	assert.Contains(t, provider.records, "qa.removed.global", "the records should be kept when they cannot be listed")
+
+	provider.recordsErr = nil
+	syncDNSRecords(ctx, rr, provider)
+	assert.Equal(t, dns.Record{Name: "qa.foo.global", TTL: 60, Targets: []dns.Target{
+		{Address: "lb1.example.com", Region: "us-west-2"},
+		{Address: "lb2.example.com", Region: "us-west-2"},
+	}}, provider.records["qa.foo.global"])
+	assert.Equal(t, dns.Record{Name: "qa.bar.global"}, provider.records["qa.bar.global"],
+		"the record of a cname without a valid load balancer should be left as it is")
+	assert.NotContains(t, provider.records, "qa.removed.global", "the records of the cnames no longer generated should be deleted")
+}
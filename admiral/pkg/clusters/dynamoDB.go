@@ -1,6 +1,7 @@
 package clusters
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"strconv"
@@ -31,6 +32,13 @@ const (
 	FailedClustersKey  = "failedClusters"
 )
 
+// dynamoDbRetryPolicy retries every failed write to dynamoDB
+var dynamoDbRetryPolicy = common.RetryPolicy{
+	Attempts:       dynamoDbMaxRetries,
+	InitialBackoff: dynamoDbRetryBackoffTime,
+	MaxBackoff:     dynamoDbRetryBackoffTime,
+}
+
 type DynamoDBConfigWrapper struct {
 	DynamoDBConfig DynamoDBConfig `yaml:"dynamoDB,omitempty"`
 }
@@ -79,11 +87,15 @@ type DynamicConfigData struct {
 		DynamoDB primary key only support string, binary, int.
 		Conversation from binary to bool is not straight forward hence choosing string
 	*/
-	EnableDynamicConfig                  string   `json:"enableDynamicConfig"`
-	NLBEnabledClusters                   []string `json:"nlbEnabledClusters"`
-	NLBEnabledIdentityList               []string `json:"nlbEnabledAssetAlias"`
-	CLBEnabledClusters                   []string `json:"clbEnabledClusters"`
-	InitiateClientInitiatedProcessingFor []string `json:"initiateClientInitiatedProcessingFor"`
+	EnableDynamicConfig                  string            `json:"enableDynamicConfig"`
+	NLBEnabledClusters                   []string          `json:"nlbEnabledClusters"`
+	NLBEnabledIdentityList               []string          `json:"nlbEnabledAssetAlias"`
+	CLBEnabledClusters                   []string          `json:"clbEnabledClusters"`
+	InitiateClientInitiatedProcessingFor []string          `json:"initiateClientInitiatedProcessingFor"`
+	NamespaceAllowList                   map[string]string `json:"namespaceAllowList"`
+	NamespaceDenyList                    map[string]string `json:"namespaceDenyList"`
+	NamespaceDenySelectors               map[string]string `json:"namespaceDenySelectors"`
+	ClusterSyncNamespaces                map[string]string `json:"clusterSyncNamespaces"`
 }
 
 type DynamoClient struct {
@@ -148,8 +160,8 @@ func (client *DynamoClient) updateWorkloadDataItem(workloadDataEntry *WorkloadDa
 		return err
 	}
 
-	for i := 0; i < dynamoDbMaxRetries; i++ {
-		_, err = client.svc.UpdateItem(&dynamodb.UpdateItemInput{
+	err = common.RetryWithBackoff(context.Background(), dynamoDbRetryPolicy, func(int) error {
+		_, err := client.svc.UpdateItem(&dynamodb.UpdateItemInput{
 			TableName:                 aws.String(tableName),
 			ReturnValues:              aws.String("NONE"), // NONE as we are ignoring the return value
 			UpdateExpression:          expr.Update(),
@@ -163,11 +175,12 @@ func (client *DynamoClient) updateWorkloadDataItem(workloadDataEntry *WorkloadDa
 
 		if err != nil {
 			ctxLogger.Errorf(common.CtxLogFormat, updateWorkloadDataItem, tableName, "", "", fmt.Sprintf("failed to update dynamoDB item: %v. Retrying in %v seconds", err, dynamoDbRetryBackoffTime.String()))
-			time.Sleep(dynamoDbRetryBackoffTime)
-		} else {
-			ctxLogger.Infof(common.CtxLogFormat, updateWorkloadDataItem, tableName, "", "", fmt.Sprintf("successfully updated workload data for endpoint=%s", workloadDataEntry.Endpoint))
-			return nil
 		}
+		return err
+	})
+	if err == nil {
+		ctxLogger.Infof(common.CtxLogFormat, updateWorkloadDataItem, tableName, "", "", fmt.Sprintf("successfully updated workload data for endpoint=%s", workloadDataEntry.Endpoint))
+		return nil
 	}
 
 	ctxLogger.Errorf(common.CtxLogFormat+" maxAttempts=%v", updateWorkloadDataItem, tableName, "", "", dynamoDbMaxRetries,
@@ -294,20 +307,20 @@ func (client *DynamoClient) deleteWorkloadDataItem(workloadDataEntry *WorkloadDa
 		TableName: aws.String(tableName),
 	}
 
-	for i := 0; i < dynamoDbMaxRetries; i++ {
-		_, err = svc.DeleteItem(input)
-
+	err = common.RetryWithBackoff(context.Background(), dynamoDbRetryPolicy, func(int) error {
+		_, err := svc.DeleteItem(input)
 		if err != nil {
 			log.Info("failed to delete dynamoDB item, retying again in " + dynamoDbRetryBackoffTime.String())
-			time.Sleep(dynamoDbRetryBackoffTime)
-		} else {
-			log.WithFields(log.Fields{
-				"workloadEndpoint": workloadDataEntry.Endpoint,
-				"assetAlias":       workloadDataEntry.AssetAlias,
-			}).Infof("Successfully deleted workload data for endpoint %s to table %s", workloadDataEntry.Endpoint, tableName)
-
-			return nil
 		}
+		return err
+	})
+	if err == nil {
+		log.WithFields(log.Fields{
+			"workloadEndpoint": workloadDataEntry.Endpoint,
+			"assetAlias":       workloadDataEntry.AssetAlias,
+		}).Infof("Successfully deleted workload data for endpoint %s to table %s", workloadDataEntry.Endpoint, tableName)
+
+		return nil
 	}
 
 	alertMsgWhenFailedToDeleteEndpointData := fmt.Sprintf("exhausted all retry attempts, failed to delete workload record for endpoint %s", workloadDataEntry.Endpoint)
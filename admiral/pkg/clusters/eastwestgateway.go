@@ -0,0 +1,121 @@
+package clusters
+
+import (
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	k8sV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const eastWestGatewayTLSPortName = "tls"
+
+type eastWestGatewayEntry struct {
+	endpoint  string
+	port      int
+	expiresAt time.Time
+}
+
+// eastWestGatewayCache holds the endpoint and port of the east-west gateway discovered
+// in each cluster until they expire or the gateway service changes
+type eastWestGatewayCache struct {
+	mutex   sync.RWMutex
+	entries map[string]*eastWestGatewayEntry
+}
+
+func newEastWestGatewayCache() *eastWestGatewayCache {
+	return &eastWestGatewayCache{
+		entries: make(map[string]*eastWestGatewayEntry),
+	}
+}
+
+func (c *eastWestGatewayCache) Put(cluster, endpoint string, port int, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[cluster] = &eastWestGatewayEntry{endpoint: endpoint, port: port, expiresAt: time.Now().Add(ttl)}
+}
+
+// Get returns the endpoint and port of the east-west gateway of the cluster, ok is false
+// when it was not discovered yet or the entry expired
+func (c *eastWestGatewayCache) Get(cluster string) (string, int, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	entry, ok := c.entries[cluster]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", 0, false
+	}
+	return entry.endpoint, entry.port, true
+}
+
+func (c *eastWestGatewayCache) Delete(cluster string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, cluster)
+}
+
+// isEastWestGatewayService checks if the service is the east-west gateway matching east_west_gateway_selector
+func isEastWestGatewayService(svc *k8sV1.Service) bool {
+	selector := common.GetEastWestGatewaySelector()
+	if svc == nil || len(selector) == 0 || svc.Namespace != common.GetEastWestGatewayNamespace() {
+		return false
+	}
+	return labels.SelectorFromSet(selector).Matches(labels.Set(svc.Labels))
+}
+
+// getEastWestGateway returns the endpoint and port of the east-west gateway of the cluster, discovering it
+// from the services of the cluster when it is not cached. ok is false when the discovery is disabled or
+// no east-west gateway with a reachable address was found
+func getEastWestGateway(rc *RemoteController, cache *AdmiralCache) (string, int, bool) {
+	if len(common.GetEastWestGatewaySelector()) == 0 || rc == nil || rc.ServiceController == nil || rc.ServiceController.Cache == nil {
+		return "", 0, false
+	}
+	if cache != nil && cache.EastWestGatewayCache != nil {
+		if endpoint, port, ok := cache.EastWestGatewayCache.Get(rc.ClusterID); ok {
+			return endpoint, port, true
+		}
+	}
+	for _, svc := range rc.ServiceController.Cache.Get(common.GetEastWestGatewayNamespace()) {
+		if !isEastWestGatewayService(svc) {
+			continue
+		}
+		endpoint, port := getEastWestGatewayAddress(svc)
+		if endpoint == "" || port == 0 {
+			continue
+		}
+		if cache != nil && cache.EastWestGatewayCache != nil {
+			cache.EastWestGatewayCache.Put(rc.ClusterID, endpoint, port, common.GetEastWestGatewayCacheTTL())
+		}
+		return endpoint, port, true
+	}
+	return "", 0, false
+}
+
+// getEastWestGatewayAddress resolves the load balancer hostname or IP of the east-west gateway service
+// and its mTLS port. The node port is used instead when the service is exposed through external IPs
+func getEastWestGatewayAddress(svc *k8sV1.Service) (string, int) {
+	var tlsPort *k8sV1.ServicePort
+	for i, port := range svc.Spec.Ports {
+		if port.Name == eastWestGatewayTLSPortName || port.Port == common.DefaultMtlsPort {
+			tlsPort = &svc.Spec.Ports[i]
+			break
+		}
+	}
+	if tlsPort == nil {
+		return "", 0
+	}
+	if ingress := svc.Status.LoadBalancer.Ingress; len(ingress) > 0 {
+		if ingress[0].Hostname != "" {
+			//Add "." at the end of the address to prevent additional DNS calls via search domains
+			if common.IsAbsoluteFQDNEnabled() {
+				return ingress[0].Hostname + common.Sep, int(tlsPort.Port)
+			}
+			return ingress[0].Hostname, int(tlsPort.Port)
+		}
+		return ingress[0].IP, int(tlsPort.Port)
+	}
+	if len(svc.Spec.ExternalIPs) > 0 {
+		return svc.Spec.ExternalIPs[0], int(tlsPort.NodePort)
+	}
+	return "", 0
+}
@@ -0,0 +1,130 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func newEastWestGatewayTestService(hostname string, ports ...coreV1.ServicePort) *coreV1.Service {
+	svc := &coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "istio-eastwestgateway",
+			Namespace: common.NamespaceIstioSystem,
+			Labels:    map[string]string{"istio": "eastwestgateway"},
+		},
+		Spec: coreV1.ServiceSpec{
+			Selector: map[string]string{"istio": "eastwestgateway"},
+			Ports:    ports,
+		},
+	}
+	if hostname != "" {
+		svc.Status.LoadBalancer.Ingress = []coreV1.LoadBalancerIngress{{Hostname: hostname}}
+	}
+	return svc
+}
+
+func TestGetEastWestGatewayAddress(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	externalIPService := newEastWestGatewayTestService("", coreV1.ServicePort{Name: "tls", Port: 15443, NodePort: 31443})
+	externalIPService.Spec.ExternalIPs = []string{"10.0.0.1"}
+	ipService := newEastWestGatewayTestService("", coreV1.ServicePort{Name: "tls", Port: 15443})
+	ipService.Status.LoadBalancer.Ingress = []coreV1.LoadBalancerIngress{{IP: "10.0.0.2"}}
+
+	testCases := []struct {
+		name             string
+		svc              *coreV1.Service
+		expectedEndpoint string
+		expectedPort     int
+	}{
+		{
+			name: "Given an east-west gateway without a tls port, " +
+				"When getEastWestGatewayAddress is called, " +
+				"Then no address should be returned",
+			svc: newEastWestGatewayTestService("ew.example.com", coreV1.ServicePort{Name: "status-port", Port: 15021}),
+		},
+		{
+			name: "Given an east-west gateway with a load balancer hostname, " +
+				"When getEastWestGatewayAddress is called, " +
+				"Then the hostname and the port of the tls port should be returned",
+			svc: newEastWestGatewayTestService("ew.example.com",
+				coreV1.ServicePort{Name: "status-port", Port: 15021}, coreV1.ServicePort{Name: "tls", Port: 16443}),
+			expectedEndpoint: "ew.example.com",
+			expectedPort:     16443,
+		},
+		{
+			name: "Given an east-west gateway with a load balancer IP, " +
+				"When getEastWestGatewayAddress is called, " +
+				"Then the IP should be returned",
+			svc:              ipService,
+			expectedEndpoint: "10.0.0.2",
+			expectedPort:     15443,
+		},
+		{
+			name: "Given an east-west gateway exposed through external IPs, " +
+				"When getEastWestGatewayAddress is called, " +
+				"Then the external IP and the node port should be returned",
+			svc:              externalIPService,
+			expectedEndpoint: "10.0.0.1",
+			expectedPort:     31443,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint, port := getEastWestGatewayAddress(tc.svc)
+			assert.Equal(t, tc.expectedEndpoint, endpoint)
+			assert.Equal(t, tc.expectedPort, port)
+		})
+	}
+}
+
+func TestGetEastWestGateway(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		EastWestGatewaySelector: map[string]string{"istio": "eastwestgateway"},
+		EastWestGatewayCacheTTL: time.Minute,
+	})
+	serviceController, err := admiral.NewServiceController(make(chan struct{}), &test.MockServiceHandler{},
+		&rest.Config{Host: "localhost"}, time.Second*time.Duration(300), loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	rc := &RemoteController{ClusterID: "cluster1", ServiceController: serviceController}
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+
+	_, _, ok := getEastWestGateway(rc, rr.AdmiralCache)
+	assert.False(t, ok, "no east-west gateway should be found before its service is added")
+
+	svc := newEastWestGatewayTestService("ew-1.example.com", coreV1.ServicePort{Name: "tls", Port: 15443})
+	serviceController.Cache.Put(svc)
+	endpoint, port, ok := getEastWestGateway(rc, rr.AdmiralCache)
+	assert.True(t, ok)
+	assert.Equal(t, "ew-1.example.com", endpoint)
+	assert.Equal(t, 15443, port)
+
+	// the cached address is used until the gateway service changes
+	serviceController.Cache.Put(newEastWestGatewayTestService("ew-2.example.com", coreV1.ServicePort{Name: "tls", Port: 15443}))
+	endpoint, _, _ = getEastWestGateway(rc, rr.AdmiralCache)
+	assert.Equal(t, "ew-1.example.com", endpoint)
+	rr.AdmiralCache.EastWestGatewayCache.Delete("cluster1")
+	endpoint, _, _ = getEastWestGateway(rc, rr.AdmiralCache)
+	assert.Equal(t, "ew-2.example.com", endpoint)
+
+	// expired entries are discovered again
+	rr.AdmiralCache.EastWestGatewayCache.Put("cluster1", "ew-stale.example.com", 15443, -time.Second)
+	endpoint, _, _ = getEastWestGateway(rc, rr.AdmiralCache)
+	assert.Equal(t, "ew-2.example.com", endpoint)
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	_, _, ok = getEastWestGateway(rc, rr.AdmiralCache)
+	assert.False(t, ok, "no east-west gateway should be discovered when the selector is not set")
+}
@@ -85,6 +85,7 @@ func createOrUpdateEnvoyFilter(ctx context.Context, rc *RemoteController, routin
 			//nolint
 			Spec: *envoyfilterSpec,
 		}
+		stampTxId(ctx, envoyfilter)
 
 		// To maintain mapping of envoyfilters created for a routing policy, and to facilitate deletion of envoyfilters when routing policy is deleted
 		admiralCache.RoutingPolicyFilterCache.Put(routingPolicy.Name+common.GetRoutingPolicyIdentity(routingPolicy)+env, rc.ClusterID, envoyFilterName, filterNamespace)
@@ -0,0 +1,198 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// maxCoalescedPassRetries is the number of times a deferred processing pass which failed is run again,
+// the same number of retries the controllers give the events they process
+const maxCoalescedPassRetries = 2
+
+// eventCoalescer collapses the workload events of an identity and environment which follow a
+// processed event within the coalescing window into a single processing pass at the end of the
+// window, so a scaling deployment does not trigger a modifySE run per event.
+// With a batch window the first event is deferred as well, so the events of an identity changing
+// in several clusters at once are processed in a single pass with the combined view of the clusters
+type eventCoalescer struct {
+	mutex    sync.Mutex
+	modifySE ModifySEFunc
+	passes   map[string]*coalescedPass
+}
+
+// coalescedPass is the latest processing pass of an identity and environment, and the latest
+// event waiting for the next one along with the clusters the waiting events were received from
+type coalescedPass struct {
+	lastRun  time.Time
+	pending  bool
+	retries  int
+	ctx      context.Context
+	event    admiral.EventType
+	clusters map[string]bool
+}
+
+func newEventCoalescer(modifySE ModifySEFunc) *eventCoalescer {
+	return &eventCoalescer{
+		modifySE: modifySE,
+		passes:   make(map[string]*coalescedPass),
+	}
+}
+
+// process runs modifySE for the event, unless the identity and environment were processed
+// within the coalescing window, or a batch window is set, in which case the event is deferred
+// to the pass at the end of the window. Delete events are never deferred. As the controller has
+// already forgotten a deferred event, the coalescer retries the pass itself when it fails
+func (c *eventCoalescer) process(ctx context.Context, event admiral.EventType, env string,
+	sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+	window := common.GetEventCoalescingWindow()
+	batchWindow := common.GetIdentityBatchWindow()
+	if (window <= 0 && batchWindow <= 0) || event == admiral.Delete {
+		return c.modifySE(ctx, event, env, sourceIdentity, remoteRegistry)
+	}
+	key := sourceIdentity + common.Sep + env
+	now := time.Now()
+
+	c.mutex.Lock()
+	pass, ok := c.passes[key]
+	if !ok || (!pass.pending && now.Sub(pass.lastRun) >= window) {
+		if batchWindow <= 0 {
+			pass = &coalescedPass{lastRun: now}
+			c.passes[key] = pass
+			c.expire(key, pass, window)
+			c.mutex.Unlock()
+			return c.modifySE(ctx, event, env, sourceIdentity, remoteRegistry)
+		}
+		pass = &coalescedPass{}
+		c.passes[key] = pass
+		c.schedule(ctx, event, pass, key, env, sourceIdentity, remoteRegistry, batchWindow)
+		c.mutex.Unlock()
+		log.Infof(common.CtxLogFormat, common.EventCoalescing, sourceIdentity, "", env,
+			"event deferred to the processing pass at the end of the batch window")
+		return nil, nil
+	}
+	c.schedule(ctx, event, pass, key, env, sourceIdentity, remoteRegistry, window-now.Sub(pass.lastRun))
+	c.mutex.Unlock()
+
+	coalescedEvents.Increment(api.WithAttributes(
+		attribute.Key("identity").String(sourceIdentity),
+		attribute.Key("environment").String(env),
+	))
+	log.Infof(common.CtxLogFormat, common.EventCoalescing, sourceIdentity, "", env,
+		"event collapsed into the pending processing pass")
+	return nil, nil
+}
+
+// schedule records the event as the latest one of the pass, and schedules the pass after the delay
+// when it is not pending yet. It must be called with the lock held
+func (c *eventCoalescer) schedule(ctx context.Context, event admiral.EventType, pass *coalescedPass,
+	key, env, sourceIdentity string, remoteRegistry *RemoteRegistry, delay time.Duration) {
+	pass.ctx, pass.event = ctx, event
+	if pass.clusters == nil {
+		pass.clusters = make(map[string]bool)
+	}
+	if clusterName, ok := ctx.Value(common.ClusterName).(string); ok {
+		pass.clusters[clusterName] = true
+	}
+	if pass.pending {
+		return
+	}
+	pass.pending = true
+	time.AfterFunc(delay, func() {
+		c.flush(key, pass, env, sourceIdentity, remoteRegistry)
+	})
+}
+
+// flush runs the deferred processing pass of the identity and environment for the latest event.
+// A failed pass is scheduled again unless newer events already did, and the pass is dropped once
+// it stays idle for the coalescing window
+func (c *eventCoalescer) flush(key string, pass *coalescedPass, env, sourceIdentity string, remoteRegistry *RemoteRegistry) {
+	c.mutex.Lock()
+	pass.pending = false
+	pass.lastRun = time.Now()
+	ctx, event := pass.ctx, pass.event
+	clusters := make([]string, 0, len(pass.clusters))
+	for cluster := range pass.clusters {
+		clusters = append(clusters, cluster)
+	}
+	pass.ctx, pass.clusters = nil, nil
+	c.mutex.Unlock()
+
+	sort.Strings(clusters)
+	log.Infof(common.CtxLogFormat, common.EventCoalescing, sourceIdentity, "", env,
+		"processing the deferred events of clusters="+strings.Join(clusters, ","))
+	err := common.CallWithRecovery(ctx, common.EventCoalescing, func() error {
+		_, err := c.modifySE(ctx, event, env, sourceIdentity, remoteRegistry)
+		return err
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		log.Errorf(common.CtxLogFormat, common.EventCoalescing, sourceIdentity, "", env,
+			"failed to process the deferred events: "+err.Error())
+		if c.passes[key] == pass && !pass.pending && pass.retries < maxCoalescedPassRetries {
+			pass.retries++
+			log.Infof(common.CtxLogFormat, common.EventCoalescing, sourceIdentity, "", env,
+				fmt.Sprintf("processing pass scheduled again. retryCount=%d", pass.retries))
+			c.schedule(ctx, event, pass, key, env, sourceIdentity, remoteRegistry, getCoalescedPassRetryDelay())
+			return
+		}
+	}
+	pass.retries = 0
+	c.expire(key, pass, common.GetEventCoalescingWindow())
+}
+
+// expire drops the pass of the identity and environment when it is still idle after the coalescing
+// window following its last run, so the passes of the identities which stopped changing are not kept.
+// It must be called with the lock held
+func (c *eventCoalescer) expire(key string, pass *coalescedPass, window time.Duration) {
+	lastRun := pass.lastRun
+	time.AfterFunc(window, func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if c.passes[key] == pass && !pass.pending && pass.lastRun.Equal(lastRun) {
+			delete(c.passes, key)
+		}
+	})
+}
+
+// getCoalescedPassRetryDelay returns the delay before a failed processing pass is run again
+func getCoalescedPassRetryDelay() time.Duration {
+	if window := common.GetEventCoalescingWindow(); window > 0 {
+		return window
+	}
+	return common.GetIdentityBatchWindow()
+}
+
+// hasPendingPasses checks if the deferred events of any identity and environment are waiting for their pass
+func (c *eventCoalescer) hasPendingPasses() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, pass := range c.passes {
+		if pass.pending {
+			return true
+		}
+	}
+	return false
+}
+
+// modifyServiceEntryForWorkloadEvent runs modifySE for a deployment or rollout event through the
+// event coalescer of the registry
+func modifyServiceEntryForWorkloadEvent(ctx context.Context, event admiral.EventType, env string,
+	sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+	if remoteRegistry.eventCoalescer == nil {
+		return modifyServiceEntryForNewServiceOrPod(ctx, event, env, sourceIdentity, remoteRegistry)
+	}
+	return remoteRegistry.eventCoalescer.process(ctx, event, env, sourceIdentity, remoteRegistry)
+}
@@ -0,0 +1,189 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+type recordedModifySE struct {
+	mutex  sync.Mutex
+	events []admiral.EventType
+}
+
+func (r *recordedModifySE) modifySE(ctx context.Context, event admiral.EventType, env string,
+	sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+	return nil, nil
+}
+
+func (r *recordedModifySE) get() []admiral.EventType {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]admiral.EventType{}, r.events...)
+}
+
+func TestEventCoalescerProcess(t *testing.T) {
+	testCases := []struct {
+		name           string
+		window         time.Duration
+		events         []admiral.EventType
+		expectedEvents []admiral.EventType
+	}{
+		{
+			name: "Given the coalescing window is disabled, " +
+				"When events of an identity are processed, " +
+				"Then modifySE should run for every event",
+			events:         []admiral.EventType{admiral.Add, admiral.Update, admiral.Update},
+			expectedEvents: []admiral.EventType{admiral.Add, admiral.Update, admiral.Update},
+		},
+		{
+			name: "Given a coalescing window, " +
+				"When events of an identity arrive within the window, " +
+				"Then modifySE should run for the first event and once for the latest event at the end of the window",
+			window:         50 * time.Millisecond,
+			events:         []admiral.EventType{admiral.Add, admiral.Update, admiral.Add, admiral.Update},
+			expectedEvents: []admiral.EventType{admiral.Add, admiral.Update},
+		},
+		{
+			name: "Given a coalescing window, " +
+				"When a Delete event of an identity arrives within the window, " +
+				"Then modifySE should run for the Delete event right away",
+			window:         50 * time.Millisecond,
+			events:         []admiral.EventType{admiral.Add, admiral.Delete},
+			expectedEvents: []admiral.EventType{admiral.Add, admiral.Delete},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{EventCoalescingWindow: c.window})
+			recorder := &recordedModifySE{}
+			coalescer := newEventCoalescer(recorder.modifySE)
+			for _, event := range c.events {
+				_, err := coalescer.process(context.Background(), event, "qa", "payments", &RemoteRegistry{})
+				assert.Nil(t, err)
+			}
+			assert.Eventually(t, func() bool {
+				return assert.ObjectsAreEqual(c.expectedEvents, recorder.get())
+			}, time.Second, 5*time.Millisecond)
+			time.Sleep(2 * c.window)
+			assert.Equal(t, c.expectedEvents, recorder.get())
+		})
+	}
+}
+
+func TestEventCoalescerProcessAfterWindow(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EventCoalescingWindow: 20 * time.Millisecond})
+	recorder := &recordedModifySE{}
+	coalescer := newEventCoalescer(recorder.modifySE)
+
+	_, _ = coalescer.process(context.Background(), admiral.Add, "qa", "payments", &RemoteRegistry{})
+	_, _ = coalescer.process(context.Background(), admiral.Add, "qa", "orders", &RemoteRegistry{})
+	time.Sleep(40 * time.Millisecond)
+	_, _ = coalescer.process(context.Background(), admiral.Update, "qa", "payments", &RemoteRegistry{})
+	assert.Equal(t, []admiral.EventType{admiral.Add, admiral.Add, admiral.Update}, recorder.get())
+}
+
+func TestEventCoalescerProcessWithBatchWindow(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{IdentityBatchWindow: 30 * time.Millisecond})
+	recorder := &recordedModifySE{}
+	coalescer := newEventCoalescer(recorder.modifySE)
+
+	for _, cluster := range []string{"cluster1", "cluster2", "cluster3"} {
+		ctx := context.WithValue(context.Background(), common.ClusterName, cluster)
+		_, err := coalescer.process(ctx, admiral.Update, "qa", "payments", &RemoteRegistry{})
+		assert.Nil(t, err)
+	}
+	assert.Empty(t, recorder.get())
+	assert.Eventually(t, func() bool {
+		return len(recorder.get()) == 1
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, []admiral.EventType{admiral.Update}, recorder.get())
+
+	_, _ = coalescer.process(context.Background(), admiral.Delete, "qa", "payments", &RemoteRegistry{})
+	assert.Equal(t, []admiral.EventType{admiral.Update, admiral.Delete}, recorder.get())
+}
+
+func TestEventCoalescerRetriesFailedPass(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{IdentityBatchWindow: 10 * time.Millisecond})
+	var mutex sync.Mutex
+	calls := 0
+	coalescer := newEventCoalescer(func(ctx context.Context, event admiral.EventType, env string,
+		sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("failed to write the service entries")
+		}
+		return nil, nil
+	})
+	getCalls := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return calls
+	}
+
+	_, err := coalescer.process(context.Background(), admiral.Update, "qa", "payments", &RemoteRegistry{})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return getCalls() == 2
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 2, getCalls())
+}
+
+func TestEventCoalescerGivesUpFailedPass(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{IdentityBatchWindow: 5 * time.Millisecond})
+	var mutex sync.Mutex
+	calls := 0
+	coalescer := newEventCoalescer(func(ctx context.Context, event admiral.EventType, env string,
+		sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls++
+		return nil, fmt.Errorf("failed to write the service entries")
+	})
+
+	_, _ = coalescer.process(context.Background(), admiral.Update, "qa", "payments", &RemoteRegistry{})
+	time.Sleep(100 * time.Millisecond)
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 1+maxCoalescedPassRetries, calls)
+}
+
+func TestEventCoalescerDropsIdlePasses(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EventCoalescingWindow: 10 * time.Millisecond})
+	recorder := &recordedModifySE{}
+	coalescer := newEventCoalescer(recorder.modifySE)
+	passes := func() int {
+		coalescer.mutex.Lock()
+		defer coalescer.mutex.Unlock()
+		return len(coalescer.passes)
+	}
+
+	_, _ = coalescer.process(context.Background(), admiral.Add, "qa", "payments", &RemoteRegistry{})
+	_, _ = coalescer.process(context.Background(), admiral.Update, "qa", "payments", &RemoteRegistry{})
+	_, _ = coalescer.process(context.Background(), admiral.Add, "qa", "orders", &RemoteRegistry{})
+	assert.Equal(t, 2, passes())
+	assert.Eventually(t, func() bool {
+		return passes() == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []admiral.EventType{admiral.Add, admiral.Add, admiral.Update}, recorder.get())
+}
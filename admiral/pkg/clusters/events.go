@@ -0,0 +1,152 @@
+package clusters
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedCoreV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	eventComponent = "admiral"
+
+	eventReasonSyncFailed         = "SyncFailed"
+	eventReasonDeadClusterSkipped = "DeadClusterSkipped"
+	eventReasonExportToCapped     = "ExportToCapped"
+)
+
+// newEventRecorder returns the recorder which emits kubernetes events against the resources
+// of the cluster the client belongs to
+func newEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedCoreV1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, coreV1.EventSource{Component: eventComponent})
+}
+
+// recordEvent emits a kubernetes event against the object in the cluster of the remote controller,
+// events are not emitted when they are disabled
+func recordEvent(rc *RemoteController, obj runtime.Object, eventType, reason, message string) {
+	if rc == nil || rc.EventRecorder == nil || obj == nil {
+		return
+	}
+	log.Debugf(LogFormat, "RecordEvent", reason, "", rc.ClusterID, message)
+	rc.EventRecorder.Event(obj, eventType, reason, message)
+}
+
+// virtualServiceReference returns the reference events are emitted against for the VirtualService,
+// as objects read from the informers do not carry their kind
+func virtualServiceReference(vs *v1alpha3.VirtualService) *coreV1.ObjectReference {
+	return &coreV1.ObjectReference{
+		Kind:            string(common.VirtualServiceResourceType),
+		APIVersion:      v1alpha3.SchemeGroupVersion.String(),
+		Name:            vs.Name,
+		Namespace:       vs.Namespace,
+		UID:             vs.UID,
+		ResourceVersion: vs.ResourceVersion,
+	}
+}
+
+// globalTrafficPolicyReference returns the reference events are emitted against for the GlobalTrafficPolicy
+func globalTrafficPolicyReference(gtp *v1.GlobalTrafficPolicy) *coreV1.ObjectReference {
+	return &coreV1.ObjectReference{
+		Kind:            string(common.GlobalTrafficPolicyResourceType),
+		APIVersion:      v1.SchemeGroupVersion.String(),
+		Name:            gtp.Name,
+		Namespace:       gtp.Namespace,
+		UID:             gtp.UID,
+		ResourceVersion: gtp.ResourceVersion,
+	}
+}
+
+// sourceWorkloadReferences returns the references of the source deployments and rollouts keyed by cluster
+func sourceWorkloadReferences(sourceDeployments map[string]*k8sAppsV1.Deployment, sourceRollouts map[string]*argo.Rollout) map[string][]*coreV1.ObjectReference {
+	references := make(map[string][]*coreV1.ObjectReference)
+	for cluster, deployment := range sourceDeployments {
+		if deployment == nil {
+			continue
+		}
+		references[cluster] = append(references[cluster], &coreV1.ObjectReference{
+			Kind:       string(common.DeploymentResourceType),
+			APIVersion: k8sAppsV1.SchemeGroupVersion.String(),
+			Name:       deployment.Name,
+			Namespace:  deployment.Namespace,
+			UID:        deployment.UID,
+		})
+	}
+	for cluster, rollout := range sourceRollouts {
+		if rollout == nil {
+			continue
+		}
+		references[cluster] = append(references[cluster], &coreV1.ObjectReference{
+			Kind:       string(common.RolloutResourceType),
+			APIVersion: argo.SchemeGroupVersion.String(),
+			Name:       rollout.Name,
+			Namespace:  rollout.Namespace,
+			UID:        rollout.UID,
+		})
+	}
+	return references
+}
+
+// recordSourceWorkloadEvents emits events for the exportTo truncation and sync failure of the
+// service entries generated for the source workloads against each of them
+func recordSourceWorkloadEvents(rr *RemoteRegistry, sourceDeployments map[string]*k8sAppsV1.Deployment,
+	sourceRollouts map[string]*argo.Rollout, serviceEntries map[string]*networking.ServiceEntry,
+	clusters []string, syncErr error) {
+	if !common.EnableK8sEvents() {
+		return
+	}
+	var messages []string
+	clusters = slices.Clone(clusters)
+	sort.Strings(clusters)
+	for _, se := range serviceEntries {
+		if se == nil || len(se.Hosts) == 0 {
+			continue
+		}
+		var cappedClusters []string
+		count := 0
+		for _, cluster := range clusters {
+			if dependentNamespaceCount, capped := getExportToCappedNamespaceCount(rr.AdmiralCache, se.Hosts[0], cluster); capped {
+				cappedClusters = append(cappedClusters, cluster)
+				count = max(count, dependentNamespaceCount)
+			}
+		}
+		if len(cappedClusters) > 0 {
+			messages = append(messages, fmt.Sprintf("exportTo of %s was capped in clusters %v as up to %d dependent namespaces exceed the max of %d",
+				se.Hosts[0], cappedClusters, count, common.GetExportToMaxNamespaces()))
+		}
+	}
+	sort.Strings(messages)
+	for cluster, references := range sourceWorkloadReferences(sourceDeployments, sourceRollouts) {
+		rc := rr.GetRemoteController(cluster)
+		for _, reference := range references {
+			for _, message := range messages {
+				recordEvent(rc, reference, coreV1.EventTypeWarning, eventReasonExportToCapped, message)
+			}
+			if syncErr != nil {
+				recordEvent(rc, reference, coreV1.EventTypeWarning, eventReasonSyncFailed,
+					fmt.Sprintf("failed to sync service entries to dependent clusters: %v", syncErr))
+			}
+		}
+	}
+}
+
+// recordDeadClusterSkippedEvent emits an event against the source VirtualService when syncing it
+// to an unreachable cluster was skipped
+func recordDeadClusterSkippedEvent(rr *RemoteRegistry, sourceCluster string, sourceReference *coreV1.ObjectReference, vsName, cluster string) {
+	recordEvent(rr.GetRemoteController(sourceCluster), sourceReference, coreV1.EventTypeWarning, eventReasonDeadClusterSkipped,
+		fmt.Sprintf("skipped syncing VirtualService %s to cluster %s as it is unreachable", vsName, cluster))
+}
@@ -0,0 +1,100 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordSourceWorkloadEvents(t *testing.T) {
+	sourceDeployments := map[string]*k8sAppsV1.Deployment{
+		"cluster1": {ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns"}},
+	}
+	sourceRollouts := map[string]*argo.Rollout{}
+	serviceEntries := map[string]*networking.ServiceEntry{
+		"stage.foo.global": {Hosts: []string{"stage.foo.global"}},
+	}
+	testCases := []struct {
+		name           string
+		enableEvents   bool
+		capped         bool
+		syncErr        error
+		expectedEvents int
+	}{
+		{
+			name: "Given kubernetes events are disabled, " +
+				"When the exportTo of the SE was capped, " +
+				"Then no event should be emitted",
+			capped:         true,
+			expectedEvents: 0,
+		},
+		{
+			name: "Given kubernetes events are enabled, " +
+				"When the exportTo of the SE was capped, " +
+				"Then an ExportToCapped event should be emitted against the source deployment",
+			enableEvents:   true,
+			capped:         true,
+			expectedEvents: 1,
+		},
+		{
+			name: "Given kubernetes events are enabled, " +
+				"When syncing the SE to the dependent clusters failed, " +
+				"Then a SyncFailed event should be emitted against the source deployment",
+			enableEvents:   true,
+			syncErr:        errors.New("failed to write SE"),
+			expectedEvents: 1,
+		},
+		{
+			name: "Given kubernetes events are enabled, " +
+				"When the SE was synced without truncation, " +
+				"Then no event should be emitted",
+			enableEvents:   true,
+			expectedEvents: 0,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{
+				LabelSet:        &common.LabelSet{},
+				EnableK8sEvents: c.enableEvents,
+			})
+			recorder := record.NewFakeRecorder(10)
+			rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+			rr.AdmiralCache.ExportToCapCache = &sync.Map{}
+			rr.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1", EventRecorder: recorder})
+			if c.capped {
+				rr.AdmiralCache.ExportToCapCache.Store(getExportToCapKey("stage.foo.global", "cluster2"), 60)
+			}
+			recordSourceWorkloadEvents(rr, sourceDeployments, sourceRollouts, serviceEntries,
+				[]string{"cluster2", "cluster1"}, c.syncErr)
+			assert.Len(t, recorder.Events, c.expectedEvents)
+		})
+	}
+}
+
+func TestRecordDeadClusterSkippedEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1", EventRecorder: recorder})
+	reference := virtualServiceReference(&v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs", Namespace: "foo-ns"},
+	})
+
+	recordDeadClusterSkippedEvent(rr, "cluster1", reference, "foo-vs", "cluster2")
+	assert.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, eventReasonDeadClusterSkipped)
+
+	recordDeadClusterSkippedEvent(rr, "unknown-cluster", reference, "foo-vs", "cluster2")
+	assert.Len(t, recorder.Events, 0)
+}
@@ -0,0 +1,127 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+)
+
+// identityFailoverPriorityCache holds the regions, in order of preference, each identity requested
+// its DestinationRules to fail over to through the admiral.io/failover-priority annotation
+type identityFailoverPriorityCache struct {
+	mutex      sync.RWMutex
+	priorities map[string][]string
+}
+
+func newIdentityFailoverPriorityCache() *identityFailoverPriorityCache {
+	return &identityFailoverPriorityCache{
+		priorities: make(map[string][]string),
+	}
+}
+
+// Put records the failover priority list requested by the identity, an empty list means the
+// identity fails over to any region
+func (c *identityFailoverPriorityCache) Put(identity string, failoverPriority []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(failoverPriority) == 0 {
+		delete(c.priorities, identity)
+		return
+	}
+	c.priorities[identity] = failoverPriority
+}
+
+func (c *identityFailoverPriorityCache) Get(identity string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.priorities[identity]
+}
+
+// parseFailoverPriority parses a comma separated list of at least two distinct regions, in order
+// of preference, e.g. us-west-2,us-east-2,eu-west-1
+func parseFailoverPriority(value string) ([]string, error) {
+	regions := make([]string, 0)
+	for _, region := range strings.Split(value, ",") {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			return nil, fmt.Errorf("%s %q has an empty region", common.FailoverPriorityAnnotation, value)
+		}
+		for _, seen := range regions {
+			if seen == region {
+				return nil, fmt.Errorf("region %s is listed more than once in %s", region, common.FailoverPriorityAnnotation)
+			}
+		}
+		regions = append(regions, region)
+	}
+	if len(regions) < 2 {
+		return nil, fmt.Errorf("%s %q should list at least two regions", common.FailoverPriorityAnnotation, value)
+	}
+	return regions, nil
+}
+
+// getFailoverPriorityFromAnnotations returns the failover priority list of the annotations, nil
+// is returned when it is not set or not valid
+func getFailoverPriorityFromAnnotations(annotations map[string]string) []string {
+	value, ok := annotations[common.FailoverPriorityAnnotation]
+	if !ok {
+		return nil
+	}
+	failoverPriority, err := parseFailoverPriority(value)
+	if err != nil {
+		log.Warnf(LogFormat, "Parse", common.FailoverPriorityAnnotation, value, "", "skipped as "+err.Error())
+		return nil
+	}
+	return failoverPriority
+}
+
+// getRequestedFailoverPriority returns the failover priority list requested through the
+// admiral.io/failover-priority annotation by the deployments and rollouts of an identity. The source
+// clusters are visited in order so that the same list is picked when the workloads disagree.
+func getRequestedFailoverPriority(sourceDeployments map[string]*k8sAppsV1.Deployment, sourceRollouts map[string]*argo.Rollout) []string {
+	clusters := make([]string, 0, len(sourceDeployments)+len(sourceRollouts))
+	for cluster := range sourceDeployments {
+		clusters = append(clusters, cluster)
+	}
+	for cluster := range sourceRollouts {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		if deployment := sourceDeployments[cluster]; deployment != nil {
+			if failoverPriority := getFailoverPriorityFromAnnotations(deployment.Spec.Template.Annotations); failoverPriority != nil {
+				return failoverPriority
+			}
+		}
+		if rollout := sourceRollouts[cluster]; rollout != nil {
+			if failoverPriority := getFailoverPriorityFromAnnotations(rollout.Spec.Template.Annotations); failoverPriority != nil {
+				return failoverPriority
+			}
+		}
+	}
+	return nil
+}
+
+// getIdentityFailoverPriority returns the failover priority list of the DestinationRules generated
+// for the identity. The list of its GTP takes precedence over the list requested by its workloads,
+// nil is returned when the identity fails over to any region
+func getIdentityFailoverPriority(cache *AdmiralCache, gtp *v1.GlobalTrafficPolicy, identity string) []string {
+	if !common.EnableFailoverPriority() {
+		return nil
+	}
+	if gtp != nil {
+		if failoverPriority := getFailoverPriorityFromAnnotations(gtp.Annotations); failoverPriority != nil {
+			return failoverPriority
+		}
+	}
+	if cache == nil || cache.IdentityFailoverPriorityCache == nil {
+		return nil
+	}
+	return cache.IdentityFailoverPriorityCache.Get(identity)
+}
@@ -0,0 +1,146 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIdentityFailoverPriorityCache(t *testing.T) {
+	cache := newIdentityFailoverPriorityCache()
+	cache.Put("identity1", []string{"us-west-2", "us-east-2"})
+	assert.Equal(t, []string{"us-west-2", "us-east-2"}, cache.Get("identity1"))
+	assert.Nil(t, cache.Get("identity2"))
+
+	cache.Put("identity1", nil)
+	assert.Nil(t, cache.Get("identity1"))
+}
+
+func TestParseFailoverPriority(t *testing.T) {
+	testCases := []struct {
+		name             string
+		value            string
+		expectedPriority []string
+		expectedErr      bool
+	}{
+		{
+			name: "Given a comma separated list of regions, " +
+				"When parseFailoverPriority is called, " +
+				"Then the trimmed regions should be returned in order",
+			value:            "us-west-2, us-east-2 ,eu-west-1",
+			expectedPriority: []string{"us-west-2", "us-east-2", "eu-west-1"},
+		},
+		{
+			name: "Given a single region, " +
+				"When parseFailoverPriority is called, " +
+				"Then an error should be returned",
+			value:       "us-west-2",
+			expectedErr: true,
+		},
+		{
+			name: "Given a list with an empty region, " +
+				"When parseFailoverPriority is called, " +
+				"Then an error should be returned",
+			value:       "us-west-2,,us-east-2",
+			expectedErr: true,
+		},
+		{
+			name: "Given a list with a region listed twice, " +
+				"When parseFailoverPriority is called, " +
+				"Then an error should be returned",
+			value:       "us-west-2,us-east-2,us-west-2",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			failoverPriority, err := parseFailoverPriority(c.value)
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedPriority, failoverPriority)
+		})
+	}
+}
+
+func TestGetRequestedFailoverPriority(t *testing.T) {
+	deploymentWithPriority := func(failoverPriority string) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.FailoverPriorityAnnotation: failoverPriority}},
+		}}}
+	}
+	rolloutWithPriority := func(failoverPriority string) *argo.Rollout {
+		return &argo.Rollout{Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.FailoverPriorityAnnotation: failoverPriority}},
+		}}}
+	}
+	testCases := []struct {
+		name              string
+		sourceDeployments map[string]*k8sAppsV1.Deployment
+		sourceRollouts    map[string]*argo.Rollout
+		expectedPriority  []string
+	}{
+		{
+			name: "Given workloads without the failover priority annotation, " +
+				"When getRequestedFailoverPriority is called, " +
+				"Then nil should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": {}},
+		},
+		{
+			name: "Given a rollout annotated with a failover priority, " +
+				"When getRequestedFailoverPriority is called, " +
+				"Then its regions should be returned",
+			sourceRollouts:   map[string]*argo.Rollout{"cluster1": rolloutWithPriority("us-west-2,us-east-2,eu-west-1")},
+			expectedPriority: []string{"us-west-2", "us-east-2", "eu-west-1"},
+		},
+		{
+			name: "Given a deployment annotated with an invalid failover priority, " +
+				"When getRequestedFailoverPriority is called, " +
+				"Then nil should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": deploymentWithPriority("us-west-2")},
+		},
+		{
+			name: "Given workloads in different clusters annotated with different failover priorities, " +
+				"When getRequestedFailoverPriority is called, " +
+				"Then the list of the first cluster in order should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{
+				"cluster2": deploymentWithPriority("us-east-2,us-west-2"),
+				"cluster1": deploymentWithPriority("us-west-2,us-east-2"),
+			},
+			expectedPriority: []string{"us-west-2", "us-east-2"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedPriority, getRequestedFailoverPriority(c.sourceDeployments, c.sourceRollouts))
+		})
+	}
+}
+
+func TestGetIdentityFailoverPriority(t *testing.T) {
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityFailoverPriorityCache.Put("foo", []string{"us-west-2", "us-east-2"})
+	gtp := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{
+		Annotations: map[string]string{common.FailoverPriorityAnnotation: "us-east-2,us-west-2,eu-west-1"},
+	}}
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	assert.Nil(t, getIdentityFailoverPriority(rr.AdmiralCache, gtp, "foo"))
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EnableFailoverPriority: true})
+	assert.Equal(t, []string{"us-east-2", "us-west-2", "eu-west-1"}, getIdentityFailoverPriority(rr.AdmiralCache, gtp, "foo"))
+	assert.Equal(t, []string{"us-west-2", "us-east-2"}, getIdentityFailoverPriority(rr.AdmiralCache, nil, "foo"))
+	assert.Equal(t, []string{"us-west-2", "us-east-2"}, getIdentityFailoverPriority(rr.AdmiralCache, &v1.GlobalTrafficPolicy{}, "foo"))
+	assert.Nil(t, getIdentityFailoverPriority(rr.AdmiralCache, nil, "bar"))
+}
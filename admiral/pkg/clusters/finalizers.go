@@ -0,0 +1,112 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// hasCleanupFinalizer checks if the source resource is held by the cleanup finalizer of admiral
+func hasCleanupFinalizer(obj metaV1.Object) bool {
+	return slices.Contains(obj.GetFinalizers(), common.AdmiralCleanupFinalizer)
+}
+
+// isCleanupPending checks if the source resource is being deleted and waits for admiral to remove what
+// was synced from it. The cleanup is pending even when the finalizers were disabled since they were added
+func isCleanupPending(obj metaV1.Object) bool {
+	return obj.GetDeletionTimestamp() != nil && hasCleanupFinalizer(obj)
+}
+
+// withCleanupFinalizer returns the finalizers with the cleanup finalizer added or removed
+func withCleanupFinalizer(finalizers []string, add bool) []string {
+	updated := slices.DeleteFunc(slices.Clone(finalizers), func(finalizer string) bool {
+		return finalizer == common.AdmiralCleanupFinalizer
+	})
+	if add {
+		updated = append(updated, common.AdmiralCleanupFinalizer)
+	}
+	return updated
+}
+
+// setVirtualServiceCleanupFinalizer adds or removes the cleanup finalizer of the source VirtualService
+func setVirtualServiceCleanupFinalizer(ctx context.Context, rc *RemoteController, vs *v1alpha3.VirtualService, add bool) error {
+	if rc == nil || rc.VirtualServiceController == nil || rc.VirtualServiceController.IstioClient == nil {
+		return fmt.Errorf("VirtualService controller not initialized for cluster")
+	}
+	client := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(vs.Namespace)
+	current, err := client.Get(ctx, vs.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if hasCleanupFinalizer(current) == add || (add && current.DeletionTimestamp != nil) {
+		return nil
+	}
+	current.Finalizers = withCleanupFinalizer(current.Finalizers, add)
+	_, err = client.Update(ctx, current, metaV1.UpdateOptions{})
+	return err
+}
+
+// setGlobalTrafficPolicyCleanupFinalizer adds or removes the cleanup finalizer of the GlobalTrafficPolicy
+func setGlobalTrafficPolicyCleanupFinalizer(ctx context.Context, rc *RemoteController, gtp *v1.GlobalTrafficPolicy, add bool) error {
+	if rc == nil || rc.GlobalTraffic == nil || rc.GlobalTraffic.CrdClient == nil {
+		return fmt.Errorf("globaltrafficpolicy controller not initialized for cluster")
+	}
+	client := rc.GlobalTraffic.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies(gtp.Namespace)
+	current, err := client.Get(ctx, gtp.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if hasCleanupFinalizer(current) == add || (add && current.DeletionTimestamp != nil) {
+		return nil
+	}
+	current.Finalizers = withCleanupFinalizer(current.Finalizers, add)
+	_, err = client.Update(ctx, current, metaV1.UpdateOptions{})
+	return err
+}
+
+// areVirtualServiceCopiesRemoved checks that no cluster holds a copy of the source VirtualService any
+// longer, in the sync namespace of the cluster or in the sync namespace requested by its identity. A
+// cluster which cannot be checked, e.g. as it is unreachable, keeps the removal unconfirmed
+func areVirtualServiceCopiesRemoved(ctx context.Context, rr *RemoteRegistry, vs *v1alpha3.VirtualService) bool {
+	vSName := getVirtualServiceSyncName(vs)
+	source := vs.Namespace + "/" + vs.Name
+	for _, cluster := range rr.GetClusterIds() {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil || rc.VirtualServiceController == nil || rc.VirtualServiceController.IstioClient == nil {
+			continue
+		}
+		for _, syncNamespace := range getIdentitySyncNamespacesForCluster(rr.AdmiralCache, getVirtualServiceIdentity(vs), cluster) {
+			existing, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, vSName, metaV1.GetOptions{})
+			if k8sErrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				log.Infof(LogFormat, "Cleanup", common.VirtualServiceResourceType, vSName, cluster, "removal of the copy could not be confirmed: "+err.Error())
+				return false
+			}
+			// the source VirtualService may live in the sync namespace under the name of its copies, which
+			// unlike the source are annotated with it
+			if existing.Namespace == vs.Namespace && getVirtualServiceSource(existing) == "" {
+				continue
+			}
+			if !isVirtualServiceNameCollision(existing, source) {
+				log.Infof(LogFormat, "Cleanup", common.VirtualServiceResourceType, vSName, cluster, "copy is not removed yet")
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	admiralFake "github.com/istio-ecosystem/admiral/admiral/pkg/client/clientset/versioned/fake"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func setupForFinalizerTests() {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:               &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SyncNamespace:          "ns",
+		EnableSourceFinalizers: true,
+	})
+}
+
+func TestWithCleanupFinalizer(t *testing.T) {
+	finalizers := []string{"foo"}
+	assert.Equal(t, []string{"foo", common.AdmiralCleanupFinalizer}, withCleanupFinalizer(finalizers, true))
+	assert.Equal(t, []string{"foo"}, withCleanupFinalizer([]string{common.AdmiralCleanupFinalizer, "foo"}, false))
+	assert.Equal(t, []string{"foo"}, finalizers)
+	assert.Equal(t, []string{common.AdmiralCleanupFinalizer}, withCleanupFinalizer([]string{common.AdmiralCleanupFinalizer}, true))
+}
+
+func TestSetVirtualServiceCleanupFinalizer(t *testing.T) {
+	setupForFinalizerTests()
+	ctx := context.Background()
+	vs := &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs", Namespace: "foo-ns"}}
+	istioClient := istioFake.NewSimpleClientset(vs)
+	rc := &RemoteController{VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient}}
+
+	assert.Nil(t, setVirtualServiceCleanupFinalizer(ctx, rc, vs, true))
+	updated, err := istioClient.NetworkingV1alpha3().VirtualServices("foo-ns").Get(ctx, "foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{common.AdmiralCleanupFinalizer}, updated.Finalizers)
+
+	// the finalizer is not added again to a VirtualService being deleted
+	assert.Nil(t, setVirtualServiceCleanupFinalizer(ctx, rc, vs, false))
+	now := metaV1.Now()
+	updated.Finalizers = nil
+	updated.DeletionTimestamp = &now
+	_, err = istioClient.NetworkingV1alpha3().VirtualServices("foo-ns").Update(ctx, updated, metaV1.UpdateOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, setVirtualServiceCleanupFinalizer(ctx, rc, vs, true))
+	updated, err = istioClient.NetworkingV1alpha3().VirtualServices("foo-ns").Get(ctx, "foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, updated.Finalizers)
+
+	// a VirtualService which is already gone is ignored
+	assert.Nil(t, setVirtualServiceCleanupFinalizer(ctx, rc, &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "bar-vs", Namespace: "foo-ns"}}, false))
+	assert.NotNil(t, setVirtualServiceCleanupFinalizer(ctx, nil, vs, true))
+}
+
+func TestSetGlobalTrafficPolicyCleanupFinalizer(t *testing.T) {
+	setupForFinalizerTests()
+	ctx := context.Background()
+	gtp := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "foo-gtp", Namespace: "foo-ns"}}
+	crdClient := admiralFake.NewSimpleClientset(gtp)
+	rc := &RemoteController{GlobalTraffic: &admiral.GlobalTrafficController{CrdClient: crdClient}}
+
+	assert.Nil(t, setGlobalTrafficPolicyCleanupFinalizer(ctx, rc, gtp, true))
+	updated, err := crdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(ctx, "foo-gtp", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, hasCleanupFinalizer(updated))
+
+	assert.Nil(t, setGlobalTrafficPolicyCleanupFinalizer(ctx, rc, gtp, false))
+	updated, err = crdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(ctx, "foo-gtp", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.False(t, hasCleanupFinalizer(updated))
+}
+
+func TestCleanupFinalizerFailureDoesNotFailSync(t *testing.T) {
+	setupForFinalizerTests()
+	ctx := context.Background()
+	forbidden := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sErrors.NewForbidden(schema.GroupResource{}, "", nil)
+	}
+
+	vs := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs", Namespace: "foo-ns"},
+		Spec:       networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+	istioClient := istioFake.NewSimpleClientset(vs)
+	istioClient.PrependReactor("update", "virtualservices", forbidden)
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	})
+	vh, err := NewVirtualServiceHandler(rr, "cluster1")
+	assert.Nil(t, err)
+	var synced bool
+	vh.syncVirtualServiceForAllClusters = func(context.Context, []string, *v1alpha3.VirtualService, common.Event, *RemoteRegistry, string, string, string) error {
+		synced = true
+		return nil
+	}
+	assert.Nil(t, vh.Added(ctx, vs))
+	assert.True(t, synced, "the VirtualService should be synced without the finalizer")
+
+	gtp := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "foo-gtp", Namespace: "foo-ns", Labels: map[string]string{"identity": "foo"}}}
+	crdClient := admiralFake.NewSimpleClientset(gtp)
+	crdClient.PrependReactor("update", "globaltrafficpolicies", forbidden)
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:     "cluster1",
+		GlobalTraffic: &admiral.GlobalTrafficController{CrdClient: crdClient},
+	})
+	gh := &GlobalTrafficHandler{RemoteRegistry: rr, ClusterID: "cluster1"}
+	gh.addCleanupFinalizer(ctx, gtp)
+	updated, err := crdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(ctx, "foo-gtp", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.False(t, hasCleanupFinalizer(updated))
+}
+
+func TestCleanUpVirtualService(t *testing.T) {
+	setupForFinalizerTests()
+	ctx := context.Background()
+	now := metaV1.Now()
+	source := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:              "foo-vs",
+			Namespace:         "foo-ns",
+			Finalizers:        []string{common.AdmiralCleanupFinalizer},
+			DeletionTimestamp: &now,
+		},
+		Spec: networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+	sourceClient := istioFake.NewSimpleClientset(source)
+	copyOfSource := &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{
+		Name:        getVirtualServiceSyncName(source),
+		Namespace:   "ns",
+		Annotations: map[string]string{common.AdmiralSourceVSAnnotation: "foo-ns/foo-vs"},
+	}}
+	remoteClient := istioFake.NewSimpleClientset(copyOfSource)
+
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: sourceClient},
+	})
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:                "cluster2",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: remoteClient},
+	})
+	vh, err := NewVirtualServiceHandler(rr, "cluster1")
+	assert.Nil(t, err)
+	// the copy is left behind, as if its cluster was unreachable
+	vh.syncVirtualServiceForAllClusters = func(context.Context, []string, *v1alpha3.VirtualService, common.Event, *RemoteRegistry, string, string, string) error {
+		return nil
+	}
+
+	// the finalizer is kept as long as a copy is left
+	assert.False(t, areVirtualServiceCopiesRemoved(ctx, rr, source))
+	assert.NotNil(t, vh.Updated(ctx, source))
+	held, err := sourceClient.NetworkingV1alpha3().VirtualServices("foo-ns").Get(ctx, "foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, hasCleanupFinalizer(held))
+
+	// a VirtualService of the same name synced from another source does not hold the finalizer
+	copyOfSource.Annotations[common.AdmiralSourceVSAnnotation] = "bar-ns/foo-vs"
+	_, err = remoteClient.NetworkingV1alpha3().VirtualServices("ns").Update(ctx, copyOfSource, metaV1.UpdateOptions{})
+	assert.Nil(t, err)
+	assert.True(t, areVirtualServiceCopiesRemoved(ctx, rr, source))
+
+	assert.Nil(t, remoteClient.NetworkingV1alpha3().VirtualServices("ns").Delete(ctx, copyOfSource.Name, metaV1.DeleteOptions{}))
+	assert.Nil(t, vh.Updated(ctx, source))
+	released, err := sourceClient.NetworkingV1alpha3().VirtualServices("foo-ns").Get(ctx, "foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.False(t, hasCleanupFinalizer(released))
+}
+
+func TestAreVirtualServiceCopiesRemovedFromSyncNamespaces(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{AdmiralCRDIdentityLabel: "identity"},
+		SyncNamespace:                 "ns",
+		ClusterSyncNamespaces:         map[string]string{"cluster2": "cluster2-ns"},
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	})
+	ctx := context.Background()
+	source := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "foo-vs",
+			Namespace: "foo-ns",
+			Labels:    map[string]string{"identity": "foo"},
+		},
+		Spec: networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+	copyOfSource := func(namespace string) *v1alpha3.VirtualService {
+		return &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{
+			Name:        getVirtualServiceSyncName(source),
+			Namespace:   namespace,
+			Annotations: map[string]string{common.AdmiralSourceVSAnnotation: "foo-ns/foo-vs"},
+		}}
+	}
+	remoteClient := istioFake.NewSimpleClientset(copyOfSource("cluster2-ns"), copyOfSource("team-sync"), copyOfSource("ns"))
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.AdmiralCache.IdentitySyncNamespaceCache.Put("foo", "team-sync")
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:                "cluster2",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: remoteClient},
+	})
+
+	// the copy in the sync namespace of the cluster is checked
+	assert.False(t, areVirtualServiceCopiesRemoved(ctx, rr, source))
+	assert.Nil(t, remoteClient.NetworkingV1alpha3().VirtualServices("cluster2-ns").Delete(ctx, copyOfSource("").Name, metaV1.DeleteOptions{}))
+	// as is the copy in the sync namespace requested by the identity
+	assert.False(t, areVirtualServiceCopiesRemoved(ctx, rr, source))
+	assert.Nil(t, remoteClient.NetworkingV1alpha3().VirtualServices("team-sync").Delete(ctx, copyOfSource("").Name, metaV1.DeleteOptions{}))
+	// the global sync namespace is not used by the cluster
+	assert.True(t, areVirtualServiceCopiesRemoved(ctx, rr, source))
+}
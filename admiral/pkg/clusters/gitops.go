@@ -0,0 +1,152 @@
+package clusters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// gitOpsRenderedValue is the value of the rendered-by annotation of the resources rendered to the GitOps repository
+const gitOpsRenderedValue = "gitops"
+
+// gitOpsRepoLock keeps the rendering of the resources from interleaving with the commits of the repository
+var gitOpsRepoLock sync.Mutex
+
+// renderGitOpsResource writes the resource admiral would have applied to the cluster into the directory of the
+// cluster in the GitOps repository. The resource is annotated as rendered, so admiral ignores it once it is synced
+// back to the cluster, and the transaction id is left out so the file only changes along with the resource
+func renderGitOpsResource(ctxLogger *log.Entry, cluster string, kind common.ResourceType, namespace string, obj runtime.Object) error {
+	rendered := obj.DeepCopyObject()
+	meta, ok := rendered.(metaV1.Object)
+	if !ok {
+		return fmt.Errorf("%s cannot be rendered", kind)
+	}
+	annotations := meta.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	delete(annotations, common.AdmiralTxIdAnnotation)
+	annotations[common.AdmiralRenderedByAnnotation] = gitOpsRenderedValue
+	meta.SetAnnotations(annotations)
+	meta.SetNamespace(namespace)
+	meta.SetResourceVersion("")
+	meta.SetUID("")
+	meta.SetGeneration(0)
+	meta.SetCreationTimestamp(metaV1.Time{})
+	meta.SetManagedFields(nil)
+	rendered.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: string(kind)})
+	content, err := yaml.Marshal(rendered)
+	if err != nil {
+		return err
+	}
+	path := getGitOpsResourcePath(cluster, kind, namespace, meta.GetName())
+	gitOpsRepoLock.Lock()
+	defer gitOpsRepoLock.Unlock()
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+	ctxLogger.Infof(LogFormat, "Render", kind, meta.GetName(), cluster, "rendered to "+path)
+	return nil
+}
+
+// removeGitOpsResource removes the resource admiral would have deleted from the cluster from the GitOps repository
+func removeGitOpsResource(cluster string, kind common.ResourceType, namespace, name string) error {
+	path := getGitOpsResourcePath(cluster, kind, namespace, name)
+	gitOpsRepoLock.Lock()
+	defer gitOpsRepoLock.Unlock()
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		log.Infof(LogFormat, "Render", kind, name, cluster, "Either the rendered resource was already removed, or it never existed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof(LogFormat, "Render", kind, name, cluster, "removed "+path)
+	return nil
+}
+
+// getGitOpsResourcePath returns the file of the resource in the GitOps repository, which holds one directory per cluster
+func getGitOpsResourcePath(cluster string, kind common.ResourceType, namespace, name string) string {
+	return filepath.Join(common.GetGitOpsRepoPath(), cluster, namespace, strings.ToLower(string(kind))+"-"+name+".yaml")
+}
+
+// isGitOpsRendered checks if the resource was rendered to the GitOps repository and synced back to the cluster
+func isGitOpsRendered(annotations map[string]string) bool {
+	return annotations[common.AdmiralRenderedByAnnotation] == gitOpsRenderedValue
+}
+
+// startGitOpsCommitter periodically commits the changes rendered to the GitOps repository, and pushes
+// them to the branch they are reviewed or synced from when one is set
+func startGitOpsCommitter(ctx context.Context) {
+	if !common.IsGitOpsOutputMode() {
+		return
+	}
+	ticker := time.NewTicker(common.GetGitOpsCommitInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := commitGitOpsChanges(ctx); err != nil {
+				log.Errorf(LogErrFormat, "Commit", "GitOpsRepository", common.GetGitOpsRepoPath(), "", err)
+			}
+		}
+	}
+}
+
+// commitGitOpsChanges commits the changes rendered since the last commit, if any, and pushes them
+func commitGitOpsChanges(ctx context.Context) error {
+	gitOpsRepoLock.Lock()
+	defer gitOpsRepoLock.Unlock()
+	if _, err := runGit(ctx, "add", "--all"); err != nil {
+		return err
+	}
+	status, err := runGit(ctx, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+	changes := len(strings.Split(strings.TrimSpace(status), "\n"))
+	message := fmt.Sprintf("Render %d admiral resource changes", changes)
+	if _, err = runGit(ctx, "-c", "user.name=admiral", "-c", "user.email=admiral@localhost", "commit", "--quiet", "-m", message); err != nil {
+		return err
+	}
+	if branch := common.GetGitOpsPushBranch(); branch != "" {
+		if _, err = runGit(ctx, "push", "--quiet", "origin", "HEAD:refs/heads/"+branch); err != nil {
+			return err
+		}
+	}
+	log.Infof(LogFormat, "Commit", "GitOpsRepository", common.GetGitOpsRepoPath(), "", message)
+	return nil
+}
+
+// runGit runs the git command in the GitOps repository and returns its output
+func runGit(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", common.GetGitOpsRepoPath()}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
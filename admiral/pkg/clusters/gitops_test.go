@@ -0,0 +1,102 @@
+package clusters
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGitOpsOutputMode(t *testing.T) {
+	repo := t.TempDir()
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", GitOpsRepoPath: repo})
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+	ctx := context.Background()
+	ctxLogger := log.WithField("op", "test")
+	istioClient := istioFake.NewSimpleClientset()
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:            "foo.global-se",
+			ResourceVersion: "42",
+			Annotations:     map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+		},
+		Spec: networkingV1Alpha3.ServiceEntry{
+			Hosts:     []string{"foo.global"},
+			Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "internal-lb.com"}},
+		},
+	}
+
+	// the ServiceEntry is rendered to the directory of the cluster instead of being created
+	assert.Nil(t, addUpdateServiceEntry(ctxLogger, ctx, se, nil, "ns", rc))
+	seList, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, seList.Items)
+	path := filepath.Join(repo, "cluster1", "ns", "serviceentry-foo.global-se.yaml")
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	var rendered v1alpha3.ServiceEntry
+	assert.Nil(t, yaml.Unmarshal(content, &rendered))
+	assert.Equal(t, "networking.istio.io/v1alpha3", rendered.APIVersion)
+	assert.Equal(t, "ServiceEntry", rendered.Kind)
+	assert.Equal(t, "ns", rendered.Namespace)
+	assert.Empty(t, rendered.ResourceVersion)
+	assert.Equal(t, gitOpsRenderedValue, rendered.Annotations[common.AdmiralRenderedByAnnotation])
+	assert.NotContains(t, rendered.Annotations, common.AdmiralTxIdAnnotation)
+	assert.Equal(t, "internal-lb.com", rendered.Spec.Endpoints[0].Address)
+
+	// the rendered resources synced back to the cluster are ignored
+	assert.True(t, IgnoreIstioResource(nil, nil, rendered.Annotations, "other-ns"))
+
+	// the ServiceEntry is removed from the repository instead of being deleted
+	assert.Nil(t, deleteServiceEntry(ctx, se, "ns", rc))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, deleteServiceEntry(ctx, se, "ns", rc))
+}
+
+func TestCommitGitOpsChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", GitOpsRepoPath: repo})
+	ctx := context.Background()
+	_, err := runGit(ctx, "init", "--quiet")
+	assert.Nil(t, err)
+
+	// nothing is committed without changes
+	assert.Nil(t, commitGitOpsChanges(ctx))
+	_, err = runGit(ctx, "rev-parse", "HEAD")
+	assert.NotNil(t, err)
+
+	dr := &v1alpha3.DestinationRule{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr"},
+		Spec:       networkingV1Alpha3.DestinationRule{Host: "foo.global"},
+	}
+	assert.Nil(t, renderGitOpsResource(log.WithField("op", "test"), "cluster1", common.DestinationRuleResourceType, "ns", dr))
+	assert.Nil(t, commitGitOpsChanges(ctx))
+	files, err := runGit(ctx, "ls-files")
+	assert.Nil(t, err)
+	assert.Equal(t, "cluster1/ns/destinationrule-foo.global-default-dr.yaml\n", files)
+	status, err := runGit(ctx, "status", "--porcelain")
+	assert.Nil(t, err)
+	assert.Empty(t, status)
+}
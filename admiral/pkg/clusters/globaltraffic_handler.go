@@ -68,15 +68,20 @@ func (gtp *GlobalTrafficHandler) Added(ctx context.Context, obj *v1.GlobalTraffi
 	if err != nil {
 		return fmt.Errorf(LogErrFormat, "Added", "globaltrafficpolicy", obj.Name, gtp.ClusterID, err.Error())
 	}
+	gtp.addCleanupFinalizer(ctx, obj)
 	return nil
 }
 
 func (gtp *GlobalTrafficHandler) Updated(ctx context.Context, obj *v1.GlobalTrafficPolicy) error {
 	log.Infof(LogFormat, "Updated", "globaltrafficpolicy", obj.Name, gtp.ClusterID, fmt.Sprintf("received gtp: %v", obj))
+	if isCleanupPending(obj) {
+		return gtp.cleanUp(ctx, obj)
+	}
 	err := HandleEventForGlobalTrafficPolicy(ctx, admiral.Update, obj, gtp.RemoteRegistry, gtp.ClusterID, modifyServiceEntryForNewServiceOrPod)
 	if err != nil {
 		return fmt.Errorf(LogErrFormat, "Updated", "globaltrafficpolicy", obj.Name, gtp.ClusterID, err.Error())
 	}
+	gtp.addCleanupFinalizer(ctx, obj)
 	return nil
 }
 
@@ -88,14 +93,47 @@ func (gtp *GlobalTrafficHandler) Deleted(ctx context.Context, obj *v1.GlobalTraf
 	return nil
 }
 
+// addCleanupFinalizer holds the deletion of the GlobalTrafficPolicy until the ServiceEntries it was applied
+// to are updated, when the finalizers are enabled. A failure is only logged, the GlobalTrafficPolicy is
+// still applied without the finalizer
+func (gtp *GlobalTrafficHandler) addCleanupFinalizer(ctx context.Context, obj *v1.GlobalTrafficPolicy) {
+	if !common.EnableSourceFinalizers() || hasCleanupFinalizer(obj) {
+		return
+	}
+	err := setGlobalTrafficPolicyCleanupFinalizer(ctx, gtp.RemoteRegistry.GetRemoteController(gtp.ClusterID), obj, true)
+	if err != nil {
+		log.Warnf(LogErrFormat, "Update", "globaltrafficpolicy", obj.Name, gtp.ClusterID, "failed to add the cleanup finalizer: "+err.Error())
+	}
+}
+
+// cleanUp updates the ServiceEntries the GlobalTrafficPolicy being deleted was applied to, and releases
+// its cleanup finalizer once they are updated
+func (gtp *GlobalTrafficHandler) cleanUp(ctx context.Context, obj *v1.GlobalTrafficPolicy) error {
+	err := HandleEventForGlobalTrafficPolicy(ctx, admiral.Delete, obj, gtp.RemoteRegistry, gtp.ClusterID, modifyServiceEntryForNewServiceOrPod)
+	if err != nil {
+		return fmt.Errorf(LogErrFormat, "Cleanup", "globaltrafficpolicy", obj.Name, gtp.ClusterID, err.Error())
+	}
+	err = setGlobalTrafficPolicyCleanupFinalizer(ctx, gtp.RemoteRegistry.GetRemoteController(gtp.ClusterID), obj, false)
+	if err != nil {
+		return fmt.Errorf(LogErrFormat, "Cleanup", "globaltrafficpolicy", obj.Name, gtp.ClusterID, "failed to remove the cleanup finalizer: "+err.Error())
+	}
+	log.Infof(LogFormat, "Cleanup", "globaltrafficpolicy", obj.Name, gtp.ClusterID, "released the cleanup finalizer")
+	return nil
+}
+
 // HandleEventForGlobalTrafficPolicy processes all the events related to GTPs
 func HandleEventForGlobalTrafficPolicy(ctx context.Context, event admiral.EventType, gtp *v1.GlobalTrafficPolicy,
 	remoteRegistry *RemoteRegistry, clusterName string, modifySE ModifySEFunc) error {
 	globalIdentifier := common.GetGtpIdentity(gtp)
-	if len(globalIdentifier) == 0 {
+	if len(globalIdentifier) == 0 && getTrafficPolicyScope(gtp) == "" {
 		return fmt.Errorf(LogFormat, "Event", "globaltrafficpolicy", gtp.Name, clusterName, "Skipped as '"+common.GetWorkloadIdentifier()+" was not found', namespace="+gtp.Namespace)
 	}
 
+	if isNamespaceFiltered(remoteRegistry, clusterName, gtp.Namespace) {
+		log.Infof(LogFormat, event, "globaltrafficpolicy", gtp.Name, clusterName, "skipped as namespace="+gtp.Namespace+" is filtered")
+		return nil
+	}
+
 	env := common.GetGtpEnv(gtp)
 
 	// For now we're going to force all the events to update only in order to prevent
@@ -109,6 +147,9 @@ func HandleEventForGlobalTrafficPolicy(ctx context.Context, event admiral.EventT
 
 	_ = callRegistryForGlobalTrafficPolicy(ctx, event, remoteRegistry, clusterName, gtp)
 
+	if getTrafficPolicyScope(gtp) != "" {
+		return handleDefaultTrafficPolicyEvent(ctx, gtp, remoteRegistry, clusterName, modifySE)
+	}
 	_, err := modifySE(ctx, admiral.Update, env, globalIdentifier, remoteRegistry)
 	return err
 }
@@ -118,11 +159,11 @@ func callRegistryForGlobalTrafficPolicy(ctx context.Context, event admiral.Event
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutCustomData(clusterName, gtp.Namespace, gtp.Name, "globaltrafficpolicy", ctx.Value("txId").(string), gtp)
+			err = registry.RegistryClient.PutCustomData(clusterName, gtp.Namespace, gtp.Name, "globaltrafficpolicy", common.GetTxId(ctx), gtp)
 		case admiral.Update:
-			err = registry.RegistryClient.PutCustomData(clusterName, gtp.Namespace, gtp.Name, "globaltrafficpolicy", ctx.Value("txId").(string), gtp)
+			err = registry.RegistryClient.PutCustomData(clusterName, gtp.Namespace, gtp.Name, "globaltrafficpolicy", common.GetTxId(ctx), gtp)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteCustomData(clusterName, gtp.Namespace, gtp.Name, "globaltrafficpolicy", ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteCustomData(clusterName, gtp.Namespace, gtp.Name, "globaltrafficpolicy", common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, "globaltrafficpolicy", gtp.Name, clusterName, "failed to "+string(event)+" globaltrafficpolicy with err: "+err.Error())
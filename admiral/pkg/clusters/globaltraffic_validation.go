@@ -0,0 +1,129 @@
+package clusters
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+const gtpTotalWeight = 100
+
+// ValidateGlobalTrafficPolicy checks the GlobalTrafficPolicy against the identities admiral
+// has discovered and returns the reasons it should be rejected. The env and target regions
+// are only checked when the identity is already known, so a GTP can be applied ahead of
+// the first deployment of an identity. Only the weights of the default traffic policies of
+// the namespaces and of the org are checked, along with their scope.
+func ValidateGlobalTrafficPolicy(rr *RemoteRegistry, gtp *v1.GlobalTrafficPolicy) []string {
+	var violations []string
+	identity := common.GetGtpIdentity(gtp)
+	if getTrafficPolicyScope(gtp) != "" {
+		if err := validateTrafficPolicyScope(gtp); err != nil {
+			violations = append(violations, err.Error())
+		}
+		// a default traffic policy does not target an identity
+		identity = ""
+	} else if identity == "" {
+		return append(violations, fmt.Sprintf("%s label is missing", common.GetAdmiralCRDIdentityLabel()))
+	}
+	if _, err := getGtpWindow(gtp); err != nil {
+		violations = append(violations, fmt.Sprintf("activation window is not valid: %v", err))
+	}
+	var failoverPriority []string
+	if value, ok := gtp.Annotations[common.FailoverPriorityAnnotation]; ok {
+		var err error
+		if failoverPriority, err = parseFailoverPriority(value); err != nil {
+			violations = append(violations, err.Error())
+		}
+	}
+	for _, policy := range gtp.Spec.Policy {
+		if policy.LbType != model.TrafficPolicy_FAILOVER || len(policy.Target) == 0 {
+			continue
+		}
+		totalWeight := int32(0)
+		seenRegions := make(map[string]bool)
+		for _, target := range policy.Target {
+			if seenRegions[target.Region] {
+				violations = append(violations, fmt.Sprintf("region %s is listed more than once in policy with dnsPrefix %s",
+					target.Region, policy.DnsPrefix))
+			}
+			seenRegions[target.Region] = true
+			if target.Weight < 0 || target.Weight > gtpTotalWeight {
+				violations = append(violations, fmt.Sprintf("weight %d of region %s in policy with dnsPrefix %s is not between 0 and %d",
+					target.Weight, target.Region, policy.DnsPrefix, gtpTotalWeight))
+			}
+			totalWeight += target.Weight
+		}
+		if totalWeight != gtpTotalWeight {
+			violations = append(violations, fmt.Sprintf("weights of policy with dnsPrefix %s add up to %d instead of %d",
+				policy.DnsPrefix, totalWeight, gtpTotalWeight))
+		}
+	}
+
+	if identity == "" || rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityClusterCache == nil {
+		return violations
+	}
+	identityClusters := rr.AdmiralCache.IdentityClusterCache.Get(identity)
+	if identityClusters == nil || identityClusters.Len() == 0 {
+		return violations
+	}
+	envs, regions := getIdentityEnvsAndRegions(rr, identity, identityClusters.GetKeys())
+	env := common.GetGtpEnv(gtp)
+	if len(envs) > 0 && !slices.Contains(envs, env) {
+		violations = append(violations, fmt.Sprintf("env %s is not one of the envs %v identity %s is deployed in", env, envs, identity))
+	}
+	if len(regions) == 0 {
+		return violations
+	}
+	for _, policy := range gtp.Spec.Policy {
+		for _, target := range policy.Target {
+			if !slices.Contains(regions, target.Region) {
+				violations = append(violations, fmt.Sprintf("region %s in policy with dnsPrefix %s is not one of the regions %v identity %s is deployed in",
+					target.Region, policy.DnsPrefix, regions, identity))
+			}
+		}
+	}
+	for _, region := range failoverPriority {
+		if !slices.Contains(regions, region) {
+			violations = append(violations, fmt.Sprintf("region %s in %s is not one of the regions %v identity %s is deployed in",
+				region, common.FailoverPriorityAnnotation, regions, identity))
+		}
+	}
+	return violations
+}
+
+// getIdentityEnvsAndRegions returns the sorted envs and regions the identity is deployed in
+// across the clusters it was discovered in
+func getIdentityEnvsAndRegions(rr *RemoteRegistry, identity string, clusters []string) ([]string, []string) {
+	var envs, regions []string
+	for _, cluster := range clusters {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			continue
+		}
+		if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+			for env := range rc.DeploymentController.Cache.GetByIdentity(identity) {
+				if !slices.Contains(envs, env) {
+					envs = append(envs, env)
+				}
+			}
+		}
+		if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+			for env := range rc.RolloutController.Cache.GetByIdentity(identity) {
+				if !slices.Contains(envs, env) {
+					envs = append(envs, env)
+				}
+			}
+		}
+		region, err := getClusterRegion(rr, cluster, rc)
+		if err == nil && region != "" && !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(envs)
+	sort.Strings(regions)
+	return envs, regions
+}
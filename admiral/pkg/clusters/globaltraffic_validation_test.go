@@ -0,0 +1,164 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateGlobalTrafficPolicy(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey:     "identity",
+			AdmiralCRDIdentityLabel: "identity",
+			EnvKey:                  "admiral.io/env",
+		},
+	})
+	deploymentCache := admiral.NewDeploymentCache()
+	deploymentCache.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns"},
+		Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{
+			Annotations: map[string]string{"admiral.io/env": "stage"},
+			Labels:      map[string]string{"identity": "foo"},
+		}}},
+	})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: deploymentCache},
+		NodeController:       &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}},
+	})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+
+	newGTP := func(identity, env string, policies ...*model.TrafficPolicy) *v1.GlobalTrafficPolicy {
+		return &v1.GlobalTrafficPolicy{
+			ObjectMeta: metaV1.ObjectMeta{
+				Labels:      map[string]string{"identity": identity},
+				Annotations: map[string]string{"admiral.io/env": env},
+			},
+			Spec: model.GlobalTrafficPolicy{Policy: policies},
+		}
+	}
+	failover := func(targets ...*model.TrafficGroup) *model.TrafficPolicy {
+		return &model.TrafficPolicy{LbType: model.TrafficPolicy_FAILOVER, DnsPrefix: "default", Target: targets}
+	}
+
+	testCases := []struct {
+		name               string
+		gtp                *v1.GlobalTrafficPolicy
+		expectedViolations int
+	}{
+		{
+			name: "Given a GTP without an identity, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp:                newGTP("", "stage"),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a valid GTP for a known identity, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be accepted",
+			gtp:                newGTP("foo", "stage", failover(&model.TrafficGroup{Region: "us-west-2", Weight: 100})),
+			expectedViolations: 0,
+		},
+		{
+			name: "Given a GTP with an env the identity is not deployed in, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp:                newGTP("foo", "prod", failover(&model.TrafficGroup{Region: "us-west-2", Weight: 100})),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a failover GTP with weights not adding up to 100, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp:                newGTP("foo", "stage", failover(&model.TrafficGroup{Region: "us-west-2", Weight: 90})),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a GTP targeting a region the identity is not deployed in, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp: newGTP("foo", "stage", failover(
+				&model.TrafficGroup{Region: "us-west-2", Weight: 50},
+				&model.TrafficGroup{Region: "us-east-2", Weight: 50})),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a GTP listing a region more than once, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp: newGTP("foo", "stage", failover(
+				&model.TrafficGroup{Region: "us-west-2", Weight: 50},
+				&model.TrafficGroup{Region: "us-west-2", Weight: 50})),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a GTP with an activation schedule but no duration, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp: func() *v1.GlobalTrafficPolicy {
+				gtp := newGTP("foo", "stage", failover(&model.TrafficGroup{Region: "us-west-2", Weight: 100}))
+				gtp.Annotations[common.GtpActiveScheduleAnnotation] = "0 8 * * *"
+				return gtp
+			}(),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a GTP with a failover priority listing a single region, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp: func() *v1.GlobalTrafficPolicy {
+				gtp := newGTP("foo", "stage", failover(&model.TrafficGroup{Region: "us-west-2", Weight: 100}))
+				gtp.Annotations[common.FailoverPriorityAnnotation] = "us-west-2"
+				return gtp
+			}(),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a GTP with a failover priority listing a region the identity is not deployed in, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be rejected",
+			gtp: func() *v1.GlobalTrafficPolicy {
+				gtp := newGTP("foo", "stage", failover(&model.TrafficGroup{Region: "us-west-2", Weight: 100}))
+				gtp.Annotations[common.FailoverPriorityAnnotation] = "us-west-2,us-east-2"
+				return gtp
+			}(),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a default GTP of a namespace, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then it should be accepted without identity",
+			gtp: func() *v1.GlobalTrafficPolicy {
+				gtp := newGTP("", "stage", failover(&model.TrafficGroup{Region: "us-east-2", Weight: 100}))
+				gtp.Annotations[common.TrafficPolicyScopeAnnotation] = common.TrafficPolicyScopeNamespace
+				return gtp
+			}(),
+			expectedViolations: 0,
+		},
+		{
+			name: "Given a GTP for an identity admiral has not discovered yet, " +
+				"When ValidateGlobalTrafficPolicy is called, " +
+				"Then only the weights should be validated",
+			gtp:                newGTP("bar", "prod", failover(&model.TrafficGroup{Region: "us-east-2", Weight: 100})),
+			expectedViolations: 0,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			violations := ValidateGlobalTrafficPolicy(rr, c.gtp)
+			assert.Len(t, violations, c.expectedViolations, violations)
+		})
+	}
+}
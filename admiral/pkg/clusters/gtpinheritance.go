@@ -0,0 +1,205 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The traffic policy of an identity in an env is resolved in the following order, the first level which has
+// a policy wins:
+//
+//  1. The GTPs of the identity, labelled with the identity in the namespace of its workloads. The GTP with the
+//     highest priority, and then the most recent one, is applied when there is more than one.
+//  2. The default traffic policies the identity inherits, merged by dns prefix:
+//     a. the default GTP of the namespace of its workloads, annotated with the namespace scope
+//     b. the default GTP of the org, annotated with the org scope in the org traffic policy namespace
+//     The policy of the namespace for a dns prefix overrides the policy of the org for the same prefix, the
+//     prefixes only one of them has a policy for are all kept.
+//
+// A GTP of the identity is never merged with the default traffic policies. A default GTP without env applies
+// to every env, and only to its env otherwise. The GTPs outside of their activation window are skipped at every
+// level. The ServiceEntries of an identity which inherited default traffic policies are annotated with the
+// default GTPs they came from, most specific first.
+
+// getTrafficPolicyScope returns the scope of a default GTP, which is empty for the GTPs of an identity
+func getTrafficPolicyScope(gtp *v1.GlobalTrafficPolicy) string {
+	return gtp.Annotations[common.TrafficPolicyScopeAnnotation]
+}
+
+// validateTrafficPolicyScope checks a default GTP does not target an identity, and that the default GTPs
+// of the org are in the org traffic policy namespace
+func validateTrafficPolicyScope(gtp *v1.GlobalTrafficPolicy) error {
+	scope := getTrafficPolicyScope(gtp)
+	switch scope {
+	case "":
+		return nil
+	case common.TrafficPolicyScopeNamespace, common.TrafficPolicyScopeOrg:
+	default:
+		return fmt.Errorf("%s %q is not one of %s, %s", common.TrafficPolicyScopeAnnotation, scope,
+			common.TrafficPolicyScopeNamespace, common.TrafficPolicyScopeOrg)
+	}
+	if common.GetGtpIdentity(gtp) != "" {
+		return fmt.Errorf("a default traffic policy of scope %s cannot have the %s label", scope, common.GetAdmiralCRDIdentityLabel())
+	}
+	if scope == common.TrafficPolicyScopeOrg && (common.GetOrgTrafficPolicyNamespace() == "" || gtp.Namespace != common.GetOrgTrafficPolicyNamespace()) {
+		return fmt.Errorf("default traffic policies of scope %s are only read from the namespace %q", scope, common.GetOrgTrafficPolicyNamespace())
+	}
+	return nil
+}
+
+// getDefaultGtp returns the default GTP of the scope in the namespace which applies to the env, nil is
+// returned when there is none
+func getDefaultGtp(rc *RemoteController, scope, namespace, env string, now time.Time) *v1.GlobalTrafficPolicy {
+	if rc == nil || rc.GlobalTraffic == nil || rc.GlobalTraffic.Cache == nil || namespace == "" {
+		return nil
+	}
+	envs := []string{env}
+	if env != common.Default {
+		envs = append(envs, common.Default)
+	}
+	candidates := make([]*v1.GlobalTrafficPolicy, 0)
+	for _, gtpEnv := range envs {
+		for _, gtp := range rc.GlobalTraffic.Cache.Get(common.ConstructKeyWithEnvAndIdentity(gtpEnv, ""), namespace) {
+			if getTrafficPolicyScope(gtp) == scope && validateTrafficPolicyScope(gtp) == nil {
+				candidates = append(candidates, gtp)
+			}
+		}
+	}
+	candidates = selectActiveGtps(candidates, now)
+	if len(candidates) == 0 {
+		return nil
+	}
+	common.SortGtpsByPriorityAndCreationTime(candidates, "", env)
+	return selectActiveGtps(candidates, now)[0]
+}
+
+// getInheritedGtp returns the default traffic policies the identity inherits in the cluster, merged into a
+// GTP of the identity. nil is returned when the identity inherits none
+func getInheritedGtp(rc *RemoteController, identity, env, namespace string, now time.Time) *v1.GlobalTrafficPolicy {
+	namespaceGtp := getDefaultGtp(rc, common.TrafficPolicyScopeNamespace, namespace, env, now)
+	orgGtp := getDefaultGtp(rc, common.TrafficPolicyScopeOrg, common.GetOrgTrafficPolicyNamespace(), env, now)
+	return mergeDefaultGtps(identity, env, namespaceGtp, orgGtp)
+}
+
+// mergeDefaultGtps merges the default GTPs, from the most to the least specific one, into a GTP of the identity.
+// The policy of a dns prefix comes from the most specific GTP which has one
+func mergeDefaultGtps(identity, env string, defaultGtps ...*v1.GlobalTrafficPolicy) *v1.GlobalTrafficPolicy {
+	var (
+		merged   *v1.GlobalTrafficPolicy
+		sources  []string
+		prefixes = make(map[string]bool)
+	)
+	for _, gtp := range defaultGtps {
+		if gtp == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &v1.GlobalTrafficPolicy{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:              gtp.Name,
+					Namespace:         gtp.Namespace,
+					CreationTimestamp: gtp.CreationTimestamp,
+					Labels:            map[string]string{common.GetAdmiralCRDIdentityLabel(): identity},
+					Annotations:       map[string]string{common.GetEnvKey(): env},
+				},
+			}
+		}
+		sources = append(sources, fmt.Sprintf("%s:%s/%s", getTrafficPolicyScope(gtp), gtp.Namespace, gtp.Name))
+		for _, policy := range gtp.Spec.Policy {
+			if policy == nil {
+				continue
+			}
+			prefix := policy.DnsPrefix
+			if prefix == "" || prefix == env {
+				prefix = common.Default
+			}
+			if prefixes[prefix] {
+				continue
+			}
+			prefixes[prefix] = true
+			merged.Spec.Policy = append(merged.Spec.Policy, policy.DeepCopy())
+		}
+	}
+	if merged == nil {
+		return nil
+	}
+	merged.Annotations[common.TrafficPolicySourceAnnotation] = strings.Join(sources, ",")
+	return merged
+}
+
+// withInheritedGtps returns the GTPs of the identity, or the default traffic policies it inherits in each
+// cluster when none of its GTPs applies
+func withInheritedGtps(gtps, inheritedGtps map[string][]*v1.GlobalTrafficPolicy, now time.Time) map[string][]*v1.GlobalTrafficPolicy {
+	for _, clusterGtps := range gtps {
+		if len(selectActiveGtps(clusterGtps, now)) > 0 {
+			return gtps
+		}
+	}
+	if len(inheritedGtps) == 0 {
+		return gtps
+	}
+	return inheritedGtps
+}
+
+// handleDefaultTrafficPolicyEvent applies the traffic policies again to the identities of the cluster which may
+// inherit the default GTP, which are the identities of its namespace, or every identity for a GTP of the org
+func handleDefaultTrafficPolicyEvent(ctx context.Context, gtp *v1.GlobalTrafficPolicy, rr *RemoteRegistry,
+	clusterName string, modifySE ModifySEFunc) error {
+	if err := validateTrafficPolicyScope(gtp); err != nil {
+		return fmt.Errorf(LogFormat, "Event", "globaltrafficpolicy", gtp.Name, clusterName, "skipped as "+err.Error())
+	}
+	rc := rr.GetRemoteController(clusterName)
+	if rc == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityClusterCache == nil {
+		return nil
+	}
+	namespace := gtp.Namespace
+	if getTrafficPolicyScope(gtp) == common.TrafficPolicyScopeOrg {
+		namespace = ""
+	}
+	gtpEnv := common.GetGtpEnv(gtp)
+	var err error
+	for _, identity := range getClusterIdentities(rr.AdmiralCache, clusterName) {
+		for _, env := range getIdentityWorkloadEnvs(rc, identity, namespace) {
+			if gtpEnv != common.Default && gtpEnv != env {
+				continue
+			}
+			_, modifyErr := modifySE(ctx, admiral.Update, env, identity, rr)
+			err = common.AppendError(err, modifyErr)
+		}
+	}
+	return err
+}
+
+// getIdentityWorkloadEnvs returns the sorted envs of the workloads of the identity in the cluster, limited to
+// the workloads of the namespace when it is set
+func getIdentityWorkloadEnvs(rc *RemoteController, identity, namespace string) []string {
+	envs := make(map[string]bool)
+	if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+		for env, item := range rc.DeploymentController.Cache.GetByIdentity(identity) {
+			if item != nil && item.Deployment != nil && (namespace == "" || item.Deployment.Namespace == namespace) {
+				envs[env] = true
+			}
+		}
+	}
+	if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+		for env, item := range rc.RolloutController.Cache.GetByIdentity(identity) {
+			if item != nil && item.Rollout != nil && (namespace == "" || item.Rollout.Namespace == namespace) {
+				envs[env] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(envs))
+	for env := range envs {
+		sorted = append(sorted, env)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,287 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func newDefaultGtp(name, namespace, scope, env string, prefixes ...string) *v1.GlobalTrafficPolicy {
+	gtp := &v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{common.TrafficPolicyScopeAnnotation: scope},
+		},
+	}
+	if env != "" {
+		gtp.Annotations["admiral.io/env"] = env
+	}
+	for _, prefix := range prefixes {
+		gtp.Spec.Policy = append(gtp.Spec.Policy, &model.TrafficPolicy{
+			DnsPrefix: prefix,
+			LbType:    model.TrafficPolicy_FAILOVER,
+			Target:    []*model.TrafficGroup{{Region: name, Weight: 100}},
+		})
+	}
+	return gtp
+}
+
+func initInheritanceTestConfig() {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                  &common.LabelSet{AdmiralCRDIdentityLabel: "identity", EnvKey: "admiral.io/env"},
+		OrgTrafficPolicyNamespace: "admiral-policies",
+	})
+}
+
+func TestValidateTrafficPolicyScope(t *testing.T) {
+	initInheritanceTestConfig()
+	defer common.ResetSync()
+	testCases := []struct {
+		name        string
+		gtp         *v1.GlobalTrafficPolicy
+		expectedErr bool
+	}{
+		{
+			name: "Given a GTP of an identity, " +
+				"When validateTrafficPolicyScope is called, " +
+				"Then it should be valid",
+			gtp: &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "foo"}}},
+		},
+		{
+			name: "Given a default GTP of a namespace, " +
+				"When validateTrafficPolicyScope is called, " +
+				"Then it should be valid",
+			gtp: newDefaultGtp("ns-default", "foo-ns", common.TrafficPolicyScopeNamespace, ""),
+		},
+		{
+			name: "Given a default GTP with an identity, " +
+				"When validateTrafficPolicyScope is called, " +
+				"Then it should be invalid",
+			gtp: func() *v1.GlobalTrafficPolicy {
+				gtp := newDefaultGtp("ns-default", "foo-ns", common.TrafficPolicyScopeNamespace, "")
+				gtp.Labels = map[string]string{"identity": "foo"}
+				return gtp
+			}(),
+			expectedErr: true,
+		},
+		{
+			name: "Given a default GTP of the org outside of the org traffic policy namespace, " +
+				"When validateTrafficPolicyScope is called, " +
+				"Then it should be invalid",
+			gtp:         newDefaultGtp("org-default", "foo-ns", common.TrafficPolicyScopeOrg, ""),
+			expectedErr: true,
+		},
+		{
+			name: "Given a GTP with an unknown scope, " +
+				"When validateTrafficPolicyScope is called, " +
+				"Then it should be invalid",
+			gtp:         newDefaultGtp("cluster-default", "foo-ns", "cluster", ""),
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateTrafficPolicyScope(c.gtp)
+			assert.Equal(t, c.expectedErr, err != nil, err)
+		})
+	}
+}
+
+func TestMergeDefaultGtps(t *testing.T) {
+	initInheritanceTestConfig()
+	defer common.ResetSync()
+	namespaceGtp := newDefaultGtp("us-west-2", "foo-ns", common.TrafficPolicyScopeNamespace, "", "stage", "west")
+	orgGtp := newDefaultGtp("us-east-2", "admiral-policies", common.TrafficPolicyScopeOrg, "", "default", "east")
+
+	merged := mergeDefaultGtps("foo", "stage", namespaceGtp, orgGtp)
+
+	assert.Equal(t, "us-west-2", merged.Name)
+	assert.Equal(t, "foo", common.GetGtpIdentity(merged))
+	assert.Equal(t, "stage", common.GetGtpEnv(merged))
+	assert.Equal(t, "namespace:foo-ns/us-west-2,org:admiral-policies/us-east-2", merged.Annotations[common.TrafficPolicySourceAnnotation])
+	policies := make(map[string]string)
+	for _, policy := range merged.Spec.Policy {
+		policies[policy.DnsPrefix] = policy.Target[0].Region
+	}
+	assert.Equal(t, map[string]string{"stage": "us-west-2", "west": "us-west-2", "east": "us-east-2"}, policies)
+	assert.Nil(t, mergeDefaultGtps("foo", "stage", nil, nil))
+}
+
+func TestGetInheritedGtp(t *testing.T) {
+	initInheritanceTestConfig()
+	defer common.ResetSync()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name            string
+		gtps            []*v1.GlobalTrafficPolicy
+		expectedSources string
+	}{
+		{
+			name: "Given no default GTP, " +
+				"When getInheritedGtp is called, " +
+				"Then nothing should be inherited",
+			gtps: []*v1.GlobalTrafficPolicy{},
+		},
+		{
+			name: "Given a default GTP of the namespace and of the org, " +
+				"When getInheritedGtp is called, " +
+				"Then both should be inherited, the namespace first",
+			gtps: []*v1.GlobalTrafficPolicy{
+				newDefaultGtp("ns-default", "foo-ns", common.TrafficPolicyScopeNamespace, "", "default"),
+				newDefaultGtp("org-default", "admiral-policies", common.TrafficPolicyScopeOrg, "", "default"),
+			},
+			expectedSources: "namespace:foo-ns/ns-default,org:admiral-policies/org-default",
+		},
+		{
+			name: "Given a default GTP of another namespace and of another env, " +
+				"When getInheritedGtp is called, " +
+				"Then nothing should be inherited",
+			gtps: []*v1.GlobalTrafficPolicy{
+				newDefaultGtp("ns-default", "bar-ns", common.TrafficPolicyScopeNamespace, "", "default"),
+				newDefaultGtp("org-default", "admiral-policies", common.TrafficPolicyScopeOrg, "prod", "default"),
+			},
+		},
+		{
+			name: "Given a default GTP of the org for the env outside of its activation window, " +
+				"When getInheritedGtp is called, " +
+				"Then nothing should be inherited",
+			gtps: []*v1.GlobalTrafficPolicy{
+				func() *v1.GlobalTrafficPolicy {
+					gtp := newDefaultGtp("org-default", "admiral-policies", common.TrafficPolicyScopeOrg, "stage", "default")
+					gtp.Annotations[common.GtpActiveFromAnnotation] = "2024-01-02T00:00:00Z"
+					return gtp
+				}(),
+			},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			gtpController, err := admiral.NewGlobalTrafficController(make(chan struct{}), &test.MockGlobalTrafficHandler{},
+				&rest.Config{Host: "localhost"}, time.Minute, loader.GetFakeClientLoader())
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			for _, gtp := range c.gtps {
+				gtpController.Cache.Put(gtp)
+			}
+			rc := &RemoteController{ClusterID: "cluster1", GlobalTraffic: gtpController}
+
+			inherited := getInheritedGtp(rc, "foo", "stage", "foo-ns", now)
+
+			if c.expectedSources == "" {
+				assert.Nil(t, inherited)
+				return
+			}
+			assert.Equal(t, c.expectedSources, inherited.Annotations[common.TrafficPolicySourceAnnotation])
+		})
+	}
+}
+
+func TestWithInheritedGtps(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	own := map[string][]*v1.GlobalTrafficPolicy{"cluster1": {{ObjectMeta: metaV1.ObjectMeta{Name: "foo-gtp"}}}}
+	ended := map[string][]*v1.GlobalTrafficPolicy{"cluster1": {{ObjectMeta: metaV1.ObjectMeta{
+		Name:        "foo-drain",
+		Annotations: map[string]string{common.GtpActiveUntilAnnotation: "2024-01-01T09:00:00Z"},
+	}}}}
+	inherited := map[string][]*v1.GlobalTrafficPolicy{"cluster2": {{ObjectMeta: metaV1.ObjectMeta{Name: "ns-default"}}}}
+
+	assert.Equal(t, own, withInheritedGtps(own, inherited, now))
+	assert.Equal(t, inherited, withInheritedGtps(ended, inherited, now))
+	assert.Equal(t, inherited, withInheritedGtps(map[string][]*v1.GlobalTrafficPolicy{}, inherited, now))
+	assert.Equal(t, own, withInheritedGtps(own, map[string][]*v1.GlobalTrafficPolicy{}, now))
+}
+
+func TestHandleDefaultTrafficPolicyEvent(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey:     "identity",
+			AdmiralCRDIdentityLabel: "identity",
+			EnvKey:                  "admiral.io/env",
+		},
+		OrgTrafficPolicyNamespace: "admiral-policies",
+	})
+	defer common.ResetSync()
+	deploymentCache := admiral.NewDeploymentCache()
+	for identity, namespace := range map[string]string{"foo": "foo-ns", "bar": "bar-ns"} {
+		deploymentCache.UpdateDeploymentToClusterCache(identity, &k8sAppsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{Name: identity, Namespace: namespace},
+			Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{
+				Annotations: map[string]string{"admiral.io/env": "stage"},
+				Labels:      map[string]string{"identity": identity},
+			}}},
+		})
+	}
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: deploymentCache},
+	})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("bar", "cluster1", "cluster1")
+
+	testCases := []struct {
+		name               string
+		gtp                *v1.GlobalTrafficPolicy
+		expectedIdentities []string
+		expectedErr        bool
+	}{
+		{
+			name: "Given a default GTP of a namespace, " +
+				"When handleDefaultTrafficPolicyEvent is called, " +
+				"Then only the identities of the namespace should be processed",
+			gtp:                newDefaultGtp("ns-default", "foo-ns", common.TrafficPolicyScopeNamespace, "", "default"),
+			expectedIdentities: []string{"stage.foo"},
+		},
+		{
+			name: "Given a default GTP of the org, " +
+				"When handleDefaultTrafficPolicyEvent is called, " +
+				"Then every identity should be processed",
+			gtp:                newDefaultGtp("org-default", "admiral-policies", common.TrafficPolicyScopeOrg, "", "default"),
+			expectedIdentities: []string{"stage.bar", "stage.foo"},
+		},
+		{
+			name: "Given a default GTP of a namespace for another env, " +
+				"When handleDefaultTrafficPolicyEvent is called, " +
+				"Then no identity should be processed",
+			gtp:                newDefaultGtp("ns-default", "foo-ns", common.TrafficPolicyScopeNamespace, "prod", "default"),
+			expectedIdentities: []string{},
+		},
+		{
+			name: "Given a default GTP of the org outside of the org traffic policy namespace, " +
+				"When handleDefaultTrafficPolicyEvent is called, " +
+				"Then an error should be returned",
+			gtp:                newDefaultGtp("org-default", "foo-ns", common.TrafficPolicyScopeOrg, "", "default"),
+			expectedIdentities: []string{},
+			expectedErr:        true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			processed := []string{}
+			modifySE := func(ctx context.Context, event admiral.EventType, env string, identity string, rr *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
+				processed = append(processed, env+"."+identity)
+				return nil, nil
+			}
+			err := handleDefaultTrafficPolicyEvent(context.Background(), c.gtp, rr, "cluster1", modifySE)
+			assert.Equal(t, c.expectedErr, err != nil, err)
+			assert.Equal(t, c.expectedIdentities, processed)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GtpStateActive is the state of a GTP within its activation window
+	GtpStateActive = "Active"
+	// GtpStateInactive is the state of a GTP outside of its activation window
+	GtpStateInactive = "Inactive"
+)
+
+// gtpWindow is the activation window of a GTP, the GTP is active between from and until when they are set,
+// and for the duration after each time the schedule fires when it is set
+type gtpWindow struct {
+	from     time.Time
+	until    time.Time
+	schedule *commonUtil.CronSchedule
+	duration time.Duration
+}
+
+// hasGtpWindow checks if the GTP only applies within an activation window
+func hasGtpWindow(gtp *v1.GlobalTrafficPolicy) bool {
+	for _, annotation := range []string{common.GtpActiveFromAnnotation, common.GtpActiveUntilAnnotation, common.GtpActiveScheduleAnnotation} {
+		if gtp.Annotations[annotation] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getGtpWindow parses the activation window of the GTP, nil is returned for a GTP without one
+func getGtpWindow(gtp *v1.GlobalTrafficPolicy) (*gtpWindow, error) {
+	if !hasGtpWindow(gtp) {
+		return nil, nil
+	}
+	window := &gtpWindow{}
+	var err error
+	if value := gtp.Annotations[common.GtpActiveFromAnnotation]; value != "" {
+		if window.from, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("%s %q is not an RFC3339 time", common.GtpActiveFromAnnotation, value)
+		}
+	}
+	if value := gtp.Annotations[common.GtpActiveUntilAnnotation]; value != "" {
+		if window.until, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("%s %q is not an RFC3339 time", common.GtpActiveUntilAnnotation, value)
+		}
+		if !window.from.IsZero() && !window.until.After(window.from) {
+			return nil, fmt.Errorf("%s %q is not after %s", common.GtpActiveUntilAnnotation, value, common.GtpActiveFromAnnotation)
+		}
+	}
+	if value := gtp.Annotations[common.GtpActiveScheduleAnnotation]; value != "" {
+		if window.schedule, err = commonUtil.ParseCronSchedule(value); err != nil {
+			return nil, err
+		}
+		durationValue := gtp.Annotations[common.GtpActiveDurationAnnotation]
+		if window.duration, err = time.ParseDuration(durationValue); err != nil || window.duration <= 0 {
+			return nil, fmt.Errorf("%s %q is not a positive duration, it is required along with %s",
+				common.GtpActiveDurationAnnotation, durationValue, common.GtpActiveScheduleAnnotation)
+		}
+	}
+	return window, nil
+}
+
+// isActive checks if the time is within the window
+func (w *gtpWindow) isActive(now time.Time) bool {
+	if !w.from.IsZero() && now.Before(w.from) {
+		return false
+	}
+	if !w.until.IsZero() && !now.Before(w.until) {
+		return false
+	}
+	if w.schedule != nil {
+		_, fired := w.schedule.LastFiredWithin(now, w.duration)
+		return fired
+	}
+	return true
+}
+
+// isGtpActive checks if the GTP applies at the time, which a GTP without activation window always does.
+// A GTP whose window cannot be parsed never applies, so a misconfigured failover is not left on for good
+func isGtpActive(gtp *v1.GlobalTrafficPolicy, now time.Time) bool {
+	window, err := getGtpWindow(gtp)
+	if err != nil {
+		log.Warnf(LogFormat, "Check", common.GtpActivationWindow, gtp.Name, "", err.Error())
+		return false
+	}
+	return window == nil || window.isActive(now)
+}
+
+// selectActiveGtps drops the GTPs outside of their activation window, and moves the GTPs within theirs
+// ahead of the GTPs without one, keeping the order of the GTPs otherwise. This way a GTP scheduled for a
+// planned failover or a maintenance drain overrides the GTP of the identity while its window is open
+func selectActiveGtps(gtps []*v1.GlobalTrafficPolicy, now time.Time) []*v1.GlobalTrafficPolicy {
+	active := make([]*v1.GlobalTrafficPolicy, 0, len(gtps))
+	for _, gtp := range gtps {
+		if isGtpActive(gtp, now) {
+			active = append(active, gtp)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return hasGtpWindow(active[i]) && !hasGtpWindow(active[j])
+	})
+	return active
+}
+
+// getGtpWindowState returns the state the status of the GTP with an activation window should show
+func getGtpWindowState(gtp *v1.GlobalTrafficPolicy, now time.Time) string {
+	if isGtpActive(gtp, now) {
+		return GtpStateActive
+	}
+	return GtpStateInactive
+}
+
+// checkGtpWindows updates the status of the GTPs of the cluster whose activation window opened or closed,
+// the update of the GTP has it applied again, or the GTP it overrode restored
+func checkGtpWindows(ctx context.Context, rc *RemoteController, now time.Time) {
+	for _, gtp := range rc.GlobalTraffic.Cache.List() {
+		if !hasGtpWindow(gtp) {
+			continue
+		}
+		state := getGtpWindowState(gtp, now)
+		if gtp.Status.State == state {
+			continue
+		}
+		err := setGtpState(ctx, rc, gtp, state)
+		if err != nil {
+			log.Errorf(LogErrFormat, "Update", common.GtpActivationWindow, gtp.Name, rc.ClusterID, err)
+			continue
+		}
+		log.Infof(LogFormat, "Update", common.GtpActivationWindow, gtp.Name, rc.ClusterID, "gtp in namespace="+gtp.Namespace+" is now "+state)
+	}
+}
+
+// setGtpState writes the state to the status of the GTP
+func setGtpState(ctx context.Context, rc *RemoteController, gtp *v1.GlobalTrafficPolicy, state string) error {
+	if rc == nil || rc.GlobalTraffic == nil || rc.GlobalTraffic.CrdClient == nil {
+		return fmt.Errorf("globaltrafficpolicy controller not initialized for cluster")
+	}
+	client := rc.GlobalTraffic.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies(gtp.Namespace)
+	current, err := client.Get(ctx, gtp.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	current.Status.State = state
+	_, err = client.Update(ctx, current, metaV1.UpdateOptions{})
+	return err
+}
+
+// startGtpWindowScheduler periodically checks the activation windows of the GTPs of the cluster
+func startGtpWindowScheduler(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	interval := common.GetGtpWindowCheckInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			// the remote controller is looked up on every check, as it is replaced when its istio
+			// controllers are started or stopped
+			rc := rr.GetRemoteController(clusterID)
+			if rc == nil || rc.GlobalTraffic == nil || rc.GlobalTraffic.Cache == nil {
+				continue
+			}
+			checkGtpWindows(context.Background(), rc, time.Now())
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestIsGtpActive(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	newGTP := func(annotations map[string]string) *v1.GlobalTrafficPolicy {
+		return &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "gtp", Annotations: annotations}}
+	}
+	testCases := []struct {
+		name     string
+		gtp      *v1.GlobalTrafficPolicy
+		expected bool
+	}{
+		{
+			name: "Given a GTP without activation window, " +
+				"When isGtpActive is called, " +
+				"Then it should be active",
+			gtp:      newGTP(nil),
+			expected: true,
+		},
+		{
+			name: "Given a GTP whose window is between two times around now, " +
+				"When isGtpActive is called, " +
+				"Then it should be active",
+			gtp: newGTP(map[string]string{
+				common.GtpActiveFromAnnotation:  "2024-01-01T09:00:00Z",
+				common.GtpActiveUntilAnnotation: "2024-01-01T11:00:00Z",
+			}),
+			expected: true,
+		},
+		{
+			name: "Given a GTP whose window ended, " +
+				"When isGtpActive is called, " +
+				"Then it should not be active",
+			gtp:      newGTP(map[string]string{common.GtpActiveUntilAnnotation: "2024-01-01T10:00:00Z"}),
+			expected: false,
+		},
+		{
+			name: "Given a GTP scheduled daily at 08:00 for 4 hours, " +
+				"When isGtpActive is called at 10:00, " +
+				"Then it should be active",
+			gtp: newGTP(map[string]string{
+				common.GtpActiveScheduleAnnotation: "0 8 * * *",
+				common.GtpActiveDurationAnnotation: "4h",
+			}),
+			expected: true,
+		},
+		{
+			name: "Given a GTP scheduled daily at 08:00 for 1 hour, " +
+				"When isGtpActive is called at 10:00, " +
+				"Then it should not be active",
+			gtp: newGTP(map[string]string{
+				common.GtpActiveScheduleAnnotation: "0 8 * * *",
+				common.GtpActiveDurationAnnotation: "1h",
+			}),
+			expected: false,
+		},
+		{
+			name: "Given a GTP with a schedule but no duration, " +
+				"When isGtpActive is called, " +
+				"Then it should not be active",
+			gtp:      newGTP(map[string]string{common.GtpActiveScheduleAnnotation: "0 8 * * *"}),
+			expected: false,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, isGtpActive(c.gtp, now))
+		})
+	}
+}
+
+func TestSelectActiveGtps(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	base := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "base"}}
+	drain := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "drain", Annotations: map[string]string{
+		common.GtpActiveFromAnnotation: "2024-01-01T09:00:00Z",
+	}}}
+	failover := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "failover", Annotations: map[string]string{
+		common.GtpActiveFromAnnotation: "2024-01-02T09:00:00Z",
+	}}}
+
+	selected := selectActiveGtps([]*v1.GlobalTrafficPolicy{base, failover, drain}, now)
+
+	assert.Equal(t, []*v1.GlobalTrafficPolicy{drain, base}, selected)
+}
+
+func TestCheckGtpWindows(t *testing.T) {
+	gtp := &v1.GlobalTrafficPolicy{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "foo-drain",
+			Namespace: "foo-ns",
+			Labels:    map[string]string{"identity": "foo"},
+			Annotations: map[string]string{
+				common.GtpActiveFromAnnotation:  "2024-01-01T09:00:00Z",
+				common.GtpActiveUntilAnnotation: "2024-01-01T11:00:00Z",
+			},
+		},
+	}
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{AdmiralCRDIdentityLabel: "identity", EnvKey: "admiral.io/env"},
+	})
+	defer common.ResetSync()
+	gtpController, err := admiral.NewGlobalTrafficController(make(chan struct{}), &test.MockGlobalTrafficHandler{},
+		&rest.Config{Host: "localhost"}, time.Minute, loader.GetFakeClientLoader())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	_, err = gtpController.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Create(context.Background(), gtp, metaV1.CreateOptions{})
+	assert.NoError(t, err)
+	gtpController.Cache.Put(gtp)
+	rc := &RemoteController{ClusterID: "cluster1", GlobalTraffic: gtpController}
+	getState := func() string {
+		current, err := gtpController.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(context.Background(), "foo-drain", metaV1.GetOptions{})
+		assert.NoError(t, err)
+		gtpController.Cache.Put(current)
+		return current.Status.State
+	}
+
+	checkGtpWindows(context.Background(), rc, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, GtpStateActive, getState())
+
+	checkGtpWindows(context.Background(), rc, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, GtpStateInactive, getState())
+}
@@ -3,6 +3,7 @@ package clusters
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -65,12 +66,23 @@ func ShouldProcessVSCreatedBy(vs *v1alpha3.VirtualService) bool {
 	return createdBy == vsCreatedBy
 }
 
-func IgnoreIstioResource(exportTo []string, annotations map[string]string, namespace string) bool {
+func IgnoreIstioResource(rr *RemoteRegistry, exportTo []string, annotations map[string]string, namespace string) bool {
 	if len(annotations) > 0 && annotations[common.AdmiralIgnoreAnnotation] == "true" {
 		return true
 	}
 
-	if namespace == common.NamespaceIstioSystem || namespace == common.NamespaceKubeSystem || namespace == common.GetSyncNamespace() {
+	if namespace == common.NamespaceIstioSystem || namespace == common.NamespaceKubeSystem || common.IsSyncNamespace(namespace) {
+		return true
+	}
+
+	// resources generated by admiral live outside the sync namespaces when an identity requested
+	// its own sync namespace
+	if isGeneratedByAdmiral(annotations) && isIdentitySyncNamespace(namespace) {
+		return true
+	}
+
+	// resources rendered to a GitOps repository are synced back to the clusters by another tool
+	if isGitOpsRendered(annotations) {
 		return true
 	}
 
@@ -140,6 +152,49 @@ func copyEndpoint(e *networkingV1Alpha3.WorkloadEntry) *networkingV1Alpha3.Workl
 	return &networkingV1Alpha3.WorkloadEntry{Address: e.Address, Ports: ports, Locality: e.Locality, Labels: labels}
 }
 
+// getRolloutCanaryHTTPRoutes returns the http routes of the VirtualService the rollout shapes traffic with,
+// which are the routes named by the rollout, or the only route of the VirtualService when none is named
+func getRolloutCanaryHTTPRoutes(rollout *rolloutsV1Alpha1.Rollout, clusterID string, istioVirtualService rolloutsV1Alpha1.IstioVirtualService,
+	httpRoutes []*networkingV1Alpha3.HTTPRoute) []*networkingV1Alpha3.HTTPRoute {
+	if len(istioVirtualService.Routes) == 0 {
+		if len(httpRoutes) == 1 {
+			log.Debugf("Using the default and the only route in Virtual Service, for rollout with name=%s route=%s in namespace=%s and cluster=%s", rollout.Name, "", rollout.Namespace, clusterID)
+			return httpRoutes
+		}
+		log.Errorf("Skipping VirtualService referenced in rollout as it has MORE THAN ONE route but no name route selector in rollout, for rollout with name=%s in namespace=%s and cluster=%s", rollout.Name, rollout.Namespace, clusterID)
+		return nil
+	}
+	matchedRoutes := make([]*networkingV1Alpha3.HTTPRoute, 0, len(istioVirtualService.Routes))
+	for _, route := range httpRoutes {
+		if slices.Contains(istioVirtualService.Routes, route.Name) {
+			matchedRoutes = append(matchedRoutes, route)
+		} else {
+			log.Debugf("Argo rollout VirtualService route name didn't match with a route, for rollout with name=%s route=%s in namespace=%s and cluster=%s", rollout.Name, route.Name, rollout.Namespace, clusterID)
+		}
+	}
+	return matchedRoutes
+}
+
+// getCanaryTrafficWeightsFromStatus returns the weights of the stable and canary services of a rollout using ALB or
+// SMI traffic routing, as recorded in its status by the traffic router. The services without weight are left out, and
+// all the traffic goes to the stable service until the traffic router records weights
+func getCanaryTrafficWeightsFromStatus(rollout *rolloutsV1Alpha1.Rollout) map[string]int32 {
+	stableService := rollout.Spec.Strategy.Canary.StableService
+	canaryService := rollout.Spec.Strategy.Canary.CanaryService
+	weights := rollout.Status.Canary.Weights
+	if weights == nil || weights.Stable.Weight+weights.Canary.Weight <= 0 {
+		return map[string]int32{stableService: 1}
+	}
+	canaryWeights := make(map[string]int32)
+	if weights.Stable.Weight > 0 {
+		canaryWeights[stableService] = weights.Stable.Weight
+	}
+	if weights.Canary.Weight > 0 {
+		canaryWeights[canaryService] = weights.Canary.Weight
+	}
+	return canaryWeights
+}
+
 // A rollout can use one of 2 stratergies :-
 // 1. Canary strategy - which can use a virtual service to manage the weights associated with a stable and canary service. Admiral created endpoints in service entries will use the weights assigned in the Virtual Service
 // 2. Blue green strategy- this contains 2 service instances in a namespace, an active service and a preview service. Admiral will use repective service to create active and preview endpoints
@@ -169,7 +224,6 @@ func getServiceForRollout(ctx context.Context, rc *RemoteController, rollout *ro
 	var (
 		canaryService           string
 		stableService           string
-		virtualServiceRouteName string
 		istioCanaryWeights      = make(map[string]int32)
 		blueGreenActiveService  string
 		blueGreenPreviewService string
@@ -195,50 +249,24 @@ func getServiceForRollout(ctx context.Context, rc *RemoteController, rollout *ro
 				//pick stable service if specified
 				istioCanaryWeights[stableService] = 1
 
-				if rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService != nil && rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Name != "" {
-					virtualServiceName := rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Name
-					virtualService, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(rollout.Namespace).Get(ctx, virtualServiceName, metaV1.GetOptions{})
-
+				for _, istioVirtualService := range common.GetCanaryIstioVirtualServices(rolloutStrategy) {
+					virtualService, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(rollout.Namespace).Get(ctx, istioVirtualService.Name, metaV1.GetOptions{})
 					if err != nil {
 						log.Warnf("Error fetching VirtualService referenced in rollout canary for rollout with name=%s in namespace=%s and cluster=%s err=%v", rollout.Name, rollout.Namespace, rc.ClusterID, err)
+						continue
 					}
-
-					if rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Routes != nil && len(rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Routes) > 0 {
-						virtualServiceRouteName = rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Routes[0]
+					//nolint
+					var vs = virtualService.Spec
+					if len(vs.Http) == 0 {
+						log.Warnf("No VirtualService was specified in rollout or the specified VirtualService has NO routes, for rollout with name=%s in namespace=%s and cluster=%s", rollout.Name, rollout.Namespace, rc.ClusterID)
+						continue
 					}
-
-					if virtualService != nil {
-						//nolint
-						var vs = virtualService.Spec
-						if len(vs.Http) > 0 {
-							var httpRoute *networkingV1Alpha3.HTTPRoute
-							if len(virtualServiceRouteName) > 0 {
-								for _, route := range vs.Http {
-									if route.Name == virtualServiceRouteName {
-										httpRoute = route
-										break
-									} else {
-										log.Debugf("Argo rollout VirtualService route name didn't match with a route, for rollout with name=%s route=%s in namespace=%s and cluster=%s", rollout.Name, route.Name, rollout.Namespace, rc.ClusterID)
-									}
-								}
-							} else {
-								if len(vs.Http) == 1 {
-									httpRoute = vs.Http[0]
-									log.Debugf("Using the default and the only route in Virtual Service, for rollout with name=%s route=%s in namespace=%s and cluster=%s", rollout.Name, "", rollout.Namespace, rc.ClusterID)
-								} else {
-									log.Errorf("Skipping VirtualService referenced in rollout as it has MORE THAN ONE route but no name route selector in rollout, for rollout with name=%s in namespace=%s and cluster=%s", rollout.Name, rollout.Namespace, rc.ClusterID)
-								}
-							}
-							if httpRoute != nil {
-								//find the weight associated with the destination (k8s service)
-								for _, destination := range httpRoute.Route {
-									if (destination.Destination.Host == canaryService || destination.Destination.Host == stableService) && destination.Weight > 0 {
-										istioCanaryWeights[destination.Destination.Host] = destination.Weight
-									}
-								}
+					for _, httpRoute := range getRolloutCanaryHTTPRoutes(rollout, rc.ClusterID, istioVirtualService, vs.Http) {
+						//find the weight associated with the destination (k8s service)
+						for _, destination := range httpRoute.Route {
+							if (destination.Destination.Host == canaryService || destination.Destination.Host == stableService) && destination.Weight > 0 {
+								istioCanaryWeights[destination.Destination.Host] = destination.Weight
 							}
-						} else {
-							log.Warnf("No VirtualService was specified in rollout or the specified VirtualService has NO routes, for rollout with name=%s in namespace=%s and cluster=%s", rollout.Name, rollout.Namespace, rc.ClusterID)
 						}
 					}
 				}
@@ -274,6 +302,19 @@ func getServiceForRollout(ctx context.Context, rc *RemoteController, rollout *ro
 					}
 				}
 			}
+		} else if common.IsCanaryALBOrSMIStrategy(rollout) && len(rolloutStrategy.Canary.StableService) > 0 && len(rolloutStrategy.Canary.CanaryService) > 0 {
+			canaryWeights := getCanaryTrafficWeightsFromStatus(rollout)
+			for _, service := range cachedServices {
+				weight, ok := canaryWeights[service.Name]
+				if !ok || !common.IsServiceMatch(service.Spec.Selector, rollout.Spec.Selector) {
+					continue
+				}
+				//make sure the service has a mesh port in the port spec
+				if len(GetMeshPortsForRollout(rc.ClusterID, service, rollout)) > 0 {
+					matchedServices[service.Name] = &WeightedService{Weight: weight, Service: service}
+				}
+			}
+			return matchedServices
 		} else {
 			/*
 				This change is for MESH-2786, where if not istio canary then all traffic will need to go to root service
@@ -351,6 +392,7 @@ func UpdateIdentityClusterCache(remoteRegistry *RemoteRegistry, identity string,
 	if remoteRegistry.AdmiralCache != nil && remoteRegistry.AdmiralCache.IdentityClusterCache != nil {
 		remoteRegistry.AdmiralCache.IdentityClusterCache.Put(identity, clusterId, clusterId)
 	}
+	remoteRegistry.startLazyIstioControllers(clusterId)
 }
 
 func DeploymentOrRolloutExistsInNamespace(remoteRegistry *RemoteRegistry, globalIdentifier string, clusterName string, namespace string) bool {
@@ -89,12 +89,16 @@ func setupForHandlerTests(argoEnabled bool) {
 func TestIgnoreIstioResource(t *testing.T) {
 
 	admiralParams := common.AdmiralParams{
-		LabelSet:             &common.LabelSet{},
-		TrafficConfigPersona: false,
-		SyncNamespace:        "ns",
+		LabelSet:                      &common.LabelSet{},
+		TrafficConfigPersona:          false,
+		SyncNamespace:                 "ns",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
 	}
 	common.ResetSync()
 	common.InitializeConfig(admiralParams)
+	rr := NewRemoteRegistry(context.Background(), admiralParams)
+	rr.AdmiralCache.IdentitySyncNamespaceCache.Put("foo", "team-sync")
 
 	//Struct of test case info. Name is required.
 	testCases := []struct {
@@ -160,12 +164,26 @@ func TestIgnoreIstioResource(t *testing.T) {
 			namespace:      "random-namespace",
 			expectedResult: true,
 		},
+		{
+			name:           "Should return true when it is generated by admiral in the sync namespace of an identity",
+			exportTo:       nil,
+			annotations:    map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+			namespace:      "team-sync",
+			expectedResult: true,
+		},
+		{
+			name:           "Should return false when it is generated by admiral outside the sync namespaces",
+			exportTo:       nil,
+			annotations:    map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+			namespace:      "random-ns",
+			expectedResult: false,
+		},
 	}
 
 	//Run the test for every provided case
 	for _, c := range testCases {
 		t.Run(c.name, func(t *testing.T) {
-			result := IgnoreIstioResource(c.exportTo, c.annotations, c.namespace)
+			result := IgnoreIstioResource(rr, c.exportTo, c.annotations, c.namespace)
 			if result == c.expectedResult {
 				//perfect
 			} else {
@@ -468,6 +486,66 @@ func TestGetServiceForRolloutCanary(t *testing.T) {
 		},
 	}
 
+	canaryRolloutIstioVirtualServices := argo.Rollout{
+		Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{}},
+		}}}
+	canaryRolloutIstioVirtualServices.Spec.Selector = &labelSelector
+
+	canaryRolloutIstioVirtualServices.Namespace = Namespace
+	canaryRolloutIstioVirtualServices.Spec.Strategy = argo.RolloutStrategy{
+		Canary: &argo.CanaryStrategy{
+			StableService: StableServiceName,
+			CanaryService: CanaryServiceName,
+			TrafficRouting: &argo.RolloutTrafficRouting{
+				Istio: &argo.IstioTrafficRouting{
+					VirtualServices: []argo.IstioVirtualService{
+						{Name: vsName3},
+						{Name: vsName2, Routes: []string{"random", vsRoutePrimary}},
+					},
+				},
+			},
+		},
+	}
+
+	canaryRolloutALB := argo.Rollout{
+		Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{}},
+		}}}
+	canaryRolloutALB.Spec.Selector = &labelSelector
+
+	canaryRolloutALB.Namespace = Namespace
+	canaryRolloutALB.Spec.Strategy = argo.RolloutStrategy{
+		Canary: &argo.CanaryStrategy{
+			StableService: StableServiceName,
+			CanaryService: CanaryServiceName,
+			TrafficRouting: &argo.RolloutTrafficRouting{
+				ALB: &argo.ALBTrafficRouting{Ingress: "ingress"},
+			},
+		},
+	}
+	canaryRolloutALB.Status.Canary.Weights = &argo.TrafficWeights{
+		Canary: argo.WeightDestination{Weight: 20, ServiceName: CanaryServiceName},
+		Stable: argo.WeightDestination{Weight: 80, ServiceName: StableServiceName},
+	}
+
+	canaryRolloutSMI := argo.Rollout{
+		Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{}},
+		}}}
+	canaryRolloutSMI.Spec.Selector = &labelSelector
+
+	canaryRolloutSMI.Namespace = Namespace
+	canaryRolloutSMI.Spec.Strategy = argo.RolloutStrategy{
+		Canary: &argo.CanaryStrategy{
+			StableService: StableServiceName,
+			CanaryService: CanaryServiceName,
+			TrafficRouting: &argo.RolloutTrafficRouting{
+				SMI: &argo.SMITrafficRouting{},
+			},
+		},
+	}
+
 	canaryRolloutIstioVsZeroWeight := argo.Rollout{
 		Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
 			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{}},
@@ -649,6 +727,24 @@ func TestGetServiceForRolloutCanary(t *testing.T) {
 			rc:      rcTemp,
 			result:  resultForCanaryWithStableService,
 		},
+		{
+			name:    "canaryRolloutWithIstioVirtualServicesRouteMatch",
+			rollout: &canaryRolloutIstioVirtualServices,
+			rc:      rcTemp,
+			result:  resultForCanaryWithIstio,
+		},
+		{
+			name:    "canaryRolloutWithALBWeightsInStatus",
+			rollout: &canaryRolloutALB,
+			rc:      rcTemp,
+			result:  resultForCanaryWithIstio,
+		},
+		{
+			name:    "canaryRolloutWithSMIWithoutWeightsInStatus",
+			rollout: &canaryRolloutSMI,
+			rc:      rcTemp,
+			result:  resultForCanaryWithStableService,
+		},
 		{
 			name:    "canaryRolloutWithRootServiceName",
 			rollout: &canaryRolloutWithRootService,
@@ -0,0 +1,267 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+)
+
+const (
+	// HostClaimIdentity is a claim made by an identity on the cname admiral generates for it
+	HostClaimIdentity = "identity"
+	// HostClaimVirtualService is a claim made by a custom VirtualService on an admiral generated host,
+	// which only the VirtualServices of the identity owning the host can route
+	HostClaimVirtualService = "virtualservice"
+)
+
+// HostConflict is a claim on a host which was refused because the host was already owned
+type HostConflict struct {
+	Kind       string    `json:"kind"`
+	Host       string    `json:"host"`
+	Owner      string    `json:"owner"`
+	Claimant   string    `json:"claimant"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// hostConflictDetector keeps track of the owner of every host per kind of claim. The host is owned by
+// the claimant whose workloads were created first, the name breaking the ties, so the owner does not
+// depend on the order the claims are made in, e.g. after a restart. The claims made by the others are
+// refused and recorded as conflicts
+type hostConflictDetector struct {
+	mutex     sync.Mutex
+	owners    map[string]map[string]string
+	since     map[string]map[string]time.Time
+	conflicts map[string]HostConflict
+	// slots is the host last claimed by a claimant for a slot, by kind, slot and claimant
+	slots map[string]string
+}
+
+func newHostConflictDetector() *hostConflictDetector {
+	return &hostConflictDetector{
+		owners:    make(map[string]map[string]string),
+		since:     make(map[string]map[string]time.Time),
+		conflicts: make(map[string]HostConflict),
+		slots:     make(map[string]string),
+	}
+}
+
+// claim makes claimant the owner of the host, since being the creation time of the workloads of the
+// claimant, unless the host is owned by a claimant which precedes it, in which case the claim is
+// refused and the current owner is returned. A claimant preceding the current owner takes the host over
+func (d *hostConflictDetector) claim(kind, host, claimant string, since time.Time) (string, bool) {
+	if d == nil {
+		return "", true
+	}
+	host = strings.ToLower(host)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.claimLocked(kind, host, claimant, since)
+}
+
+// claimInSlot makes the claim of claimant on the host for the slot, e.g. the cname of an env of an identity.
+// The host claimant claimed before for the slot is released once the claim is granted, so that it can be
+// claimed by the others when the host of the slot changes
+func (d *hostConflictDetector) claimInSlot(kind, slot, host, claimant string, since time.Time) (string, bool) {
+	if d == nil {
+		return "", true
+	}
+	host = strings.ToLower(host)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	owner, ok := d.claimLocked(kind, host, claimant, since)
+	if !ok {
+		return owner, false
+	}
+	slotKey := getHostConflictKey(kind, slot, claimant)
+	if previous, claimed := d.slots[slotKey]; claimed && previous != host {
+		d.releaseLocked(kind, previous, claimant)
+	}
+	d.slots[slotKey] = host
+	return owner, true
+}
+
+// claimLocked makes the claim of claimant on the host. It must be called with the lock held
+func (d *hostConflictDetector) claimLocked(kind, host, claimant string, since time.Time) (string, bool) {
+	if d.owners[kind] == nil {
+		d.owners[kind] = make(map[string]string)
+		d.since[kind] = make(map[string]time.Time)
+	}
+	claimantKey := strings.ToLower(claimant)
+	if earliest, ok := d.since[kind][claimantKey]; !ok || earliest.IsZero() || (!since.IsZero() && since.Before(earliest)) {
+		d.since[kind][claimantKey] = since
+	}
+	owner, ok := d.owners[kind][host]
+	if ok && !strings.EqualFold(owner, claimant) {
+		if !precedesHostClaim(claimant, d.since[kind][claimantKey], owner, d.since[kind][strings.ToLower(owner)]) {
+			d.recordConflict(kind, host, owner, claimant)
+			return owner, false
+		}
+		// the previous owner is refused from now on
+		d.recordConflict(kind, host, claimant, owner)
+		ok = false
+	}
+	if !ok {
+		d.owners[kind][host] = claimant
+		owner = claimant
+	}
+	delete(d.conflicts, getHostConflictKey(kind, host, claimant))
+	return owner, true
+}
+
+// recordConflict records the refused claim of claimant on the host owned by owner. It must be
+// called with the lock held
+func (d *hostConflictDetector) recordConflict(kind, host, owner, claimant string) {
+	key := getHostConflictKey(kind, host, claimant)
+	if conflict, recorded := d.conflicts[key]; !recorded || conflict.Owner != owner {
+		d.conflicts[key] = HostConflict{Kind: kind, Host: host, Owner: owner, Claimant: claimant, DetectedAt: time.Now()}
+	}
+}
+
+// refuse records the claim of claimant on the host as a conflict, for the claims checked against
+// an owner admiral tracks elsewhere, e.g. the identity owning the cname routed by a custom VirtualService
+func (d *hostConflictDetector) refuse(kind, host, owner, claimant string) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.recordConflict(kind, strings.ToLower(host), owner, claimant)
+}
+
+// precedesHostClaim checks if the claimant whose workloads were created at since precedes the owner
+// whose workloads were created at ownerSince. An unknown creation time comes last
+func precedesHostClaim(claimant string, since time.Time, owner string, ownerSince time.Time) bool {
+	if since.IsZero() != ownerSince.IsZero() {
+		return ownerSince.IsZero()
+	}
+	if !since.Equal(ownerSince) {
+		return since.Before(ownerSince)
+	}
+	return strings.ToLower(claimant) < strings.ToLower(owner)
+}
+
+// release gives up the hosts claimant owns, and forgets the conflicts of its refused claims.
+// The host is released only when it is passed, otherwise every host of the claimant is released
+func (d *hostConflictDetector) release(kind, host, claimant string) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.releaseLocked(kind, strings.ToLower(host), claimant)
+}
+
+// releaseLocked releases the host, or every host when empty, of claimant. It must be called with the lock held
+func (d *hostConflictDetector) releaseLocked(kind, host, claimant string) {
+	for ownedHost, owner := range d.owners[kind] {
+		if (host == "" || ownedHost == host) && strings.EqualFold(owner, claimant) {
+			delete(d.owners[kind], ownedHost)
+		}
+	}
+	if host == "" {
+		delete(d.since[kind], strings.ToLower(claimant))
+	}
+	for key, conflict := range d.conflicts {
+		if conflict.Kind == kind && (host == "" || conflict.Host == host) && strings.EqualFold(conflict.Claimant, claimant) {
+			delete(d.conflicts, key)
+		}
+	}
+	for key, slotHost := range d.slots {
+		if (host == "" || slotHost == host) && strings.HasPrefix(key, kind+"/") && strings.HasSuffix(key, "/"+strings.ToLower(claimant)) {
+			delete(d.slots, key)
+		}
+	}
+}
+
+// GetConflicts returns the conflicts which are not resolved yet, sorted by kind and host
+func (d *hostConflictDetector) GetConflicts() []HostConflict {
+	conflicts := make([]HostConflict, 0)
+	if d == nil {
+		return conflicts
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, conflict := range d.conflicts {
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind < conflicts[j].Kind
+		}
+		if conflicts[i].Host != conflicts[j].Host {
+			return conflicts[i].Host < conflicts[j].Host
+		}
+		return conflicts[i].Claimant < conflicts[j].Claimant
+	})
+	return conflicts
+}
+
+func getHostConflictKey(kind, host, claimant string) string {
+	return kind + "/" + host + "/" + strings.ToLower(claimant)
+}
+
+// getWorkloadCreationTime returns the creation time of the oldest of the deployment and the rollout
+func getWorkloadCreationTime(deployment *k8sAppsV1.Deployment, rollout *argo.Rollout) time.Time {
+	var since time.Time
+	if deployment != nil {
+		since = deployment.CreationTimestamp.Time
+	}
+	if rollout != nil && (since.IsZero() || rollout.CreationTimestamp.Time.Before(since)) {
+		since = rollout.CreationTimestamp.Time
+	}
+	return since
+}
+
+// getCnameOwner returns the identity owning the cname, or an empty string when it is not known
+func getCnameOwner(rr *RemoteRegistry, cname string) string {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.CnameIdentityCache == nil {
+		return ""
+	}
+	identity, ok := rr.AdmiralCache.CnameIdentityCache.Load(cname)
+	if !ok {
+		if identity, ok = rr.AdmiralCache.CnameIdentityCache.Load(strings.ToLower(cname)); !ok {
+			return ""
+		}
+	}
+	owner, _ := identity.(string)
+	return owner
+}
+
+// claimIdentityCname claims the cname of the env of the identity, releasing the previous cname of the env when
+// it changed. The partitions of an identity share its cnames, so the claim is made by the identity without its
+// partition
+func claimIdentityCname(cache *AdmiralCache, env, cname, partitionedIdentity string, since time.Time) (string, bool) {
+	return cache.HostConflictDetector.claimInSlot(HostClaimIdentity, env, cname, getNonPartitionedIdentity(cache, partitionedIdentity), since)
+}
+
+// releaseIdentityCnames releases the cnames of the identity, once it no longer has workloads
+func releaseIdentityCnames(cache *AdmiralCache, partitionedIdentity string) {
+	cache.HostConflictDetector.release(HostClaimIdentity, "", getNonPartitionedIdentity(cache, partitionedIdentity))
+}
+
+// GetHostConflicts returns the claims on hosts admiral refused because the host was already owned
+func GetHostConflicts(rr *RemoteRegistry) []HostConflict {
+	if rr == nil || rr.AdmiralCache == nil {
+		return []HostConflict{}
+	}
+	return rr.AdmiralCache.HostConflictDetector.GetConflicts()
+}
+
+// reportHostConflict logs and records a metric for a claim on a host which was refused
+func reportHostConflict(ctxLogger *log.Entry, kind, host, owner, claimant, cluster string) {
+	hostConflicts.Increment(api.WithAttributes(
+		attribute.Key("kind").String(kind),
+		attribute.Key("host").String(host),
+	))
+	ctxLogger.Errorf(common.CtxLogFormat, "HostConflictCheck", claimant, "", cluster,
+		fmt.Sprintf("refused %s claim on host=%s as it is owned by %s", kind, host, owner))
+}
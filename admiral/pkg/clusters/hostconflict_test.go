@@ -0,0 +1,172 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHostConflictDetector(t *testing.T) {
+	detector := newHostConflictDetector()
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	owner, ok := detector.claim(HostClaimIdentity, "stage.foo.global", "foo", older)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", owner)
+	// claims by the owner are not conflicts, identities are case insensitive
+	_, ok = detector.claim(HostClaimIdentity, "Stage.Foo.Global", "Foo", older)
+	assert.True(t, ok)
+	assert.Empty(t, detector.GetConflicts())
+
+	owner, ok = detector.claim(HostClaimIdentity, "stage.foo.global", "partition.foo", newer)
+	assert.False(t, ok)
+	assert.Equal(t, "foo", owner)
+	detector.refuse(HostClaimVirtualService, "stage.foo.global", "foo", "ns/other-vs")
+	conflicts := detector.GetConflicts()
+	assert.Equal(t, 2, len(conflicts))
+	assert.Equal(t, HostConflict{Kind: HostClaimIdentity, Host: "stage.foo.global", Owner: "foo", Claimant: "partition.foo", DetectedAt: conflicts[0].DetectedAt}, conflicts[0])
+	assert.Equal(t, HostConflict{Kind: HostClaimVirtualService, Host: "stage.foo.global", Owner: "foo", Claimant: "ns/other-vs", DetectedAt: conflicts[1].DetectedAt}, conflicts[1])
+
+	// once the owner releases the host, the next claim succeeds and resolves its conflict
+	detector.release(HostClaimIdentity, "", "foo")
+	_, ok = detector.claim(HostClaimIdentity, "stage.foo.global", "partition.foo", newer)
+	assert.True(t, ok)
+	// releasing a claimant forgets the conflicts of its refused claims
+	detector.release(HostClaimVirtualService, "stage.foo.global", "ns/other-vs")
+	assert.Empty(t, detector.GetConflicts())
+
+	var nilDetector *hostConflictDetector
+	_, ok = nilDetector.claim(HostClaimIdentity, "stage.foo.global", "bar", older)
+	assert.True(t, ok)
+	assert.Empty(t, nilDetector.GetConflicts())
+}
+
+func TestHostConflictDetectorOwnerDoesNotDependOnClaimOrder(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	testCases := []struct {
+		name          string
+		claims        []string
+		since         map[string]time.Time
+		expectedOwner string
+	}{
+		{
+			name: "Given two identities claiming a host, " +
+				"When the identity with the newer workloads claims it first, " +
+				"Then the identity with the older workloads should own it",
+			claims:        []string{"bar", "foo"},
+			since:         map[string]time.Time{"foo": older, "bar": newer},
+			expectedOwner: "foo",
+		},
+		{
+			name: "Given two identities claiming a host, " +
+				"When the identity with the older workloads claims it first, " +
+				"Then the identity with the older workloads should own it",
+			claims:        []string{"foo", "bar"},
+			since:         map[string]time.Time{"foo": older, "bar": newer},
+			expectedOwner: "foo",
+		},
+		{
+			name: "Given two identities with workloads created at the same time, " +
+				"When they claim a host, " +
+				"Then the identity whose name comes first should own it",
+			claims:        []string{"foo", "bar"},
+			since:         map[string]time.Time{"foo": older, "bar": older},
+			expectedOwner: "bar",
+		},
+		{
+			name: "Given an identity whose workloads creation time is unknown, " +
+				"When it claims a host claimed by an identity whose creation time is known, " +
+				"Then the identity whose creation time is known should own it",
+			claims:        []string{"bar", "foo"},
+			since:         map[string]time.Time{"foo": newer},
+			expectedOwner: "foo",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			detector := newHostConflictDetector()
+			for _, claimant := range c.claims {
+				detector.claim(HostClaimIdentity, "stage.foo.global", claimant, c.since[claimant])
+			}
+			for _, claimant := range c.claims {
+				owner, ok := detector.claim(HostClaimIdentity, "stage.foo.global", claimant, c.since[claimant])
+				assert.Equal(t, c.expectedOwner, owner)
+				assert.Equal(t, claimant == c.expectedOwner, ok)
+			}
+			conflicts := detector.GetConflicts()
+			assert.Equal(t, 1, len(conflicts))
+			assert.Equal(t, c.expectedOwner, conflicts[0].Owner)
+		})
+	}
+}
+
+func TestGetWorkloadCreationTime(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	deployment := &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{CreationTimestamp: metaV1.NewTime(newer)}}
+	rollout := &argo.Rollout{ObjectMeta: metaV1.ObjectMeta{CreationTimestamp: metaV1.NewTime(older)}}
+
+	assert.Equal(t, older, getWorkloadCreationTime(deployment, rollout))
+	assert.Equal(t, newer, getWorkloadCreationTime(deployment, nil))
+	assert.Equal(t, older, getWorkloadCreationTime(nil, rollout))
+	assert.True(t, getWorkloadCreationTime(nil, nil).IsZero())
+}
+
+func TestGetCnameOwner(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.foo.global", "foo")
+
+	assert.Equal(t, "foo", getCnameOwner(rr, "stage.foo.global"))
+	assert.Equal(t, "foo", getCnameOwner(rr, "Stage.Foo.Global"))
+	assert.Equal(t, "", getCnameOwner(rr, "stage.bar.global"))
+	assert.Equal(t, "", getCnameOwner(nil, "stage.foo.global"))
+}
+
+func TestClaimIdentityCname(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, EnableSWAwareNSCaches: true})
+	cache := &AdmiralCache{HostConflictDetector: newHostConflictDetector(), PartitionIdentityCache: common.NewMap()}
+	cache.PartitionIdentityCache.Put("partition1.foo", "foo")
+	cache.PartitionIdentityCache.Put("partition2.foo", "foo")
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	// the partitions of an identity share its cname
+	_, ok := claimIdentityCname(cache, "stage", "stage.foo.global", "partition1.foo", older)
+	assert.True(t, ok)
+	owner, ok := claimIdentityCname(cache, "stage", "stage.foo.global", "partition2.foo", newer)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", owner)
+	assert.Empty(t, cache.HostConflictDetector.GetConflicts())
+
+	// the cname of another env is kept
+	_, ok = claimIdentityCname(cache, "qal", "qal.foo.global", "partition1.foo", older)
+	assert.True(t, ok)
+
+	// once the cname of the env changed, the previous one can be claimed by another identity
+	_, ok = claimIdentityCname(cache, "stage", "stage.foo.global", "bar", newer)
+	assert.False(t, ok)
+	_, ok = claimIdentityCname(cache, "stage", "stage.foo2.global", "partition1.foo", older)
+	assert.True(t, ok)
+	owner, ok = claimIdentityCname(cache, "stage", "stage.foo.global", "bar", newer)
+	assert.True(t, ok)
+	assert.Equal(t, "bar", owner)
+	owner, ok = claimIdentityCname(cache, "qal", "qal.foo.global", "bar", newer)
+	assert.False(t, ok)
+	assert.Equal(t, "foo", owner)
+
+	// the cnames of an identity without workloads are released
+	releaseIdentityCnames(cache, "partition2.foo")
+	_, ok = claimIdentityCname(cache, "qal", "qal.foo.global", "bar", newer)
+	assert.True(t, ok)
+}
@@ -0,0 +1,92 @@
+package clusters
+
+import (
+	"sort"
+	"sync"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/sdk"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+)
+
+// identityClientTLSSettingsCache holds the TLS settings each identity requested for its
+// DestinationRules through the admiral.io/tls-* annotations
+type identityClientTLSSettingsCache struct {
+	mutex    sync.RWMutex
+	settings map[string]*registry.ClientTLSSettings
+}
+
+func newIdentityClientTLSSettingsCache() *identityClientTLSSettingsCache {
+	return &identityClientTLSSettingsCache{
+		settings: make(map[string]*registry.ClientTLSSettings),
+	}
+}
+
+// Put records the TLS settings requested by the identity, nil settings mean the identity
+// uses ISTIO_MUTUAL
+func (c *identityClientTLSSettingsCache) Put(identity string, settings *registry.ClientTLSSettings) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if settings == nil {
+		delete(c.settings, identity)
+		return
+	}
+	c.settings[identity] = settings
+}
+
+func (c *identityClientTLSSettingsCache) Get(identity string) *registry.ClientTLSSettings {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.settings[identity]
+}
+
+// getRequestedClientTLSSettings returns the TLS settings requested through the admiral.io/tls-*
+// annotations by the deployments and rollouts of an identity. The source clusters are visited
+// in order so that the same settings are picked when the workloads disagree.
+func getRequestedClientTLSSettings(sourceDeployments map[string]*k8sAppsV1.Deployment, sourceRollouts map[string]*argo.Rollout) *registry.ClientTLSSettings {
+	clusters := make([]string, 0, len(sourceDeployments)+len(sourceRollouts))
+	for cluster := range sourceDeployments {
+		clusters = append(clusters, cluster)
+	}
+	for cluster := range sourceRollouts {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		if deployment := sourceDeployments[cluster]; deployment != nil {
+			if settings := getClientTLSSettingsFromAnnotations(deployment.Spec.Template.Annotations); settings != nil {
+				return settings
+			}
+		}
+		if rollout := sourceRollouts[cluster]; rollout != nil {
+			if settings := getClientTLSSettingsFromAnnotations(rollout.Spec.Template.Annotations); settings != nil {
+				return settings
+			}
+		}
+	}
+	return nil
+}
+
+func getClientTLSSettingsFromAnnotations(annotations map[string]string) *registry.ClientTLSSettings {
+	mode := annotations[common.AdmiralTLSModeAnnotation]
+	if mode == "" {
+		return nil
+	}
+	return &registry.ClientTLSSettings{
+		Mode:           mode,
+		Sni:            annotations[common.AdmiralTLSSniAnnotation],
+		CredentialName: annotations[common.AdmiralTLSCredentialAnnotation],
+	}
+}
+
+// getIdentityClientTLSSettings returns the TLS settings of the DestinationRules generated for
+// the identity, or nil when the identity uses ISTIO_MUTUAL
+func getIdentityClientTLSSettings(cache *AdmiralCache, identity string) *networkingV1Alpha3.ClientTLSSettings {
+	if !common.EnableIdentityClientTLSSettings() || cache == nil || cache.IdentityClientTLSSettingsCache == nil {
+		return nil
+	}
+	return sdk.BuildClientTLSSettings(cache.IdentityClientTLSSettingsCache.Get(identity))
+}
@@ -0,0 +1,102 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIdentityClientTLSSettingsCache(t *testing.T) {
+	cache := newIdentityClientTLSSettingsCache()
+	settings := &registry.ClientTLSSettings{Mode: "SIMPLE", Sni: "foo.example.com"}
+	cache.Put("identity1", settings)
+	assert.Equal(t, settings, cache.Get("identity1"))
+
+	cache.Put("identity1", nil)
+	assert.Nil(t, cache.Get("identity1"))
+}
+
+func TestGetRequestedClientTLSSettings(t *testing.T) {
+	tlsAnnotations := func(mode, sni string) map[string]string {
+		return map[string]string{
+			common.AdmiralTLSModeAnnotation:       mode,
+			common.AdmiralTLSSniAnnotation:        sni,
+			common.AdmiralTLSCredentialAnnotation: "foo-cert",
+		}
+	}
+	deploymentWithAnnotations := func(annotations map[string]string) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: annotations},
+		}}}
+	}
+	rolloutWithAnnotations := func(annotations map[string]string) *argo.Rollout {
+		return &argo.Rollout{Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: annotations},
+		}}}
+	}
+	testCases := []struct {
+		name              string
+		sourceDeployments map[string]*k8sAppsV1.Deployment
+		sourceRollouts    map[string]*argo.Rollout
+		expectedSettings  *registry.ClientTLSSettings
+	}{
+		{
+			name: "Given workloads without the tls mode annotation, " +
+				"When getRequestedClientTLSSettings is called, " +
+				"Then nil should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{
+				"cluster1": deploymentWithAnnotations(map[string]string{common.AdmiralTLSSniAnnotation: "foo.example.com"}),
+			},
+		},
+		{
+			name: "Given a rollout with the tls annotations, " +
+				"When getRequestedClientTLSSettings is called, " +
+				"Then the annotated settings should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": {}},
+			sourceRollouts:    map[string]*argo.Rollout{"cluster2": rolloutWithAnnotations(tlsAnnotations("SIMPLE", "foo.example.com"))},
+			expectedSettings:  &registry.ClientTLSSettings{Mode: "SIMPLE", Sni: "foo.example.com", CredentialName: "foo-cert"},
+		},
+		{
+			name: "Given workloads in different clusters annotated with different settings, " +
+				"When getRequestedClientTLSSettings is called, " +
+				"Then the settings of the first cluster in order should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{
+				"cluster2": deploymentWithAnnotations(tlsAnnotations("MUTUAL", "foo-2.example.com")),
+				"cluster1": deploymentWithAnnotations(tlsAnnotations("SIMPLE", "foo-1.example.com")),
+			},
+			expectedSettings: &registry.ClientTLSSettings{Mode: "SIMPLE", Sni: "foo-1.example.com", CredentialName: "foo-cert"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedSettings, getRequestedClientTLSSettings(c.sourceDeployments, c.sourceRollouts))
+		})
+	}
+}
+
+func TestGetIdentityClientTLSSettings(t *testing.T) {
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClientTLSSettingsCache.Put("foo", &registry.ClientTLSSettings{Mode: "simple", Sni: "foo.example.com"})
+	rr.AdmiralCache.IdentityClientTLSSettingsCache.Put("bar", &registry.ClientTLSSettings{Mode: "unknown"})
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	assert.Nil(t, getIdentityClientTLSSettings(rr.AdmiralCache, "foo"))
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EnableIdentityClientTLSSettings: true})
+	assert.Equal(t, &networkingV1Alpha3.ClientTLSSettings{
+		Mode: networkingV1Alpha3.ClientTLSSettings_SIMPLE,
+		Sni:  "foo.example.com",
+	}, getIdentityClientTLSSettings(rr.AdmiralCache, "foo"))
+	assert.Nil(t, getIdentityClientTLSSettings(rr.AdmiralCache, "bar"))
+	assert.Nil(t, getIdentityClientTLSSettings(rr.AdmiralCache, "baz"))
+}
@@ -0,0 +1,159 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/notifier"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+// IdentityLockHolder is the processing of an identity holding its lock, along with the number of
+// processings of the same identity waiting for it
+type IdentityLockHolder struct {
+	Identity   string    `json:"identity"`
+	TxId       string    `json:"txId,omitempty"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Event      string    `json:"event,omitempty"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	Waiters    int       `json:"waiters"`
+}
+
+// identityLock serializes the processing of an identity. refs counts the holder and the waiters,
+// so that the lock is dropped once nobody needs it
+type identityLock struct {
+	mutex    sync.Mutex
+	refs     int
+	holder   *IdentityLockHolder
+	reported bool
+}
+
+// identityLockRegistry keeps the locks of the identities being processed, by identity
+type identityLockRegistry struct {
+	lock  sync.Mutex
+	locks map[string]*identityLock
+}
+
+var identityLocks = &identityLockRegistry{locks: make(map[string]*identityLock)}
+
+// lockIdentity waits until no other processing of the identity holds its lock and takes it, recording the
+// time waited along with the transaction holding it. The returned function releases the lock. The
+// processings of an identity are not serialized unless the identity lock is enabled
+func lockIdentity(ctx context.Context, identity string) func() {
+	if !common.EnableIdentityLock() {
+		return func() {}
+	}
+	key := strings.ToLower(identity)
+	identityLocks.lock.Lock()
+	l := identityLocks.locks[key]
+	if l == nil {
+		l = &identityLock{}
+		identityLocks.locks[key] = l
+	}
+	l.refs++
+	identityLocks.lock.Unlock()
+
+	start := time.Now()
+	l.mutex.Lock()
+	identityLockWaitTime.Record(time.Since(start).Seconds(), api.WithAttributes(
+		attribute.Key("identity").String(key),
+	))
+	holder := &IdentityLockHolder{Identity: key, TxId: common.GetTxId(ctx), AcquiredAt: time.Now()}
+	if cluster, ok := ctx.Value(common.ClusterName).(string); ok {
+		holder.Cluster = cluster
+	}
+	if event := ctx.Value(common.EventType); event != nil {
+		holder.Event = fmt.Sprint(event)
+	}
+	identityLocks.lock.Lock()
+	l.holder, l.reported = holder, false
+	identityLocks.lock.Unlock()
+
+	return func() {
+		identityLocks.lock.Lock()
+		l.holder = nil
+		l.refs--
+		if l.refs == 0 {
+			delete(identityLocks.locks, key)
+		}
+		identityLocks.lock.Unlock()
+		l.mutex.Unlock()
+	}
+}
+
+// GetIdentityLockHolders returns the processings holding the lock of an identity, the longest held first
+func GetIdentityLockHolders() []IdentityLockHolder {
+	identityLocks.lock.Lock()
+	defer identityLocks.lock.Unlock()
+	holders := make([]IdentityLockHolder, 0)
+	for _, l := range identityLocks.locks {
+		if l.holder == nil {
+			continue
+		}
+		holder := *l.holder
+		holder.Waiters = l.refs - 1
+		holders = append(holders, holder)
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		return holders[i].AcquiredAt.Before(holders[j].AcquiredAt)
+	})
+	return holders
+}
+
+// startIdentityLockWatchdog periodically reports the identity locks held beyond the hold threshold
+func startIdentityLockWatchdog(ctx context.Context) {
+	threshold := common.GetIdentityLockHoldThreshold()
+	if !common.EnableIdentityLock() || threshold <= 0 {
+		return
+	}
+	ticker := time.NewTicker(threshold / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reportStuckIdentityLocks(threshold)
+		}
+	}
+}
+
+// reportStuckIdentityLocks logs, counts and sends a notification for every identity lock held beyond the
+// threshold. A lock is reported once per holder
+func reportStuckIdentityLocks(threshold time.Duration) []IdentityLockHolder {
+	var stuck []IdentityLockHolder
+	identityLocks.lock.Lock()
+	for _, l := range identityLocks.locks {
+		if l.holder == nil || l.reported || time.Since(l.holder.AcquiredAt) < threshold {
+			continue
+		}
+		l.reported = true
+		holder := *l.holder
+		holder.Waiters = l.refs - 1
+		stuck = append(stuck, holder)
+	}
+	identityLocks.lock.Unlock()
+
+	for _, holder := range stuck {
+		stuckIdentityLocks.Increment(api.WithAttributes(
+			attribute.Key("identity").String(holder.Identity),
+		))
+		message := fmt.Sprintf("lock of identity %s held for %s by txId=%s cluster=%s event=%s, %d processing(s) waiting",
+			holder.Identity, time.Since(holder.AcquiredAt).Round(time.Second), holder.TxId, holder.Cluster, holder.Event, holder.Waiters)
+		log.Warnf(common.CtxLogFormat, common.IdentityLockWatchdog, holder.Identity, "", holder.Cluster, message)
+		notifier.Send(notifier.Notification{
+			Key:      "stuck-identity-lock/" + holder.Identity,
+			Severity: notifier.SeverityWarning,
+			Title:    "Identity lock held too long",
+			Message:  message,
+		})
+	}
+	return stuck
+}
@@ -0,0 +1,75 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityLock(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EnableIdentityLock: true})
+	ctx := context.WithValue(context.Background(), common.ClusterName, "cluster1")
+	ctx = common.WithTxId(ctx, "tx1")
+
+	unlock := lockIdentity(ctx, "Foo")
+	holders := GetIdentityLockHolders()
+	assert.Len(t, holders, 1)
+	assert.Equal(t, "foo", holders[0].Identity)
+	assert.Equal(t, "tx1", holders[0].TxId)
+	assert.Equal(t, "cluster1", holders[0].Cluster)
+	assert.Equal(t, 0, holders[0].Waiters)
+
+	// a second processing of the identity waits for the first one
+	acquired := make(chan struct{})
+	go func() {
+		defer lockIdentity(context.Background(), "foo")()
+		close(acquired)
+	}()
+	assert.Eventually(t, func() bool {
+		holders := GetIdentityLockHolders()
+		return len(holders) == 1 && holders[0].Waiters == 1
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case <-acquired:
+		t.Fatal("lock of the identity acquired while held")
+	default:
+	}
+
+	// the lock held beyond the threshold is reported once
+	assert.Len(t, reportStuckIdentityLocks(0), 1)
+	assert.Empty(t, reportStuckIdentityLocks(0))
+	assert.Empty(t, reportStuckIdentityLocks(time.Hour))
+
+	unlock()
+	<-acquired
+	assert.Eventually(t, func() bool {
+		return len(GetIdentityLockHolders()) == 0
+	}, time.Second, 10*time.Millisecond)
+	identityLocks.lock.Lock()
+	assert.Empty(t, identityLocks.locks)
+	identityLocks.lock.Unlock()
+}
+
+func TestIdentityLockDisabled(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+
+	// the processings of an identity run concurrently unless the identity lock is enabled
+	unlock := lockIdentity(context.Background(), "foo")
+	defer unlock()
+	acquired := make(chan struct{})
+	go func() {
+		defer lockIdentity(context.Background(), "foo")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("processing of the identity waited while the identity lock is disabled")
+	}
+	assert.Empty(t, GetIdentityLockHolders())
+}
@@ -0,0 +1,139 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+// IdentitySnapshot is the complete state admiral currently wants for an identity: the hosts generated for it,
+// the resources generated for those hosts in each cluster, the clusters depending on each host and the traffic
+// policies applied to it by env
+type IdentitySnapshot struct {
+	Identity                string                                 `json:"identity"`
+	Clusters                []string                               `json:"clusters"`
+	Envs                    []string                               `json:"envs"`
+	Regions                 []string                               `json:"regions"`
+	Dependents              []string                               `json:"dependents"`
+	SyncNamespace           string                                 `json:"syncNamespace,omitempty"`
+	Hosts                   []string                               `json:"hosts"`
+	DependentClusters       map[string][]string                    `json:"dependentClusters"`
+	ServiceEntries          map[string][]*v1alpha3.ServiceEntry    `json:"serviceEntries"`
+	DestinationRules        map[string][]*v1alpha3.DestinationRule `json:"destinationRules"`
+	VirtualServices         map[string][]*v1alpha3.VirtualService  `json:"virtualServices"`
+	GlobalTrafficPolicies   map[string]*v1.GlobalTrafficPolicy     `json:"globalTrafficPolicies"`
+	OutlierDetections       map[string]*v1.OutlierDetection        `json:"outlierDetections"`
+	ClientConnectionConfigs map[string]*v1.ClientConnectionConfig  `json:"clientConnectionConfigs"`
+	RoutingPolicies         []*v1.RoutingPolicy                    `json:"routingPolicies"`
+}
+
+// GetIdentitySnapshot assembles the state admiral currently wants for the identity from its caches and the caches
+// of the clusters it watches. The resources are the ones admiral generated for the hosts of the identity, keyed by
+// cluster, and the traffic policies are keyed by env. An error is returned when admiral knows nothing of the identity
+func GetIdentitySnapshot(rr *RemoteRegistry, identity string) (IdentitySnapshot, error) {
+	state := GetIdentityState(rr, identity)
+	snapshot := IdentitySnapshot{
+		Identity:                identity,
+		Clusters:                state.Clusters,
+		Envs:                    state.Envs,
+		Regions:                 state.Regions,
+		Dependents:              state.Dependents,
+		SyncNamespace:           state.SyncNamespace,
+		Hosts:                   make([]string, 0),
+		DependentClusters:       make(map[string][]string),
+		ServiceEntries:          make(map[string][]*v1alpha3.ServiceEntry),
+		DestinationRules:        make(map[string][]*v1alpha3.DestinationRule),
+		VirtualServices:         make(map[string][]*v1alpha3.VirtualService),
+		GlobalTrafficPolicies:   make(map[string]*v1.GlobalTrafficPolicy),
+		OutlierDetections:       make(map[string]*v1.OutlierDetection),
+		ClientConnectionConfigs: make(map[string]*v1.ClientConnectionConfig),
+		RoutingPolicies:         make([]*v1.RoutingPolicy, 0),
+	}
+	if rr != nil && rr.AdmiralCache != nil {
+		if hosts := getIdentityHosts(rr.AdmiralCache, identity, ""); hosts != nil {
+			snapshot.Hosts = hosts
+		}
+	}
+	if len(snapshot.Clusters) == 0 && len(snapshot.Hosts) == 0 {
+		return snapshot, fmt.Errorf("identity %s was not found", identity)
+	}
+	for _, host := range snapshot.Hosts {
+		snapshot.DependentClusters[host] = GetDependentClusters(rr, host)
+	}
+	clusterIDs := rr.GetClusterIds()
+	sort.Strings(clusterIDs)
+	for _, clusterID := range clusterIDs {
+		addClusterResourcesToSnapshot(&snapshot, rr.GetRemoteController(clusterID), clusterID)
+	}
+	addTrafficPoliciesToSnapshot(&snapshot, rr.AdmiralCache, identity)
+	return snapshot, nil
+}
+
+// addClusterResourcesToSnapshot adds the service entries, the default destination rules and the routing
+// virtual services the cluster caches hold for the hosts of the snapshot
+func addClusterResourcesToSnapshot(snapshot *IdentitySnapshot, rc *RemoteController, clusterID string) {
+	if rc == nil {
+		return
+	}
+	namespace := snapshot.SyncNamespace
+	if namespace == "" {
+		namespace = common.GetSyncNamespaceForCluster(clusterID)
+	}
+	for _, host := range snapshot.Hosts {
+		if rc.ServiceEntryController != nil && rc.ServiceEntryController.Cache != nil {
+			if se := rc.ServiceEntryController.Cache.Get(getIstioResourceName(host, "-se"), clusterID); se != nil {
+				snapshot.ServiceEntries[clusterID] = append(snapshot.ServiceEntries[clusterID], se)
+			}
+		}
+		if rc.DestinationRuleController != nil && rc.DestinationRuleController.Cache != nil {
+			if dr := rc.DestinationRuleController.Cache.Get(getIstioResourceName(host, "-default-dr"), namespace); dr != nil {
+				snapshot.DestinationRules[clusterID] = append(snapshot.DestinationRules[clusterID], dr)
+			}
+		}
+		if rc.VirtualServiceController != nil && rc.VirtualServiceController.VirtualServiceCache != nil {
+			for _, suffix := range []string{"-routing-vs", "-" + common.InclusterVSNameSuffix} {
+				if vs := rc.VirtualServiceController.VirtualServiceCache.Get(getIstioResourceName(host, suffix)); vs != nil {
+					snapshot.VirtualServices[clusterID] = append(snapshot.VirtualServices[clusterID], vs)
+				}
+			}
+		}
+	}
+}
+
+// addTrafficPoliciesToSnapshot adds the global traffic policies, the outlier detections and the client
+// connection configs applied to each env of the identity, along with its routing policies
+func addTrafficPoliciesToSnapshot(snapshot *IdentitySnapshot, cache *AdmiralCache, identity string) {
+	if cache == nil {
+		return
+	}
+	envs := snapshot.Envs
+	if !common.IsPresent(envs, common.Default) {
+		envs = append([]string{common.Default}, envs...)
+	}
+	for _, env := range envs {
+		if cache.GlobalTrafficCache != nil {
+			if gtp, err := cache.GlobalTrafficCache.GetFromIdentity(identity, env); err == nil && gtp != nil {
+				snapshot.GlobalTrafficPolicies[env] = gtp
+			}
+		}
+		if cache.OutlierDetectionCache != nil {
+			if od, err := cache.OutlierDetectionCache.GetFromIdentity(identity, env); err == nil && od != nil {
+				snapshot.OutlierDetections[env] = od
+			}
+		}
+		if cache.ClientConnectionConfigCache != nil {
+			if ccc, err := cache.ClientConnectionConfigCache.GetFromIdentity(identity, env); err == nil && ccc != nil {
+				snapshot.ClientConnectionConfigs[env] = ccc
+			}
+		}
+	}
+	if cache.RoutingPolicyCache != nil {
+		snapshot.RoutingPolicies = cache.RoutingPolicyCache.GetForIdentity(identity)
+		sort.Slice(snapshot.RoutingPolicies, func(i, j int) bool {
+			return snapshot.RoutingPolicies[i].Name < snapshot.RoutingPolicies[j].Name
+		})
+	}
+}
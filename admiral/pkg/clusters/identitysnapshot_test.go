@@ -0,0 +1,62 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetIdentitySnapshot(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{WorkloadIdentityKey: "identity", AdmiralCRDIdentityLabel: "identity", EnvKey: "admiral.io/env"},
+		SyncNamespace: "ns",
+	})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.CnameIdentityCache.Store("qa.foo.global", "foo")
+	rr.AdmiralCache.CnameIdentityCache.Store("qa.bar.global", "bar")
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameDependentClusterCache.Put("qa.foo.global", "cluster2", "cluster2")
+	gtp := &v1.GlobalTrafficPolicy{ObjectMeta: metaV1.ObjectMeta{Name: "foo-gtp", Labels: map[string]string{"identity": "foo"}}}
+	assert.Nil(t, rr.AdmiralCache.GlobalTrafficCache.Put(gtp))
+
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "qa.foo.global-se", Namespace: "ns"},
+		Spec:       networking.ServiceEntry{Hosts: []string{"qa.foo.global"}},
+	}
+	dr := &v1alpha3.DestinationRule{
+		ObjectMeta: metaV1.ObjectMeta{Name: "qa.foo.global-default-dr", Namespace: "ns"},
+		Spec:       networking.DestinationRule{Host: "qa.foo.global"},
+	}
+	for _, cluster := range []string{"cluster1", "cluster2"} {
+		seCache := istio.NewServiceEntryCache()
+		seCache.Put(se, cluster)
+		drCache := istio.NewDestinationRuleCache()
+		drCache.Put(dr)
+		rr.PutRemoteController(cluster, &RemoteController{
+			ClusterID:                 cluster,
+			ServiceEntryController:    &istio.ServiceEntryController{Cache: seCache},
+			DestinationRuleController: &istio.DestinationRuleController{Cache: drCache},
+		})
+	}
+
+	snapshot, err := GetIdentitySnapshot(rr, "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cluster1"}, snapshot.Clusters)
+	assert.Equal(t, []string{"qa.foo.global"}, snapshot.Hosts)
+	assert.Equal(t, map[string][]string{"qa.foo.global": {"cluster2"}}, snapshot.DependentClusters)
+	assert.Equal(t, map[string][]*v1alpha3.ServiceEntry{"cluster1": {se}, "cluster2": {se}}, snapshot.ServiceEntries)
+	assert.Equal(t, map[string][]*v1alpha3.DestinationRule{"cluster1": {dr}, "cluster2": {dr}}, snapshot.DestinationRules)
+	assert.Empty(t, snapshot.VirtualServices)
+	assert.Equal(t, map[string]*v1.GlobalTrafficPolicy{common.Default: gtp}, snapshot.GlobalTrafficPolicies)
+
+	_, err = GetIdentitySnapshot(rr, "unknown")
+	assert.NotNil(t, err)
+}
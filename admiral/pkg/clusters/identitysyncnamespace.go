@@ -0,0 +1,233 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/sirupsen/logrus"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// identitySyncNamespaceCache holds the sync namespace each identity requested through the
+// admiral.io/sync-namespace annotation. The namespaces an identity requested before are found
+// on the clusters, from the labels of the resources admiral wrote to them
+type identitySyncNamespaceCache struct {
+	mutex   sync.RWMutex
+	current map[string]string
+}
+
+func newIdentitySyncNamespaceCache() *identitySyncNamespaceCache {
+	return &identitySyncNamespaceCache{
+		current: make(map[string]string),
+	}
+}
+
+// Put records the namespace requested by the identity, an empty namespace means the identity
+// uses the sync namespace
+func (c *identitySyncNamespaceCache) Put(identity, namespace string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if namespace == "" {
+		delete(c.current, identity)
+		return
+	}
+	c.current[identity] = namespace
+}
+
+func (c *identitySyncNamespaceCache) Get(identity string) string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.current[identity]
+}
+
+// GetSyncNamespaces returns the namespaces requested by the identities, sorted
+func (c *identitySyncNamespaceCache) GetSyncNamespaces() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	namespaces := make([]string, 0, len(c.current))
+	for _, namespace := range c.current {
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// putIdentitySyncNamespace records the namespace requested by the identity. A namespace which is not
+// allowed is ignored, the identity uses the sync namespace instead
+func putIdentitySyncNamespace(cache *AdmiralCache, identity, namespace string) {
+	if cache == nil || cache.IdentitySyncNamespaceCache == nil {
+		return
+	}
+	if namespace != "" && !common.IsIdentitySyncNamespaceAllowed(namespace) {
+		logrus.Warnf(LogFormat, "Validate", common.AdmiralSyncNamespaceAnnotation, identity, "",
+			"ignored the sync namespace="+namespace+" as it is not in the allowed identity sync namespaces")
+		namespace = ""
+	}
+	cache.IdentitySyncNamespaceCache.Put(identity, namespace)
+}
+
+// getRequestedSyncNamespace returns the namespace requested through the admiral.io/sync-namespace
+// annotation by the deployments and rollouts of an identity. The source clusters are visited
+// in order so that the same namespace is picked when the workloads disagree.
+func getRequestedSyncNamespace(sourceDeployments map[string]*k8sAppsV1.Deployment, sourceRollouts map[string]*argo.Rollout) string {
+	clusters := make([]string, 0, len(sourceDeployments)+len(sourceRollouts))
+	for cluster := range sourceDeployments {
+		clusters = append(clusters, cluster)
+	}
+	for cluster := range sourceRollouts {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		if deployment := sourceDeployments[cluster]; deployment != nil {
+			if namespace := deployment.Spec.Template.Annotations[common.AdmiralSyncNamespaceAnnotation]; namespace != "" {
+				return namespace
+			}
+		}
+		if rollout := sourceRollouts[cluster]; rollout != nil {
+			if namespace := rollout.Spec.Template.Annotations[common.AdmiralSyncNamespaceAnnotation]; namespace != "" {
+				return namespace
+			}
+		}
+	}
+	return ""
+}
+
+// getIdentitySyncNamespace returns the namespace the identity requested its generated
+// resources to be written to, or an empty string when it uses the sync namespace
+func getIdentitySyncNamespace(cache *AdmiralCache, identity string) string {
+	if !common.EnableIdentitySyncNamespace() || cache == nil || cache.IdentitySyncNamespaceCache == nil {
+		return ""
+	}
+	return cache.IdentitySyncNamespaceCache.Get(identity)
+}
+
+// getIdentitySyncNamespacesForCluster returns the namespaces of the cluster which may hold the resources
+// generated for the identity, which are the sync namespace of the cluster and the namespace the identity
+// requested, if any
+func getIdentitySyncNamespacesForCluster(cache *AdmiralCache, identity, cluster string) []string {
+	namespaces := []string{common.GetSyncNamespaceForCluster(cluster)}
+	if namespace := getIdentitySyncNamespace(cache, identity); namespace != "" && namespace != namespaces[0] {
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// getSyncNamespacesForCluster returns the namespaces of the cluster admiral writes generated resources to,
+// which are the sync namespace of the cluster and the namespaces requested by the identities
+func getSyncNamespacesForCluster(cache *AdmiralCache, cluster string) []string {
+	namespaces := []string{common.GetSyncNamespaceForCluster(cluster)}
+	if !common.EnableIdentitySyncNamespace() || cache == nil || cache.IdentitySyncNamespaceCache == nil {
+		return namespaces
+	}
+	for _, namespace := range cache.IdentitySyncNamespaceCache.GetSyncNamespaces() {
+		if namespace != namespaces[0] {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// isIdentitySyncNamespace returns true when identities are allowed to request the namespace as their sync namespace
+func isIdentitySyncNamespace(namespace string) bool {
+	return common.EnableIdentitySyncNamespace() && common.IsIdentitySyncNamespaceAllowed(namespace)
+}
+
+// syncNamespaceCopyListOptions selects the resources of the name admiral wrote to a sync namespace, of the
+// cluster or of an identity, in any namespace
+func syncNamespaceCopyListOptions(name string) v12.ListOptions {
+	return v12.ListOptions{
+		LabelSelector: common.AdmiralSyncNamespaceOfLabel,
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+	}
+}
+
+// getPreviousSyncNamespaces returns the namespaces other than syncNamespace holding one of the copies admiral
+// wrote to a sync namespace. The operator sync namespace is written to by another admiral, it is skipped
+func getPreviousSyncNamespaces(copies []v12.Object, name, syncNamespace string) []string {
+	var namespaces []string
+	for _, copyOfResource := range copies {
+		namespace := copyOfResource.GetNamespace()
+		if copyOfResource.GetName() != name || namespace == syncNamespace || namespace == common.GetOperatorSyncNamespace() ||
+			!isGeneratedByAdmiral(copyOfResource.GetAnnotations()) || slices.Contains(namespaces, namespace) {
+			continue
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// getPreviousServiceEntrySyncNamespaces returns the sync namespaces other than syncNamespace the ServiceEntry
+// was written to, e.g. before its identity requested another sync namespace
+func getPreviousServiceEntrySyncNamespaces(ctx context.Context, rc *RemoteController, name, syncNamespace string) ([]string, error) {
+	seList, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(v12.NamespaceAll).List(ctx, syncNamespaceCopyListOptions(name))
+	if err != nil {
+		return nil, err
+	}
+	copies := make([]v12.Object, 0, len(seList.Items))
+	for _, se := range seList.Items {
+		copies = append(copies, se)
+	}
+	return getPreviousSyncNamespaces(copies, name, syncNamespace), nil
+}
+
+// deletePreviousVirtualServiceCopies deletes the copies of the source VirtualService admiral wrote to sync
+// namespaces other than syncNamespace, e.g. before its identity requested another sync namespace
+func deletePreviousVirtualServiceCopies(ctx context.Context, ctxLogger *logrus.Entry, rc *RemoteController, name, syncNamespace, source string) {
+	if !common.EnableIdentitySyncNamespace() {
+		return
+	}
+	vsList, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(v12.NamespaceAll).List(ctx, syncNamespaceCopyListOptions(name))
+	if err != nil {
+		ctxLogger.Warnf(LogErrFormat, "Delete", common.VirtualServiceResourceType, name, rc.ClusterID, "failed to look up the copies in the previous sync namespaces: "+err.Error())
+		return
+	}
+	copies := make([]v12.Object, 0, len(vsList.Items))
+	for _, vs := range vsList.Items {
+		if isVirtualServiceNameCollision(vs, source) {
+			continue
+		}
+		copies = append(copies, vs)
+	}
+	for _, namespace := range getPreviousSyncNamespaces(copies, name, syncNamespace) {
+		if err = deleteVirtualService(ctx, name, namespace, rc); err != nil {
+			ctxLogger.Warnf(LogErrFormat, "Delete", common.VirtualServiceResourceType, name, rc.ClusterID, err)
+			continue
+		}
+		ctxLogger.Infof(LogFormat, "Delete", common.VirtualServiceResourceType, name, rc.ClusterID, "deleted the copy left behind in the previous sync namespace="+namespace)
+	}
+}
+
+// deleteStaleIdentitySyncNamespaceResources deletes the admiral generated ServiceEntry and
+// DestinationRule of the seDr from the namespaces the identity no longer syncs to
+func deleteStaleIdentitySyncNamespaceResources(ctxLogger *logrus.Entry, ctx context.Context, rc *RemoteController, seDr *SeDrTuple, staleNamespaces []string) error {
+	var err error
+	for _, namespace := range staleNamespaces {
+		se, getErr := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Get(ctx, seDr.SeName, v12.GetOptions{})
+		if getErr != nil && !k8sErrors.IsNotFound(getErr) {
+			err = common.AppendError(err, fmt.Errorf("failed to get ServiceEntry %s in namespace %s: %w", seDr.SeName, namespace, getErr))
+		} else if getErr == nil && isGeneratedByAdmiral(se.Annotations) {
+			ctxLogger.Infof(common.CtxLogFormat, "DeleteStaleIdentitySyncNamespaceResources", seDr.SeName, namespace, rc.ClusterID, "deleting ServiceEntry left behind in previous sync namespace")
+			err = common.AppendError(err, deleteServiceEntry(ctx, se, namespace, rc))
+		}
+		dr, getErr := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Get(ctx, seDr.DrName, v12.GetOptions{})
+		if getErr != nil && !k8sErrors.IsNotFound(getErr) {
+			err = common.AppendError(err, fmt.Errorf("failed to get DestinationRule %s in namespace %s: %w", seDr.DrName, namespace, getErr))
+		} else if getErr == nil && isGeneratedByAdmiral(dr.Annotations) {
+			ctxLogger.Infof(common.CtxLogFormat, "DeleteStaleIdentitySyncNamespaceResources", seDr.DrName, namespace, rc.ClusterID, "deleting DestinationRule left behind in previous sync namespace")
+			err = common.AppendError(err, deleteDestinationRule(ctx, dr, namespace, rc))
+		}
+	}
+	return err
+}
@@ -0,0 +1,219 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIdentitySyncNamespaceCache(t *testing.T) {
+	cache := newIdentitySyncNamespaceCache()
+	cache.Put("identity1", "team-sync")
+	assert.Equal(t, "team-sync", cache.Get("identity1"))
+	assert.Equal(t, []string{"team-sync"}, cache.GetSyncNamespaces())
+
+	cache.Put("identity1", "")
+	assert.Equal(t, "", cache.Get("identity1"))
+	assert.Empty(t, cache.GetSyncNamespaces())
+}
+
+func TestPutIdentitySyncNamespace(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "admiral-sync",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	})
+	cache := &AdmiralCache{IdentitySyncNamespaceCache: newIdentitySyncNamespaceCache()}
+
+	putIdentitySyncNamespace(cache, "identity1", "team-sync")
+	assert.Equal(t, "team-sync", getIdentitySyncNamespace(cache, "identity1"))
+
+	// a namespace which is not allowed, e.g. the namespace of another team, is ignored
+	putIdentitySyncNamespace(cache, "identity1", "kube-system")
+	assert.Equal(t, "", getIdentitySyncNamespace(cache, "identity1"))
+
+	assert.True(t, isIdentitySyncNamespace("team-sync"))
+	assert.False(t, isIdentitySyncNamespace("kube-system"))
+}
+
+func TestGetRequestedSyncNamespace(t *testing.T) {
+	deploymentWithAnnotation := func(namespace string) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.AdmiralSyncNamespaceAnnotation: namespace}},
+		}}}
+	}
+	rolloutWithAnnotation := func(namespace string) *argo.Rollout {
+		return &argo.Rollout{Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.AdmiralSyncNamespaceAnnotation: namespace}},
+		}}}
+	}
+	testCases := []struct {
+		name              string
+		sourceDeployments map[string]*k8sAppsV1.Deployment
+		sourceRollouts    map[string]*argo.Rollout
+		expectedNamespace string
+	}{
+		{
+			name: "Given workloads without the sync namespace annotation, " +
+				"When getRequestedSyncNamespace is called, " +
+				"Then an empty namespace should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": {}},
+			expectedNamespace: "",
+		},
+		{
+			name: "Given a rollout with the sync namespace annotation, " +
+				"When getRequestedSyncNamespace is called, " +
+				"Then the annotated namespace should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": {}},
+			sourceRollouts:    map[string]*argo.Rollout{"cluster2": rolloutWithAnnotation("team-sync")},
+			expectedNamespace: "team-sync",
+		},
+		{
+			name: "Given workloads in different clusters annotated with different namespaces, " +
+				"When getRequestedSyncNamespace is called, " +
+				"Then the namespace of the first cluster in order should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{
+				"cluster2": deploymentWithAnnotation("team-sync-2"),
+				"cluster1": deploymentWithAnnotation("team-sync-1"),
+			},
+			expectedNamespace: "team-sync-1",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedNamespace, getRequestedSyncNamespace(c.sourceDeployments, c.sourceRollouts))
+		})
+	}
+}
+
+func TestGetPreviousServiceEntrySyncNamespaces(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "admiral-sync",
+		OperatorSyncNamespace:         "operator-sync",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync", "other-team-sync"},
+	})
+	serviceEntry := func(namespace string, labeled bool) *v1alpha3.ServiceEntry {
+		se := &v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "test.foo.global-se",
+			Namespace:   namespace,
+			Annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+		}}
+		if labeled {
+			stampSyncNamespaceLabel(se, "cluster1", namespace)
+		}
+		return se
+	}
+	istioClient := istioFake.NewSimpleClientset(
+		serviceEntry("admiral-sync", true),
+		serviceEntry("team-sync", true),
+		serviceEntry("other-team-sync", true),
+		// not written to a sync namespace, or by another admiral
+		serviceEntry("foo-ns", false),
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "test.foo.global-se",
+			Namespace:   "operator-sync",
+			Labels:      map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
+			Annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+		}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+			Name:      "test.bar.global-se",
+			Namespace: "admiral-sync",
+			Labels:    map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
+		}},
+	)
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	ctx := context.Background()
+
+	// the namespaces are found on the cluster, after a restart as well
+	namespaces, err := getPreviousServiceEntrySyncNamespaces(ctx, rc, "test.foo.global-se", "team-sync")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admiral-sync", "other-team-sync"}, namespaces)
+
+	namespaces, err = getPreviousServiceEntrySyncNamespaces(ctx, rc, "test.foo.global-se", "admiral-sync")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other-team-sync", "team-sync"}, namespaces)
+}
+
+func TestDeletePreviousVirtualServiceCopies(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "admiral-sync",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	})
+	virtualService := func(namespace, source string) *v1alpha3.VirtualService {
+		vs := &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{
+			Name:      "foo-ns-foo-vs",
+			Namespace: namespace,
+			Annotations: map[string]string{
+				resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue,
+				common.AdmiralSourceVSAnnotation: source,
+			},
+		}}
+		stampSyncNamespaceLabel(vs, "cluster1", namespace)
+		return vs
+	}
+	istioClient := istioFake.NewSimpleClientset(virtualService("admiral-sync", "foo-ns/foo-vs"), virtualService("team-sync", "foo-ns/foo-vs"))
+	rc := &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	ctx := context.Background()
+
+	// the copy of another source is kept
+	deletePreviousVirtualServiceCopies(ctx, logrus.WithFields(logrus.Fields{}), rc, "foo-ns-foo-vs", "team-sync", "bar-ns/foo-vs")
+	_, err := istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").Get(ctx, "foo-ns-foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+
+	deletePreviousVirtualServiceCopies(ctx, logrus.WithFields(logrus.Fields{}), rc, "foo-ns-foo-vs", "team-sync", "foo-ns/foo-vs")
+	_, err = istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").Get(ctx, "foo-ns-foo-vs", metaV1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = istioClient.NetworkingV1alpha3().VirtualServices("team-sync").Get(ctx, "foo-ns-foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+}
+
+func TestDeleteStaleIdentitySyncNamespaceResources(t *testing.T) {
+	admiralAnnotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "test.foo.global-se", Namespace: "admiral-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "test.foo.global-se", Namespace: "team-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{Name: "test.foo.global-default-dr", Namespace: "admiral-sync"}},
+	)
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient},
+	}
+	ctx := context.Background()
+	seDr := &SeDrTuple{SeName: "test.foo.global-se", DrName: "test.foo.global-default-dr"}
+
+	err := deleteStaleIdentitySyncNamespaceResources(logrus.WithFields(logrus.Fields{}), ctx, rc, seDr, []string{"admiral-sync"})
+	assert.Nil(t, err)
+
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, seDr.SeName, metaV1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("team-sync").Get(ctx, seDr.SeName, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	// DestinationRules not generated by admiral are left as is
+	_, err = istioClient.NetworkingV1alpha3().DestinationRules("admiral-sync").Get(ctx, seDr.DrName, metaV1.GetOptions{})
+	assert.Nil(t, err)
+}
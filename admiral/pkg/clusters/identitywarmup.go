@@ -0,0 +1,111 @@
+package clusters
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+)
+
+// identityWarmupDurationCache holds the warmup duration, in seconds, each identity requested for
+// the DestinationRules of each of its envs through the admiral.io/warmup-duration annotation
+type identityWarmupDurationCache struct {
+	mutex     sync.RWMutex
+	durations map[string]map[string]int64
+}
+
+func newIdentityWarmupDurationCache() *identityWarmupDurationCache {
+	return &identityWarmupDurationCache{
+		durations: make(map[string]map[string]int64),
+	}
+}
+
+// Put records the warmup duration requested by the identity for the env, a nil duration means
+// the env uses the default warmup duration
+func (c *identityWarmupDurationCache) Put(identity, env string, warmupDurationSecs *int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if warmupDurationSecs == nil {
+		delete(c.durations[identity], env)
+		if len(c.durations[identity]) == 0 {
+			delete(c.durations, identity)
+		}
+		return
+	}
+	if c.durations[identity] == nil {
+		c.durations[identity] = make(map[string]int64)
+	}
+	c.durations[identity][env] = *warmupDurationSecs
+}
+
+func (c *identityWarmupDurationCache) Get(identity, env string) *int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	warmupDurationSecs, ok := c.durations[identity][env]
+	if !ok {
+		return nil
+	}
+	return &warmupDurationSecs
+}
+
+// getRequestedWarmupDuration returns the warmup duration in seconds requested through the
+// admiral.io/warmup-duration annotation by the deployments and rollouts of an identity in an env.
+// The source clusters are visited in order so that the same duration is picked when the
+// workloads disagree.
+func getRequestedWarmupDuration(sourceDeployments map[string]*k8sAppsV1.Deployment, sourceRollouts map[string]*argo.Rollout) *int64 {
+	clusters := make([]string, 0, len(sourceDeployments)+len(sourceRollouts))
+	for cluster := range sourceDeployments {
+		clusters = append(clusters, cluster)
+	}
+	for cluster := range sourceRollouts {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		if deployment := sourceDeployments[cluster]; deployment != nil {
+			if warmupDurationSecs := getWarmupDurationFromAnnotations(deployment.Spec.Template.Annotations); warmupDurationSecs != nil {
+				return warmupDurationSecs
+			}
+		}
+		if rollout := sourceRollouts[cluster]; rollout != nil {
+			if warmupDurationSecs := getWarmupDurationFromAnnotations(rollout.Spec.Template.Annotations); warmupDurationSecs != nil {
+				return warmupDurationSecs
+			}
+		}
+	}
+	return nil
+}
+
+// getWarmupDurationFromAnnotations parses the warmup duration annotation, e.g. 90s or 2m. A duration
+// of 0s disables the slow start of the new endpoints
+func getWarmupDurationFromAnnotations(annotations map[string]string) *int64 {
+	value, ok := annotations[common.AdmiralWarmupDurationAnnotation]
+	if !ok {
+		return nil
+	}
+	warmupDuration, err := time.ParseDuration(value)
+	if err != nil || warmupDuration < 0 {
+		log.Warnf(LogFormat, "Parse", common.AdmiralWarmupDurationAnnotation, value, "", "skipped as it is not a positive duration")
+		return nil
+	}
+	warmupDurationSecs := int64(warmupDuration / time.Second)
+	return &warmupDurationSecs
+}
+
+// getIdentityWarmupDuration returns the warmup duration of the DestinationRules generated for
+// the env of the identity, or nil when the env uses the default warmup duration
+func getIdentityWarmupDuration(cache *AdmiralCache, identity, env string) *duration.Duration {
+	if !common.EnableIdentityWarmupDuration() || cache == nil || cache.IdentityWarmupDurationCache == nil {
+		return nil
+	}
+	warmupDurationSecs := cache.IdentityWarmupDurationCache.Get(identity, env)
+	if warmupDurationSecs == nil {
+		return nil
+	}
+	return &duration.Duration{Seconds: *warmupDurationSecs}
+}
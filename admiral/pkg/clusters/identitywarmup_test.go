@@ -0,0 +1,105 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIdentityWarmupDurationCache(t *testing.T) {
+	cache := newIdentityWarmupDurationCache()
+	warmupDurationSecs := int64(90)
+	cache.Put("identity1", "prd", &warmupDurationSecs)
+	assert.Equal(t, &warmupDurationSecs, cache.Get("identity1", "prd"))
+	assert.Nil(t, cache.Get("identity1", "e2e"))
+
+	cache.Put("identity1", "prd", nil)
+	assert.Nil(t, cache.Get("identity1", "prd"))
+}
+
+func TestGetRequestedWarmupDuration(t *testing.T) {
+	deploymentWithWarmup := func(warmupDuration string) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.AdmiralWarmupDurationAnnotation: warmupDuration}},
+		}}}
+	}
+	rolloutWithWarmup := func(warmupDuration string) *argo.Rollout {
+		return &argo.Rollout{Spec: argo.RolloutSpec{Template: coreV1.PodTemplateSpec{
+			ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{common.AdmiralWarmupDurationAnnotation: warmupDuration}},
+		}}}
+	}
+	seconds := func(secs int64) *int64 {
+		return &secs
+	}
+	testCases := []struct {
+		name                       string
+		sourceDeployments          map[string]*k8sAppsV1.Deployment
+		sourceRollouts             map[string]*argo.Rollout
+		expectedWarmupDurationSecs *int64
+	}{
+		{
+			name: "Given workloads without the warmup duration annotation, " +
+				"When getRequestedWarmupDuration is called, " +
+				"Then nil should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": {}},
+		},
+		{
+			name: "Given a rollout annotated with a warmup duration of 2m, " +
+				"When getRequestedWarmupDuration is called, " +
+				"Then 120 seconds should be returned",
+			sourceRollouts:             map[string]*argo.Rollout{"cluster1": rolloutWithWarmup("2m")},
+			expectedWarmupDurationSecs: seconds(120),
+		},
+		{
+			name: "Given a deployment annotated with a warmup duration of 0s, " +
+				"When getRequestedWarmupDuration is called, " +
+				"Then 0 seconds should be returned to disable the slow start",
+			sourceDeployments:          map[string]*k8sAppsV1.Deployment{"cluster1": deploymentWithWarmup("0s")},
+			expectedWarmupDurationSecs: seconds(0),
+		},
+		{
+			name: "Given a deployment annotated with an invalid warmup duration, " +
+				"When getRequestedWarmupDuration is called, " +
+				"Then nil should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{"cluster1": deploymentWithWarmup("slow")},
+		},
+		{
+			name: "Given workloads in different clusters annotated with different warmup durations, " +
+				"When getRequestedWarmupDuration is called, " +
+				"Then the duration of the first cluster in order should be returned",
+			sourceDeployments: map[string]*k8sAppsV1.Deployment{
+				"cluster2": deploymentWithWarmup("30s"),
+				"cluster1": deploymentWithWarmup("90s"),
+			},
+			expectedWarmupDurationSecs: seconds(90),
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedWarmupDurationSecs, getRequestedWarmupDuration(c.sourceDeployments, c.sourceRollouts))
+		})
+	}
+}
+
+func TestGetIdentityWarmupDuration(t *testing.T) {
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	warmupDurationSecs := int64(90)
+	rr.AdmiralCache.IdentityWarmupDurationCache.Put("foo", "prd", &warmupDurationSecs)
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	assert.Nil(t, getIdentityWarmupDuration(rr.AdmiralCache, "foo", "prd"))
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EnableIdentityWarmupDuration: true})
+	assert.Equal(t, &duration.Duration{Seconds: 90}, getIdentityWarmupDuration(rr.AdmiralCache, "foo", "prd"))
+	assert.Nil(t, getIdentityWarmupDuration(rr.AdmiralCache, "foo", "e2e"))
+	assert.Nil(t, getIdentityWarmupDuration(rr.AdmiralCache, "bar", "prd"))
+}
@@ -0,0 +1,159 @@
+package clusters
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
+	"github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	k8sV1 "k8s.io/api/core/v1"
+)
+
+// getClusterIngressEndpoints returns the load balancers of the cluster ingress when it exposes more
+// than one, e.g. an NLB per availability zone. They are the services in istio-system which carry the
+// same app label as the service of the primary load balancer, weighted and located with their
+// admiral.io/ingress-weight and admiral.io/ingress-locality annotations. The primary load balancer is
+// returned first, and nil is returned when the cluster exposes a single load balancer
+func getClusterIngressEndpoints(rc *RemoteController, primaryEndpoint string, locality string) []*registry.IngressEndpoint {
+	if !common.EnableMultipleIngressEndpoints() || isServiceControllerInitialized(rc) != nil {
+		return nil
+	}
+	services := rc.ServiceController.Cache.Get(common.NamespaceIstioSystem)
+	var primary *k8sV1.Service
+	for _, svc := range services {
+		if getLoadBalancerAddress(svc) == primaryEndpoint {
+			primary = svc
+			break
+		}
+	}
+	if primary == nil || primary.Labels[common.App] == "" {
+		return nil
+	}
+	ingressEndpoints := []*registry.IngressEndpoint{newIngressEndpoint(primary, primaryEndpoint, locality)}
+	others := make([]*registry.IngressEndpoint, 0)
+	for _, svc := range services {
+		if svc == primary || svc.Labels[common.App] != primary.Labels[common.App] {
+			continue
+		}
+		address := getLoadBalancerAddress(svc)
+		if address == "" || address == primaryEndpoint {
+			continue
+		}
+		others = append(others, newIngressEndpoint(svc, address, locality))
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].Address < others[j].Address
+	})
+	return append(ingressEndpoints, others...)
+}
+
+func newIngressEndpoint(svc *k8sV1.Service, address string, locality string) *registry.IngressEndpoint {
+	ingressEndpoint := &registry.IngressEndpoint{
+		Address:  address,
+		Locality: locality,
+	}
+	if svcLocality := svc.Annotations[common.AdmiralIngressLocalityAnnotation]; svcLocality != "" {
+		ingressEndpoint.Locality = svcLocality
+	}
+	if weight, err := strconv.ParseUint(svc.Annotations[common.AdmiralIngressWeightAnnotation], 10, 32); err == nil {
+		ingressEndpoint.Weight = uint32(weight)
+	} else if svc.Annotations[common.AdmiralIngressWeightAnnotation] != "" {
+		logrus.Warnf(LogFormat, "Get", "IngressEndpoint", svc.Name, "",
+			"ignoring invalid "+common.AdmiralIngressWeightAnnotation+" annotation: "+err.Error())
+	}
+	return ingressEndpoint
+}
+
+// getLoadBalancerAddress returns the hostname or IP of the load balancer of the service
+func getLoadBalancerAddress(svc *k8sV1.Service) string {
+	ingress := svc.Status.LoadBalancer.Ingress
+	if len(ingress) == 0 {
+		return ""
+	}
+	if ingress[0].Hostname != "" {
+		//Add "." at the end of the address to prevent additional DNS calls via search domains
+		if common.IsAbsoluteFQDNEnabled() {
+			return ingress[0].Hostname + common.Sep
+		}
+		return ingress[0].Hostname
+	}
+	return ingress[0].IP
+}
+
+// getServiceEntryRemoteEndpoints returns the endpoints of the service entry through which the
+// other clusters reach the cluster, one per load balancer of the cluster ingress
+func getServiceEntryRemoteEndpoints(rc *RemoteController, seEndpoint *networking.WorkloadEntry) []*networking.WorkloadEntry {
+	ingressEndpoints := getClusterIngressEndpoints(rc, seEndpoint.Address, seEndpoint.Locality)
+	if len(ingressEndpoints) == 0 {
+		return []*networking.WorkloadEntry{seEndpoint}
+	}
+	endpoints := make([]*networking.WorkloadEntry, 0, len(ingressEndpoints))
+	for _, ingressEndpoint := range ingressEndpoints {
+		ep := seEndpoint.DeepCopy()
+		ep.Address = ingressEndpoint.Address
+		ep.Locality = ingressEndpoint.Locality
+		ep.Weight = ingressEndpoint.Weight
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints
+}
+
+// getSourceClusterExcludedAddresses returns the addresses of the endpoints which are removed from the
+// service entries written to the source cluster itself: the additional load balancers of its ingress
+// and the load balancer it is migrating away from. Only the endpoint of its primary load balancer is
+// replaced with the local fqdn of the workload
+func getSourceClusterExcludedAddresses(ctxLogger *logrus.Entry, rc *RemoteController, admiralCache *AdmiralCache, sourceCluster string) []string {
+	excluded := make([]string, 0)
+	if migratingFrom := getLBMigratingFrom(admiralCache, sourceCluster); migratingFrom != "" {
+		excluded = append(excluded, migratingFrom)
+	}
+	if !common.EnableMultipleIngressEndpoints() {
+		return excluded
+	}
+	primaryEndpoint, _, err := getOverwrittenLoadBalancer(ctxLogger, rc, sourceCluster, admiralCache)
+	if err != nil {
+		return excluded
+	}
+	for _, ingressEndpoint := range getClusterIngressEndpoints(rc, primaryEndpoint, "") {
+		if ingressEndpoint.Address != primaryEndpoint {
+			excluded = append(excluded, ingressEndpoint.Address)
+		}
+	}
+	return excluded
+}
+
+// removeEndpointsWithAddresses returns a copy of the service entry without the endpoints with the given
+// addresses, the service entry is returned as is when it has none of them
+func removeEndpointsWithAddresses(se *networking.ServiceEntry, addresses []string) *networking.ServiceEntry {
+	if len(addresses) == 0 {
+		return se
+	}
+	copied := copyServiceEntry(se)
+	endpoints := make([]*networking.WorkloadEntry, 0, len(copied.Endpoints))
+	for _, ep := range copied.Endpoints {
+		if !slices.Contains(addresses, ep.Address) {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if len(endpoints) == len(se.Endpoints) {
+		return se
+	}
+	copied.Endpoints = endpoints
+	return copied
+}
+
+func containsEndpoint(endpoints []*networking.WorkloadEntry, endpoint *networking.WorkloadEntry) bool {
+	for _, ep := range endpoints {
+		if reflect.DeepEqual(ep, endpoint) {
+			return true
+		}
+	}
+	return false
+}
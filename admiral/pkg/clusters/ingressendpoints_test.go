@@ -0,0 +1,153 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func newIngressTestService(name, app, hostname string, annotations map[string]string) *coreV1.Service {
+	return &coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:        name,
+			Namespace:   common.NamespaceIstioSystem,
+			Labels:      map[string]string{common.App: app},
+			Annotations: annotations,
+		},
+		Status: coreV1.ServiceStatus{LoadBalancer: coreV1.LoadBalancerStatus{
+			Ingress: []coreV1.LoadBalancerIngress{{Hostname: hostname}},
+		}},
+	}
+}
+
+func newIngressTestRemoteController(t *testing.T, services ...*coreV1.Service) *RemoteController {
+	serviceController, err := admiral.NewServiceController(make(chan struct{}), &test.MockServiceHandler{},
+		&rest.Config{Host: "localhost"}, time.Second*time.Duration(300), loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	for _, svc := range services {
+		serviceController.Cache.Put(svc)
+	}
+	return &RemoteController{ClusterID: "cluster1", ServiceController: serviceController}
+}
+
+func TestGetClusterIngressEndpoints(t *testing.T) {
+	rc := newIngressTestRemoteController(t,
+		newIngressTestService("ingress-a", "istio-ingressgateway-nlb", "nlb-a.example.com", map[string]string{
+			common.AdmiralIngressWeightAnnotation:   "70",
+			common.AdmiralIngressLocalityAnnotation: "us-west-2/us-west-2a",
+		}),
+		newIngressTestService("ingress-c", "istio-ingressgateway-nlb", "nlb-c.example.com", map[string]string{
+			common.AdmiralIngressWeightAnnotation: "invalid",
+		}),
+		newIngressTestService("ingress-b", "istio-ingressgateway-nlb", "nlb-b.example.com", map[string]string{
+			common.AdmiralIngressWeightAnnotation: "30",
+		}),
+		newIngressTestService("ingress-clb", "istio-ingressgateway", "clb.example.com", nil),
+	)
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	assert.Nil(t, getClusterIngressEndpoints(rc, "nlb-a.example.com", "us-west-2"), "nothing should be returned when disabled")
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EnableMultipleIngressEndpoints: true})
+	testCases := []struct {
+		name                     string
+		primaryEndpoint          string
+		expectedIngressEndpoints []*registry.IngressEndpoint
+	}{
+		{
+			name: "Given an ingress exposed through several load balancers, " +
+				"When getClusterIngressEndpoints is called, " +
+				"Then all of them should be returned, the primary load balancer first",
+			primaryEndpoint: "nlb-b.example.com",
+			expectedIngressEndpoints: []*registry.IngressEndpoint{
+				{Address: "nlb-b.example.com", Locality: "us-west-2", Weight: 30},
+				{Address: "nlb-a.example.com", Locality: "us-west-2/us-west-2a", Weight: 70},
+				{Address: "nlb-c.example.com", Locality: "us-west-2"},
+			},
+		},
+		{
+			name: "Given an ingress exposed through a single load balancer, " +
+				"When getClusterIngressEndpoints is called, " +
+				"Then nil should be returned",
+			primaryEndpoint: "clb.example.com",
+		},
+		{
+			name: "Given a primary endpoint which is not a load balancer of the cluster, " +
+				"When getClusterIngressEndpoints is called, " +
+				"Then nil should be returned",
+			primaryEndpoint: "secondary.example.com",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedIngressEndpoints, getClusterIngressEndpoints(rc, c.primaryEndpoint, "us-west-2"))
+		})
+	}
+}
+
+func TestGetServiceEntryRemoteEndpoints(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{EnableMultipleIngressEndpoints: true})
+	rc := newIngressTestRemoteController(t,
+		newIngressTestService("ingress-a", "istio-ingressgateway", "lb-a.example.com", map[string]string{common.AdmiralIngressWeightAnnotation: "50"}),
+		newIngressTestService("ingress-b", "istio-ingressgateway", "lb-b.example.com", map[string]string{common.AdmiralIngressWeightAnnotation: "50"}),
+	)
+	seEndpoint := makeRemoteEndpointForServiceEntry("lb-a.example.com", "us-west-2", "http", 15443, common.Deployment)
+
+	endpoints := getServiceEntryRemoteEndpoints(rc, seEndpoint)
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, "lb-a.example.com", endpoints[0].Address)
+	assert.Equal(t, "lb-b.example.com", endpoints[1].Address)
+	for _, ep := range endpoints {
+		assert.Equal(t, uint32(50), ep.Weight)
+		assert.Equal(t, seEndpoint.Ports, ep.Ports)
+		assert.Equal(t, seEndpoint.Labels, ep.Labels)
+	}
+
+	single := makeRemoteEndpointForServiceEntry("other.example.com", "us-west-2", "http", 15443, common.Deployment)
+	assert.Equal(t, []*networking.WorkloadEntry{single}, getServiceEntryRemoteEndpoints(rc, single))
+}
+
+func TestGetSourceClusterExcludedAddresses(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                       &common.LabelSet{GatewayApp: "istio-ingressgateway"},
+		EnableMultipleIngressEndpoints: true,
+	})
+	// the most recently created ingress gateway service is the primary load balancer
+	primarySvc := newIngressTestService("istio-ingressgateway", "istio-ingressgateway", "lb-a.example.com", nil)
+	primarySvc.CreationTimestamp = metaV1.NewTime(time.Now())
+	rc := newIngressTestRemoteController(t,
+		primarySvc,
+		newIngressTestService("istio-ingressgateway-b", "istio-ingressgateway", "lb-b.example.com", nil),
+	)
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	ctxLogger := logrus.WithContext(context.Background())
+	primary, _, _ := getOverwrittenLoadBalancer(ctxLogger, rc, "cluster1", rr.AdmiralCache)
+	assert.Equal(t, "lb-a.example.com", primary)
+	assert.Equal(t, []string{"lb-b.example.com"}, getSourceClusterExcludedAddresses(ctxLogger, rc, rr.AdmiralCache, "cluster1"))
+
+	se := &networking.ServiceEntry{Endpoints: []*networking.WorkloadEntry{
+		{Address: "lb-a.example.com"}, {Address: "lb-b.example.com"}, {Address: "lb.cluster2.example.com"},
+	}}
+	filtered := removeEndpointsWithAddresses(se, []string{"lb-b.example.com"})
+	assert.Len(t, filtered.Endpoints, 2)
+	assert.Equal(t, "lb.cluster2.example.com", filtered.Endpoints[1].Address)
+	assert.Len(t, se.Endpoints, 3, "the service entry written to the other clusters should be left unchanged")
+	assert.Same(t, se, removeEndpointsWithAddresses(se, []string{"lb-c.example.com"}))
+	assert.Same(t, se, removeEndpointsWithAddresses(se, nil))
+}
@@ -0,0 +1,187 @@
+package clusters
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+// probeIngressEndpoint opens a TCP connection to the ingress port of the load balancer, and
+// completes a TLS handshake with it when ingress_health_check_tls is set
+var probeIngressEndpoint = func(endpoint string, port int, timeout time.Duration) error {
+	address := net.JoinHostPort(strings.TrimSuffix(endpoint, common.Sep), strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+	if common.IsIngressHealthCheckTLSEnabled() {
+		conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+			// only the reachability of the load balancer is checked, the gateway
+			// presents the mesh certificate which is not issued for its hostname
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+type ingressHealth struct {
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	failedOver           bool
+}
+
+// ingressHealthCache holds the result of the health checks of the ingress load balancer of each
+// cluster, and whether the cluster failed over to its secondary ingress endpoint
+type ingressHealthCache struct {
+	mutex  sync.RWMutex
+	health map[string]*ingressHealth
+}
+
+func newIngressHealthCache() *ingressHealthCache {
+	return &ingressHealthCache{
+		health: make(map[string]*ingressHealth),
+	}
+}
+
+// Record records the result of a health check of the ingress load balancer of the cluster. It returns
+// true when the cluster fails over, or back, because threshold consecutive checks failed, or succeeded
+func (c *ingressHealthCache) Record(cluster string, healthy bool, threshold int) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	health, ok := c.health[cluster]
+	if !ok {
+		health = &ingressHealth{}
+		c.health[cluster] = health
+	}
+	if healthy {
+		health.consecutiveFailures = 0
+		health.consecutiveSuccesses++
+		if health.failedOver && health.consecutiveSuccesses >= threshold {
+			health.failedOver = false
+			return true
+		}
+		return false
+	}
+	health.consecutiveSuccesses = 0
+	health.consecutiveFailures++
+	if !health.failedOver && health.consecutiveFailures >= threshold {
+		health.failedOver = true
+		return true
+	}
+	return false
+}
+
+func (c *ingressHealthCache) IsFailedOver(cluster string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	health, ok := c.health[cluster]
+	return ok && health.failedOver
+}
+
+// getIngressSecondaryEndpoint returns the host and port of the secondary ingress endpoint configured
+// for the cluster, the port defaults to the mTLS port of the ingress gateway
+func getIngressSecondaryEndpoint(cluster string) (string, int, bool) {
+	secondary := common.GetIngressSecondaryEndpoint(cluster)
+	if secondary == "" {
+		return "", 0, false
+	}
+	host, portValue, err := net.SplitHostPort(secondary)
+	if err != nil {
+		return secondary, common.DefaultMtlsPort, true
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil || port <= 0 {
+		return "", 0, false
+	}
+	return host, port, true
+}
+
+// getIngressFailoverEndpoint returns the secondary ingress endpoint of the cluster when its
+// load balancer failed the health checks, ok is false when the cluster did not fail over
+func getIngressFailoverEndpoint(admiralCache *AdmiralCache, cluster string) (string, int, bool) {
+	if admiralCache == nil || admiralCache.IngressHealthCache == nil || !admiralCache.IngressHealthCache.IsFailedOver(cluster) {
+		return "", 0, false
+	}
+	return getIngressSecondaryEndpoint(cluster)
+}
+
+// startIngressHealthCheck periodically probes the ingress load balancer of the cluster
+func startIngressHealthCheck(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	interval := common.GetIngressHealthCheckInterval()
+	if common.IsAdmiralOperatorMode() || interval <= 0 {
+		return
+	}
+	if _, _, ok := getIngressSecondaryEndpoint(clusterID); !ok {
+		log.Infof(LogFormat, "Start", common.IngressHealthCheck, "", clusterID, "skipped as no secondary ingress endpoint is configured")
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			// the remote controller is looked up on every check, as it is replaced when its istio
+			// controllers are started or stopped
+			if rc := rr.GetRemoteController(clusterID); rc != nil {
+				checkIngressHealth(context.Background(), rr, rc)
+			}
+		}
+	}
+}
+
+// checkIngressHealth probes the ingress load balancer of the cluster and fails the service entries of
+// the dependent clusters over to the secondary ingress endpoint once it is unhealthy, and back once
+// it is healthy again
+func checkIngressHealth(ctx context.Context, rr *RemoteRegistry, rc *RemoteController) {
+	if rr.AdmiralCache == nil || rr.AdmiralCache.IngressHealthCache == nil {
+		return
+	}
+	ctxLogger := log.WithFields(log.Fields{
+		"task":        common.IngressHealthCheck,
+		"clusterName": rc.ClusterID,
+	})
+	endpoint, port, err := getPrimaryLoadBalancer(ctxLogger, rc, rc.ClusterID, rr.AdmiralCache)
+	if err != nil || !isValidLoadBalancer(endpoint, port) {
+		return
+	}
+	err = probeIngressEndpoint(endpoint, port, common.GetIngressHealthCheckTimeout())
+	if !rr.AdmiralCache.IngressHealthCache.Record(rc.ClusterID, err == nil, common.GetIngressHealthCheckFailureThreshold()) {
+		if err != nil {
+			ctxLogger.Warnf(common.CtxLogFormat, common.IngressHealthCheck, endpoint, "", rc.ClusterID, "probe failed: "+err.Error())
+		}
+		return
+	}
+	secondaryEndpoint, _, _ := getIngressSecondaryEndpoint(rc.ClusterID)
+	direction := "failback"
+	message := "ingress load balancer " + endpoint + " is healthy again, failing back from " + secondaryEndpoint
+	if err != nil {
+		direction = "failover"
+		message = "ingress load balancer " + endpoint + " is unhealthy, failing over to " + secondaryEndpoint + ": " + err.Error()
+	}
+	ingressFailovers.Increment(api.WithAttributes(
+		attribute.Key("cluster").String(rc.ClusterID),
+		attribute.Key("direction").String(direction),
+	))
+	ctxLogger.Errorf(common.CtxLogFormat, common.IngressHealthCheck, endpoint, "", rc.ClusterID, message)
+	refreshClusterIngressEndpoints(ctx, rr, rc.ClusterID, common.IngressHealthCheck, "Completed "+direction)
+}
@@ -0,0 +1,143 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestIngressHealthCache(t *testing.T) {
+	cache := newIngressHealthCache()
+	assert.False(t, cache.Record("cluster1", false, 2))
+	assert.False(t, cache.IsFailedOver("cluster1"))
+	assert.True(t, cache.Record("cluster1", false, 2), "the cluster should fail over once the threshold is reached")
+	assert.True(t, cache.IsFailedOver("cluster1"))
+	assert.False(t, cache.Record("cluster1", false, 2))
+
+	assert.False(t, cache.Record("cluster1", true, 2))
+	assert.False(t, cache.Record("cluster1", false, 2), "a failed probe should reset the successful probes")
+	assert.False(t, cache.Record("cluster1", true, 2))
+	assert.True(t, cache.Record("cluster1", true, 2), "the cluster should fail back once the threshold is reached")
+	assert.False(t, cache.IsFailedOver("cluster1"))
+	assert.False(t, cache.IsFailedOver("cluster2"))
+}
+
+func TestGetIngressSecondaryEndpoint(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		IngressSecondaryEndpoints: map[string]string{
+			"cluster1": "secondary-1.example.com",
+			"cluster2": "secondary-2.example.com:16443",
+			"cluster3": "secondary-3.example.com:tls",
+		},
+	})
+	testCases := []struct {
+		name             string
+		cluster          string
+		expectedEndpoint string
+		expectedPort     int
+		expectedOk       bool
+	}{
+		{
+			name: "Given a secondary endpoint without a port, " +
+				"When getIngressSecondaryEndpoint is called, " +
+				"Then the mTLS port should be used",
+			cluster:          "cluster1",
+			expectedEndpoint: "secondary-1.example.com",
+			expectedPort:     common.DefaultMtlsPort,
+			expectedOk:       true,
+		},
+		{
+			name: "Given a secondary endpoint with a port, " +
+				"When getIngressSecondaryEndpoint is called, " +
+				"Then the configured port should be used",
+			cluster:          "cluster2",
+			expectedEndpoint: "secondary-2.example.com",
+			expectedPort:     16443,
+			expectedOk:       true,
+		},
+		{
+			name: "Given a secondary endpoint with an invalid port, " +
+				"When getIngressSecondaryEndpoint is called, " +
+				"Then it should not be used",
+			cluster: "cluster3",
+		},
+		{
+			name: "Given a cluster without a secondary endpoint, " +
+				"When getIngressSecondaryEndpoint is called, " +
+				"Then ok should be false",
+			cluster: "cluster4",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			endpoint, port, ok := getIngressSecondaryEndpoint(c.cluster)
+			assert.Equal(t, c.expectedEndpoint, endpoint)
+			assert.Equal(t, c.expectedPort, port)
+			assert.Equal(t, c.expectedOk, ok)
+		})
+	}
+}
+
+func TestCheckIngressHealth(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                           &common.LabelSet{GatewayApp: common.IstioIngressGatewayLabelValue},
+		IngressHealthCheckFailureThreshold: 2,
+		IngressSecondaryEndpoints:          map[string]string{"cluster1": "secondary.example.com"},
+	})
+	defer func(probe func(string, int, time.Duration) error) { probeIngressEndpoint = probe }(probeIngressEndpoint)
+
+	serviceController, err := admiral.NewServiceController(make(chan struct{}), &test.MockServiceHandler{},
+		&rest.Config{Host: "localhost"}, time.Second*time.Duration(300), loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	serviceController.Cache.Put(&coreV1.Service{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "istio-ingressgateway",
+			Namespace: common.NamespaceIstioSystem,
+			Labels:    map[string]string{common.App: common.IstioIngressGatewayLabelValue},
+		},
+		Status: coreV1.ServiceStatus{LoadBalancer: coreV1.LoadBalancerStatus{
+			Ingress: []coreV1.LoadBalancerIngress{{Hostname: "primary.example.com"}},
+		}},
+	})
+	rc := &RemoteController{ClusterID: "cluster1", ServiceController: serviceController}
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	ctxLogger := logrus.WithContext(context.Background())
+
+	var probed string
+	probeIngressEndpoint = func(endpoint string, port int, timeout time.Duration) error {
+		probed = endpoint
+		return errors.New("connection refused")
+	}
+	checkIngressHealth(context.Background(), rr, rc)
+	endpoint, _, _ := getOverwrittenLoadBalancer(ctxLogger, rc, "cluster1", rr.AdmiralCache)
+	assert.Equal(t, "primary.example.com", probed)
+	assert.Equal(t, "primary.example.com", endpoint, "a single failed probe should not fail over")
+
+	checkIngressHealth(context.Background(), rr, rc)
+	endpoint, port, _ := getOverwrittenLoadBalancer(ctxLogger, rc, "cluster1", rr.AdmiralCache)
+	assert.Equal(t, "secondary.example.com", endpoint)
+	assert.Equal(t, common.DefaultMtlsPort, port)
+
+	probeIngressEndpoint = func(endpoint string, port int, timeout time.Duration) error {
+		probed = endpoint
+		return nil
+	}
+	checkIngressHealth(context.Background(), rr, rc)
+	assert.Equal(t, "primary.example.com", probed, "the primary load balancer should be probed while failed over")
+	checkIngressHealth(context.Background(), rr, rc)
+	endpoint, _, _ = getOverwrittenLoadBalancer(ctxLogger, rc, "cluster1", rr.AdmiralCache)
+	assert.Equal(t, "primary.example.com", endpoint)
+}
@@ -0,0 +1,70 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IdentityState is the state admiral built for an identity from the clusters it watches
+type IdentityState struct {
+	Clusters      []string
+	Envs          []string
+	Regions       []string
+	Dependents    []string
+	SyncNamespace string
+}
+
+// GetIdentityState returns the clusters, envs, regions and dependents admiral discovered
+// for the identity along with the namespace its resources are synced to
+func GetIdentityState(rr *RemoteRegistry, identity string) IdentityState {
+	state := IdentityState{}
+	if rr == nil || rr.AdmiralCache == nil {
+		return state
+	}
+	if rr.AdmiralCache.IdentityClusterCache != nil {
+		if clusters := rr.AdmiralCache.IdentityClusterCache.Get(identity); clusters != nil {
+			state.Clusters = clusters.GetKeys()
+			sort.Strings(state.Clusters)
+		}
+	}
+	state.Envs, state.Regions = getIdentityEnvsAndRegions(rr, identity, state.Clusters)
+	if rr.AdmiralCache.IdentityDependencyCache != nil {
+		if dependents := rr.AdmiralCache.IdentityDependencyCache.Get(identity); dependents != nil {
+			state.Dependents = dependents.GetKeys()
+			sort.Strings(state.Dependents)
+		}
+	}
+	if rr.AdmiralCache.IdentitySyncNamespaceCache != nil {
+		state.SyncNamespace = rr.AdmiralCache.IdentitySyncNamespaceCache.Get(identity)
+	}
+	return state
+}
+
+// GetHostIdentity returns the identity the host was generated for, or an empty string when it is not known
+func GetHostIdentity(rr *RemoteRegistry, host string) string {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.CnameIdentityCache == nil {
+		return ""
+	}
+	identity, ok := rr.AdmiralCache.CnameIdentityCache.Load(host)
+	if !ok {
+		if identity, ok = rr.AdmiralCache.CnameIdentityCache.Load(strings.ToLower(host)); !ok {
+			return ""
+		}
+	}
+	return fmt.Sprint(identity)
+}
+
+// GetDependentClusters returns the sorted clusters the resources of the host are synced to
+func GetDependentClusters(rr *RemoteRegistry, host string) []string {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.CnameDependentClusterCache == nil {
+		return nil
+	}
+	clusters := rr.AdmiralCache.CnameDependentClusterCache.Get(host)
+	if clusters == nil {
+		return nil
+	}
+	keys := clusters.GetKeys()
+	sort.Strings(keys)
+	return keys
+}
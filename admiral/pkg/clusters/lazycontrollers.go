@@ -0,0 +1,175 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+)
+
+// lazyIstioControllersCheckInterval is the interval at which the clusters are checked for idle istio controllers
+var lazyIstioControllersCheckInterval = time.Minute
+
+// lazyIstioControllers is the state of the istio controllers of a cluster which are started only once the
+// first workload with an identity appears in the cluster, and stopped once the cluster has had no such
+// workload for the idle timeout. Until they are started, the remote controller holds dormant istio
+// controllers, whose clients can write to the cluster but whose caches stay empty as no informer runs
+type lazyIstioControllers struct {
+	lock         sync.Mutex
+	clientConfig *rest.Config
+	resyncPeriod util.ResyncIntervals
+	// stop is closed to stop the running istio controllers, and is nil while they are dormant
+	stop      chan struct{}
+	idleSince time.Time
+}
+
+// createLazyIstioControllers sets the dormant istio controllers of the remote controller, to be started
+// once the first workload with an identity appears in the cluster
+func (r *RemoteRegistry) createLazyIstioControllers(rc *RemoteController, clientConfig *rest.Config, resyncPeriod util.ResyncIntervals) error {
+	rc.lazyIstio = &lazyIstioControllers{
+		clientConfig: clientConfig,
+		resyncPeriod: resyncPeriod,
+	}
+	return r.createDormantIstioControllers(rc, clientConfig)
+}
+
+// createDormantIstioControllers sets istio controllers with a client and empty caches, and no informer
+func (r *RemoteRegistry) createDormantIstioControllers(rc *RemoteController, clientConfig *rest.Config) error {
+	ic, err := r.ClientLoader.LoadIstioClientFromConfig(clientConfig)
+	if err != nil {
+		return err
+	}
+	rc.ServiceEntryController = &istio.ServiceEntryController{
+		IstioClient: ic,
+		Cache:       istio.NewServiceEntryCache(),
+		Cluster:     rc.ClusterID,
+	}
+	rc.DestinationRuleController = &istio.DestinationRuleController{
+		IstioClient: ic,
+		Cache:       istio.NewDestinationRuleCache(),
+		Cluster:     rc.ClusterID,
+	}
+	rc.VirtualServiceController = &istio.VirtualServiceController{
+		IstioClient:                 ic,
+		VirtualServiceCache:         istio.NewVirtualServiceCache(),
+		IdentityVirtualServiceCache: istio.NewIdentityVirtualServiceCache(),
+		HostToRouteDestinationCache: istio.NewHostToRouteDestinationCache(),
+	}
+	rc.SidecarController = &istio.SidecarController{IstioClient: ic}
+	return nil
+}
+
+// startLazyIstioControllers starts the istio controllers of the cluster when they are dormant, and resets
+// the time the cluster has been idle for
+func (r *RemoteRegistry) startLazyIstioControllers(clusterID string) {
+	rc := r.GetRemoteController(clusterID)
+	if rc == nil || rc.lazyIstio == nil {
+		return
+	}
+	lazyIstio := rc.lazyIstio
+	lazyIstio.lock.Lock()
+	defer lazyIstio.lock.Unlock()
+	lazyIstio.idleSince = time.Time{}
+	if lazyIstio.stop != nil {
+		return
+	}
+	// the remote controller is swapped only with the lock held, so it is read again once the lock is acquired
+	rc = r.GetRemoteController(clusterID)
+	if rc == nil || rc.lazyIstio != lazyIstio {
+		return
+	}
+	stop := make(chan struct{})
+	started := *rc
+	err := r.createIstioControllers(&started, stopWithRemoteController(rc.stop, stop), lazyIstio.clientConfig, lazyIstio.resyncPeriod)
+	if err != nil {
+		close(stop)
+		log.Errorf(LogErrFormat, "Start", common.LazyIstioControllers, clusterID, clusterID, err)
+		return
+	}
+	if !r.swapRemoteController(clusterID, rc, &started) {
+		close(stop)
+		return
+	}
+	lazyIstio.stop = stop
+	log.Infof(LogFormat, "Start", common.LazyIstioControllers, clusterID, clusterID, "started on the first workload with an identity")
+}
+
+// stopLazyIstioControllersLocked stops the running istio controllers of the cluster, and sets dormant ones
+// in their place. It must be called with the lock of the lazy istio controllers held
+func (r *RemoteRegistry) stopLazyIstioControllersLocked(rc *RemoteController) {
+	lazyIstio := rc.lazyIstio
+	stopped := *rc
+	err := r.createDormantIstioControllers(&stopped, lazyIstio.clientConfig)
+	if err != nil {
+		log.Errorf(LogErrFormat, "Stop", common.LazyIstioControllers, rc.ClusterID, rc.ClusterID, err)
+		return
+	}
+	// the controllers are stopped even when the remote controller was removed in the meantime
+	r.swapRemoteController(rc.ClusterID, rc, &stopped)
+	close(lazyIstio.stop)
+	lazyIstio.stop = nil
+	lazyIstio.idleSince = time.Time{}
+	log.Infof(LogFormat, "Stop", common.LazyIstioControllers, rc.ClusterID, rc.ClusterID, "stopped as the cluster has no workload with an identity")
+}
+
+// stopWithRemoteController returns a channel which is closed once either channel is closed
+func stopWithRemoteController(remoteControllerStop, stop <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		select {
+		case <-remoteControllerStop:
+		case <-stop:
+		}
+		close(merged)
+	}()
+	return merged
+}
+
+// startIdleIstioControllersTeardown periodically stops the istio controllers of the clusters which have
+// had no workload with an identity for the idle timeout
+func startIdleIstioControllersTeardown(ctx context.Context, rr *RemoteRegistry) {
+	timeout := common.GetIstioControllersIdleTimeout()
+	if !common.EnableLazyIstioControllers() || timeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(lazyIstioControllersCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stopIdleIstioControllers(rr, timeout, time.Now())
+		}
+	}
+}
+
+// stopIdleIstioControllers stops the running istio controllers of the clusters which have had no workload
+// with an identity since at least the timeout before now
+func stopIdleIstioControllers(rr *RemoteRegistry, timeout time.Duration, now time.Time) {
+	for _, clusterID := range rr.GetClusterIds() {
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil || rc.lazyIstio == nil {
+			continue
+		}
+		lazyIstio := rc.lazyIstio
+		lazyIstio.lock.Lock()
+		rc = rr.GetRemoteController(clusterID)
+		switch {
+		case rc == nil || rc.lazyIstio != lazyIstio || lazyIstio.stop == nil:
+			// the istio controllers are dormant, or the remote controller was replaced in the meantime
+		case hasIdentityWorkload(rc, ""):
+			lazyIstio.idleSince = time.Time{}
+		case lazyIstio.idleSince.IsZero():
+			lazyIstio.idleSince = now
+		case now.Sub(lazyIstio.idleSince) >= timeout:
+			rr.stopLazyIstioControllersLocked(rc)
+		}
+		lazyIstio.lock.Unlock()
+	}
+}
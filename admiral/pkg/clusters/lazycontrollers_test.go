@@ -0,0 +1,93 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+)
+
+func setupForLazyIstioControllersTests(enabled bool) func() {
+	previous := common.GetAdmiralParams()
+	params := admiralParamsForRegistryTests()
+	params.EnableLazyIstioControllers = enabled
+	common.ResetSync()
+	common.InitializeConfig(params)
+	return func() {
+		common.ResetSync()
+		common.InitializeConfig(previous)
+	}
+}
+
+func TestLazyIstioControllers(t *testing.T) {
+	defer setupForLazyIstioControllersTests(true)()
+
+	cluster := "test.cluster"
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	err := rr.createCacheController(&rest.Config{Host: "test.com"}, cluster, util.ResyncIntervals{UniversalReconcileInterval: 300 * time.Second, SeAndDrReconcileInterval: 300 * time.Second})
+	assert.Nil(t, err)
+	defer rr.deleteCacheController(cluster)
+
+	// the istio controllers are dormant until the first workload with an identity appears
+	dormant := rr.GetRemoteController(cluster)
+	assert.NotNil(t, dormant.lazyIstio)
+	assert.Nil(t, dormant.lazyIstio.stop)
+	assert.NotNil(t, dormant.ServiceEntryController.IstioClient)
+	assert.NotNil(t, dormant.ServiceEntryController.Cache)
+	assert.NotNil(t, dormant.VirtualServiceController.VirtualServiceCache)
+
+	rr.startLazyIstioControllers(cluster)
+	started := rr.GetRemoteController(cluster)
+	assert.NotSame(t, dormant, started)
+	assert.NotNil(t, started.lazyIstio.stop)
+	assert.NotSame(t, dormant.ServiceEntryController, started.ServiceEntryController)
+	assert.Same(t, dormant.DeploymentController, started.DeploymentController)
+
+	// starting the running controllers again does nothing
+	rr.startLazyIstioControllers(cluster)
+	assert.Same(t, started, rr.GetRemoteController(cluster))
+
+	// the controllers of the cluster with no workload are stopped once it has been idle for the timeout
+	now := time.Now()
+	stopIdleIstioControllers(rr, time.Minute, now)
+	assert.Same(t, started, rr.GetRemoteController(cluster))
+	assert.Equal(t, now, started.lazyIstio.idleSince)
+	stopIdleIstioControllers(rr, time.Minute, now.Add(30*time.Second))
+	assert.Same(t, started, rr.GetRemoteController(cluster))
+	stopIdleIstioControllers(rr, time.Minute, now.Add(time.Minute))
+	stopped := rr.GetRemoteController(cluster)
+	assert.NotSame(t, started, stopped)
+	assert.Nil(t, stopped.lazyIstio.stop)
+	assert.NotNil(t, stopped.ServiceEntryController.IstioClient)
+
+	// a new workload starts the controllers again
+	rr.startLazyIstioControllers(cluster)
+	running := rr.GetRemoteController(cluster)
+	assert.NotNil(t, running.lazyIstio.stop)
+
+	// the cluster running only a client discovered from its jobs is not idle
+	running.JobController.Cache.Put(&common.K8sObject{Name: "client", Namespace: "client-ns", Labels: map[string]string{"identity": "client"}})
+	stopIdleIstioControllers(rr, time.Minute, now.Add(time.Hour))
+	stopIdleIstioControllers(rr, time.Minute, now.Add(2*time.Hour))
+	assert.Same(t, running, rr.GetRemoteController(cluster))
+	assert.NotNil(t, running.lazyIstio.stop)
+}
+
+func TestLazyIstioControllersDisabled(t *testing.T) {
+	defer setupForLazyIstioControllersTests(false)()
+	cluster := "test.cluster"
+	rr := NewRemoteRegistry(context.TODO(), common.AdmiralParams{})
+	err := rr.createCacheController(&rest.Config{Host: "test.com"}, cluster, util.ResyncIntervals{UniversalReconcileInterval: 300 * time.Second, SeAndDrReconcileInterval: 300 * time.Second})
+	assert.Nil(t, err)
+	defer rr.deleteCacheController(cluster)
+
+	rc := rr.GetRemoteController(cluster)
+	assert.Nil(t, rc.lazyIstio)
+	rr.startLazyIstioControllers(cluster)
+	stopIdleIstioControllers(rr, time.Minute, time.Now().Add(time.Hour))
+	assert.Same(t, rc, rr.GetRemoteController(cluster))
+}
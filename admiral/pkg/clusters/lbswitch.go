@@ -0,0 +1,263 @@
+package clusters
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	k8sV1 "k8s.io/api/core/v1"
+)
+
+const (
+	loadBalancerTypeNLB = "nlb"
+	loadBalancerTypeCLB = "clb"
+
+	awsLoadBalancerTypeAnnotation = "service.beta.kubernetes.io/aws-load-balancer-type"
+	// NLB hostnames look like <name>.elb.<region>.amazonaws.com
+	// and classic ELB hostnames like <name>.<region>.elb.amazonaws.com
+	awsClassicELBHostnameSuffix = ".elb.amazonaws.com"
+	awsNLBHostnameInfix         = ".elb."
+)
+
+// verifyLoadBalancer checks that the hostname of a load balancer resolves before
+// the endpoint of the load balancer it replaces is removed from the service entries
+var verifyLoadBalancer = func(endpoint string) error {
+	if net.ParseIP(endpoint) != nil {
+		return nil
+	}
+	_, err := net.LookupHost(strings.TrimSuffix(endpoint, common.Sep))
+	return err
+}
+
+// getLoadBalancerType detects whether the service is backed by an AWS network load balancer
+// or a classic ELB, using the load balancer type annotation and falling back to the hostname
+// of the load balancer. An empty string is returned when the type cannot be detected
+func getLoadBalancerType(svc *k8sV1.Service) string {
+	if svc == nil {
+		return ""
+	}
+	switch strings.ToLower(svc.Annotations[awsLoadBalancerTypeAnnotation]) {
+	case "nlb", "nlb-ip", "external":
+		return loadBalancerTypeNLB
+	case "clb", "elb":
+		return loadBalancerTypeCLB
+	}
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		hostname := strings.ToLower(strings.TrimSuffix(ingress.Hostname, common.Sep))
+		if strings.HasSuffix(hostname, awsClassicELBHostnameSuffix) {
+			return loadBalancerTypeCLB
+		}
+		if strings.HasSuffix(hostname, ".amazonaws.com") && strings.Contains(hostname, awsNLBHostnameInfix) {
+			return loadBalancerTypeNLB
+		}
+	}
+	return ""
+}
+
+// getPreferredLoadBalancerType returns the load balancer type the cluster is configured to use
+// for cross cluster traffic. clb_enabled_clusters takes precedence over nlb_enabled_clusters
+// so that a cluster can be rolled back to its classic ELB without being removed from the NLB list
+func getPreferredLoadBalancerType(admiralCache *AdmiralCache, clusterName string) string {
+	if admiralCache == nil {
+		return ""
+	}
+	if slices.Contains(admiralCache.CLBEnabledCluster, clusterName) {
+		return loadBalancerTypeCLB
+	}
+	if slices.Contains(admiralCache.NLBEnabledCluster, clusterName) {
+		return loadBalancerTypeNLB
+	}
+	return ""
+}
+
+// getIngressLabelForLoadBalancerType returns the app label of the ingress gateway service of the
+// cluster which is backed by a load balancer of the given type. The NLB ingress label is preferred
+// for NLBs and the gateway app label for classic ELBs
+func getIngressLabelForLoadBalancerType(rc *RemoteController, lbType string) (string, bool) {
+	ingressLabels := []string{common.GetAdmiralParams().LabelSet.GatewayApp, common.GetAdmiralParams().NLBIngressLabel}
+	if lbType == loadBalancerTypeNLB {
+		ingressLabels = []string{common.GetAdmiralParams().NLBIngressLabel, common.GetAdmiralParams().LabelSet.GatewayApp}
+	}
+	services := rc.ServiceController.Cache.Get(common.NamespaceIstioSystem)
+	for _, ingressLabel := range ingressLabels {
+		for _, svc := range services {
+			if common.IsIstioIngressGatewayService(svc, ingressLabel) && getLoadBalancerType(svc) == lbType {
+				return ingressLabel, true
+			}
+		}
+	}
+	return "", false
+}
+
+type lbMigration struct {
+	from      string
+	to        string
+	startedAt time.Time
+}
+
+// lbMigrationCache tracks the load balancer each cluster is reached through, and the
+// load balancer switches that are in progress. While a cluster is migrating, the service
+// entries in the other clusters point at both the old and the new load balancer
+type lbMigrationCache struct {
+	mutex      sync.RWMutex
+	current    map[string]string
+	migrations map[string]*lbMigration
+}
+
+func newLBMigrationCache() *lbMigrationCache {
+	return &lbMigrationCache{
+		current:    make(map[string]string),
+		migrations: make(map[string]*lbMigration),
+	}
+}
+
+// Observe records the load balancer endpoint chosen for the cluster, and starts a migration
+// from the previously chosen endpoint when it changed and soakDuration is set
+func (c *lbMigrationCache) Observe(cluster, endpoint string, soakDuration time.Duration) {
+	if endpoint == "" || endpoint == common.DummyAdmiralGlobal {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	previous := c.current[cluster]
+	c.current[cluster] = endpoint
+	if previous == "" || previous == endpoint {
+		return
+	}
+	if migration, ok := c.migrations[cluster]; ok && migration.from == endpoint {
+		// switched back to the old load balancer before the migration completed
+		delete(c.migrations, cluster)
+		return
+	}
+	if soakDuration <= 0 {
+		return
+	}
+	c.migrations[cluster] = &lbMigration{from: previous, to: endpoint, startedAt: time.Now()}
+}
+
+// GetMigratingFrom returns the endpoint of the load balancer the cluster is migrating away from,
+// or an empty string when no migration is in progress
+func (c *lbMigrationCache) GetMigratingFrom(cluster string) string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if migration, ok := c.migrations[cluster]; ok {
+		return migration.from
+	}
+	return ""
+}
+
+func (c *lbMigrationCache) Get(cluster string) *lbMigration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if migration, ok := c.migrations[cluster]; ok {
+		copied := *migration
+		return &copied
+	}
+	return nil
+}
+
+func (c *lbMigrationCache) Complete(cluster string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.migrations, cluster)
+}
+
+func getLBMigratingFrom(admiralCache *AdmiralCache, clusterName string) string {
+	if admiralCache == nil || admiralCache.LBMigrationCache == nil {
+		return ""
+	}
+	return admiralCache.LBMigrationCache.GetMigratingFrom(clusterName)
+}
+
+// addLBMigrationEndpoint adds an endpoint for the load balancer the cluster is migrating away from,
+// so that clients keep reaching the cluster through it until the new load balancer is verified
+func addLBMigrationEndpoint(se *networking.ServiceEntry, seEndpoint *networking.WorkloadEntry, migratingFrom string) {
+	if migratingFrom == "" || migratingFrom == seEndpoint.Address {
+		return
+	}
+	for _, ep := range se.Endpoints {
+		if ep.Address == migratingFrom {
+			return
+		}
+	}
+	oldEndpoint := seEndpoint.DeepCopy()
+	oldEndpoint.Address = migratingFrom
+	se.Endpoints = append(se.Endpoints, oldEndpoint)
+}
+
+// startLBMigrationProcessor periodically completes the load balancer migrations of the cluster
+func startLBMigrationProcessor(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	soakDuration := common.GetLBMigrationSoakDuration()
+	if common.IsAdmiralOperatorMode() || soakDuration <= 0 {
+		return
+	}
+	ticker := time.NewTicker(soakDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() {
+				continue
+			}
+			processLBMigrationCompletion(context.Background(), rr, clusterID, soakDuration)
+		}
+	}
+}
+
+// processLBMigrationCompletion removes the endpoint of the old load balancer from the service entries
+// once the cluster was migrating for soakDuration and the new load balancer is verified. The migration
+// is kept, with both endpoints, when the verification fails so that it is retried later
+func processLBMigrationCompletion(ctx context.Context, rr *RemoteRegistry, cluster string, soakDuration time.Duration) {
+	if rr.AdmiralCache == nil || rr.AdmiralCache.LBMigrationCache == nil {
+		return
+	}
+	migration := rr.AdmiralCache.LBMigrationCache.Get(cluster)
+	if migration == nil || time.Since(migration.startedAt) < soakDuration {
+		return
+	}
+	ctxLogger := log.WithField("task", common.LBUpdateProcessor)
+	err := verifyLoadBalancer(migration.to)
+	if err != nil {
+		ctxLogger.Warnf(common.CtxLogFormat, "LBMigration", migration.to, "", cluster,
+			"new load balancer could not be verified, keeping "+migration.from+": "+err.Error())
+		return
+	}
+	rr.AdmiralCache.LBMigrationCache.Complete(cluster)
+	// the service entries are regenerated without the endpoint of the old load balancer
+	refreshClusterIngressEndpoints(ctx, rr, cluster, common.LBUpdateProcessor, "Completed migration from "+migration.from)
+}
+
+// refreshClusterIngressEndpoints re-triggers the ingress gateway service of the cluster so that the
+// service entries of all its workloads are regenerated with the current load balancer of the cluster
+func refreshClusterIngressEndpoints(ctx context.Context, rr *RemoteRegistry, cluster, task, message string) {
+	rc := rr.GetRemoteController(cluster)
+	if isServiceControllerInitialized(rc) != nil {
+		return
+	}
+	ctxLogger := log.WithField("task", task)
+	start := time.Now()
+	gatewayApp := common.GetAdmiralParams().LabelSet.GatewayApp
+	for _, svc := range rc.ServiceController.Cache.Get(common.NamespaceIstioSystem) {
+		if !common.IsIstioIngressGatewayService(svc, gatewayApp) {
+			continue
+		}
+		err := handleEventForService(context.WithValue(ctx, common.EventType, admiral.Update), svc, rr, cluster)
+		if err != nil {
+			util.LogElapsedTimeSinceTask(ctxLogger, task, svc.Name, "", cluster, "Error="+err.Error(), start)
+		} else {
+			util.LogElapsedTimeSinceTask(ctxLogger, task, svc.Name, "", cluster, message, start)
+		}
+		return
+	}
+}
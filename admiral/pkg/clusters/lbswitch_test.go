@@ -0,0 +1,138 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetLoadBalancerType(t *testing.T) {
+	serviceWith := func(annotations map[string]string, hostname string) *coreV1.Service {
+		svc := &coreV1.Service{ObjectMeta: metaV1.ObjectMeta{Annotations: annotations}}
+		if hostname != "" {
+			svc.Status.LoadBalancer.Ingress = []coreV1.LoadBalancerIngress{{Hostname: hostname}}
+		}
+		return svc
+	}
+	testCases := []struct {
+		name         string
+		svc          *coreV1.Service
+		expectedType string
+	}{
+		{
+			name: "Given a service annotated with the nlb load balancer type, " +
+				"When getLoadBalancerType is called, " +
+				"Then nlb should be returned",
+			svc:          serviceWith(map[string]string{awsLoadBalancerTypeAnnotation: "nlb"}, "foo.us-west-2.elb.amazonaws.com"),
+			expectedType: loadBalancerTypeNLB,
+		},
+		{
+			name: "Given a service with a network load balancer hostname, " +
+				"When getLoadBalancerType is called, " +
+				"Then nlb should be returned",
+			svc:          serviceWith(nil, "foo-123.elb.us-west-2.amazonaws.com."),
+			expectedType: loadBalancerTypeNLB,
+		},
+		{
+			name: "Given a service with a classic ELB hostname, " +
+				"When getLoadBalancerType is called, " +
+				"Then clb should be returned",
+			svc:          serviceWith(nil, "foo-123.us-west-2.elb.amazonaws.com"),
+			expectedType: loadBalancerTypeCLB,
+		},
+		{
+			name: "Given a service with a hostname which is not an AWS load balancer, " +
+				"When getLoadBalancerType is called, " +
+				"Then an empty type should be returned",
+			svc: serviceWith(nil, "lb.example.com"),
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedType, getLoadBalancerType(c.svc))
+		})
+	}
+}
+
+func TestGetPreferredLoadBalancerType(t *testing.T) {
+	admiralCache := &AdmiralCache{
+		NLBEnabledCluster: []string{"cluster1", "cluster2"},
+		CLBEnabledCluster: []string{"cluster2", "cluster3"},
+	}
+	assert.Equal(t, loadBalancerTypeNLB, getPreferredLoadBalancerType(admiralCache, "cluster1"))
+	assert.Equal(t, loadBalancerTypeCLB, getPreferredLoadBalancerType(admiralCache, "cluster2"))
+	assert.Equal(t, loadBalancerTypeCLB, getPreferredLoadBalancerType(admiralCache, "cluster3"))
+	assert.Equal(t, "", getPreferredLoadBalancerType(admiralCache, "cluster4"))
+	assert.Equal(t, "", getPreferredLoadBalancerType(nil, "cluster1"))
+}
+
+func TestLBMigrationCache(t *testing.T) {
+	cache := newLBMigrationCache()
+	cache.Observe("cluster1", "clb.example.com", time.Minute)
+	assert.Equal(t, "", cache.GetMigratingFrom("cluster1"), "the first load balancer observed should not start a migration")
+
+	cache.Observe("cluster1", common.DummyAdmiralGlobal, time.Minute)
+	cache.Observe("cluster1", "nlb.example.com", time.Minute)
+	assert.Equal(t, "clb.example.com", cache.GetMigratingFrom("cluster1"))
+	assert.Equal(t, "nlb.example.com", cache.Get("cluster1").to)
+
+	cache.Observe("cluster1", "clb.example.com", time.Minute)
+	assert.Nil(t, cache.Get("cluster1"), "switching back to the old load balancer should cancel the migration")
+
+	cache.Observe("cluster2", "clb.example.com", 0)
+	cache.Observe("cluster2", "nlb.example.com", 0)
+	assert.Equal(t, "", cache.GetMigratingFrom("cluster2"), "no migration should be started when the soak duration is not set")
+}
+
+func TestAddLBMigrationEndpoint(t *testing.T) {
+	seEndpoint := &networking.WorkloadEntry{
+		Address:  "nlb.example.com",
+		Locality: "us-west-2",
+		Ports:    map[string]uint32{"http": 15443},
+	}
+	se := &networking.ServiceEntry{
+		Hosts:     []string{"qa.foo.global"},
+		Endpoints: []*networking.WorkloadEntry{seEndpoint},
+	}
+
+	addLBMigrationEndpoint(se, seEndpoint, "")
+	assert.Len(t, se.Endpoints, 1)
+
+	addLBMigrationEndpoint(se, seEndpoint, "clb.example.com")
+	addLBMigrationEndpoint(se, seEndpoint, "clb.example.com")
+	assert.Len(t, se.Endpoints, 2)
+	assert.Equal(t, "clb.example.com", se.Endpoints[1].Address)
+	assert.Equal(t, seEndpoint.Ports, se.Endpoints[1].Ports)
+	assert.Equal(t, seEndpoint.Locality, se.Endpoints[1].Locality)
+}
+
+func TestProcessLBMigrationCompletion(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{})
+	defer func(verify func(string) error) { verifyLoadBalancer = verify }(verifyLoadBalancer)
+
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.LBMigrationCache.Observe("cluster1", "clb.example.com", time.Minute)
+	rr.AdmiralCache.LBMigrationCache.Observe("cluster1", "nlb.example.com", time.Minute)
+
+	verifyLoadBalancer = func(string) error { return nil }
+	processLBMigrationCompletion(context.Background(), rr, "cluster1", time.Hour)
+	assert.Equal(t, "clb.example.com", rr.AdmiralCache.LBMigrationCache.GetMigratingFrom("cluster1"),
+		"the migration should not complete before the soak duration elapsed")
+
+	verifyLoadBalancer = func(string) error { return errors.New("no such host") }
+	processLBMigrationCompletion(context.Background(), rr, "cluster1", 0)
+	assert.Equal(t, "clb.example.com", rr.AdmiralCache.LBMigrationCache.GetMigratingFrom("cluster1"),
+		"the old load balancer should be kept when the new one cannot be verified")
+
+	verifyLoadBalancer = func(string) error { return nil }
+	processLBMigrationCompletion(context.Background(), rr, "cluster1", 0)
+	assert.Equal(t, "", rr.AdmiralCache.LBMigrationCache.GetMigratingFrom("cluster1"))
+}
@@ -0,0 +1,22 @@
+package clusters
+
+import (
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+)
+
+// EnterMaintenanceMode stops admiral from writing to any cluster and buffers the events it
+// receives until ExitMaintenanceMode is called
+func EnterMaintenanceMode() {
+	admiral.EnterMaintenanceMode()
+}
+
+// ExitMaintenanceMode replays the writes queued while admiral was in maintenance mode to the
+// clusters which are not paused, unless the startup barrier is still raised, followed by the
+// events buffered in the meantime
+func ExitMaintenanceMode(rr *RemoteRegistry) {
+	admiral.ExitMaintenanceMode(func() {
+		if !isStartupBarrierRaised() {
+			replayUnpausedClusterWrites(rr)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitMaintenanceMode(t *testing.T) {
+	ctx := context.Background()
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1"})
+	rr.PutRemoteController("cluster2", &RemoteController{ClusterID: "cluster2"})
+	defer clearClusterWritePause("cluster1")
+	defer clearClusterWritePause("cluster2")
+
+	var replayed []string
+	write := func(cluster string) func(ctx context.Context, rc *RemoteController) error {
+		return func(ctx context.Context, rc *RemoteController) error {
+			replayed = append(replayed, rc.ClusterID)
+			return nil
+		}
+	}
+
+	EnterMaintenanceMode()
+	PauseClusterWrites("cluster2", WritePauseSourceAPI)
+	assert.True(t, queueWriteIfPaused(ctx, "cluster1", common.ServiceEntryResourceType, "ns", "foo-se", write("cluster1")))
+	assert.True(t, queueWriteIfPaused(ctx, "cluster2", common.ServiceEntryResourceType, "ns", "foo-se", write("cluster2")))
+
+	// writes are replayed to the clusters which are not paused once out of maintenance mode
+	ExitMaintenanceMode(rr)
+	assert.Equal(t, admiral.MaintenanceOff, admiral.GetMaintenanceState())
+	assert.Equal(t, []string{"cluster1"}, replayed)
+	assert.False(t, queueWriteIfPaused(ctx, "cluster1", common.ServiceEntryResourceType, "ns", "foo-se", write("cluster1")))
+
+	ResumeClusterWrites(rr, "cluster2", WritePauseSourceAPI)
+	assert.Equal(t, []string{"cluster1", "cluster2"}, replayed)
+}
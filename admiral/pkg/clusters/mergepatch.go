@@ -0,0 +1,76 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+)
+
+// patchResource sends the JSON merge patch of the changes from the original resource to the modified one
+// with the patch function, instead of updating the whole resource. Unlike an update, the patch does not
+// carry the resource version, so it does not conflict with the writes made to the resource in the meantime,
+// and only the changed fields are sent. Nothing is sent when the resources do not differ.
+// The resource is updated instead when the patch is forbidden, as the roles granted to admiral by the older
+// installs do not allow it
+func patchResource(original, modified interface{}, patch func(data []byte) error, update func() error) error {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+	modifiedJSON, err := json.Marshal(modified)
+	if err != nil {
+		return err
+	}
+	data, err := jsonmergepatch.CreateThreeWayJSONMergePatch(originalJSON, modifiedJSON, originalJSON)
+	if err != nil {
+		return err
+	}
+	if string(data) == "{}" {
+		return nil
+	}
+	err = patch(data)
+	if k8sErrors.IsForbidden(err) {
+		log.Warnf("patch forbidden, falling back to update: %v", err)
+		return update()
+	}
+	return err
+}
+
+func patchVirtualService(ctx context.Context, rc *RemoteController, namespace string, original, modified *v1alpha3.VirtualService) error {
+	return patchResource(original, modified, func(data []byte) error {
+		_, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).
+			Patch(ctx, modified.Name, types.MergePatchType, data, metaV1.PatchOptions{})
+		return err
+	}, func() error {
+		_, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, modified, metaV1.UpdateOptions{})
+		return err
+	})
+}
+
+func patchServiceEntry(ctx context.Context, rc *RemoteController, namespace string, original, modified *v1alpha3.ServiceEntry) error {
+	return patchResource(original, modified, func(data []byte) error {
+		_, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).
+			Patch(ctx, modified.Name, types.MergePatchType, data, metaV1.PatchOptions{})
+		return err
+	}, func() error {
+		_, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Update(ctx, modified, metaV1.UpdateOptions{})
+		return err
+	})
+}
+
+func patchDestinationRule(ctx context.Context, rc *RemoteController, namespace string, original, modified *v1alpha3.DestinationRule) error {
+	return patchResource(original, modified, func(data []byte) error {
+		_, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).
+			Patch(ctx, modified.Name, types.MergePatchType, data, metaV1.PatchOptions{})
+		return err
+	}, func() error {
+		_, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).Update(ctx, modified, metaV1.UpdateOptions{})
+		return err
+	})
+}
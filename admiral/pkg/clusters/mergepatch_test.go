@@ -0,0 +1,122 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	"istio.io/api/networking/v1alpha3"
+	apiNetworkingV1Alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPatchResource(t *testing.T) {
+	original := &apiNetworkingV1Alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-vs", Namespace: "sync", Labels: map[string]string{"app": "foo", "env": "stage"}},
+		Spec:       v1alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+	unchanged := original.DeepCopy()
+	changedSpec := original.DeepCopy()
+	changedSpec.Spec.Hosts = []string{"stage.bar.global"}
+	removedLabel := original.DeepCopy()
+	delete(removedLabel.Labels, "env")
+
+	testCases := []struct {
+		name          string
+		modified      *apiNetworkingV1Alpha3.VirtualService
+		expectedPatch string
+	}{
+		{
+			name:          "Given an unchanged virtualservice, When it is patched, Then nothing is sent",
+			modified:      unchanged,
+			expectedPatch: "",
+		},
+		{
+			name:          "Given a virtualservice with a changed spec, When it is patched, Then only the spec is sent",
+			modified:      changedSpec,
+			expectedPatch: `{"spec":{"hosts":["stage.bar.global"]}}`,
+		},
+		{
+			name:          "Given a virtualservice with a removed label, When it is patched, Then the label is set to null",
+			modified:      removedLabel,
+			expectedPatch: `{"metadata":{"labels":{"env":null}}}`,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			var sent string
+			err := patchResource(original, c.modified, func(data []byte) error {
+				sent = string(data)
+				return nil
+			}, func() error {
+				t.Fatal("the resource should not be updated")
+				return nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectedPatch, sent)
+		})
+	}
+}
+
+func TestPatchVirtualService(t *testing.T) {
+	ctx := context.Background()
+	existing := &apiNetworkingV1Alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-vs", Namespace: "sync", Labels: map[string]string{"app": "foo"}},
+		Spec:       v1alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+	client := istioFake.NewSimpleClientset(existing)
+	rc := &RemoteController{
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: client},
+	}
+	modified := existing.DeepCopy()
+	modified.Spec.Hosts = []string{"stage.foo.global", "foo.global"}
+
+	err := patchVirtualService(ctx, rc, "sync", existing, modified)
+	assert.Nil(t, err)
+	vs, err := client.NetworkingV1alpha3().VirtualServices("sync").Get(ctx, "stage.foo.global-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stage.foo.global", "foo.global"}, vs.Spec.Hosts)
+	assert.Equal(t, map[string]string{"app": "foo"}, vs.Labels)
+}
+
+func TestPatchFallsBackToUpdateWhenForbidden(t *testing.T) {
+	ctx := context.Background()
+	existing := &apiNetworkingV1Alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "sync"},
+		Spec:       v1alpha3.ServiceEntry{Hosts: []string{"stage.foo.global"}},
+	}
+	client := istioFake.NewSimpleClientset(existing)
+	client.PrependReactor("patch", "serviceentries", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sErrors.NewForbidden(schema.GroupResource{}, "", nil)
+	})
+	rc := &RemoteController{
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: client},
+	}
+	modified := existing.DeepCopy()
+	modified.Spec.Hosts = []string{"stage.foo.global", "foo.global"}
+
+	err := patchServiceEntry(ctx, rc, "sync", existing, modified)
+	assert.Nil(t, err)
+	se, err := client.NetworkingV1alpha3().ServiceEntries("sync").Get(ctx, "stage.foo.global-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stage.foo.global", "foo.global"}, se.Spec.Hosts)
+	var verbs []string
+	for _, action := range client.Actions() {
+		verbs = append(verbs, action.GetVerb())
+	}
+	assert.Equal(t, []string{"patch", "update", "get"}, verbs)
+
+	// the other errors are returned
+	client.PrependReactor("patch", "serviceentries", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sErrors.NewConflict(schema.GroupResource{}, "", nil)
+	})
+	modified.Spec.Hosts = []string{"stage.foo.global"}
+	assert.True(t, k8sErrors.IsConflict(patchServiceEntry(ctx, rc, "sync", se, modified)))
+}
@@ -0,0 +1,297 @@
+package clusters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	meshFederationFetchTimeout  = 30 * time.Second
+	federatedServiceEntrySuffix = "-federated-se"
+)
+
+// MeshFederationExport is the document through which the services of a mesh are federated with other
+// meshes, it is returned by /federation/export and read from the federation endpoints of the imported meshes
+type MeshFederationExport struct {
+	Mesh     string             `json:"mesh"`
+	Services []FederatedService `json:"services"`
+}
+
+// FederatedService is a service of a mesh reachable from the other meshes through the endpoints
+// of its ingress gateways
+type FederatedService struct {
+	Host            string              `json:"host"`
+	Identity        string              `json:"identity,omitempty"`
+	Ports           []FederatedPort     `json:"ports"`
+	Endpoints       []FederatedEndpoint `json:"endpoints"`
+	SubjectAltNames []string            `json:"subjectAltNames,omitempty"`
+}
+
+type FederatedPort struct {
+	Name     string `json:"name"`
+	Number   uint32 `json:"number"`
+	Protocol string `json:"protocol"`
+}
+
+type FederatedEndpoint struct {
+	Address  string            `json:"address"`
+	Locality string            `json:"locality,omitempty"`
+	Ports    map[string]uint32 `json:"ports,omitempty"`
+}
+
+// fetchMeshFederationServices returns the body of the federation endpoint of an imported mesh
+var fetchMeshFederationServices = func(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, meshFederationFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("federation endpoint returned %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// GetMeshFederationExport returns the services admiral generated service entries for, with the
+// endpoints through which they are reached from the other clusters
+func GetMeshFederationExport(rr *RemoteRegistry) MeshFederationExport {
+	export := MeshFederationExport{Mesh: common.GetMeshFederationName(), Services: make([]FederatedService, 0)}
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.SeClusterCache == nil {
+		return export
+	}
+	rr.AdmiralCache.SeClusterCache.Range(func(host string, clusters *common.Map) {
+		service := FederatedService{Host: host}
+		if rr.AdmiralCache.CnameIdentityCache != nil {
+			if identity, ok := rr.AdmiralCache.CnameIdentityCache.Load(host); ok {
+				service.Identity = fmt.Sprintf("%v", identity)
+			}
+		}
+		clusterIDs := clusters.GetKeys()
+		sort.Strings(clusterIDs)
+		for _, clusterID := range clusterIDs {
+			rc := rr.GetRemoteController(clusterID)
+			if rc == nil || rc.ServiceEntryController == nil || rc.ServiceEntryController.Cache == nil {
+				continue
+			}
+			se := rc.ServiceEntryController.Cache.Get(getIstioResourceName(host, "-se"), clusterID)
+			if se == nil {
+				continue
+			}
+			addFederatedServiceEntry(&service, &se.Spec)
+		}
+		if len(service.Endpoints) > 0 {
+			export.Services = append(export.Services, service)
+		}
+	})
+	sort.Slice(export.Services, func(i, j int) bool {
+		return export.Services[i].Host < export.Services[j].Host
+	})
+	return export
+}
+
+// addFederatedServiceEntry adds the ports, the subject alt names and the remote endpoints of the
+// service entry to the federated service, the endpoints local to a cluster are not reachable
+// from the other meshes
+func addFederatedServiceEntry(service *FederatedService, se *networking.ServiceEntry) {
+	if len(service.Ports) == 0 {
+		for _, port := range se.Ports {
+			service.Ports = append(service.Ports, FederatedPort{Name: port.Name, Number: port.Number, Protocol: port.Protocol})
+		}
+	}
+	for _, san := range se.SubjectAltNames {
+		if !common.IsPresent(service.SubjectAltNames, san) {
+			service.SubjectAltNames = append(service.SubjectAltNames, san)
+		}
+	}
+	for _, ep := range se.Endpoints {
+		if strings.HasSuffix(strings.TrimSuffix(ep.Address, common.Sep), common.DotLocalDomainSuffix) {
+			continue
+		}
+		exists := false
+		for _, federatedEndpoint := range service.Endpoints {
+			if federatedEndpoint.Address == ep.Address {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			service.Endpoints = append(service.Endpoints, FederatedEndpoint{Address: ep.Address, Locality: ep.Locality, Ports: ep.Ports})
+		}
+	}
+}
+
+// parseFederatedServices returns the services of the body of a federation endpoint, either a
+// MeshFederationExport or a list of the ServiceEntries exported by the mesh
+func parseFederatedServices(body []byte) ([]FederatedService, error) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("[")) {
+		var serviceEntries []*v1alpha3.ServiceEntry
+		if err := json.Unmarshal(body, &serviceEntries); err != nil {
+			return nil, err
+		}
+		services := make([]FederatedService, 0)
+		for _, se := range serviceEntries {
+			if se == nil {
+				continue
+			}
+			for _, host := range se.Spec.Hosts {
+				service := FederatedService{Host: host}
+				addFederatedServiceEntry(&service, &se.Spec)
+				services = append(services, service)
+			}
+		}
+		return services, nil
+	}
+	var export MeshFederationExport
+	if err := json.Unmarshal(body, &export); err != nil {
+		return nil, err
+	}
+	return export.Services, nil
+}
+
+// buildFederatedServiceEntry returns the service entry through which the workloads of the cluster
+// reach a service of another mesh
+func buildFederatedServiceEntry(mesh string, service FederatedService, namespace string) *v1alpha3.ServiceEntry {
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      getIstioResourceName(service.Host, federatedServiceEntrySuffix),
+			Namespace: namespace,
+			Labels:    map[string]string{common.AdmiralFederatedMeshLabel: mesh},
+		},
+		Spec: networking.ServiceEntry{
+			Hosts:           []string{service.Host},
+			Location:        networking.ServiceEntry_MESH_INTERNAL,
+			Resolution:      networking.ServiceEntry_DNS,
+			SubjectAltNames: service.SubjectAltNames,
+		},
+	}
+	for _, port := range service.Ports {
+		se.Spec.Ports = append(se.Spec.Ports, &networking.ServicePort{Name: port.Name, Number: port.Number, Protocol: port.Protocol})
+	}
+	for _, ep := range service.Endpoints {
+		se.Spec.Endpoints = append(se.Spec.Endpoints, &networking.WorkloadEntry{
+			Address:  ep.Address,
+			Locality: ep.Locality,
+			Ports:    ep.Ports,
+			Labels:   map[string]string{"security.istio.io/tlsMode": "istio"},
+		})
+	}
+	return se
+}
+
+// startMeshFederationImport periodically imports the services of the other meshes into the cluster
+func startMeshFederationImport(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	interval := common.GetMeshFederationImportInterval()
+	if common.IsAdmiralOperatorMode() || len(common.GetMeshFederationImports()) == 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			// the remote controller is looked up on every import, as it is replaced when its istio
+			// controllers are started or stopped
+			importMeshFederationServices(context.Background(), rr, rr.GetRemoteController(clusterID))
+		}
+	}
+}
+
+// importMeshFederationServices writes a service entry to the cluster for each service of the imported
+// meshes, and deletes those of the services the meshes no longer export. The hosts admiral generates
+// service entries for are never imported, and the service entries of a mesh whose federation endpoint
+// cannot be read are kept as they are
+func importMeshFederationServices(ctx context.Context, rr *RemoteRegistry, rc *RemoteController) {
+	if rc == nil || rc.ServiceEntryController == nil {
+		return
+	}
+	ctxLogger := log.WithFields(log.Fields{
+		"task":        common.MeshFederation,
+		"clusterName": rc.ClusterID,
+	})
+	namespace := common.GetSyncNamespaceForCluster(rc.ClusterID)
+	imports := common.GetMeshFederationImports()
+	meshes := make([]string, 0, len(imports))
+	for mesh := range imports {
+		meshes = append(meshes, mesh)
+	}
+	sort.Strings(meshes)
+
+	imported := make(map[string]map[string]bool)
+	for _, mesh := range meshes {
+		body, err := fetchMeshFederationServices(ctx, imports[mesh])
+		if err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.MeshFederation, mesh, namespace, rc.ClusterID, "failed to fetch the federated services: "+err.Error())
+			continue
+		}
+		services, err := parseFederatedServices(body)
+		if err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.MeshFederation, mesh, namespace, rc.ClusterID, "failed to parse the federated services: "+err.Error())
+			continue
+		}
+		imported[mesh] = make(map[string]bool)
+		for _, service := range services {
+			if service.Host == "" || len(service.Ports) == 0 || len(service.Endpoints) == 0 {
+				continue
+			}
+			if rr.AdmiralCache != nil && rr.AdmiralCache.CnameIdentityCache != nil {
+				if _, ok := rr.AdmiralCache.CnameIdentityCache.Load(strings.ToLower(service.Host)); ok {
+					ctxLogger.Warnf(common.CtxLogFormat, common.MeshFederation, service.Host, namespace, rc.ClusterID,
+						"skipped as the host of mesh "+mesh+" is generated by admiral")
+					continue
+				}
+			}
+			se := buildFederatedServiceEntry(mesh, service, namespace)
+			imported[mesh][se.Name] = true
+			exist := rc.ServiceEntryController.Cache.Get(se.Name, rc.ClusterID)
+			if err := addUpdateServiceEntry(ctxLogger, ctx, se, exist, namespace, rc); err != nil {
+				ctxLogger.Errorf(common.CtxLogFormat, common.MeshFederation, se.Name, namespace, rc.ClusterID, err.Error())
+			}
+		}
+	}
+
+	serviceEntries, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: common.AdmiralFederatedMeshLabel,
+	})
+	if err != nil {
+		ctxLogger.Errorf(common.CtxLogFormat, common.MeshFederation, "", namespace, rc.ClusterID, "failed to list the federated service entries: "+err.Error())
+		return
+	}
+	for _, se := range serviceEntries.Items {
+		mesh := se.Labels[common.AdmiralFederatedMeshLabel]
+		if _, configured := imports[mesh]; configured {
+			if services, fetched := imported[mesh]; !fetched || services[se.Name] {
+				continue
+			}
+		}
+		ctxLogger.Infof(common.CtxLogFormat, common.MeshFederation, se.Name, namespace, rc.ClusterID, "deleting the service entry no longer exported by mesh "+mesh)
+		if err := deleteServiceEntry(ctx, se, namespace, rc); err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, common.MeshFederation, se.Name, namespace, rc.ClusterID, err.Error())
+		}
+	}
+}
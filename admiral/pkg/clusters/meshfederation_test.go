@@ -0,0 +1,158 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseFederatedServices(t *testing.T) {
+	testCases := []struct {
+		name             string
+		body             string
+		expectedServices []FederatedService
+		expectedErr      bool
+	}{
+		{
+			name: "Given the services exported by a mesh, " +
+				"When parseFederatedServices is called, " +
+				"Then the services should be returned",
+			body: `{"mesh":"other","services":[{"host":"foo.other.mesh","ports":[{"name":"http","number":80,"protocol":"http"}],` +
+				`"endpoints":[{"address":"lb.other.com","ports":{"http":15443}}]}]}`,
+			expectedServices: []FederatedService{{
+				Host:      "foo.other.mesh",
+				Ports:     []FederatedPort{{Name: "http", Number: 80, Protocol: "http"}},
+				Endpoints: []FederatedEndpoint{{Address: "lb.other.com", Ports: map[string]uint32{"http": 15443}}},
+			}},
+		},
+		{
+			name: "Given the ServiceEntries exported by a mesh, " +
+				"When parseFederatedServices is called, " +
+				"Then a service should be returned per host with the remote endpoints",
+			body: `[{"metadata":{"name":"foo-se"},"spec":{"hosts":["foo.other.mesh"],"ports":[{"name":"http","number":80,"protocol":"http"}],` +
+				`"endpoints":[{"address":"foo.ns.svc.cluster.local"},{"address":"lb.other.com","locality":"us-west-2"}],"subjectAltNames":["spiffe://other/foo"]}}]`,
+			expectedServices: []FederatedService{{
+				Host:            "foo.other.mesh",
+				Ports:           []FederatedPort{{Name: "http", Number: 80, Protocol: "http"}},
+				Endpoints:       []FederatedEndpoint{{Address: "lb.other.com", Locality: "us-west-2"}},
+				SubjectAltNames: []string{"spiffe://other/foo"},
+			}},
+		},
+		{
+			name: "Given an invalid body, " +
+				"When parseFederatedServices is called, " +
+				"Then an error should be returned",
+			body:        "not json",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			services, err := parseFederatedServices([]byte(c.body))
+			assert.Equal(t, c.expectedErr, err != nil)
+			assert.Equal(t, c.expectedServices, services)
+		})
+	}
+}
+
+func TestGetMeshFederationExport(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{MeshFederationName: "admiral"})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	newSE := func(address string) *v1alpha3.ServiceEntry {
+		return &v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "qa.foo.global-se"},
+			Spec: networking.ServiceEntry{
+				Hosts: []string{"qa.foo.global"},
+				Ports: []*networking.ServicePort{{Name: "http", Number: 80, Protocol: "http"}},
+				Endpoints: []*networking.WorkloadEntry{
+					{Address: "foo.ns.svc.cluster.local", Ports: map[string]uint32{"http": 8080}},
+					{Address: address, Locality: "us-west-2", Ports: map[string]uint32{"http": 15443}},
+				},
+			},
+		}
+	}
+	for cluster, address := range map[string]string{"cluster1": "lb.cluster2.com", "cluster2": "lb.cluster1.com"} {
+		cache := istio.NewServiceEntryCache()
+		cache.Put(newSE(address), cluster)
+		rr.PutRemoteController(cluster, &RemoteController{ClusterID: cluster, ServiceEntryController: &istio.ServiceEntryController{Cache: cache}})
+		rr.AdmiralCache.SeClusterCache.Put("qa.foo.global", cluster, cluster)
+	}
+	rr.AdmiralCache.CnameIdentityCache.Store("qa.foo.global", "foo")
+
+	assert.Equal(t, MeshFederationExport{
+		Mesh: "admiral",
+		Services: []FederatedService{{
+			Host:     "qa.foo.global",
+			Identity: "foo",
+			Ports:    []FederatedPort{{Name: "http", Number: 80, Protocol: "http"}},
+			Endpoints: []FederatedEndpoint{
+				{Address: "lb.cluster2.com", Locality: "us-west-2", Ports: map[string]uint32{"http": 15443}},
+				{Address: "lb.cluster1.com", Locality: "us-west-2", Ports: map[string]uint32{"http": 15443}},
+			},
+		}},
+	}, GetMeshFederationExport(rr))
+}
+
+func TestImportMeshFederationServices(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		SyncNamespace:         "admiral-sync",
+		MeshFederationImports: map[string]string{"other": "http://federation.other.com/export"},
+	})
+	defer func(fetch func(context.Context, string) ([]byte, error)) {
+		fetchMeshFederationServices = fetch
+	}(fetchMeshFederationServices)
+
+	ctx := context.Background()
+	istioClient := istioFake.NewSimpleClientset(&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+		Name:      "bar.removed.mesh-federated-se",
+		Namespace: "admiral-sync",
+		Labels:    map[string]string{common.AdmiralFederatedMeshLabel: "removed"},
+	}})
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient, Cache: istio.NewServiceEntryCache()},
+	}
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.AdmiralCache.CnameIdentityCache.Store("qa.foo.global", "foo")
+
+	body := `{"mesh":"other","services":[` +
+		`{"host":"foo.other.mesh","ports":[{"name":"http","number":80,"protocol":"http"}],"endpoints":[{"address":"lb.other.com","ports":{"http":15443}}]},` +
+		`{"host":"qa.foo.global","ports":[{"name":"http","number":80,"protocol":"http"}],"endpoints":[{"address":"lb.other.com","ports":{"http":15443}}]}]}`
+	fetchMeshFederationServices = func(context.Context, string) ([]byte, error) {
+		return []byte(body), nil
+	}
+	importMeshFederationServices(ctx, rr, rc)
+
+	se, err := istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "foo.other.mesh-federated-se", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "other", se.Labels[common.AdmiralFederatedMeshLabel])
+	assert.Equal(t, "lb.other.com", se.Spec.Endpoints[0].Address)
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "qa.foo.global-federated-se", metaV1.GetOptions{})
+	assert.NotNil(t, err, "the hosts generated by admiral should not be imported")
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "bar.removed.mesh-federated-se", metaV1.GetOptions{})
+	assert.NotNil(t, err, "the service entries of the meshes no longer imported should be deleted")
+
+	fetchMeshFederationServices = func(context.Context, string) ([]byte, error) {
+		return nil, errors.New("connection refused")
+	}
+	importMeshFederationServices(ctx, rr, rc)
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "foo.other.mesh-federated-se", metaV1.GetOptions{})
+	assert.Nil(t, err, "the imported service entries should be kept when the federation endpoint cannot be read")
+
+	fetchMeshFederationServices = func(context.Context, string) ([]byte, error) {
+		return []byte(`{"mesh":"other","services":[]}`), nil
+	}
+	importMeshFederationServices(ctx, rr, rc)
+	_, err = istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").Get(ctx, "foo.other.mesh-federated-se", metaV1.GetOptions{})
+	assert.NotNil(t, err, "the service entries of the services no longer exported should be deleted")
+}
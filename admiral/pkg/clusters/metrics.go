@@ -9,4 +9,69 @@ var (
 		"total_config_write_invocations",
 		"total number of times config writer was invoked",
 		monitoring.WithMeter(configWriterMeter))
+	clusterTierViolations = monitoring.NewCounter(
+		"cluster_tier_violations",
+		"total number of resources blocked from being written to a cluster of a different tier")
+	exportToCapExceeded = monitoring.NewCounter(
+		"exportto_cap_exceeded",
+		"total number of times the dependent namespaces exceeded the exportTo cap and the fallback was used")
+	resourcePolicyRejections = monitoring.NewCounter(
+		"resource_policy_rejections",
+		"total number of generated resources rejected by the resource policy evaluator")
+	resourceValidationRejections = monitoring.NewCounter(
+		"resource_validation_rejections",
+		"total number of generated resources rejected as invalid before they were applied")
+	ownershipConflicts = monitoring.NewCounter(
+		"ownership_conflicts",
+		"total number of writes refused because the existing resource was not owned by admiral")
+	externalReverts = monitoring.NewCounter(
+		"external_reverts",
+		"total number of generated resources found changed by something other than admiral")
+	cohortMutations = monitoring.NewCounter(
+		"cohort_mutations",
+		"total number of creates, updates and deletes admiral performed, per cluster cohort")
+	teamMutations = monitoring.NewCounter(
+		"team_mutations",
+		"total number of creates, updates and deletes admiral performed, per team of the identity of the resource")
+	hostConflicts = monitoring.NewCounter(
+		"host_conflicts",
+		"total number of claims on a host refused because the host was owned by another identity or virtualservice")
+	vsNameCollisions = monitoring.NewCounter(
+		"vs_name_collisions",
+		"total number of virtualservice syncs refused because the generated name was owned by another virtualservice")
+	ingressFailovers = monitoring.NewCounter(
+		"ingress_failovers",
+		"total number of times a cluster failed over to, or back from, its secondary ingress endpoint")
+	missingDependencies = monitoring.NewCounter(
+		"missing_dependencies",
+		"total number of calls observed in the mesh telemetry to identities missing from the dependency record of the caller")
+	coalescedEvents = monitoring.NewCounter(
+		"coalesced_events",
+		"total number of workload events collapsed into the processing pass of an earlier event of the same identity and environment")
+	remoteAPILatency = monitoring.NewHistogram(
+		"remote_api_latency_seconds",
+		"latency of the requests admiral sent to the API server of each remote cluster",
+		"s")
+	remoteAPIErrors = monitoring.NewCounter(
+		"remote_api_errors",
+		"total number of requests to the API server of each remote cluster which failed, per class of error")
+	identityLockWaitTime = monitoring.NewHistogram(
+		"identity_lock_wait_seconds",
+		"time the processing of an identity waited for the lock held by an earlier processing of the same identity",
+		"s")
+	stuckIdentityLocks = monitoring.NewCounter(
+		"stuck_identity_locks",
+		"total number of identity locks held beyond the hold threshold")
+	evictedCacheEntries = monitoring.NewCounter(
+		"evicted_cache_entries",
+		"total number of cache entries evicted as their identity was gone from all the clusters beyond the ttl")
+	quotaViolations = monitoring.NewCounter(
+		"quota_violations",
+		"total number of times an identity exceeded a quota on the resources generated for it, per quota")
+	cacheInconsistencies = monitoring.NewCounter(
+		"cache_inconsistencies",
+		"total number of cache entries found not backed by the informer stores of their cluster")
+	trafficShiftSteps = monitoring.NewCounter(
+		"traffic_shift_steps",
+		"total number of steps the traffic shifts of the GTPs took, per state the shift was left in")
 )
@@ -0,0 +1,80 @@
+package clusters
+
+import (
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+)
+
+// lazyNamespaceController is the namespace controller of a cluster, which caches the namespaces to read
+// their labels from. It is only started once a namespace deny selector is configured for the cluster,
+// either when the cluster is added or later through the dynamic config
+type lazyNamespaceController struct {
+	lock         sync.Mutex
+	clientConfig *rest.Config
+	resyncPeriod time.Duration
+	clientLoader loader.ClientLoader
+	stop         <-chan struct{}
+	controller   *admiral.NamespaceController
+}
+
+// get returns the namespace controller, starting it on the first call
+func (l *lazyNamespaceController) get(cluster string) (*admiral.NamespaceController, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.controller == nil {
+		log.Infof("starting NamespaceController clusterID: %v", cluster)
+		controller, err := admiral.NewNamespaceController(l.stop, l.clientConfig, l.resyncPeriod, l.clientLoader)
+		if err != nil {
+			return nil, err
+		}
+		l.controller = controller
+	}
+	return l.controller, nil
+}
+
+// isNamespaceFiltered checks if the namespace has been excluded from processing for the cluster,
+// either by the namespace allow/deny lists or by the deny label selector.
+// The filters are read on every call so that they can be reloaded at runtime. A filter change only
+// applies to the events processed after it: the resources generated for a namespace before it was
+// filtered are kept until they are deleted, and a namespace which is no longer filtered is processed
+// again on the next event or resync of its workloads.
+func isNamespaceFiltered(remoteRegistry *RemoteRegistry, cluster, namespace string) bool {
+	if common.IsNamespaceFilteredByName(cluster, namespace) {
+		return true
+	}
+	denySelector := common.GetNamespaceDenySelector(cluster)
+	if denySelector == "" {
+		return false
+	}
+	selector, err := labels.Parse(denySelector)
+	if err != nil {
+		log.Errorf(LogErrFormat, "NamespaceFilter", "namespace", namespace, cluster, "invalid namespace deny selector="+denySelector+", err: "+err.Error())
+		return false
+	}
+	if remoteRegistry == nil {
+		return false
+	}
+	// the labels are read from the namespaces cached by the namespace controller of the cluster
+	rc := remoteRegistry.GetRemoteController(cluster)
+	if rc == nil || rc.namespaces == nil {
+		return false
+	}
+	namespaceController, err := rc.namespaces.get(cluster)
+	if err != nil {
+		log.Errorf(LogErrFormat, "NamespaceFilter", "namespace", namespace, cluster, "failed to start the namespace controller, err: "+err.Error())
+		return false
+	}
+	ns, err := namespaceController.Get(namespace)
+	if err != nil {
+		log.Warnf(LogFormat, "NamespaceFilter", "namespace", namespace, cluster, "failed to get namespace object, err: "+err.Error())
+		return false
+	}
+	return selector.Matches(labels.Set(ns.Labels))
+}
@@ -0,0 +1,133 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sFake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestIsNamespaceFiltered(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:               &common.LabelSet{},
+		NamespaceDenyList:      map[string]string{"*": "test-.*"},
+		NamespaceDenySelectors: map[string]string{"cluster1": "admiral.io/noisy=true", "cluster2": "!!"},
+	})
+	config := &rest.Config{Host: "namespace-filter.cluster"}
+	loader.FakeKubeClientMap[config.Host] = k8sFake.NewSimpleClientset(
+		&coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "noisy-ns", Labels: map[string]string{"admiral.io/noisy": "true"}}},
+		&coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "payments"}},
+	)
+	defer delete(loader.FakeKubeClientMap, config.Host)
+	stop := make(chan struct{})
+	defer close(stop)
+	namespaceController, err := admiral.NewNamespaceController(stop, config, 0, loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	assert.Eventually(t, namespaceController.HasSynced, time.Second, 10*time.Millisecond)
+	remoteRegistry := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	remoteRegistry.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:  "cluster1",
+		namespaces: &lazyNamespaceController{controller: namespaceController},
+	})
+	remoteRegistry.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:  "cluster2",
+		namespaces: &lazyNamespaceController{controller: namespaceController},
+	})
+
+	testCases := []struct {
+		name      string
+		cluster   string
+		namespace string
+		expected  bool
+	}{
+		{
+			name: "Given a namespace matching the deny list, " +
+				"When isNamespaceFiltered is called, " +
+				"Then it should return true",
+			cluster:   "cluster1",
+			namespace: "test-payments",
+			expected:  true,
+		},
+		{
+			name: "Given a namespace with labels matching the deny selector, " +
+				"When isNamespaceFiltered is called, " +
+				"Then it should return true",
+			cluster:   "cluster1",
+			namespace: "noisy-ns",
+			expected:  true,
+		},
+		{
+			name: "Given a namespace not matching any filter, " +
+				"When isNamespaceFiltered is called, " +
+				"Then it should return false",
+			cluster:   "cluster1",
+			namespace: "payments",
+			expected:  false,
+		},
+		{
+			name: "Given a namespace which does not exist, " +
+				"When isNamespaceFiltered is called, " +
+				"Then it should return false",
+			cluster:   "cluster1",
+			namespace: "missing",
+			expected:  false,
+		},
+		{
+			name: "Given an invalid deny selector for the cluster, " +
+				"When isNamespaceFiltered is called, " +
+				"Then it should return false",
+			cluster:   "cluster2",
+			namespace: "noisy-ns",
+			expected:  false,
+		},
+		{
+			name: "Given a cluster without a deny selector, " +
+				"When isNamespaceFiltered is called for a labelled namespace, " +
+				"Then it should return false",
+			cluster:   "cluster3",
+			namespace: "noisy-ns",
+			expected:  false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isNamespaceFiltered(remoteRegistry, tc.cluster, tc.namespace))
+		})
+	}
+}
+
+func TestIsNamespaceFilteredStartsTheNamespaceController(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	config := &rest.Config{Host: "lazy-namespace-filter.cluster"}
+	loader.FakeKubeClientMap[config.Host] = k8sFake.NewSimpleClientset(
+		&coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "noisy-ns", Labels: map[string]string{"admiral.io/noisy": "true"}}},
+	)
+	defer delete(loader.FakeKubeClientMap, config.Host)
+	stop := make(chan struct{})
+	defer close(stop)
+	namespaces := &lazyNamespaceController{clientConfig: config, clientLoader: loader.GetFakeClientLoader(), stop: stop}
+	remoteRegistry := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	remoteRegistry.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1", namespaces: namespaces})
+
+	assert.False(t, isNamespaceFiltered(remoteRegistry, "cluster1", "noisy-ns"))
+	assert.Nil(t, namespaces.controller, "the namespace controller should not be started without a deny selector")
+
+	// the deny selector is added through the dynamic config
+	params := common.GetAdmiralParams()
+	params.NamespaceDenySelectors = map[string]string{"*": "admiral.io/noisy=true"}
+	common.UpdateAdmiralParams(params)
+	isNamespaceFiltered(remoteRegistry, "cluster1", "noisy-ns")
+	assert.NotNil(t, namespaces.controller)
+	assert.Eventually(t, namespaces.controller.HasSynced, time.Second, 10*time.Millisecond)
+	assert.True(t, isNamespaceFiltered(remoteRegistry, "cluster1", "noisy-ns"))
+}
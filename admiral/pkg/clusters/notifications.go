@@ -0,0 +1,71 @@
+package clusters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/notifier"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// markClusterUnreachable records the first time the cluster was found unreachable and sends
+// a notification once it has been unreachable for longer than the configured duration
+func markClusterUnreachable(rr *RemoteRegistry, cluster string) {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.UnreachableClusterCache == nil {
+		return
+	}
+	since, _ := rr.AdmiralCache.UnreachableClusterCache.LoadOrStore(cluster, time.Now())
+	unreachableFor := time.Since(since.(time.Time))
+	if unreachableFor < common.GetClusterUnreachableAlertDuration() {
+		return
+	}
+	notifier.Send(notifier.Notification{
+		Key:      "cluster-unreachable/" + cluster,
+		Severity: notifier.SeverityCritical,
+		Title:    "Cluster unreachable",
+		Message:  fmt.Sprintf("cluster %s has been unreachable for %s", cluster, unreachableFor.Round(time.Second)),
+	})
+}
+
+// markClusterReachable clears the unreachable state of the cluster
+func markClusterReachable(rr *RemoteRegistry, cluster string) {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.UnreachableClusterCache == nil {
+		return
+	}
+	rr.AdmiralCache.UnreachableClusterCache.Delete(cluster)
+}
+
+// notifyIdentityWithoutEndpoints sends a notification when a delete left none of the
+// service entries generated for the identity with an endpoint
+func notifyIdentityWithoutEndpoints(event admiral.EventType, identity, env string, serviceEntries map[string]*networking.ServiceEntry) {
+	if event != admiral.Delete || len(serviceEntries) == 0 {
+		return
+	}
+	for _, se := range serviceEntries {
+		if se != nil && len(se.Endpoints) > 0 {
+			return
+		}
+	}
+	notifier.Send(notifier.Notification{
+		Key:      "identity-without-endpoints/" + common.ConstructKeyWithEnvAndIdentity(env, identity),
+		Severity: notifier.SeverityCritical,
+		Title:    "Identity has no endpoints",
+		Message:  fmt.Sprintf("identity %s in env %s has no endpoints left after a delete", identity, env),
+	})
+}
+
+// notifyGarbageCollection sends a notification when a clean up deleted more resources
+// from the cluster than the configured threshold
+func notifyGarbageCollection(cleanup, cluster string, deleted int) {
+	if deleted <= common.GetGarbageCollectionAlertThreshold() {
+		return
+	}
+	notifier.Send(notifier.Notification{
+		Key:      "garbage-collection/" + cleanup + "/" + cluster,
+		Severity: notifier.SeverityWarning,
+		Title:    "Large garbage collection",
+		Message:  fmt.Sprintf("%s deleted %d resources from cluster %s", cleanup, deleted, cluster),
+	})
+}
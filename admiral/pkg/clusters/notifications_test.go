@@ -0,0 +1,90 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestNotifications(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                        &common.LabelSet{},
+		ClusterUnreachableAlertDuration: 0,
+		GarbageCollectionAlertThreshold: 10,
+	})
+	received := make(chan notifier.Notification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := notifier.Notification{}
+		_ = json.NewDecoder(r.Body).Decode(&notification)
+		received <- notification
+	}))
+	defer server.Close()
+	assert.Nil(t, notifier.Initialize("webhook", server.URL, time.Hour))
+	defer func() { _ = notifier.Initialize("", "", 0) }()
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+
+	testCases := []struct {
+		name        string
+		notify      func()
+		expectedKey string
+	}{
+		{
+			name: "Given a cluster is unreachable for longer than the alert duration, " +
+				"When it is marked unreachable, " +
+				"Then a notification should be sent",
+			notify:      func() { markClusterUnreachable(rr, "cluster1") },
+			expectedKey: "cluster-unreachable/cluster1",
+		},
+		{
+			name: "Given a delete left the SEs of an identity without endpoints, " +
+				"When notifyIdentityWithoutEndpoints is called, " +
+				"Then a notification should be sent",
+			notify: func() {
+				notifyIdentityWithoutEndpoints(admiral.Delete, "foo", "stage",
+					map[string]*networking.ServiceEntry{"stage.foo.global": {Hosts: []string{"stage.foo.global"}}})
+			},
+			expectedKey: "identity-without-endpoints/stage.foo",
+		},
+		{
+			name: "Given a clean up deleted more resources than the threshold, " +
+				"When notifyGarbageCollection is called, " +
+				"Then a notification should be sent",
+			notify:      func() { notifyGarbageCollection("SyncNamespaceMigration", "cluster1", 11) },
+			expectedKey: "garbage-collection/SyncNamespaceMigration/cluster1",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			c.notify()
+			select {
+			case notification := <-received:
+				assert.Equal(t, c.expectedKey, notification.Key)
+			case <-time.After(5 * time.Second):
+				t.Fatal("notification was not sent")
+			}
+		})
+	}
+
+	// conditions below the thresholds are not notified
+	notifyIdentityWithoutEndpoints(admiral.Update, "foo", "stage",
+		map[string]*networking.ServiceEntry{"stage.foo.global": {Hosts: []string{"stage.foo.global"}}})
+	notifyGarbageCollection("SyncNamespaceMigration", "cluster1", 10)
+	markClusterReachable(rr, "cluster1")
+	_, unreachable := rr.AdmiralCache.UnreachableClusterCache.Load("cluster1")
+	assert.False(t, unreachable)
+	select {
+	case notification := <-received:
+		t.Fatalf("unexpected notification %s", notification.Key)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,224 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	OrphanReasonIdentityGone = "identity no longer exists"
+	OrphanReasonNoDependency = "identity is neither deployed in nor a dependency of the cluster"
+)
+
+// OrphanedResource is a resource admiral generated in the sync namespace of a cluster which no longer has a source
+type OrphanedResource struct {
+	Cluster   string `json:"cluster"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Identity  string `json:"identity"`
+	Reason    string `json:"reason"`
+	object    metaV1.Object
+}
+
+// OrphanReport lists the orphaned resources, along with the clusters which could not be checked
+type OrphanReport struct {
+	CacheWarmup    bool               `json:"cacheWarmup"`
+	Orphans        []OrphanedResource `json:"orphans"`
+	FailedClusters map[string]string  `json:"failedClusters,omitempty"`
+}
+
+// GetOrphanReport returns the resources generated by admiral in the sync namespace of the cluster, or of
+// all the clusters when empty, whose identity no longer exists or no longer needs them in the cluster.
+// The report is not reliable before the cache warm up is over, which it flags
+func GetOrphanReport(ctx context.Context, rr *RemoteRegistry, cluster string) (OrphanReport, error) {
+	report := OrphanReport{Orphans: make([]OrphanedResource, 0)}
+	if rr == nil || rr.AdmiralCache == nil {
+		return report, nil
+	}
+	clusters := rr.GetClusterIds()
+	if cluster != "" {
+		if rr.GetRemoteController(cluster) == nil {
+			return report, fmt.Errorf("admiral is not monitoring cluster %s", cluster)
+		}
+		clusters = []string{cluster}
+	}
+	sort.Strings(clusters)
+	report.CacheWarmup = IsCacheWarmupTime(rr)
+	for _, clusterID := range clusters {
+		orphans, err := findOrphanedResources(ctx, rr, clusterID)
+		if err != nil {
+			if report.FailedClusters == nil {
+				report.FailedClusters = make(map[string]string)
+			}
+			report.FailedClusters[clusterID] = err.Error()
+			continue
+		}
+		report.Orphans = append(report.Orphans, orphans...)
+	}
+	return report, nil
+}
+
+// findOrphanedResources returns the ServiceEntries, DestinationRules and VirtualServices generated by admiral
+// in the sync namespace of the cluster which no longer have a source. The resources owned by a discovery source
+// or a federated mesh, and the ones the identity of which is not known, are left out
+func findOrphanedResources(ctx context.Context, rr *RemoteRegistry, cluster string) ([]OrphanedResource, error) {
+	rc := rr.GetRemoteController(cluster)
+	if rc == nil {
+		return nil, fmt.Errorf("remote controller not initialized for cluster %s", cluster)
+	}
+	namespace := common.GetSyncNamespaceForCluster(cluster)
+	clusterIdentities := make(map[string]bool)
+	for _, identity := range getClusterIdentities(rr.AdmiralCache, cluster) {
+		clusterIdentities[strings.ToLower(getNonPartitionedIdentity(rr.AdmiralCache, identity))] = true
+	}
+	var (
+		orphans   = make([]OrphanedResource, 0)
+		resources []metaV1.Object
+		kinds     []common.ResourceType
+	)
+	if rc.ServiceEntryController != nil && rc.ServiceEntryController.IstioClient != nil {
+		seList, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(ctx, metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, se := range seList.Items {
+			resources, kinds = append(resources, se), append(kinds, common.ServiceEntryResourceType)
+		}
+	}
+	if rc.DestinationRuleController != nil && rc.DestinationRuleController.IstioClient != nil {
+		drList, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).List(ctx, metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, dr := range drList.Items {
+			resources, kinds = append(resources, dr), append(kinds, common.DestinationRuleResourceType)
+		}
+	}
+	if rc.VirtualServiceController != nil && rc.VirtualServiceController.IstioClient != nil {
+		vsList, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, vs := range vsList.Items {
+			resources, kinds = append(resources, vs), append(kinds, common.VirtualServiceResourceType)
+		}
+	}
+	for i, resource := range resources {
+		if !isGeneratedByAdmiral(resource.GetAnnotations()) {
+			continue
+		}
+		labels := resource.GetLabels()
+		if labels[common.AdmiralDiscoverySourceLabel] != "" || labels[common.AdmiralFederatedMeshLabel] != "" {
+			continue
+		}
+		identity := getOwnerIdentity(resource)
+		if identity == "" {
+			identity = labels[common.AdmiralSourceIdentityLabel]
+		}
+		if identity == "" || clusterIdentities[strings.ToLower(getNonPartitionedIdentity(rr.AdmiralCache, identity))] {
+			continue
+		}
+		reason := OrphanReasonNoDependency
+		if !isIdentityKnown(rr.AdmiralCache, identity) {
+			reason = OrphanReasonIdentityGone
+		}
+		orphans = append(orphans, OrphanedResource{
+			Cluster:   cluster,
+			Kind:      string(kinds[i]),
+			Namespace: resource.GetNamespace(),
+			Name:      resource.GetName(),
+			Identity:  identity,
+			Reason:    reason,
+			object:    resource,
+		})
+	}
+	return orphans, nil
+}
+
+// isIdentityKnown checks if a workload of the identity is deployed in any cluster. The identities of the
+// cache are partitioned, while the resources hold the identity without its partition
+func isIdentityKnown(cache *AdmiralCache, identity string) bool {
+	if cache.IdentityClusterCache == nil {
+		return false
+	}
+	identity = getNonPartitionedIdentity(cache, identity)
+	known := false
+	cache.IdentityClusterCache.Range(func(key string, clusters *common.Map) {
+		if strings.EqualFold(getNonPartitionedIdentity(cache, key), identity) && clusters != nil && clusters.Len() > 0 {
+			known = true
+		}
+	})
+	return known
+}
+
+// startOrphanPruning periodically deletes the orphaned resources of all the clusters once the cache warm
+// up is over, or only logs them in dry run
+func startOrphanPruning(ctx context.Context, rr *RemoteRegistry) {
+	interval := common.GetOrphanPruningInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if IsCacheWarmupTime(rr) {
+				continue
+			}
+			if commonUtil.IsAdmiralReadOnly() {
+				log.Infof(LogFormat, "OrphanPruning", "", "", "", "skipped as Admiral is in Read-only mode")
+				continue
+			}
+			for _, cluster := range rr.GetClusterIds() {
+				pruneOrphanedResources(ctx, rr, cluster, common.IsOrphanPruningDryRun())
+			}
+		}
+	}
+}
+
+// pruneOrphanedResources deletes the orphaned resources of the cluster, or only logs them in dry run
+func pruneOrphanedResources(ctx context.Context, rr *RemoteRegistry, cluster string, dryRun bool) {
+	orphans, err := findOrphanedResources(ctx, rr, cluster)
+	if err != nil {
+		log.Errorf(LogErrFormat, "OrphanPruning", "", "", cluster, err)
+		return
+	}
+	rc := rr.GetRemoteController(cluster)
+	deleted := 0
+	for _, orphan := range orphans {
+		message := fmt.Sprintf("orphaned %s in namespace=%s of identity=%s: %s", orphan.Kind, orphan.Namespace, orphan.Identity, orphan.Reason)
+		if dryRun {
+			log.Infof(LogFormat, "OrphanPruning", orphan.Kind, orphan.Name, cluster, "dry run, would delete "+message)
+			continue
+		}
+		var err error
+		switch resource := orphan.object.(type) {
+		case *v1alpha3.ServiceEntry:
+			err = deleteServiceEntry(ctx, resource, orphan.Namespace, rc)
+		case *v1alpha3.DestinationRule:
+			err = deleteDestinationRule(ctx, resource, orphan.Namespace, rc)
+		case *v1alpha3.VirtualService:
+			err = deleteVirtualService(ctx, resource.Name, orphan.Namespace, rc)
+		}
+		if err != nil {
+			log.Errorf(LogErrFormat, "OrphanPruning", orphan.Kind, orphan.Name, cluster, err)
+			continue
+		}
+		deleted++
+		log.Infof(LogFormat, "OrphanPruning", orphan.Kind, orphan.Name, cluster, "deleted "+message)
+	}
+	notifyGarbageCollection("OrphanPruning", cluster, deleted)
+}
@@ -0,0 +1,148 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func setupForOrphanTests() (*RemoteRegistry, *istioFake.Clientset) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SyncNamespace: "ns",
+	})
+	generated := func(annotations map[string]string) map[string]string {
+		annotations[resourceCreatedByAnnotationLabel] = resourceCreatedByAnnotationValue
+		return annotations
+	}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se", Namespace: "ns", Annotations: generated(map[string]string{"identity": "foo"})}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "bar.global-se", Namespace: "ns", Annotations: generated(map[string]string{"identity": "bar"})}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "baz.global-se", Namespace: "ns", Annotations: generated(map[string]string{"identity": "baz"})}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "custom.global-se", Namespace: "ns", Annotations: map[string]string{"identity": "baz"}}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "qux.global-se",
+			Namespace:   "ns",
+			Labels:      map[string]string{common.AdmiralDiscoverySourceLabel: "consul"},
+			Annotations: generated(map[string]string{"identity": "qux"}),
+		}},
+		&v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "baz.global-default-dr",
+			Namespace:   "ns",
+			Labels:      map[string]string{common.AdmiralSourceIdentityLabel: "baz"},
+			Annotations: generated(map[string]string{}),
+		}},
+	)
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("bar", "cluster2", "cluster2")
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient},
+	})
+	return rr, istioClient
+}
+
+func TestGetOrphanReport(t *testing.T) {
+	rr, _ := setupForOrphanTests()
+	ctx := context.Background()
+
+	report, err := GetOrphanReport(ctx, rr, "cluster1")
+	assert.Nil(t, err)
+	assert.Nil(t, report.FailedClusters)
+	assert.Equal(t, []OrphanedResource{
+		{Cluster: "cluster1", Kind: string(common.ServiceEntryResourceType), Namespace: "ns", Name: "bar.global-se", Identity: "bar", Reason: OrphanReasonNoDependency},
+		{Cluster: "cluster1", Kind: string(common.ServiceEntryResourceType), Namespace: "ns", Name: "baz.global-se", Identity: "baz", Reason: OrphanReasonIdentityGone},
+		{Cluster: "cluster1", Kind: string(common.DestinationRuleResourceType), Namespace: "ns", Name: "baz.global-default-dr", Identity: "baz", Reason: OrphanReasonIdentityGone},
+	}, stripOrphanObjects(report.Orphans))
+
+	_, err = GetOrphanReport(ctx, rr, "cluster3")
+	assert.NotNil(t, err)
+}
+
+func TestPruneOrphanedResources(t *testing.T) {
+	rr, istioClient := setupForOrphanTests()
+	ctx := context.Background()
+
+	// nothing is deleted in dry run
+	pruneOrphanedResources(ctx, rr, "cluster1", true)
+	seList, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, seList.Items, 5)
+
+	pruneOrphanedResources(ctx, rr, "cluster1", false)
+	seList, err = istioClient.NetworkingV1alpha3().ServiceEntries("ns").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	remaining := make([]string, 0, len(seList.Items))
+	for _, se := range seList.Items {
+		remaining = append(remaining, se.Name)
+	}
+	assert.ElementsMatch(t, []string{"foo.global-se", "custom.global-se", "qux.global-se"}, remaining)
+	drList, err := istioClient.NetworkingV1alpha3().DestinationRules("ns").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, drList.Items)
+}
+
+func TestFindOrphanedResourcesWithPartitionedIdentities(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:              &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SyncNamespace:         "ns",
+		EnableSWAwareNSCaches: true,
+	})
+	generated := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue, "identity": "foo"}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se", Namespace: "ns", Annotations: generated}},
+		&v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{
+			Name:        "foo.global-default-dr",
+			Namespace:   "ns",
+			Labels:      map[string]string{common.AdmiralSourceIdentityLabel: "foo"},
+			Annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+		}},
+	)
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.PartitionIdentityCache = common.NewMap()
+	rr.AdmiralCache.PartitionIdentityCache.Put("partition.foo", "foo")
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient},
+	})
+	ctx := context.Background()
+
+	rr.AdmiralCache.IdentityClusterCache.Put("partition.foo", "cluster1", "cluster1")
+	orphans, err := findOrphanedResources(ctx, rr, "cluster1")
+	assert.Nil(t, err)
+	assert.Empty(t, orphans, "the resources of a partitioned identity deployed in the cluster should not be orphans")
+
+	// the identity is still known while deployed in another cluster
+	rr.AdmiralCache.IdentityClusterCache.Delete("partition.foo")
+	rr.AdmiralCache.IdentityClusterCache.Put("partition.foo", "cluster2", "cluster2")
+	assert.True(t, isIdentityKnown(rr.AdmiralCache, "foo"))
+	orphans, err = findOrphanedResources(ctx, rr, "cluster1")
+	assert.Nil(t, err)
+	assert.Len(t, orphans, 2)
+	assert.Equal(t, OrphanReasonNoDependency, orphans[0].Reason)
+
+	pruneOrphanedResources(ctx, rr, "cluster1", true)
+	seList, err := istioClient.NetworkingV1alpha3().ServiceEntries("ns").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, seList.Items, 1)
+}
+
+func stripOrphanObjects(orphans []OrphanedResource) []OrphanedResource {
+	stripped := make([]OrphanedResource, 0, len(orphans))
+	for _, orphan := range orphans {
+		orphan.object = nil
+		stripped = append(stripped, orphan)
+	}
+	return stripped
+}
@@ -123,11 +123,11 @@ func callRegistryForOutlierDetection(ctx context.Context, event admiral.EventTyp
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutCustomData(clusterName, od.Namespace, od.Name, common.OutlierDetection, ctx.Value("txId").(string), od)
+			err = registry.RegistryClient.PutCustomData(clusterName, od.Namespace, od.Name, common.OutlierDetection, common.GetTxId(ctx), od)
 		case admiral.Update:
-			err = registry.RegistryClient.PutCustomData(clusterName, od.Namespace, od.Name, common.OutlierDetection, ctx.Value("txId").(string), od)
+			err = registry.RegistryClient.PutCustomData(clusterName, od.Namespace, od.Name, common.OutlierDetection, common.GetTxId(ctx), od)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteCustomData(clusterName, od.Namespace, od.Name, common.OutlierDetection, ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteCustomData(clusterName, od.Namespace, od.Name, common.OutlierDetection, common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, common.OutlierDetection, od.Name, clusterName, "failed to "+string(event)+" "+common.OutlierDetection+" with err: "+err.Error())
@@ -0,0 +1,81 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// stampOwnerLabels labels the resource admiral is about to write with the admiral instance managing it,
+// along with the cluster and the identity it was generated from when they are known
+func stampOwnerLabels(ctx context.Context, obj metaV1.Object) {
+	if !common.EnableOwnershipLabels() {
+		return
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[common.AdmiralManagedByLabel] = common.GetAdmiralInstanceName()
+	if cluster, ok := ctx.Value(common.ClusterName).(string); ok && isValidOwnerLabelValue(cluster) {
+		labels[common.AdmiralSourceClusterLabel] = cluster
+	}
+	if identity := getOwnerIdentity(obj); isValidOwnerLabelValue(identity) {
+		labels[common.AdmiralSourceIdentityLabel] = identity
+	}
+	obj.SetLabels(labels)
+}
+
+// getOwnerIdentity returns the identity the resource was generated from, as set on its labels or annotations
+func getOwnerIdentity(obj metaV1.Object) string {
+	identityKey := common.GetWorkloadIdentifier()
+	if identity := obj.GetLabels()[identityKey]; identity != "" {
+		return identity
+	}
+	if identity := obj.GetAnnotations()[identityKey]; identity != "" {
+		return identity
+	}
+	return obj.GetLabels()[common.CreatedFor]
+}
+
+func isValidOwnerLabelValue(value string) bool {
+	return value != "" && len(validation.IsValidLabelValue(value)) == 0
+}
+
+// checkOwnership returns an error when the existing resource is owned by something other than this admiral
+// instance and is not annotated for adoption, in which case it must not be overwritten. The resources written
+// before the ownership labels were enabled only carry the created-by annotation, and are owned by admiral
+func checkOwnership(ctxLogger *log.Entry, kind common.ResourceType, clusterID string, exist metaV1.Object) error {
+	if !common.EnableOwnershipLabels() {
+		return nil
+	}
+	if adopt, err := strconv.ParseBool(exist.GetAnnotations()[common.AdmiralAdoptAnnotation]); err == nil && adopt {
+		ctxLogger.Infof(common.CtxLogFormat, "CheckOwnership", exist.GetName(), exist.GetNamespace(), clusterID,
+			fmt.Sprintf("adopting %s annotated with %s", kind, common.AdmiralAdoptAnnotation))
+		return nil
+	}
+	owner, labeled := exist.GetLabels()[common.AdmiralManagedByLabel]
+	if labeled && owner == common.GetAdmiralInstanceName() {
+		return nil
+	}
+	if !labeled && exist.GetAnnotations()[resourceCreatedByAnnotationLabel] == resourceCreatedByAnnotationValue {
+		return nil
+	}
+	if !labeled {
+		owner = exist.GetAnnotations()[resourceCreatedByAnnotationLabel]
+	}
+	ownershipConflicts.Increment(api.WithAttributes(
+		attribute.Key("kind").String(string(kind)),
+		attribute.Key("cluster").String(clusterID),
+	))
+	ctxLogger.Warnf(common.CtxLogFormat, "CheckOwnership", exist.GetName(), exist.GetNamespace(), clusterID,
+		fmt.Sprintf("refusing to overwrite %s owned by %q, annotate it with %s=true to adopt it", kind, owner, common.AdmiralAdoptAnnotation))
+	return fmt.Errorf("%s %s in namespace %s of cluster %s is not owned by admiral", kind, exist.GetName(), exist.GetNamespace(), clusterID)
+}
@@ -0,0 +1,158 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func setupForOwnershipTests(enabled bool) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:              &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SyncNamespace:         "ns",
+		EnableOwnershipLabels: enabled,
+		AdmiralInstanceName:   "admiral-a",
+	})
+}
+
+func TestStampOwnerLabels(t *testing.T) {
+	ctx := context.WithValue(context.Background(), common.ClusterName, "cluster1")
+
+	setupForOwnershipTests(false)
+	se := &v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Annotations: map[string]string{"identity": "foo"}}}
+	stampOwnerLabels(ctx, se)
+	assert.Nil(t, se.Labels)
+
+	setupForOwnershipTests(true)
+	stampOwnerLabels(ctx, se)
+	assert.Equal(t, map[string]string{
+		common.AdmiralManagedByLabel:      "admiral-a",
+		common.AdmiralSourceClusterLabel:  "cluster1",
+		common.AdmiralSourceIdentityLabel: "foo",
+	}, se.Labels)
+
+	// the source labels are left out when they are not known
+	vs := &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"app": "foo"}}}
+	stampOwnerLabels(context.Background(), vs)
+	assert.Equal(t, map[string]string{"app": "foo", common.AdmiralManagedByLabel: "admiral-a"}, vs.Labels)
+}
+
+func TestCheckOwnership(t *testing.T) {
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	testCases := []struct {
+		name        string
+		enabled     bool
+		labels      map[string]string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "Given the ownership labels are disabled, " +
+				"When a VS which is not owned by admiral is checked, " +
+				"Then it should be overwritten",
+		},
+		{
+			name: "Given the ownership labels are enabled, " +
+				"When a VS owned by the admiral instance is checked, " +
+				"Then it should be overwritten",
+			enabled: true,
+			labels:  map[string]string{common.AdmiralManagedByLabel: "admiral-a"},
+		},
+		{
+			name: "Given the ownership labels are enabled, " +
+				"When a VS written by admiral before the ownership labels is checked, " +
+				"Then it should be overwritten",
+			enabled:     true,
+			annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+		},
+		{
+			name: "Given the ownership labels are enabled, " +
+				"When a VS which is not owned by admiral is checked, " +
+				"Then it should not be overwritten",
+			enabled:     true,
+			expectedErr: true,
+		},
+		{
+			name: "Given the ownership labels are enabled, " +
+				"When a VS owned by another admiral instance is checked, " +
+				"Then it should not be overwritten",
+			enabled:     true,
+			labels:      map[string]string{common.AdmiralManagedByLabel: "admiral-b"},
+			annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+			expectedErr: true,
+		},
+		{
+			name: "Given the ownership labels are enabled, " +
+				"When a VS which is not owned by admiral but annotated for adoption is checked, " +
+				"Then it should be overwritten",
+			enabled:     true,
+			annotations: map[string]string{common.AdmiralAdoptAnnotation: "true"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			setupForOwnershipTests(c.enabled)
+			vs := &v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{
+				Name:        "stage.foo.global-vs",
+				Namespace:   "ns",
+				Labels:      c.labels,
+				Annotations: c.annotations,
+			}}
+			err := checkOwnership(ctxLogger, common.VirtualServiceResourceType, "cluster1", vs)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestAddUpdateVirtualServiceOwnership(t *testing.T) {
+	setupForOwnershipTests(true)
+	ctx := context.Background()
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	foreign := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-vs", Namespace: "ns"},
+		Spec:       networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+	istioClient := istioFake.NewSimpleClientset(foreign)
+	rc := &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	vs := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-vs", Namespace: "ns"},
+		Spec:       networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global", "stage.foo.mesh"}},
+	}
+
+	// the VirtualService created by something else is left alone
+	assert.NotNil(t, addUpdateVirtualService(ctxLogger, ctx, vs, nil, "ns", rc, rr))
+	existing, err := istioClient.NetworkingV1alpha3().VirtualServices("ns").Get(ctx, vs.Name, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stage.foo.global"}, existing.Spec.Hosts)
+
+	// it is adopted once annotated for adoption
+	existing.Annotations = map[string]string{common.AdmiralAdoptAnnotation: "true"}
+	existing, err = istioClient.NetworkingV1alpha3().VirtualServices("ns").Update(ctx, existing, metaV1.UpdateOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, addUpdateVirtualService(ctxLogger, ctx, vs, existing, "ns", rc, rr))
+	adopted, err := istioClient.NetworkingV1alpha3().VirtualServices("ns").Get(ctx, vs.Name, metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stage.foo.global", "stage.foo.mesh"}, adopted.Spec.Hosts)
+	assert.Equal(t, "admiral-a", adopted.Labels[common.AdmiralManagedByLabel])
+	assert.NotContains(t, adopted.Annotations, common.AdmiralAdoptAnnotation)
+
+	// the adopted VirtualService is owned by admiral from then on
+	assert.Nil(t, checkOwnership(ctxLogger, common.VirtualServiceResourceType, "cluster1", adopted))
+}
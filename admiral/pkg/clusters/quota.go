@@ -0,0 +1,258 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+const (
+	// QuotaHostsPerIdentity is the quota of the hosts generated for an identity across its environments
+	QuotaHostsPerIdentity = "hosts_per_identity"
+	// QuotaCustomVirtualServices is the quota of the custom VirtualServices merged for an identity and environment
+	QuotaCustomVirtualServices = "custom_virtualservices"
+	// QuotaExportToNamespaces is the quota of the namespaces written in the exportTo of a host in a cluster
+	QuotaExportToNamespaces = "exportto_namespaces"
+
+	eventReasonQuotaExceeded = "QuotaExceeded"
+)
+
+// QuotaViolation is a quota an identity exceeded, along with what admiral left out of the generated
+// resources to keep it within the quota
+type QuotaViolation struct {
+	Quota      string    `json:"quota"`
+	Identity   string    `json:"identity"`
+	Env        string    `json:"env,omitempty"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Limit      int       `json:"limit"`
+	Requested  int       `json:"requested"`
+	Excluded   []string  `json:"excluded,omitempty"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// quotaTracker keeps the quota violations of the identities until they are within their quotas again
+type quotaTracker struct {
+	mutex      sync.Mutex
+	violations map[string]QuotaViolation
+}
+
+func newQuotaTracker() *quotaTracker {
+	return &quotaTracker{violations: make(map[string]QuotaViolation)}
+}
+
+// quotas is shared by every remote controller, as the quotas apply to an identity across the clusters
+var quotas = newQuotaTracker()
+
+// record keeps the violation, along with the time the identity first exceeded the quota
+func (t *quotaTracker) record(violation QuotaViolation) {
+	if t == nil {
+		return
+	}
+	key := getQuotaViolationKey(violation.Quota, violation.Identity, violation.Env)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	violation.DetectedAt = time.Now()
+	if existing, ok := t.violations[key]; ok {
+		violation.DetectedAt = existing.DetectedAt
+	}
+	t.violations[key] = violation
+}
+
+// clear forgets the violation of the quota by the identity in the env, as it is within the quota again
+func (t *quotaTracker) clear(quota, identity, env string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.violations, getQuotaViolationKey(quota, identity, env))
+}
+
+// GetViolations returns the violations which are not resolved yet, sorted by quota, identity and env
+func (t *quotaTracker) GetViolations() []QuotaViolation {
+	violations := make([]QuotaViolation, 0)
+	if t == nil {
+		return violations
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, violation := range t.violations {
+		violations = append(violations, violation)
+	}
+	sortQuotaViolations(violations)
+	return violations
+}
+
+func sortQuotaViolations(violations []QuotaViolation) {
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Quota != violations[j].Quota {
+			return violations[i].Quota < violations[j].Quota
+		}
+		if violations[i].Identity != violations[j].Identity {
+			return violations[i].Identity < violations[j].Identity
+		}
+		if violations[i].Env != violations[j].Env {
+			return violations[i].Env < violations[j].Env
+		}
+		return violations[i].Cluster < violations[j].Cluster
+	})
+}
+
+func getQuotaViolationKey(quota, identity, env string) string {
+	return quota + "/" + strings.ToLower(identity) + "/" + env
+}
+
+// GetQuotaViolations returns the quotas the identities currently exceed, the exportTo of the hosts whose
+// dependent namespaces exceed the max namespaces included
+func GetQuotaViolations(rr *RemoteRegistry) []QuotaViolation {
+	violations := quotas.GetViolations()
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.ExportToCapCache == nil {
+		return violations
+	}
+	rr.AdmiralCache.ExportToCapCache.Range(func(key, count interface{}) bool {
+		cname, cluster, _ := strings.Cut(fmt.Sprint(key), "/")
+		identity := cname
+		if rr.AdmiralCache.CnameIdentityCache != nil {
+			if cnameIdentity, ok := rr.AdmiralCache.CnameIdentityCache.Load(cname); ok {
+				identity = fmt.Sprint(cnameIdentity)
+			}
+		}
+		violations = append(violations, QuotaViolation{
+			Quota:     QuotaExportToNamespaces,
+			Identity:  identity,
+			Cluster:   cluster,
+			Limit:     common.GetExportToMaxNamespaces(),
+			Requested: count.(int),
+			Excluded:  []string{cname},
+		})
+		return true
+	})
+	sortQuotaViolations(violations)
+	return violations
+}
+
+// reportQuotaViolation logs, records a metric for and keeps the violation of a quota
+func reportQuotaViolation(ctxLogger *log.Entry, violation QuotaViolation) {
+	quotaViolations.Increment(api.WithAttributes(
+		attribute.Key("quota").String(violation.Quota),
+		attribute.Key("identity").String(violation.Identity),
+	))
+	ctxLogger.Warnf(common.CtxLogFormat, "QuotaCheck", violation.Identity, "", violation.Cluster,
+		fmt.Sprintf("%s quota of %d exceeded with %d, excluded %v", violation.Quota, violation.Limit, violation.Requested, violation.Excluded))
+	quotas.record(violation)
+}
+
+// enforceHostQuota drops the ServiceEntries of the hosts of the identity beyond the max hosts per identity,
+// counting the hosts the identity has in its other environments. The shortest hosts are kept, so that the
+// canary and preview hosts are dropped before the host of the identity, and the same hosts are dropped on
+// every sync. It returns the dropped hosts
+func enforceHostQuota(ctxLogger *log.Entry, admiralCache *AdmiralCache, identity, env string,
+	serviceEntries map[string]*networking.ServiceEntry) []string {
+	limit := common.GetQuotaMaxHostsPerIdentity()
+	if limit <= 0 || admiralCache == nil {
+		return nil
+	}
+	hosts := make([]string, 0, len(serviceEntries))
+	generated := make(map[string]bool)
+	for host := range serviceEntries {
+		hosts = append(hosts, host)
+		generated[strings.ToLower(host)] = true
+	}
+	otherHosts := 0
+	for _, host := range getIdentityHosts(admiralCache, identity, "") {
+		if !generated[strings.ToLower(host)] {
+			otherHosts++
+		}
+	}
+	allowed := max(limit-otherHosts, 0)
+	if len(hosts) <= allowed {
+		quotas.clear(QuotaHostsPerIdentity, identity, env)
+		return nil
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if len(hosts[i]) != len(hosts[j]) {
+			return len(hosts[i]) < len(hosts[j])
+		}
+		return hosts[i] < hosts[j]
+	})
+	dropped := hosts[allowed:]
+	for _, host := range dropped {
+		delete(serviceEntries, host)
+	}
+	reportQuotaViolation(ctxLogger, QuotaViolation{
+		Quota:     QuotaHostsPerIdentity,
+		Identity:  identity,
+		Env:       env,
+		Limit:     limit,
+		Requested: otherHosts + len(hosts),
+		Excluded:  dropped,
+	})
+	return dropped
+}
+
+// enforceCustomVirtualServiceQuota keeps the custom VirtualServices, sorted in the order they are merged,
+// up to the max custom VirtualServices merged for an identity and environment. The ones left out are
+// reported along with an event against each of them
+func enforceCustomVirtualServiceQuota(ctxLogger *log.Entry, rc *RemoteController, identity, env string,
+	virtualServices []*v1alpha3.VirtualService) []*v1alpha3.VirtualService {
+	limit := common.GetQuotaMaxCustomVirtualServices()
+	if limit <= 0 {
+		return virtualServices
+	}
+	if len(virtualServices) <= limit {
+		quotas.clear(QuotaCustomVirtualServices, identity, env)
+		return virtualServices
+	}
+	excluded := make([]string, 0, len(virtualServices)-limit)
+	for _, vs := range virtualServices[limit:] {
+		excluded = append(excluded, vs.Namespace+"/"+vs.Name)
+		if common.EnableK8sEvents() {
+			recordEvent(rc, virtualServiceReference(vs), coreV1.EventTypeWarning, eventReasonQuotaExceeded,
+				fmt.Sprintf("VirtualService was not merged as identity %s has %d custom VirtualServices for env %s, beyond the max of %d",
+					identity, len(virtualServices), env, limit))
+		}
+	}
+	cluster := ""
+	if rc != nil {
+		cluster = rc.ClusterID
+	}
+	reportQuotaViolation(ctxLogger, QuotaViolation{
+		Quota:     QuotaCustomVirtualServices,
+		Identity:  identity,
+		Env:       env,
+		Cluster:   cluster,
+		Limit:     limit,
+		Requested: len(virtualServices),
+		Excluded:  excluded,
+	})
+	return virtualServices[:limit]
+}
+
+// recordHostQuotaEvents emits an event against the source workloads of the identity for the hosts which were
+// not generated as the identity exceeded its max hosts
+func recordHostQuotaEvents(rr *RemoteRegistry, sourceDeployments map[string]*k8sAppsV1.Deployment,
+	sourceRollouts map[string]*argo.Rollout, identity string, dropped []string) {
+	if !common.EnableK8sEvents() || len(dropped) == 0 {
+		return
+	}
+	message := fmt.Sprintf("hosts %v of identity %s were not generated as they exceed the max of %d hosts per identity",
+		dropped, identity, common.GetQuotaMaxHostsPerIdentity())
+	for cluster, references := range sourceWorkloadReferences(sourceDeployments, sourceRollouts) {
+		rc := rr.GetRemoteController(cluster)
+		for _, reference := range references {
+			recordEvent(rc, reference, coreV1.EventTypeWarning, eventReasonQuotaExceeded, message)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package clusters
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnforceHostQuota(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, QuotaMaxHostsPerIdentity: 3})
+	defer common.ResetSync()
+	quotas = newQuotaTracker()
+	ctxLogger := log.WithField("test", "TestEnforceHostQuota")
+	cache := &AdmiralCache{CnameIdentityCache: &sync.Map{}}
+	cache.CnameIdentityCache.Store("prod.foo.global", "foo")
+	cache.CnameIdentityCache.Store("stage.foo.global", "foo")
+	cache.CnameIdentityCache.Store("stage.bar.global", "bar")
+
+	serviceEntries := map[string]*networking.ServiceEntry{
+		"stage.foo.global":         {},
+		"canary.stage.foo.global":  {},
+		"preview.stage.foo.global": {},
+	}
+	// the host of the other env counts against the quota, the longest hosts are dropped first
+	dropped := enforceHostQuota(ctxLogger, cache, "foo", "stage", serviceEntries)
+	assert.Equal(t, []string{"preview.stage.foo.global"}, dropped)
+	assert.Len(t, serviceEntries, 2)
+	assert.NotNil(t, serviceEntries["stage.foo.global"])
+	violations := quotas.GetViolations()
+	assert.Len(t, violations, 1)
+	assert.Equal(t, QuotaViolation{Quota: QuotaHostsPerIdentity, Identity: "foo", Env: "stage", Limit: 3, Requested: 4,
+		Excluded: []string{"preview.stage.foo.global"}, DetectedAt: violations[0].DetectedAt}, violations[0])
+
+	// once the identity is within its quota the violation is cleared
+	dropped = enforceHostQuota(ctxLogger, cache, "foo", "stage", map[string]*networking.ServiceEntry{"stage.foo.global": {}})
+	assert.Empty(t, dropped)
+	assert.Empty(t, quotas.GetViolations())
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	assert.Empty(t, enforceHostQuota(ctxLogger, cache, "foo", "stage", serviceEntries))
+}
+
+func TestEnforceCustomVirtualServiceQuota(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, QuotaMaxCustomVirtualServices: 1})
+	defer common.ResetSync()
+	quotas = newQuotaTracker()
+	ctxLogger := log.WithField("test", "TestEnforceCustomVirtualServiceQuota")
+	virtualServices := []*v1alpha3.VirtualService{
+		{ObjectMeta: metaV1.ObjectMeta{Name: "vs1", Namespace: "ns"}},
+		{ObjectMeta: metaV1.ObjectMeta{Name: "vs2", Namespace: "ns"}},
+	}
+	rc := &RemoteController{ClusterID: "cluster1"}
+
+	kept := enforceCustomVirtualServiceQuota(ctxLogger, rc, "foo", "stage", virtualServices)
+	assert.Equal(t, virtualServices[:1], kept)
+	violations := quotas.GetViolations()
+	assert.Len(t, violations, 1)
+	assert.Equal(t, QuotaViolation{Quota: QuotaCustomVirtualServices, Identity: "foo", Env: "stage", Cluster: "cluster1", Limit: 1,
+		Requested: 2, Excluded: []string{"ns/vs2"}, DetectedAt: violations[0].DetectedAt}, violations[0])
+
+	kept = enforceCustomVirtualServiceQuota(ctxLogger, rc, "foo", "stage", virtualServices[:1])
+	assert.Equal(t, virtualServices[:1], kept)
+	assert.Empty(t, quotas.GetViolations())
+}
+
+func TestGetQuotaViolations(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, ExportToMaxNamespaces: 2})
+	defer common.ResetSync()
+	quotas = newQuotaTracker()
+	quotas.record(QuotaViolation{Quota: QuotaHostsPerIdentity, Identity: "bar", Env: "stage", Limit: 1, Requested: 2})
+	rr := &RemoteRegistry{AdmiralCache: &AdmiralCache{CnameIdentityCache: &sync.Map{}, ExportToCapCache: &sync.Map{}}}
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.foo.global", "foo")
+	rr.AdmiralCache.ExportToCapCache.Store(getExportToCapKey("stage.foo.global", "cluster1"), 5)
+
+	violations := GetQuotaViolations(rr)
+	assert.Len(t, violations, 2)
+	assert.Equal(t, QuotaViolation{Quota: QuotaExportToNamespaces, Identity: "foo", Cluster: "cluster1", Limit: 2, Requested: 5,
+		Excluded: []string{"stage.foo.global"}}, violations[0])
+	assert.Equal(t, QuotaHostsPerIdentity, violations[1].Quota)
+	assert.Len(t, GetQuotaViolations(nil), 1)
+}
@@ -0,0 +1,63 @@
+package clusters
+
+import (
+	"context"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// panicRestartDelay is the delay before a background loop which panicked is restarted
+var panicRestartDelay = time.Second
+
+// runRecovered runs the background loop of the task, and restarts it when it panics so that a
+// panic does not stop the loop for the lifetime of admiral
+func runRecovered(ctx context.Context, task string, loop func()) {
+	for {
+		err := common.CallWithRecovery(ctx, task, func() error {
+			loop()
+			return nil
+		})
+		if err == nil {
+			return
+		}
+		time.Sleep(panicRestartDelay)
+	}
+}
+
+// runAddServiceEntriesWithDrWorker runs AddServiceEntriesWithDrWorker on the clusters one at a time, and
+// reports a cluster whose processing panicked as failed before moving on to the next one, so that the
+// caller still receives a single result per cluster and the event is retried
+func runAddServiceEntriesWithDrWorker(
+	ctxLogger *logrus.Entry,
+	ctx context.Context,
+	rr *RemoteRegistry,
+	isAdditionalEndpointsEnabled bool,
+	isServiceEntryModifyCalledForSourceCluster bool,
+	identityId,
+	env,
+	eventNamespace string,
+	seObj *networking.ServiceEntry,
+	clusters <-chan string,
+	errors chan<- error) {
+	for cluster := range clusters {
+		clusterToProcess := make(chan string, 1)
+		clusterToProcess <- cluster
+		close(clusterToProcess)
+		result := make(chan error, 1)
+		err := common.CallWithRecovery(ctx, "AddServiceEntriesWithDrWorker", func() error {
+			AddServiceEntriesWithDrWorker(ctxLogger, ctx, rr, isAdditionalEndpointsEnabled, isServiceEntryModifyCalledForSourceCluster,
+				identityId, env, eventNamespace, seObj, clusterToProcess, result)
+			return nil
+		})
+		select {
+		case clusterErr := <-result:
+			// the cluster was processed before the panic, if any
+			errors <- common.AppendError(clusterErr, err)
+		default:
+			errors <- err
+		}
+	}
+}
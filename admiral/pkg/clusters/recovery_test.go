@@ -0,0 +1,73 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+)
+
+func TestRunRecovered(t *testing.T) {
+	restartDelay := panicRestartDelay
+	panicRestartDelay = 0
+	defer func() { panicRestartDelay = restartDelay }()
+
+	runs := 0
+	runRecovered(context.Background(), "test", func() {
+		runs++
+		if runs < 3 {
+			panic("boom")
+		}
+	})
+	// the loop is restarted after each panic, until it returns
+	assert.Equal(t, 3, runs)
+}
+
+func TestRunAddServiceEntriesWithDrWorker(t *testing.T) {
+	ctx := context.Background()
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns"})
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.AdmiralCache.GlobalTrafficCache = nil
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:      "cluster2",
+		NodeController: &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}},
+	})
+	run := func() []error {
+		clusters := make(chan string, 3)
+		for _, cluster := range []string{"cluster1", "cluster2", "cluster3"} {
+			clusters <- cluster
+		}
+		close(clusters)
+		errors := make(chan error, 6)
+		runAddServiceEntriesWithDrWorker(log.WithField("test", t.Name()), ctx, rr, false, false, "foo", "stage", "",
+			&networkingV1Alpha3.ServiceEntry{Hosts: []string{"stage.foo.global"}}, clusters, errors)
+		close(errors)
+		var results []error
+		for err := range errors {
+			results = append(results, err)
+		}
+		return results
+	}
+
+	// cluster1 and cluster3 are not monitored, and the processing of cluster2 panics
+	results := run()
+	assert.Len(t, results, 3)
+	assert.Nil(t, results[0])
+	assert.NotNil(t, results[1])
+	assert.Nil(t, results[2])
+
+	// a panic before the clusters are processed fails each of them once
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", EnableSWAwareNSCaches: true})
+	rr.AdmiralCache = nil
+	results = run()
+	assert.Len(t, results, 3)
+	for _, err := range results {
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,174 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// startFullRefresh periodically refreshes the resources of every identity once the cache warm up is over,
+// so that the resources which drifted in the clusters are brought back in line
+func startFullRefresh(ctx context.Context, rr *RemoteRegistry) {
+	interval := common.GetFullRefreshInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			refreshAllIdentities(rr, modifyServiceEntryForWorkloadEvent)
+		}
+	}
+}
+
+// refreshAllIdentities runs modifySE for every env of every identity running in the clusters. Unrelated
+// identities are refreshed in parallel
+func refreshAllIdentities(rr *RemoteRegistry, modifySE ModifySEFunc) {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityClusterCache == nil {
+		return
+	}
+	var identities []string
+	rr.AdmiralCache.IdentityClusterCache.Range(func(identity string, clusters *common.Map) {
+		if clusters != nil && len(clusters.GetKeys()) > 0 {
+			identities = append(identities, identity)
+		}
+	})
+	if len(identities) == 0 {
+		return
+	}
+	sort.Strings(identities)
+	start := time.Now()
+	refreshIdentities(rr, identities, common.FullRefresh, modifySE)
+	log.Infof(LogFormat, "FullRefresh", "identity", "", "", fmt.Sprintf("refreshed the resources of %d identities in %v", len(identities), time.Since(start)))
+}
+
+// refreshIdentities runs modifySE for every env of the identities through processIdentities, from the
+// cluster the workloads of each identity are first found in
+func refreshIdentities(rr *RemoteRegistry, identities []string, task string, modifySE ModifySEFunc) {
+	processIdentities(rr.AdmiralCache, identities, func(identity string) {
+		sourceCluster, resourceType, envs := getIdentitySourceEnvs(rr, identity)
+		for _, env := range envs {
+			ctx := context.WithValue(context.Background(), common.ClusterName, sourceCluster)
+			ctx = context.WithValue(ctx, common.EventResourceType, resourceType)
+			ctx = context.WithValue(ctx, common.EventType, admiral.Update)
+			err := common.CallWithRecovery(ctx, task, func() error {
+				_, err := modifySE(ctx, admiral.Update, env, identity, rr)
+				return err
+			})
+			if err != nil {
+				log.Errorf(LogErrFormat, task, identity, env, sourceCluster, err)
+			}
+		}
+	})
+}
+
+// groupRelatedIdentities splits the identities into the groups which have to be processed one after the other,
+// because processing an identity writes the resources of the identities it depends on or shares a host with.
+// Identities related directly or through other identities of the list end up in the same group. The groups,
+// and the identities of each group, keep the order of the list
+func groupRelatedIdentities(cache *AdmiralCache, identities []string) [][]string {
+	parents := make(map[string]string, len(identities))
+	for _, identity := range identities {
+		parents[strings.ToLower(identity)] = strings.ToLower(identity)
+	}
+	var find func(identity string) string
+	find = func(identity string) string {
+		if parents[identity] != identity {
+			parents[identity] = find(parents[identity])
+		}
+		return parents[identity]
+	}
+	union := func(a, b string) {
+		a, b = strings.ToLower(a), strings.ToLower(b)
+		if _, ok := parents[a]; !ok {
+			return
+		}
+		if _, ok := parents[b]; !ok {
+			return
+		}
+		parents[find(a)] = find(b)
+	}
+
+	if cache != nil {
+		for _, identity := range identities {
+			if cache.IdentityDependencyCache != nil {
+				if dependents := cache.IdentityDependencyCache.Get(identity); dependents != nil {
+					for _, dependent := range dependents.GetKeys() {
+						union(identity, dependent)
+					}
+				}
+			}
+			if cache.SourceToDestinations != nil {
+				for _, destination := range cache.SourceToDestinations.Get(identity) {
+					union(identity, destination)
+				}
+			}
+		}
+		for _, conflict := range cache.HostConflictDetector.GetConflicts() {
+			if conflict.Kind == HostClaimIdentity {
+				union(conflict.Owner, conflict.Claimant)
+			}
+		}
+	}
+
+	var groups [][]string
+	groupIndexes := make(map[string]int)
+	for _, identity := range identities {
+		root := find(strings.ToLower(identity))
+		index, ok := groupIndexes[root]
+		if !ok {
+			index = len(groups)
+			groupIndexes[root] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], identity)
+	}
+	return groups
+}
+
+// processIdentities runs process for each identity. The groups of related identities are processed in
+// parallel by up to the configured number of workers, the largest groups first, while the identities of
+// a group are processed one after the other
+func processIdentities(cache *AdmiralCache, identities []string, process func(identity string)) {
+	groups := groupRelatedIdentities(cache, identities)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i]) > len(groups[j])
+	})
+	concurrency := common.GetFullRefreshConcurrency()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pending := make(chan []string, len(groups))
+	for _, group := range groups {
+		pending <- group
+	}
+	close(pending)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(groups); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range pending {
+				for _, identity := range group {
+					process(identity)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
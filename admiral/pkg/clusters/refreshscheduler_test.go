@@ -0,0 +1,113 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	admiralV1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGroupRelatedIdentities(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	// a depends on b, c depends on d, e claims the host of f, g is unrelated
+	rr.AdmiralCache.IdentityDependencyCache.Put("b", "a", "a")
+	rr.AdmiralCache.SourceToDestinations.put(&v1.Dependency{
+		ObjectMeta: metaV1.ObjectMeta{Name: "c"},
+		Spec:       admiralV1.Dependency{Source: "c", Destinations: []string{"d"}},
+	})
+	rr.AdmiralCache.HostConflictDetector.claim(HostClaimIdentity, "stage.f.global", "F", time.Time{})
+	rr.AdmiralCache.HostConflictDetector.claim(HostClaimIdentity, "stage.f.global", "e", time.Time{})
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e", "F"}, {"g"}},
+		groupRelatedIdentities(rr.AdmiralCache, []string{"a", "b", "c", "d", "e", "F", "g"}))
+	// the identities the list does not hold do not relate the others
+	assert.Equal(t, [][]string{{"a"}, {"c"}},
+		groupRelatedIdentities(rr.AdmiralCache, []string{"a", "c"}))
+}
+
+func TestProcessIdentities(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, FullRefreshConcurrency: 2})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityDependencyCache.Put("b", "a", "a")
+
+	var (
+		lock      sync.Mutex
+		running   = make(map[string]bool)
+		processed []string
+		parallel  bool
+	)
+	processIdentities(rr.AdmiralCache, []string{"a", "b", "c"}, func(identity string) {
+		lock.Lock()
+		// a and b are related, so they never run at the same time
+		assert.False(t, (identity == "a" && running["b"]) || (identity == "b" && running["a"]))
+		if len(running) > 0 {
+			parallel = true
+		}
+		running[identity] = true
+		lock.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		lock.Lock()
+		delete(running, identity)
+		processed = append(processed, identity)
+		lock.Unlock()
+	})
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, processed)
+	assert.True(t, parallel)
+}
+
+func TestRefreshAllIdentities(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", FullRefreshConcurrency: 2})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	deployments := admiral.NewDeploymentCache()
+	for _, identity := range []string{"a", "b", "c"} {
+		deployments.UpdateDeploymentToClusterCache(identity, &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: identity, Namespace: identity + "-stage"}})
+		rr.AdmiralCache.IdentityClusterCache.Put(identity, "cluster1", "cluster1")
+	}
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: deployments},
+	})
+	// a depends on b
+	rr.AdmiralCache.IdentityDependencyCache.Put("b", "a", "a")
+
+	var (
+		lock      sync.Mutex
+		running   = make(map[string]bool)
+		refreshed []string
+		parallel  bool
+	)
+	modifySE := func(ctx context.Context, event admiral.EventType, env string, sourceIdentity string,
+		remoteRegistry *RemoteRegistry) (map[string]*networkingV1Alpha3.ServiceEntry, error) {
+		lock.Lock()
+		// the full refresh goes through the scheduler, so a and b never run at the same time
+		assert.False(t, (sourceIdentity == "a" && running["b"]) || (sourceIdentity == "b" && running["a"]))
+		if len(running) > 0 {
+			parallel = true
+		}
+		running[sourceIdentity] = true
+		lock.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		lock.Lock()
+		defer lock.Unlock()
+		delete(running, sourceIdentity)
+		refreshed = append(refreshed, sourceIdentity+"/"+env+"/"+ctx.Value(common.ClusterName).(string))
+		return nil, nil
+	}
+
+	refreshAllIdentities(rr, modifySE)
+	assert.ElementsMatch(t, []string{"a/stage/cluster1", "b/stage/cluster1", "c/stage/cluster1"}, refreshed)
+	assert.True(t, parallel)
+}
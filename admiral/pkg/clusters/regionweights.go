@@ -0,0 +1,61 @@
+package clusters
+
+import (
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// regionEndpointWeightScale is what the weight of a region is multiplied by before it is split between the
+// endpoints of the region, so that the share of each endpoint stays a whole number
+const regionEndpointWeightScale = 100
+
+// getRegionWeights returns the weight of each region the traffic policy targets, the weights of a region
+// listed more than once are added up. It returns nil when the policy does not distribute the traffic by weight
+func getRegionWeights(policy *model.TrafficPolicy) map[string]uint32 {
+	if policy == nil || policy.LbType != model.TrafficPolicy_FAILOVER || len(policy.Target) == 0 {
+		return nil
+	}
+	weights := make(map[string]uint32)
+	for _, target := range policy.Target {
+		if target == nil || target.Region == "" || target.Weight <= 0 {
+			continue
+		}
+		weights[target.Region] += uint32(target.Weight)
+	}
+	if len(weights) == 0 {
+		return nil
+	}
+	return weights
+}
+
+// weightEndpointsByRegion returns a copy of the ServiceEntry whose endpoints are weighted by the weight of
+// their region, the weight of a region being split evenly between its endpoints. This way the clients get
+// the same distribution whatever their locality is, or when it is unknown. The endpoints of the regions
+// without weight are dropped, as they get no traffic from the locality distribution either, unless none
+// of the endpoints is in a weighted region
+func weightEndpointsByRegion(se *networking.ServiceEntry, weights map[string]uint32) *networking.ServiceEntry {
+	if se == nil || len(weights) == 0 {
+		return se
+	}
+	endpointsPerRegion := make(map[string]uint32)
+	for _, endpoint := range se.Endpoints {
+		if weights[endpoint.Locality] > 0 {
+			endpointsPerRegion[endpoint.Locality]++
+		}
+	}
+	if len(endpointsPerRegion) == 0 {
+		return se
+	}
+	weighted := copyServiceEntry(se)
+	endpoints := make([]*networking.WorkloadEntry, 0, len(weighted.Endpoints))
+	for _, endpoint := range weighted.Endpoints {
+		count := endpointsPerRegion[endpoint.Locality]
+		if count == 0 {
+			continue
+		}
+		endpoint.Weight = max(weights[endpoint.Locality]*regionEndpointWeightScale/count, 1)
+		endpoints = append(endpoints, endpoint)
+	}
+	weighted.Endpoints = endpoints
+	return weighted
+}
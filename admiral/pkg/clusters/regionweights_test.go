@@ -0,0 +1,93 @@
+package clusters
+
+import (
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestGetRegionWeights(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   *model.TrafficPolicy
+		expected map[string]uint32
+	}{
+		{
+			name: "Given a topology policy, " +
+				"When getRegionWeights is called, " +
+				"Then no weights should be returned",
+			policy:   &model.TrafficPolicy{LbType: model.TrafficPolicy_TOPOLOGY},
+			expected: nil,
+		},
+		{
+			name: "Given a failover policy across three regions, " +
+				"When getRegionWeights is called, " +
+				"Then the weight of each region with traffic should be returned",
+			policy: &model.TrafficPolicy{LbType: model.TrafficPolicy_FAILOVER, Target: []*model.TrafficGroup{
+				{Region: "us-west-2", Weight: 70},
+				{Region: "us-east-2", Weight: 30},
+				{Region: "eu-west-1", Weight: 0},
+			}},
+			expected: map[string]uint32{"us-west-2": 70, "us-east-2": 30},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, getRegionWeights(c.policy))
+		})
+	}
+}
+
+func TestWeightEndpointsByRegion(t *testing.T) {
+	se := &networking.ServiceEntry{
+		Hosts: []string{"stage.foo.global"},
+		Endpoints: []*networking.WorkloadEntry{
+			{Address: "west-1.elb", Locality: "us-west-2"},
+			{Address: "west-2.elb", Locality: "us-west-2"},
+			{Address: "east.elb", Locality: "us-east-2"},
+			{Address: "eu.elb", Locality: "eu-west-1"},
+		},
+	}
+	testCases := []struct {
+		name              string
+		weights           map[string]uint32
+		expectedEndpoints map[string]uint32
+	}{
+		{
+			name: "Given no region weights, " +
+				"When weightEndpointsByRegion is called, " +
+				"Then the endpoints should be left as they are",
+			weights:           nil,
+			expectedEndpoints: map[string]uint32{"west-1.elb": 0, "west-2.elb": 0, "east.elb": 0, "eu.elb": 0},
+		},
+		{
+			name: "Given region weights, " +
+				"When weightEndpointsByRegion is called, " +
+				"Then the weight of each region should be split between its endpoints and the regions without weight dropped",
+			weights:           map[string]uint32{"us-west-2": 60, "us-east-2": 40},
+			expectedEndpoints: map[string]uint32{"west-1.elb": 3000, "west-2.elb": 3000, "east.elb": 4000},
+		},
+		{
+			name: "Given region weights of regions without endpoints, " +
+				"When weightEndpointsByRegion is called, " +
+				"Then the endpoints should be left as they are",
+			weights:           map[string]uint32{"ap-south-1": 100},
+			expectedEndpoints: map[string]uint32{"west-1.elb": 0, "west-2.elb": 0, "east.elb": 0, "eu.elb": 0},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			weighted := weightEndpointsByRegion(se, c.weights)
+			endpoints := make(map[string]uint32)
+			for _, endpoint := range weighted.Endpoints {
+				endpoints[endpoint.Address] = endpoint.Weight
+			}
+			assert.Equal(t, c.expectedEndpoints, endpoints)
+			for _, endpoint := range se.Endpoints {
+				assert.Equal(t, uint32(0), endpoint.Weight)
+			}
+		})
+	}
+}
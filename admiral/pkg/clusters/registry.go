@@ -10,6 +10,8 @@ import (
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/secret"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/discovery"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/telemetry"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/util"
 	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
 	"k8s.io/client-go/rest"
@@ -41,6 +43,11 @@ func InitAdmiral(ctx context.Context, params common.AdmiralParams) (*RemoteRegis
 	RunAdmiralStateCheck(ctx, params.AdmiralStateCheckerName, drStateChecker)
 	pauseForAdmiralToInitializeState()
 
+	if timeout := common.GetStartupBarrierTimeout(); timeout > 0 {
+		raiseStartupBarrier()
+		go runRecovered(ctx, common.StartupBarrier, func() { awaitStartupBarrier(ctx, rr, timeout) })
+	}
+
 	var err error
 	destinationServiceProcessor := &ProcessDestinationService{}
 	routingPolicyProcessor := NewRoutingPolicyProcessor(rr)
@@ -59,7 +66,7 @@ func InitAdmiral(ctx context.Context, params common.AdmiralParams) (*RemoteRegis
 		logrus.Info("argo rollouts disabled")
 	}
 
-	if common.IsTrafficConfigProcessingEnabledForSlowStart() {
+	if common.IsTrafficConfigProcessingEnabledForSlowStart() || common.IsTrafficConfigThrottlingEnabled() {
 		tcHandler := TrafficConfigHandler{
 			RemoteRegistry: rr,
 		}
@@ -70,7 +77,7 @@ func InitAdmiral(ctx context.Context, params common.AdmiralParams) (*RemoteRegis
 		}
 		rr.TrafficConfigController = tcHandler.TrafficConfigController
 	} else {
-		logrus.Infof("TrafficConfig processing is disabled for slow start")
+		logrus.Infof("TrafficConfig processing is disabled for slow start and throttling")
 	}
 
 	configMapController, err := admiral.NewConfigMapController(params.ServiceEntryIPPrefix, rr.ClientLoader)
@@ -87,6 +94,44 @@ func InitAdmiral(ctx context.Context, params common.AdmiralParams) (*RemoteRegis
 		return nil, err
 	}
 
+	if params.DiscoverySourceType != "" {
+		source, err := discovery.NewSource(params.DiscoverySourceType, params.DiscoverySourceAddress)
+		if err != nil {
+			return nil, fmt.Errorf("error with discovery source init: %v", err)
+		}
+		go runRecovered(ctx, common.DiscoverySourceSync, func() { startDiscoverySourceSync(ctx, rr, source) })
+	}
+
+	if params.DNSProvider != "" {
+		provider, err := newDNSProvider(params, rr)
+		if err != nil {
+			return nil, fmt.Errorf("error with dns provider init: %v", err)
+		}
+		go runRecovered(ctx, common.DNSRecordSync, func() { startDNSRecordSync(ctx, rr, provider) })
+	}
+
+	go runRecovered(ctx, common.DestinationResolution, func() { wd.startDestinationResolution(ctx) })
+	go runRecovered(ctx, common.CacheMetrics, func() { startCacheMetrics(ctx, rr) })
+	go runRecovered(ctx, common.LazyIstioControllers, func() { startIdleIstioControllersTeardown(ctx, rr) })
+	go runRecovered(ctx, common.DeadClusterProbe, func() { startDeadClusterProber(ctx, rr) })
+	go runRecovered(ctx, common.DeferredDeletion, func() { startDeferredServiceEntryDeleter(ctx, rr) })
+	go runRecovered(ctx, common.OrphanPruning, func() { startOrphanPruning(ctx, rr) })
+	go runRecovered(ctx, common.GitOpsCommit, func() { startGitOpsCommitter(ctx) })
+	go runRecovered(ctx, common.ConfigHistoryPersistence, func() { startConfigHistoryPersister(ctx, rr) })
+	go runRecovered(ctx, common.IdentityLockWatchdog, func() { startIdentityLockWatchdog(ctx) })
+	go runRecovered(ctx, common.StaleCacheEviction, func() { startStaleCacheEviction(ctx, rr) })
+	go runRecovered(ctx, common.DependentClusterPersist, func() { startDependentClusterPersister(ctx, rr) })
+	go runRecovered(ctx, common.CacheConsistencyCheck, func() { startCacheConsistencyChecker(ctx, rr) })
+	go runRecovered(ctx, common.FullRefresh, func() { startFullRefresh(ctx, rr) })
+
+	if params.DependencyDiscoverySourceType != "" {
+		source, err := telemetry.NewSource(params.DependencyDiscoverySourceType, params.DependencyDiscoveryAddress, params.DependencyDiscoveryWindow)
+		if err != nil {
+			return nil, fmt.Errorf("error with dependency discovery init: %v", err)
+		}
+		go runRecovered(ctx, common.DependencyDiscovery, func() { startDependencyDiscovery(ctx, rr, source) })
+	}
+
 	go rr.shutdown()
 
 	return rr, err
@@ -160,7 +205,8 @@ func createSecretController(ctx context.Context, w *RemoteRegistry) error {
 		w.secretClient,
 		w.createCacheController,
 		w.updateCacheController,
-		w.deleteCacheController,
+		w.removeCluster,
+		w.updateClusterWritePause,
 		common.GetClusterRegistriesNamespace(),
 		common.GetAdmiralProfile(), common.GetAdmiralConfigPath())
 	if err != nil {
@@ -181,9 +227,18 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 			StartTime: time.Now(),
 		}
 	)
+	clientConfig = instrumentClientConfig(clientConfig, clusterID)
+	if common.EnableK8sEvents() {
+		logrus.Infof("starting EventRecorder clusterID: %v", clusterID)
+		kubeClient, err := r.ClientLoader.LoadKubeClientFromConfig(clientConfig)
+		if err != nil {
+			return fmt.Errorf("error with EventRecorder initialization, err: %v", err)
+		}
+		rc.EventRecorder = newEventRecorder(kubeClient)
+	}
 	if !common.IsAdmiralOperatorMode() {
 		logrus.Infof("starting ServiceController clusterID: %v", clusterID)
-		rc.ServiceController, err = admiral.NewServiceController(stop, &ServiceHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, 0, r.ClientLoader)
+		rc.ServiceController, err = admiral.NewServiceController(stop, &ServiceHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, util.ResyncInterval(resyncPeriod.ServiceReconcileInterval, 0), r.ClientLoader)
 		if err != nil {
 			return fmt.Errorf("error with ServiceController initialization, err: %v", err)
 		}
@@ -208,10 +263,22 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 			return fmt.Errorf("error with OutlierDetectionController initialization, err: %v", err)
 		}
 		logrus.Infof("starting NodeController clusterID: %v", clusterID)
-		rc.NodeController, err = admiral.NewNodeController(stop, &NodeHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, resyncPeriod.UniversalReconcileInterval, r.ClientLoader)
+		rc.NodeController, err = admiral.NewNodeController(stop, &NodeHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, util.ResyncInterval(resyncPeriod.NodeReconcileInterval, resyncPeriod.UniversalReconcileInterval), r.ClientLoader)
 		if err != nil {
 			return fmt.Errorf("error with NodeController controller initialization, err: %v", err)
 		}
+		rc.namespaces = &lazyNamespaceController{
+			clientConfig: clientConfig,
+			resyncPeriod: resyncPeriod.UniversalReconcileInterval,
+			clientLoader: r.ClientLoader,
+			stop:         stop,
+		}
+		// the namespaces are only cached for the clusters filtering them by label
+		if common.GetNamespaceDenySelector(clusterID) != "" {
+			if _, err = rc.namespaces.get(clusterID); err != nil {
+				return fmt.Errorf("error with NamespaceController initialization, err: %v", err)
+			}
+		}
 		logrus.Infof("starting RoutingPoliciesController for clusterID: %v", clusterID)
 		rpProcessor := NewRoutingPolicyProcessor(r)
 		rpHandler := NewRoutingPolicyHandler(r, clusterID, rpProcessor)
@@ -220,7 +287,7 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 			return fmt.Errorf("error with RoutingPoliciesController initialization, err: %v", err)
 		}
 		logrus.Infof("starting DeploymentController for clusterID: %v", clusterID)
-		rc.DeploymentController, err = admiral.NewDeploymentController(stop, &DeploymentHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, resyncPeriod.UniversalReconcileInterval, r.ClientLoader)
+		rc.DeploymentController, err = admiral.NewDeploymentController(stop, &DeploymentHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, util.ResyncInterval(resyncPeriod.DeploymentReconcileInterval, resyncPeriod.UniversalReconcileInterval), r.ClientLoader)
 		if err != nil {
 			return fmt.Errorf("error with DeploymentController initialization, err: %v", err)
 		}
@@ -228,7 +295,7 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 		if r.AdmiralCache == nil {
 			logrus.Warn("admiral cache was nil!")
 		} else if r.AdmiralCache.argoRolloutsEnabled {
-			rc.RolloutController, err = admiral.NewRolloutsController(stop, &RolloutHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, resyncPeriod.UniversalReconcileInterval, r.ClientLoader)
+			rc.RolloutController, err = admiral.NewRolloutsController(stop, &RolloutHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, util.ResyncInterval(resyncPeriod.RolloutReconcileInterval, resyncPeriod.UniversalReconcileInterval), r.ClientLoader)
 			if err != nil {
 				return fmt.Errorf("error with RolloutController initialization, err: %v", err)
 			}
@@ -244,6 +311,12 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 				if err != nil {
 					return fmt.Errorf("error with JobController initialization, err: %v", err)
 				}
+
+				logrus.Infof("starting CronJobController clusterID: %v", clusterID)
+				rc.CronJobController, err = admiral.NewCronJobController(stop, &ClientDiscoveryHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, 0, r.ClientLoader)
+				if err != nil {
+					return fmt.Errorf("error with CronJobController initialization, err: %v", err)
+				}
 			}
 
 			clustersForNumaflow := common.GetClientDiscoveryClustersForNumaflow()
@@ -264,13 +337,39 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 		}
 
 	}
+	if common.EnableLazyIstioControllers() {
+		err = r.createLazyIstioControllers(&rc, clientConfig, resyncPeriod)
+	} else {
+		err = r.createIstioControllers(&rc, stop, clientConfig, resyncPeriod)
+	}
+	if err != nil {
+		return err
+	}
+	r.PutRemoteController(clusterID, &rc)
+	clusterCtx := context.WithValue(context.Background(), common.ClusterName, clusterID)
+	go runRecovered(clusterCtx, common.SyncNamespaceMigration, func() { startSyncNamespaceMigration(stop, r, clusterID) })
+	go runRecovered(clusterCtx, common.VSNameMigration, func() { startVirtualServiceNameMigration(stop, r, clusterID) })
+	go runRecovered(clusterCtx, common.LBUpdateProcessor, func() { startLBMigrationProcessor(stop, r, clusterID) })
+	go runRecovered(clusterCtx, common.IngressHealthCheck, func() { startIngressHealthCheck(stop, r, clusterID) })
+	go runRecovered(clusterCtx, common.TrafficShift, func() { startTrafficShiftScheduler(stop, r, clusterID) })
+	go runRecovered(clusterCtx, common.GtpActivationWindow, func() { startGtpWindowScheduler(stop, r, clusterID) })
+	go runRecovered(clusterCtx, common.MeshFederation, func() { startMeshFederationImport(stop, r, clusterID) })
+	return nil
+}
+
+// createIstioControllers starts the ServiceEntry, DestinationRule, VirtualService and Sidecar controllers of the cluster
+func (r *RemoteRegistry) createIstioControllers(rc *RemoteController, stop <-chan struct{}, clientConfig *rest.Config, resyncPeriod util.ResyncIntervals) error {
+	var (
+		err       error
+		clusterID = rc.ClusterID
+	)
 	logrus.Infof("starting ServiceEntryController for clusterID: %v", clusterID)
-	rc.ServiceEntryController, err = istio.NewServiceEntryController(stop, &ServiceEntryHandler{RemoteRegistry: r, ClusterID: clusterID}, clusterID, clientConfig, resyncPeriod.SeAndDrReconcileInterval, r.ClientLoader)
+	rc.ServiceEntryController, err = istio.NewServiceEntryController(stop, &ServiceEntryHandler{RemoteRegistry: r, ClusterID: clusterID}, clusterID, clientConfig, util.ResyncInterval(resyncPeriod.ServiceEntryReconcileInterval, resyncPeriod.SeAndDrReconcileInterval), r.ClientLoader)
 	if err != nil {
 		return fmt.Errorf("error with ServiceEntryController initialization, err: %v", err)
 	}
 	logrus.Infof("starting DestinationRuleController for clusterID: %v", clusterID)
-	rc.DestinationRuleController, err = istio.NewDestinationRuleController(stop, &DestinationRuleHandler{RemoteRegistry: r, ClusterID: clusterID}, clusterID, clientConfig, resyncPeriod.SeAndDrReconcileInterval, r.ClientLoader)
+	rc.DestinationRuleController, err = istio.NewDestinationRuleController(stop, &DestinationRuleHandler{RemoteRegistry: r, ClusterID: clusterID}, clusterID, clientConfig, util.ResyncInterval(resyncPeriod.DestinationRuleReconcileInterval, resyncPeriod.SeAndDrReconcileInterval), r.ClientLoader)
 	if err != nil {
 		return fmt.Errorf("error with DestinationRuleController initialization, err: %v", err)
 	}
@@ -279,16 +378,15 @@ func (r *RemoteRegistry) createCacheController(clientConfig *rest.Config, cluste
 	if err != nil {
 		return fmt.Errorf("error initializing VirtualServiceHandler: %v", err)
 	}
-	rc.VirtualServiceController, err = istio.NewVirtualServiceController(stop, virtualServiceHandler, clientConfig, 0, r.ClientLoader)
+	rc.VirtualServiceController, err = istio.NewVirtualServiceController(stop, virtualServiceHandler, clientConfig, util.ResyncInterval(resyncPeriod.VirtualServiceReconcileInterval, 0), r.ClientLoader)
 	if err != nil {
 		return fmt.Errorf("error with VirtualServiceController initialization, err: %v", err)
 	}
 	logrus.Infof("starting SidecarController for clusterID: %v", clusterID)
-	rc.SidecarController, err = istio.NewSidecarController(stop, &SidecarHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, 0, r.ClientLoader)
+	rc.SidecarController, err = istio.NewSidecarController(stop, &SidecarHandler{RemoteRegistry: r, ClusterID: clusterID}, clientConfig, util.ResyncInterval(resyncPeriod.SidecarReconcileInterval, 0), r.ClientLoader)
 	if err != nil {
 		return fmt.Errorf("error with SidecarController initialization, err: %v", err)
 	}
-	r.PutRemoteController(clusterID, &rc)
 	return nil
 }
 
@@ -312,6 +410,25 @@ func (r *RemoteRegistry) updateCacheController(clientConfig *rest.Config, cluste
 	return nil
 }
 
+// removeCluster stops the cache controllers of a cluster admiral no longer watches and drops the writes queued for it
+func (r *RemoteRegistry) removeCluster(clusterID string) error {
+	clearClusterWritePause(clusterID)
+	clearSkippedWrites(clusterID)
+	clearMarkedServiceEntries(clusterID)
+	clearWrittenSpecs(clusterID)
+	clearResourceDiffs(clusterID)
+	return r.deleteCacheController(clusterID)
+}
+
+// updateClusterWritePause pauses or resumes writes to the cluster as set with the write-paused or sync annotation on its secret
+func (r *RemoteRegistry) updateClusterWritePause(clusterID string, paused bool) {
+	if paused {
+		PauseClusterWrites(clusterID, WritePauseSourceSecret)
+		return
+	}
+	ResumeClusterWrites(r, clusterID, WritePauseSourceSecret)
+}
+
 func (r *RemoteRegistry) deleteCacheController(clusterID string) error {
 	controller := r.GetRemoteController(clusterID)
 	if controller != nil {
@@ -0,0 +1,116 @@
+package clusters
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"k8s.io/client-go/rest"
+)
+
+// instrumentClientConfig returns a copy of the client config of the cluster whose requests to the
+// API server are measured. The latency of each request is recorded per cluster, verb and resource,
+// and the failed requests are counted per class of error, watches are left out of the latency
+func instrumentClientConfig(clientConfig *rest.Config, clusterID string) *rest.Config {
+	instrumented := rest.CopyConfig(clientConfig)
+	instrumented.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &remoteAPIRoundTripper{clusterID: clusterID, next: rt}
+	})
+	return instrumented
+}
+
+type remoteAPIRoundTripper struct {
+	clusterID string
+	next      http.RoundTripper
+}
+
+func (r *remoteAPIRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	attributes := []attribute.KeyValue{
+		attribute.Key("cluster").String(r.clusterID),
+		attribute.Key("verb").String(getRequestVerb(req)),
+		attribute.Key("resource").String(getRequestResource(req.URL.Path)),
+	}
+	if req.URL.Query().Get("watch") != "true" {
+		remoteAPILatency.Record(time.Since(start).Seconds(), api.WithAttributes(attributes...))
+	}
+	if class := getErrorClass(resp, err); class != "" {
+		remoteAPIErrors.Increment(api.WithAttributes(append(attributes, attribute.Key("class").String(class))...))
+	}
+	return resp, err
+}
+
+func getRequestVerb(req *http.Request) string {
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("watch") == "true" {
+			return "watch"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return strings.ToLower(req.Method)
+	}
+}
+
+// getRequestResource returns the resource of the API path, e.g. virtualservices for
+// /apis/networking.istio.io/v1alpha3/namespaces/ns/virtualservices/name
+func getRequestResource(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var rest []string
+	switch {
+	case len(segments) >= 3 && segments[0] == "apis":
+		rest = segments[3:]
+	case len(segments) >= 2 && segments[0] == "api":
+		rest = segments[2:]
+	default:
+		return "other"
+	}
+	if len(rest) >= 3 && rest[0] == "namespaces" {
+		rest = rest[2:]
+	}
+	if len(rest) == 0 {
+		return "other"
+	}
+	return rest[0]
+}
+
+// getErrorClass returns the class of the error of a failed request, or an empty string when the
+// request succeeded
+func getErrorClass(resp *http.Response, err error) string {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	switch {
+	case resp == nil || resp.StatusCode < http.StatusBadRequest:
+		return ""
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "throttled"
+	case resp.StatusCode == http.StatusNotFound:
+		return "not_found"
+	case resp.StatusCode == http.StatusConflict:
+		return "conflict"
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "unauthorized"
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	default:
+		return "client_error"
+	}
+}
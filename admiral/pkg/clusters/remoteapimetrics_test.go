@@ -0,0 +1,166 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/rest"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestGetRequestResource(t *testing.T) {
+	testCases := []struct {
+		name             string
+		path             string
+		expectedResource string
+	}{
+		{
+			name: "Given the path of a namespaced resource of a group, " +
+				"When getRequestResource is called, " +
+				"Then the resource should be returned",
+			path:             "/apis/networking.istio.io/v1alpha3/namespaces/sync/virtualservices/foo",
+			expectedResource: "virtualservices",
+		},
+		{
+			name: "Given the path of a list of a group across namespaces, " +
+				"When getRequestResource is called, " +
+				"Then the resource should be returned",
+			path:             "/apis/networking.istio.io/v1alpha3/serviceentries",
+			expectedResource: "serviceentries",
+		},
+		{
+			name: "Given the path of a core resource, " +
+				"When getRequestResource is called, " +
+				"Then the resource should be returned",
+			path:             "/api/v1/namespaces/sync/services/foo",
+			expectedResource: "services",
+		},
+		{
+			name: "Given the path of a namespace, " +
+				"When getRequestResource is called, " +
+				"Then namespaces should be returned",
+			path:             "/api/v1/namespaces/sync",
+			expectedResource: "namespaces",
+		},
+		{
+			name: "Given a path which is not of a resource, " +
+				"When getRequestResource is called, " +
+				"Then other should be returned",
+			path:             "/version",
+			expectedResource: "other",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedResource, getRequestResource(c.path))
+		})
+	}
+}
+
+func TestGetErrorClass(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statusCode    int
+		err           error
+		expectedClass string
+	}{
+		{
+			name: "Given a successful request, " +
+				"When getErrorClass is called, " +
+				"Then no class should be returned",
+			statusCode:    http.StatusOK,
+			expectedClass: "",
+		},
+		{
+			name: "Given a throttled request, " +
+				"When getErrorClass is called, " +
+				"Then throttled should be returned",
+			statusCode:    http.StatusTooManyRequests,
+			expectedClass: "throttled",
+		},
+		{
+			name: "Given a request which failed on the server, " +
+				"When getErrorClass is called, " +
+				"Then server_error should be returned",
+			statusCode:    http.StatusServiceUnavailable,
+			expectedClass: "server_error",
+		},
+		{
+			name: "Given a request for a missing resource, " +
+				"When getErrorClass is called, " +
+				"Then not_found should be returned",
+			statusCode:    http.StatusNotFound,
+			expectedClass: "not_found",
+		},
+		{
+			name: "Given a request which was forbidden, " +
+				"When getErrorClass is called, " +
+				"Then unauthorized should be returned",
+			statusCode:    http.StatusForbidden,
+			expectedClass: "unauthorized",
+		},
+		{
+			name: "Given an invalid request, " +
+				"When getErrorClass is called, " +
+				"Then client_error should be returned",
+			statusCode:    http.StatusUnprocessableEntity,
+			expectedClass: "client_error",
+		},
+		{
+			name: "Given a request which timed out, " +
+				"When getErrorClass is called, " +
+				"Then timeout should be returned",
+			err:           timeoutError{},
+			expectedClass: "timeout",
+		},
+		{
+			name: "Given a request which could not be sent, " +
+				"When getErrorClass is called, " +
+				"Then network should be returned",
+			err:           errors.New("connection refused"),
+			expectedClass: "network",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			var resp *http.Response
+			if c.err == nil {
+				resp = &http.Response{StatusCode: c.statusCode}
+			}
+			assert.Equal(t, c.expectedClass, getErrorClass(resp, c.err))
+		})
+	}
+}
+
+func TestInstrumentClientConfig(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	clientConfig := &rest.Config{Host: server.URL}
+	instrumented := instrumentClientConfig(clientConfig, "cluster1")
+	assert.Nil(t, clientConfig.WrapTransport)
+	assert.Equal(t, clientConfig.Host, instrumented.Host)
+
+	client, err := rest.HTTPClientFor(instrumented)
+	assert.Nil(t, err)
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPut,
+		server.URL+"/apis/networking.istio.io/v1alpha3/namespaces/sync/serviceentries/foo", nil)
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, []string{http.MethodPut}, methods)
+}
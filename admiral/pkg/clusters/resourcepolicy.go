@@ -0,0 +1,92 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const wildcardExportToPolicy = "wildcard-exportto"
+
+// ResourcePolicyEvaluator is called with every ServiceEntry, VirtualService and DestinationRule
+// admiral is about to apply to a cluster. The evaluator may mutate the annotations of the
+// resource, and rejects the change by returning an error in which case the resource is not applied.
+type ResourcePolicyEvaluator interface {
+	Evaluate(ctx context.Context, clusterID string, obj runtime.Object) error
+}
+
+/*
+utility function to identify the resource policy evaluator based on the program parameters
+*/
+func getResourcePolicyEvaluator() ResourcePolicyEvaluator {
+	switch strings.ToLower(common.GetResourcePolicyEvaluator()) {
+	// Add entries for your custom policy evaluators, such as OPA clients, below
+	// case "evaluator":
+	// return customEvaluator{}
+	case wildcardExportToPolicy:
+		return wildcardExportToPolicyEvaluator{deniedClusters: common.GetWildcardExportToDeniedClusters()}
+	default:
+		return NoOPResourcePolicyEvaluator{}
+	}
+}
+
+// evaluateResourcePolicy passes the resource to the configured policy evaluator and returns
+// an error when the change is rejected
+func evaluateResourcePolicy(ctxLogger *log.Entry, ctx context.Context, kind common.ResourceType, clusterID string, obj runtime.Object) error {
+	err := getResourcePolicyEvaluator().Evaluate(ctx, clusterID, obj)
+	if err == nil {
+		return nil
+	}
+	var name, namespace string
+	if accessor, accessorErr := meta.Accessor(obj); accessorErr == nil {
+		name, namespace = accessor.GetName(), accessor.GetNamespace()
+	}
+	resourcePolicyRejections.Increment(api.WithAttributes(
+		attribute.Key("kind").String(string(kind)),
+		attribute.Key("cluster").String(clusterID),
+	))
+	ctxLogger.Warnf(common.CtxLogFormat, "EvaluateResourcePolicy", name, namespace, clusterID, fmt.Sprintf("rejected %s: %v", kind, err))
+	return fmt.Errorf("resource policy rejected %s %s in cluster %s: %w", kind, name, clusterID, err)
+}
+
+/*
+Default implementation of the resource policy evaluator which accepts every change
+*/
+type NoOPResourcePolicyEvaluator struct{}
+
+func (NoOPResourcePolicyEvaluator) Evaluate(ctx context.Context, clusterID string, obj runtime.Object) error {
+	return nil
+}
+
+// wildcardExportToPolicyEvaluator rejects resources exported to every namespace in the denied clusters
+type wildcardExportToPolicyEvaluator struct {
+	deniedClusters []string
+}
+
+func (e wildcardExportToPolicyEvaluator) Evaluate(ctx context.Context, clusterID string, obj runtime.Object) error {
+	if !slices.Contains(e.deniedClusters, clusterID) {
+		return nil
+	}
+	var exportTo []string
+	switch resource := obj.(type) {
+	case *v1alpha3.ServiceEntry:
+		exportTo = resource.Spec.ExportTo
+	case *v1alpha3.VirtualService:
+		exportTo = resource.Spec.ExportTo
+	case *v1alpha3.DestinationRule:
+		exportTo = resource.Spec.ExportTo
+	}
+	if slices.Contains(exportTo, "*") {
+		return fmt.Errorf("exportTo * is not allowed in cluster %s", clusterID)
+	}
+	return nil
+}
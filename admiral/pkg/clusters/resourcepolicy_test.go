@@ -0,0 +1,101 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestEvaluateResourcePolicy(t *testing.T) {
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	wildcardSE := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se"},
+		Spec:       networkingV1Alpha3.ServiceEntry{ExportTo: []string{"*"}},
+	}
+	namespacedDR := &v1alpha3.DestinationRule{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr"},
+		Spec:       networkingV1Alpha3.DestinationRule{ExportTo: []string{"bar-ns"}},
+	}
+	wildcardVS := &v1alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-vs"},
+		Spec:       networkingV1Alpha3.VirtualService{ExportTo: []string{"istio-system", "*"}},
+	}
+	testCases := []struct {
+		name        string
+		evaluator   string
+		kind        common.ResourceType
+		cluster     string
+		obj         runtime.Object
+		expectedErr bool
+	}{
+		{
+			name: "Given no policy evaluator is configured, " +
+				"When a SE exported to * is evaluated, " +
+				"Then it should be accepted",
+			kind:    common.ServiceEntryResourceType,
+			cluster: "prod-cluster",
+			obj:     wildcardSE,
+		},
+		{
+			name: "Given the wildcard exportTo policy is configured, " +
+				"When a SE exported to * is evaluated for a denied cluster, " +
+				"Then it should be rejected",
+			evaluator:   wildcardExportToPolicy,
+			kind:        common.ServiceEntryResourceType,
+			cluster:     "prod-cluster",
+			obj:         wildcardSE,
+			expectedErr: true,
+		},
+		{
+			name: "Given the wildcard exportTo policy is configured, " +
+				"When a VS exported to * is evaluated for a denied cluster, " +
+				"Then it should be rejected",
+			evaluator:   wildcardExportToPolicy,
+			kind:        common.VirtualServiceResourceType,
+			cluster:     "prod-cluster",
+			obj:         wildcardVS,
+			expectedErr: true,
+		},
+		{
+			name: "Given the wildcard exportTo policy is configured, " +
+				"When a SE exported to * is evaluated for a cluster which is not denied, " +
+				"Then it should be accepted",
+			evaluator: wildcardExportToPolicy,
+			kind:      common.ServiceEntryResourceType,
+			cluster:   "dev-cluster",
+			obj:       wildcardSE,
+		},
+		{
+			name: "Given the wildcard exportTo policy is configured, " +
+				"When a DR exported to a namespace is evaluated for a denied cluster, " +
+				"Then it should be accepted",
+			evaluator: wildcardExportToPolicy,
+			kind:      common.DestinationRuleResourceType,
+			cluster:   "prod-cluster",
+			obj:       namespacedDR,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{
+				LabelSet:                       &common.LabelSet{},
+				ResourcePolicyEvaluator:        c.evaluator,
+				WildcardExportToDeniedClusters: []string{"prod-cluster"},
+			})
+			err := evaluateResourcePolicy(ctxLogger, context.Background(), c.kind, c.cluster, c.obj)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// validateGeneratedResource checks the ServiceEntry, VirtualService or DestinationRule admiral is about to
+// apply to the cluster when the validation is enabled, and returns an error when the resource is invalid,
+// in which case it is not applied
+func validateGeneratedResource(ctxLogger *log.Entry, ctx context.Context, kind common.ResourceType, rc *RemoteController, obj metaV1.Object) error {
+	if !common.EnableGeneratedResourceValidation() {
+		return nil
+	}
+	var (
+		violations []string
+		exportTo   []string
+	)
+	switch resource := obj.(type) {
+	case *v1alpha3.ServiceEntry:
+		violations, exportTo = validateGeneratedServiceEntry(resource), resource.Spec.ExportTo
+	case *v1alpha3.VirtualService:
+		violations, exportTo = validateGeneratedVirtualService(resource), resource.Spec.ExportTo
+	case *v1alpha3.DestinationRule:
+		violations, exportTo = validateGeneratedDestinationRule(resource), resource.Spec.ExportTo
+	}
+	violations = append(violations, validateExportTo(ctx, rc, exportTo)...)
+	if len(violations) == 0 {
+		return nil
+	}
+	resourceValidationRejections.Increment(api.WithAttributes(
+		attribute.Key("kind").String(string(kind)),
+		attribute.Key("cluster").String(rc.ClusterID),
+	))
+	ctxLogger.Warnf(common.CtxLogFormat, "ValidateGeneratedResource", obj.GetName(), obj.GetNamespace(), rc.ClusterID,
+		fmt.Sprintf("rejected invalid %s: %s", kind, strings.Join(violations, "; ")))
+	return fmt.Errorf("invalid %s %s in cluster %s: %s", kind, obj.GetName(), rc.ClusterID, strings.Join(violations, "; "))
+}
+
+// validateGeneratedServiceEntry returns the reasons the ServiceEntry is invalid
+func validateGeneratedServiceEntry(se *v1alpha3.ServiceEntry) []string {
+	var violations []string
+	if len(se.Spec.Hosts) == 0 {
+		violations = append(violations, "hosts are missing")
+	}
+	violations = append(violations, validateHosts("host", se.Spec.Hosts)...)
+	ports := make(map[uint32]bool)
+	for _, port := range se.Spec.Ports {
+		if port == nil {
+			continue
+		}
+		if ports[port.Number] {
+			violations = append(violations, fmt.Sprintf("port %d is duplicated", port.Number))
+		}
+		ports[port.Number] = true
+	}
+	for _, endpoint := range se.Spec.Endpoints {
+		if endpoint != nil && endpoint.Address == "" {
+			violations = append(violations, "endpoint has no address")
+		}
+	}
+	return violations
+}
+
+// validateGeneratedVirtualService returns the reasons the VirtualService is invalid. A VirtualService
+// without hosts is a delegate, and is only checked for its destinations
+func validateGeneratedVirtualService(vs *v1alpha3.VirtualService) []string {
+	violations := validateHosts("host", vs.Spec.Hosts)
+	var destinations []*networkingV1Alpha3.Destination
+	for _, route := range vs.Spec.Http {
+		for _, routeDestination := range route.GetRoute() {
+			destinations = append(destinations, routeDestination.GetDestination())
+		}
+		if route.GetMirror() != nil {
+			destinations = append(destinations, route.GetMirror())
+		}
+	}
+	for _, route := range vs.Spec.Tls {
+		for _, routeDestination := range route.GetRoute() {
+			destinations = append(destinations, routeDestination.GetDestination())
+		}
+	}
+	for _, route := range vs.Spec.Tcp {
+		for _, routeDestination := range route.GetRoute() {
+			destinations = append(destinations, routeDestination.GetDestination())
+		}
+	}
+	for _, destination := range destinations {
+		if destination == nil {
+			violations = append(violations, "route has a destination without a host")
+			continue
+		}
+		violations = append(violations, validateHost("destination host", destination.Host)...)
+	}
+	return violations
+}
+
+// validateGeneratedDestinationRule returns the reasons the DestinationRule is invalid
+func validateGeneratedDestinationRule(dr *v1alpha3.DestinationRule) []string {
+	return validateHost("host", dr.Spec.Host)
+}
+
+// validateHosts returns the reasons the hosts are invalid, including the hosts listed more than once
+func validateHosts(field string, hosts []string) []string {
+	var violations []string
+	seen := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		violations = append(violations, validateHost(field, host)...)
+		if seen[strings.ToLower(host)] {
+			violations = append(violations, fmt.Sprintf("%s %s is duplicated", field, host))
+		}
+		seen[strings.ToLower(host)] = true
+	}
+	return violations
+}
+
+// validateHost returns the reasons the host is not a valid DNS name, which may start with a * wildcard
+func validateHost(field, host string) []string {
+	if host == "" {
+		return []string{field + " is missing"}
+	}
+	if host == "*" {
+		return nil
+	}
+	var violations []string
+	for _, err := range validation.IsDNS1123Subdomain(strings.ToLower(strings.TrimPrefix(host, "*."))) {
+		violations = append(violations, fmt.Sprintf("%s %s is invalid: %s", field, host, err))
+	}
+	return violations
+}
+
+// validateExportTo returns the reasons the exportTo is invalid, including the namespaces it references
+// which do not exist in the cluster. The namespaces are not checked when they cannot be listed
+func validateExportTo(ctx context.Context, rc *RemoteController, exportTo []string) []string {
+	var (
+		violations []string
+		namespaces []string
+	)
+	for _, namespace := range exportTo {
+		switch namespace {
+		case "*":
+			if len(exportTo) > 1 {
+				violations = append(violations, "exportTo * is combined with other namespaces")
+			}
+		case ".", "~":
+		default:
+			if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+				violations = append(violations, fmt.Sprintf("exportTo namespace %s is invalid: %s", namespace, strings.Join(errs, ", ")))
+				continue
+			}
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) == 0 || rc.ServiceController == nil || rc.ServiceController.K8sClient == nil {
+		return violations
+	}
+	nsList, err := rc.ServiceController.K8sClient.CoreV1().Namespaces().List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		log.Warnf(LogFormat, "ValidateExportTo", "namespace", "", rc.ClusterID, "failed to list the namespaces, err: "+err.Error())
+		return violations
+	}
+	existing := make(map[string]bool, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		existing[ns.Name] = true
+	}
+	for _, namespace := range namespaces {
+		if !existing[namespace] {
+			violations = append(violations, fmt.Sprintf("exportTo namespace %s does not exist", namespace))
+		}
+	}
+	return violations
+}
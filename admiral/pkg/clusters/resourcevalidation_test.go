@@ -0,0 +1,186 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sFake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateGeneratedResource(t *testing.T) {
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	rc := &RemoteController{
+		ClusterID: "cluster1",
+		ServiceController: &admiral.ServiceController{K8sClient: k8sFake.NewSimpleClientset(
+			&coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "bar-ns"}},
+			&coreV1.Namespace{ObjectMeta: metaV1.ObjectMeta{Name: "istio-system"}},
+		)},
+	}
+	testCases := []struct {
+		name        string
+		enabled     bool
+		kind        common.ResourceType
+		obj         metaV1.Object
+		expectedErr bool
+	}{
+		{
+			name: "Given the validation is disabled, " +
+				"When a SE with duplicate hosts is validated, " +
+				"Then it should be accepted",
+			kind: common.ServiceEntryResourceType,
+			obj: &v1alpha3.ServiceEntry{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se"},
+				Spec:       networkingV1Alpha3.ServiceEntry{Hosts: []string{"foo.global", "foo.global"}},
+			},
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a valid SE exported to existing namespaces is validated, " +
+				"Then it should be accepted",
+			enabled: true,
+			kind:    common.ServiceEntryResourceType,
+			obj: &v1alpha3.ServiceEntry{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se"},
+				Spec: networkingV1Alpha3.ServiceEntry{
+					Hosts:     []string{"foo.global"},
+					ExportTo:  []string{"bar-ns", "istio-system"},
+					Ports:     []*networkingV1Alpha3.ServicePort{{Number: 80}},
+					Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "internal-lb.com"}},
+				},
+			},
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a SE with duplicate hosts is validated, " +
+				"Then it should be rejected",
+			enabled: true,
+			kind:    common.ServiceEntryResourceType,
+			obj: &v1alpha3.ServiceEntry{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se"},
+				Spec:       networkingV1Alpha3.ServiceEntry{Hosts: []string{"foo.global", "Foo.global"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a SE exported to a namespace missing from the cluster is validated, " +
+				"Then it should be rejected",
+			enabled: true,
+			kind:    common.ServiceEntryResourceType,
+			obj: &v1alpha3.ServiceEntry{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se"},
+				Spec:       networkingV1Alpha3.ServiceEntry{Hosts: []string{"foo.global"}, ExportTo: []string{"bar-ns", "baz-ns"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a VS with an invalid destination host is validated, " +
+				"Then it should be rejected",
+			enabled: true,
+			kind:    common.VirtualServiceResourceType,
+			obj: &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-vs"},
+				Spec: networkingV1Alpha3.VirtualService{
+					Hosts: []string{"foo.global"},
+					Http: []*networkingV1Alpha3.HTTPRoute{{
+						Route: []*networkingV1Alpha3.HTTPRouteDestination{{Destination: &networkingV1Alpha3.Destination{Host: "foo_bar.global"}}},
+					}},
+				},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a delegate VS without hosts is validated, " +
+				"Then it should be accepted",
+			enabled: true,
+			kind:    common.VirtualServiceResourceType,
+			obj: &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo-delegate-vs"},
+				Spec: networkingV1Alpha3.VirtualService{
+					ExportTo: []string{"."},
+					Tcp: []*networkingV1Alpha3.TCPRoute{{
+						Route: []*networkingV1Alpha3.RouteDestination{{Destination: &networkingV1Alpha3.Destination{Host: "foo.bar-ns.svc.cluster.local"}}},
+					}},
+				},
+			},
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a DR exported to * along with a namespace is validated, " +
+				"Then it should be rejected",
+			enabled: true,
+			kind:    common.DestinationRuleResourceType,
+			obj: &v1alpha3.DestinationRule{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr"},
+				Spec:       networkingV1Alpha3.DestinationRule{Host: "foo.global", ExportTo: []string{"*", "bar-ns"}},
+			},
+			expectedErr: true,
+		},
+		{
+			name: "Given the validation is enabled, " +
+				"When a DR without a host is validated, " +
+				"Then it should be rejected",
+			enabled: true,
+			kind:    common.DestinationRuleResourceType,
+			obj: &v1alpha3.DestinationRule{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-default-dr"},
+			},
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{
+				LabelSet:                          &common.LabelSet{},
+				EnableGeneratedResourceValidation: c.enabled,
+			})
+			err := validateGeneratedResource(ctxLogger, context.Background(), c.kind, rc, c.obj)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestAddUpdateServiceEntryRejectsInvalidServiceEntry(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                          &common.LabelSet{},
+		SyncNamespace:                     "ns",
+		EnableGeneratedResourceValidation: true,
+	})
+	ctx := context.Background()
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	istioClient := istioFake.NewSimpleClientset()
+	rc := &RemoteController{
+		ClusterID:              "cluster1",
+		ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient},
+	}
+	se := &v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo.global-se"},
+		Spec: networkingV1Alpha3.ServiceEntry{
+			Hosts:     []string{"foo.global", "foo.global"},
+			Endpoints: []*networkingV1Alpha3.WorkloadEntry{{Address: "internal-lb.com"}},
+		},
+	}
+	err := addUpdateServiceEntry(ctxLogger, ctx, se, nil, "ns", rc)
+	assert.NotNil(t, err)
+	seList, listErr := istioClient.NetworkingV1alpha3().ServiceEntries("ns").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, listErr)
+	assert.Empty(t, seList.Items)
+}
@@ -14,6 +14,10 @@ type RolloutHandler struct {
 	ClusterID      string
 }
 
+func (rh *RolloutHandler) GetClusterID() string {
+	return rh.ClusterID
+}
+
 func (rh *RolloutHandler) Added(ctx context.Context, obj *argo.Rollout) error {
 	err := HandleEventForRollout(ctx, admiral.Add, obj, rh.RemoteRegistry, rh.ClusterID)
 	if err != nil {
@@ -45,6 +49,10 @@ func HandleEventForRollout(ctx context.Context, event admiral.EventType, obj *ar
 	if len(globalIdentifier) == 0 {
 		return nil
 	}
+	if isNamespaceFiltered(remoteRegistry, clusterName, obj.Namespace) {
+		log.Infof(LogFormat, event, common.RolloutResourceType, obj.Name, clusterName, "skipped as namespace="+obj.Namespace+" is filtered")
+		return nil
+	}
 	env := common.GetEnvForRollout(obj)
 
 	ctx = context.WithValue(ctx, "clusterName", clusterName)
@@ -52,6 +60,11 @@ func HandleEventForRollout(ctx context.Context, event admiral.EventType, obj *ar
 
 	_ = callRegistryForRollout(ctx, event, remoteRegistry, globalIdentifier, clusterName, obj)
 
+	if event != admiral.Delete {
+		remoteRegistry.startLazyIstioControllers(clusterName)
+		recordIdentityTeam(globalIdentifier, obj.Spec.Template.Annotations, obj.Spec.Template.Labels)
+	}
+
 	if remoteRegistry.AdmiralCache != nil {
 		if remoteRegistry.AdmiralCache.IdentityClusterCache != nil {
 			remoteRegistry.AdmiralCache.IdentityClusterCache.Put(globalIdentifier, clusterName, clusterName)
@@ -67,7 +80,7 @@ func HandleEventForRollout(ctx context.Context, event admiral.EventType, obj *ar
 	}
 
 	// Use the same function as added deployment function to update and put new service entry in place to replace old one
-	_, err := modifyServiceEntryForNewServiceOrPod(ctx, event, env, globalIdentifier, remoteRegistry)
+	_, err := modifyServiceEntryForWorkloadEvent(ctx, event, env, globalIdentifier, remoteRegistry)
 
 	if common.ClientInitiatedProcessingEnabledForControllers() {
 		var c ClientDependencyRecordProcessor
@@ -85,11 +98,11 @@ func callRegistryForRollout(ctx context.Context, event admiral.EventType, regist
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Rollout, ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Rollout, common.GetTxId(ctx), obj)
 		case admiral.Update:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Rollout, ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Rollout, common.GetTxId(ctx), obj)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Rollout, ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, common.Rollout, common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, common.Rollout, obj.Name, clusterName, "failed to "+string(event)+" "+common.Rollout+" with err: "+err.Error())
@@ -383,11 +383,11 @@ func callRegistryForRoutingPolicy(ctx context.Context, event admiral.EventType,
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutCustomData(clusterName, routingPolicy.Namespace, routingPolicy.Name, "RoutingPolicy", ctx.Value("txId").(string), routingPolicy)
+			err = registry.RegistryClient.PutCustomData(clusterName, routingPolicy.Namespace, routingPolicy.Name, "RoutingPolicy", common.GetTxId(ctx), routingPolicy)
 		case admiral.Update:
-			err = registry.RegistryClient.PutCustomData(clusterName, routingPolicy.Namespace, routingPolicy.Name, "RoutingPolicy", ctx.Value("txId").(string), routingPolicy)
+			err = registry.RegistryClient.PutCustomData(clusterName, routingPolicy.Namespace, routingPolicy.Name, "RoutingPolicy", common.GetTxId(ctx), routingPolicy)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteCustomData(clusterName, routingPolicy.Namespace, routingPolicy.Name, "RoutingPolicy", ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteCustomData(clusterName, routingPolicy.Namespace, routingPolicy.Name, "RoutingPolicy", common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, "RoutingPolicy", routingPolicy.Name, clusterName, "failed to "+string(event)+" RoutingPolicy with err: "+err.Error())
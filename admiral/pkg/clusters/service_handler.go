@@ -59,6 +59,11 @@ func handleEventForService(
 		return fmt.Errorf("could not find the remote controller for cluster=%s", clusterName)
 	}
 
+	// the east-west gateway is discovered again with its new address
+	if isEastWestGatewayService(svc) && remoteRegistry.AdmiralCache != nil && remoteRegistry.AdmiralCache.EastWestGatewayCache != nil {
+		remoteRegistry.AdmiralCache.EastWestGatewayCache.Delete(clusterName)
+	}
+
 	var handleSvcEventError error
 	deploymentController := rc.DeploymentController
 	rolloutController := rc.RolloutController
@@ -99,14 +104,14 @@ func handleServiceEventForDeployment(
 		return fmt.Errorf(AlertLogMsg, ctx.Value(common.EventType))
 	}
 
-	if common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().LabelSet.GatewayApp) || common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().NLBIngressLabel) {
+	if common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().LabelSet.GatewayApp) || common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().NLBIngressLabel) || isEastWestGatewayService(svc) {
 		// The eventType is overridden to admiral.Update. This is mainly
 		// for admiral.Delete events sent for the ingress in the cluster
 		// else it would delete all the SEs in the source and dependent clusters
 		eventType = admiral.Update
 		deployments = deployController.Cache.List()
-		if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) {
-			regErr := remoteRegistry.RegistryClient.PutClusterGateway(clusterName, svc.Name, svc.Status.LoadBalancer.Ingress[0].Hostname, "", "istio-ingressgateway", ctx.Value("txId").(string), nil)
+		if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && len(svc.Status.LoadBalancer.Ingress) > 0 {
+			regErr := remoteRegistry.RegistryClient.PutClusterGateway(clusterName, svc.Name, svc.Status.LoadBalancer.Ingress[0].Hostname, "", "istio-ingressgateway", common.GetTxId(ctx), nil)
 			if regErr != nil {
 				log.Errorf(LogFormat, "Event", "Deployment", "", clusterName,
 					fmt.Sprintf("failed to push cluster gateway in namespace %s for service %s", svc.Namespace, svc.Name))
@@ -164,14 +169,14 @@ func handleServiceEventForRollout(
 		return fmt.Errorf(AlertLogMsg, ctx.Value(common.EventType))
 	}
 
-	if common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().LabelSet.GatewayApp) || common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().NLBIngressLabel) {
+	if common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().LabelSet.GatewayApp) || common.IsIstioIngressGatewayService(svc, common.GetAdmiralParams().NLBIngressLabel) || isEastWestGatewayService(svc) {
 		// The eventType is overridden to admiral.Update. This is mainly
 		// for admiral.Delete events sent for the ingress in the cluster
 		// else it would delete all the SEs in the source and dependent clusters
 		eventType = admiral.Update
 		rollouts = rolloutController.Cache.List()
-		if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) {
-			regErr := remoteRegistry.RegistryClient.PutClusterGateway(clusterName, svc.Name, svc.Status.LoadBalancer.Ingress[0].Hostname, "", "istio-ingressgateway", ctx.Value("txId").(string), nil)
+		if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && len(svc.Status.LoadBalancer.Ingress) > 0 {
+			regErr := remoteRegistry.RegistryClient.PutClusterGateway(clusterName, svc.Name, svc.Status.LoadBalancer.Ingress[0].Hostname, "", "istio-ingressgateway", common.GetTxId(ctx), nil)
 			if regErr != nil {
 				log.Errorf(LogFormat, "Event", "Rollout", "", clusterName,
 					fmt.Sprintf("failed to push cluster gateway in namespace %s for service %s", svc.Namespace, svc.Name))
@@ -275,11 +280,11 @@ func callRegistryForService(ctx context.Context, event admiral.EventType, regist
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case admiral.Add:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, "Service", ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, "Service", common.GetTxId(ctx), obj)
 		case admiral.Update:
-			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, "Service", ctx.Value("txId").(string), obj)
+			err = registry.RegistryClient.PutHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, "Service", common.GetTxId(ctx), obj)
 		case admiral.Delete:
-			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, "Service", ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteHostingData(clusterName, obj.Namespace, obj.Name, globalIdentifier, "Service", common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, "Service", obj.Name, clusterName, "failed to "+string(event)+" Service with err: "+err.Error())
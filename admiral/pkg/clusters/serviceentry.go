@@ -5,10 +5,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
-	"math"
-	"math/rand"
 	"reflect"
-	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,6 +13,7 @@ import (
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/core/vsrouting"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/sdk"
 	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
 	"gopkg.in/yaml.v2"
 
@@ -44,12 +42,14 @@ type SeDrTuple struct {
 	DestinationRule             *networking.DestinationRule
 	SeDnsPrefix                 string
 	SeDrGlobalTrafficPolicyName string
+	SeDrTrafficPolicySource     string
 }
 
 const (
 	resourceCreatedByAnnotationLabel             = "app.kubernetes.io/created-by"
 	resourceCreatedByAnnotationValue             = "admiral"
 	resourceCreatedByAnnotationCartographerValue = "cartographer"
+	exportToCappedAnnotationLabel                = "admiral.io/exportto-capped"
 	dnsPrefixAnnotationLabel                     = "dns-prefix"
 	serviceEntryAssociatedGtpAnnotationLabel     = "associated-gtp"
 	gtpManagedByGithub                           = "github"
@@ -95,6 +95,9 @@ func createServiceEntryForDeployment(
 	}
 
 	san := getSanForDeployment(destDeployment, workloadIdentityKey)
+	if spiffeID := getWorkloadSpiffeID(rc.ClusterID, &destDeployment.Spec.Template); len(san) > 0 && spiffeID != "" {
+		san = []string{spiffeID}
+	}
 	return generateServiceEntry(ctxLogger, event, admiralCache, meshPorts, globalFqdn, rc, serviceEntries, address, san, common.Deployment), nil
 }
 
@@ -105,6 +108,7 @@ func modifyServiceEntryForNewServiceOrPod(
 	sourceIdentity string, remoteRegistry *RemoteRegistry) (map[string]*networking.ServiceEntry, error) {
 	ctxLogger := common.GetCtxLogger(ctx, sourceIdentity, env)
 	ctxLogger.Infof(common.CtxLogFormat, "event", "", "", "", "received")
+	defer lockIdentity(ctx, sourceIdentity)()
 	defer util.LogElapsedTimeForTask(ctxLogger, "event", "", "", "", "TotalModifySETime")()
 	var modifySEerr error
 	var isServiceEntryModifyCalledForSourceCluster bool
@@ -162,6 +166,7 @@ func modifyServiceEntryForNewServiceOrPod(
 		outlierDetections                     = make(map[string][]*v1.OutlierDetection)
 		clientConnectionSettings              = make(map[string][]*v1.ClientConnectionConfig)
 		gtps                                  = make(map[string][]*v1.GlobalTrafficPolicy)
+		inheritedGtps                         = make(map[string][]*v1.GlobalTrafficPolicy)
 		weightedServices                      = make(map[string]*WeightedService)
 		cnames                                = make(map[string]string)
 		sourceServices                        = make(map[string]map[string]*k8sV1.Service)
@@ -174,6 +179,7 @@ func modifyServiceEntryForNewServiceOrPod(
 		clusterAppDeleteMap                   = make(map[string]string)
 		clusterDeployRolloutPresent           = make(map[string]map[string]bool)
 		sourceClusters                        []string
+		stabilizingClusters                   []string
 		isAdditionalEndpointGenerationEnabled bool
 		deployRolloutMigration                = make(map[string]bool)
 
@@ -183,6 +189,8 @@ func modifyServiceEntryForNewServiceOrPod(
 		sourceClusterToInClusterDestinations = make(map[string]map[string][]*vsrouting.RouteDestination)
 		// Holds the DR hosts (*.svc.cluster.local) used for VS based routing
 		sourceClusterToDRHosts = make(map[string]map[string]string)
+		// Holds the mesh ports of the source cluster used for VS based routing
+		sourceClusterToMeshPorts = make(map[string]map[string]uint32)
 		// Holds the source cluster to namespace mapping from where the event is received
 		sourceClusterToEventNsCache = make(map[string]string)
 	)
@@ -228,20 +236,40 @@ func modifyServiceEntryForNewServiceOrPod(
 			rollout = rc.RolloutController.Cache.Get(partitionedIdentity, env)
 		}
 
+		// Workloads cached before their namespace was filtered should not be synced
+		if deployment != nil && isNamespaceFiltered(remoteRegistry, clusterId, deployment.Namespace) {
+			ctxLogger.Infof(common.CtxLogFormat, "event", deployment.Name, deployment.Namespace, clusterId, "skipped deployment as namespace is filtered")
+			deployment = nil
+		}
+
+		if rollout != nil && isNamespaceFiltered(remoteRegistry, clusterId, rollout.Namespace) {
+			ctxLogger.Infof(common.CtxLogFormat, "event", rollout.Name, rollout.Namespace, clusterId, "skipped rollout as namespace is filtered")
+			rollout = nil
+		}
+
 		if deployment == nil && rollout == nil {
 			ctxLogger.Infof(common.CtxLogFormat, "event", "", "", clusterId, "neither deployment nor rollouts found")
 			continue
 		}
 
+		if isWorkloadStabilizing(remoteRegistry, event, clusterId, identityKey, deployment, rollout) {
+			err := fmt.Errorf("processing deferred for cluster %s as workload of identity %s in env %s is not fully available", clusterId, sourceIdentity, env)
+			ctxLogger.Infof(common.CtxLogFormat, "event", "", "", clusterId, err.Error())
+			stabilizingClusters = append(stabilizingClusters, clusterId)
+			modifySEerr = common.AppendError(modifySEerr, err)
+			continue
+		}
+
 		// START - Admiral 2.0
 		ingressEndpoint, port, _ := getOverwrittenLoadBalancer(ctxLogger, rc, clusterName, remoteRegistry.AdmiralCache)
 
 		registryConfig.Clusters[clusterId] = &registry.IdentityConfigCluster{
-			Name:            clusterId,
-			Locality:        getLocality(rc),
-			IngressEndpoint: ingressEndpoint,
-			IngressPort:     strconv.Itoa(port),
-			Environment:     map[string]*registry.IdentityConfigEnvironment{},
+			Name:             clusterId,
+			Locality:         getLocality(rc),
+			IngressEndpoint:  ingressEndpoint,
+			IngressPort:      strconv.Itoa(port),
+			IngressEndpoints: getClusterIngressEndpoints(rc, ingressEndpoint, getLocality(rc)),
+			Environment:      map[string]*registry.IdentityConfigEnvironment{},
 		}
 		// END admiral 2.0
 
@@ -293,6 +321,12 @@ func modifyServiceEntryForNewServiceOrPod(
 			}
 			sourceServices[rc.ClusterID][common.Deployment] = serviceInstance
 			sourceClusterToEventNsCache[rc.ClusterID] = deployment.Namespace
+			// the endpoints are spread across the other deployments of the identity behind the env
+			if !deployRolloutMigration[rc.ClusterID] {
+				if variantServices := getWeightedServicesForDeploymentVariants(ctx, ctxLogger, remoteRegistry, rc, partitionedIdentity, env); len(variantServices) > 1 {
+					sourceWeightedServices[rc.ClusterID] = variantServices
+				}
+			}
 
 			namespace = deployment.Namespace
 			localMeshPorts := common.GetMeshPortsForDeployments(rc.ClusterID, serviceInstance, deployment)
@@ -352,6 +386,12 @@ func modifyServiceEntryForNewServiceOrPod(
 				serviceInstance = sInstance.Service
 				break
 			}
+			// the endpoints are spread across the other rollouts of the identity behind the env
+			if len(weightedServices) == 1 && !isBlueGreenStrategy(rollout) {
+				if variantServices := getWeightedServicesForRolloutVariants(ctx, ctxLogger, remoteRegistry, rc, partitionedIdentity, env); len(variantServices) > 1 {
+					weightedServices = variantServices
+				}
+			}
 			sourceServices[rc.ClusterID][common.Rollout] = serviceInstance
 			sourceClusterToEventNsCache[rc.ClusterID] = rollout.Namespace
 
@@ -375,6 +415,11 @@ func modifyServiceEntryForNewServiceOrPod(
 			}
 		}
 
+		if owner, ok := claimIdentityCname(remoteRegistry.AdmiralCache, env, cname, partitionedIdentity,
+			getWorkloadCreationTime(deployment, rollout)); !ok {
+			reportHostConflict(ctxLogger, HostClaimIdentity, cname, owner, sourceIdentity, rc.ClusterID)
+			return nil, common.AppendError(modifySEerr, fmt.Errorf("cname %s is owned by identity %s", cname, owner))
+		}
 		start = time.Now()
 		remoteRegistry.AdmiralCache.CnameClusterCache.Put(cname, rc.ClusterID, rc.ClusterID)
 		remoteRegistry.AdmiralCache.CnameIdentityCache.Store(cname, partitionedIdentity)
@@ -392,6 +437,9 @@ func modifyServiceEntryForNewServiceOrPod(
 			gtps[rc.ClusterID] = gtpsInNamespace
 		} else {
 			ctxLogger.Infof(common.CtxLogFormat, "GetGlobalTrafficCache", deploymentOrRolloutName, deploymentOrRolloutNS, clusterId, "No GTPs found")
+			if inheritedGtp := getInheritedGtp(rc, partitionedIdentity, env, namespace, time.Now()); inheritedGtp != nil {
+				inheritedGtps[rc.ClusterID] = []*v1.GlobalTrafficPolicy{inheritedGtp}
+			}
 		}
 
 		if rc.OutlierDetectionController != nil && rc.OutlierDetectionController.GetCache() != nil {
@@ -415,11 +463,46 @@ func modifyServiceEntryForNewServiceOrPod(
 			}
 		}
 	}
+	if len(stabilizingClusters) > 0 {
+		if len(sourceClusters) == 0 {
+			// the workload is stabilizing in all its clusters, so there is nothing to update yet
+			return nil, modifySEerr
+		}
+		keepStabilizingClusterEndpoints(ctxLogger, remoteRegistry, stabilizingClusters, sourceClusters, serviceEntries)
+	}
+	if event != admiral.Delete && isBlueGreenPreviewPromotedInAllClusters(sourceRollouts) {
+		for _, rollout := range sourceRollouts {
+			if err := deleteBlueGreenPreviewResources(ctx, ctxLogger, remoteRegistry, rollout); err != nil {
+				ctxLogger.Errorf(common.CtxLogFormat, "DeleteBlueGreenPreview", deploymentOrRolloutName, deploymentOrRolloutNS, "", err.Error())
+			}
+			break
+		}
+	}
+	// the requested sync namespace is only refreshed while the identity has workloads, so that
+	// its resources are deleted from the namespace they were written to
+	if len(sourceDeployments) > 0 || len(sourceRollouts) > 0 {
+		registryConfig.SyncNamespace = getRequestedSyncNamespace(sourceDeployments, sourceRollouts)
+		putIdentitySyncNamespace(remoteRegistry.AdmiralCache, partitionedIdentity, registryConfig.SyncNamespace)
+		registryConfig.ClientTLSSettings = getRequestedClientTLSSettings(sourceDeployments, sourceRollouts)
+		if remoteRegistry.AdmiralCache.IdentityClientTLSSettingsCache != nil {
+			remoteRegistry.AdmiralCache.IdentityClientTLSSettingsCache.Put(partitionedIdentity, registryConfig.ClientTLSSettings)
+		}
+		if remoteRegistry.AdmiralCache.IdentityWarmupDurationCache != nil {
+			remoteRegistry.AdmiralCache.IdentityWarmupDurationCache.Put(partitionedIdentity, env,
+				getRequestedWarmupDuration(sourceDeployments, sourceRollouts))
+		}
+		registryConfig.FailoverPriority = getRequestedFailoverPriority(sourceDeployments, sourceRollouts)
+		if remoteRegistry.AdmiralCache.IdentityFailoverPriorityCache != nil {
+			remoteRegistry.AdmiralCache.IdentityFailoverPriorityCache.Put(partitionedIdentity, registryConfig.FailoverPriority)
+		}
+	}
 	//PID: use partitionedIdentity because IdentityDependencyCache is filled using the partitionedIdentity - DONE
 	dependents := remoteRegistry.AdmiralCache.IdentityDependencyCache.Get(partitionedIdentity).Copy()
 	// updates CnameDependentClusterCache and CnameDependentClusterNamespaceCache
 	cname = strings.TrimSpace(cname)
 	if cname == "" {
+		// the identity no longer has workloads, so the cname it owned can be claimed by another identity
+		releaseIdentityCnames(remoteRegistry.AdmiralCache, partitionedIdentity)
 		ctxLogger.Infof(common.CtxLogFormat, "UpdateCnameDependentClusterNamespaceCache", deploymentOrRolloutName, deploymentOrRolloutNS, "", "Skipping processing as cname is empty")
 		return nil, common.AppendError(modifySEerr, errors.New("skipped processing as cname is empty"))
 	}
@@ -450,9 +533,15 @@ func modifyServiceEntryForNewServiceOrPod(
 	util.LogElapsedTimeSinceTask(ctxLogger, "BuildServiceEntry",
 		deploymentOrRolloutName, deploymentOrRolloutNS, "", "", start)
 
+	if dropped := enforceHostQuota(ctxLogger, remoteRegistry.AdmiralCache, partitionedIdentity, env, serviceEntries); len(dropped) > 0 {
+		recordHostQuotaEvents(remoteRegistry, sourceDeployments, sourceRollouts, partitionedIdentity, dropped)
+	}
+
 	//cache the latest GTP in global cache to be reused during DR creation
 	start = time.Now()
 	// GTP preference region is the region to which the failover has to be done. Because we want to update the DRs in active region for the service first.
+	// the identities without a GTP of their own inherit the default traffic policies of their namespace and of the org
+	gtps = withInheritedGtps(gtps, inheritedGtps, time.Now())
 	gtpPreferenceRegion, err := updateGlobalGtpCacheAndGetGtpPreferenceRegion(remoteRegistry, partitionedIdentity, env, gtps, clusterName, ctxLogger)
 	ctx = context.WithValue(ctx, common.GtpPreferenceRegion, gtpPreferenceRegion)
 	if err != nil {
@@ -545,8 +634,9 @@ func modifyServiceEntryForNewServiceOrPod(
 			meshDeployAndRolloutPorts[common.Rollout] = meshPorts
 		}
 		registryConfig.Clusters[sourceCluster].IngressPortName = getIngressPortName(meshPorts)
+		sourceClusterToMeshPorts[sourceCluster] = meshPorts
 
-		registryConfig.Clusters[sourceCluster].Environment[env].Ports = []*networking.ServicePort{getServiceEntryPort(meshPorts)}
+		registryConfig.Clusters[sourceCluster].Environment[env].Ports = getServiceEntryPorts(meshPorts)
 		// store admiral crd configurations for state syncer
 		gtp, err := remoteRegistry.AdmiralCache.GlobalTrafficCache.GetFromIdentity(sourceIdentity, env)
 		if err != nil {
@@ -570,8 +660,14 @@ func modifyServiceEntryForNewServiceOrPod(
 		if ccc != nil {
 			registryConfig.Clusters[sourceCluster].Environment[env].TrafficPolicy.ClientConnectionConfig = *ccc
 		}
+		if remoteRegistry.AdmiralCache.IdentityWarmupDurationCache != nil {
+			registryConfig.Clusters[sourceCluster].Environment[env].WarmupDurationSecs =
+				remoteRegistry.AdmiralCache.IdentityWarmupDurationCache.Get(partitionedIdentity, env)
+		}
 
+		excludedAddresses := getSourceClusterExcludedAddresses(ctxLogger, rc, remoteRegistry.AdmiralCache, sourceCluster)
 		for key, serviceEntry := range serviceEntries {
+			serviceEntry = removeEndpointsWithAddresses(serviceEntry, excludedAddresses)
 			if len(serviceEntry.Endpoints) == 0 || (!deployRolloutMigration[sourceCluster] && clustersToDeleteSE[sourceCluster]) {
 				if common.IsAdmiralStateSyncerMode() {
 					registryConfig.Clusters[sourceCluster].Environment[env] = &registry.IdentityConfigEnvironment{
@@ -876,7 +972,7 @@ func modifyServiceEntryForNewServiceOrPod(
 	// VS Based Routing
 	// Writing phase: We update the base ingress virtualservices with the RouteDestinations
 	// gathered during the discovery phase and write them to the source cluster
-	err = addUpdateVirtualServicesForIngress(ctx, ctxLogger, remoteRegistry, sourceClusterToDestinations, cname)
+	err = addUpdateVirtualServicesForIngress(ctx, ctxLogger, remoteRegistry, sourceClusterToDestinations, cname, sourceIdentity)
 	if err != nil {
 		ctxLogger.Errorf(common.CtxLogFormat, "addUpdateVirtualServicesForIngress",
 			deploymentOrRolloutName, namespace, "", err)
@@ -887,6 +983,7 @@ func modifyServiceEntryForNewServiceOrPod(
 			ctxLogger,
 			remoteRegistry,
 			sourceClusterToDRHosts,
+			sourceClusterToMeshPorts,
 			sourceIdentity)
 		if err != nil {
 			ctxLogger.Errorf(common.CtxLogFormat, "addUpdateDestinationRuleForSourceIngress",
@@ -913,7 +1010,7 @@ func modifyServiceEntryForNewServiceOrPod(
 	// Writing phase: We update the base in-cluster virtualservices with the RouteDestinations
 	// gathered during the discovery phase and write them to the source cluster
 	err = addUpdateInClusterVirtualServices(
-		ctx, ctxLogger, remoteRegistry, sourceClusterToInClusterDestinations, cname, sourceIdentity, env)
+		ctx, ctxLogger, remoteRegistry, sourceClusterToInClusterDestinations, sourceClusterToMeshPorts, cname, sourceIdentity, env)
 	if err != nil {
 		ctxLogger.Errorf(common.CtxLogFormat, "addUpdateInClusterVirtualServices",
 			deploymentOrRolloutName, namespace, "", err)
@@ -924,6 +1021,7 @@ func modifyServiceEntryForNewServiceOrPod(
 			ctxLogger,
 			remoteRegistry,
 			sourceClusterToDRHosts,
+			sourceClusterToMeshPorts,
 			sourceIdentity,
 			cname,
 			env)
@@ -955,6 +1053,8 @@ func modifyServiceEntryForNewServiceOrPod(
 		ctxLogger.Errorf(common.CtxLogFormat, "Event", deploymentOrRolloutName, deploymentOrRolloutNS, "", err.Error())
 		modifySEerr = common.AppendError(modifySEerr, err)
 	}
+	recordSourceWorkloadEvents(remoteRegistry, sourceDeployments, sourceRollouts, serviceEntries, clusters, err)
+	notifyIdentityWithoutEndpoints(event, partitionedIdentity, env, serviceEntries)
 
 	util.LogElapsedTimeSinceTask(ctxLogger, "WriteServiceEntryToDependentClusters",
 		deploymentOrRolloutName, deploymentOrRolloutNS, "", "", start)
@@ -966,9 +1066,28 @@ func modifyServiceEntryForNewServiceOrPod(
 This method first fetch CLB (or load balancer associated with app label mention in gateway_app
 If provided cluster is overwritten with some other app label mention in nlb-istio-ingressgateway
 
-	then overwrite load balancer
+	then overwrite load balancer. The secondary ingress endpoint of the cluster is returned instead
+	while its load balancer fails the ingress health checks
 */
 func getOverwrittenLoadBalancer(ctx *logrus.Entry, rc *RemoteController, clusterName string, admiralCache *AdmiralCache) (string, int, error) {
+	endpoint, port, err := getPrimaryLoadBalancer(ctx, rc, clusterName, admiralCache)
+	if err != nil {
+		return endpoint, port, err
+	}
+	if secondaryEndpoint, secondaryPort, ok := getIngressFailoverEndpoint(admiralCache, clusterName); ok {
+		ctx.WithFields(logrus.Fields{
+			"task":        common.IngressHealthCheck,
+			"clusterName": clusterName,
+			"PrimaryLB":   endpoint,
+			"SecondaryLB": secondaryEndpoint,
+		}).Info("")
+		return secondaryEndpoint, secondaryPort, nil
+	}
+	return endpoint, port, nil
+}
+
+// getPrimaryLoadBalancer returns the load balancer of the cluster ingress, ignoring the ingress health checks
+func getPrimaryLoadBalancer(ctx *logrus.Entry, rc *RemoteController, clusterName string, admiralCache *AdmiralCache) (string, int, error) {
 
 	err := isServiceControllerInitialized(rc)
 	if err != nil {
@@ -981,39 +1100,78 @@ func getOverwrittenLoadBalancer(ctx *logrus.Entry, rc *RemoteController, cluster
 	})
 
 	endpoint, port := rc.ServiceController.Cache.GetSingleLoadBalancer(common.GetAdmiralParams().LabelSet.GatewayApp, common.NamespaceIstioSystem)
+	if eastWestEndpoint, eastWestPort, ok := getEastWestGateway(rc, admiralCache); ok {
+		endpoint, port = eastWestEndpoint, eastWestPort
+	}
 
 	ctx = ctx.WithFields(logrus.Fields{
 		"RegularLB": endpoint,
 		"Port":      port,
 	})
 
-	//Overwrite for NLB
-	if slices.Contains(admiralCache.NLBEnabledCluster, clusterName) {
-		overwriteEndpoint, overwritePort := rc.ServiceController.Cache.GetSingleLoadBalancer(common.GetAdmiralParams().NLBIngressLabel, common.NamespaceIstioSystem)
+	switch getPreferredLoadBalancerType(admiralCache, clusterName) {
+	case loadBalancerTypeNLB:
+		//Overwrite for NLB
+		overwriteLabel := common.GetAdmiralParams().NLBIngressLabel
+		overwriteEndpoint, overwritePort := rc.ServiceController.Cache.GetSingleLoadBalancer(overwriteLabel, common.NamespaceIstioSystem)
+		if !isValidLoadBalancer(overwriteEndpoint, overwritePort) {
+			if detectedLabel, ok := getIngressLabelForLoadBalancerType(rc, loadBalancerTypeNLB); ok {
+				overwriteLabel = detectedLabel
+				overwriteEndpoint, overwritePort = rc.ServiceController.Cache.GetSingleLoadBalancer(overwriteLabel, common.NamespaceIstioSystem)
+			}
+		}
 		ctx = ctx.WithFields(logrus.Fields{
 			"OverwritenLB":     overwriteEndpoint,
 			"Port":             overwritePort,
-			"OverwrittenLabel": common.GetAdmiralParams().NLBIngressLabel,
+			"OverwrittenLabel": overwriteLabel,
 		})
 
 		//Validate if provided LB information is not default dummy, If Dummy then coutinue default LB
-		if len(overwriteEndpoint) > 0 && overwritePort > 0 && overwriteEndpoint != common.DummyAdmiralGlobal {
+		if isValidLoadBalancer(overwriteEndpoint, overwritePort) {
+			endpoint, port = overwriteEndpoint, overwritePort
 			ctx = ctx.WithFields(logrus.Fields{
 				"Overwritten": true,
 			})
-			ctx.Info("")
-			return overwriteEndpoint, overwritePort, nil
+		} else {
+			ctx = ctx.WithFields(logrus.Fields{
+				"Overwritten": false,
+			})
 		}
-
+	case loadBalancerTypeCLB:
+		//Overwrite for CLB, the classic ELB of the ingress gateway is used instead of the east-west gateway or NLB
+		overwriteLabel := common.GetAdmiralParams().LabelSet.GatewayApp
+		if detectedLabel, ok := getIngressLabelForLoadBalancerType(rc, loadBalancerTypeCLB); ok {
+			overwriteLabel = detectedLabel
+		}
+		overwriteEndpoint, overwritePort := rc.ServiceController.Cache.GetSingleLoadBalancer(overwriteLabel, common.NamespaceIstioSystem)
 		ctx = ctx.WithFields(logrus.Fields{
-			"Overwritten": false,
+			"OverwritenLB":     overwriteEndpoint,
+			"Port":             overwritePort,
+			"OverwrittenLabel": overwriteLabel,
 		})
+		if isValidLoadBalancer(overwriteEndpoint, overwritePort) {
+			endpoint, port = overwriteEndpoint, overwritePort
+			ctx = ctx.WithFields(logrus.Fields{
+				"Overwritten": true,
+			})
+		} else {
+			ctx = ctx.WithFields(logrus.Fields{
+				"Overwritten": false,
+			})
+		}
 	}
 
+	if admiralCache != nil && admiralCache.LBMigrationCache != nil {
+		admiralCache.LBMigrationCache.Observe(clusterName, endpoint, common.GetLBMigrationSoakDuration())
+	}
 	ctx.Info("")
 	return endpoint, port, nil
 }
 
+func isValidLoadBalancer(endpoint string, port int) bool {
+	return len(endpoint) > 0 && port > 0 && endpoint != common.DummyAdmiralGlobal
+}
+
 func orderSourceClusters(ctx context.Context, rr *RemoteRegistry, services map[string]map[string]*k8sV1.Service) []string {
 	clusterKeySlice := make([]string, 0, len(services))
 	clusterKeys := make(map[string]string, len(services))
@@ -1214,6 +1372,12 @@ func updateGlobalGtpCacheAndGetGtpPreferenceRegion(remoteRegistry *RemoteRegistr
 	for _, gtpsInCluster := range gtps {
 		gtpsOrdered = append(gtpsOrdered, gtpsInCluster...)
 	}
+	if len(gtpsOrdered) > 1 {
+		//sort by creation time and priority, gtp with highest priority and most recent at the beginning
+		common.SortGtpsByPriorityAndCreationTime(gtpsOrdered, identity, env)
+	}
+	// the GTPs within their activation window go first, whatever their priority
+	gtpsOrdered = selectActiveGtps(gtpsOrdered, time.Now())
 	oldGTP, _ := remoteRegistry.AdmiralCache.GlobalTrafficCache.GetFromIdentity(identity, env)
 	if len(gtpsOrdered) == 0 {
 		ctxLogger.Debugf("No GTPs found for identity=%s in env=%s. Deleting global cache entries if any", identity, env)
@@ -1226,9 +1390,6 @@ func updateGlobalGtpCacheAndGetGtpPreferenceRegion(remoteRegistry *RemoteRegistr
 		}
 		remoteRegistry.AdmiralCache.GlobalTrafficCache.Delete(identity, env)
 		return "", nil
-	} else if len(gtpsOrdered) > 1 {
-		//sort by creation time and priority, gtp with highest priority and most recent at the beginning
-		common.SortGtpsByPriorityAndCreationTime(gtpsOrdered, identity, env)
 	}
 
 	mostRecentGtp := gtpsOrdered[0]
@@ -1382,12 +1543,20 @@ func modifySidecarForLocalClusterCommunication(
 
 func addUpdateSidecar(ctxLogger *logrus.Entry, ctx context.Context, obj *v1alpha3.Sidecar, exist *v1alpha3.Sidecar, namespace string, rc *RemoteController) {
 	var err error
+	stampTxId(ctx, obj)
 	exist.Labels = obj.Labels
 	exist.Annotations = obj.Annotations
 	exist.Spec = obj.Spec
 	if commonUtil.IsAdmiralReadOnly() {
 		return
 	}
+	sidecarCopy, existCopy := obj.DeepCopy(), exist.DeepCopy()
+	if queueWriteIfPaused(ctx, rc.ClusterID, common.SidecarResourceType, namespace, obj.Name, func(ctx context.Context, rc *RemoteController) error {
+		addUpdateSidecar(ctxLogger, ctx, sidecarCopy, existCopy, namespace, rc)
+		return nil
+	}) {
+		return
+	}
 	_, err = rc.SidecarController.IstioClient.NetworkingV1alpha3().Sidecars(namespace).Update(ctx, obj, v12.UpdateOptions{})
 	if err != nil {
 		err := retryUpdatingSidecar(ctxLogger, ctx, obj, exist, namespace, rc, err, "Update")
@@ -1398,28 +1567,30 @@ func addUpdateSidecar(ctxLogger *logrus.Entry, ctx context.Context, obj *v1alpha
 }
 
 func retryUpdatingSidecar(ctxLogger *logrus.Entry, ctx context.Context, obj *v1alpha3.Sidecar, exist *v1alpha3.Sidecar, namespace string, rc *RemoteController, err error, op string) error {
-	numRetries := 5
-	if err != nil && (k8sErrors.IsConflict(err) || k8sErrors.IsInvalid(err)) {
-		for i := 0; i < numRetries; i++ {
-			ctxLogger.Errorf(common.CtxLogFormat, op, obj.Name, obj.Namespace, rc.ClusterID, err.Error()+". retrying sidecar update.")
+	// an invalid sidecar is retried as well, as the update is made with the resource version of the existing one
+	isRetryable := func(err error) bool {
+		return common.IsRetryableWriteError(err) || k8sErrors.IsInvalid(err)
+	}
+	if err == nil || !isRetryable(err) {
+		return err
+	}
+	policy := common.GetWriteRetryPolicy()
+	policy.Retryable = isRetryable
+	return common.RetryAfterFirstAttempt(ctx, policy, err, func(int) error {
+		ctxLogger.Errorf(common.CtxLogFormat, op, obj.Name, obj.Namespace, rc.ClusterID, err.Error()+". retrying sidecar update.")
 
-			updatedSidecar, err := rc.SidecarController.IstioClient.NetworkingV1alpha3().Sidecars(namespace).Get(ctx, exist.Name, v12.GetOptions{})
-			// if old sidecar not found, just create a new sidecar instead
-			if err != nil {
-				continue
-			}
-			existingResourceVersion := updatedSidecar.GetResourceVersion()
-			updatedSidecar.Spec = obj.Spec
-			updatedSidecar.Annotations = obj.Annotations
-			updatedSidecar.Labels = obj.Labels
-			updatedSidecar.SetResourceVersion(existingResourceVersion)
-			_, err = rc.SidecarController.IstioClient.NetworkingV1alpha3().Sidecars(namespace).Update(ctx, updatedSidecar, v12.UpdateOptions{})
-			if err == nil {
-				return nil
-			}
+		updatedSidecar, getErr := rc.SidecarController.IstioClient.NetworkingV1alpha3().Sidecars(namespace).Get(ctx, exist.Name, v12.GetOptions{})
+		if getErr != nil {
+			return err
 		}
-	}
-	return err
+		existingResourceVersion := updatedSidecar.GetResourceVersion()
+		updatedSidecar.Spec = obj.Spec
+		updatedSidecar.Annotations = obj.Annotations
+		updatedSidecar.Labels = obj.Labels
+		updatedSidecar.SetResourceVersion(existingResourceVersion)
+		_, err = rc.SidecarController.IstioClient.NetworkingV1alpha3().Sidecars(namespace).Update(ctx, updatedSidecar, v12.UpdateOptions{})
+		return err
+	})
 }
 
 func copySidecar(sidecar *v1alpha3.Sidecar) *v1alpha3.Sidecar {
@@ -1474,7 +1645,7 @@ func AddServiceEntriesWithDrToAllCluster(ctxLogger *logrus.Entry, ctx context.Co
 		}
 
 		for w := 1; w <= common.DependentClusterWorkerConcurrency(); w++ {
-			go AddServiceEntriesWithDrWorker(ctxLogger, ctx, rr, isAdditionalEndpointsEnabled, isServiceEntryModifyCalledForSourceCluster,
+			go runAddServiceEntriesWithDrWorker(ctxLogger, ctx, rr, isAdditionalEndpointsEnabled, isServiceEntryModifyCalledForSourceCluster,
 				identityId, env, eventNamespace, copyServiceEntry(se), clusters, errors)
 		}
 
@@ -1523,6 +1694,12 @@ func AddServiceEntriesWithDrWorker(
 
 		if common.IsAdmiralOperatorMode() {
 			syncNamespace = common.GetOperatorSyncNamespace()
+		} else if clusterSyncNamespace := common.GetClusterSyncNamespace(cluster); clusterSyncNamespace != "" {
+			syncNamespace = clusterSyncNamespace
+		}
+		identitySyncNamespace := getIdentitySyncNamespace(cache, partitionedIdentity)
+		if identitySyncNamespace != "" {
+			syncNamespace = identitySyncNamespace
 		}
 
 		rc := rr.GetRemoteController(cluster)
@@ -1531,6 +1708,11 @@ func AddServiceEntriesWithDrWorker(
 			errors <- nil
 			continue
 		}
+		if envTier := common.GetEnvironmentTier(env); !isClusterTierAllowed(envTier, cluster) {
+			reportClusterTierViolation(ctxLogger, common.ServiceEntryResourceType, se.Hosts[0], envTier, cluster)
+			errors <- nil
+			continue
+		}
 		region, err := getClusterRegion(rr, cluster, rc)
 		if err != nil {
 			ctxLogger.Warnf(common.CtxLogFormat, "AddServiceEntriesWithDrWorker", "", "", cluster, "region not found for the cluster")
@@ -1581,6 +1763,38 @@ func AddServiceEntriesWithDrWorker(
 		var seDrSet, clientNamespaces = createSeAndDrSetFromGtp(ctxLogger, ctx, env, region, cluster, se,
 			globalTrafficPolicy, outlierDetection, clientConnectionSettings, cache, currentDR, doDRUpdateForInClusterVSRouting)
 		util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheCreateSeAndDrSetFromGtp", "", "", cluster, "", start)
+		if identitySyncNamespace != "" {
+			for _, seDr := range seDrSet {
+				seDr.ServiceEntry.ExportTo = sdk.AddNamespaceToExportTo(seDr.ServiceEntry.ExportTo, identitySyncNamespace)
+				seDr.DestinationRule.ExportTo = sdk.AddNamespaceToExportTo(seDr.DestinationRule.ExportTo, identitySyncNamespace)
+			}
+		}
+		if clientTLSSettings := getIdentityClientTLSSettings(cache, partitionedIdentity); clientTLSSettings != nil {
+			for _, seDr := range seDrSet {
+				seDr.DestinationRule.TrafficPolicy.Tls = clientTLSSettings.DeepCopy()
+			}
+		}
+		for _, seDr := range seDrSet {
+			warmupDuration := getIdentityWarmupDuration(cache, partitionedIdentity, env)
+			if loadBalancer := seDr.DestinationRule.GetTrafficPolicy().GetLoadBalancer(); warmupDuration != nil && loadBalancer != nil {
+				loadBalancer.WarmupDurationSecs = warmupDuration
+			}
+		}
+		if failoverPriority := getIdentityFailoverPriority(cache, globalTrafficPolicy, partitionedIdentity); failoverPriority != nil {
+			for _, seDr := range seDrSet {
+				sdk.ApplyFailoverPriority(seDr.DestinationRule, failoverPriority)
+			}
+		}
+		for _, seDr := range seDrSet {
+			sans := getFederatedSubjectAltNames(cache, cluster, seDr.ServiceEntry)
+			if len(sans) == 0 {
+				continue
+			}
+			seDr.ServiceEntry.SubjectAltNames = sans
+			if tls := seDr.DestinationRule.GetTrafficPolicy().GetTls(); tls != nil && tls.Mode == networking.ClientTLSSettings_ISTIO_MUTUAL {
+				tls.SubjectAltNames = sans
+			}
+		}
 
 		for _, seDr := range seDrSet {
 			var (
@@ -1605,6 +1819,11 @@ func AddServiceEntriesWithDrWorker(
 
 				}
 			}
+			// the SE cache is not namespaced, an SE found in a namespace the identity moved away
+			// from is deleted once the SE in the new namespace is written
+			if oldServiceEntry != nil && common.EnableIdentitySyncNamespace() && oldServiceEntry.Namespace != syncNamespace {
+				oldServiceEntry = nil
+			}
 
 			// check if the existing service entry was created outside of admiral
 			// if it was, then admiral will not take any action on this SE
@@ -1619,7 +1838,7 @@ func AddServiceEntriesWithDrWorker(
 				seDr.DestinationRule.DeepCopy(),
 				seDr.DrName,
 				cluster,
-				common.GetSyncNamespace())
+				syncNamespace)
 			util.LogElapsedTimeSinceTask(ctxLogger, "ReconcileDestinationRule", "", "", cluster, "", start)
 			if drReconciliationRequired {
 				oldDestinationRule, err = rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(syncNamespace).Get(ctx, seDr.DrName, v12.GetOptions{})
@@ -1638,8 +1857,8 @@ func AddServiceEntriesWithDrWorker(
 				skipDRUpdate = true
 			}
 
+			// the result of the cluster is sent once all its ServiceEntries are processed
 			if skipSEUpdate && skipDRUpdate {
-				errors <- nil
 				continue
 			}
 
@@ -1654,44 +1873,56 @@ func AddServiceEntriesWithDrWorker(
 			//clean service entry in case no endpoints are configured or if all the endpoints are invalid
 			if (len(seDr.ServiceEntry.Endpoints) == 0) || deleteOldServiceEntry {
 				if !skipSEUpdate {
-					start = time.Now()
-					err := deleteServiceEntry(ctx, oldServiceEntry, syncNamespace, rc) // [TODO] (needs fix): what happens if it was not able to get the old service entry even though it existed
-					util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteServiceEntry", "", "", cluster, "", start)
-					addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
-
-					if isServiceEntryModifyCalledForSourceCluster {
-						start = time.Now()
-						err = deleteWorkloadData(cluster, env, oldServiceEntry, rr, ctxLogger)
-						util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteWorkloadData", "", "", cluster, "", start)
-						if err != nil {
-							addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
-							ctxLogger.Errorf(LogErrFormat, "Delete", "dynamoDbWorkloadData", env+"."+identityId, cluster, err.Error())
+					// the DestinationRule, the VirtualServices and the cache entries of the service entry are
+					// removed along with it, once its grace period is over
+					cleanUp := func(ctx context.Context, rc *RemoteController) error {
+						var cleanupErr error
+						if isServiceEntryModifyCalledForSourceCluster {
+							start := time.Now()
+							err := deleteWorkloadData(cluster, env, oldServiceEntry, rr, ctxLogger)
+							util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteWorkloadData", "", "", cluster, "", start)
+							if err != nil {
+								cleanupErr = common.AppendError(cleanupErr, err)
+								ctxLogger.Errorf(LogErrFormat, "Delete", "dynamoDbWorkloadData", env+"."+identityId, cluster, err.Error())
+							}
+						} else {
+							ctxLogger.Infof(LogFormat, "Delete", "dynamoDbWorkloadData", env+"."+identityId, cluster, "skipped deleting workload data as this is not source cluster")
 						}
-					} else {
-						ctxLogger.Infof(LogFormat, "Delete", "dynamoDbWorkloadData", env+"."+identityId, cluster, "skipped deleting workload data as this is not source cluster")
-					}
 
-					start = time.Now()
-					cache.SeClusterCache.Delete(seDr.ServiceEntry.Hosts[0])
-					util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheSeClusterCache Delete", "", "", cluster, "", start)
+						start := time.Now()
+						cache.SeClusterCache.Delete(seDr.ServiceEntry.Hosts[0])
+						util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheSeClusterCache Delete", "", "", cluster, "", start)
 
-					// Delete additional endpoints if any
-					if isAdditionalEndpointsEnabled {
-						vsDNSPrefix := getDNSPrefixFromServiceEntry(seDr)
-						start = time.Now()
-						// if env contains -air suffix remove it else return original string
-						trimmedAirEnv := strings.TrimSuffix(env, common.AIREnvSuffix)
-						err = deleteAdditionalEndpoints(ctxLogger, ctx, rc, identityId, trimmedAirEnv, syncNamespace, vsDNSPrefix)
-						util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteWorkloadData", "", "", cluster, "", start)
-						if err != nil {
-							ctxLogger.Errorf(LogErrFormat, "Delete", "VirtualService", trimmedAirEnv+"."+identityId, cluster, err.Error())
-							addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
+						// Delete additional endpoints if any
+						if isAdditionalEndpointsEnabled {
+							vsDNSPrefix := getDNSPrefixFromServiceEntry(seDr)
+							start = time.Now()
+							// if env contains -air suffix remove it else return original string
+							trimmedAirEnv := strings.TrimSuffix(env, common.AIREnvSuffix)
+							err := deleteAdditionalEndpoints(ctxLogger, ctx, rc, identityId, trimmedAirEnv, syncNamespace, vsDNSPrefix)
+							util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteWorkloadData", "", "", cluster, "", start)
+							if err != nil {
+								ctxLogger.Errorf(LogErrFormat, "Delete", "VirtualService", trimmedAirEnv+"."+identityId, cluster, err.Error())
+								cleanupErr = common.AppendError(cleanupErr, err)
+							}
+						} else {
+							ctxLogger.Infof(LogFormat, "Delete", "VirtualService", env+"."+identityId, cluster, "skipped deleting additional endpoints through VirtualService in "+syncNamespace+" namespace")
 						}
-					} else {
-						ctxLogger.Infof(LogFormat, "Delete", "VirtualService", env+"."+identityId, cluster, "skipped deleting additional endpoints through VirtualService in "+syncNamespace+" namespace")
+
+						if !skipDRUpdate {
+							start = time.Now()
+							// after deleting the service entry, destination rule also need to be deleted if the service entry host no longer exists
+							err := deleteDestinationRule(ctx, oldDestinationRule, syncNamespace, rc)
+							util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteDestinationRule", "", "", cluster, "", start)
+							cleanupErr = common.AppendError(cleanupErr, err)
+						}
+						return cleanupErr
 					}
-				}
-				if !skipDRUpdate {
+					start = time.Now()
+					err := deleteServiceEntryAfterGracePeriod(ctx, oldServiceEntry, syncNamespace, rc, cleanUp) // [TODO] (needs fix): what happens if it was not able to get the old service entry even though it existed
+					util.LogElapsedTimeSinceTask(ctxLogger, "AdmiralCacheDeleteServiceEntry", "", "", cluster, "", start)
+					addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
+				} else if !skipDRUpdate {
 					start = time.Now()
 					// after deleting the service entry, destination rule also need to be deleted if the service entry host no longer exists
 					err = deleteDestinationRule(ctx, oldDestinationRule, syncNamespace, rc)
@@ -1719,6 +1950,16 @@ func AddServiceEntriesWithDrWorker(
 							newServiceEntry.Annotations[serviceEntryAssociatedGtpAnnotationLabel] = seDr.SeDrGlobalTrafficPolicyName
 							compareAnnotations = append(compareAnnotations, serviceEntryAssociatedGtpAnnotationLabel)
 						}
+						if seDr.SeDrTrafficPolicySource != "" {
+							newServiceEntry.Annotations[common.TrafficPolicySourceAnnotation] = seDr.SeDrTrafficPolicySource
+						}
+						compareAnnotations = append(compareAnnotations, common.TrafficPolicySourceAnnotation)
+						if dependentNamespaceCount, capped := getExportToCappedNamespaceCount(cache, se.Hosts[0], cluster); capped {
+							newServiceEntry.Annotations[exportToCappedAnnotationLabel] = strconv.Itoa(dependentNamespaceCount)
+						}
+						compareAnnotations = append(compareAnnotations, exportToCappedAnnotationLabel)
+						// a ServiceEntry marked for deletion is written again to unmark it, as its workload is back
+						compareAnnotations = append(compareAnnotations, common.AdmiralDeleteAfterAnnotation)
 
 						start = time.Now()
 						seReconciliationRequired := reconcileServiceEntry(
@@ -1825,6 +2066,26 @@ func AddServiceEntriesWithDrWorker(
 				}
 			}
 		}
+		// the resources in the namespaces the identity moved away from are only deleted
+		// after they were written to the new namespace
+		if addSEorDRToAClusterError == nil && common.EnableIdentitySyncNamespace() {
+			for _, seDr := range seDrSet {
+				staleSyncNamespaces, err := getPreviousServiceEntrySyncNamespaces(ctx, rc, seDr.SeName, syncNamespace)
+				if err != nil {
+					addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
+					continue
+				}
+				err = deleteStaleIdentitySyncNamespaceResources(ctxLogger, ctx, rc, seDr, staleSyncNamespaces)
+				addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
+				if !isAdditionalEndpointsEnabled {
+					continue
+				}
+				for _, staleSyncNamespace := range staleSyncNamespaces {
+					err = deleteAdditionalEndpoints(ctxLogger, ctx, rc, identityId, strings.TrimSuffix(env, common.AIREnvSuffix), staleSyncNamespace, getDNSPrefixFromServiceEntry(seDr))
+					addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, err)
+				}
+			}
+		}
 		if addSEorDRToAClusterError != nil {
 			addSEorDRToAClusterError = common.AppendError(addSEorDRToAClusterError, fmt.Errorf("%s=%s", errorCluster, cluster))
 		} else {
@@ -1971,7 +2232,7 @@ func hasInClusterVSWithValidExportToNS(se *networking.ServiceEntry, rc *RemoteCo
 		return false, fmt.Errorf("virtualService %s not found in cache", vsName)
 	}
 	for _, ns := range cachedVS.Spec.ExportTo {
-		if ns == common.GetSyncNamespace() {
+		if ns == common.GetSyncNamespaceForCluster(rc.ClusterID) {
 			return false, nil
 		}
 	}
@@ -2375,7 +2636,7 @@ func createAdditionalEndpoints(
 
 	defaultVSName := getIstioResourceName(virtualServiceHostnames[0], "-vs")
 
-	existingVS, err := getExistingVS(ctxLogger, ctx, rc, defaultVSName, common.GetSyncNamespace())
+	existingVS, err := getExistingVS(ctxLogger, ctx, rc, defaultVSName, namespace)
 	if err != nil {
 		ctxLogger.Warn(err.Error())
 	}
@@ -2490,7 +2751,7 @@ func createSeAndDrSetFromGtp(ctxLogger *logrus.Entry, ctx context.Context, env,
 	}
 
 	// This is calculated elsewhere for Operator
-	if !common.IsAdmiralOperatorMode() && common.EnableExportTo(se.Hosts[0]) && se != nil {
+	if !common.IsAdmiralOperatorMode() && common.EnableExportToForCluster(se.Hosts[0], cluster) && se != nil {
 		sortedDependentNamespaces := getSortedDependentNamespaces(cache, se.Hosts[0], cluster, ctxLogger, false)
 		se.ExportTo = sortedDependentNamespaces
 	}
@@ -2532,6 +2793,10 @@ func createSeAndDrSetFromGtp(ctxLogger *logrus.Entry, ctx context.Context, env,
 				ServiceEntry:                modifiedSe,
 				SeDnsPrefix:                 gtpTrafficPolicy.DnsPrefix,
 				SeDrGlobalTrafficPolicyName: globalTrafficPolicy.Name,
+				SeDrTrafficPolicySource:     globalTrafficPolicy.Annotations[common.TrafficPolicySourceAnnotation],
+			}
+			if common.EnableGTPWeightedEndpoints() {
+				seDr.ServiceEntry = weightEndpointsByRegion(modifiedSe, getRegionWeights(gtpTrafficPolicy))
 			}
 			if strings.HasPrefix(se.Hosts[0], common.CanaryRolloutCanaryPrefix) && len(seDr.SeDnsPrefix) > 0 {
 				if seDr.SeDnsPrefix != common.Default {
@@ -2617,7 +2882,7 @@ func GetServiceEntriesByCluster(ctxLogger *logrus.Entry, ctx context.Context, cl
 	remoteController := remoteRegistry.GetRemoteController(clusterID)
 
 	if remoteController != nil {
-		serviceEntries, err := remoteController.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(common.GetSyncNamespace()).List(ctx, v12.ListOptions{})
+		serviceEntries, err := remoteController.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(common.GetSyncNamespaceForCluster(clusterID)).List(ctx, v12.ListOptions{})
 
 		if err != nil {
 			ctxLogger.Errorf(LogFormat, "Get", "ServiceEntries", "", clusterID, err)
@@ -2734,8 +2999,13 @@ func createServiceEntryForRollout(ctxLogger *logrus.Entry, ctx context.Context,
 	}
 
 	san := getSanForRollout(destRollout, workloadIdentityKey)
+	if spiffeID := getWorkloadSpiffeID(rc.ClusterID, &destRollout.Spec.Template); len(san) > 0 && spiffeID != "" {
+		san = []string{spiffeID}
+	}
 
-	if destRollout.Spec.Strategy.BlueGreen != nil && destRollout.Spec.Strategy.BlueGreen.PreviewService != "" {
+	// the preview host is not generated once the preview replica set is promoted, it is deleted
+	// by deleteBlueGreenPreviewResources when every cluster promoted it
+	if destRollout.Spec.Strategy.BlueGreen != nil && destRollout.Spec.Strategy.BlueGreen.PreviewService != "" && !isBlueGreenPreviewPromoted(destRollout) {
 		ctxLogger.Infof(common.CtxLogFormat,
 			"createServiceEntryForRollout", destRollout.Name, destRollout.Namespace, "", "Building ServiceEntry for BlueGreen")
 		rolloutServices := getServiceForRollout(ctx, rc, destRollout)
@@ -2778,6 +3048,20 @@ func getSanForDeployment(destDeployment *k8sAppsV1.Deployment, workloadIdentityK
 
 }
 
+// getWorkloadSpiffeID returns the SPIFFE ID of the workload when its identity is resolved from it in the cluster,
+// the workloads of the other clusters are verified with their workload identifier san
+func getWorkloadSpiffeID(cluster string, template *k8sV1.PodTemplateSpec) string {
+	if !common.IsSpiffeIdentityEnabledForCluster(cluster) {
+		return ""
+	}
+	spiffeID := template.Annotations[common.SpiffeIdAnnotation]
+	identity := common.GetIdentityFromSpiffeID(spiffeID)
+	if identity == "" || identity != template.Labels[common.GetWorkloadIdentifier()] {
+		return ""
+	}
+	return spiffeID
+}
+
 func getSanForRollout(destRollout *argo.Rollout, workloadIdentityKey string) (san []string) {
 	if common.GetEnableSAN() {
 		tmpSan := common.GetSANForRollout(common.GetSANPrefix(), destRollout, workloadIdentityKey)
@@ -2789,39 +3073,48 @@ func getSanForRollout(destRollout *argo.Rollout, workloadIdentityKey string) (sa
 
 }
 
+// uniqueAddressRetryPolicy retries getting the address of a service entry, which conflicts with the
+// other admiral writes to the address configmap, with a random exponential backoff
+var uniqueAddressRetryPolicy = common.RetryPolicy{
+	Attempts:       3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Jitter:         1,
+}
+
+// errNoUniqueAddress is returned by an attempt to get the address of a service entry which got no address
+var errNoUniqueAddress = errors.New("no unique address")
+
 func getUniqueAddress(ctxLogger *logrus.Entry, ctx context.Context, admiralCache *AdmiralCache, globalFqdn string) (string, error) {
 	start := time.Now()
 	defer util.LogElapsedTimeSinceTask(ctxLogger, "GetUniqueAddress",
 		"", "", "", "", start)
 	//initializations
 	var err error = nil
-	maxRetries := 3
-	counter := 0
 	address := ""
 	needsCacheUpdate := false
 
-	for counter < maxRetries {
+	retryErr := common.RetryWithBackoff(ctx, uniqueAddressRetryPolicy, func(int) error {
 		address, needsCacheUpdate, err = GetLocalAddressForSe(ctxLogger, ctx, getIstioResourceName(globalFqdn, "-se"), admiralCache.ServiceEntryAddressStore, admiralCache.ConfigMapController)
 
 		if len(address) > 0 {
-			break
+			return nil
 		}
-		if len(address) == 0 && err == nil && common.DisableIPGeneration() {
-			break
+		if err == nil && common.DisableIPGeneration() {
+			return nil
 		}
 		if err != nil {
 			ctxLogger.Errorf("error getting local address for service entry. err: %v", err)
+			return err
 		}
-
-		//random expo backoff
-		timeToBackoff := rand.Intn(int(math.Pow(100.0, float64(counter)))) //get a random number between 0 and 100^counter. Will always be 0 the first time, will be 0-100 the second, and 0-1000 the third
-		time.Sleep(time.Duration(timeToBackoff) * time.Millisecond)
-
-		counter++
+		return errNoUniqueAddress
+	})
+	if retryErr != nil && retryErr != errNoUniqueAddress {
+		err = retryErr
 	}
 
 	if err != nil {
-		return address, fmt.Errorf("could not get unique address after %v retries. Failing to create serviceentry name=%v", maxRetries, globalFqdn)
+		return address, fmt.Errorf("could not get unique address after %v retries. Failing to create serviceentry name=%v", uniqueAddressRetryPolicy.Attempts, globalFqdn)
 	}
 
 	if needsCacheUpdate {
@@ -2849,15 +3142,7 @@ func generateServiceEntry(
 	defer util.LogElapsedTimeSinceTask(ctxLogger, "GenerateServiceEntry", "", "", rc.ClusterID, "", start)
 	tmpSe := serviceEntries[globalFqdn]
 
-	var finalProtocol = commonUtil.Http
-	var sePorts = []*networking.ServicePort{{Number: uint32(common.DefaultServiceEntryPort),
-		Name: finalProtocol, Protocol: finalProtocol}}
-
-	for protocol := range meshPorts {
-		sePorts = []*networking.ServicePort{{Number: uint32(common.DefaultServiceEntryPort),
-			Name: protocol, Protocol: protocol}}
-		finalProtocol = protocol
-	}
+	var sePorts = getServiceEntryPorts(meshPorts)
 
 	if tmpSe == nil {
 		tmpSe = &networking.ServiceEntry{
@@ -2883,28 +3168,40 @@ func generateServiceEntry(
 	}
 
 	seEndpoint := makeRemoteEndpointForServiceEntry(endpointAddress,
-		locality, finalProtocol, port, appType)
+		locality, sePorts[0].Name, port, appType)
+	// the cross cluster traffic of all the ports goes through the ingress gateway port
+	for _, sePort := range sePorts[1:] {
+		seEndpoint.Ports[sePort.Name] = uint32(port)
+	}
+	// one endpoint per load balancer of the cluster ingress
+	seEndpoints := getServiceEntryRemoteEndpoints(rc, seEndpoint)
+
+	migratingFrom := getLBMigratingFrom(admiralCache, rc.ClusterID)
 
 	// if the action is deleting an endpoint from service entry, loop through the list and delete matching ones
 
 	if event == admiral.Add || event == admiral.Update {
-		match := false
-		for _, ep := range tmpSe.Endpoints {
-			if ep.Address == seEndpoint.Address {
-				match = true
+		for _, endpoint := range seEndpoints {
+			match := false
+			for _, ep := range tmpSe.Endpoints {
+				if ep.Address == endpoint.Address {
+					match = true
+				}
 			}
-		}
 
-		if !match {
-			tmpSe.Endpoints = append(tmpSe.Endpoints, seEndpoint)
+			if !match {
+				tmpSe.Endpoints = append(tmpSe.Endpoints, endpoint)
+			}
 		}
+		// keep the old load balancer of the cluster until its migration to the new one completes
+		addLBMigrationEndpoint(tmpSe, seEndpoint, migratingFrom)
 	} else if event == admiral.Delete {
 		// create a tmp endpoint list to store all the endpoints that we intend to keep
 		remainEndpoints := []*networking.WorkloadEntry{}
 
 		// if the endpoint is not equal to the endpoint we intend to delete, append it to remainEndpoint list
 		for _, existingEndpoint := range tmpSe.Endpoints {
-			if !reflect.DeepEqual(existingEndpoint, seEndpoint) {
+			if !containsEndpoint(seEndpoints, existingEndpoint) && (migratingFrom == "" || existingEndpoint.Address != migratingFrom) {
 				remainEndpoints = append(remainEndpoints, existingEndpoint)
 			}
 		}
@@ -3016,7 +3313,7 @@ func reconcileDestinationRule(
 
 func getCurrentDRForLocalityLbSetting(rr *RemoteRegistry, isServiceEntryModifyCalledForSourceCluster bool, cluster string, se *networking.ServiceEntry, identityId string) *v1alpha3.DestinationRule {
 	var (
-		syncNamespace = common.GetSyncNamespace()
+		syncNamespace = common.GetSyncNamespaceForCluster(cluster)
 		cache         = rr.AdmiralCache
 		rc            = rr.GetRemoteController(cluster)
 		currentDR     *v1alpha3.DestinationRule
@@ -3041,7 +3338,7 @@ func getCurrentDRForLocalityLbSetting(rr *RemoteRegistry, isServiceEntryModifyCa
 		for _, clusterID := range sourceClusters {
 			sourceRC := rr.GetRemoteController(clusterID)
 			if sourceRC != nil {
-				currentDR = sourceRC.DestinationRuleController.Cache.Get(getIstioResourceName(se.Hosts[0], "-default-dr"), syncNamespace)
+				currentDR = sourceRC.DestinationRuleController.Cache.Get(getIstioResourceName(se.Hosts[0], "-default-dr"), common.GetSyncNamespaceForCluster(clusterID))
 				// When we have found another cluster where the DR exists we will break from the loop
 				if currentDR != nil {
 					break
@@ -3125,11 +3422,28 @@ func updateCnameDependentClusterNamespaceCache(
 		deploymentOrRolloutName, deploymentOrRolloutNS, "", "total dependent clusters="+strconv.Itoa(dependentClusterCounter))
 }
 
-func getServiceEntryPort(meshPorts map[string]uint32) *networking.ServicePort {
-	var sePorts *networking.ServicePort
-	for protocol := range meshPorts {
-		sePorts = &networking.ServicePort{Number: uint32(common.DefaultServiceEntryPort),
-			Name: protocol, Protocol: protocol}
+// getServiceEntryPorts returns the ports of the service entry generated for the mesh ports. The first mesh port
+// is served on the default service entry port and the others on their service port number, so that the ports
+// are unique and the SNI of the cross cluster traffic of each of them is routed by the ingress gateway
+func getServiceEntryPorts(meshPorts map[string]uint32) []*networking.ServicePort {
+	var sePorts []*networking.ServicePort
+	for name, number := range meshPorts {
+		if common.IsAdditionalMeshPort(name, number) {
+			sePorts = append(sePorts, &networking.ServicePort{Number: number, Name: name, Protocol: commonUtil.GetPortProtocol(name)})
+			continue
+		}
+		sePorts = append(sePorts, &networking.ServicePort{Number: uint32(common.DefaultServiceEntryPort), Name: name, Protocol: name})
+	}
+	if len(sePorts) == 0 {
+		return []*networking.ServicePort{{Number: uint32(common.DefaultServiceEntryPort),
+			Name: commonUtil.Http, Protocol: commonUtil.Http}}
 	}
+	sort.Slice(sePorts, func(i, j int) bool {
+		iAdditional, jAdditional := common.IsAdditionalMeshPort(sePorts[i].Name, sePorts[i].Number), common.IsAdditionalMeshPort(sePorts[j].Name, sePorts[j].Number)
+		if iAdditional != jAdditional {
+			return jAdditional
+		}
+		return sePorts[i].Number < sePorts[j].Number
+	})
 	return sePorts
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	log "github.com/sirupsen/logrus"
 	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
@@ -45,14 +46,41 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 		skipUpdate      bool
 		seAlreadyExists bool
 	)
+	seCopy, existCopy := obj.DeepCopy(), exist.DeepCopy()
+	if queueWriteIfPaused(ctx, rc.ClusterID, common.ServiceEntryResourceType, namespace, obj.Name, func(ctx context.Context, rc *RemoteController) error {
+		return addUpdateServiceEntry(ctxLogger, ctx, seCopy, existCopy, namespace, rc)
+	}) {
+		return nil
+	}
 	ctxLogger.Infof(common.CtxLogFormat, "AddUpdateServiceEntry", "", "", rc.ClusterID, "Creating/Updating ServiceEntry="+obj.Name)
 	if obj.Annotations == nil {
 		obj.Annotations = map[string]string{}
 	}
 	obj.Annotations["app.kubernetes.io/created-by"] = "admiral"
+	stampTxId(ctx, obj)
+	stampOwnerLabels(ctx, obj)
+	stampSyncNamespaceLabel(obj, rc.ClusterID, namespace)
+	stampCoexistenceAnnotations(obj)
 
 	areEndpointsValid := validateAndProcessServiceEntryEndpoints(obj)
 
+	if err = evaluateResourcePolicy(ctxLogger, ctx, common.ServiceEntryResourceType, rc.ClusterID, obj); err != nil {
+		return err
+	}
+	if err = validateGeneratedResource(ctxLogger, ctx, common.ServiceEntryResourceType, rc, obj); err != nil {
+		return err
+	}
+	if common.IsGitOpsOutputMode() {
+		return renderGitOpsResource(ctxLogger, rc.ClusterID, common.ServiceEntryResourceType, namespace, obj)
+	}
+	if common.IsDiffOnlyMode() {
+		var existState *resourceState
+		if exist != nil && exist.Spec.Hosts != nil {
+			existState = newResourceState(exist, &exist.Spec)
+		}
+		return recordResourceDiff(ctx, rc.ClusterID, common.ServiceEntryResourceType, namespace, obj.Name, existState, newResourceState(obj, &obj.Spec))
+	}
+
 	seIsNew := exist == nil || exist.Spec.Hosts == nil
 	if seIsNew {
 		op = "Add"
@@ -66,6 +94,11 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 				ctxLogger.Infof(common.CtxLogFormat, "addUpdateServiceEntry", obj.Name, obj.Namespace, rc.ClusterID, "object already exists. Will update instead")
 				seAlreadyExists = true
 			} else {
+				auditMutation(ctx, audit.OperationCreate, common.ServiceEntryResourceType, rc.ClusterID, obj, namespace, "", err)
+				if err == nil {
+					recordWrittenSpec(rc.ClusterID, common.ServiceEntryResourceType, namespace, obj.Name, &obj.Spec)
+					recordConfigHistory(ctx, rc.ClusterID, common.ServiceEntryResourceType, obj)
+				}
 				return err
 			}
 			ctxLogger.Infof(common.CtxLogFormat, "Add", " SE=%s", op, "ServiceEntry", obj.Name, rc.ClusterID, "New SE", obj.Spec.String())
@@ -74,6 +107,8 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 		}
 	}
 	if !seIsNew || seAlreadyExists {
+		// the changes are sent as a merge patch when enabled, unless the existing service entry could not be fetched
+		patch := common.EnableMergePatchUpdates()
 		if seAlreadyExists {
 			exist, err = rc.ServiceEntryController.IstioClient.
 				NetworkingV1alpha3().
@@ -81,6 +116,7 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 				Get(ctx, obj.Name, metav1.GetOptions{})
 			if err != nil {
 				exist = obj
+				patch = false
 				// when there is an error, assign exist to obj,
 				// which will fail in the update operation, but will be retried
 				// in the retry logic
@@ -89,6 +125,8 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 		}
 		op = "Update"
 		if areEndpointsValid { //update will happen only when all the endpoints are valid // TODO: why not have this check when
+			detectExternalRevert(ctxLogger, rc.ClusterID, common.ServiceEntryResourceType, exist, &exist.Spec, &obj.Spec)
+			original := exist.DeepCopy()
 			exist.Labels = obj.Labels
 			exist.Annotations = obj.Annotations
 			skipUpdate, diff = skipDestructiveUpdate(rc, obj, exist)
@@ -100,10 +138,15 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 			} else {
 				//nolint
 				exist.Spec = obj.Spec
-				_, err = rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Update(ctx, exist, metav1.UpdateOptions{})
+				if patch {
+					err = patchServiceEntry(ctx, rc, namespace, original, exist)
+				} else {
+					_, err = rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Update(ctx, exist, metav1.UpdateOptions{})
+				}
 				if err != nil {
 					err = retryUpdatingSE(ctxLogger, ctx, obj, exist, namespace, rc, err, op)
 				}
+				auditMutation(ctx, audit.OperationUpdate, common.ServiceEntryResourceType, rc.ClusterID, obj, namespace, diff, err)
 			}
 		} else {
 			ctxLogger.Infof(LogFormat, op, "ServiceEntry", obj.Name, rc.ClusterID, "SE could not be updated as all the recived endpoints are not valid.")
@@ -115,35 +158,36 @@ func addUpdateServiceEntry(ctxLogger *log.Entry, ctx context.Context,
 		ctxLogger.Errorf(LogErrFormat, op, "ServiceEntry", obj.Name, rc.ClusterID, err)
 		return err
 	} else {
+		recordWrittenSpec(rc.ClusterID, common.ServiceEntryResourceType, namespace, obj.Name, &obj.Spec)
+		recordConfigHistory(ctx, rc.ClusterID, common.ServiceEntryResourceType, obj)
+		if _, marked := obj.Annotations[common.AdmiralDeleteAfterAnnotation]; !marked {
+			unmarkServiceEntry(rc.ClusterID, namespace, obj.Name)
+		}
 		ctxLogger.Infof(LogFormat, op, "ServiceEntry", obj.Name, rc.ClusterID, "Success")
 	}
 	return nil
 }
 
 func retryUpdatingSE(ctxLogger *log.Entry, ctx context.Context, obj *v1alpha3.ServiceEntry, exist *v1alpha3.ServiceEntry, namespace string, rc *RemoteController, err error, op string) error {
-	numRetries := 5
-	if err != nil && k8sErrors.IsConflict(err) {
-		for i := 0; i < numRetries; i++ {
-			ctxLogger.Errorf(common.CtxLogFormat, op, obj.Name, obj.Namespace, rc.ClusterID, err.Error()+". will retry the update operation before adding back to the controller queue.")
-
-			updatedServiceEntry, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(common.GetSyncNamespace()).Get(ctx, exist.Name, metav1.GetOptions{})
-			// if old service entry not find, just create a new service entry instead
-			if err != nil {
-				ctxLogger.Infof(common.CtxLogFormat, op, exist.Name, exist.Namespace, rc.ClusterID, err.Error()+fmt.Sprintf(". Error getting old serviceEntry"))
-				continue
-			}
+	if err == nil || !common.IsRetryableWriteError(err) {
+		return err
+	}
+	return common.RetryAfterFirstAttempt(ctx, common.GetWriteRetryPolicy(), err, func(int) error {
+		ctxLogger.Errorf(common.CtxLogFormat, op, obj.Name, obj.Namespace, rc.ClusterID, err.Error()+". will retry the update operation before adding back to the controller queue.")
 
-			ctxLogger.Infof(common.CtxLogFormat, op, obj.Name, obj.Namespace, rc.ClusterID, fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s", updatedServiceEntry.ResourceVersion, obj.ResourceVersion))
-			updatedServiceEntry.Spec = obj.Spec
-			updatedServiceEntry.Annotations = obj.Annotations
-			updatedServiceEntry.Labels = obj.Labels
-			_, err = rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Update(ctx, updatedServiceEntry, metav1.UpdateOptions{})
-			if err == nil {
-				return nil
-			}
+		updatedServiceEntry, getErr := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Get(ctx, exist.Name, metav1.GetOptions{})
+		if getErr != nil {
+			ctxLogger.Infof(common.CtxLogFormat, op, exist.Name, exist.Namespace, rc.ClusterID, getErr.Error()+". Error getting old serviceEntry")
+			return err
 		}
-	}
-	return err
+
+		ctxLogger.Infof(common.CtxLogFormat, op, obj.Name, obj.Namespace, rc.ClusterID, fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s", updatedServiceEntry.ResourceVersion, obj.ResourceVersion))
+		updatedServiceEntry.Spec = obj.Spec
+		updatedServiceEntry.Annotations = obj.Annotations
+		updatedServiceEntry.Labels = obj.Labels
+		_, err = rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Update(ctx, updatedServiceEntry, metav1.UpdateOptions{})
+		return err
+	})
 }
 
 func skipDestructiveUpdate(rc *RemoteController, new *v1alpha3.ServiceEntry, old *v1alpha3.ServiceEntry) (bool, string) {
@@ -225,7 +269,24 @@ func getServiceEntryDiff(new *v1alpha3.ServiceEntry, old *v1alpha3.ServiceEntry)
 
 func deleteServiceEntry(ctx context.Context, serviceEntry *v1alpha3.ServiceEntry, namespace string, rc *RemoteController) error {
 	if serviceEntry != nil {
+		seCopy := serviceEntry.DeepCopy()
+		if queueWriteIfPaused(ctx, rc.ClusterID, common.ServiceEntryResourceType, namespace, serviceEntry.Name, func(ctx context.Context, rc *RemoteController) error {
+			return deleteServiceEntry(ctx, seCopy, namespace, rc)
+		}) {
+			return nil
+		}
+		if common.IsGitOpsOutputMode() {
+			return removeGitOpsResource(rc.ClusterID, common.ServiceEntryResourceType, namespace, serviceEntry.Name)
+		}
+		if common.IsDiffOnlyMode() {
+			return recordResourceDiff(ctx, rc.ClusterID, common.ServiceEntryResourceType, namespace, serviceEntry.Name, newResourceState(serviceEntry, &serviceEntry.Spec), nil)
+		}
+		forgetWrittenSpec(rc.ClusterID, common.ServiceEntryResourceType, namespace, serviceEntry.Name)
+		forgetConfigHistory(ctx, rc.ClusterID, common.ServiceEntryResourceType, namespace, serviceEntry.Name)
 		err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).Delete(ctx, serviceEntry.Name, metav1.DeleteOptions{})
+		if !k8sErrors.IsNotFound(err) {
+			auditMutation(ctx, audit.OperationDelete, common.ServiceEntryResourceType, rc.ClusterID, serviceEntry, namespace, "", err)
+		}
 		if err != nil {
 			if k8sErrors.IsNotFound(err) {
 				log.Infof(LogFormat, "Delete", "ServiceEntry", serviceEntry.Name, rc.ClusterID, "Either ServiceEntry was already deleted, or it never existed")
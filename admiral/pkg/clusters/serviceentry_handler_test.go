@@ -21,6 +21,8 @@ import (
 	v1alpha32 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestSkipDestructiveUpdate(t *testing.T) {
@@ -652,3 +654,48 @@ func TestRetryUpdatingSE(t *testing.T) {
 		t.Error("Expected non-nil error, got nil")
 	}
 }
+
+func TestRetryUpdatingSECountsTheFirstWrite(t *testing.T) {
+	ctx := context.Background()
+	ctxLogger := log.WithField("test", t.Name())
+	exist := &v1alpha32.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "ns"},
+		Spec:       v1alpha3.ServiceEntry{Hosts: []string{"stage.foo.global"}},
+	}
+	errConflict := k8sErrors.NewConflict(schema.GroupResource{}, "", nil)
+
+	testCases := []struct {
+		name            string
+		attempts        int
+		expectedUpdates int
+	}{
+		{
+			name:            "Given a single write attempt, When the first write conflicted, Then the serviceentry is not written again",
+			attempts:        1,
+			expectedUpdates: 0,
+		},
+		{
+			name:            "Given 3 write attempts, When the writes always conflict, Then the serviceentry is written twice after the first write",
+			attempts:        3,
+			expectedUpdates: 2,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, SyncNamespace: "ns", WriteRetryAttempts: c.attempts})
+			client := istioFake.NewSimpleClientset(exist)
+			updates := 0
+			client.PrependReactor("update", "serviceentries", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				return true, nil, errConflict
+			})
+			rc := &RemoteController{ServiceEntryController: &istio.ServiceEntryController{IstioClient: client}}
+
+			err := retryUpdatingSE(ctxLogger, ctx, exist, exist, "ns", rc, errConflict, "Update")
+			assert.True(t, k8sErrors.IsConflict(err))
+			assert.Equal(t, c.expectedUpdates, updates)
+		})
+	}
+}
@@ -1607,7 +1607,7 @@ func TestAddServiceEntriesWithDr(t *testing.T) {
 			identity:                     "bar",
 			env:                          "dev",
 			virtualServiceAssertion:      virtualServiceAssertion,
-			expectedVSLabels:             map[string]string{common.GetEnvKey(): "dev", dnsPrefixAnnotationLabel: "default"},
+			expectedVSLabels:             map[string]string{common.GetEnvKey(): "dev", dnsPrefixAnnotationLabel: "default", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 			expectedLabels:               map[string]string{"env": "dev"},
 			isAdditionalEndpointsEnabled: true,
 			sourceClusters:               map[string]string{"cl1": "cl1"},
@@ -1627,7 +1627,7 @@ func TestAddServiceEntriesWithDr(t *testing.T) {
 			expectedVSAnnotations:        map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue, common.GetWorkloadIdentifier(): "bar"},
 			virtualServiceAssertion:      virtualServiceAssertion,
 			expectedLabels:               map[string]string{"env": "e2e"},
-			expectedVSLabels:             map[string]string{common.GetEnvKey(): "e2e", dnsPrefixAnnotationLabel: "prefix"},
+			expectedVSLabels:             map[string]string{common.GetEnvKey(): "e2e", dnsPrefixAnnotationLabel: "prefix", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 			isAdditionalEndpointsEnabled: true,
 			sourceClusters:               map[string]string{"cl1": "cl1"},
 		},
@@ -1646,7 +1646,7 @@ func TestAddServiceEntriesWithDr(t *testing.T) {
 			expectedVSAnnotations:        map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue, common.GetWorkloadIdentifier(): "bar1"},
 			virtualServiceAssertion:      virtualServiceAssertion,
 			expectedLabels:               map[string]string{"env": "e2e"},
-			expectedVSLabels:             map[string]string{common.GetEnvKey(): "e2e", dnsPrefixAnnotationLabel: "canary"},
+			expectedVSLabels:             map[string]string{common.GetEnvKey(): "e2e", dnsPrefixAnnotationLabel: "canary", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 			isAdditionalEndpointsEnabled: true,
 			sourceClusters:               map[string]string{"cl1": "cl1"},
 		},
@@ -1665,7 +1665,7 @@ func TestAddServiceEntriesWithDr(t *testing.T) {
 			expectedVSAnnotations:        map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue, common.GetWorkloadIdentifier(): "bar"},
 			virtualServiceAssertion:      virtualServiceAssertion,
 			expectedLabels:               map[string]string{"env": "e2e"},
-			expectedVSLabels:             map[string]string{common.GetEnvKey(): "e2e", dnsPrefixAnnotationLabel: "prefix.canary"},
+			expectedVSLabels:             map[string]string{common.GetEnvKey(): "e2e", dnsPrefixAnnotationLabel: "prefix.canary", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 			isAdditionalEndpointsEnabled: true,
 			sourceClusters:               map[string]string{"cl1": "cl1"},
 		},
@@ -4230,6 +4230,14 @@ func TestUpdateGlobalGtpCacheAndGetGtpPreferenceRegion(t *testing.T) {
 		gtp7 = &v13.GlobalTrafficPolicy{ObjectMeta: metav1.ObjectMeta{Name: "gtp7", Namespace: "namespace1", CreationTimestamp: metav1.NewTime(time.Now().Add(time.Duration(-45))), Labels: map[string]string{"identity": identity1, "env": envStage, "priority": "2"}}, Spec: model.GlobalTrafficPolicy{
 			Policy: []*model.TrafficPolicy{{DnsPrefix: "hellogtp7"}},
 		}}
+		gtpExpired = &v13.GlobalTrafficPolicy{ObjectMeta: metav1.ObjectMeta{Name: "gtpExpired", Namespace: "namespace1", CreationTimestamp: metav1.NewTime(time.Now()), Labels: map[string]string{"identity": identity1, "env": envStage, "priority": "2000"},
+			Annotations: map[string]string{common.GtpActiveUntilAnnotation: "2000-01-01T00:00:00Z"}}, Spec: model.GlobalTrafficPolicy{
+			Policy: []*model.TrafficPolicy{{DnsPrefix: "hellogtpexpired"}},
+		}}
+		gtpDrain = &v13.GlobalTrafficPolicy{ObjectMeta: metav1.ObjectMeta{Name: "gtpDrain", Namespace: "namespace1", CreationTimestamp: metav1.NewTime(time.Now().Add(time.Duration(-60))), Labels: map[string]string{"identity": identity1, "env": envStage},
+			Annotations: map[string]string{common.GtpActiveFromAnnotation: "2000-01-01T00:00:00Z"}}, Spec: model.GlobalTrafficPolicy{
+			Policy: []*model.TrafficPolicy{{DnsPrefix: "hellogtpdrain"}},
+		}}
 	)
 
 	remoteRegistryWithGtpAndAdmiralClient.AdmiralCache.GlobalTrafficCache.Put(gtp7)
@@ -4329,6 +4337,24 @@ func TestUpdateGlobalGtpCacheAndGetGtpPreferenceRegion(t *testing.T) {
 			remoteRegistry: remoteRegistryWithoutGtpWithoutAdmiralClient,
 			expectedGtp:    gtp6,
 		},
+		{
+			name:     "Should return nil when every gtp is outside of its activation window",
+			gtps:     map[string][]*v13.GlobalTrafficPolicy{"c1": {gtpExpired}, "c2": {gtpExpired}},
+			identity: identity1,
+			env:      envStage,
+			remoteRegistry: &RemoteRegistry{
+				AdmiralCache: &AdmiralCache{GlobalTrafficCache: &globalTrafficCache{identityCache: make(map[string]*v13.GlobalTrafficPolicy), mutex: &sync.Mutex{}}},
+			},
+			expectedGtp: nil,
+		},
+		{
+			name:           "Should return the gtp within its activation window over the priority gtps",
+			gtps:           map[string][]*v13.GlobalTrafficPolicy{"c1": {gtp4, gtpExpired, gtpDrain}, "c2": {gtp6}},
+			identity:       identity1,
+			env:            envStage,
+			remoteRegistry: remoteRegistryWithoutGtpWithoutAdmiralClient,
+			expectedGtp:    gtpDrain,
+		},
 	}
 
 	var ctxLogger = logrus.WithFields(logrus.Fields{
@@ -4479,7 +4505,7 @@ func TestCreateAdditionalEndpoints(t *testing.T) {
 	fooVS := &v1alpha3.VirtualService{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        "stage.test00.foo-vs",
-			Labels:      map[string]string{"admiral.io/env": "stage", dnsPrefixAnnotationLabel: "default"},
+			Labels:      map[string]string{"admiral.io/env": "stage", dnsPrefixAnnotationLabel: "default", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 			Annotations: map[string]string{"identity": "test00"},
 		},
 		Spec: istioNetworkingV1Alpha3.VirtualService{
@@ -4797,7 +4823,7 @@ func TestCreateAdditionalEndpointsForGatewayCluster(t *testing.T) {
 			expectedVS: &networking.VirtualService{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        fmt.Sprintf("test.%s.buzz-vs", identity1),
-					Labels:      map[string]string{"admiral.io/env": "test", dnsPrefixAnnotationLabel: "default"},
+					Labels:      map[string]string{"admiral.io/env": "test", dnsPrefixAnnotationLabel: "default", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 					Annotations: map[string]string{"identity": identity1, "app.kubernetes.io/created-by": "admiral"},
 				},
 				Spec: istioNetworkingV1Alpha3.VirtualService{
@@ -4930,7 +4956,7 @@ func TestCreateAdditionalEndpointsForGatewayCluster(t *testing.T) {
 			expectedVS: &networking.VirtualService{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        fmt.Sprintf("test.%s.buzz-vs", "my.asset.identity1"),
-					Labels:      map[string]string{"admiral.io/env": "test", dnsPrefixAnnotationLabel: "default"},
+					Labels:      map[string]string{"admiral.io/env": "test", dnsPrefixAnnotationLabel: "default", common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster},
 					Annotations: map[string]string{common.GetWorkloadIdentifier(): "my.asset.identity1", "app.kubernetes.io/created-by": "admiral"},
 				},
 				Spec: istioNetworkingV1Alpha3.VirtualService{
@@ -9995,6 +10021,13 @@ func Test_getOverwrittenLoadBalancer(t *testing.T) {
 	testArg2.rc.ServiceController.Cache.Put(&testService)
 	testArg2.rc.ServiceController.Cache.Put(testService2)
 
+	testArg3 := args{
+		ctx:          logrus.New().WithContext(context.Background()),
+		rc:           testArg1.rc,
+		clusterName:  "cluster1",
+		admiralCache: &AdmiralCache{NLBEnabledCluster: []string{"cluster1"}, CLBEnabledCluster: []string{"cluster1"}},
+	}
+
 	tests := []struct {
 		name             string
 		args             args
@@ -10002,6 +10035,7 @@ func Test_getOverwrittenLoadBalancer(t *testing.T) {
 		expectedPort     int
 	}{
 		{"When NLB Cluster Overwrite is not then getOverwrittenLoadBalancer should return CLB value", testArg, "clb.istio.com", 15443},
+		{"When CLB Cluster Overwrite is set then getOverwrittenLoadBalancer should return CLB value even when NLB is enabled", testArg3, "clb.istio.com", 15443},
 		{"When NLB Cluster Overwrite is set then getOverwrittenLoadBalancer should return NLB value", testArg1, "nlb.istio.com", 15443},
 		{"When NLB Cluster Overwrite is set but NLB is not present then getOverwrittenLoadBalancer should return CLB value", testArg2, "clb.istio.com", 15443},
 	}
@@ -10475,3 +10509,75 @@ func TestDoesIdentityHaveVS(t *testing.T) {
 		})
 	}
 }
+
+func TestGetServiceEntryPorts(t *testing.T) {
+	testCases := []struct {
+		name      string
+		meshPorts map[string]uint32
+		expected  []*istioNetworkingV1Alpha3.ServicePort
+	}{
+		{
+			name: "Given no mesh ports, " +
+				"When getServiceEntryPorts is called, " +
+				"Then it should return the default http port",
+			expected: []*istioNetworkingV1Alpha3.ServicePort{{Number: 80, Name: "http", Protocol: "http"}},
+		},
+		{
+			name: "Given a single mesh port, " +
+				"When getServiceEntryPorts is called, " +
+				"Then it should be served on the default service entry port",
+			meshPorts: map[string]uint32{"grpc": 8090},
+			expected:  []*istioNetworkingV1Alpha3.ServicePort{{Number: 80, Name: "grpc", Protocol: "grpc"}},
+		},
+		{
+			name: "Given multiple mesh ports, " +
+				"When getServiceEntryPorts is called, " +
+				"Then the first port should be served on the default service entry port " +
+				"And the other ports on their service port number with the protocol inferred from their name",
+			meshPorts: map[string]uint32{"http": 8080, "grpc-9090": 9090, "http2-70": 70},
+			expected: []*istioNetworkingV1Alpha3.ServicePort{
+				{Number: 80, Name: "http", Protocol: "http"},
+				{Number: 70, Name: "http2-70", Protocol: "http2"},
+				{Number: 9090, Name: "grpc-9090", Protocol: "grpc"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := getServiceEntryPorts(tc.meshPorts)
+			if !cmp.Equal(tc.expected, actual, protocmp.Transform()) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGenerateServiceEntryWithMultipleMeshPorts(t *testing.T) {
+	ctxLogger := logrus.WithFields(logrus.Fields{"type": "GenerateServiceEntry"})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rc := &RemoteController{ClusterID: "cluster1", NodeController: &admiral.NodeController{}}
+	serviceEntries := make(map[string]*istioNetworkingV1Alpha3.ServiceEntry)
+
+	se := generateServiceEntry(ctxLogger, admiral.Add, rr.AdmiralCache, map[string]uint32{"http": 8080, "grpc-9090": 9090},
+		"stage.foo.global", rc, serviceEntries, "240.0.10.1", nil, common.Deployment)
+
+	assert.Equal(t, 2, len(se.Ports))
+	assert.Equal(t, 1, len(se.Endpoints))
+	assert.Equal(t, map[string]uint32{"http": common.DefaultMtlsPort, "grpc-9090": common.DefaultMtlsPort}, se.Endpoints[0].Ports)
+}
+
+func TestGetWorkloadSpiffeID(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:               &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SpiffeIdentityClusters: []string{"cluster1"},
+	})
+	template := &coreV1.PodTemplateSpec{}
+	template.Labels = map[string]string{"identity": "foo"}
+	template.Annotations = map[string]string{common.SpiffeIdAnnotation: "spiffe://example.org/ns/ns1/sa/foo"}
+	assert.Equal(t, "spiffe://example.org/ns/ns1/sa/foo", getWorkloadSpiffeID("cluster1", template))
+	assert.Equal(t, "", getWorkloadSpiffeID("cluster2", template), "the SPIFFE ID should only be used in the clusters resolving identities from it")
+
+	template.Labels["identity"] = "bar"
+	assert.Equal(t, "", getWorkloadSpiffeID("cluster1", template), "the SPIFFE ID should not be used when it is not the identity of the workload")
+}
@@ -13,7 +13,6 @@ import (
 	admiralapi "github.com/istio-ecosystem/admiral-api/pkg/client/clientset/versioned"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/registry"
-	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
@@ -172,6 +171,20 @@ func ConsumeIdentityConfigs(ctxLogger *log.Entry, ctx context.Context, configWri
 		clientCluster := data.ClusterName
 		ctxLogger.Infof(common.CtxLogFormat, "ConsumeIdentityConfig", assetName, "", clientCluster, "starting to consume identityConfig")
 		start := time.Now()
+		putIdentitySyncNamespace(rr.AdmiralCache, assetName, identityConfig.SyncNamespace)
+		if rr.AdmiralCache != nil && rr.AdmiralCache.IdentityClientTLSSettingsCache != nil {
+			rr.AdmiralCache.IdentityClientTLSSettingsCache.Put(assetName, identityConfig.ClientTLSSettings)
+		}
+		if rr.AdmiralCache != nil && rr.AdmiralCache.IdentityWarmupDurationCache != nil {
+			for _, cluster := range identityConfig.Clusters {
+				for _, environment := range cluster.Environment {
+					rr.AdmiralCache.IdentityWarmupDurationCache.Put(assetName, environment.Name, environment.WarmupDurationSecs)
+				}
+			}
+		}
+		if rr.AdmiralCache != nil && rr.AdmiralCache.IdentityFailoverPriorityCache != nil {
+			rr.AdmiralCache.IdentityFailoverPriorityCache.Put(assetName, identityConfig.FailoverPriority)
+		}
 		serviceEntryBuilder := ServiceEntryBuilder{ClientCluster: clientCluster, RemoteRegistry: rr}
 		serviceEntries, err := serviceEntryBuilder.BuildServiceEntriesFromIdentityConfig(ctxLogger, *identityConfig)
 		if err != nil {
@@ -210,7 +223,7 @@ func ConsumeIdentityConfigs(ctxLogger *log.Entry, ctx context.Context, configWri
 			}
 			clusters := make(chan string, 1)
 			errors := make(chan error, 1)
-			go AddServiceEntriesWithDrWorker(ctxLogger, ctx, rr,
+			go runAddServiceEntriesWithDrWorker(ctxLogger, ctx, rr,
 				true, //TODO: doGenerateAdditionalEndpoints()
 				isServiceEntryModifyCalledForSourceClusterAndEnv,
 				assetName,
@@ -321,26 +334,27 @@ func updateShardStatus(ctx context.Context, ctxLogger *log.Entry, obj *admiralap
 }
 
 func retryUpdatingShard(ctx context.Context, ctxLogger *log.Entry, obj *admiralapiv1.Shard, exist *admiralapiv1.Shard, cc admiralapi.Interface, err error) error {
-	numRetries := 5
-	if err != nil && k8sErrors.IsConflict(err) {
-		for i := 0; i < numRetries; i++ {
-			ctxLogger.Errorf(common.CtxLogFormat, "Update", obj.Name, obj.Namespace, "", err.Error()+". retry Shard update "+strconv.Itoa(i+1)+"/"+strconv.Itoa(numRetries))
-			updatedShard, err := cc.AdmiralV1().Shards(exist.Namespace).Get(ctx, exist.Name, v1.GetOptions{})
-			// if old shard not found, move on
-			if err != nil {
-				ctxLogger.Infof(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, "", err.Error()+fmt.Sprintf(". Error getting old shard"))
-				continue
-			}
-			ctxLogger.Infof(common.CtxLogFormat, "Update", obj.Name, obj.Namespace, "", fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s", updatedShard.ResourceVersion, obj.ResourceVersion))
-			updatedShard.Spec = obj.Spec
-			updatedShard.Status = obj.Status
-			updatedShard.Annotations = obj.Annotations
-			updatedShard.Labels = obj.Labels
-			sh, err := cc.AdmiralV1().Shards(exist.Namespace).Update(ctx, updatedShard, v1.UpdateOptions{})
-			if err == nil || sh == nil {
-				return nil
-			}
-		}
+	if err == nil || !common.IsRetryableWriteError(err) {
+		return err
 	}
-	return err
+	policy := common.GetWriteRetryPolicy()
+	return common.RetryAfterFirstAttempt(ctx, policy, err, func(attempt int) error {
+		ctxLogger.Errorf(common.CtxLogFormat, "Update", obj.Name, obj.Namespace, "", err.Error()+". retry Shard update "+strconv.Itoa(attempt)+"/"+strconv.Itoa(policy.Attempts))
+		updatedShard, getErr := cc.AdmiralV1().Shards(exist.Namespace).Get(ctx, exist.Name, v1.GetOptions{})
+		if getErr != nil {
+			ctxLogger.Infof(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, "", getErr.Error()+". Error getting old shard")
+			return err
+		}
+		ctxLogger.Infof(common.CtxLogFormat, "Update", obj.Name, obj.Namespace, "", fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s", updatedShard.ResourceVersion, obj.ResourceVersion))
+		updatedShard.Spec = obj.Spec
+		updatedShard.Status = obj.Status
+		updatedShard.Annotations = obj.Annotations
+		updatedShard.Labels = obj.Labels
+		sh, updateErr := cc.AdmiralV1().Shards(exist.Namespace).Update(ctx, updatedShard, v1.UpdateOptions{})
+		if updateErr == nil || sh == nil {
+			return nil
+		}
+		err = updateErr
+		return err
+	})
 }
@@ -0,0 +1,473 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// SimulationRequest is a hypothetical change to simulate. Only one of the changes can be set
+type SimulationRequest struct {
+	Dependency          *v1alpha1.Dependency          `json:"dependency,omitempty"`
+	GlobalTrafficPolicy *v1alpha1.GlobalTrafficPolicy `json:"globalTrafficPolicy,omitempty"`
+	RemovedCluster      string                        `json:"removedCluster,omitempty"`
+}
+
+// SimulatedChange is a resource admiral would create, update or delete in a cluster
+type SimulatedChange struct {
+	Operation string `json:"operation"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	Reason    string `json:"reason"`
+	// Weights are the regions whose share of the traffic changes, along with the host the share
+	// applies to for the VirtualServices routing more than one host
+	Weights []SimulatedWeight `json:"weights,omitempty"`
+}
+
+// SimulatedWeight is the share of the traffic sent to a region before and after a simulated change
+type SimulatedWeight struct {
+	Host   string `json:"host,omitempty"`
+	Region string `json:"region"`
+	From   uint32 `json:"from"`
+	To     uint32 `json:"to"`
+}
+
+// String returns a one line summary of the change, with the weights it changes
+func (c SimulatedChange) String() string {
+	summary := fmt.Sprintf("%s %s %s/%s in cluster %s", strings.ToLower(c.Operation), c.Kind, c.Namespace, c.Name, c.Cluster)
+	if len(c.Weights) == 0 {
+		return summary
+	}
+	weights := make([]string, 0, len(c.Weights))
+	for _, weight := range c.Weights {
+		region := weight.Region
+		if weight.Host != "" {
+			region = weight.Host + "@" + region
+		}
+		weights = append(weights, fmt.Sprintf("%s %d->%d", region, weight.From, weight.To))
+	}
+	return summary + ": " + strings.Join(weights, ", ")
+}
+
+// SimulationResult is the outcome of a simulated change. Warnings describe the effects of
+// the change admiral does not act on
+type SimulationResult struct {
+	Changes  []SimulatedChange `json:"changes"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// Simulate returns the resources admiral would create, update or delete if the change was
+// applied. The changes are computed against the caches admiral built from the clusters it
+// watches, nothing is applied and the caches are left untouched
+func Simulate(rr *RemoteRegistry, request SimulationRequest) (SimulationResult, error) {
+	result := SimulationResult{Changes: []SimulatedChange{}}
+	changes := 0
+	for _, set := range []bool{request.Dependency != nil, request.GlobalTrafficPolicy != nil, request.RemovedCluster != ""} {
+		if set {
+			changes++
+		}
+	}
+	if changes != 1 {
+		return result, fmt.Errorf("exactly one of dependency, globalTrafficPolicy or removedCluster must be set")
+	}
+	if rr == nil || rr.AdmiralCache == nil {
+		return result, fmt.Errorf("admiral caches are not initialized")
+	}
+
+	var err error
+	switch {
+	case request.Dependency != nil:
+		err = simulateDependency(rr, request.Dependency, &result)
+	case request.GlobalTrafficPolicy != nil:
+		err = simulateGlobalTrafficPolicy(rr, request.GlobalTrafficPolicy, &result)
+	default:
+		err = simulateClusterRemoval(rr, request.RemovedCluster, &result)
+	}
+	sort.SliceStable(result.Changes, func(i, j int) bool {
+		if result.Changes[i].Cluster != result.Changes[j].Cluster {
+			return result.Changes[i].Cluster < result.Changes[j].Cluster
+		}
+		return result.Changes[i].Name < result.Changes[j].Name
+	})
+	return result, err
+}
+
+// simulateDependency returns the ServiceEntries and DestinationRules of the destinations added
+// to the dependency, which are written to the clusters the source runs in
+func simulateDependency(rr *RemoteRegistry, dependency *v1alpha1.Dependency, result *SimulationResult) error {
+	source := dependency.Spec.Source
+	if source == "" {
+		return fmt.Errorf("dependency source is not set")
+	}
+	sourceClusters := getCacheKeys(rr.AdmiralCache.IdentityClusterCache, source)
+	if len(sourceClusters) == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("source %s is not running in any cluster admiral watches", source))
+		return nil
+	}
+
+	currentDestinations := make(map[string]bool)
+	if rr.AdmiralCache.SourceToDestinations != nil {
+		for _, destination := range rr.AdmiralCache.SourceToDestinations.Get(source) {
+			currentDestinations[strings.ToLower(destination)] = true
+		}
+	}
+	newDestinations := make(map[string]bool)
+	for _, destination := range dependency.Spec.Destinations {
+		newDestinations[strings.ToLower(destination)] = true
+		if currentDestinations[strings.ToLower(destination)] {
+			continue
+		}
+		hosts := getIdentityHosts(rr.AdmiralCache, destination, "")
+		if len(hosts) == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("destination %s is not running in any cluster admiral watches", destination))
+			continue
+		}
+		for _, host := range hosts {
+			existingClusters := getHostClusters(rr.AdmiralCache, host)
+			for _, cluster := range sourceClusters {
+				namespace := getSimulatedSyncNamespace(rr.AdmiralCache, destination, cluster)
+				if existingClusters[cluster] {
+					result.addChange(audit.OperationUpdate, common.ServiceEntryResourceType, getIstioResourceName(host, "-se"), namespace, cluster,
+						fmt.Sprintf("exportTo of %s includes the namespaces of the new dependent %s", host, source))
+					continue
+				}
+				reason := fmt.Sprintf("%s added as a destination of %s", destination, source)
+				result.addChange(audit.OperationCreate, common.ServiceEntryResourceType, getIstioResourceName(host, "-se"), namespace, cluster, reason)
+				result.addChange(audit.OperationCreate, common.DestinationRuleResourceType, getIstioResourceName(host, "-default-dr"), namespace, cluster, reason)
+			}
+		}
+	}
+	for destination := range currentDestinations {
+		if !newDestinations[destination] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("destination %s removed from the dependency, its resources are left in place in the clusters of %s", destination, source))
+		}
+	}
+	sort.Strings(result.Warnings)
+	return nil
+}
+
+// simulateGlobalTrafficPolicy returns the DestinationRules, ServiceEntries and in-cluster VirtualServices
+// the traffic policies of the GTP change, along with the weights of the regions before and after the change.
+// The resources of the clusters whose caches are synced are compared with their live state, so the resources
+// the GTP leaves as they are are not returned
+func simulateGlobalTrafficPolicy(rr *RemoteRegistry, gtp *v1alpha1.GlobalTrafficPolicy, result *SimulationResult) error {
+	identity := common.GetGtpIdentity(gtp)
+	if identity == "" {
+		return fmt.Errorf("globalTrafficPolicy is missing the %s label", common.GetAdmiralCRDIdentityLabel())
+	}
+	env := common.GetGtpEnv(gtp)
+	hosts := getIdentityHosts(rr.AdmiralCache, identity, env)
+	if len(hosts) == 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("identity %s in env %s is not running in any cluster admiral watches", identity, env))
+		return nil
+	}
+
+	var currentGtp *v1alpha1.GlobalTrafficPolicy
+	currentPrefixes := make(map[string]bool)
+	if rr.AdmiralCache.GlobalTrafficCache != nil {
+		if cachedGtp, err := rr.AdmiralCache.GlobalTrafficCache.GetFromIdentity(identity, env); err == nil && cachedGtp != nil {
+			currentGtp = cachedGtp
+			currentPrefixes = getGtpDnsPrefixes(currentGtp, env)
+		}
+	}
+	newPrefixes := getGtpDnsPrefixes(gtp, env)
+	reason := fmt.Sprintf("traffic policies of globaltrafficpolicy %s", gtp.Name)
+	for _, host := range hosts {
+		sourceClusters := make(map[string]bool)
+		for _, cluster := range getCacheKeys(rr.AdmiralCache.CnameClusterCache, host) {
+			sourceClusters[cluster] = true
+		}
+		for cluster := range getHostClusters(rr.AdmiralCache, host) {
+			rc := rr.GetRemoteController(cluster)
+			namespace := getSimulatedSyncNamespace(rr.AdmiralCache, identity, cluster)
+			currentPolicy, newPolicy := getGtpPolicy(currentGtp, "", env), getGtpPolicy(gtp, "", env)
+			simulateTrafficPolicy(result, rc, host, "-default-dr", namespace, cluster, currentPolicy, newPolicy, reason)
+			vsWeights := getHostWeightChanges(host, getRegionWeights(currentPolicy), getRegionWeights(newPolicy))
+			for prefix := range newPrefixes {
+				prefixedHost := common.GetCnameVal([]string{prefix, host})
+				newPrefixPolicy := getGtpPolicy(gtp, prefix, env)
+				if currentPrefixes[prefix] {
+					currentPrefixPolicy := getGtpPolicy(currentGtp, prefix, env)
+					simulateTrafficPolicy(result, rc, prefixedHost, "-dr", namespace, cluster, currentPrefixPolicy, newPrefixPolicy, reason)
+					vsWeights = append(vsWeights, getHostWeightChanges(prefixedHost, getRegionWeights(currentPrefixPolicy), getRegionWeights(newPrefixPolicy))...)
+					continue
+				}
+				prefixReason := fmt.Sprintf("dns prefix %s added by globaltrafficpolicy %s", prefix, gtp.Name)
+				prefixWeights := getWeightChanges(nil, getRegionWeights(newPrefixPolicy))
+				result.addChange(audit.OperationCreate, common.ServiceEntryResourceType, getIstioResourceName(prefixedHost, "-se"), namespace, cluster, prefixReason)
+				result.addWeightedChange(audit.OperationCreate, common.DestinationRuleResourceType, getIstioResourceName(prefixedHost, "-dr"), namespace, cluster, prefixReason, prefixWeights)
+				vsWeights = append(vsWeights, getHostWeightChanges(prefixedHost, nil, getRegionWeights(newPrefixPolicy))...)
+			}
+			if sourceClusters[cluster] && len(vsWeights) > 0 {
+				simulateInClusterVirtualService(result, rc, host, cluster, reason, vsWeights)
+			}
+		}
+	}
+	for prefix := range currentPrefixes {
+		if !newPrefixes[prefix] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("dns prefix %s removed from the globaltrafficpolicy, its resources are left in place", prefix))
+		}
+	}
+	sort.Strings(result.Warnings)
+	return nil
+}
+
+// simulateTrafficPolicy adds the changes of the DestinationRule of the host, and of its ServiceEntry when its
+// endpoints are weighted by region, going from the current to the new traffic policy. The weights are compared
+// with the live resources when the caches of the cluster are synced, and with the current policy otherwise
+func simulateTrafficPolicy(result *SimulationResult, rc *RemoteController, host, drSuffix, namespace, cluster string,
+	currentPolicy, newPolicy *model.TrafficPolicy, reason string) {
+	currentWeights, newWeights := getRegionWeights(currentPolicy), getRegionWeights(newPolicy)
+	drName := getIstioResourceName(host, drSuffix)
+	if rc != nil && rc.DestinationRuleController != nil && rc.DestinationRuleController.Cache != nil {
+		dr := rc.DestinationRuleController.Cache.Get(drName, namespace)
+		if dr == nil {
+			result.addWeightedChange(audit.OperationCreate, common.DestinationRuleResourceType, drName, namespace, cluster, reason, getWeightChanges(nil, newWeights))
+		} else if weights := getWeightChanges(getDestinationRuleWeights(&dr.Spec), newWeights); len(weights) > 0 || !proto.Equal(currentPolicy, newPolicy) {
+			result.addWeightedChange(audit.OperationUpdate, common.DestinationRuleResourceType, drName, namespace, cluster, reason, weights)
+		}
+	} else {
+		result.addWeightedChange(audit.OperationUpdate, common.DestinationRuleResourceType, drName, namespace, cluster, reason, getWeightChanges(currentWeights, newWeights))
+	}
+
+	if !common.EnableGTPWeightedEndpoints() {
+		return
+	}
+	seName := getIstioResourceName(host, "-se")
+	seWeights := currentWeights
+	if rc != nil && rc.ServiceEntryController != nil && rc.ServiceEntryController.Cache != nil {
+		se := rc.ServiceEntryController.Cache.Get(seName, cluster)
+		if se == nil {
+			return
+		}
+		seWeights = getServiceEntryWeights(&se.Spec)
+	}
+	if weights := getWeightChanges(seWeights, newWeights); len(weights) > 0 {
+		result.addWeightedChange(audit.OperationUpdate, common.ServiceEntryResourceType, seName, namespace, cluster,
+			fmt.Sprintf("endpoints of %s weighted by the %s", host, reason), weights)
+	}
+}
+
+// simulateInClusterVirtualService adds the update of the in-cluster VirtualService of the host, whose routes
+// are weighted by the traffic policies of the GTP. Only the in-cluster VirtualServices present in the cluster
+// are updated, the cluster is skipped when its caches are not synced
+func simulateInClusterVirtualService(result *SimulationResult, rc *RemoteController, host, cluster, reason string, weights []SimulatedWeight) {
+	if rc == nil || rc.VirtualServiceController == nil || rc.VirtualServiceController.VirtualServiceCache == nil {
+		return
+	}
+	vs := rc.VirtualServiceController.VirtualServiceCache.Get(getIstioResourceName(host, "-"+common.InclusterVSNameSuffix))
+	if vs == nil {
+		return
+	}
+	sort.SliceStable(weights, func(i, j int) bool {
+		if weights[i].Host != weights[j].Host {
+			return weights[i].Host < weights[j].Host
+		}
+		return weights[i].Region < weights[j].Region
+	})
+	result.addWeightedChange(audit.OperationUpdate, common.VirtualServiceResourceType, vs.Name, vs.Namespace, cluster, reason, weights)
+}
+
+// simulateClusterRemoval returns the ServiceEntries which lose the endpoints of the removed cluster,
+// along with the resources deleted for the identities which only run in the removed cluster
+func simulateClusterRemoval(rr *RemoteRegistry, removedCluster string, result *SimulationResult) error {
+	if rr.GetRemoteController(removedCluster) == nil {
+		return fmt.Errorf("admiral is not monitoring cluster %s", removedCluster)
+	}
+	result.Warnings = append(result.Warnings, fmt.Sprintf("resources in cluster %s are left in place and no longer updated", removedCluster))
+	if rr.AdmiralCache.IdentityClusterCache == nil {
+		return nil
+	}
+	rr.AdmiralCache.IdentityClusterCache.Range(func(identity string, identityClusters *common.Map) {
+		if identityClusters == nil || !identityClusters.CheckIfPresent(removedCluster) {
+			return
+		}
+		remainingClusters := identityClusters.Len() - 1
+		for _, host := range getIdentityHosts(rr.AdmiralCache, identity, "") {
+			for cluster := range getHostClusters(rr.AdmiralCache, host) {
+				if cluster == removedCluster {
+					continue
+				}
+				namespace := getSimulatedSyncNamespace(rr.AdmiralCache, identity, cluster)
+				if remainingClusters > 0 {
+					result.addChange(audit.OperationUpdate, common.ServiceEntryResourceType, getIstioResourceName(host, "-se"), namespace, cluster,
+						fmt.Sprintf("endpoints of %s in cluster %s are removed", identity, removedCluster))
+					continue
+				}
+				reason := fmt.Sprintf("%s only runs in cluster %s", identity, removedCluster)
+				result.addChange(audit.OperationDelete, common.ServiceEntryResourceType, getIstioResourceName(host, "-se"), namespace, cluster, reason)
+				result.addChange(audit.OperationDelete, common.DestinationRuleResourceType, getIstioResourceName(host, "-default-dr"), namespace, cluster, reason)
+			}
+		}
+	})
+	return nil
+}
+
+func (r *SimulationResult) addChange(operation string, kind common.ResourceType, name, namespace, cluster, reason string) {
+	r.addWeightedChange(operation, kind, name, namespace, cluster, reason, nil)
+}
+
+func (r *SimulationResult) addWeightedChange(operation string, kind common.ResourceType, name, namespace, cluster, reason string, weights []SimulatedWeight) {
+	r.Changes = append(r.Changes, SimulatedChange{
+		Operation: operation,
+		Kind:      string(kind),
+		Name:      name,
+		Namespace: namespace,
+		Cluster:   cluster,
+		Reason:    reason,
+		Weights:   weights,
+	})
+}
+
+// getIdentityHosts returns the sorted hosts admiral generated for the identity, limited to the
+// hosts of env when it is set
+func getIdentityHosts(cache *AdmiralCache, identity, env string) []string {
+	var hosts []string
+	if cache.CnameIdentityCache == nil {
+		return hosts
+	}
+	cache.CnameIdentityCache.Range(func(cname, cnameIdentity interface{}) bool {
+		host, ok := cname.(string)
+		if !ok || !strings.EqualFold(fmt.Sprint(cnameIdentity), identity) {
+			return true
+		}
+		if env == "" || strings.HasPrefix(strings.ToLower(host), strings.ToLower(env)+common.Sep) {
+			hosts = append(hosts, host)
+		}
+		return true
+	})
+	sort.Strings(hosts)
+	return hosts
+}
+
+// getHostClusters returns the clusters the resources of the host are written to, which are the
+// clusters the host runs in along with the clusters of its dependents
+func getHostClusters(cache *AdmiralCache, host string) map[string]bool {
+	clusters := make(map[string]bool)
+	for _, c := range []*common.MapOfMaps{cache.CnameClusterCache, cache.CnameDependentClusterCache} {
+		for _, cluster := range getCacheKeys(c, host) {
+			clusters[cluster] = true
+		}
+	}
+	return clusters
+}
+
+func getCacheKeys(cache *common.MapOfMaps, key string) []string {
+	if cache == nil {
+		return nil
+	}
+	values := cache.Get(key)
+	if values == nil {
+		return nil
+	}
+	keys := values.GetKeys()
+	sort.Strings(keys)
+	return keys
+}
+
+func getSimulatedSyncNamespace(cache *AdmiralCache, identity, cluster string) string {
+	if namespace := getIdentitySyncNamespace(cache, identity); namespace != "" {
+		return namespace
+	}
+	return common.GetSyncNamespaceForCluster(cluster)
+}
+
+// getGtpDnsPrefixes returns the dns prefixes of the GTP for which admiral generates additional hosts
+func getGtpDnsPrefixes(gtp *v1alpha1.GlobalTrafficPolicy, env string) map[string]bool {
+	prefixes := make(map[string]bool)
+	for _, policy := range gtp.Spec.Policy {
+		if policy == nil || policy.DnsPrefix == "" || policy.DnsPrefix == env || policy.DnsPrefix == common.Default {
+			continue
+		}
+		prefixes[policy.DnsPrefix] = true
+	}
+	return prefixes
+}
+
+// getGtpPolicy returns the traffic policy of the GTP for the dns prefix, the policy of the env or the default
+// prefix is returned when the prefix is empty
+func getGtpPolicy(gtp *v1alpha1.GlobalTrafficPolicy, prefix, env string) *model.TrafficPolicy {
+	if gtp == nil {
+		return nil
+	}
+	for _, policy := range gtp.Spec.Policy {
+		if policy == nil {
+			continue
+		}
+		if prefix == "" && (policy.DnsPrefix == env || policy.DnsPrefix == common.Default) || prefix != "" && policy.DnsPrefix == prefix {
+			return policy
+		}
+	}
+	return nil
+}
+
+// getWeightChanges returns the regions whose weight differs, sorted by region
+func getWeightChanges(from, to map[string]uint32) []SimulatedWeight {
+	var changes []SimulatedWeight
+	for region, weight := range to {
+		if from[region] != weight {
+			changes = append(changes, SimulatedWeight{Region: region, From: from[region], To: weight})
+		}
+	}
+	for region, weight := range from {
+		if _, ok := to[region]; !ok && weight != 0 {
+			changes = append(changes, SimulatedWeight{Region: region, From: weight})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Region < changes[j].Region
+	})
+	return changes
+}
+
+func getHostWeightChanges(host string, from, to map[string]uint32) []SimulatedWeight {
+	changes := getWeightChanges(from, to)
+	for i := range changes {
+		changes[i].Host = host
+	}
+	return changes
+}
+
+// getDestinationRuleWeights returns the weight of each region the locality distribution of the DestinationRule sends traffic to
+func getDestinationRuleWeights(dr *networking.DestinationRule) map[string]uint32 {
+	if dr == nil || dr.TrafficPolicy == nil || dr.TrafficPolicy.LoadBalancer == nil || dr.TrafficPolicy.LoadBalancer.LocalityLbSetting == nil {
+		return nil
+	}
+	weights := make(map[string]uint32)
+	for _, distribute := range dr.TrafficPolicy.LoadBalancer.LocalityLbSetting.Distribute {
+		for locality, weight := range distribute.To {
+			region := strings.TrimSuffix(locality, "/*")
+			if _, ok := weights[region]; !ok && weight != 0 {
+				weights[region] = weight
+			}
+		}
+	}
+	return weights
+}
+
+// getServiceEntryWeights returns the weight of each region the endpoints of the ServiceEntry were weighted by,
+// nil is returned when the endpoints are not weighted
+func getServiceEntryWeights(se *networking.ServiceEntry) map[string]uint32 {
+	weights := make(map[string]uint32)
+	for _, endpoint := range se.Endpoints {
+		if endpoint.Weight != 0 {
+			weights[endpoint.Locality] += endpoint.Weight
+		}
+	}
+	if len(weights) == 0 {
+		return nil
+	}
+	for region, weight := range weights {
+		weights[region] = max((weight+regionEndpointWeightScale/2)/regionEndpointWeightScale, 1)
+	}
+	return weights
+}
@@ -0,0 +1,281 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newSimulationTestRegistry() *RemoteRegistry {
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{ClusterID: "cluster1"})
+	rr.PutRemoteController("cluster2", &RemoteController{ClusterID: "cluster2"})
+	rr.PutRemoteController("cluster3", &RemoteController{ClusterID: "cluster3"})
+	cache := rr.AdmiralCache
+	// foo runs in cluster1 and cluster2, bar only runs in cluster3 and depends on foo,
+	// another identity in cluster1 depends on bar
+	cache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	cache.IdentityClusterCache.Put("foo", "cluster2", "cluster2")
+	cache.IdentityClusterCache.Put("bar", "cluster3", "cluster3")
+	cache.CnameIdentityCache.Store("stage.foo.global", "foo")
+	cache.CnameIdentityCache.Store("stage.bar.global", "bar")
+	cache.CnameClusterCache.Put("stage.foo.global", "cluster1", "cluster1")
+	cache.CnameClusterCache.Put("stage.foo.global", "cluster2", "cluster2")
+	cache.CnameClusterCache.Put("stage.bar.global", "cluster3", "cluster3")
+	cache.CnameDependentClusterCache.Put("stage.foo.global", "cluster3", "cluster3")
+	cache.CnameDependentClusterCache.Put("stage.bar.global", "cluster1", "cluster1")
+	cache.SourceToDestinations.put(&v1alpha1.Dependency{
+		Spec: model.Dependency{Source: "bar", Destinations: []string{"foo"}},
+	})
+	cache.GlobalTrafficCache = &globalTrafficCache{
+		identityCache: make(map[string]*v1alpha1.GlobalTrafficPolicy),
+		mutex:         &sync.Mutex{},
+	}
+	return rr
+}
+
+func TestSimulate(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			AdmiralCRDIdentityLabel: "identity",
+			EnvKey:                  "admiral.io/env",
+		},
+		SyncNamespace: "admiral-sync",
+	})
+	gtp := func(prefixes ...string) *v1alpha1.GlobalTrafficPolicy {
+		g := &v1alpha1.GlobalTrafficPolicy{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:   "foo-gtp",
+				Labels: map[string]string{"identity": "foo", "admiral.io/env": "stage"},
+			},
+		}
+		for _, prefix := range prefixes {
+			g.Spec.Policy = append(g.Spec.Policy, &model.TrafficPolicy{DnsPrefix: prefix})
+		}
+		return g
+	}
+
+	testCases := []struct {
+		name             string
+		request          SimulationRequest
+		existingGtp      *v1alpha1.GlobalTrafficPolicy
+		expectedChanges  []SimulatedChange
+		expectedWarnings []string
+		expectedErr      string
+	}{
+		{
+			name: "Given no change is set, " +
+				"When Simulate is called, " +
+				"Then it should return an error",
+			request:     SimulationRequest{},
+			expectedErr: "exactly one of dependency, globalTrafficPolicy or removedCluster must be set",
+		},
+		{
+			name: "Given a dependency adding a destination, " +
+				"When Simulate is called, " +
+				"Then the resources of the destination should be created in the clusters of the source",
+			request: SimulationRequest{Dependency: &v1alpha1.Dependency{
+				Spec: model.Dependency{Source: "foo", Destinations: []string{"bar", "baz"}},
+			}},
+			expectedChanges: []SimulatedChange{
+				{Operation: audit.OperationUpdate, Kind: "ServiceEntry", Name: "stage.bar.global-se", Namespace: "admiral-sync", Cluster: "cluster1", Reason: "exportTo of stage.bar.global includes the namespaces of the new dependent foo"},
+				{Operation: audit.OperationCreate, Kind: "DestinationRule", Name: "stage.bar.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster2", Reason: "bar added as a destination of foo"},
+				{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "stage.bar.global-se", Namespace: "admiral-sync", Cluster: "cluster2", Reason: "bar added as a destination of foo"},
+			},
+			expectedWarnings: []string{"destination baz is not running in any cluster admiral watches"},
+		},
+		{
+			name: "Given a dependency removing a destination, " +
+				"When Simulate is called, " +
+				"Then nothing should change and a warning should be returned",
+			request: SimulationRequest{Dependency: &v1alpha1.Dependency{
+				Spec: model.Dependency{Source: "bar", Destinations: []string{}},
+			}},
+			expectedChanges:  []SimulatedChange{},
+			expectedWarnings: []string{"destination foo removed from the dependency, its resources are left in place in the clusters of bar"},
+		},
+		{
+			name: "Given a GTP adding a dns prefix, " +
+				"When Simulate is called, " +
+				"Then the default DRs should be updated and the resources of the prefix created",
+			request:     SimulationRequest{GlobalTrafficPolicy: gtp("default", "west")},
+			existingGtp: gtp("east"),
+			expectedChanges: []SimulatedChange{
+				{Operation: audit.OperationUpdate, Kind: "DestinationRule", Name: "stage.foo.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster1", Reason: "traffic policies of globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationCreate, Kind: "DestinationRule", Name: "west.stage.foo.global-dr", Namespace: "admiral-sync", Cluster: "cluster1", Reason: "dns prefix west added by globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "west.stage.foo.global-se", Namespace: "admiral-sync", Cluster: "cluster1", Reason: "dns prefix west added by globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationUpdate, Kind: "DestinationRule", Name: "stage.foo.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster2", Reason: "traffic policies of globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationCreate, Kind: "DestinationRule", Name: "west.stage.foo.global-dr", Namespace: "admiral-sync", Cluster: "cluster2", Reason: "dns prefix west added by globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "west.stage.foo.global-se", Namespace: "admiral-sync", Cluster: "cluster2", Reason: "dns prefix west added by globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationUpdate, Kind: "DestinationRule", Name: "stage.foo.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster3", Reason: "traffic policies of globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationCreate, Kind: "DestinationRule", Name: "west.stage.foo.global-dr", Namespace: "admiral-sync", Cluster: "cluster3", Reason: "dns prefix west added by globaltrafficpolicy foo-gtp"},
+				{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "west.stage.foo.global-se", Namespace: "admiral-sync", Cluster: "cluster3", Reason: "dns prefix west added by globaltrafficpolicy foo-gtp"},
+			},
+			expectedWarnings: []string{"dns prefix east removed from the globaltrafficpolicy, its resources are left in place"},
+		},
+		{
+			name: "Given the removal of a cluster, " +
+				"When Simulate is called, " +
+				"Then the SEs of identities running in other clusters should be updated and the others deleted",
+			request: SimulationRequest{RemovedCluster: "cluster3"},
+			expectedChanges: []SimulatedChange{
+				{Operation: audit.OperationDelete, Kind: "DestinationRule", Name: "stage.bar.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster1", Reason: "bar only runs in cluster cluster3"},
+				{Operation: audit.OperationDelete, Kind: "ServiceEntry", Name: "stage.bar.global-se", Namespace: "admiral-sync", Cluster: "cluster1", Reason: "bar only runs in cluster cluster3"},
+			},
+			expectedWarnings: []string{"resources in cluster cluster3 are left in place and no longer updated"},
+		},
+		{
+			name: "Given the removal of a cluster admiral does not watch, " +
+				"When Simulate is called, " +
+				"Then it should return an error",
+			request:     SimulationRequest{RemovedCluster: "cluster4"},
+			expectedErr: "admiral is not monitoring cluster cluster4",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := newSimulationTestRegistry()
+			if tc.existingGtp != nil {
+				assert.Nil(t, rr.AdmiralCache.GlobalTrafficCache.Put(tc.existingGtp))
+			}
+			result, err := Simulate(rr, tc.request)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedChanges, result.Changes)
+			assert.Equal(t, tc.expectedWarnings, result.Warnings)
+		})
+	}
+}
+
+func TestSimulateGlobalTrafficPolicyWeights(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			AdmiralCRDIdentityLabel: "identity",
+			EnvKey:                  "admiral.io/env",
+		},
+		SyncNamespace:              "admiral-sync",
+		EnableGTPWeightedEndpoints: true,
+	})
+	defer common.ResetSync()
+	gtp := func(weights ...int32) *v1alpha1.GlobalTrafficPolicy {
+		policy := &model.TrafficPolicy{LbType: model.TrafficPolicy_FAILOVER, DnsPrefix: "default"}
+		for i, region := range []string{"us-west-2", "us-east-2"} {
+			if i < len(weights) {
+				policy.Target = append(policy.Target, &model.TrafficGroup{Region: region, Weight: weights[i]})
+			}
+		}
+		return &v1alpha1.GlobalTrafficPolicy{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:   "foo-gtp",
+				Labels: map[string]string{"identity": "foo", "admiral.io/env": "stage"},
+			},
+			Spec: model.GlobalTrafficPolicy{Policy: []*model.TrafficPolicy{policy}},
+		}
+	}
+	newLiveRegistry := func() *RemoteRegistry {
+		rr := newSimulationTestRegistry()
+		drCache := istio.NewDestinationRuleCache()
+		drCache.Put(&v1alpha3.DestinationRule{
+			ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-default-dr", Namespace: "admiral-sync"},
+			Spec: networking.DestinationRule{TrafficPolicy: &networking.TrafficPolicy{LoadBalancer: &networking.LoadBalancerSettings{
+				LocalityLbSetting: &networking.LocalityLoadBalancerSetting{Distribute: []*networking.LocalityLoadBalancerSetting_Distribute{
+					{From: "us-west-2/*", To: map[string]uint32{"us-west-2": 100}},
+				}},
+			}}},
+		})
+		seCache := istio.NewServiceEntryCache()
+		seCache.Put(&v1alpha3.ServiceEntry{
+			ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "admiral-sync"},
+			Spec: networking.ServiceEntry{Endpoints: []*networking.WorkloadEntry{
+				{Address: "foo.west", Locality: "us-west-2", Weight: 10000},
+			}},
+		}, "cluster1")
+		vsCache := istio.NewVirtualServiceCache()
+		assert.Nil(t, vsCache.Put(&v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      "stage.foo.global-incluster-vs",
+				Namespace: "foo-ns",
+				Labels:    map[string]string{common.VSRoutingLabel: "enabled", common.VSRoutingType: common.VSRoutingTypeInCluster},
+			},
+		}))
+		rr.PutRemoteController("cluster1", &RemoteController{
+			ClusterID:                 "cluster1",
+			DestinationRuleController: &istio.DestinationRuleController{Cache: drCache},
+			ServiceEntryController:    &istio.ServiceEntryController{Cache: seCache},
+			VirtualServiceController:  &istio.VirtualServiceController{VirtualServiceCache: vsCache},
+		})
+		assert.Nil(t, rr.AdmiralCache.GlobalTrafficCache.Put(gtp(100)))
+		return rr
+	}
+
+	testCases := []struct {
+		name            string
+		gtp             *v1alpha1.GlobalTrafficPolicy
+		expectedChanges []SimulatedChange
+	}{
+		{
+			name: "Given a GTP moving traffic to another region, " +
+				"When Simulate is called, " +
+				"Then the live DR, SE and in-cluster VS should be updated with the weights of the regions",
+			gtp: gtp(80, 20),
+			expectedChanges: []SimulatedChange{
+				{Operation: audit.OperationUpdate, Kind: "DestinationRule", Name: "stage.foo.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster1",
+					Reason:  "traffic policies of globaltrafficpolicy foo-gtp",
+					Weights: []SimulatedWeight{{Region: "us-east-2", From: 0, To: 20}, {Region: "us-west-2", From: 100, To: 80}}},
+				{Operation: audit.OperationUpdate, Kind: "VirtualService", Name: "stage.foo.global-incluster-vs", Namespace: "foo-ns", Cluster: "cluster1",
+					Reason: "traffic policies of globaltrafficpolicy foo-gtp",
+					Weights: []SimulatedWeight{
+						{Host: "stage.foo.global", Region: "us-east-2", From: 0, To: 20},
+						{Host: "stage.foo.global", Region: "us-west-2", From: 100, To: 80},
+					}},
+				{Operation: audit.OperationUpdate, Kind: "ServiceEntry", Name: "stage.foo.global-se", Namespace: "admiral-sync", Cluster: "cluster1",
+					Reason:  "endpoints of stage.foo.global weighted by the traffic policies of globaltrafficpolicy foo-gtp",
+					Weights: []SimulatedWeight{{Region: "us-east-2", From: 0, To: 20}, {Region: "us-west-2", From: 100, To: 80}}},
+			},
+		},
+		{
+			name: "Given a GTP matching the live resources, " +
+				"When Simulate is called, " +
+				"Then nothing should change in the cluster",
+			gtp:             gtp(100),
+			expectedChanges: []SimulatedChange{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Simulate(newLiveRegistry(), SimulationRequest{GlobalTrafficPolicy: tc.gtp})
+			assert.Nil(t, err)
+			changes := []SimulatedChange{}
+			for _, change := range result.Changes {
+				if change.Cluster == "cluster1" {
+					changes = append(changes, change)
+				}
+			}
+			assert.Equal(t, tc.expectedChanges, changes)
+		})
+	}
+}
+
+func TestSimulatedChangeString(t *testing.T) {
+	change := SimulatedChange{
+		Operation: audit.OperationUpdate, Kind: "DestinationRule", Name: "stage.foo.global-default-dr", Namespace: "admiral-sync", Cluster: "cluster1",
+		Weights: []SimulatedWeight{{Region: "us-east-2", From: 0, To: 20}, {Region: "us-west-2", From: 100, To: 80}},
+	}
+	assert.Equal(t, "update DestinationRule admiral-sync/stage.foo.global-default-dr in cluster cluster1: us-east-2 0->20, us-west-2 100->80", change.String())
+}
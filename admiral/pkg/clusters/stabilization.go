@@ -0,0 +1,130 @@
+package clusters
+
+import (
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/sirupsen/logrus"
+	networking "istio.io/api/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+)
+
+// isWorkloadStabilizing checks if the cluster should be skipped while the service entries of the identity
+// are generated, as its deployment or rollout is being rolled out and is not fully available yet. Only
+// workloads already published in the cluster are skipped, for at most the configured stabilization window,
+// so that the endpoints and weights seen by the other clusters are not shifted or removed during a surge.
+// The cluster is processed again with the next status change of the workload
+func isWorkloadStabilizing(rr *RemoteRegistry, event admiral.EventType, clusterID, identityKey string,
+	deployment *k8sAppsV1.Deployment, rollout *argo.Rollout) bool {
+	if rr.AdmiralCache == nil || rr.AdmiralCache.WorkloadStabilizationCache == nil {
+		return false
+	}
+	key := clusterID + "/" + identityKey
+	window := common.GetWorkloadStabilizationWindow()
+	if window <= 0 || event == admiral.Delete || isWorkloadFullyAvailable(deployment, rollout) ||
+		!isWorkloadPublishedInCluster(rr, clusterID, deployment, rollout) {
+		rr.AdmiralCache.WorkloadStabilizationCache.Delete(key)
+		return false
+	}
+	since, _ := rr.AdmiralCache.WorkloadStabilizationCache.LoadOrStore(key, time.Now())
+	return time.Since(since.(time.Time)) < window
+}
+
+// isWorkloadFullyAvailable checks if all the replicas of the deployment and the rollout are updated and
+// available, and the replicas surged during their roll out are gone
+func isWorkloadFullyAvailable(deployment *k8sAppsV1.Deployment, rollout *argo.Rollout) bool {
+	if deployment != nil {
+		status := deployment.Status
+		desired := getDesiredReplicas(deployment.Spec.Replicas)
+		if status.ObservedGeneration < deployment.Generation || status.UpdatedReplicas < desired ||
+			status.AvailableReplicas < desired || status.Replicas > desired {
+			return false
+		}
+	}
+	if rollout != nil {
+		status := rollout.Status
+		desired := getDesiredReplicas(rollout.Spec.Replicas)
+		if status.UpdatedReplicas < desired || status.AvailableReplicas < desired || status.Replicas > desired {
+			return false
+		}
+	}
+	return true
+}
+
+func getDesiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// isWorkloadPublishedInCluster checks if admiral already generated the service entry of the workload
+// for the cluster, as workloads being onboarded have no endpoints to keep stable
+func isWorkloadPublishedInCluster(rr *RemoteRegistry, clusterID string, deployment *k8sAppsV1.Deployment, rollout *argo.Rollout) bool {
+	var cname string
+	if deployment != nil {
+		cname = common.GetCname(deployment, common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
+	} else if rollout != nil {
+		cname = common.GetCnameForRollout(rollout, common.GetWorkloadIdentifier(), common.GetHostnameSuffix())
+	}
+	clusters := rr.AdmiralCache.CnameClusterCache.Get(cname)
+	return clusters != nil && clusters.CheckIfPresent(clusterID)
+}
+
+// keepStabilizingClusterEndpoints adds to the generated service entries the endpoints currently published
+// for the clusters skipped as their workload is stabilizing, so that they are not removed from the other
+// clusters. The published endpoints are read from the copies of the service entries in the source clusters
+// which were processed, and are matched on the ingress addresses of the skipped cluster
+func keepStabilizingClusterEndpoints(ctxLogger *logrus.Entry, rr *RemoteRegistry, stabilizingClusters []string,
+	sourceClusters []string, serviceEntries map[string]*networking.ServiceEntry) {
+	for _, stabilizingCluster := range stabilizingClusters {
+		rc := rr.GetRemoteController(stabilizingCluster)
+		if rc == nil {
+			continue
+		}
+		ingressEndpoint, _, err := getOverwrittenLoadBalancer(ctxLogger, rc, stabilizingCluster, rr.AdmiralCache)
+		if err != nil {
+			ctxLogger.Warnf(common.CtxLogFormat, "KeepStabilizingClusterEndpoints", "", "", stabilizingCluster, err.Error())
+			continue
+		}
+		addresses := map[string]bool{ingressEndpoint: true}
+		for _, endpoint := range getClusterIngressEndpoints(rc, ingressEndpoint, getLocality(rc)) {
+			addresses[endpoint.Address] = true
+		}
+		for host, se := range serviceEntries {
+			published := getPublishedServiceEntry(rr, sourceClusters, getIstioResourceName(host, "-se"))
+			if published == nil {
+				continue
+			}
+			for _, endpoint := range published.Endpoints {
+				if addresses[endpoint.Address] && !hasEndpointWithAddress(se, endpoint.Address) {
+					se.Endpoints = append(se.Endpoints, endpoint.DeepCopy())
+				}
+			}
+		}
+	}
+}
+
+func getPublishedServiceEntry(rr *RemoteRegistry, clusters []string, seName string) *networking.ServiceEntry {
+	for _, cluster := range clusters {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil || rc.ServiceEntryController == nil || rc.ServiceEntryController.Cache == nil {
+			continue
+		}
+		if se := rc.ServiceEntryController.Cache.Get(seName, cluster); se != nil {
+			return &se.Spec
+		}
+	}
+	return nil
+}
+
+func hasEndpointWithAddress(se *networking.ServiceEntry, address string) bool {
+	for _, endpoint := range se.Endpoints {
+		if endpoint.Address == address {
+			return true
+		}
+	}
+	return false
+}
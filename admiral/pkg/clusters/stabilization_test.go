@@ -0,0 +1,230 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newStabilizationTestDeployment(replicas, updated, available, total int32) *k8sAppsV1.Deployment {
+	return &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns", Generation: 2},
+		Spec: k8sAppsV1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: coreV1.PodTemplateSpec{
+				ObjectMeta: metaV1.ObjectMeta{Labels: map[string]string{"identity": "foo", "env": "stage"}},
+			},
+		},
+		Status: k8sAppsV1.DeploymentStatus{
+			ObservedGeneration: 2,
+			UpdatedReplicas:    updated,
+			AvailableReplicas:  available,
+			Replicas:           total,
+		},
+	}
+}
+
+func TestIsWorkloadFullyAvailable(t *testing.T) {
+	notObserved := newStabilizationTestDeployment(2, 2, 2, 2)
+	notObserved.Status.ObservedGeneration = 1
+	rollout := makeTestRollout("foo", "foo-ns", "foo")
+	rollout.Status = argo.RolloutStatus{UpdatedReplicas: 1, AvailableReplicas: 1, Replicas: 1}
+	surgingRollout := makeTestRollout("foo", "foo-ns", "foo")
+	surgingRollout.Status = argo.RolloutStatus{UpdatedReplicas: 1, AvailableReplicas: 1, Replicas: 2}
+
+	testCases := []struct {
+		name       string
+		deployment *k8sAppsV1.Deployment
+		rollout    *argo.Rollout
+		expected   bool
+	}{
+		{
+			name: "Given a deployment with all its replicas updated and available, " +
+				"When isWorkloadFullyAvailable is called, " +
+				"Then it should return true",
+			deployment: newStabilizationTestDeployment(2, 2, 2, 2),
+			expected:   true,
+		},
+		{
+			name: "Given a deployment surging its replicas, " +
+				"When isWorkloadFullyAvailable is called, " +
+				"Then it should return false",
+			deployment: newStabilizationTestDeployment(2, 1, 2, 3),
+		},
+		{
+			name: "Given a deployment whose updated replicas are not available yet, " +
+				"When isWorkloadFullyAvailable is called, " +
+				"Then it should return false",
+			deployment: newStabilizationTestDeployment(2, 2, 1, 2),
+		},
+		{
+			name: "Given a deployment whose latest generation is not observed yet, " +
+				"When isWorkloadFullyAvailable is called, " +
+				"Then it should return false",
+			deployment: notObserved,
+		},
+		{
+			name: "Given a rollout with all its replicas updated and available, " +
+				"When isWorkloadFullyAvailable is called, " +
+				"Then it should return true",
+			rollout:  &rollout,
+			expected: true,
+		},
+		{
+			name: "Given a deployment migrating to a rollout surging its replicas, " +
+				"When isWorkloadFullyAvailable is called, " +
+				"Then it should return false",
+			deployment: newStabilizationTestDeployment(2, 2, 2, 2),
+			rollout:    &surgingRollout,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isWorkloadFullyAvailable(tc.deployment, tc.rollout))
+		})
+	}
+}
+
+func TestIsWorkloadStabilizing(t *testing.T) {
+	admiralParams := common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey: "identity",
+			EnvKey:              "env",
+		},
+		HostnameSuffix: "global",
+	}
+	surging := newStabilizationTestDeployment(2, 1, 2, 3)
+	cname := common.GetCname(surging, "identity", "global")
+
+	testCases := []struct {
+		name       string
+		window     time.Duration
+		event      admiral.EventType
+		deployment *k8sAppsV1.Deployment
+		published  bool
+		since      time.Time
+		expected   bool
+	}{
+		{
+			name: "Given the stabilization window is disabled, " +
+				"When a published deployment is surging, " +
+				"Then the update should not be deferred",
+			event:      admiral.Update,
+			deployment: surging,
+			published:  true,
+		},
+		{
+			name: "Given a published deployment surging within the stabilization window, " +
+				"When isWorkloadStabilizing is called, " +
+				"Then the update should be deferred",
+			window:     time.Minute,
+			event:      admiral.Update,
+			deployment: surging,
+			published:  true,
+			expected:   true,
+		},
+		{
+			name: "Given a published deployment surging for longer than the stabilization window, " +
+				"When isWorkloadStabilizing is called, " +
+				"Then the update should not be deferred",
+			window:     time.Minute,
+			event:      admiral.Update,
+			deployment: surging,
+			published:  true,
+			since:      time.Now().Add(-2 * time.Minute),
+		},
+		{
+			name: "Given a deployment surging before it is published, " +
+				"When isWorkloadStabilizing is called, " +
+				"Then the update should not be deferred",
+			window:     time.Minute,
+			event:      admiral.Add,
+			deployment: surging,
+		},
+		{
+			name: "Given a published deployment which is fully available, " +
+				"When isWorkloadStabilizing is called, " +
+				"Then the update should not be deferred",
+			window:     time.Minute,
+			event:      admiral.Update,
+			deployment: newStabilizationTestDeployment(2, 2, 2, 2),
+			published:  true,
+		},
+		{
+			name: "Given a published deployment surging within the stabilization window, " +
+				"When it is deleted, " +
+				"Then the delete should not be deferred",
+			window:     time.Minute,
+			event:      admiral.Delete,
+			deployment: surging,
+			published:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			admiralParams.WorkloadStabilizationWindow = tc.window
+			common.ResetSync()
+			common.InitializeConfig(admiralParams)
+			rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+			if tc.published {
+				rr.AdmiralCache.CnameClusterCache.Put(cname, "cluster1", "cluster1")
+			}
+			if !tc.since.IsZero() {
+				rr.AdmiralCache.WorkloadStabilizationCache.Store("cluster1/stage.foo", tc.since)
+			}
+			assert.Equal(t, tc.expected, isWorkloadStabilizing(rr, tc.event, "cluster1", "stage.foo", tc.deployment, nil))
+		})
+	}
+}
+
+func TestKeepStabilizingClusterEndpoints(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{GatewayApp: "istio-ingressgateway"}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	stabilizingRC := newIngressTestRemoteController(t,
+		newIngressTestService("istio-ingressgateway", "istio-ingressgateway", "lb.cluster1.com", nil))
+	stabilizingRC.NodeController = &admiral.NodeController{}
+	rr.PutRemoteController("cluster1", stabilizingRC)
+	cache := istio.NewServiceEntryCache()
+	cache.Put(&v1alpha3.ServiceEntry{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-se"},
+		Spec: networking.ServiceEntry{
+			Hosts: []string{"stage.foo.global"},
+			Endpoints: []*networking.WorkloadEntry{
+				{Address: "foo.foo-ns.svc.cluster.local", Locality: "us-west-2", Ports: map[string]uint32{"http": 8080}},
+				{Address: "lb.cluster1.com", Locality: "us-east-2", Ports: map[string]uint32{"http": 15443}},
+				{Address: "lb.cluster3.com", Locality: "us-east-1", Ports: map[string]uint32{"http": 15443}},
+			},
+		},
+	}, "cluster2")
+	rr.PutRemoteController("cluster2", &RemoteController{ClusterID: "cluster2", ServiceEntryController: &istio.ServiceEntryController{Cache: cache}})
+	serviceEntries := map[string]*networking.ServiceEntry{
+		"stage.foo.global": {
+			Hosts:     []string{"stage.foo.global"},
+			Endpoints: []*networking.WorkloadEntry{{Address: "lb.cluster2.com", Locality: "us-west-2", Ports: map[string]uint32{"http": 15443}}},
+		},
+	}
+
+	keepStabilizingClusterEndpoints(logrus.WithContext(context.Background()), rr, []string{"cluster1"}, []string{"cluster2"}, serviceEntries)
+
+	var addresses []string
+	for _, endpoint := range serviceEntries["stage.foo.global"].Endpoints {
+		addresses = append(addresses, endpoint.Address+"/"+endpoint.Locality)
+	}
+	assert.Equal(t, []string{"lb.cluster2.com/us-west-2", "lb.cluster1.com/us-east-2"}, addresses, "only the published endpoints of the stabilizing cluster should be kept")
+
+	keepStabilizingClusterEndpoints(logrus.WithContext(context.Background()), rr, []string{"cluster1"}, []string{"cluster2"}, serviceEntries)
+	assert.Equal(t, 2, len(serviceEntries["stage.foo.global"].Endpoints), "the endpoints should not be added twice")
+}
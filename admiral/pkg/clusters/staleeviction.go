@@ -0,0 +1,123 @@
+package clusters
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+// startStaleCacheEviction periodically evicts the cache entries of the identities gone from all the clusters
+// for longer than the ttl, once the cache warm up is over
+func startStaleCacheEviction(ctx context.Context, rr *RemoteRegistry) {
+	ttl := common.GetCacheEntryTTL()
+	if ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if IsCacheWarmupTime(rr) {
+				continue
+			}
+			evictStaleCacheEntries(rr, ttl)
+		}
+	}
+}
+
+// evictStaleCacheEntries records the time each identity of the cache was last seen in a cluster, and evicts
+// the entries of the identity, and of its cnames, once it was not seen for longer than the ttl. An identity
+// last seen in a cluster which is unreachable or no longer monitored is kept, as it may only be hidden by the
+// outage, and its ttl restarts once the cluster is back. It returns the evicted identities
+func evictStaleCacheEntries(rr *RemoteRegistry, ttl time.Duration) []string {
+	cache := rr.AdmiralCache
+	if cache == nil || cache.IdentityClusterCache == nil || cache.IdentityLastSeenCache == nil {
+		return nil
+	}
+	now := time.Now()
+	var stale []string
+	for _, identity := range cache.IdentityClusterCache.GetKeys() {
+		seen, known := isIdentitySeen(rr, identity)
+		if seen || !known {
+			cache.IdentityLastSeenCache.Store(identity, now)
+			continue
+		}
+		lastSeen, _ := cache.IdentityLastSeenCache.LoadOrStore(identity, now)
+		if now.Sub(lastSeen.(time.Time)) < ttl {
+			continue
+		}
+		stale = append(stale, identity)
+	}
+	sort.Strings(stale)
+	for _, identity := range stale {
+		evictIdentity(cache, identity)
+	}
+	return stale
+}
+
+// isIdentitySeen checks if a workload of the identity, including a job or a vertex, runs in any of the clusters
+// it was last known in. known is false when one of those clusters is unreachable or no longer monitored, so
+// the absence of the identity cannot be told apart from the outage
+func isIdentitySeen(rr *RemoteRegistry, identity string) (seen bool, known bool) {
+	clusters := rr.AdmiralCache.IdentityClusterCache.Get(identity)
+	if clusters == nil {
+		return false, true
+	}
+	known = true
+	for _, cluster := range clusters.GetKeys() {
+		if rr.AdmiralCache.UnreachableClusterCache != nil {
+			if _, unreachable := rr.AdmiralCache.UnreachableClusterCache.Load(cluster); unreachable {
+				known = false
+				continue
+			}
+		}
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			known = false
+			continue
+		}
+		if hasIdentityWorkload(rc, identity) {
+			return true, true
+		}
+	}
+	return false, known
+}
+
+// evictIdentity drops the identity, and its cnames, from the caches tracking where they run and depend on.
+// The dependencies of the identity are kept, as they come from its dependency records
+func evictIdentity(cache *AdmiralCache, identity string) {
+	for _, cname := range getIdentityHosts(cache, identity, "") {
+		cache.CnameIdentityCache.Delete(cname)
+		if cache.CnameClusterCache != nil {
+			cache.CnameClusterCache.Delete(cname)
+		}
+		if cache.CnameDependentClusterCache != nil {
+			cache.CnameDependentClusterCache.Delete(cname)
+		}
+		if cache.CnameDependentClusterNamespaceCache != nil {
+			cache.CnameDependentClusterNamespaceCache.Delete(cname)
+		}
+		evictedCacheEntries.Increment(api.WithAttributes(
+			attribute.Key("kind").String("cname"),
+		))
+		log.Infof(LogFormat, common.StaleCacheEviction, "cname", cname, "", "evicted as identity="+identity+" is gone from all the clusters")
+	}
+	cache.IdentityClusterCache.Delete(identity)
+	if cache.IdentityClusterNamespaceCache != nil {
+		cache.IdentityClusterNamespaceCache.Delete(identity)
+	}
+	cache.IdentityLastSeenCache.Delete(identity)
+	forgetIdentityTeam(identity)
+	evictedCacheEntries.Increment(api.WithAttributes(
+		attribute.Key("kind").String("identity"),
+	))
+	log.Infof(LogFormat, common.StaleCacheEviction, "identity", identity, "", "evicted as it is gone from all the clusters")
+}
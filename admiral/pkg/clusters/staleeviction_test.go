@@ -0,0 +1,91 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEvictStaleCacheEntries(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	cache := rr.AdmiralCache
+
+	// foo is deployed in cluster1, bar is gone from cluster1, baz was in cluster2 which is unreachable
+	deployments := admiral.NewDeploymentCache()
+	deployments.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-stage"}})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: deployments},
+	})
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:            "cluster2",
+		DeploymentController: &admiral.DeploymentController{Cache: admiral.NewDeploymentCache()},
+	})
+	cache.UnreachableClusterCache.Store("cluster2", time.Now())
+	for identity, cluster := range map[string]string{"foo": "cluster1", "bar": "cluster1", "baz": "cluster2"} {
+		cache.IdentityClusterCache.Put(identity, cluster, cluster)
+		cache.IdentityClusterNamespaceCache.Put(identity, cluster, identity+"-stage", identity+"-stage")
+		cache.CnameIdentityCache.Store("stage."+identity+".global", identity)
+		cache.CnameClusterCache.Put("stage."+identity+".global", cluster, cluster)
+		cache.CnameDependentClusterCache.Put("stage."+identity+".global", "cluster3", "cluster3")
+	}
+	cache.IdentityDependencyCache.Put("bar", "foo", "foo")
+
+	// the first pass records bar as last seen now
+	assert.Empty(t, evictStaleCacheEntries(rr, time.Hour))
+	assert.NotNil(t, cache.IdentityClusterCache.Get("bar"))
+
+	// bar is evicted once it was not seen for longer than the ttl
+	cache.IdentityLastSeenCache.Store("bar", time.Now().Add(-2*time.Hour))
+	cache.IdentityLastSeenCache.Store("baz", time.Now().Add(-2*time.Hour))
+	assert.Equal(t, []string{"bar"}, evictStaleCacheEntries(rr, time.Hour))
+	assert.Nil(t, cache.IdentityClusterCache.Get("bar"))
+	assert.Nil(t, cache.IdentityClusterNamespaceCache.Get("bar"))
+	_, ok := cache.CnameIdentityCache.Load("stage.bar.global")
+	assert.False(t, ok)
+	assert.Nil(t, cache.CnameClusterCache.Get("stage.bar.global"))
+	assert.Nil(t, cache.CnameDependentClusterCache.Get("stage.bar.global"))
+	_, ok = cache.IdentityLastSeenCache.Load("bar")
+	assert.False(t, ok)
+	// the dependencies of bar come from its dependency records
+	assert.NotNil(t, cache.IdentityDependencyCache.Get("bar"))
+
+	// foo is deployed and baz is hidden by the outage of its cluster, which restarts its ttl
+	assert.NotNil(t, cache.IdentityClusterCache.Get("foo"))
+	assert.NotNil(t, cache.IdentityClusterCache.Get("baz"))
+	lastSeen, _ := cache.IdentityLastSeenCache.Load("baz")
+	assert.WithinDuration(t, time.Now(), lastSeen.(time.Time), time.Minute)
+}
+
+func TestEvictStaleCacheEntriesKeepsJobClients(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{WorkloadIdentityKey: "identity"}})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	cache := rr.AdmiralCache
+
+	// client only runs as a job in cluster1, which has no deployment
+	jobs := admiral.NewJobCache()
+	jobs.Put(&common.K8sObject{Name: "client", Namespace: "client-ns", Labels: map[string]string{"identity": "client"}})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: admiral.NewDeploymentCache()},
+		JobController:        &admiral.JobController{Cache: jobs},
+	})
+	cache.IdentityClusterCache.Put("client", "cluster1", "cluster1")
+	cache.IdentityClusterCache.Put("gone", "cluster1", "cluster1")
+	cache.IdentityLastSeenCache.Store("client", time.Now().Add(-2*time.Hour))
+	cache.IdentityLastSeenCache.Store("gone", time.Now().Add(-2*time.Hour))
+
+	assert.Equal(t, []string{"gone"}, evictStaleCacheEntries(rr, time.Hour))
+	assert.NotNil(t, cache.IdentityClusterCache.Get("client"))
+	lastSeen, _ := cache.IdentityLastSeenCache.Load("client")
+	assert.WithinDuration(t, time.Now(), lastSeen.(time.Time), time.Minute)
+}
@@ -0,0 +1,90 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// startupBarrierCheckInterval is the interval at which the caches are checked while the startup barrier is raised
+var startupBarrierCheckInterval = time.Second
+
+// writeBarrier holds the writes to every cluster while it is raised, the writes are queued the same
+// way as the writes to a paused cluster and replayed when the barrier is lifted
+type writeBarrier struct {
+	lock   sync.Mutex
+	raised bool
+}
+
+// startupBarrier keeps admiral from writing to the clusters at startup until the dependency and
+// identity caches are built, so that the early events are not applied with incomplete dependency data
+var startupBarrier = &writeBarrier{}
+
+func raiseStartupBarrier() {
+	startupBarrier.lock.Lock()
+	defer startupBarrier.lock.Unlock()
+	startupBarrier.raised = true
+	log.Infof(LogFormat, common.StartupBarrier, "", "", "", "writes held until the caches are built")
+}
+
+func isStartupBarrierRaised() bool {
+	startupBarrier.lock.Lock()
+	defer startupBarrier.lock.Unlock()
+	return startupBarrier.raised
+}
+
+// liftStartupBarrier lets admiral write to the clusters again, and replays the writes queued while the
+// barrier was raised to the clusters which are not paused
+func liftStartupBarrier(rr *RemoteRegistry, reason string) {
+	startupBarrier.lock.Lock()
+	if !startupBarrier.raised {
+		startupBarrier.lock.Unlock()
+		return
+	}
+	startupBarrier.raised = false
+	startupBarrier.lock.Unlock()
+	log.Infof(LogFormat, common.StartupBarrier, "", "", "", "writes resumed as "+reason)
+	if !admiral.IsWriteStoppedForMaintenance() {
+		replayUnpausedClusterWrites(rr)
+	}
+}
+
+// awaitStartupBarrier lifts the startup barrier once the caches are built, or once the timeout
+// elapses so that a cluster which never syncs does not hold the writes to the others forever
+func awaitStartupBarrier(ctx context.Context, rr *RemoteRegistry, timeout time.Duration) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(startupBarrierCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			log.Warnf(LogFormat, common.StartupBarrier, "", "", "", "caches not built after "+timeout.String())
+			liftStartupBarrier(rr, "the startup barrier timed out")
+			return
+		case <-ticker.C:
+			if areStartupCachesBuilt(rr) {
+				liftStartupBarrier(rr, "the caches are built")
+				return
+			}
+		}
+	}
+}
+
+// areStartupCachesBuilt checks if the informer caches of every registered cluster have synced and the
+// events of their initial listing, which build the identity and dependent cluster caches, have been processed
+func areStartupCachesBuilt(rr *RemoteRegistry) bool {
+	if len(rr.GetClusterIds()) == 0 || IsCacheWarmupTime(rr) {
+		return false
+	}
+	if rr.eventCoalescer != nil && rr.eventCoalescer.hasPendingPasses() {
+		return false
+	}
+	return admiral.AreCachesSettled()
+}
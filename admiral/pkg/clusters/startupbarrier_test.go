@@ -0,0 +1,63 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	apiNetworkingV1Alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStartupBarrier(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{},
+		SyncNamespace: "ns",
+	})
+	ctx := context.Background()
+	ctxLogger := log.WithFields(log.Fields{"txId": "abc"})
+	cluster := "cluster1"
+	namespace := "ns"
+	istioClient := istioFake.NewSimpleClientset()
+	rc := &RemoteController{
+		ClusterID:                cluster,
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	defer clearClusterWritePause(cluster)
+
+	vs := &apiNetworkingV1Alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-vs", Namespace: namespace},
+		Spec:       networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+	}
+
+	raiseStartupBarrier()
+	assert.True(t, isStartupBarrierRaised())
+	// writes are queued while the barrier is raised
+	assert.Nil(t, addUpdateVirtualService(ctxLogger, ctx, vs, nil, namespace, rc, rr))
+	vsList, err := istioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, vsList.Items)
+	// the caches are not built as long as no cluster is registered
+	assert.False(t, areStartupCachesBuilt(rr))
+
+	rr.PutRemoteController(cluster, rc)
+	rr.eventCoalescer.passes["foo"+common.Sep+"stage"] = &coalescedPass{pending: true}
+	// the caches are not built as long as the deferred events of an identity have not been processed
+	assert.False(t, areStartupCachesBuilt(rr))
+
+	// the barrier is lifted once the timeout elapses, and the queued writes are replayed
+	awaitStartupBarrier(ctx, rr, 10*time.Millisecond)
+	assert.False(t, isStartupBarrierRaised())
+	vsList, err = istioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vsList.Items))
+	assert.Empty(t, GetClusterWritePauseStates())
+}
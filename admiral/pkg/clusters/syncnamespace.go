@@ -0,0 +1,214 @@
+package clusters
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// stampSyncNamespaceLabel labels the resource admiral is about to write to the sync namespace of the cluster,
+// or to a namespace identities are allowed to request, so that it can be found and removed once the cluster
+// is mapped to another sync namespace, or its identity requests another namespace
+func stampSyncNamespaceLabel(obj metaV1.Object, clusterID, namespace string) {
+	syncNamespaceOf := common.AdmiralSyncNamespaceOfCluster
+	if namespace == "" {
+		return
+	} else if namespace != common.GetSyncNamespaceForCluster(clusterID) {
+		if !isIdentitySyncNamespace(namespace) {
+			return
+		}
+		syncNamespaceOf = common.AdmiralSyncNamespaceOfIdentity
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[common.AdmiralSyncNamespaceOfLabel] = syncNamespaceOf
+	obj.SetLabels(labels)
+}
+
+// getStaleSyncNamespaces returns the namespaces the cluster was mapped to before, found from the ServiceEntries
+// labeled as written to the sync namespace of the cluster. The current sync namespace of the cluster, the
+// operator sync namespace and the namespaces requested by the identities are never stale
+func getStaleSyncNamespaces(ctx context.Context, rr *RemoteRegistry, rc *RemoteController) ([]string, error) {
+	if rc.ServiceEntryController == nil {
+		return nil, nil
+	}
+	serviceEntries, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(metaV1.NamespaceAll).List(ctx, clusterSyncNamespaceListOptions())
+	if err != nil {
+		return nil, err
+	}
+	excluded := getSyncNamespacesForCluster(rr.AdmiralCache, rc.ClusterID)
+	if operatorSyncNamespace := common.GetOperatorSyncNamespace(); operatorSyncNamespace != "" {
+		excluded = append(excluded, operatorSyncNamespace)
+	}
+	staleSyncNamespaces := make([]string, 0)
+	for _, serviceEntry := range serviceEntries.Items {
+		namespace := serviceEntry.Namespace
+		if !isGeneratedByAdmiral(serviceEntry.Annotations) || slices.Contains(excluded, namespace) ||
+			isIdentitySyncNamespace(namespace) || slices.Contains(staleSyncNamespaces, namespace) {
+			continue
+		}
+		staleSyncNamespaces = append(staleSyncNamespaces, namespace)
+	}
+	sort.Strings(staleSyncNamespaces)
+	return staleSyncNamespaces, nil
+}
+
+// clusterSyncNamespaceListOptions selects the resources labeled as written to the sync namespace of the cluster
+func clusterSyncNamespaceListOptions() metaV1.ListOptions {
+	return metaV1.ListOptions{LabelSelector: common.AdmiralSyncNamespaceOfLabel + "=" + common.AdmiralSyncNamespaceOfCluster}
+}
+
+// startSyncNamespaceMigration waits for the cache warm up to complete, by which time the
+// resources have been written to the cluster's current sync namespace, and then removes
+// the ones admiral generated in the namespaces the cluster is no longer mapped to. Nothing
+// is removed unless the cluster was mapped to another sync namespace before the restart
+func startSyncNamespaceMigration(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	if common.IsAdmiralOperatorMode() {
+		return
+	}
+	timer := time.NewTimer(time.Until(rr.StartTime.Add(common.GetAdmiralParams().CacheReconcileDuration)))
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return
+	case <-timer.C:
+	}
+	if commonUtil.IsAdmiralReadOnly() {
+		log.Infof(LogFormat, "SyncNamespaceMigration", "", "", clusterID, "skipped as Admiral is in Read-only mode")
+		return
+	}
+	// the remote controller is looked up once the warm up completed, as it is replaced when its
+	// istio controllers are started or stopped
+	rc := rr.GetRemoteController(clusterID)
+	if rc == nil {
+		return
+	}
+	migrateStaleSyncNamespaces(context.Background(), rr, rc)
+}
+
+// migrateChangedSyncNamespaces moves the resources of the clusters whose sync namespace was changed through
+// the dynamic config. The resources of every identity are written again first, so that they are in the new
+// sync namespaces before they are removed from the stale ones
+func migrateChangedSyncNamespaces(ctx context.Context, rr *RemoteRegistry) {
+	if common.IsAdmiralOperatorMode() {
+		return
+	}
+	clusters := getChangedSyncNamespaceClusters(common.GetAdmiralParams().ClusterSyncNamespaces, &rr.AdmiralCache.ClusterSyncNamespaces)
+	if len(clusters) == 0 {
+		return
+	}
+	log.Infof(LogFormat, "SyncNamespaceMigration", "", "", strings.Join(clusters, ","), "sync namespaces changed")
+	refreshAllIdentities(rr, modifyServiceEntryForWorkloadEvent)
+	for _, clusterID := range clusters {
+		rc := rr.GetRemoteController(clusterID)
+		if rc == nil {
+			continue
+		}
+		migrateStaleSyncNamespaces(ctx, rr, rc)
+	}
+}
+
+// getChangedSyncNamespaceClusters returns the clusters whose sync namespace mapping was added, removed or
+// changed since the last call, and replaces the cached mappings with the updated ones
+func getChangedSyncNamespaceClusters(updated map[string]string, cache *map[string]string) []string {
+	clusters := make([]string, 0)
+	for cluster, syncNamespace := range updated {
+		if (*cache)[cluster] != syncNamespace {
+			clusters = append(clusters, cluster)
+		}
+	}
+	for cluster := range *cache {
+		if _, ok := updated[cluster]; !ok {
+			clusters = append(clusters, cluster)
+		}
+	}
+	*cache = updated
+	sort.Strings(clusters)
+	return clusters
+}
+
+// migrateStaleSyncNamespaces removes the resources admiral generated in the stale sync namespaces of the cluster
+func migrateStaleSyncNamespaces(ctx context.Context, rr *RemoteRegistry, rc *RemoteController) {
+	staleSyncNamespaces, err := getStaleSyncNamespaces(ctx, rr, rc)
+	if err != nil {
+		log.Errorf(LogErrFormat, "SyncNamespaceMigration", common.ServiceEntryResourceType, "", rc.ClusterID, err)
+		return
+	}
+	if len(staleSyncNamespaces) == 0 {
+		return
+	}
+	migrateSyncNamespace(ctx, rc, staleSyncNamespaces)
+}
+
+// migrateSyncNamespace deletes the ServiceEntries, DestinationRules and VirtualServices
+// generated by admiral for the cluster in its stale sync namespaces
+func migrateSyncNamespace(ctx context.Context, rc *RemoteController, staleSyncNamespaces []string) {
+	deleted := 0
+	for _, namespace := range staleSyncNamespaces {
+		if rc.ServiceEntryController != nil {
+			serviceEntries, err := rc.ServiceEntryController.IstioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(ctx, clusterSyncNamespaceListOptions())
+			if err != nil {
+				log.Errorf(LogErrFormat, "SyncNamespaceMigration", common.ServiceEntryResourceType, namespace, rc.ClusterID, err)
+			} else {
+				for _, serviceEntry := range serviceEntries.Items {
+					if isGeneratedByAdmiral(serviceEntry.Annotations) {
+						err := deleteServiceEntry(ctx, serviceEntry, namespace, rc)
+						logSyncNamespaceMigration(common.ServiceEntryResourceType, serviceEntry.Name, namespace, rc.ClusterID, err)
+						if err == nil {
+							deleted++
+						}
+					}
+				}
+			}
+		}
+		if rc.DestinationRuleController != nil {
+			destinationRules, err := rc.DestinationRuleController.IstioClient.NetworkingV1alpha3().DestinationRules(namespace).List(ctx, clusterSyncNamespaceListOptions())
+			if err != nil {
+				log.Errorf(LogErrFormat, "SyncNamespaceMigration", common.DestinationRuleResourceType, namespace, rc.ClusterID, err)
+			} else {
+				for _, destinationRule := range destinationRules.Items {
+					if isGeneratedByAdmiral(destinationRule.Annotations) {
+						err := deleteDestinationRule(ctx, destinationRule, namespace, rc)
+						logSyncNamespaceMigration(common.DestinationRuleResourceType, destinationRule.Name, namespace, rc.ClusterID, err)
+						if err == nil {
+							deleted++
+						}
+					}
+				}
+			}
+		}
+		if rc.VirtualServiceController != nil {
+			virtualServices, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, clusterSyncNamespaceListOptions())
+			if err != nil {
+				log.Errorf(LogErrFormat, "SyncNamespaceMigration", common.VirtualServiceResourceType, namespace, rc.ClusterID, err)
+			} else {
+				for _, virtualService := range virtualServices.Items {
+					if isGeneratedByAdmiral(virtualService.Annotations) {
+						err := deleteVirtualService(ctx, virtualService.Name, namespace, rc)
+						logSyncNamespaceMigration(common.VirtualServiceResourceType, virtualService.Name, namespace, rc.ClusterID, err)
+						if err == nil {
+							deleted++
+						}
+					}
+				}
+			}
+		}
+	}
+	notifyGarbageCollection("SyncNamespaceMigration", rc.ClusterID, deleted)
+}
+
+// logSyncNamespaceMigration records a successful migration, failures are logged by the delete functions
+func logSyncNamespaceMigration(resourceType common.ResourceType, name, namespace, cluster string, err error) {
+	if err == nil {
+		log.Infof(LogFormat, "SyncNamespaceMigration", resourceType, name, cluster, "deleted from stale sync namespace="+namespace)
+	}
+}
@@ -0,0 +1,160 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetStaleSyncNamespaces(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "admiral-sync",
+		ClusterSyncNamespaces:         map[string]string{"cluster1": "mesh-sync"},
+		OperatorSyncNamespace:         "operator-sync",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	})
+	admiralAnnotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	clusterLabels := map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster}
+	newSE := func(namespace string, labels map[string]string) *v1alpha3.ServiceEntry {
+		return &v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "admiral-se", Namespace: namespace, Annotations: admiralAnnotations, Labels: labels}}
+	}
+	istioClient := istioFake.NewSimpleClientset(
+		newSE("mesh-sync", clusterLabels),
+		newSE("admiral-sync", clusterLabels),
+		newSE("removed-sync", clusterLabels),
+		newSE("team-sync", clusterLabels),
+		newSE("operator-sync", clusterLabels),
+		newSE("other-ns", nil),
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "custom-se", Namespace: "custom-ns", Labels: clusterLabels}},
+	)
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentitySyncNamespaceCache.Put("foo", "team-sync")
+	rc := &RemoteController{ClusterID: "cluster1", ServiceEntryController: &istio.ServiceEntryController{IstioClient: istioClient}}
+
+	staleSyncNamespaces, err := getStaleSyncNamespaces(context.Background(), rr, rc)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"admiral-sync", "removed-sync"}, staleSyncNamespaces,
+		"only the namespaces holding resources labeled for a previous mapping of the cluster should be stale")
+
+	rc.ClusterID = "cluster2"
+	staleSyncNamespaces, err = getStaleSyncNamespaces(context.Background(), rr, rc)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mesh-sync", "removed-sync"}, staleSyncNamespaces)
+}
+
+func TestMigrateStaleSyncNamespacesWithUnchangedMapping(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "admiral-sync",
+		OperatorSyncNamespace:         "operator-sync",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	})
+	admiralAnnotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	clusterLabels := map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "admiral-se", Namespace: "admiral-sync", Annotations: admiralAnnotations, Labels: clusterLabels}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "operator-se", Namespace: "operator-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "identity-se", Namespace: "team-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{Name: "identity-dr", Namespace: "team-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "identity-vs", Namespace: "team-sync", Annotations: admiralAnnotations}},
+	)
+	// the identity requesting team-sync is not known yet, as when the migration runs before it is processed again
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient},
+		VirtualServiceController:  &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	ctx := context.Background()
+
+	migrateStaleSyncNamespaces(ctx, rr, rc)
+
+	for _, namespace := range []string{"admiral-sync", "operator-sync", "team-sync"} {
+		serviceEntries, err := istioClient.NetworkingV1alpha3().ServiceEntries(namespace).List(ctx, metaV1.ListOptions{})
+		assert.Nil(t, err)
+		assert.Len(t, serviceEntries.Items, 1, "nothing should be deleted from "+namespace)
+	}
+	destinationRules, err := istioClient.NetworkingV1alpha3().DestinationRules("team-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, destinationRules.Items, 1)
+	virtualServices, err := istioClient.NetworkingV1alpha3().VirtualServices("team-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, virtualServices.Items, 1)
+}
+
+func TestStampSyncNamespaceLabel(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:              &common.LabelSet{},
+		SyncNamespace:         "admiral-sync",
+		ClusterSyncNamespaces: map[string]string{"cluster1": "mesh-sync"},
+	})
+	se := &v1alpha3.ServiceEntry{}
+	stampSyncNamespaceLabel(se, "cluster1", "admiral-sync")
+	assert.Empty(t, se.Labels, "resources written outside the sync namespace of the cluster should not be labeled")
+	stampSyncNamespaceLabel(se, "cluster1", "mesh-sync")
+	assert.Equal(t, map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster}, se.Labels)
+}
+
+func TestGetChangedSyncNamespaceClusters(t *testing.T) {
+	cache := map[string]string{"cluster1": "mesh-sync", "cluster2": "mesh-sync"}
+
+	clusters := getChangedSyncNamespaceClusters(map[string]string{"cluster1": "mesh-sync", "cluster2": "other-sync", "cluster3": "mesh-sync"}, &cache)
+	assert.Equal(t, []string{"cluster2", "cluster3"}, clusters)
+	assert.Equal(t, map[string]string{"cluster1": "mesh-sync", "cluster2": "other-sync", "cluster3": "mesh-sync"}, cache)
+
+	clusters = getChangedSyncNamespaceClusters(map[string]string{"cluster2": "other-sync", "cluster3": "mesh-sync"}, &cache)
+	assert.Equal(t, []string{"cluster1"}, clusters, "clusters moved back to the global sync namespace should be migrated")
+
+	clusters = getChangedSyncNamespaceClusters(map[string]string{"cluster2": "other-sync", "cluster3": "mesh-sync"}, &cache)
+	assert.Empty(t, clusters)
+}
+
+func TestMigrateSyncNamespace(t *testing.T) {
+	admiralAnnotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	clusterLabels := map[string]string{common.AdmiralSyncNamespaceOfLabel: common.AdmiralSyncNamespaceOfCluster}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "admiral-se", Namespace: "admiral-sync", Annotations: admiralAnnotations, Labels: clusterLabels}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "custom-se", Namespace: "admiral-sync"}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "unlabeled-se", Namespace: "admiral-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.ServiceEntry{ObjectMeta: metaV1.ObjectMeta{Name: "admiral-se", Namespace: "mesh-sync", Annotations: admiralAnnotations, Labels: clusterLabels}},
+		&v1alpha3.DestinationRule{ObjectMeta: metaV1.ObjectMeta{Name: "admiral-dr", Namespace: "admiral-sync", Annotations: admiralAnnotations, Labels: clusterLabels}},
+		&v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "admiral-vs", Namespace: "admiral-sync", Annotations: admiralAnnotations, Labels: clusterLabels}},
+	)
+	rc := &RemoteController{
+		ClusterID:                 "cluster1",
+		ServiceEntryController:    &istio.ServiceEntryController{IstioClient: istioClient},
+		DestinationRuleController: &istio.DestinationRuleController{IstioClient: istioClient},
+		VirtualServiceController:  &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	ctx := context.Background()
+
+	migrateSyncNamespace(ctx, rc, []string{"admiral-sync"})
+
+	serviceEntries, err := istioClient.NetworkingV1alpha3().ServiceEntries("admiral-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, serviceEntries.Items, 2, "the resources not labeled for the cluster sync namespace should be kept")
+	assert.Equal(t, "custom-se", serviceEntries.Items[0].Name)
+	assert.Equal(t, "unlabeled-se", serviceEntries.Items[1].Name)
+	serviceEntries, err = istioClient.NetworkingV1alpha3().ServiceEntries("mesh-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, serviceEntries.Items, 1)
+	destinationRules, err := istioClient.NetworkingV1alpha3().DestinationRules("admiral-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, destinationRules.Items, 0)
+	virtualServices, err := istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, virtualServices.Items, 0)
+}
@@ -0,0 +1,104 @@
+package clusters
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+// identityTeamRegistry keeps track of the team the team label of the workloads of each identity names
+type identityTeamRegistry struct {
+	lock  sync.RWMutex
+	teams map[string]string
+}
+
+// identityTeams is shared by every remote controller, as an identity belongs to the same team in every cluster
+var identityTeams = &identityTeamRegistry{teams: make(map[string]string)}
+
+// recordIdentityTeam keeps the team the team label, or annotation, of a workload of the identity names. The team
+// of the identity is forgotten when the label was removed, so that it falls back to its identity prefix
+func recordIdentityTeam(identity string, annotations, labels map[string]string) {
+	label := common.GetTeamLabel()
+	if label == "" || identity == "" {
+		return
+	}
+	team := labels[label]
+	if team == "" {
+		team = annotations[label]
+	}
+	identityTeams.lock.Lock()
+	defer identityTeams.lock.Unlock()
+	if team == "" {
+		delete(identityTeams.teams, strings.ToLower(identity))
+		return
+	}
+	identityTeams.teams[strings.ToLower(identity)] = team
+}
+
+// forgetIdentityTeam drops the team the workloads of the identity named
+func forgetIdentityTeam(identity string) {
+	identityTeams.lock.Lock()
+	defer identityTeams.lock.Unlock()
+	delete(identityTeams.teams, strings.ToLower(identity))
+}
+
+// GetIdentityTeam returns the team the identity belongs to, which is the team the label of its workloads names,
+// else the team of its longest identity prefix, else the unassigned team
+func GetIdentityTeam(identity string) string {
+	identityTeams.lock.RLock()
+	team := identityTeams.teams[strings.ToLower(identity)]
+	identityTeams.lock.RUnlock()
+	if team != "" {
+		return team
+	}
+	if team = common.GetIdentityTeamByPrefix(identity); team != "" {
+		return team
+	}
+	return common.UnassignedTeam
+}
+
+// TeamSummary is what the cache holds for the identities of a team
+type TeamSummary struct {
+	Team       string   `json:"team"`
+	Identities []string `json:"identities"`
+	Clusters   []string `json:"clusters"`
+	Hosts      int      `json:"hosts"`
+}
+
+// GetTeamSummaries returns the identities of the cache grouped by team, along with the clusters they run in and
+// the number of their hosts, sorted by team
+func GetTeamSummaries(rr *RemoteRegistry) []TeamSummary {
+	summaries := make([]TeamSummary, 0)
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityClusterCache == nil {
+		return summaries
+	}
+	byTeam := make(map[string]*TeamSummary)
+	teamClusters := make(map[string]map[string]bool)
+	identities := rr.AdmiralCache.IdentityClusterCache.GetKeys()
+	sort.Strings(identities)
+	for _, identity := range identities {
+		team := GetIdentityTeam(identity)
+		summary, ok := byTeam[team]
+		if !ok {
+			summary = &TeamSummary{Team: team, Identities: make([]string, 0), Clusters: make([]string, 0)}
+			byTeam[team] = summary
+			teamClusters[team] = make(map[string]bool)
+		}
+		summary.Identities = append(summary.Identities, identity)
+		for _, cluster := range getCacheKeys(rr.AdmiralCache.IdentityClusterCache, identity) {
+			teamClusters[team][cluster] = true
+		}
+		summary.Hosts += len(getIdentityHosts(rr.AdmiralCache, identity, ""))
+	}
+	for team, summary := range byTeam {
+		for cluster := range teamClusters[team] {
+			summary.Clusters = append(summary.Clusters, cluster)
+		}
+		sort.Strings(summary.Clusters)
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Team < summaries[j].Team })
+	return summaries
+}
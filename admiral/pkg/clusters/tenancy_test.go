@@ -0,0 +1,88 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIdentityTeam(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:             &common.LabelSet{},
+		TeamLabel:            "team",
+		TeamIdentityPrefixes: map[string]string{"payments.": "payments", "payments.fraud.": "fraud"},
+	})
+	defer forgetIdentityTeam("payments.checkout")
+
+	testCases := []struct {
+		name         string
+		identity     string
+		labels       map[string]string
+		annotations  map[string]string
+		expectedTeam string
+	}{
+		{
+			name:         "Given an identity without a team label or a matching prefix, Then it belongs to the unassigned team",
+			identity:     "orders.cart",
+			expectedTeam: common.UnassignedTeam,
+		},
+		{
+			name:         "Given an identity matching a prefix, Then it belongs to the team of the prefix",
+			identity:     "Payments.Checkout",
+			expectedTeam: "payments",
+		},
+		{
+			name:         "Given an identity matching several prefixes, Then it belongs to the team of the longest prefix",
+			identity:     "payments.fraud.scoring",
+			expectedTeam: "fraud",
+		},
+		{
+			name:         "Given a workload with the team label, Then the label takes precedence over the prefix",
+			identity:     "payments.checkout",
+			labels:       map[string]string{"team": "checkout"},
+			expectedTeam: "checkout",
+		},
+		{
+			name:         "Given a workload with the team annotation, Then the annotation names the team",
+			identity:     "payments.checkout",
+			annotations:  map[string]string{"team": "billing"},
+			expectedTeam: "billing",
+		},
+		{
+			name:         "Given a workload whose team label was removed, Then the identity falls back to its prefix",
+			identity:     "payments.checkout",
+			labels:       map[string]string{},
+			expectedTeam: "payments",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.labels != nil || c.annotations != nil {
+				recordIdentityTeam(c.identity, c.annotations, c.labels)
+			}
+			assert.Equal(t, c.expectedTeam, GetIdentityTeam(c.identity))
+		})
+	}
+}
+
+func TestGetTeamSummaries(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:             &common.LabelSet{},
+		TeamIdentityPrefixes: map[string]string{"payments.": "payments"},
+	})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("payments.checkout", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("payments.refunds", "cluster2", "cluster2")
+	rr.AdmiralCache.IdentityClusterCache.Put("orders.cart", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.payments.checkout.global", "payments.checkout")
+	rr.AdmiralCache.CnameIdentityCache.Store("qal.payments.checkout.global", "payments.checkout")
+
+	assert.Equal(t, []TeamSummary{
+		{Team: "payments", Identities: []string{"payments.checkout", "payments.refunds"}, Clusters: []string{"cluster1", "cluster2"}, Hosts: 2},
+		{Team: common.UnassignedTeam, Identities: []string{"orders.cart"}, Clusters: []string{"cluster1"}},
+	}, GetTeamSummaries(rr))
+}
@@ -0,0 +1,114 @@
+package clusters
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+)
+
+// IdentityCacheState is what the cache holds for an identity: the clusters it runs in, the hosts generated for it,
+// the clusters depending on each host and the processing status of the ServiceEntry of each host in each cluster
+type IdentityCacheState struct {
+	Identity          string                       `json:"identity"`
+	Clusters          []string                     `json:"clusters"`
+	Hosts             []string                     `json:"hosts"`
+	DependentClusters map[string][]string          `json:"dependentClusters"`
+	SyncStatus        map[string]map[string]string `json:"syncStatus"`
+}
+
+// CacheStateSummary aggregates the cache state of a set of identities across all the clusters
+type CacheStateSummary struct {
+	Identities []IdentityCacheState `json:"identities"`
+	Clusters   []string             `json:"clusters"`
+	Hosts      int                  `json:"hosts"`
+	SyncStatus map[string]int       `json:"syncStatus"`
+}
+
+// GetIdentityCacheState returns what the cache holds for the identity, or an error when it holds nothing
+func GetIdentityCacheState(rr *RemoteRegistry, identity string) (IdentityCacheState, error) {
+	state := IdentityCacheState{
+		Identity:          identity,
+		Clusters:          make([]string, 0),
+		Hosts:             make([]string, 0),
+		DependentClusters: make(map[string][]string),
+		SyncStatus:        make(map[string]map[string]string),
+	}
+	if rr == nil || rr.AdmiralCache == nil {
+		return state, fmt.Errorf("identity %s was not found", identity)
+	}
+	if clusters := getCacheKeys(rr.AdmiralCache.IdentityClusterCache, identity); clusters != nil {
+		state.Clusters = clusters
+	}
+	if hosts := getIdentityHosts(rr.AdmiralCache, identity, ""); hosts != nil {
+		state.Hosts = hosts
+	}
+	if len(state.Clusters) == 0 && len(state.Hosts) == 0 {
+		return state, fmt.Errorf("identity %s was not found", identity)
+	}
+	for _, host := range state.Hosts {
+		dependentClusters := GetDependentClusters(rr, host)
+		if dependentClusters == nil {
+			dependentClusters = make([]string, 0)
+		}
+		state.DependentClusters[host] = dependentClusters
+		status := make(map[string]string)
+		for cluster := range getHostClusters(rr.AdmiralCache, host) {
+			status[cluster] = getServiceEntrySyncStatus(rr.GetRemoteController(cluster), host, cluster)
+		}
+		state.SyncStatus[host] = status
+	}
+	return state, nil
+}
+
+// GetCacheStateSummary returns the cache state of every identity of the cache allowed, along with the clusters
+// they run in, the number of their hosts and the number of their ServiceEntries by processing status
+func GetCacheStateSummary(rr *RemoteRegistry, allowed func(identity string) bool) CacheStateSummary {
+	summary := CacheStateSummary{
+		Identities: make([]IdentityCacheState, 0),
+		Clusters:   make([]string, 0),
+		SyncStatus: make(map[string]int),
+	}
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityClusterCache == nil {
+		return summary
+	}
+	identities := rr.AdmiralCache.IdentityClusterCache.GetKeys()
+	sort.Strings(identities)
+	clusters := make(map[string]bool)
+	for _, identity := range identities {
+		if !allowed(identity) {
+			continue
+		}
+		state, err := GetIdentityCacheState(rr, identity)
+		if err != nil {
+			continue
+		}
+		summary.Identities = append(summary.Identities, state)
+		for _, cluster := range state.Clusters {
+			clusters[cluster] = true
+		}
+		summary.Hosts += len(state.Hosts)
+		for _, status := range state.SyncStatus {
+			for _, s := range status {
+				summary.SyncStatus[s]++
+			}
+		}
+	}
+	for cluster := range clusters {
+		summary.Clusters = append(summary.Clusters, cluster)
+	}
+	sort.Strings(summary.Clusters)
+	return summary
+}
+
+// getServiceEntrySyncStatus returns the processing status of the ServiceEntry of the host in the cluster
+func getServiceEntrySyncStatus(rc *RemoteController, host, cluster string) string {
+	if rc == nil || rc.ServiceEntryController == nil || rc.ServiceEntryController.Cache == nil {
+		return common.NotProcessed
+	}
+	se := rc.ServiceEntryController.Cache.Get(getIstioResourceName(host, "-se"), cluster)
+	if se == nil {
+		return common.NotProcessed
+	}
+	return rc.ServiceEntryController.Cache.GetSEProcessStatus(se, cluster)
+}
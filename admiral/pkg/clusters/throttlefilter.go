@@ -0,0 +1,432 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/structpb"
+	"istio.io/api/networking/v1alpha3"
+	networking "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	throttleFilter                                       = "ThrottleFilter"
+	throttleDescriptorKey                                = "header_match"
+	throttleSoftBehaviour                                = "softThrottle"
+	throttleUnlimitedTokens                              = math.MaxUint32
+	envoyfilterAssociatedTrafficConfigAnnotation         = "associated-traffic-config"
+	envoyfilterAssociatedTrafficConfigIdentityAnnotation = "associated-traffic-config-identity"
+)
+
+// The quotas of a TrafficConfig are rendered into a local rate limit EnvoyFilter of the workloads of each
+// workload env of its identity, in every cluster the identity runs in. Every quota becomes a descriptor of the
+// filter, matched by the path, the methods and the headers of the quota, and by the identity of the client for
+// the quotas of the app quota groups. The maxAmount of a quota is shared between the pods of the workload env,
+// of the cluster for the quota groups with a region level limit, and of every cluster otherwise. The quotas with
+// the softThrottle behaviour are only reported, by a second filter which does not enforce them.
+
+// throttleQuota is a quota of a TrafficConfig which applies to a workload env
+type throttleQuota struct {
+	name        string
+	quota       *v1.Quota
+	clients     []string
+	regionLevel bool
+}
+
+func (q throttleQuota) enforced() bool {
+	return q.quota.Behaviour != throttleSoftBehaviour
+}
+
+// getThrottleQuotas returns the quotas of the quota groups of the TrafficConfig which select the workload env,
+// the quota groups without workload env selectors select the workload envs of the TrafficConfig
+func getThrottleQuotas(tc *v1.TrafficConfig, workloadEnv string) []throttleQuota {
+	quotas := make([]throttleQuota, 0)
+	if tc.Spec.QuotaGroup == nil {
+		return quotas
+	}
+	selects := func(selectors []string) bool {
+		if len(selectors) == 0 {
+			selectors = tc.Spec.WorkloadEnv
+		}
+		for _, selector := range selectors {
+			if selector == workloadEnv {
+				return true
+			}
+		}
+		return false
+	}
+	for _, group := range tc.Spec.QuotaGroup.TotalQuotaGroup {
+		if group == nil || !selects(group.WorkloadEnvSelectors) {
+			continue
+		}
+		for _, quota := range group.Quotas {
+			if quota != nil {
+				quotas = append(quotas, throttleQuota{name: group.Name + "/" + quota.Name, quota: quota, regionLevel: group.RegionLevelLimit})
+			}
+		}
+	}
+	if common.GetThrottlingClientIdentityHeader() == "" {
+		return quotas
+	}
+	for _, group := range tc.Spec.QuotaGroup.AppQuotaGroups {
+		if group == nil || len(group.AssociatedApps) == 0 || !selects(group.WorkloadEnvSelectors) {
+			continue
+		}
+		for _, quota := range group.Quotas {
+			if quota != nil {
+				quotas = append(quotas, throttleQuota{name: group.Name + "/" + quota.Name, quota: quota, clients: group.AssociatedApps})
+			}
+		}
+	}
+	return quotas
+}
+
+// getIdentityWorkload returns the namespace and the number of replicas of the workloads of the identity in
+// the workload env of the cluster, the namespace is empty when the identity has no workload there
+func getIdentityWorkload(rc *RemoteController, identity, workloadEnv string) (string, int) {
+	if rc == nil {
+		return "", 0
+	}
+	replicas := func(count *int32) int {
+		if count == nil {
+			return 1
+		}
+		return int(*count)
+	}
+	if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+		item := rc.DeploymentController.Cache.GetByIdentity(identity)[workloadEnv]
+		if item != nil && item.Deployment != nil {
+			return item.Deployment.Namespace, replicas(item.Deployment.Spec.Replicas)
+		}
+	}
+	if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+		item := rc.RolloutController.Cache.GetByIdentity(identity)[workloadEnv]
+		if item != nil && item.Rollout != nil {
+			return item.Rollout.Namespace, replicas(item.Rollout.Spec.Replicas)
+		}
+	}
+	return "", 0
+}
+
+// getThrottleFilterName returns the name of the throttle filter of the workload env of the identity
+func getThrottleFilterName(identity, workloadEnv, version string) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s-throttle-%s", identity, workloadEnv, version))
+}
+
+// syncThrottleFilters renders the quotas of the TrafficConfig into the throttle filters of the workload envs of
+// its identity in every cluster the identity runs in. The filters of the workload envs without quotas, and all
+// of them when the TrafficConfig is deleted, are removed
+func syncThrottleFilters(ctx context.Context, rr *RemoteRegistry, tc *v1.TrafficConfig, eventType admiral.EventType) error {
+	identity := getTrafficConfigLabel(tc.Labels, common.TrafficConfigAssetLabelKey)
+	if identity == "" {
+		return fmt.Errorf(LogFormat, string(eventType), throttleFilter, tc.Name, "", "skipped as the TrafficConfig has no "+common.TrafficConfigAssetLabelKey+" label")
+	}
+	if len(common.GetEnvoyFilterVersion()) == 0 {
+		return errors.New("envoy filter version not supplied")
+	}
+	clusterMap := rr.AdmiralCache.IdentityClusterCache.Get(identity)
+	if clusterMap == nil {
+		log.Infof(LogFormat, string(eventType), throttleFilter, tc.Name, "", "no source clusters found for identity "+identity)
+		return nil
+	}
+	clusters := clusterMap.GetKeys()
+	sort.Strings(clusters)
+
+	var err error
+	for _, cluster := range clusters {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil || rc.VirtualServiceController == nil || rc.VirtualServiceController.IstioClient == nil {
+			continue
+		}
+		for _, workloadEnv := range getIdentityWorkloadEnvs(rc, identity, "") {
+			namespace, replicas := getIdentityWorkload(rc, identity, workloadEnv)
+			quotas := getThrottleQuotas(tc, workloadEnv)
+			if eventType == admiral.Delete || len(quotas) == 0 {
+				err = common.AppendError(err, deleteThrottleFilters(ctx, rc, identity, workloadEnv, namespace))
+				continue
+			}
+			totalReplicas := 0
+			for _, sourceCluster := range clusters {
+				_, clusterReplicas := getIdentityWorkload(rr.GetRemoteController(sourceCluster), identity, workloadEnv)
+				totalReplicas += clusterReplicas
+			}
+			for _, version := range common.GetEnvoyFilterVersion() {
+				name := getThrottleFilterName(identity, workloadEnv, version)
+				spec, specErr := constructThrottleFilterStruct(quotas, identity, version, replicas, totalReplicas)
+				if specErr != nil {
+					log.Errorf(LogErrFormat, string(eventType), throttleFilter, name, cluster, specErr)
+					err = common.AppendError(err, specErr)
+					continue
+				}
+				filter := &networking.EnvoyFilter{
+					TypeMeta: metaV1.TypeMeta{
+						Kind:       "EnvoyFilter",
+						APIVersion: "networking.istio.io/v1alpha3",
+					},
+					ObjectMeta: metaV1.ObjectMeta{
+						Name:      name,
+						Namespace: namespace,
+						Annotations: map[string]string{
+							envoyfilterAssociatedTrafficConfigAnnotation:         tc.Name,
+							envoyfilterAssociatedTrafficConfigIdentityAnnotation: identity,
+						},
+					},
+					Spec: v1alpha3.EnvoyFilter{WorkloadSelector: spec.WorkloadSelector, ConfigPatches: spec.ConfigPatches},
+				}
+				stampTxId(ctx, filter)
+				err = common.AppendError(err, applyThrottleFilter(ctx, rc, filter))
+			}
+		}
+	}
+	return err
+}
+
+// applyThrottleFilter creates the throttle filter, or updates it when it exists
+func applyThrottleFilter(ctx context.Context, rc *RemoteController, filter *networking.EnvoyFilter) error {
+	client := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().EnvoyFilters(filter.Namespace)
+	// We query the API server instead of getting it from cache because there could be potential condition where the filter exists in the cache but not on the cluster.
+	existing, err := client.Get(ctx, filter.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		_, err = client.Create(ctx, filter, metaV1.CreateOptions{})
+	} else if err == nil {
+		filter.ResourceVersion = existing.ResourceVersion
+		_, err = client.Update(ctx, filter, metaV1.UpdateOptions{})
+	}
+	if err != nil {
+		log.Errorf(LogErrFormat, "Apply", throttleFilter, filter.Name, rc.ClusterID, err)
+		return err
+	}
+	log.Infof(LogFormat, "Apply", throttleFilter, filter.Name, rc.ClusterID, "Success")
+	return nil
+}
+
+// deleteThrottleFilters deletes the throttle filters of the workload env of the identity
+func deleteThrottleFilters(ctx context.Context, rc *RemoteController, identity, workloadEnv, namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	var err error
+	client := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().EnvoyFilters(namespace)
+	for _, version := range common.GetEnvoyFilterVersion() {
+		name := getThrottleFilterName(identity, workloadEnv, version)
+		deleteErr := client.Delete(ctx, name, metaV1.DeleteOptions{})
+		if deleteErr != nil && !k8sErrors.IsNotFound(deleteErr) {
+			log.Errorf(LogErrFormat, "Delete", throttleFilter, name, rc.ClusterID, deleteErr)
+			err = common.AppendError(err, deleteErr)
+		} else if deleteErr == nil {
+			log.Infof(LogFormat, "Delete", throttleFilter, name, rc.ClusterID, "Success")
+		}
+	}
+	return err
+}
+
+// constructThrottleFilterStruct returns the spec of the throttle filter of the quotas. The enforced and the
+// soft quotas are rendered into two local rate limit filters of different stages, the rate limit actions of
+// the inbound virtual hosts produce the descriptors of both
+func constructThrottleFilterStruct(quotas []throttleQuota, identity, filterVersion string, replicas, totalReplicas int) (*v1alpha3.EnvoyFilter, error) {
+	var (
+		enforced   = make([]*structpb.Value, 0)
+		soft       = make([]*structpb.Value, 0)
+		rateLimits = make([]*structpb.Value, 0)
+	)
+	for _, quota := range quotas {
+		podReplicas := totalReplicas
+		if quota.regionLevel {
+			podReplicas = replicas
+		}
+		descriptor, err := getThrottleDescriptor(quota, podReplicas)
+		if err != nil {
+			return nil, err
+		}
+		stage := 0.0
+		if quota.enforced() {
+			enforced = append(enforced, descriptor)
+		} else {
+			stage = 1
+			soft = append(soft, descriptor)
+		}
+		rateLimits = append(rateLimits, structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"stage": structpb.NewNumberValue(stage),
+			"actions": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+				structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+					"header_value_match": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+						"descriptor_value": structpb.NewStringValue(quota.name),
+						"expect_match":     structpb.NewBoolValue(true),
+						"headers":          structpb.NewListValue(&structpb.ListValue{Values: getThrottleHeaderMatchers(quota)}),
+					}}),
+				}}),
+			}}),
+		}}))
+	}
+
+	workloadSelectorLabels := map[string]string{common.GetWorkloadIdentifier(): identity}
+	subFilter := &v1alpha3.EnvoyFilter_ListenerMatch_SubFilterMatch{Name: "envoy.filters.http.router"}
+	envoyfilter := &v1alpha3.EnvoyFilter{WorkloadSelector: &v1alpha3.WorkloadSelector{Labels: workloadSelectorLabels}}
+	if len(enforced) > 0 {
+		spec := getEnvoyFilterSpec(workloadSelectorLabels, "throttleFilterPatch", getThrottleTypedConfig(enforced, 0, true),
+			v1alpha3.EnvoyFilter_SIDECAR_INBOUND, subFilter, v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE, filterVersion)
+		envoyfilter.ConfigPatches = append(envoyfilter.ConfigPatches, spec.ConfigPatches...)
+	}
+	if len(soft) > 0 {
+		spec := getEnvoyFilterSpec(workloadSelectorLabels, "softThrottleFilterPatch", getThrottleTypedConfig(soft, 1, false),
+			v1alpha3.EnvoyFilter_SIDECAR_INBOUND, subFilter, v1alpha3.EnvoyFilter_Patch_INSERT_BEFORE, filterVersion)
+		envoyfilter.ConfigPatches = append(envoyfilter.ConfigPatches, spec.ConfigPatches...)
+	}
+	envoyfilter.ConfigPatches = append(envoyfilter.ConfigPatches, &v1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: v1alpha3.EnvoyFilter_VIRTUAL_HOST,
+		Match: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: v1alpha3.EnvoyFilter_SIDECAR_INBOUND,
+			Proxy:   &v1alpha3.EnvoyFilter_ProxyMatch{ProxyVersion: "^" + strings.ReplaceAll(filterVersion, ".", "\\.") + ".*"},
+			ObjectTypes: &v1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_RouteConfiguration{
+				RouteConfiguration: &v1alpha3.EnvoyFilter_RouteConfigurationMatch{},
+			},
+		},
+		Patch: &v1alpha3.EnvoyFilter_Patch{
+			Operation: v1alpha3.EnvoyFilter_Patch_MERGE,
+			Value: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"rate_limits": structpb.NewListValue(&structpb.ListValue{Values: rateLimits}),
+			}},
+		},
+	})
+	return envoyfilter, nil
+}
+
+// getThrottleTypedConfig returns the typed config of a local rate limit filter of the stage with the descriptors.
+// The requests which match no descriptor are not limited
+func getThrottleTypedConfig(descriptors []*structpb.Value, stage float64, enforced bool) *structpb.Struct {
+	enforcedPercent := 0.0
+	if enforced {
+		enforcedPercent = 100
+	}
+	percent := func(runtimeKey string, numerator float64) *structpb.Value {
+		return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"runtime_key": structpb.NewStringValue(runtimeKey),
+			"default_value": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"numerator":   structpb.NewNumberValue(numerator),
+				"denominator": structpb.NewStringValue("HUNDRED"),
+			}}),
+		}})
+	}
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"@type":    structpb.NewStringValue("type.googleapis.com/udpa.type.v1.TypedStruct"),
+			"type_url": structpb.NewStringValue("type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit"),
+			"value": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"stat_prefix":     structpb.NewStringValue("admiral_throttle"),
+				"stage":           structpb.NewNumberValue(stage),
+				"token_bucket":    getTokenBucket(throttleUnlimitedTokens, time.Second),
+				"filter_enabled":  percent("admiral_throttle_enabled", 100),
+				"filter_enforced": percent("admiral_throttle_enforced", enforcedPercent),
+				"descriptors":     structpb.NewListValue(&structpb.ListValue{Values: descriptors}),
+			}}),
+		},
+	}
+}
+
+// getThrottleDescriptor returns the descriptor of the quota, its maxAmount is shared between the replicas
+func getThrottleDescriptor(quota throttleQuota, replicas int) (*structpb.Value, error) {
+	if quota.quota.MaxAmount <= 0 {
+		return nil, fmt.Errorf("maxAmount of quota %s is %d, it should be greater than 0", quota.name, quota.quota.MaxAmount)
+	}
+	period, err := time.ParseDuration(quota.quota.TimePeriod)
+	if err != nil || period <= 0 {
+		return nil, fmt.Errorf("timePeriod of quota %s is %q, it should be a positive duration", quota.name, quota.quota.TimePeriod)
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+	tokens := (quota.quota.MaxAmount + replicas - 1) / replicas
+	return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"entries": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				"key":   structpb.NewStringValue(throttleDescriptorKey),
+				"value": structpb.NewStringValue(quota.name),
+			}}),
+		}}),
+		"token_bucket": getTokenBucket(int64(tokens), period),
+	}}), nil
+}
+
+func getTokenBucket(tokens int64, period time.Duration) *structpb.Value {
+	return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"max_tokens":      structpb.NewNumberValue(float64(tokens)),
+		"tokens_per_fill": structpb.NewNumberValue(float64(tokens)),
+		"fill_interval":   structpb.NewStringValue(period.String()),
+	}})
+}
+
+// getThrottleHeaderMatchers returns the header matchers of the requests the quota applies to. The path of the
+// quota, or its rule when it has none, is a prefix when it ends with * and an exact path otherwise
+func getThrottleHeaderMatchers(quota throttleQuota) []*structpb.Value {
+	matcher := func(name, matchType, value string) *structpb.Value {
+		match := structpb.NewStringValue(value)
+		if matchType == "safe_regex" {
+			match = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"regex": match}})
+		}
+		return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"name": structpb.NewStringValue(name),
+			"string_match": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				matchType: match,
+			}}),
+		}})
+	}
+	anyOf := func(values []string) string {
+		quoted := make([]string, 0, len(values))
+		for _, value := range values {
+			quoted = append(quoted, regexp.QuoteMeta(value))
+		}
+		return "^(" + strings.Join(quoted, "|") + ")$"
+	}
+
+	path := quota.quota.Path
+	if path == "" {
+		path = quota.quota.Rule
+	}
+	if path == "" || path == "*" {
+		path = "/*"
+	}
+	matchers := make([]*structpb.Value, 0)
+	if strings.HasSuffix(path, "*") {
+		matchers = append(matchers, matcher(":path", "prefix", strings.TrimSuffix(path, "*")))
+	} else {
+		matchers = append(matchers, matcher(":path", "exact", path))
+	}
+	if len(quota.quota.Methods) > 0 {
+		methods := make([]string, 0, len(quota.quota.Methods))
+		for _, method := range quota.quota.Methods {
+			methods = append(methods, strings.ToUpper(method))
+		}
+		matchers = append(matchers, matcher(":method", "safe_regex", anyOf(methods)))
+	}
+	for _, header := range quota.quota.Headers {
+		if header == nil {
+			continue
+		}
+		switch strings.ToUpper(header.Condition) {
+		case "PREFIX":
+			matchers = append(matchers, matcher(header.Name, "prefix", header.Value))
+		case "CONTAINS":
+			matchers = append(matchers, matcher(header.Name, "contains", header.Value))
+		case "REGEX":
+			matchers = append(matchers, matcher(header.Name, "safe_regex", header.Value))
+		default:
+			matchers = append(matchers, matcher(header.Name, "exact", header.Value))
+		}
+	}
+	if len(quota.clients) > 0 {
+		matchers = append(matchers, matcher(common.GetThrottlingClientIdentityHeader(), "safe_regex", anyOf(quota.clients)))
+	}
+	return matchers
+}
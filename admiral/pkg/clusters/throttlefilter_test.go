@@ -0,0 +1,184 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	"github.com/stretchr/testify/assert"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newThrottleTrafficConfig() *v1.TrafficConfig {
+	return &v1.TrafficConfig{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:   "foo-e2e",
+			Labels: map[string]string{common.TrafficConfigAssetLabelKey: "foo", common.TrafficConfigEnvLabelKey: "e2e"},
+		},
+		Spec: v1.TrafficConfigSpec{
+			WorkloadEnv: []string{"e2e"},
+			QuotaGroup: &v1.QuotaGroup{
+				TotalQuotaGroup: []*v1.TotalQuotaGroup{
+					{
+						Name:             "total",
+						RegionLevelLimit: true,
+						Quotas:           []*v1.Quota{{Name: "all", Rule: "/*", TimePeriod: "1s", MaxAmount: 200}},
+					},
+					{
+						Name:                 "health",
+						WorkloadEnvSelectors: []string{"e2e"},
+						Quotas: []*v1.Quota{{Name: "health", Rule: "/health", TimePeriod: "1m", MaxAmount: 30,
+							Methods: []string{"get"}, Behaviour: throttleSoftBehaviour}},
+					},
+					{
+						Name:                 "other-env",
+						WorkloadEnvSelectors: []string{"prd"},
+						Quotas:               []*v1.Quota{{Name: "all", Rule: "/*", TimePeriod: "1s", MaxAmount: 100}},
+					},
+				},
+				AppQuotaGroups: []*v1.AppQuotaGroup{
+					{
+						Name:           "clients",
+						AssociatedApps: []string{"bar", "baz"},
+						Quotas:         []*v1.Quota{{Name: "orders", Path: "/orders/*", TimePeriod: "1s", MaxAmount: 10}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGetThrottleQuotas(t *testing.T) {
+	tc := newThrottleTrafficConfig()
+	testCases := []struct {
+		name           string
+		clientHeader   string
+		workloadEnv    string
+		expectedQuotas []string
+	}{
+		{
+			name: "Given a TrafficConfig with quota groups, " +
+				"When getThrottleQuotas is called for an env of the TrafficConfig, " +
+				"Then the quotas of the groups selecting the env should be returned",
+			clientHeader:   "x-client-identity",
+			workloadEnv:    "e2e",
+			expectedQuotas: []string{"total/all", "health/health", "clients/orders"},
+		},
+		{
+			name: "Given no client identity header, " +
+				"When getThrottleQuotas is called, " +
+				"Then the quotas of the app quota groups should be skipped",
+			workloadEnv:    "e2e",
+			expectedQuotas: []string{"total/all", "health/health"},
+		},
+		{
+			name: "Given a quota group selecting another env, " +
+				"When getThrottleQuotas is called for that env, " +
+				"Then only its quotas should be returned",
+			clientHeader:   "x-client-identity",
+			workloadEnv:    "prd",
+			expectedQuotas: []string{"other-env/all"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{
+				LabelSet:                       &common.LabelSet{WorkloadIdentityKey: "identity"},
+				ThrottlingClientIdentityHeader: c.clientHeader,
+			})
+			names := make([]string, 0)
+			for _, quota := range getThrottleQuotas(tc, c.workloadEnv) {
+				names = append(names, quota.name)
+			}
+			assert.Equal(t, c.expectedQuotas, names)
+		})
+	}
+}
+
+func TestConstructThrottleFilterStruct(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:                       &common.LabelSet{WorkloadIdentityKey: "identity"},
+		ThrottlingClientIdentityHeader: "x-client-identity",
+	})
+	quotas := getThrottleQuotas(newThrottleTrafficConfig(), "e2e")
+
+	filter, err := constructThrottleFilterStruct(quotas, "foo", "1.20", 3, 6)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"identity": "foo"}, filter.WorkloadSelector.Labels)
+	assert.Len(t, filter.ConfigPatches, 3)
+	enforcedConfig := filter.ConfigPatches[0].Patch.Value.Fields["typed_config"].GetStructValue().Fields["value"].GetStructValue()
+	descriptors := enforcedConfig.Fields["descriptors"].GetListValue().Values
+	assert.Len(t, descriptors, 2)
+	// the region level quota is shared by the pods of the cluster, the other ones by the pods of every cluster
+	assert.Equal(t, float64(67), descriptors[0].GetStructValue().Fields["token_bucket"].GetStructValue().Fields["max_tokens"].GetNumberValue())
+	assert.Equal(t, float64(2), descriptors[1].GetStructValue().Fields["token_bucket"].GetStructValue().Fields["max_tokens"].GetNumberValue())
+	softConfig := filter.ConfigPatches[1].Patch.Value.Fields["typed_config"].GetStructValue().Fields["value"].GetStructValue()
+	assert.Equal(t, float64(1), softConfig.Fields["stage"].GetNumberValue())
+	assert.Equal(t, float64(0), softConfig.Fields["filter_enforced"].GetStructValue().Fields["default_value"].GetStructValue().Fields["numerator"].GetNumberValue())
+	rateLimits := filter.ConfigPatches[2].Patch.Value.Fields["rate_limits"].GetListValue().Values
+	assert.Len(t, rateLimits, 3)
+	headers := rateLimits[2].GetStructValue().Fields["actions"].GetListValue().Values[0].GetStructValue().
+		Fields["header_value_match"].GetStructValue().Fields["headers"].GetListValue().Values
+	assert.Len(t, headers, 2)
+	assert.Equal(t, "/orders/", headers[0].GetStructValue().Fields["string_match"].GetStructValue().Fields["prefix"].GetStringValue())
+	assert.Equal(t, "^(bar|baz)$", headers[1].GetStructValue().Fields["string_match"].GetStructValue().Fields["safe_regex"].GetStructValue().Fields["regex"].GetStringValue())
+
+	quotas[0].quota = &v1.Quota{Name: "all", Rule: "/*", TimePeriod: "soon", MaxAmount: 200}
+	_, err = constructThrottleFilterStruct(quotas, "foo", "1.20", 3, 6)
+	assert.Error(t, err)
+}
+
+func TestSyncThrottleFilters(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:           &common.LabelSet{WorkloadIdentityKey: "identity", EnvKey: "admiral.io/env"},
+		EnvoyFilterVersion: "1.20",
+	})
+	defer common.ResetSync()
+	replicas := int32(2)
+	deploymentCache := admiral.NewDeploymentCache()
+	deploymentCache.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{
+		ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns"},
+		Spec: k8sAppsV1.DeploymentSpec{Replicas: &replicas, Template: coreV1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{
+			Annotations: map[string]string{"admiral.io/env": "e2e"},
+			Labels:      map[string]string{"identity": "foo"},
+		}}},
+	})
+	istioClient := istioFake.NewSimpleClientset()
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:                "cluster1",
+		DeploymentController:     &admiral.DeploymentController{Cache: deploymentCache},
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	tc := newThrottleTrafficConfig()
+	getFilter := func() error {
+		_, err := istioClient.NetworkingV1alpha3().EnvoyFilters("foo-ns").Get(context.Background(), "foo-e2e-throttle-1.20", metaV1.GetOptions{})
+		return err
+	}
+
+	assert.NoError(t, syncThrottleFilters(context.Background(), rr, tc, admiral.Add))
+	assert.NoError(t, getFilter())
+
+	assert.NoError(t, syncThrottleFilters(context.Background(), rr, tc, admiral.Update))
+	assert.NoError(t, getFilter())
+
+	tc.Spec.QuotaGroup = nil
+	assert.NoError(t, syncThrottleFilters(context.Background(), rr, tc, admiral.Update))
+	assert.True(t, k8sErrors.IsNotFound(getFilter()))
+
+	assert.NoError(t, syncThrottleFilters(context.Background(), rr, newThrottleTrafficConfig(), admiral.Add))
+	assert.NoError(t, syncThrottleFilters(context.Background(), rr, newThrottleTrafficConfig(), admiral.Delete))
+	assert.True(t, k8sErrors.IsNotFound(getFilter()))
+}
@@ -30,31 +30,47 @@ type TrafficConfigHandler struct {
 // Added method to handle new TrafficConfig additions
 func (th *TrafficConfigHandler) Added(ctx context.Context, obj *v1.TrafficConfig) error {
 	log.Debugf(LogFormat, common.Add, common.TrafficConfigResourceType, obj.Name, "", common.ReceivedStatus)
+	throttlingErr := th.HandleTrafficConfigThrottling(ctx, obj, th.RemoteRegistry, admiral.Add)
 	slowStartConfigs, err := th.populateCaches(obj, admiral.Add)
 	if err != nil {
-		return err
+		return common.AppendError(err, throttlingErr)
 	}
-	return th.HandleTrafficConfigRecord(ctx, obj, th.RemoteRegistry, admiral.Add, slowStartConfigs)
+	return common.AppendError(th.HandleTrafficConfigRecord(ctx, obj, th.RemoteRegistry, admiral.Add, slowStartConfigs), throttlingErr)
 }
 
 // Updated method to handle updates to existing TrafficConfig
 func (th *TrafficConfigHandler) Updated(ctx context.Context, obj *v1.TrafficConfig) error {
 	log.Debugf(LogFormat, common.Update, common.TrafficConfigResourceType, obj.Name, "", common.ReceivedStatus)
+	throttlingErr := th.HandleTrafficConfigThrottling(ctx, obj, th.RemoteRegistry, admiral.Update)
 	slowStartConfigs, err := th.populateCaches(obj, admiral.Update)
 	if err != nil {
-		return err
+		return common.AppendError(err, throttlingErr)
 	}
-	return th.HandleTrafficConfigRecord(ctx, obj, th.RemoteRegistry, admiral.Update, slowStartConfigs)
+	return common.AppendError(th.HandleTrafficConfigRecord(ctx, obj, th.RemoteRegistry, admiral.Update, slowStartConfigs), throttlingErr)
 }
 
 // Deleted method to handle deletions of TrafficConfig
 func (th *TrafficConfigHandler) Deleted(ctx context.Context, obj *v1.TrafficConfig) error {
 	log.Debugf(LogFormat, common.Delete, common.TrafficConfigResourceType, obj.Name, "", common.ReceivedStatus)
+	throttlingErr := th.HandleTrafficConfigThrottling(ctx, obj, th.RemoteRegistry, admiral.Delete)
 	slowStartConfigs, err := th.populateCaches(obj, admiral.Delete)
 	if err != nil {
-		return err
+		return common.AppendError(err, throttlingErr)
 	}
-	return th.HandleTrafficConfigRecord(ctx, obj, th.RemoteRegistry, admiral.Update, slowStartConfigs)
+	return common.AppendError(th.HandleTrafficConfigRecord(ctx, obj, th.RemoteRegistry, admiral.Update, slowStartConfigs), throttlingErr)
+}
+
+// HandleTrafficConfigThrottling renders the quotas of the TrafficConfig into the throttle filters of its identity
+func (th *TrafficConfigHandler) HandleTrafficConfigThrottling(ctx context.Context, obj *v1.TrafficConfig, remoteRegistry *RemoteRegistry, eventType admiral.EventType) error {
+	if !common.IsTrafficConfigThrottlingEnabled() {
+		return nil
+	}
+	ctx = context.WithValue(ctx, common.EventResourceType, common.TrafficConfig)
+	if IsCacheWarmupTime(remoteRegistry) {
+		log.Debugf(LogFormat, string(eventType), throttleFilter, obj.Name, "", "processing skipped during cache warm up state")
+		return nil
+	}
+	return syncThrottleFilters(ctx, remoteRegistry, obj, eventType)
 }
 
 // HandleTrafficConfigRecord processes TrafficConfig records
@@ -69,6 +85,9 @@ func (th *TrafficConfigHandler) HandleTrafficConfigRecord(ctx context.Context, o
 		log.Infof(LogFormat, string(eventType), common.TrafficConfigResourceType, obj.Name, "", "trafficConfig processing is disabled")
 		return nil
 	}
+	if slowStartConfigs == nil {
+		return nil
+	}
 
 	assetAlias := getTrafficConfigLabel(obj.Labels, common.TrafficConfigAssetLabelKey)
 	assetEnv := getTrafficConfigLabel(obj.Labels, common.TrafficConfigEnvLabelKey)
@@ -117,8 +136,7 @@ func (th *TrafficConfigHandler) populateCaches(obj *v1.TrafficConfig, eventType
 	//ctx = context.WithValue(ctx, common.TrafficConfigContextAssetKey, assetAlias)
 	//ctx = context.WithValue(ctx, common.TrafficConfigContextEnvKey, assetEnv)
 	// Implement logic here. Placeholder for now.
-	slowStartConfigs := obj.Spec.EdgeService.SlowStartConfig
-	if slowStartConfigs == nil {
+	if obj.Spec.EdgeService == nil || obj.Spec.EdgeService.SlowStartConfig == nil {
 		log.Warnf("No slowStartConfig found for TrafficConfig %s", obj.Name)
 		return nil, nil
 	}
@@ -126,7 +144,7 @@ func (th *TrafficConfigHandler) populateCaches(obj *v1.TrafficConfig, eventType
 	// Create a map of workloadEnvSelectors for quick lookup
 	workloadEnvSelectorsMap := make(map[string]bool)
 	// Iterate over each slowStartConfig
-	for _, slowStartConfig := range slowStartConfigs {
+	for _, slowStartConfig := range obj.Spec.EdgeService.SlowStartConfig {
 		// Retrieve the workload environment selectors
 		workloadEnvSelectors := slowStartConfig.WorkloadEnvSelectors
 
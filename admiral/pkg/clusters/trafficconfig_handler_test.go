@@ -255,6 +255,9 @@ func initializeControllers() *RemoteRegistry {
 		log.Fatalf("%v", err)
 	}
 
+	// the routing VirtualServices are only written when their gateway exists
+	virtualServiceController.IstioClient.NetworkingV1alpha3().Gateways(common.NamespaceIstioSystem).Create(context.Background(),
+		&v1alpha3.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "passthrough-gateway", Namespace: common.NamespaceIstioSystem}}, metav1.CreateOptions{})
 	rcCluster1.VirtualServiceController = virtualServiceController
 	rcCluster2.VirtualServiceController = virtualServiceController
 
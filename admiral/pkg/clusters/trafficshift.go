@@ -0,0 +1,350 @@
+package clusters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TrafficShiftRamping is the state of a traffic shift whose weights are being stepped towards its target
+	TrafficShiftRamping = "Ramping"
+	// TrafficShiftCompleted is the state of a traffic shift which reached its target
+	TrafficShiftCompleted = "Completed"
+	// TrafficShiftRolledBack is the state of a traffic shift rolled back to the weights it started from,
+	// as the endpoints of a region it shifted the traffic to became unhealthy
+	TrafficShiftRolledBack = "RolledBack"
+
+	eventReasonTrafficShiftRolledBack = "TrafficShiftRolledBack"
+)
+
+// TrafficShiftProgress is the progress of the shift of the traffic of a GTP, checkpointed on the GTP after
+// every step so that admiral resumes the shift where it stopped after a restart. From holds the weights of
+// the regions of each failover policy of the GTP, by dnsPrefix, when the shift started
+type TrafficShiftProgress struct {
+	State      string                      `json:"state"`
+	Target     map[string]int32            `json:"target"`
+	From       map[string]map[string]int32 `json:"from"`
+	Step       int                         `json:"step"`
+	Steps      int                         `json:"steps"`
+	StartedAt  time.Time                   `json:"startedAt"`
+	LastStepAt time.Time                   `json:"lastStepAt"`
+	Reason     string                      `json:"reason,omitempty"`
+}
+
+// parseTrafficShiftTarget parses the weights of the regions the traffic is shifted to, written as
+// region=weight pairs separated by commas, the weights have to add up to 100
+func parseTrafficShiftTarget(value string) (map[string]int32, error) {
+	target := make(map[string]int32)
+	total := int32(0)
+	for _, pair := range strings.Split(value, ",") {
+		region, weightValue, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || strings.TrimSpace(region) == "" {
+			return nil, fmt.Errorf("%q is not a region=weight pair", pair)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightValue))
+		if err != nil || weight < 0 || weight > gtpTotalWeight {
+			return nil, fmt.Errorf("weight %q of region %s is not between 0 and %d", weightValue, region, gtpTotalWeight)
+		}
+		target[strings.TrimSpace(region)] += int32(weight)
+		total += int32(weight)
+	}
+	if total != gtpTotalWeight {
+		return nil, fmt.Errorf("weights add up to %d instead of %d", total, gtpTotalWeight)
+	}
+	return target, nil
+}
+
+// getTrafficShiftProgress returns the progress checkpointed on the GTP, nil when no shift was started
+// or the checkpoint cannot be read
+func getTrafficShiftProgress(gtp *v1.GlobalTrafficPolicy) *TrafficShiftProgress {
+	value := gtp.Annotations[common.TrafficShiftProgressAnnotation]
+	if value == "" {
+		return nil
+	}
+	progress := &TrafficShiftProgress{}
+	if err := json.Unmarshal([]byte(value), progress); err != nil {
+		log.Warnf(LogFormat, "Read", common.TrafficShift, gtp.Name, "", "progress could not be read: "+err.Error())
+		return nil
+	}
+	return progress
+}
+
+// getTrafficShiftInterval returns the interval between the steps of the shift of the GTP
+func getTrafficShiftInterval(gtp *v1.GlobalTrafficPolicy) time.Duration {
+	if interval, err := time.ParseDuration(gtp.Annotations[common.TrafficShiftIntervalAnnotation]); err == nil && interval > 0 {
+		return interval
+	}
+	return common.GetTrafficShiftStepInterval()
+}
+
+// startTrafficShift returns the progress of a shift starting from the current weights of the failover
+// policies of the GTP, ramped over its duration
+func startTrafficShift(gtp *v1.GlobalTrafficPolicy, target map[string]int32, now time.Time) (*TrafficShiftProgress, error) {
+	duration, err := time.ParseDuration(gtp.Annotations[common.TrafficShiftDurationAnnotation])
+	if err != nil || duration < 0 {
+		return nil, fmt.Errorf("duration %q is not valid", gtp.Annotations[common.TrafficShiftDurationAnnotation])
+	}
+	steps := 1
+	if interval := getTrafficShiftInterval(gtp); interval > 0 {
+		steps = max(int(math.Ceil(float64(duration)/float64(interval))), 1)
+	}
+	from := make(map[string]map[string]int32)
+	for _, policy := range gtp.Spec.Policy {
+		if policy.LbType != model.TrafficPolicy_FAILOVER {
+			continue
+		}
+		weights := make(map[string]int32)
+		for _, group := range policy.Target {
+			weights[group.Region] += group.Weight
+		}
+		from[policy.DnsPrefix] = weights
+	}
+	if len(from) == 0 {
+		return nil, fmt.Errorf("no failover policy to shift the traffic of")
+	}
+	return &TrafficShiftProgress{
+		State:      TrafficShiftRamping,
+		Target:     target,
+		From:       from,
+		Steps:      steps,
+		StartedAt:  now,
+		LastStepAt: now,
+	}, nil
+}
+
+// getTrafficShiftWeights returns the weights of the regions at the step of the shift, moved linearly from
+// the weights the shift started from towards its target. The weights are rounded down and the points lost
+// to the rounding given to the regions with the largest remainders, so they still add up to 100
+func getTrafficShiftWeights(from, target map[string]int32, step, steps int) map[string]int32 {
+	regions := make([]string, 0, len(from)+len(target))
+	for region := range from {
+		regions = append(regions, region)
+	}
+	for region := range target {
+		if _, ok := from[region]; !ok {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	progress := 1.0
+	if steps > 0 && step < steps {
+		progress = float64(step) / float64(steps)
+	}
+	weights := make(map[string]int32, len(regions))
+	remainders := make(map[string]float64, len(regions))
+	total := int32(0)
+	for _, region := range regions {
+		weight := float64(from[region]) + float64(target[region]-from[region])*progress
+		weights[region] = int32(math.Floor(weight))
+		remainders[region] = weight - math.Floor(weight)
+		total += weights[region]
+	}
+	sort.SliceStable(regions, func(i, j int) bool {
+		return remainders[regions[i]] > remainders[regions[j]]
+	})
+	for i := 0; total < gtpTotalWeight && i < len(regions); i++ {
+		weights[regions[i]]++
+		total++
+	}
+	return weights
+}
+
+// applyTrafficShift sets the weights of the failover policies of the GTP to the ones of the current step
+// of the shift, or back to the ones it started from when it was rolled back
+func applyTrafficShift(gtp *v1.GlobalTrafficPolicy, progress *TrafficShiftProgress) {
+	for _, policy := range gtp.Spec.Policy {
+		from, ok := progress.From[policy.DnsPrefix]
+		if !ok || policy.LbType != model.TrafficPolicy_FAILOVER {
+			continue
+		}
+		weights := from
+		if progress.State != TrafficShiftRolledBack {
+			weights = getTrafficShiftWeights(from, progress.Target, progress.Step, progress.Steps)
+		}
+		regions := make([]string, 0, len(weights))
+		for region := range weights {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+		targets := make([]*model.TrafficGroup, 0, len(regions))
+		for _, region := range regions {
+			targets = append(targets, &model.TrafficGroup{Region: region, Weight: weights[region]})
+		}
+		policy.Target = targets
+	}
+}
+
+// getTrafficShiftDegradation returns why the endpoints of a region the traffic is shifted to are unhealthy,
+// which is when the ingress of one of the clusters of the identity in the region failed its health checks,
+// or the workload of the identity in one of them has no available replica. It is empty when they are healthy
+func getTrafficShiftDegradation(rr *RemoteRegistry, gtp *v1.GlobalTrafficPolicy, progress *TrafficShiftProgress) string {
+	if rr == nil || rr.AdmiralCache == nil || rr.AdmiralCache.IdentityClusterCache == nil {
+		return ""
+	}
+	gaining := make(map[string]bool)
+	for _, from := range progress.From {
+		for region, weight := range progress.Target {
+			if weight > from[region] {
+				gaining[region] = true
+			}
+		}
+	}
+	identity := common.GetGtpIdentity(gtp)
+	env := common.GetGtpEnv(gtp)
+	clusters := rr.AdmiralCache.IdentityClusterCache.Get(identity)
+	if clusters == nil {
+		return ""
+	}
+	for _, cluster := range clusters.GetKeys() {
+		rc := rr.GetRemoteController(cluster)
+		if rc == nil {
+			continue
+		}
+		region, err := getClusterRegion(rr, cluster, rc)
+		if err != nil || !gaining[region] {
+			continue
+		}
+		if rr.AdmiralCache.IngressHealthCache != nil && rr.AdmiralCache.IngressHealthCache.IsFailedOver(cluster) {
+			return fmt.Sprintf("ingress of cluster %s in region %s failed its health checks", cluster, region)
+		}
+		if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+			deployment := rc.DeploymentController.Cache.Get(identity, env)
+			if deployment != nil && getDesiredReplicas(deployment.Spec.Replicas) > 0 && deployment.Status.AvailableReplicas == 0 {
+				return fmt.Sprintf("deployment %s in cluster %s in region %s has no available replica", deployment.Name, cluster, region)
+			}
+		}
+		if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+			rollout := rc.RolloutController.Cache.Get(identity, env)
+			if rollout != nil && getDesiredReplicas(rollout.Spec.Replicas) > 0 && rollout.Status.AvailableReplicas == 0 {
+				return fmt.Sprintf("rollout %s in cluster %s in region %s has no available replica", rollout.Name, cluster, region)
+			}
+		}
+	}
+	return ""
+}
+
+// stepTrafficShift starts the shift of the traffic of the GTP towards the target it is annotated with, or
+// takes the next step of the shift once its interval elapsed since the last one. The shift is rolled back
+// to the weights it started from as soon as the endpoints of a region it shifts the traffic to are unhealthy.
+// A target changed while the traffic is shifted starts a new shift from the current weights
+func stepTrafficShift(ctx context.Context, rr *RemoteRegistry, rc *RemoteController, gtp *v1.GlobalTrafficPolicy, now time.Time) error {
+	targetValue := gtp.Annotations[common.TrafficShiftTargetAnnotation]
+	if targetValue == "" {
+		return nil
+	}
+	target, err := parseTrafficShiftTarget(targetValue)
+	if err != nil {
+		return fmt.Errorf("target %q is not valid: %v", targetValue, err)
+	}
+	progress := getTrafficShiftProgress(gtp)
+	if progress == nil || !maps.Equal(progress.Target, target) {
+		progress, err = startTrafficShift(gtp, target, now)
+		if err != nil {
+			return err
+		}
+		return checkpointTrafficShift(ctx, rc, gtp, progress)
+	}
+	if progress.State != TrafficShiftRamping {
+		return nil
+	}
+	if reason := getTrafficShiftDegradation(rr, gtp, progress); reason != "" {
+		progress.State = TrafficShiftRolledBack
+		progress.Reason = reason
+		progress.LastStepAt = now
+		if common.EnableK8sEvents() {
+			recordEvent(rc, globalTrafficPolicyReference(gtp), coreV1.EventTypeWarning, eventReasonTrafficShiftRolledBack,
+				fmt.Sprintf("traffic shift rolled back at step %d of %d as %s", progress.Step, progress.Steps, reason))
+		}
+	} else {
+		if now.Sub(progress.LastStepAt) < getTrafficShiftInterval(gtp) {
+			return nil
+		}
+		progress.Step++
+		progress.LastStepAt = now
+		if progress.Step >= progress.Steps {
+			progress.Step = progress.Steps
+			progress.State = TrafficShiftCompleted
+		}
+	}
+	trafficShiftSteps.Increment(api.WithAttributes(
+		attribute.Key("identity").String(common.GetGtpIdentity(gtp)),
+		attribute.Key("state").String(progress.State),
+	))
+	return checkpointTrafficShift(ctx, rc, gtp, progress)
+}
+
+// checkpointTrafficShift writes the weights of the step of the shift to the GTP, along with its progress
+func checkpointTrafficShift(ctx context.Context, rc *RemoteController, gtp *v1.GlobalTrafficPolicy, progress *TrafficShiftProgress) error {
+	if rc == nil || rc.GlobalTraffic == nil || rc.GlobalTraffic.CrdClient == nil {
+		return fmt.Errorf("globaltrafficpolicy controller not initialized for cluster")
+	}
+	value, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	client := rc.GlobalTraffic.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies(gtp.Namespace)
+	current, err := client.Get(ctx, gtp.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Annotations == nil {
+		current.Annotations = make(map[string]string)
+	}
+	current.Annotations[common.TrafficShiftProgressAnnotation] = string(value)
+	applyTrafficShift(current, progress)
+	_, err = client.Update(ctx, current, metaV1.UpdateOptions{})
+	return err
+}
+
+// startTrafficShiftScheduler periodically steps the traffic shifts of the GTPs of the cluster
+func startTrafficShiftScheduler(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	interval := common.GetTrafficShiftCheckInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if commonUtil.IsAdmiralReadOnly() || IsCacheWarmupTime(rr) {
+				continue
+			}
+			// the remote controller is looked up on every step, as it is replaced when its istio
+			// controllers are started or stopped
+			rc := rr.GetRemoteController(clusterID)
+			if rc == nil || rc.GlobalTraffic == nil || rc.GlobalTraffic.Cache == nil {
+				continue
+			}
+			for _, gtp := range rc.GlobalTraffic.Cache.List() {
+				err := stepTrafficShift(context.Background(), rr, rc, gtp, time.Now())
+				if err != nil {
+					log.Errorf(LogErrFormat, "Step", common.TrafficShift, gtp.Name, rc.ClusterID, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
+	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	admiralFake "github.com/istio-ecosystem/admiral/admiral/pkg/client/clientset/versioned/fake"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseTrafficShiftTarget(t *testing.T) {
+	testCases := []struct {
+		name        string
+		value       string
+		expected    map[string]int32
+		expectedErr bool
+	}{
+		{
+			name: "Given weights adding up to 100, " +
+				"When parseTrafficShiftTarget is called, " +
+				"Then the weight of each region should be returned",
+			value:    "us-west-2=20, us-east-2=80",
+			expected: map[string]int32{"us-west-2": 20, "us-east-2": 80},
+		},
+		{
+			name: "Given weights not adding up to 100, " +
+				"When parseTrafficShiftTarget is called, " +
+				"Then an error should be returned",
+			value:       "us-west-2=20,us-east-2=70",
+			expectedErr: true,
+		},
+		{
+			name: "Given a region without a weight, " +
+				"When parseTrafficShiftTarget is called, " +
+				"Then an error should be returned",
+			value:       "us-west-2",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			target, err := parseTrafficShiftTarget(c.value)
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, target)
+		})
+	}
+}
+
+func TestGetTrafficShiftWeights(t *testing.T) {
+	from := map[string]int32{"us-west-2": 100, "us-east-2": 0}
+	target := map[string]int32{"us-east-2": 60, "eu-west-1": 40}
+	testCases := []struct {
+		name     string
+		step     int
+		steps    int
+		expected map[string]int32
+	}{
+		{
+			name: "Given the first step of a shift, " +
+				"When getTrafficShiftWeights is called, " +
+				"Then the weights the shift started from should be returned",
+			step:     0,
+			steps:    3,
+			expected: map[string]int32{"us-west-2": 100, "us-east-2": 0, "eu-west-1": 0},
+		},
+		{
+			name: "Given a step in the middle of a shift, " +
+				"When getTrafficShiftWeights is called, " +
+				"Then the weights should be moved towards the target and still add up to 100",
+			step:     1,
+			steps:    3,
+			expected: map[string]int32{"us-west-2": 67, "us-east-2": 20, "eu-west-1": 13},
+		},
+		{
+			name: "Given the last step of a shift, " +
+				"When getTrafficShiftWeights is called, " +
+				"Then the target weights should be returned",
+			step:     3,
+			steps:    3,
+			expected: map[string]int32{"us-west-2": 0, "us-east-2": 60, "eu-west-1": 40},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, getTrafficShiftWeights(from, target, c.step, c.steps))
+		})
+	}
+}
+
+func TestStepTrafficShift(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey:     "identity",
+			AdmiralCRDIdentityLabel: "identity",
+			EnvKey:                  "admiral.io/env",
+		},
+		TrafficShiftStepInterval: 10 * time.Minute,
+	})
+	defer common.ResetSync()
+
+	newGTP := func(annotations map[string]string) *v1.GlobalTrafficPolicy {
+		annotations["admiral.io/env"] = "prod"
+		return &v1.GlobalTrafficPolicy{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        "foo-gtp",
+				Namespace:   "foo-ns",
+				Labels:      map[string]string{"identity": "foo"},
+				Annotations: annotations,
+			},
+			Spec: model.GlobalTrafficPolicy{Policy: []*model.TrafficPolicy{{
+				LbType:    model.TrafficPolicy_FAILOVER,
+				DnsPrefix: "default",
+				Target: []*model.TrafficGroup{
+					{Region: "us-west-2", Weight: 100},
+					{Region: "us-east-2", Weight: 0},
+				},
+			}}},
+		}
+	}
+	newRegistry := func(gtp *v1.GlobalTrafficPolicy, availableReplicas int32) (*RemoteRegistry, *RemoteController) {
+		deploymentCache := admiral.NewDeploymentCache()
+		deploymentCache.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-ns"},
+			Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{ObjectMeta: metaV1.ObjectMeta{
+				Annotations: map[string]string{"admiral.io/env": "prod"},
+				Labels:      map[string]string{"identity": "foo"},
+			}}},
+			Status: k8sAppsV1.DeploymentStatus{AvailableReplicas: availableReplicas},
+		})
+		rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+		rc := &RemoteController{
+			ClusterID:      "cluster-west",
+			GlobalTraffic:  &admiral.GlobalTrafficController{CrdClient: admiralFake.NewSimpleClientset(gtp)},
+			NodeController: &admiral.NodeController{Locality: &admiral.Locality{Region: "us-west-2"}},
+		}
+		rr.PutRemoteController("cluster-west", rc)
+		rr.PutRemoteController("cluster-east", &RemoteController{
+			ClusterID:            "cluster-east",
+			DeploymentController: &admiral.DeploymentController{Cache: deploymentCache},
+			NodeController:       &admiral.NodeController{Locality: &admiral.Locality{Region: "us-east-2"}},
+		})
+		rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster-east", "cluster-east")
+		return rr, rc
+	}
+	getWeights := func(t *testing.T, rc *RemoteController) (map[string]int32, *TrafficShiftProgress) {
+		gtp, err := rc.GlobalTraffic.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(context.Background(), "foo-gtp", metaV1.GetOptions{})
+		assert.NoError(t, err)
+		weights := make(map[string]int32)
+		for _, target := range gtp.Spec.Policy[0].Target {
+			weights[target.Region] = target.Weight
+		}
+		return weights, getTrafficShiftProgress(gtp)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	annotations := func() map[string]string {
+		return map[string]string{
+			common.TrafficShiftTargetAnnotation:   "us-west-2=0,us-east-2=100",
+			common.TrafficShiftDurationAnnotation: "20m",
+		}
+	}
+
+	t.Run("Given a GTP annotated with a traffic shift target, "+
+		"When the shift is stepped over its duration, "+
+		"Then the weights should be ramped to the target and the progress checkpointed on the GTP", func(t *testing.T) {
+		rr, rc := newRegistry(newGTP(annotations()), 3)
+		getCurrent := func() *v1.GlobalTrafficPolicy {
+			gtp, _ := rc.GlobalTraffic.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(context.Background(), "foo-gtp", metaV1.GetOptions{})
+			return gtp
+		}
+
+		assert.NoError(t, stepTrafficShift(context.Background(), rr, rc, getCurrent(), start))
+		weights, progress := getWeights(t, rc)
+		assert.Equal(t, map[string]int32{"us-west-2": 100, "us-east-2": 0}, weights)
+		assert.Equal(t, TrafficShiftRamping, progress.State)
+		assert.Equal(t, 2, progress.Steps)
+
+		assert.NoError(t, stepTrafficShift(context.Background(), rr, rc, getCurrent(), start.Add(5*time.Minute)))
+		_, progress = getWeights(t, rc)
+		assert.Equal(t, 0, progress.Step)
+
+		assert.NoError(t, stepTrafficShift(context.Background(), rr, rc, getCurrent(), start.Add(10*time.Minute)))
+		weights, progress = getWeights(t, rc)
+		assert.Equal(t, map[string]int32{"us-west-2": 50, "us-east-2": 50}, weights)
+		assert.Equal(t, 1, progress.Step)
+
+		assert.NoError(t, stepTrafficShift(context.Background(), rr, rc, getCurrent(), start.Add(20*time.Minute)))
+		weights, progress = getWeights(t, rc)
+		assert.Equal(t, map[string]int32{"us-west-2": 0, "us-east-2": 100}, weights)
+		assert.Equal(t, TrafficShiftCompleted, progress.State)
+	})
+
+	t.Run("Given a traffic shift to a region whose workload has no available replica, "+
+		"When the shift is stepped, "+
+		"Then it should be rolled back to the weights it started from", func(t *testing.T) {
+		rr, rc := newRegistry(newGTP(annotations()), 0)
+		gtp := newGTP(annotations())
+		progress, err := startTrafficShift(gtp, map[string]int32{"us-west-2": 0, "us-east-2": 100}, start)
+		assert.NoError(t, err)
+		progress.Step = 1
+		applyTrafficShift(gtp, progress)
+		assert.NoError(t, checkpointTrafficShift(context.Background(), rc, gtp, progress))
+		current, _ := rc.GlobalTraffic.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies("foo-ns").Get(context.Background(), "foo-gtp", metaV1.GetOptions{})
+
+		assert.NoError(t, stepTrafficShift(context.Background(), rr, rc, current, start.Add(10*time.Minute)))
+		weights, progress := getWeights(t, rc)
+		assert.Equal(t, map[string]int32{"us-west-2": 100, "us-east-2": 0}, weights)
+		assert.Equal(t, TrafficShiftRolledBack, progress.State)
+		assert.Contains(t, progress.Reason, "cluster-east")
+	})
+}
@@ -0,0 +1,50 @@
+package clusters
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// getFederatedSubjectAltNames returns the subject alt names the client cluster verifies the workloads
+// of the service entry with when the clusters are federated across trust domains. The san of each
+// server cluster of the service entry host is rewritten with the trust domain mapped for the cluster
+// pair, nil is returned when trust domain federation is disabled
+func getFederatedSubjectAltNames(cache *AdmiralCache, clientCluster string, se *networking.ServiceEntry) []string {
+	if !common.IsTrustDomainFederationEnabled() || cache == nil || cache.CnameClusterCache == nil ||
+		se == nil || len(se.Hosts) == 0 || len(se.SubjectAltNames) == 0 {
+		return nil
+	}
+	serverClusters := cache.CnameClusterCache.Get(strings.ToLower(se.Hosts[0]))
+	if serverClusters == nil || serverClusters.Len() == 0 {
+		return nil
+	}
+	sanPrefix := getSubjectAltName(common.GetSANPrefix(), "")
+	sans := make(map[string]bool)
+	for _, san := range se.SubjectAltNames {
+		identity, ok := strings.CutPrefix(san, sanPrefix)
+		if !ok || identity == "" {
+			sans[san] = true
+			continue
+		}
+		for _, serverCluster := range serverClusters.GetKeys() {
+			sans[getSubjectAltName(common.GetTrustDomainForClusterPair(clientCluster, serverCluster), identity)] = true
+		}
+	}
+	federatedSans := make([]string, 0, len(sans))
+	for san := range sans {
+		federatedSans = append(federatedSans, san)
+	}
+	sort.Strings(federatedSans)
+	return federatedSans
+}
+
+// getSubjectAltName returns the san of the identity in the trust domain, in the format spiffe://<trustDomain>/<identity>
+func getSubjectAltName(trustDomain string, identity string) string {
+	if trustDomain == "" {
+		return common.SpiffePrefix + identity
+	}
+	return common.SpiffePrefix + trustDomain + common.Slash + identity
+}
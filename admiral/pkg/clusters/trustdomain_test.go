@@ -0,0 +1,69 @@
+package clusters
+
+import (
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func TestGetFederatedSubjectAltNames(t *testing.T) {
+	cache := &AdmiralCache{CnameClusterCache: common.NewMapOfMaps()}
+	cache.CnameClusterCache.Put("qa.foo.global", "cluster1", "cluster1")
+	cache.CnameClusterCache.Put("qa.foo.global", "cluster3", "cluster3")
+	se := &networking.ServiceEntry{
+		Hosts:           []string{"qa.foo.global"},
+		SubjectAltNames: []string{"spiffe://prefix/foo", "spiffe://other.example.com/bar"},
+	}
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{SANPrefix: "prefix"})
+	assert.Nil(t, getFederatedSubjectAltNames(cache, "cluster2", se), "nothing should be returned when disabled")
+
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		SANPrefix:           "prefix",
+		ClusterTrustDomains: map[string]string{"cluster3": "east.example.com"},
+		TrustDomainMappings: map[string]string{"cluster2:cluster1": "west.example.com"},
+	})
+	testCases := []struct {
+		name          string
+		clientCluster string
+		se            *networking.ServiceEntry
+		expectedSans  []string
+	}{
+		{
+			name: "Given a service entry whose server clusters are in different trust domains, " +
+				"When getFederatedSubjectAltNames is called, " +
+				"Then the san of each server cluster should be returned",
+			clientCluster: "cluster2",
+			se:            se,
+			expectedSans: []string{
+				"spiffe://east.example.com/foo",
+				"spiffe://other.example.com/bar",
+				"spiffe://west.example.com/foo",
+			},
+		},
+		{
+			name: "Given a client cluster without a mapping for a server cluster, " +
+				"When getFederatedSubjectAltNames is called, " +
+				"Then the trust domain of the server cluster should be used, or else the san prefix",
+			clientCluster: "cluster4",
+			se:            &networking.ServiceEntry{Hosts: []string{"qa.foo.global"}, SubjectAltNames: []string{"spiffe://prefix/foo"}},
+			expectedSans:  []string{"spiffe://east.example.com/foo", "spiffe://prefix/foo"},
+		},
+		{
+			name: "Given a service entry host without server clusters, " +
+				"When getFederatedSubjectAltNames is called, " +
+				"Then nil should be returned",
+			clientCluster: "cluster2",
+			se:            &networking.ServiceEntry{Hosts: []string{"qa.bar.global"}, SubjectAltNames: []string{"spiffe://prefix/bar"}},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedSans, getFederatedSubjectAltNames(cache, c.clientCluster, c.se))
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	networking "istio.io/api/networking/v1alpha3"
 	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 type ServiceEntrySuspender interface {
@@ -49,10 +50,16 @@ type RemoteController struct {
 	OutlierDetectionController       *admiral.OutlierDetectionController
 	ClientConnectionConfigController *admiral.ClientConnectionConfigController
 	JobController                    *admiral.JobController
+	CronJobController                *admiral.CronJobController
 	VertexController                 *admiral.VertexController
 	MonoVertexController             *admiral.MonoVertexController
 	TrafficConfigController          *admiral.TrafficConfigController
+	EventRecorder                    record.EventRecorder
 	stop                             chan struct{}
+	// lazyIstio is the state of the istio controllers of the cluster when they are started lazily
+	lazyIstio *lazyIstioControllers
+	// namespaces is the namespace controller of the cluster, started once a namespace deny selector is configured
+	namespaces *lazyNamespaceController
 	//listener for normal types
 }
 
@@ -83,10 +90,25 @@ type AdmiralCache struct {
 	CnameDependentClusterNamespaceCache *common.MapOfMapOfMaps
 	PartitionIdentityCache              *common.Map
 	ClientClusterNamespaceServerCache   *common.MapOfMapOfMaps
+	IdentitySyncNamespaceCache          *identitySyncNamespaceCache
+	IdentityClientTLSSettingsCache      *identityClientTLSSettingsCache
+	IdentityWarmupDurationCache         *identityWarmupDurationCache
+	IdentityFailoverPriorityCache       *identityFailoverPriorityCache
+	EastWestGatewayCache                *eastWestGatewayCache
+	ExportToCapCache                    *sync.Map // cname and cluster to the number of dependent namespaces which exceeded the exportTo cap
+	UnreachableClusterCache             *sync.Map // cluster to the time it was first found unreachable
+	WorkloadStabilizationCache          *sync.Map // cluster and identity to the time its workload was first found not fully available
+	IdentityLastSeenCache               *sync.Map // identity to the time it was last seen in a cluster
+	HostConflictDetector                *hostConflictDetector
 
 	//LB Migration Cache
 	NLBEnabledCluster []string
 	CLBEnabledCluster []string
+	LBMigrationCache  *lbMigrationCache
+	// ClusterSyncNamespaces holds the sync namespaces of the clusters the stale sync namespaces were last migrated for
+	ClusterSyncNamespaces map[string]string
+	// IngressHealthCache holds the ingress health checks of each cluster
+	IngressHealthCache *ingressHealthCache
 
 	// TrafficConfigCache
 	SlowStartConfigCache *common.MapOfMapOfMaps // mapping of <asset> to <trafficConfigEnv> to <worklaodEnv> to slowStartDurationInSeconds
@@ -109,6 +131,7 @@ type RemoteRegistry struct {
 	RegistryClient              registry.ClientAPI
 	ConfigWriter                ConfigWriter
 	TrafficConfigController     *admiral.TrafficConfigController
+	eventCoalescer              *eventCoalescer
 }
 
 // ModifySEFunc is a function that follows the dependency injection pattern which is used by HandleEventForGlobalTrafficPolicy
@@ -162,6 +185,18 @@ func NewRemoteRegistry(ctx context.Context, params common.AdmiralParams) *Remote
 		CnameDependentClusterNamespaceCache: common.NewMapOfMapOfMaps(),
 		ClientClusterNamespaceServerCache:   common.NewMapOfMapOfMaps(),
 		PartitionIdentityCache:              common.NewMap(),
+		IdentitySyncNamespaceCache:          newIdentitySyncNamespaceCache(),
+		IdentityClientTLSSettingsCache:      newIdentityClientTLSSettingsCache(),
+		IdentityWarmupDurationCache:         newIdentityWarmupDurationCache(),
+		IdentityFailoverPriorityCache:       newIdentityFailoverPriorityCache(),
+		EastWestGatewayCache:                newEastWestGatewayCache(),
+		LBMigrationCache:                    newLBMigrationCache(),
+		IngressHealthCache:                  newIngressHealthCache(),
+		ExportToCapCache:                    &sync.Map{},
+		UnreachableClusterCache:             &sync.Map{},
+		WorkloadStabilizationCache:          &sync.Map{},
+		IdentityLastSeenCache:               &sync.Map{},
+		HostConflictDetector:                newHostConflictDetector(),
 		SlowStartConfigCache:                common.NewMapOfMapOfMaps(),
 	}
 	if common.GetAdmiralProfile() == common.AdmiralProfileDefault || common.GetAdmiralProfile() == common.AdmiralProfilePerf {
@@ -174,6 +209,7 @@ func NewRemoteRegistry(ctx context.Context, params common.AdmiralParams) *Remote
 
 	admiralCache.NLBEnabledCluster = params.NLBEnabledClusters
 	admiralCache.CLBEnabledCluster = params.CLBEnabledClusters
+	admiralCache.ClusterSyncNamespaces = params.ClusterSyncNamespaces
 
 	if common.IsAdmiralDynamicConfigEnabled() {
 		admiralDynamicConfigDatabaseClient, err = NewDynamicConfigDatabaseClient(common.GetAdmiralConfigPath(), NewDynamoClient)
@@ -208,6 +244,7 @@ func NewRemoteRegistry(ctx context.Context, params common.AdmiralParams) *Remote
 		DynamicConfigDatabaseClient: admiralDynamicConfigDatabaseClient,
 		ClientLoader:                clientLoader,
 		ConfigWriter:                NewConfigWriter(),
+		eventCoalescer:              newEventCoalescer(modifyServiceEntryForNewServiceOrPod),
 	}
 
 	if common.IsAdmiralOperatorMode() || common.IsAdmiralStateSyncerMode() {
@@ -245,6 +282,12 @@ func (d *sourceToDestinations) put(dependencyObj *admiralV1.Dependency) {
 	d.mutex.Unlock()
 }
 
+func (d *sourceToDestinations) delete(source string) {
+	d.mutex.Lock()
+	delete(d.sourceDestinations, source)
+	d.mutex.Unlock()
+}
+
 func (d *sourceToDestinations) Get(key string) []string {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
@@ -268,6 +311,17 @@ func (r *RemoteRegistry) PutRemoteController(clusterId string, rc *RemoteControl
 	r.remoteControllers[clusterId] = rc
 }
 
+// swapRemoteController replaces the remote controller of the cluster, unless it was replaced or removed in the meantime
+func (r *RemoteRegistry) swapRemoteController(clusterId string, old, new *RemoteController) bool {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if r.remoteControllers[clusterId] != old {
+		return false
+	}
+	r.remoteControllers[clusterId] = new
+	return true
+}
+
 func (r *RemoteRegistry) DeleteRemoteController(clusterId string) {
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
@@ -292,6 +346,21 @@ func (r *RemoteRegistry) GetClusterIds() []string {
 	return clusters
 }
 
+// GetUnsyncedControllers returns the controllers of each registered cluster whose informer caches
+// have not synced yet, the clusters whose caches have all synced are left out
+func (r *RemoteRegistry) GetUnsyncedControllers() map[string][]string {
+	unsynced := make(map[string][]string)
+	r.RangeRemoteControllers(func(clusterID string, rc *RemoteController) {
+		if rc == nil {
+			return
+		}
+		if controllers := admiral.GetUnsyncedControllers(rc.ApiServer); len(controllers) > 0 {
+			unsynced[clusterID] = controllers
+		}
+	})
+	return unsynced
+}
+
 func (r *RemoteRegistry) shutdown() {
 
 	done := r.ctx.Done()
@@ -18,6 +18,8 @@ import (
 	v1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
 	"gopkg.in/yaml.v2"
 	networking "istio.io/api/networking/v1alpha3"
 	k8sV1 "k8s.io/api/core/v1"
@@ -239,6 +241,9 @@ func getSortedDependentNamespaces(
 	if admiralCache == nil || admiralCache.CnameDependentClusterNamespaceCache == nil {
 		return namespaceSlice
 	}
+	if admiralCache.ExportToCapCache != nil {
+		admiralCache.ExportToCapCache.Delete(getExportToCapKey(cname, clusterId))
+	}
 	//This section gets the identity and uses it to fetch the identity's source clusters
 	//If the cluster we are fetching dependent namespaces for is also a source cluster
 	//Then we add istio-system to the list of namespaces for ExportTo
@@ -273,8 +278,7 @@ func getSortedDependentNamespaces(
 		if namespaces != nil && namespaces.Len() > 0 {
 			namespaceSlice = append(namespaceSlice, namespaces.GetValues()...)
 			if len(namespaceSlice) > common.GetExportToMaxNamespaces() {
-				namespaceSlice = []string{"*"}
-				ctxLogger.Infof("exceeded max namespaces for cname=%s in cluster=%s", cname, clusterId)
+				namespaceSlice = capExportToNamespaces(admiralCache, ctxLogger, cname, clusterId, namespaceSlice)
 			}
 			sort.Strings(namespaceSlice)
 		}
@@ -297,6 +301,51 @@ func getSortedDependentNamespaces(
 	return finalDeDupedNamespaces
 }
 
+// capExportToNamespaces returns the namespaces to use in exportTo when the dependent namespaces of the
+// cname exceed the cap. This is the fallback namespace along with istio-system when it was present,
+// or * when no fallback namespace is configured. The decision is recorded in the ExportToCapCache so
+// that it can be surfaced on the generated ServiceEntry.
+func capExportToNamespaces(admiralCache *AdmiralCache, ctxLogger *logrus.Entry, cname, clusterId string, namespaces []string) []string {
+	fallbackNamespaces := []string{"*"}
+	if fallbackNamespace := common.GetExportToFallbackNamespace(); fallbackNamespace != "" {
+		fallbackNamespaces = []string{fallbackNamespace}
+		for _, namespace := range namespaces {
+			if namespace == common.NamespaceIstioSystem && fallbackNamespace != common.NamespaceIstioSystem {
+				fallbackNamespaces = append(fallbackNamespaces, common.NamespaceIstioSystem)
+				break
+			}
+		}
+		sort.Strings(fallbackNamespaces)
+	}
+	if admiralCache.ExportToCapCache != nil {
+		admiralCache.ExportToCapCache.Store(getExportToCapKey(cname, clusterId), len(namespaces))
+	}
+	exportToCapExceeded.Increment(api.WithAttributes(
+		attribute.Key("cluster").String(clusterId),
+	))
+	ctxLogger.Infof("exceeded max namespaces for cname=%s in cluster=%s with %d namespaces, falling back to %v", cname, clusterId, len(namespaces), fallbackNamespaces)
+	return fallbackNamespaces
+}
+
+// getExportToCappedNamespaceCount returns the number of dependent namespaces of the cname
+// in the cluster when they exceeded the exportTo cap
+func getExportToCappedNamespaceCount(admiralCache *AdmiralCache, cname, clusterId string) (int, bool) {
+	if admiralCache == nil || admiralCache.ExportToCapCache == nil {
+		return 0, false
+	}
+	count, ok := admiralCache.ExportToCapCache.Load(getExportToCapKey(cname, clusterId))
+	if !ok {
+		return 0, false
+	}
+	return count.(int), true
+}
+
+// getExportToCapKey returns the key of the cname and cluster in the ExportToCapCache, they are separated by
+// a slash as both of them can contain dots. The cname is lower cased as the hosts of the ServiceEntries are
+func getExportToCapKey(cname, clusterId string) string {
+	return strings.ToLower(cname) + "/" + clusterId
+}
+
 func (w WorkloadEntrySorted) Len() int {
 	return len(w)
 }
@@ -319,8 +368,10 @@ func getLocality(rc *RemoteController) string {
 
 func getIngressPortName(meshPorts map[string]uint32) string {
 	var finalProtocol = commonUtil.Http
-	for protocol := range meshPorts {
-		finalProtocol = protocol
+	for protocol, port := range meshPorts {
+		if !common.IsAdditionalMeshPort(protocol, port) {
+			finalProtocol = protocol
+		}
 	}
 	return finalProtocol
 }
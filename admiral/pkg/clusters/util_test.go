@@ -13,6 +13,7 @@ import (
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	istioNetworkingV1Alpha3 "istio.io/api/networking/v1alpha3"
 	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
 	"k8s.io/client-go/rest"
@@ -1170,6 +1171,90 @@ func TestGetSortedDependentNamespaces(t *testing.T) {
 	}
 }
 
+func TestCapExportToNamespaces(t *testing.T) {
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	testCases := []struct {
+		name              string
+		fallbackNamespace string
+		namespaces        []string
+		expectedResult    []string
+	}{
+		{
+			name: "Given no fallback namespace is configured, " +
+				"When capExportToNamespaces is called, " +
+				"Then exportTo should be replaced with *",
+			namespaces:     []string{"istio-system", "ns1", "ns2"},
+			expectedResult: []string{"*"},
+		},
+		{
+			name: "Given a fallback namespace is configured, " +
+				"When capExportToNamespaces is called with istio-system in the namespaces, " +
+				"Then exportTo should be replaced with the fallback namespace and istio-system",
+			fallbackNamespace: "shared-ns",
+			namespaces:        []string{"istio-system", "ns1", "ns2"},
+			expectedResult:    []string{"istio-system", "shared-ns"},
+		},
+		{
+			name: "Given a fallback namespace is configured, " +
+				"When capExportToNamespaces is called without istio-system in the namespaces, " +
+				"Then exportTo should be replaced with the fallback namespace",
+			fallbackNamespace: "shared-ns",
+			namespaces:        []string{"ns1", "ns2"},
+			expectedResult:    []string{"shared-ns"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			common.ResetSync()
+			common.InitializeConfig(common.AdmiralParams{
+				LabelSet:                  &common.LabelSet{},
+				ExportToFallbackNamespace: c.fallbackNamespace,
+			})
+			admiralCache := &AdmiralCache{ExportToCapCache: &sync.Map{}}
+			result := capExportToNamespaces(admiralCache, ctxLogger, "cname", "cluster1", c.namespaces)
+			assert.Equal(t, c.expectedResult, result)
+			count, capped := getExportToCappedNamespaceCount(admiralCache, "CNAME", "cluster1")
+			assert.True(t, capped)
+			assert.Equal(t, len(c.namespaces), count)
+		})
+	}
+}
+
+func TestGetExportToCappedNamespaceCount(t *testing.T) {
+	admiralParams := common.AdmiralParams{
+		LabelSet:              &common.LabelSet{},
+		EnableSWAwareNSCaches: true,
+		ExportToMaxNamespaces: 2,
+	}
+	common.ResetSync()
+	common.InitializeConfig(admiralParams)
+	ctxLogger := logrus.WithFields(logrus.Fields{"txId": "abc"})
+	cnameDependentClusterNamespaceCache := common.NewMapOfMapOfMaps()
+	cnameDependentClusterNamespaceCache.Put("cname", "cluster1", "ns1", "ns1")
+	cnameDependentClusterNamespaceCache.Put("cname", "cluster1", "ns2", "ns2")
+	cnameDependentClusterNamespaceCache.Put("cname", "cluster1", "ns3", "ns3")
+	admiralCache := &AdmiralCache{
+		CnameDependentClusterNamespaceCache: cnameDependentClusterNamespaceCache,
+		ExportToCapCache:                    &sync.Map{},
+	}
+
+	assert.Equal(t, []string{"*"}, getSortedDependentNamespaces(admiralCache, "cname", "cluster1", ctxLogger, false))
+	count, capped := getExportToCappedNamespaceCount(admiralCache, "cname", "cluster1")
+	assert.True(t, capped)
+	assert.Equal(t, 3, count)
+
+	cnameDependentClusterNamespaceCache.PutMapofMaps("cname", common.NewMapOfMaps())
+	getSortedDependentNamespaces(admiralCache, "cname", "cluster1", ctxLogger, false)
+	_, capped = getExportToCappedNamespaceCount(admiralCache, "cname", "cluster1")
+	assert.False(t, capped)
+
+	// the cname is compared case-insensitively, as the hosts of the ServiceEntries are lower cased
+	capExportToNamespaces(admiralCache, ctxLogger, "Stage.Foo.Global", "cluster1", []string{"ns1", "ns2", "ns3"})
+	count, capped = getExportToCappedNamespaceCount(admiralCache, "stage.foo.global", "cluster1")
+	assert.True(t, capped)
+	assert.Equal(t, 3, count)
+}
+
 func TestGetDestinationsToBeProcessedForClientInitiatedProcessing(t *testing.T) {
 	identityClusterCache := common.NewMapOfMaps()
 	identityClusterCache.Put("foo", "cluster1", "cluster1")
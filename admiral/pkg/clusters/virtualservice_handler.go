@@ -11,12 +11,14 @@ import (
 
 	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/google/uuid"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
 	log "github.com/sirupsen/logrus"
 	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	coreV1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -182,7 +184,7 @@ func (vh *VirtualServiceHandler) Added(ctx context.Context, obj *v1alpha3.Virtua
 		return nil
 	}
 	shouldProcessVS := ShouldProcessVSCreatedBy(obj)
-	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) && !shouldProcessVS {
+	if IgnoreIstioResource(vh.remoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) && !shouldProcessVS {
 		return nil
 	}
 	return vh.handleVirtualServiceEvent(ctx, obj, common.Add)
@@ -192,8 +194,11 @@ func (vh *VirtualServiceHandler) Updated(ctx context.Context, obj *v1alpha3.Virt
 	if commonUtil.IsAdmiralReadOnly() {
 		return nil
 	}
+	if isCleanupPending(obj) {
+		return vh.cleanUpVirtualService(ctx, obj)
+	}
 	shouldProcessVS := ShouldProcessVSCreatedBy(obj)
-	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) && !shouldProcessVS {
+	if IgnoreIstioResource(vh.remoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) && !shouldProcessVS {
 		return nil
 	}
 	return vh.handleVirtualServiceEvent(ctx, obj, common.Update)
@@ -205,7 +210,7 @@ func (vh *VirtualServiceHandler) Deleted(ctx context.Context, obj *v1alpha3.Virt
 		return nil
 	}
 	shouldProcessVS := ShouldProcessVSCreatedBy(obj)
-	if IgnoreIstioResource(obj.Spec.ExportTo, obj.Annotations, obj.Namespace) && !shouldProcessVS {
+	if IgnoreIstioResource(vh.remoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) && !shouldProcessVS {
 		log.Infof(LogFormat, common.Delete, "VirtualService", obj.Name, vh.clusterID, "Skipping resource from namespace="+obj.Namespace)
 		if len(obj.Annotations) > 0 && obj.Annotations[common.AdmiralIgnoreAnnotation] == "true" {
 			log.Debugf(LogFormat, "admiralIoIgnoreAnnotationCheck", "VirtualService", obj.Name, vh.clusterID, "Value=true namespace="+obj.Namespace)
@@ -215,6 +220,27 @@ func (vh *VirtualServiceHandler) Deleted(ctx context.Context, obj *v1alpha3.Virt
 	return vh.handleVirtualServiceEvent(ctx, obj, common.Delete)
 }
 
+// cleanUpVirtualService removes the copies of the source VirtualService being deleted, and releases its
+// cleanup finalizer once no cluster holds one any longer. Until then an error is returned, so the
+// removal is retried
+func (vh *VirtualServiceHandler) cleanUpVirtualService(ctx context.Context, obj *v1alpha3.VirtualService) error {
+	if !IgnoreIstioResource(vh.remoteRegistry, obj.Spec.ExportTo, obj.Annotations, obj.Namespace) || ShouldProcessVSCreatedBy(obj) {
+		err := vh.handleVirtualServiceEvent(ctx, obj, common.Delete)
+		if err != nil {
+			return err
+		}
+	}
+	if !areVirtualServiceCopiesRemoved(ctx, vh.remoteRegistry, obj) {
+		return fmt.Errorf(LogFormat, "Cleanup", common.VirtualServiceResourceType, obj.Name, vh.clusterID, "copies are not removed yet, keeping the cleanup finalizer")
+	}
+	err := setVirtualServiceCleanupFinalizer(ctx, vh.remoteRegistry.GetRemoteController(vh.clusterID), obj, false)
+	if err != nil {
+		return fmt.Errorf(LogErrFormat, "Cleanup", common.VirtualServiceResourceType, obj.Name, vh.clusterID, "failed to remove the cleanup finalizer: "+err.Error())
+	}
+	log.Infof(LogFormat, "Cleanup", common.VirtualServiceResourceType, obj.Name, vh.clusterID, "copies removed, released the cleanup finalizer")
+	return nil
+}
+
 func (vh *VirtualServiceHandler) handleVirtualServiceEvent(ctx context.Context, virtualService *v1alpha3.VirtualService, event common.Event) error {
 	var (
 		//nolint
@@ -235,6 +261,11 @@ func (vh *VirtualServiceHandler) handleVirtualServiceEvent(ctx context.Context,
 
 	log.Infof(LogFormat, event, common.VirtualServiceResourceType, virtualService.Name, vh.clusterID, "Received event")
 
+	if isNamespaceFiltered(vh.remoteRegistry, vh.clusterID, virtualService.Namespace) {
+		log.Infof(LogFormat, event, common.VirtualServiceResourceType, virtualService.Name, vh.clusterID, "skipped as namespace="+virtualService.Namespace+" is filtered")
+		return nil
+	}
+
 	// Process VS
 	if ShouldProcessVSCreatedBy(virtualService) {
 		log.Infof(
@@ -269,17 +300,44 @@ func (vh *VirtualServiceHandler) handleVirtualServiceEvent(ctx context.Context,
 	}
 
 	if len(spec.Hosts) == 0 {
-		log.Infof(LogFormat, "Event", common.VirtualServiceResourceType, virtualService.Name, vh.clusterID, "No hosts found in VirtualService, will not sync to other clusters")
-		return nil
+		// a VirtualService without hosts can only be used as a delegate, it is synced along with
+		// the VirtualServices delegating to it
+		return vh.handleDelegateVirtualServiceEvent(ctx, virtualService, event)
 	}
 
-	vSName := common.GenerateUniqueNameForVS(virtualService.Namespace, virtualService.Name)
+	if event != common.Delete && common.EnableSourceFinalizers() && !hasCleanupFinalizer(virtualService) {
+		err := setVirtualServiceCleanupFinalizer(ctx, vh.remoteRegistry.GetRemoteController(vh.clusterID), virtualService, true)
+		if err != nil {
+			// the VirtualService is still synced, its deletion only won't wait for the cleanup
+			log.Warnf(LogErrFormat, "Update", common.VirtualServiceResourceType, virtualService.Name, vh.clusterID, "failed to add the cleanup finalizer: "+err.Error())
+		}
+	}
+
+	vSName := getVirtualServiceSyncName(virtualService)
+	vsTier := getVirtualServiceTier(virtualService, vh.clusterID)
+	ctxLogger := log.WithFields(log.Fields{"type": "VirtualService", "identity": vSName})
 
 	dependentClusters := vh.remoteRegistry.AdmiralCache.CnameDependentClusterCache.Get(spec.Hosts[0]).CopyJustValues()
 	if len(dependentClusters) > 0 {
+		// only the custom VirtualServices of the identity owning an admiral generated host can route it,
+		// they are merged together. The conflict recorded before is released first, as the host or the
+		// identity of the VirtualService may have changed
+		vsOwner := virtualService.Namespace + "/" + virtualService.Name
+		vh.remoteRegistry.AdmiralCache.HostConflictDetector.release(HostClaimVirtualService, "", vsOwner)
+		if event != common.Delete {
+			vsIdentity := virtualService.Labels[common.CreatedFor]
+			if owner := getCnameOwner(vh.remoteRegistry, spec.Hosts[0]); owner != "" && vsIdentity != "" && !strings.EqualFold(owner, vsIdentity) {
+				vh.remoteRegistry.AdmiralCache.HostConflictDetector.refuse(HostClaimVirtualService, spec.Hosts[0], owner, vsOwner)
+				reportHostConflict(ctxLogger, HostClaimVirtualService, spec.Hosts[0], owner, vsOwner, vh.clusterID)
+				return nil
+			}
+		}
 		// Add source clusters to the list of clusters to copy the virtual service
 		sourceClusters := vh.remoteRegistry.AdmiralCache.CnameClusterCache.Get(spec.Hosts[0]).CopyJustValues()
 		clusters := append(dependentClusters, sourceClusters...)
+		if event != common.Delete {
+			clusters = filterClustersByTier(ctxLogger, common.VirtualServiceResourceType, virtualService.Name, vsTier, clusters)
+		}
 		err := vh.syncVirtualServiceForDependentClusters(
 			ctx,
 			clusters,
@@ -301,6 +359,9 @@ func (vh *VirtualServiceHandler) handleVirtualServiceEvent(ctx context.Context,
 	// copy the VirtualService `as is` if they are not generated by Admiral (not in CnameDependentClusterCache)
 	log.Infof(LogFormat, "Event", "VirtualService", virtualService.Name, vh.clusterID, "Replicating 'as is' to all clusters")
 	remoteClusters := vh.remoteRegistry.GetClusterIds()
+	if event != common.Delete {
+		remoteClusters = filterClustersByTier(ctxLogger, common.VirtualServiceResourceType, virtualService.Name, vsTier, remoteClusters)
+	}
 	err := vh.syncVirtualServiceForAllClusters(
 		ctx,
 		remoteClusters,
@@ -368,11 +429,11 @@ func callRegistryForVirtualService(ctx context.Context, event common.Event, regi
 	if common.IsAdmiralStateSyncerMode() && common.IsStateSyncerCluster(clusterName) && registry.RegistryClient != nil {
 		switch event {
 		case common.Add:
-			err = registry.RegistryClient.PutCustomData(clusterName, vs.Namespace, vsName, "VirtualService", ctx.Value("txId").(string), vs)
+			err = registry.RegistryClient.PutCustomData(clusterName, vs.Namespace, vsName, "VirtualService", common.GetTxId(ctx), vs)
 		case common.Update:
-			err = registry.RegistryClient.PutCustomData(clusterName, vs.Namespace, vsName, "VirtualService", ctx.Value("txId").(string), vs)
+			err = registry.RegistryClient.PutCustomData(clusterName, vs.Namespace, vsName, "VirtualService", common.GetTxId(ctx), vs)
 		case common.Delete:
-			err = registry.RegistryClient.DeleteCustomData(clusterName, vs.Namespace, vsName, "VirtualService", ctx.Value("txId").(string))
+			err = registry.RegistryClient.DeleteCustomData(clusterName, vs.Namespace, vsName, "VirtualService", common.GetTxId(ctx))
 		}
 		if err != nil {
 			err = fmt.Errorf(LogFormat, event, "VirtualService", vsName, clusterName, "failed to "+string(event)+" VirtualService with err: "+err.Error())
@@ -404,21 +465,28 @@ func syncVirtualServicesToAllDependentClusters(
 	}
 	var allClusterErrors error
 	var wg sync.WaitGroup
+	sourceRC := remoteRegistry.GetRemoteController(sourceCluster)
+	sourceReference := virtualServiceReference(virtualService)
 	wg.Add(len(clusters))
 	for _, cluster := range clusters {
 		go func(ctx context.Context, cluster string, remoteRegistry *RemoteRegistry, virtualServiceCopy *v1alpha3.VirtualService, event common.Event, syncNamespace string) {
 			defer wg.Done()
-			err := syncVirtualServiceToDependentCluster(
-				ctx,
-				cluster,
-				remoteRegistry,
-				virtualServiceCopy,
-				event,
-				syncNamespace,
-				vSName,
-			)
+			err := common.CallWithRecovery(context.WithValue(ctx, common.ClusterName, cluster), common.VirtualServiceSync, func() error {
+				return syncVirtualServiceToDependentCluster(
+					ctx,
+					cluster,
+					remoteRegistry,
+					virtualServiceCopy,
+					event,
+					syncNamespace,
+					vSName,
+					sourceCluster,
+				)
+			})
 			if err != nil {
 				allClusterErrors = common.AppendError(allClusterErrors, err)
+				recordEvent(sourceRC, sourceReference, coreV1.EventTypeWarning, eventReasonSyncFailed,
+					fmt.Sprintf("failed to sync VirtualService %s to cluster %s: %v", vSName, cluster, err))
 			}
 		}(ctx, cluster, remoteRegistry, virtualService.DeepCopy(), event, syncNamespace)
 	}
@@ -433,7 +501,8 @@ func syncVirtualServiceToDependentCluster(
 	virtualService *v1alpha3.VirtualService,
 	event common.Event,
 	syncNamespace string,
-	vSName string) error {
+	vSName string,
+	sourceCluster string) error {
 
 	ctxLogger := log.WithFields(log.Fields{
 		"type":     "syncVirtualServiceToDependentCluster",
@@ -442,6 +511,7 @@ func syncVirtualServiceToDependentCluster(
 	})
 
 	defer logElapsedTimeForVirtualService("syncVirtualServiceToDependentCluster="+string(event), cluster, virtualService)()
+	sourceReference := virtualServiceReference(virtualService)
 	rc := remoteRegistry.GetRemoteController(cluster)
 	if rc == nil {
 		return fmt.Errorf(LogFormat, "Event", common.VirtualServiceResourceType, vSName,
@@ -451,12 +521,37 @@ func syncVirtualServiceToDependentCluster(
 	if rc.VirtualServiceController == nil {
 		return fmt.Errorf(LogFormat, "Event", common.VirtualServiceResourceType, vSName, cluster, "VirtualService controller not initialized for cluster")
 	}
+	if clusterSyncNamespace := common.GetClusterSyncNamespace(cluster); clusterSyncNamespace != "" {
+		syncNamespace = clusterSyncNamespace
+	}
 
+	sourceNamespace, sourceName, identity := virtualService.Namespace, virtualService.Name, getVirtualServiceIdentity(virtualService)
+	source := sourceNamespace + "/" + sourceName
+	// the copies are written along with the ServiceEntries of the identity, to the namespace it requested
+	if identitySyncNamespace := getIdentitySyncNamespace(remoteRegistry.AdmiralCache, identity); identitySyncNamespace != "" {
+		syncNamespace = identitySyncNamespace
+	}
+	sourceRC := remoteRegistry.GetRemoteController(sourceCluster)
+	delegates := getDelegateReferences(virtualService)
+	delegateSyncNames := getDelegateSyncNames(ctx, sourceRC, delegates)
 	if event == common.Delete {
-		// Best effort delete for existing virtual service with old name
-		_ = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(ctx, virtualService.Name, metav1.DeleteOptions{})
-
+		deleteStaleVirtualServiceSyncNames(ctx, ctxLogger, rc, syncNamespace, sourceNamespace, sourceName, identity, vSName)
+		deletePreviousVirtualServiceCopies(ctx, ctxLogger, rc, vSName, syncNamespace, source)
+
+		// the VirtualService synced with the name is left in place when another source owns it
+		existing, getErr := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, vSName, metav1.GetOptions{})
+		if getErr == nil && isVirtualServiceNameCollision(existing, source) {
+			ctxLogger.Infof(LogFormat, "Delete", common.VirtualServiceResourceType, vSName, cluster,
+				"skipped as the name is owned by "+getVirtualServiceSource(existing))
+			return nil
+		}
+		if err := syncDelegateVirtualServices(ctx, ctxLogger, remoteRegistry, sourceRC, rc, delegates, delegateSyncNames, "", event, syncNamespace); err != nil {
+			ctxLogger.Warnf(LogErrFormat, "Delete", common.VirtualServiceResourceType, vSName, cluster, err)
+		}
 		err := deleteVirtualService(ctx, vSName, syncNamespace, rc)
+		if !isDeadCluster(err) {
+			forgetSkippedWrite(cluster, getSkippedVirtualServiceSyncKey(sourceCluster, sourceNamespace, sourceName))
+		}
 		if err != nil {
 			var vsAlreadyDeletedErr *IsVSAlreadyDeletedErr
 			if errors.As(err, &vsAlreadyDeletedErr) {
@@ -465,6 +560,9 @@ func syncVirtualServiceToDependentCluster(
 			}
 			if isDeadCluster(err) {
 				ctxLogger.Warnf(LogErrFormat, "Create/Update", common.VirtualServiceResourceType, vSName, cluster, "dead cluster")
+				recordDeadClusterSkippedEvent(remoteRegistry, sourceCluster, sourceReference, vSName, cluster)
+				recordSkippedVirtualServiceSync(syncVirtualServiceToDependentCluster, cluster, remoteRegistry, virtualService, sourceName, event, syncNamespace, vSName, sourceCluster)
+				markClusterUnreachable(remoteRegistry, cluster)
 				return nil
 			}
 			return fmt.Errorf(LogErrFormat, "Delete", "VirtualService", vSName, cluster, err)
@@ -473,9 +571,13 @@ func syncVirtualServiceToDependentCluster(
 		return nil
 	}
 
-	oldVSname := virtualService.Name
-	//Update vs name to be unique per namespace
+	//Update vs name to be unique per namespace, the source is recorded so that the
+	//VirtualService can be renamed when the naming strategy changes
 	virtualService.Name = vSName
+	if virtualService.Annotations == nil {
+		virtualService.Annotations = map[string]string{}
+	}
+	virtualService.Annotations[common.AdmiralSourceVSAnnotation] = source
 
 	exist, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, vSName, metav1.GetOptions{})
 	if k8sErrors.IsNotFound(err) {
@@ -484,8 +586,26 @@ func syncVirtualServiceToDependentCluster(
 	}
 	if isDeadCluster(err) {
 		ctxLogger.Warnf(LogErrFormat, "Create/Update", common.VirtualServiceResourceType, vSName, cluster, "dead cluster")
+		recordDeadClusterSkippedEvent(remoteRegistry, sourceCluster, sourceReference, vSName, cluster)
+		recordSkippedVirtualServiceSync(syncVirtualServiceToDependentCluster, cluster, remoteRegistry, virtualService, sourceName, event, syncNamespace, vSName, sourceCluster)
+		markClusterUnreachable(remoteRegistry, cluster)
+		return nil
+	}
+	markClusterReachable(remoteRegistry, cluster)
+	forgetSkippedWrite(cluster, getSkippedVirtualServiceSyncKey(sourceCluster, sourceNamespace, sourceName))
+	// two sources given the same name, e.g. after truncation, would overwrite each other's copy
+	if isVirtualServiceNameCollision(exist, source) {
+		reportVirtualServiceNameCollision(ctxLogger, vSName, getVirtualServiceSource(exist), source, cluster)
 		return nil
 	}
+	// the delegates are synced first, so that the copy of the VirtualService never delegates to a missing copy
+	if len(delegates) > 0 {
+		err = syncDelegateVirtualServices(ctx, ctxLogger, remoteRegistry, sourceRC, rc, delegates, delegateSyncNames, virtualService.Spec.Hosts[0], event, syncNamespace)
+		if err != nil {
+			return err
+		}
+		rewriteDelegateReferences(virtualService, sourceNamespace, syncNamespace, delegateSyncNames)
+	}
 	//change destination host for all http routes <service_name>.<ns>. to same as host on the virtual service
 	for _, httpRoute := range virtualService.Spec.Http {
 		for _, destination := range httpRoute.Route {
@@ -504,12 +624,14 @@ func syncVirtualServiceToDependentCluster(
 		}
 	}
 
-	// nolint
 	err = addUpdateVirtualService(ctxLogger, ctx, virtualService, exist, syncNamespace, rc, remoteRegistry)
-
-	// Best effort delete for existing virtual service with old name
-	_ = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(ctx, oldVSname, metav1.DeleteOptions{})
-
+	if err == nil {
+		// the copies synced with an old name are deleted once the VirtualService is synced with its current name
+		deleteStaleVirtualServiceSyncNames(ctx, ctxLogger, rc, syncNamespace, sourceNamespace, sourceName, identity, vSName)
+		// as are the copies written to the namespaces the identity moved away from
+		deletePreviousVirtualServiceCopies(ctx, ctxLogger, rc, vSName, syncNamespace, source)
+	}
+	// nolint
 	return err
 }
 
@@ -534,21 +656,28 @@ func syncVirtualServicesToAllRemoteClusters(
 	}
 	var allClusterErrors error
 	var wg sync.WaitGroup
+	sourceRC := remoteRegistry.GetRemoteController(sourceCluster)
+	sourceReference := virtualServiceReference(virtualService)
 	wg.Add(len(clusters))
 	for _, cluster := range clusters {
 		go func(ctx context.Context, cluster string, remoteRegistry *RemoteRegistry, virtualServiceCopy *v1alpha3.VirtualService, event common.Event, syncNamespace string) {
 			defer wg.Done()
-			err := syncVirtualServiceToRemoteCluster(
-				ctx,
-				cluster,
-				remoteRegistry,
-				virtualServiceCopy,
-				event,
-				syncNamespace,
-				vSName,
-			)
+			err := common.CallWithRecovery(context.WithValue(ctx, common.ClusterName, cluster), common.VirtualServiceSync, func() error {
+				return syncVirtualServiceToRemoteCluster(
+					ctx,
+					cluster,
+					remoteRegistry,
+					virtualServiceCopy,
+					event,
+					syncNamespace,
+					vSName,
+					sourceCluster,
+				)
+			})
 			if err != nil {
 				allClusterErrors = common.AppendError(allClusterErrors, err)
+				recordEvent(sourceRC, sourceReference, coreV1.EventTypeWarning, eventReasonSyncFailed,
+					fmt.Sprintf("failed to sync VirtualService %s to cluster %s: %v", vSName, cluster, err))
 			}
 		}(ctx, cluster, remoteRegistry, virtualService.DeepCopy(), event, syncNamespace)
 	}
@@ -563,7 +692,8 @@ func syncVirtualServiceToRemoteCluster(
 	virtualService *v1alpha3.VirtualService,
 	event common.Event,
 	syncNamespace string,
-	vSName string) error {
+	vSName string,
+	sourceCluster string) error {
 
 	ctxLogger := log.WithFields(log.Fields{
 		"type":     "syncVirtualServicesToAllRemoteClusters",
@@ -572,6 +702,7 @@ func syncVirtualServiceToRemoteCluster(
 	})
 
 	defer logElapsedTimeForVirtualService("syncVirtualServiceToRemoteCluster="+string(event), cluster, virtualService)()
+	sourceReference := virtualServiceReference(virtualService)
 	rc := remoteRegistry.GetRemoteController(cluster)
 	if rc == nil {
 		return fmt.Errorf(LogFormat, "Event", common.VirtualServiceResourceType, vSName, cluster, "remote controller not initialized for cluster")
@@ -579,12 +710,37 @@ func syncVirtualServiceToRemoteCluster(
 	if rc.VirtualServiceController == nil {
 		return fmt.Errorf(LogFormat, "Event", common.VirtualServiceResourceType, vSName, cluster, "VirtualService controller not initialized for cluster")
 	}
+	if clusterSyncNamespace := common.GetClusterSyncNamespace(cluster); clusterSyncNamespace != "" {
+		syncNamespace = clusterSyncNamespace
+	}
 
+	sourceNamespace, sourceName, identity := virtualService.Namespace, virtualService.Name, getVirtualServiceIdentity(virtualService)
+	source := sourceNamespace + "/" + sourceName
+	// the copies are written along with the ServiceEntries of the identity, to the namespace it requested
+	if identitySyncNamespace := getIdentitySyncNamespace(remoteRegistry.AdmiralCache, identity); identitySyncNamespace != "" {
+		syncNamespace = identitySyncNamespace
+	}
+	sourceRC := remoteRegistry.GetRemoteController(sourceCluster)
+	delegates := getDelegateReferences(virtualService)
+	delegateSyncNames := getDelegateSyncNames(ctx, sourceRC, delegates)
 	if event == common.Delete {
-		// Best effort delete for existing virtual service with old name
-		_ = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(ctx, virtualService.Name, metav1.DeleteOptions{})
-
+		deleteStaleVirtualServiceSyncNames(ctx, ctxLogger, rc, syncNamespace, sourceNamespace, sourceName, identity, vSName)
+		deletePreviousVirtualServiceCopies(ctx, ctxLogger, rc, vSName, syncNamespace, source)
+
+		// the VirtualService synced with the name is left in place when another source owns it
+		existing, getErr := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, vSName, metav1.GetOptions{})
+		if getErr == nil && isVirtualServiceNameCollision(existing, source) {
+			ctxLogger.Infof(LogFormat, "Delete", common.VirtualServiceResourceType, vSName, cluster,
+				"skipped as the name is owned by "+getVirtualServiceSource(existing))
+			return nil
+		}
+		if err := syncDelegateVirtualServices(ctx, ctxLogger, remoteRegistry, sourceRC, rc, delegates, delegateSyncNames, "", event, syncNamespace); err != nil {
+			ctxLogger.Warnf(LogErrFormat, "Delete", common.VirtualServiceResourceType, vSName, cluster, err)
+		}
 		err := deleteVirtualService(ctx, vSName, syncNamespace, rc)
+		if !isDeadCluster(err) {
+			forgetSkippedWrite(cluster, getSkippedVirtualServiceSyncKey(sourceCluster, sourceNamespace, sourceName))
+		}
 		if err != nil {
 			var vsAlreadyDeletedErr *IsVSAlreadyDeletedErr
 			if errors.As(err, &vsAlreadyDeletedErr) {
@@ -593,6 +749,9 @@ func syncVirtualServiceToRemoteCluster(
 			}
 			if isDeadCluster(err) {
 				ctxLogger.Warnf(LogErrFormat, "Delete", common.VirtualServiceResourceType, vSName, cluster, "dead cluster")
+				recordDeadClusterSkippedEvent(remoteRegistry, sourceCluster, sourceReference, vSName, cluster)
+				recordSkippedVirtualServiceSync(syncVirtualServiceToRemoteCluster, cluster, remoteRegistry, virtualService, sourceName, event, syncNamespace, vSName, sourceCluster)
+				markClusterUnreachable(remoteRegistry, cluster)
 				return nil
 			}
 
@@ -601,9 +760,13 @@ func syncVirtualServiceToRemoteCluster(
 		ctxLogger.Infof(LogFormat, "Delete", common.VirtualServiceResourceType, vSName, cluster, "Success")
 		return nil
 	}
-	oldVSname := virtualService.Name
-	//Update vs name to be unique per namespace
+	//Update vs name to be unique per namespace, the source is recorded so that the
+	//VirtualService can be renamed when the naming strategy changes
 	virtualService.Name = vSName
+	if virtualService.Annotations == nil {
+		virtualService.Annotations = map[string]string{}
+	}
+	virtualService.Annotations[common.AdmiralSourceVSAnnotation] = source
 	exist, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, vSName, metav1.GetOptions{})
 	if k8sErrors.IsNotFound(err) {
 		ctxLogger.Infof(LogFormat, "Get", common.VirtualServiceResourceType, vSName, cluster, "VirtualService does not exist")
@@ -611,25 +774,46 @@ func syncVirtualServiceToRemoteCluster(
 	}
 	if isDeadCluster(err) {
 		ctxLogger.Warnf(LogErrFormat, "Create/Update", common.VirtualServiceResourceType, vSName, cluster, "dead cluster")
+		recordDeadClusterSkippedEvent(remoteRegistry, sourceCluster, sourceReference, vSName, cluster)
+		recordSkippedVirtualServiceSync(syncVirtualServiceToRemoteCluster, cluster, remoteRegistry, virtualService, sourceName, event, syncNamespace, vSName, sourceCluster)
+		markClusterUnreachable(remoteRegistry, cluster)
+		return nil
+	}
+	markClusterReachable(remoteRegistry, cluster)
+	forgetSkippedWrite(cluster, getSkippedVirtualServiceSyncKey(sourceCluster, sourceNamespace, sourceName))
+	// two sources given the same name, e.g. after truncation, would overwrite each other's copy
+	if isVirtualServiceNameCollision(exist, source) {
+		reportVirtualServiceNameCollision(ctxLogger, vSName, getVirtualServiceSource(exist), source, cluster)
 		return nil
 	}
+	if len(delegates) > 0 {
+		err = syncDelegateVirtualServices(ctx, ctxLogger, remoteRegistry, sourceRC, rc, delegates, delegateSyncNames, "", event, syncNamespace)
+		if err != nil {
+			return err
+		}
+		rewriteDelegateReferences(virtualService, sourceNamespace, syncNamespace, delegateSyncNames)
+	}
 
 	err = addUpdateVirtualService(ctxLogger, ctx, virtualService, exist, syncNamespace, rc, remoteRegistry)
-
-	// Best effort delete of existing virtual service with old name
-	_ = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Delete(ctx, oldVSname, metav1.DeleteOptions{})
+	if err == nil {
+		// the copies synced with an old name are deleted once the VirtualService is synced with its current name
+		deleteStaleVirtualServiceSyncNames(ctx, ctxLogger, rc, syncNamespace, sourceNamespace, sourceName, identity, vSName)
+		// as are the copies written to the namespaces the identity moved away from
+		deletePreviousVirtualServiceCopies(ctx, ctxLogger, rc, vSName, syncNamespace, source)
+	}
 	// nolint
 	return err
 }
 
+// matchRolloutCanaryStrategy checks if the canary strategy shapes traffic with the VirtualService,
+// through either its virtualService or one of its virtualServices
 func matchRolloutCanaryStrategy(rolloutStrategy argo.RolloutStrategy, virtualServiceName string) bool {
-	if rolloutStrategy.Canary == nil ||
-		rolloutStrategy.Canary.TrafficRouting == nil ||
-		rolloutStrategy.Canary.TrafficRouting.Istio == nil ||
-		rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService == nil {
-		return false
+	for _, virtualService := range common.GetCanaryIstioVirtualServices(rolloutStrategy) {
+		if virtualService.Name == virtualServiceName {
+			return true
+		}
 	}
-	return rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Name == virtualServiceName
+	return false
 }
 
 /*
@@ -649,18 +833,10 @@ func updateVirtualService(
 	if rc == nil {
 		return fmt.Errorf("remoteController is nil")
 	}
-	maxRetries := 5
-	var err error
-	for i := 0; i < maxRetries; i++ {
-		_, err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, vs, metav1.UpdateOptions{})
-		if err == nil {
-			break
-		}
-	}
-	if err != nil {
+	return common.RetryWithBackoff(ctx, common.GetWriteRetryPolicy(), func(int) error {
+		_, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, vs, metav1.UpdateOptions{})
 		return err
-	}
-	return nil
+	})
 }
 
 /*
@@ -679,6 +855,12 @@ func addUpdateVirtualService(
 		op      string
 		newCopy = new.DeepCopy()
 	)
+	existCopy := exist.DeepCopy()
+	if queueWriteIfPaused(ctx, rc.ClusterID, common.VirtualServiceResourceType, namespace, newCopy.Name, func(ctx context.Context, rc *RemoteController) error {
+		return addUpdateVirtualService(ctxLogger, ctx, newCopy, existCopy, namespace, rc, rr)
+	}) {
+		return nil
+	}
 
 	format := "virtualservice %s before: %v, after: %v;"
 
@@ -686,6 +868,10 @@ func addUpdateVirtualService(
 		newCopy.Annotations = map[string]string{}
 	}
 	newCopy.Annotations["app.kubernetes.io/created-by"] = "admiral"
+	stampTxId(ctx, newCopy)
+	stampOwnerLabels(ctx, newCopy)
+	stampSyncNamespaceLabel(newCopy, rc.ClusterID, namespace)
+	stampCoexistenceAnnotations(newCopy)
 
 	skipAddingExportTo := false
 	//Check if VS has the admiral.io/vs-routing label
@@ -701,12 +887,29 @@ func addUpdateVirtualService(
 		delete(newCopy.Annotations, ignored)
 	}
 
-	if common.EnableExportTo(newCopy.Spec.Hosts[0]) && !skipAddingExportTo {
+	// delegate VirtualServices have no hosts, they keep the exportTo they are defined with
+	if len(newCopy.Spec.Hosts) > 0 && common.EnableExportToForCluster(newCopy.Spec.Hosts[0], rc.ClusterID) && !skipAddingExportTo {
 		sortedDependentNamespaces := getSortedDependentNamespaces(
 			rr.AdmiralCache, newCopy.Spec.Hosts[0], rc.ClusterID, ctxLogger, false)
 		newCopy.Spec.ExportTo = sortedDependentNamespaces
 		ctxLogger.Infof(LogFormat, "ExportTo", common.VirtualServiceResourceType, newCopy.Name, rc.ClusterID, fmt.Sprintf("VS usecase-ExportTo updated to %v", newCopy.Spec.ExportTo))
 	}
+	if err = evaluateResourcePolicy(ctxLogger, ctx, common.VirtualServiceResourceType, rc.ClusterID, newCopy); err != nil {
+		return err
+	}
+	if err = validateGeneratedResource(ctxLogger, ctx, common.VirtualServiceResourceType, rc, newCopy); err != nil {
+		return err
+	}
+	if common.IsGitOpsOutputMode() {
+		return renderGitOpsResource(ctxLogger, rc.ClusterID, common.VirtualServiceResourceType, namespace, newCopy)
+	}
+	if common.IsDiffOnlyMode() {
+		var existState *resourceState
+		if exist != nil {
+			existState = newResourceState(exist, &exist.Spec)
+		}
+		return recordResourceDiff(ctx, rc.ClusterID, common.VirtualServiceResourceType, namespace, newCopy.Name, existState, newResourceState(newCopy, &newCopy.Spec))
+	}
 	vsAlreadyExists := false
 	if exist == nil {
 		op = "Add"
@@ -721,9 +924,13 @@ func addUpdateVirtualService(
 				fmt.Sprintf("skipping create virtualservice and it already exists for cluster: %s VirtualService name=%s",
 					rc.ClusterID, newCopy.Name))
 			vsAlreadyExists = true
+		} else {
+			auditMutation(ctx, audit.OperationCreate, common.VirtualServiceResourceType, rc.ClusterID, newCopy, namespace, "", err)
 		}
 	}
 	if exist != nil || vsAlreadyExists {
+		// the changes are sent as a merge patch when enabled, unless the existing virtualservice could not be fetched
+		patch := common.EnableMergePatchUpdates()
 		if vsAlreadyExists {
 			exist, err = rc.VirtualServiceController.IstioClient.
 				NetworkingV1alpha3().
@@ -734,6 +941,7 @@ func addUpdateVirtualService(
 				// which will fail in the update operation, but will be retried
 				// in the retry logic
 				exist = newCopy
+				patch = false
 				ctxLogger.Warnf(common.CtxLogFormat, "Update", exist.Name, exist.Namespace, rc.ClusterID, "got error on fetching se, will retry updating")
 			}
 		}
@@ -741,58 +949,68 @@ func addUpdateVirtualService(
 		ctxLogger.Infof(LogFormat, op, common.VirtualServiceResourceType, newCopy.Name, rc.ClusterID,
 			fmt.Sprintf("existing virtualservice for cluster: %s VirtualService name=%s",
 				rc.ClusterID, newCopy.Name))
+		if ownershipErr := checkOwnership(ctxLogger, common.VirtualServiceResourceType, rc.ClusterID, exist); ownershipErr != nil {
+			return ownershipErr
+		}
 		ctxLogger.Infof(format, op, exist.Spec.String(), newCopy.Spec.String())
+		detectExternalRevert(ctxLogger, rc.ClusterID, common.VirtualServiceResourceType, exist, &exist.Spec, &newCopy.Spec)
+		diff := summarizeChanges(exist, newCopy, &exist.Spec, &newCopy.Spec)
+		original := exist.DeepCopy()
 		exist.Labels = newCopy.Labels
 		exist.Annotations = newCopy.Annotations
 		//nolint
 		exist.Spec = newCopy.Spec
-		_, err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, exist, metav1.UpdateOptions{})
+		if patch {
+			err = patchVirtualService(ctx, rc, namespace, original, exist)
+		} else {
+			_, err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, exist, metav1.UpdateOptions{})
+		}
 		if err != nil {
 			err = retryUpdatingVS(ctxLogger, ctx, newCopy, exist, namespace, rc, err, op)
 		}
+		auditMutation(ctx, audit.OperationUpdate, common.VirtualServiceResourceType, rc.ClusterID, newCopy, namespace, diff, err)
 	}
 
 	if err != nil {
 		ctxLogger.Errorf(LogErrFormat, op, common.VirtualServiceResourceType, newCopy.Name, rc.ClusterID, err)
 		return err
 	}
+	recordWrittenSpec(rc.ClusterID, common.VirtualServiceResourceType, namespace, newCopy.Name, &newCopy.Spec)
+	recordConfigHistory(ctx, rc.ClusterID, common.VirtualServiceResourceType, newCopy)
 	ctxLogger.Infof(LogFormat, op, common.VirtualServiceResourceType, newCopy.Name, rc.ClusterID, "ExportTo: "+strings.Join(newCopy.Spec.ExportTo, " ")+" Success")
 	return nil
 }
 
 func retryUpdatingVS(ctxLogger *log.Entry, ctx context.Context, obj *v1alpha3.VirtualService,
 	exist *v1alpha3.VirtualService, namespace string, rc *RemoteController, err error, op string) error {
-	numRetries := 5
-	if err != nil && k8sErrors.IsConflict(err) {
-		for i := 0; i < numRetries; i++ {
-			vsIdentity := ""
-			if obj.Annotations != nil {
-				vsIdentity = obj.Labels[common.GetWorkloadIdentifier()]
-			}
-			ctxLogger.Errorf(LogFormatNew, op, common.VirtualServiceResourceType, obj.Name, obj.Namespace,
-				vsIdentity, rc.ClusterID, err.Error()+". will retry the update operation before adding back to the controller queue.")
-
-			updatedVS, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().
-				VirtualServices(namespace).Get(ctx, exist.Name, metav1.GetOptions{})
-			if err != nil {
-				ctxLogger.Infof(LogFormatNew, op, common.VirtualServiceResourceType, exist.Name, exist.Namespace,
-					vsIdentity, rc.ClusterID, err.Error()+fmt.Sprintf(". Error getting virtualservice"))
-				continue
-			}
+	if err == nil || !common.IsRetryableWriteError(err) {
+		return err
+	}
+	vsIdentity := ""
+	if obj.Annotations != nil {
+		vsIdentity = obj.Labels[common.GetWorkloadIdentifier()]
+	}
+	return common.RetryAfterFirstAttempt(ctx, common.GetWriteRetryPolicy(), err, func(int) error {
+		ctxLogger.Errorf(LogFormatNew, op, common.VirtualServiceResourceType, obj.Name, obj.Namespace,
+			vsIdentity, rc.ClusterID, err.Error()+". will retry the update operation before adding back to the controller queue.")
 
-			ctxLogger.Infof(LogFormatNew, op, common.VirtualServiceResourceType, obj.Name, obj.Namespace,
-				vsIdentity, rc.ClusterID, fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s",
-					updatedVS.ResourceVersion, obj.ResourceVersion))
-			updatedVS.Spec = obj.Spec
-			updatedVS.Labels = obj.Labels
-			updatedVS.Annotations = obj.Annotations
-			_, err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, updatedVS, metav1.UpdateOptions{})
-			if err == nil {
-				return nil
-			}
+		updatedVS, getErr := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().
+			VirtualServices(namespace).Get(ctx, exist.Name, metav1.GetOptions{})
+		if getErr != nil {
+			ctxLogger.Infof(LogFormatNew, op, common.VirtualServiceResourceType, exist.Name, exist.Namespace,
+				vsIdentity, rc.ClusterID, getErr.Error()+". Error getting virtualservice")
+			return err
 		}
-	}
-	return err
+
+		ctxLogger.Infof(LogFormatNew, op, common.VirtualServiceResourceType, obj.Name, obj.Namespace,
+			vsIdentity, rc.ClusterID, fmt.Sprintf("existingResourceVersion=%s resourceVersionUsedForUpdate=%s",
+				updatedVS.ResourceVersion, obj.ResourceVersion))
+		updatedVS.Spec = obj.Spec
+		updatedVS.Labels = obj.Labels
+		updatedVS.Annotations = obj.Annotations
+		_, err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Update(ctx, updatedVS, metav1.UpdateOptions{})
+		return err
+	})
 }
 
 func isDeadCluster(err error) bool {
@@ -828,18 +1046,27 @@ func createVirtualServiceSkeleton(vs networkingV1Alpha3.VirtualService, name str
 }
 
 func deleteVirtualService(ctx context.Context, vsName string, namespace string, rc *RemoteController) error {
-	err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Delete(ctx, vsName, metaV1.DeleteOptions{})
-	if err == nil {
+	if queueWriteIfPaused(ctx, rc.ClusterID, common.VirtualServiceResourceType, namespace, vsName, func(ctx context.Context, rc *RemoteController) error {
+		return deleteVirtualService(ctx, vsName, namespace, rc)
+	}) {
 		return nil
 	}
+	if common.IsGitOpsOutputMode() {
+		return removeGitOpsResource(rc.ClusterID, common.VirtualServiceResourceType, namespace, vsName)
+	}
+	if common.IsDiffOnlyMode() {
+		return recordResourceDiff(ctx, rc.ClusterID, common.VirtualServiceResourceType, namespace, vsName, &resourceState{}, nil)
+	}
+	forgetWrittenSpec(rc.ClusterID, common.VirtualServiceResourceType, namespace, vsName)
+	forgetConfigHistory(ctx, rc.ClusterID, common.VirtualServiceResourceType, namespace, vsName)
+	err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Delete(ctx, vsName, metaV1.DeleteOptions{})
 	if k8sErrors.IsNotFound(err) {
-		err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Delete(ctx, strings.ToLower(vsName), metaV1.DeleteOptions{})
-		if err == nil {
-			return nil
-		}
+		vsName = strings.ToLower(vsName)
+		err = rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(namespace).Delete(ctx, vsName, metaV1.DeleteOptions{})
 		if k8sErrors.IsNotFound(err) {
 			return &IsVSAlreadyDeletedErr{vsAlreadyDeletedMsg}
 		}
 	}
+	auditMutation(ctx, audit.OperationDelete, common.VirtualServiceResourceType, rc.ClusterID, &metaV1.ObjectMeta{Name: vsName}, namespace, "", err)
 	return err
 }
@@ -46,7 +46,9 @@ func TestHandleVirtualServiceEvent(t *testing.T) {
 		remoteRegistry                           = NewRemoteRegistry(ctx, common.AdmiralParams{})
 		remoteRegistryWithDependents             = newRemoteRegistryWithDependents(ctx, cname1, dependentCluster1)
 		remoteRegistryWithDependentsAndSource    = newRemoteRegistryWithSourceClusters(ctx, cname1, clusterID, dependentCluster1)
+		remoteRegistryWithCnameOwner             = newRemoteRegistryWithDependents(ctx, cname1, dependentCluster1)
 	)
+	remoteRegistryWithCnameOwner.AdmiralCache.CnameIdentityCache.Store(cname1, "foo")
 	cases := []struct {
 		name                                         string
 		virtualService                               *apiNetworkingV1Alpha3.VirtualService
@@ -364,6 +366,66 @@ func TestHandleVirtualServiceEvent(t *testing.T) {
 			expectToCallSyncResourceForAllClusters:       false,
 			expectedErr:                                  nil,
 		},
+		{
+			name: "Given a custom VirtualService created for the identity owning its host, " +
+				"When, handleVirtualServiceEvent is invoked, " +
+				"Then, syncVirtualServiceForDependentClusters should be called",
+			params: common.AdmiralParams{
+				SyncNamespace: syncNamespace,
+			},
+			remoteRegistry: remoteRegistryWithCnameOwner,
+			virtualService: &apiNetworkingV1Alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs", Namespace: syncNamespace, Labels: map[string]string{common.CreatedFor: "foo"}},
+				Spec: networkingV1Alpha3.VirtualService{
+					Hosts: []string{cname1},
+				},
+			},
+			updateResource:                               newFakeUpdateResource(false, nil),
+			syncResourceForDependentClusters:             newFakeSyncResource(nil),
+			syncResourceForAllClusters:                   newFakeSyncResource(nil),
+			expectToCallSyncResourceForDependentClusters: true,
+			expectedErr:                                  nil,
+		},
+		{
+			name: "Given another custom VirtualService created for the identity owning its host, " +
+				"When, handleVirtualServiceEvent is invoked, " +
+				"Then, syncVirtualServiceForDependentClusters should be called as the VirtualServices are merged",
+			params: common.AdmiralParams{
+				SyncNamespace: syncNamespace,
+			},
+			remoteRegistry: remoteRegistryWithCnameOwner,
+			virtualService: &apiNetworkingV1Alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo-canary-vs", Namespace: syncNamespace, Labels: map[string]string{common.CreatedFor: "Foo"}},
+				Spec: networkingV1Alpha3.VirtualService{
+					Hosts: []string{cname1},
+				},
+			},
+			updateResource:                               newFakeUpdateResource(false, nil),
+			syncResourceForDependentClusters:             newFakeSyncResource(nil),
+			syncResourceForAllClusters:                   newFakeSyncResource(nil),
+			expectToCallSyncResourceForDependentClusters: true,
+			expectedErr:                                  nil,
+		},
+		{
+			name: "Given a custom VirtualService created for another identity than the one owning its host, " +
+				"When, handleVirtualServiceEvent is invoked, " +
+				"Then, it should be refused and not be synced",
+			params: common.AdmiralParams{
+				SyncNamespace: syncNamespace,
+			},
+			remoteRegistry: remoteRegistryWithCnameOwner,
+			virtualService: &apiNetworkingV1Alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "bar-vs", Namespace: syncNamespace, Labels: map[string]string{common.CreatedFor: "bar"}},
+				Spec: networkingV1Alpha3.VirtualService{
+					Hosts: []string{cname1},
+				},
+			},
+			updateResource:                               newFakeUpdateResource(false, nil),
+			syncResourceForDependentClusters:             newFakeSyncResource(nil),
+			syncResourceForAllClusters:                   newFakeSyncResource(nil),
+			expectToCallSyncResourceForDependentClusters: false,
+			expectedErr:                                  nil,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -1476,6 +1538,66 @@ func TestVirtualSvcHandlerCUDScenarios(t *testing.T) {
 	}
 }
 
+func TestVirtualServiceHandlerAdmiralGeneratedVirtualService(t *testing.T) {
+	ctx := context.Background()
+	admiralParams := common.AdmiralParams{
+		LabelSet:                      &common.LabelSet{},
+		SyncNamespace:                 "test-sync-ns",
+		EnableIdentitySyncNamespace:   true,
+		AllowedIdentitySyncNamespaces: []string{"team-sync"},
+	}
+	common.ResetSync()
+	common.InitializeConfig(admiralParams)
+	commonUtil.CurrentAdmiralState.ReadOnly = false
+	rr := NewRemoteRegistry(ctx, admiralParams)
+	rr.AdmiralCache.IdentitySyncNamespaceCache.Put("foo", "team-sync")
+
+	testCases := []struct {
+		name           string
+		namespace      string
+		expectedSynced bool
+	}{
+		{
+			name: "Given a VirtualService generated by admiral, " +
+				"When it is in the sync namespace requested by an identity, " +
+				"Then it should not be synced",
+			namespace:      "team-sync",
+			expectedSynced: false,
+		},
+		{
+			name: "Given a VirtualService generated by admiral, " +
+				"When it is outside the sync namespaces, " +
+				"Then it should be synced",
+			namespace:      "test-ns",
+			expectedSynced: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			synced := false
+			vsHandler := &VirtualServiceHandler{
+				clusterID:      "test-cluster",
+				remoteRegistry: rr,
+				syncVirtualServiceForAllClusters: func(ctx context.Context, clusters []string, virtualService *apiNetworkingV1Alpha3.VirtualService,
+					event common.Event, remoteRegistry *RemoteRegistry, sourceCluster string, syncNamespace string, vsName string) error {
+					synced = true
+					return nil
+				},
+			}
+			vs := &apiNetworkingV1Alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:        "my-vs",
+					Namespace:   c.namespace,
+					Annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue},
+				},
+				Spec: networkingV1Alpha3.VirtualService{Hosts: []string{"stage.foo.global"}},
+			}
+			assert.NoError(t, vsHandler.Added(ctx, vs))
+			assert.Equal(t, c.expectedSynced, synced)
+		})
+	}
+}
+
 func TestDeleteVirtualService(t *testing.T) {
 	ctx := context.Background()
 	namespace := "testns"
@@ -2301,3 +2423,55 @@ func TestToUpperFirst(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchRolloutCanaryStrategy(t *testing.T) {
+	istioStrategy := func(istio *v1alpha1.IstioTrafficRouting) v1alpha1.RolloutStrategy {
+		return v1alpha1.RolloutStrategy{Canary: &v1alpha1.CanaryStrategy{
+			TrafficRouting: &v1alpha1.RolloutTrafficRouting{Istio: istio},
+		}}
+	}
+	testCases := []struct {
+		name     string
+		strategy v1alpha1.RolloutStrategy
+		expected bool
+	}{
+		{
+			name: "Given a blue-green strategy, " +
+				"When matchRolloutCanaryStrategy is called, " +
+				"Then it should return false",
+			strategy: v1alpha1.RolloutStrategy{BlueGreen: &v1alpha1.BlueGreenStrategy{}},
+		},
+		{
+			name: "Given a canary strategy referencing the VirtualService with virtualService, " +
+				"When matchRolloutCanaryStrategy is called, " +
+				"Then it should return true",
+			strategy: istioStrategy(&v1alpha1.IstioTrafficRouting{
+				VirtualService: &v1alpha1.IstioVirtualService{Name: "test-vs", Routes: []string{"primary"}},
+			}),
+			expected: true,
+		},
+		{
+			name: "Given a canary strategy referencing the VirtualService with virtualServices, " +
+				"When matchRolloutCanaryStrategy is called, " +
+				"Then it should return true",
+			strategy: istioStrategy(&v1alpha1.IstioTrafficRouting{
+				VirtualServices: []v1alpha1.IstioVirtualService{{Name: "other-vs"}, {Name: "test-vs", Routes: []string{"primary"}}},
+			}),
+			expected: true,
+		},
+		{
+			name: "Given a canary strategy referencing other VirtualServices, " +
+				"When matchRolloutCanaryStrategy is called, " +
+				"Then it should return false",
+			strategy: istioStrategy(&v1alpha1.IstioTrafficRouting{
+				VirtualService:  &v1alpha1.IstioVirtualService{Name: "other-vs"},
+				VirtualServices: []v1alpha1.IstioVirtualService{{Name: "another-vs"}},
+			}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchRolloutCanaryStrategy(tc.strategy, "test-vs"))
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	k8sAppsV1 "k8s.io/api/apps/v1"
 	k8sV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -66,9 +67,8 @@ func getBaseInClusterVirtualService() (*v1alpha3.VirtualService, error) {
 
 // getBaseVirtualServiceForIngress generates the base virtual service for the ingress gateway
 // This is just the barebones of the ingress virtual service
-func getBaseVirtualServiceForIngress() (*v1alpha3.VirtualService, error) {
+func getBaseVirtualServiceForIngress(gateways []string) (*v1alpha3.VirtualService, error) {
 
-	gateways := common.GetVSRoutingGateways()
 	if len(gateways) == 0 {
 		return nil, fmt.Errorf("no gateways configured for ingress virtual service")
 	}
@@ -376,7 +376,7 @@ func populateDestinationsForBlueGreenStrategy(
 	}
 
 	previewServiceName := rollout.Spec.Strategy.BlueGreen.PreviewService
-	if weightedPreviewService, ok := weightedServices[previewServiceName]; ok {
+	if weightedPreviewService, ok := weightedServices[previewServiceName]; ok && !isBlueGreenPreviewPromoted(rollout) {
 		previewFQDN, err := getPreviewFQDNFromRollout(rollout)
 		if err != nil {
 			return err
@@ -479,6 +479,7 @@ func generateVirtualServiceForIncluster(
 	ctx context.Context,
 	ctxLogger *log.Entry,
 	destination map[string][]*vsrouting.RouteDestination,
+	meshPorts map[string]uint32,
 	vsName string,
 	remoteRegistry *RemoteRegistry,
 	sourceCluster string,
@@ -492,6 +493,7 @@ func generateVirtualServiceForIncluster(
 
 	vsHosts := make([]string, 0)
 	httpRoutes := make([]*networkingV1Alpha3.HTTPRoute, 0)
+	h2MeshPorts := getH2MeshPorts(meshPorts)
 
 	for globalFQDN, routeDestinations := range destination {
 		if routeDestinations == nil || len(routeDestinations) == 0 {
@@ -514,6 +516,9 @@ func generateVirtualServiceForIncluster(
 			httpRouteDestinations = append(httpRouteDestinations, routeDestination.ToHTTPRouteDestination())
 		}
 		httpRoute.Route = httpRouteDestinations
+		if isGrpcRoute(routeDestinations, h2MeshPorts) {
+			httpRoute.Retries = getGrpcHTTPRetry()
+		}
 		httpRoutes = append(httpRoutes, &httpRoute)
 		vsHosts = append(vsHosts, globalFQDN)
 	}
@@ -536,9 +541,9 @@ func generateVirtualServiceForIncluster(
 	virtualService.Name = fmt.Sprintf("%s-%s", vsName, common.InclusterVSNameSuffix)
 
 	// Add the exportTo namespaces to the virtual service
-	virtualService.Spec.ExportTo = []string{common.GetSyncNamespace()}
+	virtualService.Spec.ExportTo = []string{common.GetSyncNamespaceForCluster(sourceCluster)}
 	vsRoutingInclusterEnabledForClusterAndIdentity := false
-	if common.EnableExportTo(vsName) &&
+	if common.EnableExportToForCluster(vsName, sourceCluster) &&
 		DoVSRoutingInClusterForClusterAndIdentity(ctx, ctxLogger, env, sourceCluster, sourceIdentity, remoteRegistry) {
 		vsRoutingInclusterEnabledForClusterAndIdentity = true
 		virtualService.Spec.ExportTo = getSortedDependentNamespaces(
@@ -554,9 +559,10 @@ func generateVirtualServiceForIncluster(
 // generateVirtualServiceForIngress generates the VirtualService for the cross-cluster routing
 func generateVirtualServiceForIngress(
 	destination map[string][]*vsrouting.RouteDestination,
-	vsName string) (*v1alpha3.VirtualService, error) {
+	vsName string,
+	gateways []string) (*v1alpha3.VirtualService, error) {
 
-	virtualService, err := getBaseVirtualServiceForIngress()
+	virtualService, err := getBaseVirtualServiceForIngress(gateways)
 	if err != nil {
 		return nil, err
 	}
@@ -678,6 +684,7 @@ func addUpdateInClusterVirtualServices(
 	ctxLogger *log.Entry,
 	remoteRegistry *RemoteRegistry,
 	sourceClusterToDestinations map[string]map[string][]*vsrouting.RouteDestination,
+	sourceClusterToMeshPorts map[string]map[string]uint32,
 	cname string,
 	sourceIdentity string,
 	env string) error {
@@ -709,7 +716,7 @@ func addUpdateInClusterVirtualServices(
 		}
 
 		virtualService, err := generateVirtualServiceForIncluster(
-			ctx, ctxLogger, destination, cname, remoteRegistry, sourceCluster, sourceIdentity, env)
+			ctx, ctxLogger, destination, sourceClusterToMeshPorts[sourceCluster], cname, remoteRegistry, sourceCluster, sourceIdentity, env)
 		if err != nil {
 			ctxLogger.Errorf(common.CtxLogFormat, "addUpdateInClusterVirtualServices",
 				"", "", sourceCluster, err.Error())
@@ -955,7 +962,7 @@ func performDRPinning(ctx context.Context,
 			drName = fmt.Sprintf("%s-dr", host)
 		}
 		// Get DR from cache
-		cachedDR := rc.DestinationRuleController.Cache.Get(drName, common.GetSyncNamespace())
+		cachedDR := rc.DestinationRuleController.Cache.Get(drName, common.GetSyncNamespaceForCluster(rc.ClusterID))
 		if cachedDR == nil {
 			errs = append(errs, fmt.Errorf(
 				"skipped pinning DR to remote region as no cached DR found with drName %s in cluster %s",
@@ -980,18 +987,18 @@ func performDRPinning(ctx context.Context,
 			continue
 		}
 		doReconcileDR := reconcileDestinationRule(
-			ctxLogger, true, rc, &newDR.Spec, drName, sourceCluster, common.GetSyncNamespace())
+			ctxLogger, true, rc, &newDR.Spec, drName, sourceCluster, common.GetSyncNamespaceForCluster(rc.ClusterID))
 		if !doReconcileDR {
 			continue
 		}
-		err = addUpdateDestinationRule(ctxLogger, ctx, newDR, cachedDR, common.GetSyncNamespace(), rc, remoteRegistry)
+		err = addUpdateDestinationRule(ctxLogger, ctx, newDR, cachedDR, common.GetSyncNamespaceForCluster(rc.ClusterID), rc, remoteRegistry)
 		if err != nil {
 			errs = append(errs, fmt.Errorf(
 				"performDRPinning failed for DR %s in cluster %s: %w", drName, sourceCluster, err))
 			continue
 		}
 		ctxLogger.Infof(common.CtxLogFormat, "performDRPinning",
-			drName, common.GetSyncNamespace(), sourceCluster, "DR pinning completed successfully")
+			drName, common.GetSyncNamespaceForCluster(rc.ClusterID), sourceCluster, "DR pinning completed successfully")
 	}
 
 	return errors.Join(errs...)
@@ -1062,13 +1069,20 @@ func mergeCustomVirtualServices(
 		return mergedVirtualServices, nil
 	}
 	for _, tuple := range customVirtualServices {
+		// the routes of the delegates are merged in place of the routes delegating to them,
+		// as the merged VirtualService is not in the namespace of the delegates
+		customVS, err := resolveDelegateRoutes(ctx, ctxLogger, rc, tuple.customVS)
+		if err != nil {
+			return nil, err
+		}
 		// env matches for which the event was received
 		// then use the virtualService passed to this func
 		if tuple.env == env {
-			mergedVirtualService, err := mergeVS(tuple.customVS, virtualService, rc)
+			mergedVirtualService, err := mergeVS(customVS, virtualService, rc)
 			if err != nil {
 				return nil, err
 			}
+			setMergedRouteOrder(mergedVirtualService)
 			err = rc.VirtualServiceController.HostToRouteDestinationCache.Put(mergedVirtualService)
 			if err != nil {
 				return nil, err
@@ -1089,10 +1103,11 @@ func mergeCustomVirtualServices(
 				fmt.Sprintf("no custom virtualservice found for env %s", tuple.env))
 			continue
 		}
-		mergedVirtualService, err := mergeVS(tuple.customVS, vsFromCache, rc)
+		mergedVirtualService, err := mergeVS(customVS, vsFromCache, rc)
 		if err != nil {
 			return nil, err
 		}
+		setMergedRouteOrder(mergedVirtualService)
 		mergedVirtualServices = append(
 			mergedVirtualServices, mergedVirtualService)
 	}
@@ -1107,6 +1122,8 @@ func mergeCustomVirtualServices(
 // index.
 // Example: if the env passed is stage and the createdForEnv in the VS has "stage1_stage2_stage"
 // []{{"stage":virtualService}, {"stage1": virtualService}, {"stage2": virtualService}}
+// When several custom VSs are created for the env, the VSs of each env are combined into one,
+// ordered by the admiral.io/custom-vs-priority annotation and then by name.
 func getCustomVirtualService(
 	ctx context.Context,
 	ctxLogger *log.Entry,
@@ -1129,7 +1146,7 @@ func getCustomVirtualService(
 		common.CreatedBy:  common.GetProcessVSCreatedBy(),
 	}}
 
-	virtualServiceList, err := getAllVirtualServices(ctxLogger, ctx, rc, common.GetSyncNamespace(),
+	virtualServiceList, err := getAllVirtualServices(ctxLogger, ctx, rc, common.GetSyncNamespaceForCluster(rc.ClusterID),
 		metaV1.ListOptions{
 			LabelSelector: labels.Set(labelSelector.MatchLabels).String(),
 		})
@@ -1141,45 +1158,101 @@ func getCustomVirtualService(
 	if len(virtualServiceList.Items) == 0 {
 		return nil, nil
 	}
-	var matchedVS envCustomVSTuple
-
 	finalVirtualServices := make([]envCustomVSTuple, 0)
-	foundMatchingVirtualServices := false
-
+	matchedVirtualServices := make([]*v1alpha3.VirtualService, 0)
 	for _, vs := range virtualServiceList.Items {
-		vsForOtherEnvs := make([]envCustomVSTuple, 0)
-		annotations := vs.Annotations
-		if annotations == nil {
-			continue
-		}
-		createdForEnv, ok := annotations[common.CreatedForEnv]
-		if !ok {
-			continue
+		//This is to handle multi env usecase
+		if slices.Contains(getCustomVirtualServiceEnvs(vs), env) {
+			matchedVirtualServices = append(matchedVirtualServices, vs)
 		}
-		if createdForEnv == "" {
-			continue
+	}
+	if len(matchedVirtualServices) == 0 {
+		return finalVirtualServices, nil
+	}
+
+	// The custom VSs are merged by descending priority, and by name when the priority is the same,
+	// so that the merged routes do not depend on the order the VSs are listed in
+	sort.SliceStable(matchedVirtualServices, func(i, j int) bool {
+		iPriority, jPriority := getCustomVirtualServicePriority(matchedVirtualServices[i]), getCustomVirtualServicePriority(matchedVirtualServices[j])
+		if iPriority != jPriority {
+			return iPriority > jPriority
 		}
-		//This is to handle multi env usecase
-		splitEnvs := strings.Split(createdForEnv, "_")
-		for _, splitEnv := range splitEnvs {
-			if splitEnv == env {
-				matchedVS = envCustomVSTuple{env: env, customVS: vs}
-				foundMatchingVirtualServices = true
-				continue
+		return matchedVirtualServices[i].Name < matchedVirtualServices[j].Name
+	})
+	matchedVirtualServices = enforceCustomVirtualServiceQuota(ctxLogger, rc, identity, env, matchedVirtualServices)
+
+	// The matched env should be added at the 0th index
+	envs := []string{env}
+	virtualServicesByEnv := make(map[string][]*v1alpha3.VirtualService)
+	for _, vs := range matchedVirtualServices {
+		for _, splitEnv := range getCustomVirtualServiceEnvs(vs) {
+			if _, ok := virtualServicesByEnv[splitEnv]; !ok && splitEnv != env {
+				envs = append(envs, splitEnv)
+			}
+			if !slices.Contains(virtualServicesByEnv[splitEnv], vs) {
+				virtualServicesByEnv[splitEnv] = append(virtualServicesByEnv[splitEnv], vs)
 			}
-			vsForOtherEnvs = append(vsForOtherEnvs, envCustomVSTuple{env: splitEnv, customVS: vs})
-		}
-		if foundMatchingVirtualServices {
-			// The matched env should be added at the 0th index
-			finalVirtualServices = append(finalVirtualServices, matchedVS)
-			finalVirtualServices = append(finalVirtualServices, vsForOtherEnvs...)
-			break
 		}
 	}
+	for _, splitEnv := range envs {
+		finalVirtualServices = append(finalVirtualServices,
+			envCustomVSTuple{env: splitEnv, customVS: combineCustomVirtualServices(virtualServicesByEnv[splitEnv])})
+	}
 
 	return finalVirtualServices, nil
 }
 
+// getCustomVirtualServiceEnvs returns the underscore separated envs the custom VS is created for
+func getCustomVirtualServiceEnvs(vs *v1alpha3.VirtualService) []string {
+	envs := make([]string, 0)
+	for _, env := range strings.Split(vs.Annotations[common.CreatedForEnv], "_") {
+		if env != "" {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// getCustomVirtualServicePriority returns the merge priority of the custom VS, which is 0 when
+// the priority annotation is not set or is not a number
+func getCustomVirtualServicePriority(vs *v1alpha3.VirtualService) int {
+	priority, err := strconv.Atoi(vs.Annotations[common.CustomVSPriorityAnnotation])
+	if err != nil {
+		return 0
+	}
+	return priority
+}
+
+// combineCustomVirtualServices combines the custom VSs of an env, which are sorted in the
+// order they should be merged, into a single VS whose routes are the routes of the VSs in that order
+func combineCustomVirtualServices(virtualServices []*v1alpha3.VirtualService) *v1alpha3.VirtualService {
+	if len(virtualServices) == 1 {
+		return virtualServices[0]
+	}
+	combined := virtualServices[0].DeepCopy()
+	combined.Spec.Hosts = make([]string, 0)
+	combined.Spec.Http = make([]*networkingV1Alpha3.HTTPRoute, 0)
+	for _, vs := range virtualServices {
+		combined.Spec.Hosts = mergeHosts(combined.Spec.Hosts, vs.Spec.Hosts)
+		// the routes are scoped to the hosts of their VS, so that their header, query param and URI
+		// matches do not apply to the hosts of the other VSs
+		combined.Spec.Http = append(combined.Spec.Http, scopeHTTPRoutesToHosts(vs.Spec.Http, vs.Spec.Hosts)...)
+	}
+	return combined
+}
+
+// setMergedRouteOrder records the names of the routes of the merged VS, in the order istio evaluates them
+func setMergedRouteOrder(vs *v1alpha3.VirtualService) {
+	routeNames := make([]string, 0, len(vs.Spec.Http))
+	for _, httpRoute := range vs.Spec.Http {
+		routeNames = append(routeNames, httpRoute.Name)
+	}
+	if vs.Annotations == nil {
+		vs.Annotations = make(map[string]string)
+	}
+	vs.Annotations[common.MergedRouteOrderAnnotation] = strings.Join(routeNames, ",")
+}
+
 // httpRoutesComparator comparator that matches the routes between two virtualservice spec
 // This will be used to check if reconciliation is required
 func httpRoutesComparator(
@@ -1256,7 +1329,8 @@ func addUpdateVirtualServicesForIngress(
 	ctxLogger *log.Entry,
 	remoteRegistry *RemoteRegistry,
 	sourceClusterToDestinations map[string]map[string][]*vsrouting.RouteDestination,
-	vsName string) error {
+	vsName string,
+	sourceIdentity string) error {
 
 	if remoteRegistry == nil {
 		return fmt.Errorf("remoteRegistry is nil")
@@ -1287,7 +1361,15 @@ func addUpdateVirtualServicesForIngress(
 			continue
 		}
 
-		virtualService, err := generateVirtualServiceForIngress(destination, vsName)
+		gateways := common.GetVSRoutingGatewaysForClusterAndIdentity(sourceCluster, sourceIdentity)
+		err := validateVSRoutingGateways(ctx, rc, gateways)
+		if err != nil {
+			ctxLogger.Errorf(common.CtxLogFormat, "addUpdateVirtualServicesForIngress",
+				"", "", sourceCluster, err.Error())
+			return err
+		}
+
+		virtualService, err := generateVirtualServiceForIngress(destination, vsName, gateways)
 		if err != nil {
 			ctxLogger.Errorf(common.CtxLogFormat, "addUpdateVirtualServicesForIngress",
 				"", "", sourceCluster, err.Error())
@@ -1336,6 +1418,32 @@ func addUpdateVirtualServicesForIngress(
 	return nil
 }
 
+// validateVSRoutingGateways checks that the gateways of the routing VirtualService exist in the cluster,
+// so that the VirtualService is not created with routes that no gateway serves.
+// Gateways without a namespace are looked up in the namespace of the VirtualService
+func validateVSRoutingGateways(ctx context.Context, rc *RemoteController, gateways []string) error {
+	if rc.VirtualServiceController == nil || rc.VirtualServiceController.IstioClient == nil {
+		return fmt.Errorf("virtualService controller is not initialized")
+	}
+	for _, gateway := range gateways {
+		if gateway == common.Mesh {
+			continue
+		}
+		namespace, name := util.IstioSystemNamespace, gateway
+		if parts := strings.SplitN(gateway, common.Slash, 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		}
+		_, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().Gateways(namespace).Get(ctx, name, metaV1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("gateway %s not found in cluster %s", gateway, rc.ClusterID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get gateway %s in cluster %s: %w", gateway, rc.ClusterID, err)
+		}
+	}
+	return nil
+}
+
 // getAllVSRouteDestinationsByCluster generates the route destinations for each source cluster
 // This is during the discovery phase where the route destinations are created for each source cluster
 // For a given identity and env, we are going to build a map of all possible services across deployments
@@ -1472,7 +1580,7 @@ func doActivePassiveInClusterVS(remoteRegistry *RemoteRegistry,
 		return nil, fmt.Errorf("skipped active passive for incluster as the SE is not multi-region %s", seName)
 	}
 	drName := fmt.Sprintf("%s-default-dr", cname)
-	drFromCache := rc.DestinationRuleController.Cache.Get(drName, common.GetSyncNamespace())
+	drFromCache := rc.DestinationRuleController.Cache.Get(drName, common.GetSyncNamespaceForCluster(sourceCluster))
 	if drFromCache == nil {
 		return nil, fmt.Errorf("no dr found in cache for drName %s", drName)
 	}
@@ -1687,11 +1795,16 @@ func getMeshHTTPPort(
 	if !ok {
 		return 0, fmt.Errorf("%s ports not found", resourceType)
 	}
-	for _, port := range deploymentPorts {
-		if port > 0 {
-			return port, nil
+	// the first mesh port, whose name carries the protocol of the service entry, is routed to
+	var meshPort uint32
+	for name, port := range deploymentPorts {
+		if port > 0 && (meshPort == 0 || !common.IsAdditionalMeshPort(name, port)) {
+			meshPort = port
 		}
 	}
+	if meshPort > 0 {
+		return meshPort, nil
+	}
 	return 0, fmt.Errorf("no valid port found for %s", resourceType)
 }
 
@@ -1705,6 +1818,75 @@ func getMeshHTTPPortForDeployment(ports map[string]map[string]uint32) (uint32, e
 	return getMeshHTTPPort(common.Deployment, ports)
 }
 
+// getH2MeshPorts returns the protocol of the mesh ports serving gRPC or HTTP/2 keyed by their number
+func getH2MeshPorts(meshPorts map[string]uint32) map[uint32]string {
+	h2MeshPorts := make(map[uint32]string)
+	for name, port := range meshPorts {
+		protocol := util.GetPortProtocol(name)
+		if protocol == util.Grpc || protocol == util.Http2 {
+			h2MeshPorts[port] = protocol
+		}
+	}
+	return h2MeshPorts
+}
+
+// isGrpcRoute checks if all the destinations of the route are gRPC mesh ports
+func isGrpcRoute(routeDestinations []*vsrouting.RouteDestination, h2MeshPorts map[uint32]string) bool {
+	if len(routeDestinations) == 0 {
+		return false
+	}
+	for _, routeDestination := range routeDestinations {
+		if routeDestination.Destination == nil || routeDestination.Destination.Port == nil ||
+			h2MeshPorts[routeDestination.Destination.Port.Number] != util.Grpc {
+			return false
+		}
+	}
+	return true
+}
+
+// getGrpcHTTPRetry returns the retry policy of the gRPC routes. gRPC errors are returned in the
+// trailers of responses with a 200 status, so the retries are keyed on the gRPC status codes
+// instead of the HTTP status codes
+func getGrpcHTTPRetry() *networkingV1Alpha3.HTTPRetry {
+	return &networkingV1Alpha3.HTTPRetry{
+		Attempts: 2,
+		RetryOn:  "connect-failure,refused-stream,cancelled,unavailable",
+	}
+}
+
+// getH2UpgradePortLevelSettings returns the port level settings upgrading the connections to the
+// gRPC and HTTP/2 mesh ports to HTTP/2. The connection pool of the destination rule is carried over
+// as the port level settings replace it for the port
+func getH2UpgradePortLevelSettings(
+	meshPorts map[string]uint32,
+	connectionPool *networkingV1Alpha3.ConnectionPoolSettings) []*networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy {
+	h2MeshPorts := getH2MeshPorts(meshPorts)
+	if len(h2MeshPorts) == 0 {
+		return nil
+	}
+	ports := make([]uint32, 0, len(h2MeshPorts))
+	for port := range h2MeshPorts {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+	portLevelSettings := make([]*networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy, 0, len(ports))
+	for _, port := range ports {
+		portConnectionPool := &networkingV1Alpha3.ConnectionPoolSettings{}
+		if connectionPool != nil {
+			portConnectionPool = connectionPool.DeepCopy()
+		}
+		if portConnectionPool.Http == nil {
+			portConnectionPool.Http = &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{}
+		}
+		portConnectionPool.Http.H2UpgradePolicy = networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings_UPGRADE
+		portLevelSettings = append(portLevelSettings, &networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy{
+			Port:           &networkingV1Alpha3.PortSelector{Number: port},
+			ConnectionPool: portConnectionPool,
+		})
+	}
+	return portLevelSettings
+}
+
 // addUpdateInClusterDestinationRule adds or updates the DestinationRule for the source cluster client proxies
 // This is where the DestinationRules are created for the in-cluster VS based routing
 // The DestinationRule is created for the .svc.cluster.local hosts that were discovered during the discovery phase
@@ -1714,6 +1896,7 @@ func addUpdateInClusterDestinationRule(
 	ctxLogger *log.Entry,
 	remoteRegistry *RemoteRegistry,
 	sourceClusterToDRHosts map[string]map[string]string,
+	sourceClusterToMeshPorts map[string]map[string]uint32,
 	sourceIdentity string,
 	cname string,
 	env string) error {
@@ -1753,7 +1936,7 @@ func addUpdateInClusterDestinationRule(
 			"", "", sourceCluster,
 			fmt.Sprintf("Writing phase: addUpdateInClusterDestinationRule: VS based routing in-cluster enabled for cluster %s and identity %s", sourceCluster, sourceIdentity))
 
-		san := fmt.Sprintf("%s%s/%s", common.SpiffePrefix, common.GetSANPrefix(), sourceIdentity)
+		san := fmt.Sprintf("%s%s/%s", common.SpiffePrefix, common.GetTrustDomainForClusterPair(sourceCluster, sourceCluster), sourceIdentity)
 
 		clientTLSSettings := &networkingV1Alpha3.ClientTLSSettings{
 			Mode:            networkingV1Alpha3.ClientTLSSettings_ISTIO_MUTUAL,
@@ -1765,7 +1948,8 @@ func addUpdateInClusterDestinationRule(
 
 		err := addUpdateRoutingDestinationRule(
 			ctx, ctxLogger, remoteRegistry, drHosts, sourceCluster,
-			common.InclusterDRSuffix, exportToNamespaces, clientTLSSettings, clientConnectionSettings)
+			common.InclusterDRSuffix, exportToNamespaces, clientTLSSettings, clientConnectionSettings,
+			sourceClusterToMeshPorts[sourceCluster])
 
 		if err != nil {
 			ctxLogger.Errorf(common.CtxLogFormat, "addUpdateDestinationRuleForSourceIngress",
@@ -1785,6 +1969,7 @@ func addUpdateDestinationRuleForSourceIngress(
 	ctxLogger *log.Entry,
 	remoteRegistry *RemoteRegistry,
 	sourceClusterToDRHosts map[string]map[string]string,
+	sourceClusterToMeshPorts map[string]map[string]uint32,
 	sourceIdentity string) error {
 
 	for sourceCluster, drHosts := range sourceClusterToDRHosts {
@@ -1807,7 +1992,7 @@ func addUpdateDestinationRuleForSourceIngress(
 			return err
 		}
 
-		san := fmt.Sprintf("%s%s/%s", common.SpiffePrefix, common.GetSANPrefix(), sourceIdentity)
+		san := fmt.Sprintf("%s%s/%s", common.SpiffePrefix, common.GetTrustDomainForClusterPair(sourceCluster, sourceCluster), sourceIdentity)
 
 		clientTLSSettings := &networkingV1Alpha3.ClientTLSSettings{
 			SubjectAltNames: []string{san},
@@ -1815,7 +2000,8 @@ func addUpdateDestinationRuleForSourceIngress(
 
 		err := addUpdateRoutingDestinationRule(
 			ctx, ctxLogger, remoteRegistry, drHosts, sourceCluster,
-			common.RoutingDRSuffix, common.GetIngressVSExportToNamespace(), clientTLSSettings, nil)
+			common.RoutingDRSuffix, common.GetIngressVSExportToNamespace(), clientTLSSettings, nil,
+			sourceClusterToMeshPorts[sourceCluster])
 
 		if err != nil {
 			ctxLogger.Errorf(common.CtxLogFormat, "addUpdateDestinationRuleForSourceIngress",
@@ -1836,7 +2022,8 @@ func addUpdateRoutingDestinationRule(
 	drNameSuffix string,
 	exportToNamespaces []string,
 	clientTLSSettings *networkingV1Alpha3.ClientTLSSettings,
-	clientConnectionSettings *v1alpha1.ClientConnectionConfig) error {
+	clientConnectionSettings *v1alpha1.ClientConnectionConfig,
+	meshPorts map[string]uint32) error {
 
 	if remoteRegistry == nil {
 		return fmt.Errorf("remoteRegistry is nil")
@@ -1888,6 +2075,7 @@ func addUpdateRoutingDestinationRule(
 		if clientConnectionSettingsOverride != nil {
 			drObj.TrafficPolicy.ConnectionPool = clientConnectionSettingsOverride
 		}
+		drObj.TrafficPolicy.PortLevelSettings = getH2UpgradePortLevelSettings(meshPorts, drObj.TrafficPolicy.ConnectionPool)
 		if common.DisableDefaultAutomaticFailover() {
 			// If automatic failover is disabled, we set the outlier detection settings to zero
 			// TODO: need add OOD processing similar to SE based routing
@@ -2055,7 +2243,7 @@ func performInVSRoutingRollback(
 				continue
 			}
 			for _, virtualService := range virtualServiceList.Items {
-				virtualService.Spec.ExportTo = []string{common.GetSyncNamespace()}
+				virtualService.Spec.ExportTo = []string{common.GetSyncNamespaceForCluster(clusterID)}
 				err := updateVirtualService(ctx, virtualService, util.IstioSystemNamespace, rc)
 				if err != nil {
 					e := fmt.Errorf(
@@ -2091,7 +2279,7 @@ func performInVSRoutingRollback(
 					virtualServiceName, util.IstioSystemNamespace, clusterID, "virtualservice does not exist")
 				continue
 			}
-			existingVS.Spec.ExportTo = []string{common.GetSyncNamespace()}
+			existingVS.Spec.ExportTo = []string{common.GetSyncNamespaceForCluster(clusterID)}
 			err = updateVirtualService(ctx, existingVS, util.IstioSystemNamespace, rc)
 			if err != nil {
 				e := fmt.Errorf(
@@ -2164,6 +2352,71 @@ func mergeVS(
 
 }
 
+// scopeHTTPRoutesToHosts returns copies of the routes whose match conditions, such as the header,
+// query param and URI matches, only apply to the given hosts. Every match condition which does not
+// already match on the authority is split into one condition per host, a route without match
+// conditions gets one condition per host
+func scopeHTTPRoutesToHosts(
+	httpRoutes []*networkingV1Alpha3.HTTPRoute,
+	hosts []string) []*networkingV1Alpha3.HTTPRoute {
+	scopedRoutes := make([]*networkingV1Alpha3.HTTPRoute, 0, len(httpRoutes))
+	for _, httpRoute := range httpRoutes {
+		scopedRoute := httpRoute.DeepCopy()
+		if len(hosts) == 0 || scopedRoute.Delegate != nil {
+			scopedRoutes = append(scopedRoutes, scopedRoute)
+			continue
+		}
+		matches := scopedRoute.Match
+		if len(matches) == 0 {
+			matches = []*networkingV1Alpha3.HTTPMatchRequest{{}}
+		}
+		scopedMatches := make([]*networkingV1Alpha3.HTTPMatchRequest, 0, len(matches)*len(hosts))
+		for _, match := range matches {
+			if match.Authority != nil {
+				scopedMatches = append(scopedMatches, match)
+				continue
+			}
+			for _, host := range hosts {
+				scopedMatch := match.DeepCopy()
+				scopedMatch.Authority = &networkingV1Alpha3.StringMatch{
+					MatchType: &networkingV1Alpha3.StringMatch_Prefix{Prefix: host},
+				}
+				scopedMatches = append(scopedMatches, scopedMatch)
+			}
+		}
+		scopedRoute.Match = scopedMatches
+		scopedRoutes = append(scopedRoutes, scopedRoute)
+	}
+	return scopedRoutes
+}
+
+// mergeHTTPRoutePolicies sets the timeout, retries and corsPolicy the route does not set from the
+// fallback route. The per-route policies follow these rules when custom VSs are merged into the
+// in-cluster VS:
+//   - a custom VS route keeps its own timeout, retries and corsPolicy, whatever destinations
+//     its .global/.mesh destinations are resolved to
+//   - a route resolved from a delegate keeps its own values, and takes the values of the route
+//     delegating to it for the fields it does not set
+//   - a custom VS route which has the name of an in-cluster route replaces it, none of the values
+//     of the in-cluster route are kept, so that removing a value from the custom VS removes it
+//     from the merged VS
+//   - values are never copied to the routes of other hosts, including when several custom VSs
+//     are combined for an env
+func mergeHTTPRoutePolicies(route, fallback *networkingV1Alpha3.HTTPRoute) {
+	if route == nil || fallback == nil {
+		return
+	}
+	if route.Timeout == nil && fallback.Timeout != nil {
+		route.Timeout = fallback.Timeout
+	}
+	if route.Retries == nil && fallback.Retries != nil {
+		route.Retries = fallback.Retries
+	}
+	if route.CorsPolicy == nil && fallback.CorsPolicy != nil {
+		route.CorsPolicy = fallback.CorsPolicy
+	}
+}
+
 // getHostsDiff returns a map of hosts that are in in-cluster VS host list but
 // not in the custom VS
 func getHostsDiff(inclusterHosts []string, customVSHosts []string) map[string]bool {
@@ -2250,6 +2503,8 @@ func modifyCustomVSHTTPRoutes(
 
 	newCustomVSHTTPRoutes := make([]*networkingV1Alpha3.HTTPRoute, 0)
 	for _, httpRoute := range customVSRoutes {
+		// The copy keeps the match conditions and the per-route policies of the custom VS route,
+		// only its destinations are replaced
 		copyHTTPRoute := httpRoute.DeepCopy()
 		newRouteDestinations := make([]*networkingV1Alpha3.HTTPRouteDestination, 0)
 		for _, routeDestination := range httpRoute.Route {
@@ -10,6 +10,7 @@ import (
 	"github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/google/go-cmp/cmp"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/model"
 	v1alpha12 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
@@ -20,6 +21,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
 	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
 	apiNetworkingV1Alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
@@ -610,6 +612,7 @@ func TestAddUpdateInClusterVirtualServices(t *testing.T) {
 				ctxLogger,
 				tc.remoteRegistry,
 				tc.sourceClusterToDestinations,
+				nil,
 				tc.vsName,
 				tc.sourceIdentity, tc.env)
 			if tc.expectedError != nil {
@@ -681,11 +684,24 @@ func TestAddUpdateVirtualServicesForIngress(t *testing.T) {
 	common.ResetSync()
 	common.InitializeConfig(ap)
 
+	passthroughGateway := &apiNetworkingV1Alpha3.Gateway{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      "passthrough-gateway",
+			Namespace: util.IstioSystemNamespace,
+		},
+	}
+
 	istioClientWithExistingVS := istioFake.NewSimpleClientset()
 	istioClientWithExistingVS.NetworkingV1alpha3().VirtualServices(util.IstioSystemNamespace).
 		Create(context.Background(), existingVS, metaV1.CreateOptions{})
+	istioClientWithExistingVS.NetworkingV1alpha3().Gateways(util.IstioSystemNamespace).
+		Create(context.Background(), passthroughGateway, metaV1.CreateOptions{})
 
 	istioClientWithNoExistingVS := istioFake.NewSimpleClientset()
+	istioClientWithNoExistingVS.NetworkingV1alpha3().Gateways(util.IstioSystemNamespace).
+		Create(context.Background(), passthroughGateway, metaV1.CreateOptions{})
+
+	istioClientWithNoGateway := istioFake.NewSimpleClientset()
 	rc := &RemoteController{
 		ClusterID: "cluster-1",
 		VirtualServiceController: &istio.VirtualServiceController{
@@ -800,6 +816,17 @@ func TestAddUpdateVirtualServicesForIngress(t *testing.T) {
 			sourceClusterToDestinations: sourceDestinationsWithSingleDestinationSvc,
 			expectedError:               fmt.Errorf("vsName is empty"),
 		},
+		{
+			name: "Given a valid sourceClusterToDestinations " +
+				"And the gateway does not exist in the cluster, " +
+				"When addUpdateVirtualServicesForSourceIngress is invoked, " +
+				"Then it should return an error",
+			remoteRegistry:              rr,
+			vsName:                      "test-env.test-identity.global",
+			sourceClusterToDestinations: sourceDestinationsWithSingleDestinationSvc,
+			istioClient:                 istioClientWithNoGateway,
+			expectedError:               fmt.Errorf("gateway istio-system/passthrough-gateway not found in cluster cluster-1"),
+		},
 		{
 			name: "Given a valid sourceClusterToDestinations " +
 				"And the VS is a new VS" +
@@ -1080,7 +1107,8 @@ func TestAddUpdateVirtualServicesForIngress(t *testing.T) {
 				ctxLogger,
 				tc.remoteRegistry,
 				tc.sourceClusterToDestinations,
-				tc.vsName)
+				tc.vsName,
+				"test-identity")
 			if tc.expectedError != nil {
 				require.NotNil(t, err)
 				require.Equal(t, tc.expectedError.Error(), err.Error())
@@ -2058,7 +2086,7 @@ func TestGetBaseVirtualServiceForIngress(t *testing.T) {
 			ap.VSRoutingGateways = tc.routingGateways
 			common.ResetSync()
 			common.InitializeConfig(ap)
-			actual, err := getBaseVirtualServiceForIngress()
+			actual, err := getBaseVirtualServiceForIngress(common.GetVSRoutingGateways())
 			if tc.expectedError != nil {
 				require.NotNil(t, err)
 				require.Equal(t, tc.expectedError.Error(), err.Error())
@@ -3534,6 +3562,7 @@ func TestAddUpdateInClusterDestinationRule(t *testing.T) {
 		istioClient              *istioFake.Clientset
 		drName                   string
 		sourceClusterToDRHosts   map[string]map[string]string
+		sourceClusterToMeshPorts map[string]map[string]uint32
 		sourceIdentity           string
 		cname                    string
 		env                      string
@@ -3718,6 +3747,67 @@ func TestAddUpdateInClusterDestinationRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Given a valid sourceClusterToDRHosts " +
+				"When addUpdateInClusterDestinationRule is invoked," +
+				"And the source serves grpc " +
+				"Then it should create the destination rules with h2 upgrade on the grpc port",
+			drName:         "test-ns.svc.cluster.local-incluster-dr",
+			sourceIdentity: "test-identity",
+			cname:          "test-env.test-identity.global",
+			env:            "stage",
+			sourceClusterToDRHosts: map[string]map[string]string{
+				"cluster-1": {
+					"test-ns.svc.cluster.local": "*.test-ns.svc.cluster.local",
+				},
+			},
+			sourceClusterToMeshPorts: map[string]map[string]uint32{
+				"cluster-1": {"grpc": 8080},
+			},
+			istioClient:   istioClientWithNoExistingDR,
+			expectedError: nil,
+			expectedDestinationRules: &apiNetworkingV1Alpha3.DestinationRule{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:      "test-ns.svc.cluster.local-incluster-dr",
+					Namespace: util.IstioSystemNamespace,
+				},
+				Spec: networkingV1Alpha3.DestinationRule{
+					Host:     "*.test-ns.svc.cluster.local",
+					ExportTo: []string{"test-dependent-ns0", "test-dependent-ns1", "test-ns"},
+					TrafficPolicy: &networkingV1Alpha3.TrafficPolicy{
+						LoadBalancer: &networkingV1Alpha3.LoadBalancerSettings{
+							LbPolicy: &networkingV1Alpha3.LoadBalancerSettings_Simple{
+								Simple: networkingV1Alpha3.LoadBalancerSettings_ROUND_ROBIN,
+							},
+							LocalityLbSetting: &networkingV1Alpha3.LocalityLoadBalancerSetting{
+								Enabled: &wrappers.BoolValue{Value: false},
+							},
+							WarmupDurationSecs: &duration.Duration{Seconds: common.GetDefaultWarmupDurationSecs()},
+						},
+						Tls: &networkingV1Alpha3.ClientTLSSettings{
+							Mode:            networkingV1Alpha3.ClientTLSSettings_ISTIO_MUTUAL,
+							SubjectAltNames: []string{"spiffe://test-san-prefix/test-identity"},
+						},
+						ConnectionPool: &networkingV1Alpha3.ConnectionPoolSettings{
+							Http: &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{
+								MaxRequestsPerConnection: 100,
+							},
+						},
+						PortLevelSettings: []*networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy{
+							{
+								Port: &networkingV1Alpha3.PortSelector{Number: 8080},
+								ConnectionPool: &networkingV1Alpha3.ConnectionPoolSettings{
+									Http: &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{
+										MaxRequestsPerConnection: 100,
+										H2UpgradePolicy:          networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -3752,6 +3842,7 @@ func TestAddUpdateInClusterDestinationRule(t *testing.T) {
 				ctxLogger,
 				rr,
 				tc.sourceClusterToDRHosts,
+				tc.sourceClusterToMeshPorts,
 				tc.sourceIdentity,
 				tc.cname, tc.env)
 			if tc.expectedError != nil {
@@ -3775,6 +3866,9 @@ func TestAddUpdateInClusterDestinationRule(t *testing.T) {
 				require.Equal(t,
 					tc.expectedDestinationRules.Spec.TrafficPolicy.ConnectionPool.Http.MaxRequestsPerConnection,
 					actualDR.Spec.TrafficPolicy.ConnectionPool.Http.MaxRequestsPerConnection)
+				require.Equal(t,
+					tc.expectedDestinationRules.Spec.TrafficPolicy.PortLevelSettings,
+					actualDR.Spec.TrafficPolicy.PortLevelSettings)
 			}
 		})
 	}
@@ -4016,6 +4110,7 @@ func TestAddUpdateDestinationRuleForSourceIngress(t *testing.T) {
 				ctxLogger,
 				rr,
 				tc.sourceClusterToDRHosts,
+				nil,
 				tc.sourceIdentity)
 			if tc.expectedError != nil {
 				require.NotNil(t, err)
@@ -8022,6 +8117,232 @@ func TestGetCustomVirtualService(t *testing.T) {
 
 }
 
+func TestGetCustomVirtualServiceMergeOrder(t *testing.T) {
+	syncNS := "test-ns"
+	newCustomVS := func(name, envs, priority string, routes ...string) *apiNetworkingV1Alpha3.VirtualService {
+		vs := &apiNetworkingV1Alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        name,
+				Namespace:   syncNS,
+				Annotations: map[string]string{common.CreatedForEnv: envs},
+				Labels: map[string]string{
+					common.CreatedBy:  "testCreatedBy",
+					common.CreatedFor: "testidentity",
+				},
+			},
+			Spec: networkingV1Alpha3.VirtualService{Hosts: []string{name + ".global"}},
+		}
+		if priority != "" {
+			vs.Annotations[common.CustomVSPriorityAnnotation] = priority
+		}
+		for _, route := range routes {
+			vs.Spec.Http = append(vs.Spec.Http, &networkingV1Alpha3.HTTPRoute{Name: route})
+		}
+		return vs
+	}
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		SyncNamespace:      syncNS,
+		ProcessVSCreatedBy: "testCreatedBy",
+	})
+	ctxLogger := log.WithFields(log.Fields{"type": "VirtualService"})
+
+	testCases := []struct {
+		name                string
+		virtualServices     []*apiNetworkingV1Alpha3.VirtualService
+		expectedEnvs        []string
+		expectedRouteOrders [][]string
+	}{
+		{
+			name: "Given multiple custom VSs without priority for an env, " +
+				"When getCustomVirtualService func is called, " +
+				"Then the VSs should be combined in name order",
+			virtualServices: []*apiNetworkingV1Alpha3.VirtualService{
+				newCustomVS("custom-vs-b", "stage", "", "b"),
+				newCustomVS("custom-vs-a", "stage", "", "a"),
+			},
+			expectedEnvs:        []string{"stage"},
+			expectedRouteOrders: [][]string{{"a", "b"}},
+		},
+		{
+			name: "Given multiple custom VSs with priorities for an env, " +
+				"When getCustomVirtualService func is called, " +
+				"Then the VSs should be combined by descending priority",
+			virtualServices: []*apiNetworkingV1Alpha3.VirtualService{
+				newCustomVS("custom-vs-a", "stage", "1", "a"),
+				newCustomVS("custom-vs-b", "stage_qa", "10", "b"),
+				newCustomVS("custom-vs-c", "stage", "invalid", "c"),
+			},
+			expectedEnvs:        []string{"stage", "qa"},
+			expectedRouteOrders: [][]string{{"b", "a", "c"}, {"b"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			istioClient := istioFake.NewSimpleClientset()
+			for _, vs := range tc.virtualServices {
+				_, err := istioClient.NetworkingV1alpha3().VirtualServices(syncNS).Create(context.Background(), vs, metaV1.CreateOptions{})
+				assert.Nil(t, err)
+			}
+			rc := &RemoteController{
+				VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+			}
+
+			actual, err := getCustomVirtualService(context.Background(), ctxLogger, rc, "stage", "testIdentity")
+
+			assert.Nil(t, err)
+			assert.Len(t, actual, len(tc.expectedEnvs))
+			for i, tuple := range actual {
+				assert.Equal(t, tc.expectedEnvs[i], tuple.env)
+				routeOrder := make([]string, 0)
+				for _, route := range tuple.customVS.Spec.Http {
+					routeOrder = append(routeOrder, route.Name)
+				}
+				assert.Equal(t, tc.expectedRouteOrders[i], routeOrder)
+			}
+		})
+	}
+}
+
+func TestScopeHTTPRoutesToHosts(t *testing.T) {
+	authority := func(host string) *networkingV1Alpha3.StringMatch {
+		return &networkingV1Alpha3.StringMatch{MatchType: &networkingV1Alpha3.StringMatch_Prefix{Prefix: host}}
+	}
+	header := map[string]*networkingV1Alpha3.StringMatch{
+		"x-route": {MatchType: &networkingV1Alpha3.StringMatch_Exact{Exact: "health"}},
+	}
+	routes := []*networkingV1Alpha3.HTTPRoute{
+		{Name: "header", Match: []*networkingV1Alpha3.HTTPMatchRequest{{Headers: header}}},
+		{Name: "authority", Match: []*networkingV1Alpha3.HTTPMatchRequest{{Authority: authority("other.global")}}},
+		{Name: "catch-all"},
+	}
+
+	scoped := scopeHTTPRoutesToHosts(routes, []string{"a.global", "b.global"})
+
+	assert.Len(t, scoped, 3)
+	assert.Len(t, scoped[0].Match, 2)
+	assert.Equal(t, "a.global", scoped[0].Match[0].Authority.GetPrefix())
+	assert.Equal(t, "b.global", scoped[0].Match[1].Authority.GetPrefix())
+	assert.Equal(t, "health", scoped[0].Match[0].Headers["x-route"].GetExact())
+	assert.Equal(t, "health", scoped[0].Match[1].Headers["x-route"].GetExact())
+	assert.Len(t, scoped[1].Match, 1)
+	assert.Equal(t, "other.global", scoped[1].Match[0].Authority.GetPrefix())
+	assert.Len(t, scoped[2].Match, 2)
+	assert.Nil(t, routes[0].Match[0].Authority)
+	assert.Nil(t, routes[2].Match)
+}
+
+func TestMergeHTTPRoutePolicies(t *testing.T) {
+	fallback := &networkingV1Alpha3.HTTPRoute{
+		Timeout:    &duration.Duration{Seconds: 5},
+		Retries:    &networkingV1Alpha3.HTTPRetry{Attempts: 3},
+		CorsPolicy: &networkingV1Alpha3.CorsPolicy{AllowMethods: []string{"GET"}},
+	}
+	testCases := []struct {
+		name            string
+		route           *networkingV1Alpha3.HTTPRoute
+		expectedTimeout int64
+		expectedRetries int32
+		expectedMethods []string
+	}{
+		{
+			name: "Given a route without policies, " +
+				"When mergeHTTPRoutePolicies is called, " +
+				"Then the policies of the fallback route should be used",
+			route:           &networkingV1Alpha3.HTTPRoute{},
+			expectedTimeout: 5,
+			expectedRetries: 3,
+			expectedMethods: []string{"GET"},
+		},
+		{
+			name: "Given a route with its own policies, " +
+				"When mergeHTTPRoutePolicies is called, " +
+				"Then the policies of the route should win",
+			route: &networkingV1Alpha3.HTTPRoute{
+				Timeout: &duration.Duration{Seconds: 10},
+				Retries: &networkingV1Alpha3.HTTPRetry{Attempts: 1},
+			},
+			expectedTimeout: 10,
+			expectedRetries: 1,
+			expectedMethods: []string{"GET"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mergeHTTPRoutePolicies(tc.route, fallback)
+			assert.Equal(t, tc.expectedTimeout, tc.route.Timeout.Seconds)
+			assert.Equal(t, tc.expectedRetries, tc.route.Retries.Attempts)
+			assert.Equal(t, tc.expectedMethods, tc.route.CorsPolicy.AllowMethods)
+		})
+	}
+}
+
+func TestMergeVSKeepsRoutePolicies(t *testing.T) {
+	rc := &RemoteController{
+		ClusterID: "cluster-1",
+		VirtualServiceController: &istio.VirtualServiceController{
+			HostToRouteDestinationCache: istio.NewHostToRouteDestinationCache(),
+		},
+	}
+	customVS := &apiNetworkingV1Alpha3.VirtualService{
+		Spec: networkingV1Alpha3.VirtualService{
+			Hosts: []string{"stage.foo.global"},
+			Http: []*networkingV1Alpha3.HTTPRoute{
+				{
+					Name:       "custom",
+					Timeout:    &duration.Duration{Seconds: 10},
+					Retries:    &networkingV1Alpha3.HTTPRetry{Attempts: 2, PerTryTimeout: &duration.Duration{Seconds: 3}},
+					CorsPolicy: &networkingV1Alpha3.CorsPolicy{AllowMethods: []string{"GET"}},
+					Route: []*networkingV1Alpha3.HTTPRouteDestination{
+						{Destination: &networkingV1Alpha3.Destination{Host: "stage.foo.global"}, Weight: 100},
+					},
+				},
+			},
+		},
+	}
+	inclusterVS := &apiNetworkingV1Alpha3.VirtualService{
+		Spec: networkingV1Alpha3.VirtualService{
+			Hosts: []string{"stage.foo.global"},
+			Http: []*networkingV1Alpha3.HTTPRoute{
+				{
+					Name: "stage.foo.global",
+					Route: []*networkingV1Alpha3.HTTPRouteDestination{
+						{Destination: &networkingV1Alpha3.Destination{Host: "foo.ns.svc.cluster.local"}, Weight: 100},
+					},
+				},
+			},
+		},
+	}
+
+	merged, err := mergeVS(customVS, inclusterVS, rc)
+
+	assert.Nil(t, err)
+	assert.Len(t, merged.Spec.Http, 2)
+	customRoute := merged.Spec.Http[0]
+	assert.Equal(t, "custom", customRoute.Name)
+	assert.Equal(t, "foo.ns.svc.cluster.local", customRoute.Route[0].Destination.Host)
+	assert.Equal(t, int64(10), customRoute.Timeout.Seconds)
+	assert.Equal(t, int32(2), customRoute.Retries.Attempts)
+	assert.Equal(t, int64(3), customRoute.Retries.PerTryTimeout.Seconds)
+	assert.Equal(t, []string{"GET"}, customRoute.CorsPolicy.AllowMethods)
+	// the policies of the custom route are not copied to the in-cluster route
+	assert.Nil(t, merged.Spec.Http[1].Timeout)
+	assert.Nil(t, merged.Spec.Http[1].Retries)
+	assert.Nil(t, merged.Spec.Http[1].CorsPolicy)
+}
+
+func TestSetMergedRouteOrder(t *testing.T) {
+	vs := &apiNetworkingV1Alpha3.VirtualService{
+		Spec: networkingV1Alpha3.VirtualService{
+			Http: []*networkingV1Alpha3.HTTPRoute{{Name: "test.foo.global"}, {Name: "custom"}, {Name: "foo.global"}},
+		},
+	}
+	setMergedRouteOrder(vs)
+	assert.Equal(t, "test.foo.global,custom,foo.global", vs.Annotations[common.MergedRouteOrderAnnotation])
+}
+
 func TestGetHostsDiff(t *testing.T) {
 
 	testCases := []struct {
@@ -9545,3 +9866,125 @@ func TestIsVSRoutingInClusterDisabledForCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestIsGrpcRoute(t *testing.T) {
+	destination := func(port uint32) *vsrouting.RouteDestination {
+		return &vsrouting.RouteDestination{
+			Destination: &networkingV1Alpha3.Destination{
+				Host: "foo.test-ns.svc.cluster.local",
+				Port: &networkingV1Alpha3.PortSelector{Number: port},
+			},
+		}
+	}
+	h2MeshPorts := getH2MeshPorts(map[string]uint32{"grpc": 8080, "http2-9090": 9090, "http-8090": 8090})
+	testCases := []struct {
+		name              string
+		routeDestinations []*vsrouting.RouteDestination
+		expected          bool
+	}{
+		{
+			name: "Given no route destinations, " +
+				"When isGrpcRoute is called, " +
+				"Then it should return false",
+		},
+		{
+			name: "Given route destinations on the grpc mesh port, " +
+				"When isGrpcRoute is called, " +
+				"Then it should return true",
+			routeDestinations: []*vsrouting.RouteDestination{destination(8080), destination(8080)},
+			expected:          true,
+		},
+		{
+			name: "Given a route destination on the http2 mesh port, " +
+				"When isGrpcRoute is called, " +
+				"Then it should return false",
+			routeDestinations: []*vsrouting.RouteDestination{destination(9090)},
+		},
+		{
+			name: "Given route destinations on the grpc and the http mesh ports, " +
+				"When isGrpcRoute is called, " +
+				"Then it should return false",
+			routeDestinations: []*vsrouting.RouteDestination{destination(8080), destination(8090)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isGrpcRoute(tc.routeDestinations, h2MeshPorts))
+		})
+	}
+}
+
+func TestGetH2UpgradePortLevelSettings(t *testing.T) {
+	connectionPool := &networkingV1Alpha3.ConnectionPoolSettings{
+		Http: &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{MaxRequestsPerConnection: 100},
+	}
+	testCases := []struct {
+		name           string
+		meshPorts      map[string]uint32
+		connectionPool *networkingV1Alpha3.ConnectionPoolSettings
+		expected       []*networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy
+	}{
+		{
+			name: "Given http mesh ports only, " +
+				"When getH2UpgradePortLevelSettings is called, " +
+				"Then it should return nil",
+			meshPorts:      map[string]uint32{"http": 8080},
+			connectionPool: connectionPool,
+		},
+		{
+			name: "Given grpc and http2 mesh ports, " +
+				"When getH2UpgradePortLevelSettings is called, " +
+				"Then it should upgrade the ports sorted by number and carry over the connection pool",
+			meshPorts:      map[string]uint32{"http": 8080, "http2-9090": 9090, "grpc-8443": 8443},
+			connectionPool: connectionPool,
+			expected: []*networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy{
+				{
+					Port: &networkingV1Alpha3.PortSelector{Number: 8443},
+					ConnectionPool: &networkingV1Alpha3.ConnectionPoolSettings{
+						Http: &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{
+							MaxRequestsPerConnection: 100,
+							H2UpgradePolicy:          networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+						},
+					},
+				},
+				{
+					Port: &networkingV1Alpha3.PortSelector{Number: 9090},
+					ConnectionPool: &networkingV1Alpha3.ConnectionPoolSettings{
+						Http: &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{
+							MaxRequestsPerConnection: 100,
+							H2UpgradePolicy:          networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Given a grpc mesh port and no connection pool, " +
+				"When getH2UpgradePortLevelSettings is called, " +
+				"Then it should only upgrade the port",
+			meshPorts: map[string]uint32{"grpc": 8080},
+			expected: []*networkingV1Alpha3.TrafficPolicy_PortTrafficPolicy{
+				{
+					Port: &networkingV1Alpha3.PortSelector{Number: 8080},
+					ConnectionPool: &networkingV1Alpha3.ConnectionPoolSettings{
+						Http: &networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings{
+							H2UpgradePolicy: networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := getH2UpgradePortLevelSettings(tc.meshPorts, tc.connectionPool)
+			if !cmp.Equal(tc.expected, actual, protocmp.Transform()) {
+				t.Errorf("PortLevelSettings mismatch. Diff: %v", cmp.Diff(tc.expected, actual, protocmp.Transform()))
+			}
+			if tc.connectionPool != nil {
+				assert.Equal(t, networkingV1Alpha3.ConnectionPoolSettings_HTTPSettings_DEFAULT,
+					tc.connectionPool.Http.H2UpgradePolicy)
+			}
+		})
+	}
+}
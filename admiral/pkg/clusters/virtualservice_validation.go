@@ -0,0 +1,78 @@
+package clusters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const vsTotalWeight = 100
+
+// ValidateCustomVirtualService checks a custom VirtualService which admiral merges into the
+// in-cluster VirtualService and returns the reasons it should be rejected. VirtualServices
+// which are not marked with the createdBy label admiral processes are not checked.
+func ValidateCustomVirtualService(vs *v1alpha3.VirtualService) []string {
+	var violations []string
+	if !ShouldProcessVSCreatedBy(vs) {
+		return violations
+	}
+	if vs.Labels[common.CreatedFor] == "" {
+		violations = append(violations, fmt.Sprintf("%s label is missing", common.CreatedFor))
+	}
+	if envs := vs.Annotations[common.CreatedForEnv]; envs == "" {
+		violations = append(violations, fmt.Sprintf("%s annotation is missing", common.CreatedForEnv))
+	} else if strings.Contains("_"+envs+"_", "__") {
+		violations = append(violations, fmt.Sprintf("%s annotation %s has an empty env", common.CreatedForEnv, envs))
+	}
+
+	if len(vs.Spec.Hosts) == 0 {
+		violations = append(violations, "hosts are missing")
+	}
+	for _, host := range vs.Spec.Hosts {
+		violations = append(violations, validateMeshHost("host", host)...)
+	}
+
+	if len(vs.Spec.Http) == 0 {
+		violations = append(violations, "http routes are missing")
+	}
+	for i, route := range vs.Spec.Http {
+		// routes are merged into the in-cluster VirtualService by name
+		if route.Name == "" {
+			violations = append(violations, fmt.Sprintf("http route %d has no name", i))
+		}
+		if len(route.Route) == 0 {
+			violations = append(violations, fmt.Sprintf("http route %s has no destinations", route.Name))
+			continue
+		}
+		totalWeight := int32(0)
+		for _, routeDestination := range route.Route {
+			if routeDestination.Destination == nil {
+				violations = append(violations, fmt.Sprintf("http route %s has a destination without a host", route.Name))
+				continue
+			}
+			violations = append(violations, validateMeshHost("destination host", routeDestination.Destination.Host)...)
+			totalWeight += routeDestination.Weight
+		}
+		if totalWeight != 0 && totalWeight != vsTotalWeight {
+			violations = append(violations, fmt.Sprintf("weights of http route %s add up to %d instead of %d",
+				route.Name, totalWeight, vsTotalWeight))
+		}
+	}
+	return violations
+}
+
+// validateMeshHost returns the reasons the host is not a valid mesh host generated by admiral
+func validateMeshHost(field, host string) []string {
+	var violations []string
+	for _, err := range validation.IsDNS1123Subdomain(host) {
+		violations = append(violations, fmt.Sprintf("%s %s is invalid: %s", field, host, err))
+	}
+	suffix := common.GetHostnameSuffix()
+	if suffix != "" && !strings.HasSuffix(host, common.Sep+suffix) {
+		violations = append(violations, fmt.Sprintf("%s %s does not end with .%s", field, host, suffix))
+	}
+	return violations
+}
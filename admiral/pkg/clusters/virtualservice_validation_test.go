@@ -0,0 +1,110 @@
+package clusters
+
+import (
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCustomVirtualService(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		HostnameSuffix:     "global",
+		ProcessVSCreatedBy: "testCreatedBy",
+		LabelSet:           &common.LabelSet{},
+	})
+	newVS := func(createdBy, createdFor, envs string, hosts []string, routes ...*networkingV1Alpha3.HTTPRoute) *v1alpha3.VirtualService {
+		return &v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:        "custom-vs",
+				Labels:      map[string]string{common.CreatedBy: createdBy, common.CreatedFor: createdFor},
+				Annotations: map[string]string{common.CreatedForEnv: envs},
+			},
+			Spec: networkingV1Alpha3.VirtualService{Hosts: hosts, Http: routes},
+		}
+	}
+	newRoute := func(name string, destinations ...*networkingV1Alpha3.HTTPRouteDestination) *networkingV1Alpha3.HTTPRoute {
+		return &networkingV1Alpha3.HTTPRoute{Name: name, Route: destinations}
+	}
+	newDestination := func(host string, weight int32) *networkingV1Alpha3.HTTPRouteDestination {
+		return &networkingV1Alpha3.HTTPRouteDestination{
+			Destination: &networkingV1Alpha3.Destination{Host: host},
+			Weight:      weight,
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		vs                 *v1alpha3.VirtualService
+		expectedViolations int
+	}{
+		{
+			name: "Given a VS not created by the configured createdBy, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be accepted",
+			vs:                 newVS("someone", "", "", nil),
+			expectedViolations: 0,
+		},
+		{
+			name: "Given a valid custom VS, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be accepted",
+			vs: newVS("testCreatedBy", "foo", "stage_qal", []string{"stage.foo.global"},
+				newRoute("stage.foo.global",
+					newDestination("canary.stage.foo.global", 10),
+					newDestination("stage.foo.global", 90))),
+			expectedViolations: 0,
+		},
+		{
+			name: "Given a custom VS without the createdFor label and createdForEnv annotation, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be rejected",
+			vs: newVS("testCreatedBy", "", "", []string{"stage.foo.global"},
+				newRoute("stage.foo.global", newDestination("stage.foo.global", 0))),
+			expectedViolations: 2,
+		},
+		{
+			name: "Given a custom VS with an empty env in the createdForEnv annotation, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be rejected",
+			vs: newVS("testCreatedBy", "foo", "stage_", []string{"stage.foo.global"},
+				newRoute("stage.foo.global", newDestination("stage.foo.global", 0))),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a custom VS with a host not ending with the hostname suffix, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be rejected",
+			vs: newVS("testCreatedBy", "foo", "stage", []string{"stage.foo.svc.cluster.local"},
+				newRoute("stage.foo.global", newDestination("stage.foo.global", 0))),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a custom VS without http routes, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be rejected",
+			vs:                 newVS("testCreatedBy", "foo", "stage", []string{"stage.foo.global"}),
+			expectedViolations: 1,
+		},
+		{
+			name: "Given a custom VS with an unnamed route, a missing destination and weights not adding up to 100, " +
+				"When ValidateCustomVirtualService is called, " +
+				"Then it should be rejected",
+			vs: newVS("testCreatedBy", "foo", "stage", []string{"stage.foo.global"},
+				newRoute("",
+					&networkingV1Alpha3.HTTPRouteDestination{},
+					newDestination("Stage.foo.global", 50))),
+			expectedViolations: 4,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			violations := ValidateCustomVirtualService(c.vs)
+			assert.Len(t, violations, c.expectedViolations, violations)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	commonUtil "github.com/istio-ecosystem/admiral/admiral/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var vsNameStrategies = []string{common.VSNameStrategyLegacy, common.VSNameStrategyHashSuffix, common.VSNameStrategyIdentity}
+
+// getVirtualServiceIdentity returns the identity the VirtualService is labeled or annotated with
+func getVirtualServiceIdentity(vs *v1alpha3.VirtualService) string {
+	return common.GetIdentity(vs.Labels, vs.Annotations)
+}
+
+// getVirtualServiceSyncName returns the name the VirtualService is synced to other clusters with
+func getVirtualServiceSyncName(vs *v1alpha3.VirtualService) string {
+	return common.GenerateNameForVS(common.GetVSNameStrategy(), vs.Namespace, vs.Name, getVirtualServiceIdentity(vs))
+}
+
+// getStaleVirtualServiceSyncNames returns the names other than syncName the VirtualService could
+// have been synced with, which are its own name and the names given by the other strategies
+func getStaleVirtualServiceSyncNames(namespace, name, identity, syncName string) []string {
+	staleNames := make([]string, 0, len(vsNameStrategies)+1)
+	if name != syncName {
+		staleNames = append(staleNames, name)
+	}
+	for _, strategy := range vsNameStrategies {
+		staleName := common.GenerateNameForVS(strategy, namespace, name, identity)
+		if staleName == "" || staleName == syncName || slices.Contains(staleNames, staleName) {
+			continue
+		}
+		staleNames = append(staleNames, staleName)
+	}
+	return staleNames
+}
+
+// deleteStaleVirtualServiceSyncNames deletes the copies of the VirtualService synced to the cluster
+// with a name other than syncName. Only the VirtualServices generated by admiral are deleted
+func deleteStaleVirtualServiceSyncNames(ctx context.Context, ctxLogger *log.Entry, rc *RemoteController,
+	syncNamespace, namespace, name, identity, syncName string) {
+	for _, staleName := range getStaleVirtualServiceSyncNames(namespace, name, identity, syncName) {
+		stale, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, staleName, metaV1.GetOptions{})
+		if err != nil || !isGeneratedByAdmiral(stale.Annotations) || isVirtualServiceNameCollision(stale, namespace+"/"+name) {
+			continue
+		}
+		if err = deleteVirtualService(ctx, staleName, syncNamespace, rc); err != nil {
+			ctxLogger.Warnf(LogErrFormat, "Delete", common.VirtualServiceResourceType, staleName, rc.ClusterID, err)
+			continue
+		}
+		ctxLogger.Infof(LogFormat, "Delete", common.VirtualServiceResourceType, staleName, rc.ClusterID, "deleted copy synced with a stale name, current name="+syncName)
+	}
+}
+
+// getVirtualServiceSource returns the source VirtualService, as namespace/name, a synced VirtualService was copied from
+func getVirtualServiceSource(vs *v1alpha3.VirtualService) string {
+	if vs == nil {
+		return ""
+	}
+	return vs.Annotations[common.AdmiralSourceVSAnnotation]
+}
+
+// isVirtualServiceNameCollision checks if the synced VirtualService is owned by a source other than
+// the given one, which happens when two sources are given the same name. VirtualServices synced
+// before the source was recorded on them are adopted by the first source synced to them
+func isVirtualServiceNameCollision(exist *v1alpha3.VirtualService, source string) bool {
+	owner := getVirtualServiceSource(exist)
+	return owner != "" && owner != source
+}
+
+// reportVirtualServiceNameCollision logs and records a metric for a sync refused because of a name collision
+func reportVirtualServiceNameCollision(ctxLogger *log.Entry, name, owner, source, cluster string) {
+	vsNameCollisions.Increment(api.WithAttributes(
+		attribute.Key("cluster").String(cluster),
+		attribute.Key("name").String(name),
+	))
+	ctxLogger.Errorf(LogErrFormat, "NameCollisionCheck", common.VirtualServiceResourceType, name, cluster,
+		fmt.Sprintf("refused to sync %s as the name is owned by %s", source, owner))
+}
+
+// startVirtualServiceNameMigration waits for the cache warm up to complete and then renames the
+// VirtualServices synced to the cluster whose name does not follow the naming strategy
+func startVirtualServiceNameMigration(stop <-chan struct{}, rr *RemoteRegistry, clusterID string) {
+	if common.IsAdmiralOperatorMode() {
+		return
+	}
+	timer := time.NewTimer(time.Until(rr.StartTime.Add(common.GetAdmiralParams().CacheReconcileDuration)))
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return
+	case <-timer.C:
+	}
+	if commonUtil.IsAdmiralReadOnly() {
+		log.Infof(LogFormat, "VirtualServiceNameMigration", "", "", clusterID, "skipped as Admiral is in Read-only mode")
+		return
+	}
+	// the remote controller is looked up once the warm up completed, as it is replaced when its
+	// istio controllers are started or stopped
+	rc := rr.GetRemoteController(clusterID)
+	if rc == nil || rc.VirtualServiceController == nil {
+		return
+	}
+	migrateVirtualServiceNames(context.Background(), rr, rc)
+}
+
+// migrateVirtualServiceNames renames the VirtualServices admiral synced to the sync namespace of the
+// cluster. The VirtualService is created with its new name before the old one is deleted, so that
+// the host is routed throughout the migration. Copies synced before the source VirtualService was
+// recorded on them are renamed the next time the source VirtualService is synced
+func migrateVirtualServiceNames(ctx context.Context, rr *RemoteRegistry, rc *RemoteController) {
+	syncNamespace := common.GetSyncNamespaceForCluster(rc.ClusterID)
+	ctxLogger := log.WithFields(log.Fields{"type": "VirtualServiceNameMigration", "cluster": rc.ClusterID})
+	virtualServices, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		ctxLogger.Errorf(LogErrFormat, "VirtualServiceNameMigration", common.VirtualServiceResourceType, syncNamespace, rc.ClusterID, err)
+		return
+	}
+	renamed := 0
+	for _, virtualService := range virtualServices.Items {
+		source := virtualService.Annotations[common.AdmiralSourceVSAnnotation]
+		if !isGeneratedByAdmiral(virtualService.Annotations) || !strings.Contains(source, "/") || len(virtualService.Spec.Hosts) == 0 {
+			continue
+		}
+		sourceNamespace, sourceName, _ := strings.Cut(source, "/")
+		syncName := common.GenerateNameForVS(common.GetVSNameStrategy(), sourceNamespace, sourceName, getVirtualServiceIdentity(virtualService))
+		if syncName == "" || syncName == virtualService.Name {
+			continue
+		}
+		exist, err := rc.VirtualServiceController.IstioClient.NetworkingV1alpha3().VirtualServices(syncNamespace).Get(ctx, syncName, metaV1.GetOptions{})
+		if err == nil && isVirtualServiceNameCollision(exist, source) {
+			reportVirtualServiceNameCollision(ctxLogger, syncName, getVirtualServiceSource(exist), source, rc.ClusterID)
+			continue
+		}
+		if k8sErrors.IsNotFound(err) {
+			renamedVS := &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:        syncName,
+					Namespace:   syncNamespace,
+					Labels:      virtualService.Labels,
+					Annotations: virtualService.Annotations,
+				},
+				Spec: *virtualService.Spec.DeepCopy(),
+			}
+			err = addUpdateVirtualService(ctxLogger, ctx, renamedVS, nil, syncNamespace, rc, rr)
+		}
+		if err != nil {
+			ctxLogger.Errorf(LogErrFormat, "VirtualServiceNameMigration", common.VirtualServiceResourceType, syncName, rc.ClusterID, err)
+			continue
+		}
+		if err = deleteVirtualService(ctx, virtualService.Name, syncNamespace, rc); err != nil {
+			ctxLogger.Errorf(LogErrFormat, "VirtualServiceNameMigration", common.VirtualServiceResourceType, virtualService.Name, rc.ClusterID, err)
+			continue
+		}
+		renamed++
+		ctxLogger.Infof(LogFormat, "VirtualServiceNameMigration", common.VirtualServiceResourceType, virtualService.Name, rc.ClusterID, "renamed to "+syncName)
+	}
+	notifyGarbageCollection("VirtualServiceNameMigration", rc.ClusterID, renamed)
+}
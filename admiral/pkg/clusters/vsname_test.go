@@ -0,0 +1,195 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMigrateVirtualServiceNames(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:       &common.LabelSet{AdmiralCRDIdentityLabel: "identity"},
+		SyncNamespace:  "admiral-sync",
+		VSNameStrategy: common.VSNameStrategyIdentity,
+	})
+	syncedAnnotations := func(source string) map[string]string {
+		return map[string]string{
+			resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue,
+			common.AdmiralSourceVSAnnotation: source,
+		}
+	}
+	istioClient := istioFake.NewSimpleClientset(
+		// synced with the legacy strategy, should be renamed
+		&v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{Name: "foo-ns-foo-vs", Namespace: "admiral-sync",
+				Labels: map[string]string{"identity": "Foo"}, Annotations: syncedAnnotations("foo-ns/foo-vs")},
+			Spec: networking.VirtualService{Hosts: []string{"stage.foo.global"}},
+		},
+		// synced with the current strategy, should be left as is
+		&v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{Name: "bar-bar-vs", Namespace: "admiral-sync",
+				Labels: map[string]string{"identity": "bar"}, Annotations: syncedAnnotations("bar-ns/bar-vs")},
+			Spec: networking.VirtualService{Hosts: []string{"stage.bar.global"}},
+		},
+		// synced before the source was recorded, should be left as is
+		&v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{Name: "baz-ns-baz-vs", Namespace: "admiral-sync",
+				Annotations: map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}},
+		},
+		// not generated by admiral, should be left as is
+		&v1alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{Name: "custom-vs", Namespace: "admiral-sync",
+				Annotations: map[string]string{common.AdmiralSourceVSAnnotation: "custom-ns/vs"}},
+		},
+	)
+	rc := &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	ctx := context.Background()
+
+	migrateVirtualServiceNames(ctx, rr, rc)
+
+	virtualServices, err := istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	names := make([]string, 0, len(virtualServices.Items))
+	for _, virtualService := range virtualServices.Items {
+		names = append(names, virtualService.Name)
+	}
+	assert.ElementsMatch(t, []string{"foo-foo-vs", "bar-bar-vs", "baz-ns-baz-vs", "custom-vs"}, names)
+	renamed, err := istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").Get(ctx, "foo-foo-vs", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stage.foo.global"}, renamed.Spec.Hosts)
+	assert.Equal(t, "foo-ns/foo-vs", renamed.Annotations[common.AdmiralSourceVSAnnotation])
+}
+
+func TestDeleteStaleVirtualServiceSyncNames(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{},
+		SyncNamespace: "admiral-sync",
+	})
+	admiralAnnotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+	istioClient := istioFake.NewSimpleClientset(
+		&v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "foo-ns-foo-vs", Namespace: "admiral-sync", Annotations: admiralAnnotations}},
+		&v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "foo-foo-vs", Namespace: "admiral-sync", Annotations: admiralAnnotations}},
+		// a VirtualService with the name of the source which admiral did not generate is not deleted
+		&v1alpha3.VirtualService{ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs", Namespace: "admiral-sync"}},
+	)
+	rc := &RemoteController{
+		ClusterID:                "cluster1",
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	ctx := context.Background()
+
+	deleteStaleVirtualServiceSyncNames(ctx, log.WithField("type", "VirtualService"), rc, "admiral-sync", "foo-ns", "foo-vs", "foo", "foo-ns-foo-vs")
+
+	virtualServices, err := istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	names := make([]string, 0, len(virtualServices.Items))
+	for _, virtualService := range virtualServices.Items {
+		names = append(names, virtualService.Name)
+	}
+	assert.ElementsMatch(t, []string{"foo-ns-foo-vs", "foo-vs"}, names)
+}
+
+func TestSyncVirtualServiceNameCollision(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{},
+		SyncNamespace: "admiral-sync",
+	})
+	testCases := []struct {
+		name          string
+		event         common.Event
+		owner         string
+		expectedHosts []string
+		expectedFound bool
+	}{
+		{
+			name: "Given a synced virtualservice owned by another source, " +
+				"When a virtualservice with the same name is synced, " +
+				"Then the synced virtualservice should not be overwritten",
+			event:         common.Add,
+			owner:         "bar-ns/foo-vs",
+			expectedHosts: []string{"stage.bar.global"},
+			expectedFound: true,
+		},
+		{
+			name: "Given a synced virtualservice owned by another source, " +
+				"When a virtualservice with the same name is deleted, " +
+				"Then the synced virtualservice should not be deleted",
+			event:         common.Delete,
+			owner:         "bar-ns/foo-vs",
+			expectedHosts: []string{"stage.bar.global"},
+			expectedFound: true,
+		},
+		{
+			name: "Given a synced virtualservice owned by the same source, " +
+				"When the virtualservice is synced, " +
+				"Then the synced virtualservice should be updated",
+			event:         common.Update,
+			owner:         "foo-ns/foo-vs",
+			expectedHosts: []string{"stage.foo.global"},
+			expectedFound: true,
+		},
+		{
+			name: "Given a synced virtualservice owned by the same source, " +
+				"When the virtualservice is deleted, " +
+				"Then the synced virtualservice should be deleted",
+			event:         common.Delete,
+			owner:         "foo-ns/foo-vs",
+			expectedFound: false,
+		},
+		{
+			name: "Given a synced virtualservice without a recorded source, " +
+				"When a virtualservice with the same name is synced, " +
+				"Then the synced virtualservice should be adopted",
+			event:         common.Update,
+			expectedHosts: []string{"stage.foo.global"},
+			expectedFound: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{resourceCreatedByAnnotationLabel: resourceCreatedByAnnotationValue}
+			if tc.owner != "" {
+				annotations[common.AdmiralSourceVSAnnotation] = tc.owner
+			}
+			istioClient := istioFake.NewSimpleClientset(&v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs-synced", Namespace: "admiral-sync", Annotations: annotations},
+				Spec:       networking.VirtualService{Hosts: []string{"stage.bar.global"}},
+			})
+			rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+			rr.PutRemoteController("cluster1", &RemoteController{
+				ClusterID:                "cluster1",
+				VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+			})
+			virtualService := &v1alpha3.VirtualService{
+				ObjectMeta: metaV1.ObjectMeta{Name: "foo-vs", Namespace: "foo-ns"},
+				Spec:       networking.VirtualService{Hosts: []string{"stage.foo.global"}},
+			}
+			ctx := context.Background()
+
+			err := syncVirtualServiceToDependentCluster(ctx, "cluster1", rr, virtualService, tc.event, "admiral-sync", "foo-vs-synced", "cluster2")
+
+			assert.Nil(t, err)
+			synced, err := istioClient.NetworkingV1alpha3().VirtualServices("admiral-sync").Get(ctx, "foo-vs-synced", metaV1.GetOptions{})
+			assert.Equal(t, tc.expectedFound, err == nil)
+			if tc.expectedFound {
+				assert.Equal(t, tc.expectedHosts, synced.Spec.Hosts)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package clusters
+
+import (
+	"context"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sV1 "k8s.io/api/core/v1"
+)
+
+// getWeightedServicesForDeploymentVariants returns the services of the deployments the identity runs behind the env
+// in the cluster, weighted by their replicas, when the identity runs more than one deployment behind the env.
+// The endpoints of the ServiceEntry generated for the source cluster are then spread across all the deployments
+func getWeightedServicesForDeploymentVariants(ctx context.Context, ctxLogger *log.Entry, rr *RemoteRegistry,
+	rc *RemoteController, identity, env string) map[string]*WeightedService {
+	variants := rc.DeploymentController.Cache.GetVariants(identity, env)
+	if len(variants) < 2 {
+		return nil
+	}
+	weightedServices := make(map[string]*WeightedService)
+	for _, variant := range variants {
+		if isNamespaceFiltered(rr, rc.ClusterID, variant.Namespace) {
+			continue
+		}
+		service, err := getServiceForDeployment(rc, variant)
+		if err != nil || service == nil {
+			ctxLogger.Warnf(common.CtxLogFormat, "GetServiceForDeploymentVariant", variant.Name, variant.Namespace, rc.ClusterID, "no matching service instance found")
+			continue
+		}
+		addVariantWeightedService(weightedServices, service, variant.Spec.Replicas)
+	}
+	return normalizeVariantWeights(weightedServices)
+}
+
+// getWeightedServicesForRolloutVariants returns the services of the rollouts the identity runs behind the env in
+// the cluster, weighted by their replicas, when the identity runs more than one rollout behind the env. Rollouts
+// splitting traffic between several services, such as blue-green and canary rollouts, are left out as their
+// endpoints are weighted by their strategy
+func getWeightedServicesForRolloutVariants(ctx context.Context, ctxLogger *log.Entry, rr *RemoteRegistry,
+	rc *RemoteController, identity, env string) map[string]*WeightedService {
+	variants := rc.RolloutController.Cache.GetVariants(identity, env)
+	if len(variants) < 2 {
+		return nil
+	}
+	weightedServices := make(map[string]*WeightedService)
+	for _, variant := range variants {
+		if isNamespaceFiltered(rr, rc.ClusterID, variant.Namespace) || isBlueGreenStrategy(variant) {
+			continue
+		}
+		services := getServiceForRollout(ctx, rc, variant)
+		if len(services) != 1 {
+			ctxLogger.Warnf(common.CtxLogFormat, "GetServiceForRolloutVariant", variant.Name, variant.Namespace, rc.ClusterID, "skipped as it does not have exactly one service")
+			continue
+		}
+		for _, service := range services {
+			addVariantWeightedService(weightedServices, service.Service, variant.Spec.Replicas)
+		}
+	}
+	return normalizeVariantWeights(weightedServices)
+}
+
+// addVariantWeightedService adds the replicas of a workload to the weight of its service, as
+// several workloads can be selected by the same service
+func addVariantWeightedService(weightedServices map[string]*WeightedService, service *k8sV1.Service, replicas *int32) {
+	weight := int32(1)
+	if replicas != nil {
+		weight = *replicas
+	}
+	if weightedService, ok := weightedServices[service.Name]; ok {
+		weightedService.Weight += weight
+		return
+	}
+	weightedServices[service.Name] = &WeightedService{Weight: weight, Service: service}
+}
+
+// normalizeVariantWeights weights the services evenly when all the workloads are scaled down,
+// so that the ServiceEntry keeps an endpoint for each of them
+func normalizeVariantWeights(weightedServices map[string]*WeightedService) map[string]*WeightedService {
+	var totalWeight int32
+	for _, weightedService := range weightedServices {
+		totalWeight += weightedService.Weight
+	}
+	if totalWeight > 0 {
+		return weightedServices
+	}
+	for _, weightedService := range weightedServices {
+		weightedService.Weight = 1
+	}
+	return weightedServices
+}
@@ -0,0 +1,110 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestGetWeightedServicesForDeploymentVariants(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey: "identity",
+			EnvKey:              "env",
+		},
+	})
+	ctx := context.Background()
+	ctxLogger := log.WithFields(log.Fields{"type": "WorkloadVariants"})
+	int32Ptr := func(i int32) *int32 { return &i }
+	deployment := func(name string, replicas *int32) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{
+			ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: "foo-ns"},
+			Spec: k8sAppsV1.DeploymentSpec{
+				Replicas: replicas,
+				Selector: &metaV1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+				Template: coreV1.PodTemplateSpec{
+					ObjectMeta: metaV1.ObjectMeta{
+						Labels:      map[string]string{"identity": "foo", "env": "stage", "app": name},
+						Annotations: map[string]string{common.SidecarEnabledPorts: "8090"},
+					},
+				},
+			},
+		}
+	}
+	service := func(name string) *coreV1.Service {
+		return &coreV1.Service{
+			ObjectMeta: metaV1.ObjectMeta{Name: name + "-svc", Namespace: "foo-ns"},
+			Spec: coreV1.ServiceSpec{
+				Selector: map[string]string{"app": name},
+				Ports:    []coreV1.ServicePort{{Name: "http", Port: 8090}},
+			},
+		}
+	}
+
+	newRemoteController := func(deployments ...*k8sAppsV1.Deployment) *RemoteController {
+		serviceController, err := admiral.NewServiceController(make(chan struct{}), &test.MockServiceHandler{},
+			&rest.Config{Host: "localhost"}, time.Second*time.Duration(300), loader.GetFakeClientLoader())
+		assert.Nil(t, err)
+		deploymentController := &admiral.DeploymentController{Cache: admiral.NewDeploymentCache()}
+		for _, d := range deployments {
+			serviceController.Cache.Put(service(d.Name))
+			deploymentController.Cache.UpdateDeploymentToClusterCache("foo", d)
+		}
+		return &RemoteController{ClusterID: "cluster1", ServiceController: serviceController, DeploymentController: deploymentController}
+	}
+
+	testCases := []struct {
+		name        string
+		deployments []*k8sAppsV1.Deployment
+		expected    map[string]int32
+	}{
+		{
+			name: "Given the identity runs a single deployment behind the env, " +
+				"When getWeightedServicesForDeploymentVariants is called, " +
+				"Then it should return nothing",
+			deployments: []*k8sAppsV1.Deployment{deployment("foo-cpu", int32Ptr(3))},
+		},
+		{
+			name: "Given the identity runs two deployments behind the env, " +
+				"When getWeightedServicesForDeploymentVariants is called, " +
+				"Then the services of both deployments should be weighted by their replicas",
+			deployments: []*k8sAppsV1.Deployment{deployment("foo-cpu", int32Ptr(3)), deployment("foo-gpu", nil)},
+			expected:    map[string]int32{"foo-cpu-svc": 3, "foo-gpu-svc": 1},
+		},
+		{
+			name: "Given the identity runs two deployments scaled down behind the env, " +
+				"When getWeightedServicesForDeploymentVariants is called, " +
+				"Then the services of both deployments should be weighted evenly",
+			deployments: []*k8sAppsV1.Deployment{deployment("foo-cpu", int32Ptr(0)), deployment("foo-gpu", int32Ptr(0))},
+			expected:    map[string]int32{"foo-cpu-svc": 1, "foo-gpu-svc": 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+			rc := newRemoteController(tc.deployments...)
+			weightedServices := getWeightedServicesForDeploymentVariants(ctx, ctxLogger, rr, rc, "foo", "stage")
+			if tc.expected == nil {
+				assert.Nil(t, weightedServices)
+				return
+			}
+			weights := make(map[string]int32)
+			for name, weightedService := range weightedServices {
+				weights[name] = weightedService.Weight
+			}
+			assert.Equal(t, tc.expected, weights)
+		})
+	}
+}
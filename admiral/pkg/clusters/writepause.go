@@ -0,0 +1,269 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// WritePauseSourceSecret is the source of a pause set with the write-paused annotation on the cluster secret
+	WritePauseSourceSecret = "secret"
+	// WritePauseSourceAPI is the source of a pause set through the admiral API
+	WritePauseSourceAPI = "api"
+)
+
+// queuedWrite is a write to a paused cluster which is replayed when writes to the cluster resume
+type queuedWrite struct {
+	key   string
+	ctx   context.Context
+	write func(ctx context.Context, rc *RemoteController) error
+}
+
+// clusterWritePauseCache keeps track of the clusters writes are paused for, along with the
+// writes which were attempted while they were paused. A cluster stays paused as long as one
+// of the sources has paused it
+type clusterWritePauseCache struct {
+	lock   sync.Mutex
+	paused map[string]map[string]bool
+	queued map[string][]queuedWrite
+}
+
+func newClusterWritePauseCache() *clusterWritePauseCache {
+	return &clusterWritePauseCache{
+		paused: make(map[string]map[string]bool),
+		queued: make(map[string][]queuedWrite),
+	}
+}
+
+// clusterWritePauses is shared by every remote controller, so that the pause of a cluster and
+// its queued writes survive the remote controller being recreated
+var clusterWritePauses = newClusterWritePauseCache()
+
+// PauseClusterWrites stops admiral from writing to the cluster until the source resumes them
+func PauseClusterWrites(cluster, source string) {
+	clusterWritePauses.lock.Lock()
+	defer clusterWritePauses.lock.Unlock()
+	if clusterWritePauses.paused[cluster] == nil {
+		clusterWritePauses.paused[cluster] = make(map[string]bool)
+	}
+	if !clusterWritePauses.paused[cluster][source] {
+		log.Infof(LogFormat, "PauseWrites", "cluster", cluster, cluster, "writes paused by source="+source)
+	}
+	clusterWritePauses.paused[cluster][source] = true
+}
+
+// ResumeClusterWrites clears the pause the source set on the cluster. When no other source keeps
+// the cluster paused, the writes queued while it was paused are replayed in the order they were queued,
+// and the resources of the cluster are then repaired
+func ResumeClusterWrites(rr *RemoteRegistry, cluster, source string) {
+	clusterWritePauses.lock.Lock()
+	if !clusterWritePauses.paused[cluster][source] {
+		clusterWritePauses.lock.Unlock()
+		return
+	}
+	delete(clusterWritePauses.paused[cluster], source)
+	log.Infof(LogFormat, "ResumeWrites", "cluster", cluster, cluster, "writes resumed by source="+source)
+	if len(clusterWritePauses.paused[cluster]) > 0 {
+		clusterWritePauses.lock.Unlock()
+		return
+	}
+	delete(clusterWritePauses.paused, cluster)
+	writes := clusterWritePauses.queued[cluster]
+	delete(clusterWritePauses.queued, cluster)
+	clusterWritePauses.lock.Unlock()
+	replayQueuedWrites(rr, cluster, writes)
+	repairClusterWrites(rr, cluster, modifyServiceEntryForWorkloadEvent)
+}
+
+// IsClusterWritePaused checks if writes to the cluster are paused by any source
+func IsClusterWritePaused(cluster string) bool {
+	clusterWritePauses.lock.Lock()
+	defer clusterWritePauses.lock.Unlock()
+	return len(clusterWritePauses.paused[cluster]) > 0
+}
+
+// ClusterWritePauseState is the pause state of a cluster
+type ClusterWritePauseState struct {
+	Cluster      string   `json:"cluster"`
+	Sources      []string `json:"sources"`
+	QueuedWrites int      `json:"queuedWrites"`
+}
+
+// GetClusterWritePauseStates returns the state of every paused cluster, sorted by cluster
+func GetClusterWritePauseStates() []ClusterWritePauseState {
+	clusterWritePauses.lock.Lock()
+	defer clusterWritePauses.lock.Unlock()
+	states := make([]ClusterWritePauseState, 0, len(clusterWritePauses.paused))
+	for cluster, sources := range clusterWritePauses.paused {
+		if len(sources) == 0 {
+			continue
+		}
+		state := ClusterWritePauseState{Cluster: cluster, QueuedWrites: len(clusterWritePauses.queued[cluster])}
+		for source := range sources {
+			state.Sources = append(state.Sources, source)
+		}
+		sort.Strings(state.Sources)
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Cluster < states[j].Cluster })
+	return states
+}
+
+// queueWriteIfPaused queues the write when writes to the cluster are paused, admiral is in
+// maintenance mode or the startup barrier is raised, and returns whether it was queued. A queued write replaces the write queued
+// earlier for the same resource, so that only the latest state of a resource is applied when writes resume
+func queueWriteIfPaused(ctx context.Context, cluster string, kind common.ResourceType, namespace, name string,
+	write func(ctx context.Context, rc *RemoteController) error) bool {
+	clusterWritePauses.lock.Lock()
+	defer clusterWritePauses.lock.Unlock()
+	if len(clusterWritePauses.paused[cluster]) == 0 && !admiral.IsWriteStoppedForMaintenance() && !isStartupBarrierRaised() {
+		return false
+	}
+	key := fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+	writes := clusterWritePauses.queued[cluster]
+	for i := range writes {
+		if writes[i].key == key {
+			writes = append(writes[:i], writes[i+1:]...)
+			break
+		}
+	}
+	clusterWritePauses.queued[cluster] = append(writes, queuedWrite{key: key, ctx: ctx, write: write})
+	log.Infof(LogFormatAdv, "QueueWrite", kind, name, namespace, cluster, "writes to cluster are paused, write queued")
+	return true
+}
+
+// replayUnpausedClusterWrites replays the writes queued while admiral was in maintenance mode
+// to the clusters which are not paused
+func replayUnpausedClusterWrites(rr *RemoteRegistry) {
+	clusterWritePauses.lock.Lock()
+	unpaused := make(map[string][]queuedWrite)
+	for cluster, writes := range clusterWritePauses.queued {
+		if len(clusterWritePauses.paused[cluster]) == 0 {
+			unpaused[cluster] = writes
+			delete(clusterWritePauses.queued, cluster)
+		}
+	}
+	clusterWritePauses.lock.Unlock()
+	for cluster, writes := range unpaused {
+		replayQueuedWrites(rr, cluster, writes)
+	}
+}
+
+// clearClusterWritePause forgets the pause and the queued writes of a cluster admiral no longer watches
+func clearClusterWritePause(cluster string) {
+	clusterWritePauses.lock.Lock()
+	defer clusterWritePauses.lock.Unlock()
+	if queued := len(clusterWritePauses.queued[cluster]); queued > 0 {
+		log.Warnf(LogFormat, "ClearWritePause", "cluster", cluster, cluster, fmt.Sprintf("dropping %d queued writes", queued))
+	}
+	delete(clusterWritePauses.paused, cluster)
+	delete(clusterWritePauses.queued, cluster)
+}
+
+func replayQueuedWrites(rr *RemoteRegistry, cluster string, writes []queuedWrite) {
+	if len(writes) == 0 {
+		return
+	}
+	var rc *RemoteController
+	if rr != nil {
+		rc = rr.GetRemoteController(cluster)
+	}
+	if rc == nil {
+		log.Warnf(LogFormat, "ReplayWrites", "cluster", cluster, cluster, fmt.Sprintf("remote controller not found, dropping %d queued writes", len(writes)))
+		return
+	}
+	log.Infof(LogFormat, "ReplayWrites", "cluster", cluster, cluster, fmt.Sprintf("replaying %d queued writes", len(writes)))
+	for _, w := range writes {
+		if err := common.CallWithRecovery(w.ctx, common.ReplayWrites, func() error { return w.write(w.ctx, rc) }); err != nil {
+			log.Errorf(LogErrFormat, "ReplayWrites", w.key, "", cluster, err)
+		}
+	}
+}
+
+// repairClusterWrites runs modifySE again for every identity which runs in the cluster or has dependents
+// in it, so that the resources which were changed or deleted in the cluster while writes to it were paused
+// are brought back in line. The resources which did not drift are left alone, as their specs are unchanged.
+// Unrelated identities are repaired in parallel
+func repairClusterWrites(rr *RemoteRegistry, cluster string, modifySE ModifySEFunc) {
+	if rr == nil || rr.AdmiralCache == nil || rr.GetRemoteController(cluster) == nil {
+		return
+	}
+	identities := getClusterIdentities(rr.AdmiralCache, cluster)
+	if len(identities) == 0 {
+		return
+	}
+	log.Infof(LogFormat, "RepairWrites", "cluster", cluster, cluster, fmt.Sprintf("repairing the resources of %d identities", len(identities)))
+	refreshIdentities(rr, identities, common.ClusterWriteRepair, modifySE)
+}
+
+// getClusterIdentities returns the identities which run in the cluster, along with the identities
+// of the cnames which have dependents in it, sorted by identity
+func getClusterIdentities(cache *AdmiralCache, cluster string) []string {
+	identities := make(map[string]bool)
+	if cache.IdentityClusterCache != nil {
+		cache.IdentityClusterCache.Range(func(identity string, clusters *common.Map) {
+			if clusters != nil && clusters.CheckIfPresent(cluster) {
+				identities[identity] = true
+			}
+		})
+	}
+	if cache.CnameDependentClusterCache != nil && cache.CnameIdentityCache != nil {
+		cache.CnameDependentClusterCache.Range(func(cname string, clusters *common.Map) {
+			if clusters == nil || !clusters.CheckIfPresent(cluster) {
+				return
+			}
+			if identity, ok := cache.CnameIdentityCache.Load(cname); ok {
+				identities[identity.(string)] = true
+			}
+		})
+	}
+	sorted := make([]string, 0, len(identities))
+	for identity := range identities {
+		sorted = append(sorted, identity)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// getIdentitySourceEnvs returns a source cluster of the identity, the type of its workloads in that
+// cluster and the environments they run in
+func getIdentitySourceEnvs(rr *RemoteRegistry, identity string) (string, string, []string) {
+	clusters := rr.AdmiralCache.IdentityClusterCache.Get(identity)
+	if clusters == nil {
+		return "", "", nil
+	}
+	sourceClusters := clusters.GetKeys()
+	sort.Strings(sourceClusters)
+	for _, sourceCluster := range sourceClusters {
+		rc := rr.GetRemoteController(sourceCluster)
+		if rc == nil {
+			continue
+		}
+		var envs []string
+		if rc.DeploymentController != nil && rc.DeploymentController.Cache != nil {
+			for env := range rc.DeploymentController.Cache.GetByIdentity(identity) {
+				envs = append(envs, env)
+			}
+			if len(envs) > 0 {
+				sort.Strings(envs)
+				return sourceCluster, common.Deployment, envs
+			}
+		}
+		if rc.RolloutController != nil && rc.RolloutController.Cache != nil {
+			for env := range rc.RolloutController.Cache.GetByIdentity(identity) {
+				envs = append(envs, env)
+			}
+			if len(envs) > 0 {
+				sort.Strings(envs)
+				return sourceCluster, common.Rollout, envs
+			}
+		}
+	}
+	return "", "", nil
+}
@@ -0,0 +1,156 @@
+package clusters
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/istio"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	networkingV1Alpha3 "istio.io/api/networking/v1alpha3"
+	apiNetworkingV1Alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPauseAndResumeClusterWrites(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{},
+		SyncNamespace: "ns",
+	})
+	ctx := context.Background()
+	ctxLogger := log.WithFields(log.Fields{"txId": "abc"})
+	cluster := "cluster1"
+	namespace := "ns"
+	istioClient := istioFake.NewSimpleClientset(&apiNetworkingV1Alpha3.VirtualService{
+		ObjectMeta: metaV1.ObjectMeta{Name: "stage.bar.global-vs", Namespace: namespace},
+	})
+	rc := &RemoteController{
+		ClusterID:                cluster,
+		VirtualServiceController: &istio.VirtualServiceController{IstioClient: istioClient},
+	}
+	rr := NewRemoteRegistry(ctx, common.AdmiralParams{})
+	rr.PutRemoteController(cluster, rc)
+	defer clearClusterWritePause(cluster)
+
+	vs := func(host string) *apiNetworkingV1Alpha3.VirtualService {
+		return &apiNetworkingV1Alpha3.VirtualService{
+			ObjectMeta: metaV1.ObjectMeta{Name: "stage.foo.global-vs", Namespace: namespace},
+			Spec:       networkingV1Alpha3.VirtualService{Hosts: []string{host}},
+		}
+	}
+
+	PauseClusterWrites(cluster, WritePauseSourceSecret)
+	PauseClusterWrites(cluster, WritePauseSourceAPI)
+	assert.True(t, IsClusterWritePaused(cluster))
+
+	// writes to a paused cluster are queued, the latest write of a resource replaces the earlier one
+	assert.Nil(t, addUpdateVirtualService(ctxLogger, ctx, vs("stage.foo.global"), nil, namespace, rc, rr))
+	assert.Nil(t, addUpdateVirtualService(ctxLogger, ctx, vs("stage.foo.updated.global"), nil, namespace, rc, rr))
+	assert.Nil(t, deleteVirtualService(ctx, "stage.bar.global-vs", namespace, rc))
+	vsList, err := istioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vsList.Items))
+	assert.Equal(t, []ClusterWritePauseState{{
+		Cluster:      cluster,
+		Sources:      []string{WritePauseSourceAPI, WritePauseSourceSecret},
+		QueuedWrites: 2,
+	}}, GetClusterWritePauseStates())
+
+	// the cluster stays paused while one of the sources keeps it paused
+	ResumeClusterWrites(rr, cluster, WritePauseSourceAPI)
+	assert.True(t, IsClusterWritePaused(cluster))
+	vsList, err = istioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vsList.Items))
+
+	// the queued writes are replayed once every source resumed the cluster
+	ResumeClusterWrites(rr, cluster, WritePauseSourceSecret)
+	assert.False(t, IsClusterWritePaused(cluster))
+	assert.Empty(t, GetClusterWritePauseStates())
+	vsList, err = istioClient.NetworkingV1alpha3().VirtualServices(namespace).List(ctx, metaV1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(vsList.Items))
+	assert.Equal(t, "stage.foo.global-vs", vsList.Items[0].Name)
+	assert.Equal(t, []string{"stage.foo.updated.global"}, vsList.Items[0].Spec.Hosts)
+}
+
+func TestClearClusterWritePause(t *testing.T) {
+	ctx := context.Background()
+	cluster := "cluster-removed"
+	PauseClusterWrites(cluster, WritePauseSourceAPI)
+	replayed := false
+	assert.True(t, queueWriteIfPaused(ctx, cluster, common.ServiceEntryResourceType, "ns", "foo-se",
+		func(ctx context.Context, rc *RemoteController) error {
+			replayed = true
+			return nil
+		}))
+	clearClusterWritePause(cluster)
+	assert.False(t, IsClusterWritePaused(cluster))
+	ResumeClusterWrites(nil, cluster, WritePauseSourceAPI)
+	assert.False(t, replayed)
+	assert.False(t, queueWriteIfPaused(ctx, cluster, common.ServiceEntryResourceType, "ns", "foo-se",
+		func(ctx context.Context, rc *RemoteController) error { return nil }))
+}
+
+func TestRepairClusterWrites(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:      &common.LabelSet{},
+		SyncNamespace: "ns",
+	})
+	rr := NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+
+	// foo runs in the resumed cluster, bar runs in another cluster and has dependents in the resumed cluster
+	deployments := admiral.NewDeploymentCache()
+	deployments.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-stage"}})
+	deployments.UpdateDeploymentToClusterCache("foo", &k8sAppsV1.Deployment{ObjectMeta: metaV1.ObjectMeta{Name: "foo", Namespace: "foo-qa"}})
+	rr.PutRemoteController("cluster1", &RemoteController{
+		ClusterID:            "cluster1",
+		DeploymentController: &admiral.DeploymentController{Cache: deployments},
+	})
+	rollouts := admiral.NewRolloutCache()
+	rollouts.UpdateRolloutToClusterCache("bar", &argo.Rollout{ObjectMeta: metaV1.ObjectMeta{Name: "bar", Namespace: "bar-stage"}})
+	rr.PutRemoteController("cluster2", &RemoteController{
+		ClusterID:            "cluster2",
+		DeploymentController: &admiral.DeploymentController{Cache: admiral.NewDeploymentCache()},
+		RolloutController:    &admiral.RolloutController{Cache: rollouts},
+	})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("bar", "cluster2", "cluster2")
+	rr.AdmiralCache.IdentityClusterCache.Put("baz", "cluster2", "cluster2")
+	rr.AdmiralCache.CnameDependentClusterCache.Put("stage.bar.global", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.bar.global", "bar")
+
+	var (
+		lock     sync.Mutex
+		repaired []string
+	)
+	modifySE := func(ctx context.Context, event admiral.EventType, env string, sourceIdentity string,
+		remoteRegistry *RemoteRegistry) (map[string]*networkingV1Alpha3.ServiceEntry, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, admiral.Update, event)
+		repaired = append(repaired, sourceIdentity+"/"+env+"/"+
+			ctx.Value(common.ClusterName).(string)+"/"+ctx.Value(common.EventResourceType).(string))
+		return nil, nil
+	}
+
+	repairClusterWrites(rr, "cluster1", modifySE)
+	assert.Equal(t, []string{
+		"bar/stage/cluster2/" + common.Rollout,
+		"foo/qa/cluster1/" + common.Deployment,
+		"foo/stage/cluster1/" + common.Deployment,
+	}, repaired)
+
+	// nothing is repaired for a cluster admiral does not watch
+	repaired = nil
+	repairClusterWrites(rr, "cluster3", modifySE)
+	assert.Empty(t, repaired)
+}
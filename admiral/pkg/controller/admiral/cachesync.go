@@ -0,0 +1,67 @@
+package admiral
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	cacheSyncMutex sync.Mutex
+	// cacheSyncs holds the running controllers of each cluster, keyed by the API server of the cluster,
+	// along with whether the events of their initial listing have been processed
+	cacheSyncs = make(map[string]map[*Controller]bool)
+)
+
+func trackCacheSync(c *Controller) {
+	cacheSyncMutex.Lock()
+	defer cacheSyncMutex.Unlock()
+	if cacheSyncs[c.cluster] == nil {
+		cacheSyncs[c.cluster] = make(map[*Controller]bool)
+	}
+	cacheSyncs[c.cluster][c] = false
+}
+
+func untrackCacheSync(c *Controller) {
+	cacheSyncMutex.Lock()
+	defer cacheSyncMutex.Unlock()
+	delete(cacheSyncs[c.cluster], c)
+	if len(cacheSyncs[c.cluster]) == 0 {
+		delete(cacheSyncs, c.cluster)
+	}
+}
+
+// GetUnsyncedControllers returns the names of the running controllers of the cluster with the
+// given API server whose informer caches have not synced yet
+func GetUnsyncedControllers(apiServer string) []string {
+	cacheSyncMutex.Lock()
+	defer cacheSyncMutex.Unlock()
+	unsynced := make([]string, 0)
+	for c := range cacheSyncs[apiServer] {
+		if !c.informer.HasSynced() {
+			unsynced = append(unsynced, c.name)
+		}
+	}
+	sort.Strings(unsynced)
+	return unsynced
+}
+
+// AreCachesSettled returns true when the informer caches of the running controllers of every
+// cluster have synced and the events of their initial listing have been processed
+func AreCachesSettled() bool {
+	cacheSyncMutex.Lock()
+	defer cacheSyncMutex.Unlock()
+	settled := true
+	for _, controllers := range cacheSyncs {
+		for c, processed := range controllers {
+			if processed {
+				continue
+			}
+			if c.informer.HasSynced() && c.queue.Len() == 0 {
+				controllers[c] = true
+				continue
+			}
+			settled = false
+		}
+	}
+	return settled
+}
@@ -0,0 +1,54 @@
+package admiral
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestGetUnsyncedControllers(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	syncedInformer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Pods("").List(context.TODO(), opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Pods("").Watch(context.TODO(), opts)
+		},
+	}, &v1.Pod{}, 0, cache.Indexers{})
+	unsyncedInformer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return nil, errors.New("cluster unreachable")
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return nil, errors.New("cluster unreachable")
+		},
+	}, &v1.Pod{}, 0, cache.Indexers{})
+
+	stop := make(chan struct{})
+	NewController("synced-ctrl", "api-server-1", stop, nil, syncedInformer)
+	NewController("unsynced-ctrl", "api-server-1", stop, nil, unsyncedInformer)
+
+	assert.Eventually(t, func() bool {
+		unsynced := GetUnsyncedControllers("api-server-1")
+		return len(unsynced) == 1 && unsynced[0] == "unsynced-ctrl"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, GetUnsyncedControllers("api-server-2"))
+
+	close(stop)
+	assert.Eventually(t, func() bool {
+		cacheSyncMutex.Lock()
+		defer cacheSyncMutex.Unlock()
+		_, ok := cacheSyncs["api-server-1"]
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
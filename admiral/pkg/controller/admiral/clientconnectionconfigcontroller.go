@@ -231,7 +231,7 @@ func (c *ClientConnectionConfigController) Get(ctx context.Context, isRetry bool
 		return nil, fmt.Errorf("type assertion failed, %v is not of type *v1.ClientConnectionConfig", obj)
 	}
 	if c.crdClient == nil {
-		return nil, fmt.Errorf("crd client is not initialized, txId=%s", ctx.Value("txId"))
+		return nil, fmt.Errorf("crd client is not initialized, txId=%s", common.GetTxId(ctx))
 	}
 	return c.crdClient.AdmiralV1alpha1().
 		ClientConnectionConfigs(clientConnectionSettings.Namespace).
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/util"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/sdk"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/sirupsen/logrus"
@@ -57,7 +58,8 @@ type Delegator interface {
 	IsOnlyReplicaCountChanged(*log.Entry, interface{}, interface{}) (bool, error)
 }
 
-type EventType string
+// EventType is shared with the sdk, so that the IdentityConfig records the event with this type
+type EventType = sdk.EventType
 
 const (
 	Add    EventType = "Add"
@@ -72,6 +74,8 @@ type InformerCacheObj struct {
 	oldObj    interface{}
 	txId      string
 	ctxLogger *log.Entry
+	// tenant is the team of the identity of the object, its events are served in turns with the other tenants
+	tenant string
 }
 
 type Controller struct {
@@ -92,7 +96,7 @@ func NewController(name, clusterEndpoint string, stopCh <-chan struct{}, delegat
 		cluster:   clusterEndpoint,
 		informer:  informer,
 		delegator: delegator,
-		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		queue:     newPriorityQueue(name, clusterEndpoint, workqueue.DefaultControllerRateLimiter()),
 	}
 	controller.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    controller.AddFuncImpl,
@@ -125,7 +129,13 @@ func updateTxId(
 	return txId, ctxLogger
 }
 
+// recoveryContext is the context the panics of the controller are logged with
+func (c *Controller) recoveryContext() context.Context {
+	return context.WithValue(context.Background(), common.ClusterName, c.cluster)
+}
+
 func (c *Controller) AddFuncImpl(obj interface{}) {
+	defer common.RecoverPanic(c.recoveryContext(), c.name, nil)
 	var (
 		txId                    = uuid.NewString()
 		metaName, metaNamespace string
@@ -153,11 +163,13 @@ func (c *Controller) AddFuncImpl(obj interface{}) {
 			obj:       obj,
 			txId:      txId,
 			ctxLogger: ctxLogger,
+			tenant:    tenantOf(obj),
 		})
 	}
 }
 
 func (c *Controller) UpdateFuncImpl(oldObj, newObj interface{}) {
+	defer common.RecoverPanic(c.recoveryContext(), c.name, nil)
 	var (
 		ctx                     = context.Background()
 		txId                    = uuid.NewString()
@@ -169,7 +181,7 @@ func (c *Controller) UpdateFuncImpl(oldObj, newObj interface{}) {
 		metaName = meta.GetName()
 		metaNamespace = meta.GetNamespace()
 	}
-	ctx = context.WithValue(ctx, "txId", txId)
+	ctx = common.WithTxId(ctx, txId)
 	ctxLogger := log.WithFields(log.Fields{
 		"op":         operationInformerEvents,
 		"name":       metaName,
@@ -232,6 +244,7 @@ func (c *Controller) UpdateFuncImpl(oldObj, newObj interface{}) {
 					oldObj:    oldObj,
 					txId:      txId,
 					ctxLogger: ctxLogger,
+					tenant:    tenantOf(latestObj),
 				})
 			// If the pod is running in Active Mode we update the status to ProcessingInProgress
 			// to prevent any duplicate events that might be added to the queue if there is full
@@ -246,6 +259,7 @@ func (c *Controller) UpdateFuncImpl(oldObj, newObj interface{}) {
 }
 
 func (c *Controller) DeleteFuncImpl(obj interface{}) {
+	defer common.RecoverPanic(c.recoveryContext(), c.name, nil)
 	var (
 		txId = uuid.NewString()
 	)
@@ -284,6 +298,8 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	defer c.queue.ShutDown()
 
 	log.Infof("Starting controller=%v cluster=%v", c.name, c.cluster)
+	trackCacheSync(c)
+	defer untrackCacheSync(c)
 
 	go c.informer.Run(stopCh)
 
@@ -327,6 +343,10 @@ func (c *Controller) processNextItem() bool {
 	if !ok {
 		return true
 	}
+	if c.bufferForMaintenance(informerCache) {
+		c.queue.Forget(item)
+		return true
+	}
 	var (
 		txId         string
 		err          error
@@ -335,7 +355,7 @@ func (c *Controller) processNextItem() bool {
 	)
 
 	txId = informerCache.txId
-	ctx = context.WithValue(ctx, "txId", txId)
+	ctx = common.WithTxId(ctx, txId)
 	ctxLogger := informerCache.ctxLogger
 	if c.queue.NumRequeues(item) > 0 {
 		ctxLogger.Infof(ControllerLogFormat, taskRequeueAttempt, c.queue.Len(),
@@ -387,16 +407,19 @@ func (c *Controller) processItem(informerCacheObj InformerCacheObj) error {
 	ctxLogger.Infof(ControllerLogFormat, taskSendEventToDelegator, c.queue.Len(), "processing event")
 	defer util.LogElapsedTimeController(
 		ctxLogger, fmt.Sprintf(ControllerLogFormat, taskSendEventToDelegator, c.queue.Len(), "processingTime"))()
-	ctx = context.WithValue(ctx, "txId", txId)
+	ctx = common.WithTxId(ctx, txId)
 	ctx = context.WithValue(ctx, "controller", c.name)
-	var err error
-	if informerCacheObj.eventType == Delete {
-		err = c.delegator.Deleted(ctx, informerCacheObj.obj)
-	} else if informerCacheObj.eventType == Update {
-		err = c.delegator.Updated(ctx, informerCacheObj.obj, informerCacheObj.oldObj)
-	} else if informerCacheObj.eventType == Add {
-		err = c.delegator.Added(ctx, informerCacheObj.obj)
-	}
+	// a panic of the delegator is returned as an error, so the event is requeued like any failed event
+	err := common.CallWithRecovery(common.WithTxId(c.recoveryContext(), txId), c.name, func() error {
+		if informerCacheObj.eventType == Delete {
+			return c.delegator.Deleted(ctx, informerCacheObj.obj)
+		} else if informerCacheObj.eventType == Update {
+			return c.delegator.Updated(ctx, informerCacheObj.obj, informerCacheObj.oldObj)
+		} else if informerCacheObj.eventType == Add {
+			return c.delegator.Added(ctx, informerCacheObj.obj)
+		}
+		return nil
+	})
 
 	// processItemStatus is set to:
 	// 1. Processed only if there are no errors and Admiral is not in read only mode
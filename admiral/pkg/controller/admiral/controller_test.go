@@ -462,3 +462,31 @@ func TestController_ProcessNextItem(t *testing.T) {
 	// Act
 	controller.processNextItem()
 }
+
+type panickingDelegator struct {
+	MockDelegator
+}
+
+func (p *panickingDelegator) Added(context.Context, interface{}) error {
+	panic("nil map")
+}
+
+func TestProcessNextItemRecoversFromDelegatorPanic(t *testing.T) {
+	controller := &Controller{
+		name:      "test-controller",
+		cluster:   "test-cluster",
+		delegator: &panickingDelegator{},
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+	item := InformerCacheObj{
+		key:       "ns/foo",
+		eventType: Add,
+		obj:       "ns/foo",
+		ctxLogger: log.WithFields(log.Fields{"controller": controller.name}),
+	}
+	controller.queue.Add(item)
+
+	assert.True(t, controller.processNextItem())
+	// the event is requeued like any event which failed to be processed
+	assert.Equal(t, 1, controller.queue.NumRequeues(item))
+}
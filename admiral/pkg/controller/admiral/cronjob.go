@@ -0,0 +1,266 @@
+package admiral
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	batchV1 "k8s.io/api/batch/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchInformers "k8s.io/client-go/informers/batch/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+//CronJob controller discovers cron jobs as mesh clients, so that the client side resources of the jobs they
+//schedule are in place before the first job runs (like jobs, cron jobs are assumed to not have any ingress communication)
+
+type CronJobController struct {
+	K8sClient      kubernetes.Interface
+	CronJobHandler ClientDiscoveryHandler
+	informer       cache.SharedIndexInformer
+	Cache          *cronJobCache
+}
+
+type CronJobEntry struct {
+	Identity string
+	CronJobs map[string]*common.K8sObject
+}
+
+type cronJobCache struct {
+	//map of cron jobs key=identity value=cron jobs of the identity keyed by namespace
+	cache map[string]*CronJobEntry
+	mutex *sync.Mutex
+}
+
+func NewCronJobCache() *cronJobCache {
+	return &cronJobCache{
+		cache: make(map[string]*CronJobEntry),
+		mutex: &sync.Mutex{},
+	}
+}
+
+func getK8sObjectFromCronJob(cronJob *batchV1.CronJob) *common.K8sObject {
+	template := cronJob.Spec.JobTemplate.Spec.Template
+	return &common.K8sObject{
+		Name:        cronJob.Name,
+		Namespace:   cronJob.Namespace,
+		Annotations: template.Annotations,
+		Labels:      template.Labels,
+		Status:      common.NotProcessed,
+		Type:        common.CronJob,
+	}
+}
+
+func (p *cronJobCache) Put(cronJob *common.K8sObject) *common.K8sObject {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	identity := common.GetGlobalIdentifier(cronJob.Annotations, cronJob.Labels)
+	existingCronJobs := p.cache[identity]
+	if existingCronJobs == nil {
+		p.cache[identity] = &CronJobEntry{
+			Identity: identity,
+			CronJobs: map[string]*common.K8sObject{cronJob.Namespace: cronJob},
+		}
+		return cronJob
+	}
+	if _, ok := existingCronJobs.CronJobs[cronJob.Namespace]; !ok {
+		existingCronJobs.CronJobs[cronJob.Namespace] = cronJob
+	}
+	return cronJob
+}
+
+func (p *cronJobCache) Get(key string, namespace string) *common.K8sObject {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	cje, ok := p.cache[key]
+	if ok {
+		cj, ok := cje.CronJobs[namespace]
+		if ok {
+			return cj
+		}
+	}
+	return nil
+}
+
+// GetByIdentity returns the cron jobs of the identity by namespace
+func (p *cronJobCache) GetByIdentity(key string) map[string]*common.K8sObject {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	cje := p.cache[key]
+	if cje != nil {
+		return cje.CronJobs
+	}
+	return nil
+}
+
+// HasWorkloads returns true when the cache holds a cron job of any identity
+func (p *cronJobCache) HasWorkloads() bool {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for _, cje := range p.cache {
+		if len(cje.CronJobs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *cronJobCache) GetCronJobProcessStatus(cronJob *batchV1.CronJob) (string, error) {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	cronJobObj := getK8sObjectFromCronJob(cronJob)
+	identity := common.GetGlobalIdentifier(cronJobObj.Annotations, cronJobObj.Labels)
+	cje, ok := p.cache[identity]
+	if ok {
+		cronJobFromNamespace, ok := cje.CronJobs[cronJob.Namespace]
+		if ok {
+			return cronJobFromNamespace.Status, nil
+		}
+	}
+	return common.NotProcessed, nil
+}
+
+func (p *cronJobCache) UpdateCronJobProcessStatus(cronJob *batchV1.CronJob, status string) error {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	cronJobObj := getK8sObjectFromCronJob(cronJob)
+	identity := common.GetGlobalIdentifier(cronJobObj.Annotations, cronJobObj.Labels)
+	cje, ok := p.cache[identity]
+	if ok {
+		cronJobFromNamespace, ok := cje.CronJobs[cronJob.Namespace]
+		if ok {
+			cronJobFromNamespace.Status = status
+			return nil
+		}
+		cronJobObj.Status = status
+		cje.CronJobs[cronJob.Namespace] = cronJobObj
+		return nil
+	}
+	return fmt.Errorf(LogCacheFormat, "UpdateStatus", "CronJob",
+		cronJob.Name, cronJob.Namespace, "", "nothing to update, cron job not found in cache")
+}
+
+func (p *CronJobController) DoesGenerationMatch(ctxLogger *log.Entry, obj interface{}, oldObj interface{}) (bool, error) {
+	if !common.DoGenerationCheck() {
+		ctxLogger.Debugf(ControllerLogFormat, "DoesGenerationMatch", "",
+			fmt.Sprintf("generation check is disabled"))
+		return false, nil
+	}
+	cronJobNew, ok := obj.(*batchV1.CronJob)
+	if !ok {
+		return false, fmt.Errorf("type assertion failed, %v is not of type *CronJob", obj)
+	}
+	cronJobOld, ok := oldObj.(*batchV1.CronJob)
+	if !ok {
+		return false, fmt.Errorf("type assertion failed, %v is not of type *CronJob", oldObj)
+	}
+	if cronJobNew.Generation == cronJobOld.Generation {
+		ctxLogger.Infof(ControllerLogFormat, "DoesGenerationMatch", "",
+			fmt.Sprintf("old and new generation matched for cron job %s", cronJobNew.Name))
+		return true, nil
+	}
+	return false, nil
+}
+
+func (p *CronJobController) IsOnlyReplicaCountChanged(*log.Entry, interface{}, interface{}) (bool, error) {
+	return false, nil
+}
+
+func NewCronJobController(stopCh <-chan struct{}, handler ClientDiscoveryHandler, config *rest.Config, resyncPeriod time.Duration, clientLoader loader.ClientLoader) (*CronJobController, error) {
+	cronJobController := CronJobController{}
+	cronJobController.CronJobHandler = handler
+
+	var err error
+	cronJobController.K8sClient, err = clientLoader.LoadKubeClientFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cron job controller k8s client: %v", err)
+	}
+
+	cronJobController.informer = batchInformers.NewCronJobInformer(
+		cronJobController.K8sClient,
+		meta_v1.NamespaceAll,
+		resyncPeriod,
+		cache.Indexers{},
+	)
+
+	cronJobController.Cache = NewCronJobCache()
+
+	NewController("cronjob-ctrl", config.Host, stopCh, &cronJobController, cronJobController.informer)
+
+	return &cronJobController, nil
+}
+
+func (c *CronJobController) Added(ctx context.Context, obj interface{}) error {
+	return addUpdateCronJob(c, ctx, obj)
+}
+
+func (c *CronJobController) Updated(ctx context.Context, obj interface{}, oldObj interface{}) error {
+	//the identity of the jobs scheduled by a cron job can change with its job template
+	return addUpdateCronJob(c, ctx, obj)
+}
+
+func addUpdateCronJob(c *CronJobController, ctx context.Context, obj interface{}) error {
+	cronJob, ok := obj.(*batchV1.CronJob)
+	if !ok {
+		return fmt.Errorf("failed to covert informer object to CronJob")
+	}
+	template := cronJob.Spec.JobTemplate.Spec.Template
+	if common.ShouldIgnore(template.Annotations, template.Labels) {
+		return nil
+	}
+	newK8sObj := c.Cache.Put(getK8sObjectFromCronJob(cronJob))
+	newK8sObj.Status = common.ProcessingInProgress
+	return c.CronJobHandler.Added(ctx, newK8sObj)
+}
+
+func (c *CronJobController) Deleted(ctx context.Context, obj interface{}) error {
+	//Not Required (to be handled via asset off boarding)
+	return nil
+}
+
+func (c *CronJobController) GetProcessItemStatus(obj interface{}) (string, error) {
+	cronJob, ok := obj.(*batchV1.CronJob)
+	if !ok {
+		return common.NotProcessed, fmt.Errorf("type assertion failed, %v is not of type *CronJob", obj)
+	}
+	return c.Cache.GetCronJobProcessStatus(cronJob)
+}
+
+func (c *CronJobController) UpdateProcessItemStatus(obj interface{}, status string) error {
+	cronJob, ok := obj.(*batchV1.CronJob)
+	if !ok {
+		return fmt.Errorf("type assertion failed, %v is not of type *CronJob", obj)
+	}
+	return c.Cache.UpdateCronJobProcessStatus(cronJob, status)
+}
+
+func (c *CronJobController) LogValueOfAdmiralIoIgnore(obj interface{}) {
+	cronJob, ok := obj.(*batchV1.CronJob)
+	if !ok {
+		return
+	}
+	cronJobObj := getK8sObjectFromCronJob(cronJob)
+	if cronJobObj.Annotations[common.AdmiralIgnoreAnnotation] == "true" {
+		log.Infof("op=%s type=%v name=%v namespace=%s cluster=%s message=%s", "admiralIoIgnoreAnnotationCheck", common.CronJob,
+			cronJob.Name, cronJob.Namespace, "", "Value=true")
+	}
+}
+
+func (c *CronJobController) Get(ctx context.Context, isRetry bool, obj interface{}) (interface{}, error) {
+	cronJob, ok := obj.(*batchV1.CronJob)
+	if ok && isRetry {
+		cronJobObj := getK8sObjectFromCronJob(cronJob)
+		identity := common.GetGlobalIdentifier(cronJobObj.Annotations, cronJobObj.Labels)
+		return c.Cache.Get(identity, cronJob.Namespace), nil
+	}
+	if ok && c.K8sClient != nil {
+		return c.K8sClient.BatchV1().CronJobs(cronJob.Namespace).Get(ctx, cronJob.Name, meta_v1.GetOptions{})
+	}
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
+}
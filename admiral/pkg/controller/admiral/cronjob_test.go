@@ -0,0 +1,130 @@
+package admiral
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func getCronJob(namespace string, annotations map[string]string, labels map[string]string) *batchV1.CronJob {
+	return &batchV1.CronJob{
+		ObjectMeta: metaV1.ObjectMeta{Name: "cronjob", Namespace: namespace},
+		Spec: batchV1.CronJobSpec{
+			JobTemplate: batchV1.JobTemplateSpec{
+				Spec: batchV1.JobSpec{
+					Template: coreV1.PodTemplateSpec{
+						ObjectMeta: metaV1.ObjectMeta{Annotations: annotations, Labels: labels},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCronJobController_Added(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey:     "identity",
+			EnvKey:                  "admiral.io/env",
+			AdmiralCRDIdentityLabel: "identity",
+			DeploymentAnnotation:    "sidecar.istio.io/inject",
+			AdmiralIgnoreLabel:      "admiral-ignore",
+		},
+	})
+	ctx := context.Background()
+	cronJob := getCronJob("cronjob-ns", map[string]string{"sidecar.istio.io/inject": "true", "admiral.io/env": "dev"}, map[string]string{"identity": "cronjob"})
+	expectedCronJob := getK8sObjectFromCronJob(cronJob)
+	expectedCronJob.Status = common.ProcessingInProgress
+
+	testCases := []struct {
+		name            string
+		cronJob         *batchV1.CronJob
+		id              string
+		expectedCronJob *common.K8sObject
+	}{
+		{
+			name: "Given a cron job scheduling mesh enabled jobs, " +
+				"When the cron job is added, " +
+				"Then it should be added to the cache",
+			cronJob:         cronJob,
+			id:              "cronjob",
+			expectedCronJob: expectedCronJob,
+		},
+		{
+			name: "Given a cron job scheduling jobs without sidecar, " +
+				"When the cron job is added, " +
+				"Then it should be ignored",
+			cronJob: getCronJob("cronjob-ns", map[string]string{"admiral.io/env": "dev"}, map[string]string{"identity": "cronjobWithoutSidecar"}),
+			id:      "cronjobWithoutSidecar",
+		},
+		{
+			name: "Given a cron job scheduling jobs with the ignore label, " +
+				"When the cron job is added, " +
+				"Then it should be ignored",
+			cronJob: getCronJob("cronjob-ns", map[string]string{"sidecar.istio.io/inject": "true"}, map[string]string{"identity": "cronjobWithIgnoreLabel", "admiral-ignore": "true"}),
+			id:      "cronjobWithIgnoreLabel",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := test.MockClientDiscoveryHandler{}
+			cronJobController := CronJobController{CronJobHandler: &handler, Cache: NewCronJobCache()}
+			err := cronJobController.Added(ctx, c.cronJob)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectedCronJob, cronJobController.Cache.Get(c.id, c.cronJob.Namespace))
+		})
+	}
+}
+
+func TestCronJobUpdateProcessItemStatus(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{
+			WorkloadIdentityKey:     "identity",
+			AdmiralCRDIdentityLabel: "identity",
+		},
+	})
+	cronJobController := CronJobController{Cache: NewCronJobCache()}
+	cronJob := getCronJob("cronjob-ns", nil, map[string]string{"identity": "cronjob"})
+	cronJobInOtherNamespace := getCronJob("cronjob-ns2", nil, map[string]string{"identity": "cronjob"})
+	unknownCronJob := getCronJob("cronjob-ns", nil, map[string]string{"identity": "unknown"})
+	cronJobController.Cache.Put(getK8sObjectFromCronJob(cronJob))
+
+	assert.Nil(t, cronJobController.UpdateProcessItemStatus(cronJob, common.Processed))
+	status, err := cronJobController.GetProcessItemStatus(cronJob)
+	assert.Nil(t, err)
+	assert.Equal(t, common.Processed, status)
+
+	assert.Nil(t, cronJobController.UpdateProcessItemStatus(cronJobInOtherNamespace, common.ProcessingInProgress))
+	status, err = cronJobController.GetProcessItemStatus(cronJobInOtherNamespace)
+	assert.Nil(t, err)
+	assert.Equal(t, common.ProcessingInProgress, status)
+
+	assert.NotNil(t, cronJobController.UpdateProcessItemStatus(unknownCronJob, common.Processed))
+	status, err = cronJobController.GetProcessItemStatus(unknownCronJob)
+	assert.Nil(t, err)
+	assert.Equal(t, common.NotProcessed, status)
+
+	_, err = cronJobController.GetProcessItemStatus(&batchV1.Job{})
+	assert.NotNil(t, err)
+}
+
+func TestNewCronJobController(t *testing.T) {
+	config, err := clientcmd.BuildConfigFromFlags("", "../../test/resources/admins@fake-cluster.k8s.local")
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	stop := make(chan struct{})
+	cronJobController, err := NewCronJobController(stop, &test.MockClientDiscoveryHandler{}, config, 0, loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	assert.NotNil(t, cronJobController)
+}
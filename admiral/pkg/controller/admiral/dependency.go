@@ -85,6 +85,17 @@ func (d *depCache) Get(identity string) *v1.Dependency {
 	return nil
 }
 
+func (d *depCache) List() []*v1.Dependency {
+	defer d.mutex.Unlock()
+	d.mutex.Lock()
+
+	dependencies := make([]*v1.Dependency, 0, len(d.cache))
+	for _, depItem := range d.cache {
+		dependencies = append(dependencies, depItem.Dependency)
+	}
+	return dependencies
+}
+
 func (d *depCache) Delete(dep *v1.Dependency) {
 	defer d.mutex.Unlock()
 	d.mutex.Lock()
@@ -213,5 +224,5 @@ func (d *DependencyController) Get(ctx context.Context, isRetry bool, obj interf
 	if ok && d.DepCrdClient != nil {
 		return d.DepCrdClient.AdmiralV1alpha1().Dependencies(dep.Namespace).Get(ctx, dep.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("depcrd client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("depcrd client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
@@ -41,6 +42,9 @@ type DeploymentItem struct {
 type DeploymentClusterEntry struct {
 	Identity    string
 	Deployments map[string]*DeploymentItem
+	// Variants holds every deployment of the identity per env, keyed by namespace/name,
+	// as an identity can run more than one deployment behind the same env
+	Variants map[string]map[string]*k8sAppsV1.Deployment
 }
 
 type DeploymentController struct {
@@ -211,6 +215,18 @@ func (p *deploymentCache) GetByIdentity(key string) map[string]*DeploymentItem {
 	}
 }
 
+// HasWorkloads returns true when the cache holds a deployment of any identity
+func (p *deploymentCache) HasWorkloads() bool {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for _, dce := range p.cache {
+		if len(dce.Deployments) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *deploymentCache) UpdateDeploymentToClusterCache(key string, deployment *k8sAppsV1.Deployment) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -229,9 +245,50 @@ func (p *deploymentCache) UpdateDeploymentToClusterCache(key string, deployment
 		Deployment: deployment,
 		Status:     common.ProcessingInProgress,
 	}
+	if dce.Variants == nil {
+		dce.Variants = make(map[string]map[string]*k8sAppsV1.Deployment)
+	}
+	if dce.Variants[env] == nil {
+		dce.Variants[env] = make(map[string]*k8sAppsV1.Deployment)
+	}
+	dce.Variants[env][getDeploymentVariantKey(deployment)] = deployment
 	p.cache[dce.Identity] = dce
 }
 
+// GetVariants returns the deployments of the identity in the env, sorted by namespace and name
+func (p *deploymentCache) GetVariants(key string, env string) []*k8sAppsV1.Deployment {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	dce := p.cache[key]
+	if dce == nil {
+		return nil
+	}
+	if len(dce.Variants[env]) == 0 {
+		if dce.Deployments[env] != nil && dce.Deployments[env].Deployment != nil {
+			return []*k8sAppsV1.Deployment{dce.Deployments[env].Deployment}
+		}
+		return nil
+	}
+	return getSortedDeploymentVariants(dce.Variants[env])
+}
+
+func getDeploymentVariantKey(deployment *k8sAppsV1.Deployment) string {
+	return deployment.Namespace + "/" + deployment.Name
+}
+
+func getSortedDeploymentVariants(variants map[string]*k8sAppsV1.Deployment) []*k8sAppsV1.Deployment {
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	deployments := make([]*k8sAppsV1.Deployment, 0, len(keys))
+	for _, key := range keys {
+		deployments = append(deployments, variants[key])
+	}
+	return deployments
+}
+
 func (p *deploymentCache) DeleteFromDeploymentClusterCache(key string, deployment *k8sAppsV1.Deployment) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -241,10 +298,16 @@ func (p *deploymentCache) DeleteFromDeploymentClusterCache(key string, deploymen
 	)
 
 	if dce != nil {
+		delete(dce.Variants[env], getDeploymentVariantKey(deployment))
 		if dce.Deployments[env] != nil && dce.Deployments[env].Deployment != nil && deployment.Name == dce.Deployments[env].Deployment.Name {
 			log.Infof("op=%s type=%v name=%v namespace=%s cluster=%s message=%s", "Delete", "Deployment",
 				deployment.Name, deployment.Namespace, "", "ignoring deployment and deleting from cache")
-			delete(dce.Deployments, env)
+			// another deployment of the identity keeps serving the env
+			if variants := getSortedDeploymentVariants(dce.Variants[env]); len(variants) > 0 {
+				dce.Deployments[env] = &DeploymentItem{Deployment: variants[0], Status: dce.Deployments[env].Status}
+			} else {
+				delete(dce.Deployments, env)
+			}
 		} else {
 			log.Warnf("op=%s type=%v name=%v namespace=%s cluster=%s message=%s", "Get", "Deployment",
 				deployment.Name, deployment.Namespace, "", "ignoring deployment delete as it doesn't match the one in cache")
@@ -275,6 +338,11 @@ func NewDeploymentController(stopCh <-chan struct{}, handler DeploymentHandler,
 		cache.Indexers{},
 	)
 
+	err = setSpiffeIdentityTransform(deploymentController.informer, handler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set deployment controller spiffe identity transform: %v", err)
+	}
+
 	NewController(deploymentControllerPrefix, config.Host, stopCh, &deploymentController, deploymentController.informer)
 
 	return &deploymentController, nil
@@ -347,6 +415,14 @@ func (d *DeploymentController) Deleted(ctx context.Context, obj interface{}) err
 		return nil
 	}
 	key := d.Cache.getKey(deployment)
+	env := common.GetEnv(deployment)
+	if len(key) > 0 && len(d.Cache.GetVariants(key, env)) > 1 {
+		// the other deployments of the identity keep serving the env, so its
+		// resources are updated to leave out the deleted deployment
+		d.Cache.DeleteFromDeploymentClusterCache(key, deployment)
+		d.Cache.DeleteFromDeploymentClusterCache(common.GetDeploymentOriginalIdentifier(deployment), deployment)
+		return d.DeploymentHandler.Added(ctx, d.Cache.Get(key, env))
+	}
 	err := d.DeploymentHandler.Deleted(ctx, deployment)
 	if err == nil && len(key) > 0 {
 		d.Cache.DeleteFromDeploymentClusterCache(key, deployment)
@@ -428,5 +504,5 @@ func (d *DeploymentController) Get(ctx context.Context, isRetry bool, obj interf
 	if ok && d.K8sClient != nil {
 		return d.K8sClient.AppsV1().Deployments(deployment.Namespace).Get(ctx, deployment.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
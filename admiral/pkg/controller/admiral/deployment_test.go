@@ -692,6 +692,75 @@ func TestDeleteFromDeploymentClusterCache(t *testing.T) {
 	}
 }
 
+func TestDeploymentCacheVariants(t *testing.T) {
+	var (
+		identity   = "app1"
+		env        = "prd"
+		deployment = func(name string) *k8sAppsV1.Deployment {
+			return &k8sAppsV1.Deployment{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "namespace-" + env},
+				Spec: k8sAppsV1.DeploymentSpec{
+					Template: coreV1.PodTemplateSpec{
+						ObjectMeta: v1.ObjectMeta{Labels: map[string]string{"identity": identity, "env": env}},
+					},
+				},
+			}
+		}
+		cpu = deployment("app1-cpu")
+		gpu = deployment("app1-gpu")
+	)
+	cache := NewDeploymentCache()
+	cache.UpdateDeploymentToClusterCache(identity, gpu)
+	cache.UpdateDeploymentToClusterCache(identity, cpu)
+	cache.UpdateDeploymentToClusterCache(identity, cpu)
+	assert.Equal(t, []*k8sAppsV1.Deployment{cpu, gpu}, cache.GetVariants(identity, env))
+	assert.Equal(t, cpu, cache.Get(identity, env))
+
+	// the remaining variant keeps serving the env once the latest one is deleted
+	cache.DeleteFromDeploymentClusterCache(identity, cpu)
+	assert.Equal(t, []*k8sAppsV1.Deployment{gpu}, cache.GetVariants(identity, env))
+	assert.Equal(t, gpu, cache.Get(identity, env))
+
+	cache.DeleteFromDeploymentClusterCache(identity, gpu)
+	assert.Empty(t, cache.GetVariants(identity, env))
+	assert.Nil(t, cache.Get(identity, env))
+}
+
+func TestDeploymentDeletedWithVariants(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{WorkloadIdentityKey: "identity", EnvKey: "env"},
+	})
+	deployment := func(name string) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Spec: k8sAppsV1.DeploymentSpec{
+				Template: coreV1.PodTemplateSpec{
+					ObjectMeta: v1.ObjectMeta{
+						Labels:      map[string]string{"identity": "app1", "env": "prd"},
+						Annotations: map[string]string{"sidecar.istio.io/inject": "true"},
+					},
+				},
+			},
+		}
+	}
+	cpu, gpu := deployment("app1-cpu"), deployment("app1-gpu")
+	handler := &test.MockDeploymentHandler{}
+	deploymentController := DeploymentController{
+		DeploymentHandler: handler,
+		Cache:             NewDeploymentCache(),
+		labelSet:          &common.LabelSet{DeploymentAnnotation: "sidecar.istio.io/inject"},
+		K8sClient:         fake.NewSimpleClientset(),
+	}
+	deploymentController.Cache.UpdateDeploymentToClusterCache("app1", cpu)
+	deploymentController.Cache.UpdateDeploymentToClusterCache("app1", gpu)
+
+	assert.Nil(t, deploymentController.Deleted(context.Background(), gpu))
+	// the env is still served by the other deployment, which is handled as an update
+	assert.Equal(t, cpu, handler.Obj)
+	assert.Equal(t, cpu, deploymentController.Cache.Get("app1", "prd"))
+}
+
 func TestHandleAddUpdateDeploymentTypeAssertion(t *testing.T) {
 
 	ctx := context.Background()
@@ -118,6 +118,21 @@ func (p *gtpCache) Get(key, namespace string) []*v1.GlobalTrafficPolicy {
 	return matchedGtps
 }
 
+// List returns a copy of every gtp of the cache
+func (p *gtpCache) List() []*v1.GlobalTrafficPolicy {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	gtps := make([]*v1.GlobalTrafficPolicy, 0)
+	for _, namespacesWithGtp := range p.cache {
+		for _, gtpItems := range namespacesWithGtp {
+			for _, item := range gtpItems {
+				gtps = append(gtps, item.GlobalTrafficPolicy.DeepCopy())
+			}
+		}
+	}
+	return gtps
+}
+
 func (p *gtpCache) GetGTPProcessStatus(gtp *v1.GlobalTrafficPolicy) string {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -206,7 +221,12 @@ func (d *GlobalTrafficController) Updated(ctx context.Context, obj interface{},
 	if !ok {
 		return fmt.Errorf("type assertion failed, %v is not of type *v1.GlobalTrafficPolicy", obj)
 	}
-	d.Cache.Put(gtp)
+	// a GlobalTrafficPolicy held by a finalizer no longer applies once it is being deleted
+	if gtp.DeletionTimestamp != nil {
+		d.Cache.Delete(gtp)
+	} else {
+		d.Cache.Put(gtp)
+	}
 	return d.GlobalTrafficHandler.Updated(ctx, gtp)
 }
 
@@ -260,5 +280,5 @@ func (d *GlobalTrafficController) Get(ctx context.Context, isRetry bool, obj int
 	if ok && d.CrdClient != nil {
 		return d.CrdClient.AdmiralV1alpha1().GlobalTrafficPolicies(gtp.Namespace).Get(ctx, gtp.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
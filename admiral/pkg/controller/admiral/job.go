@@ -93,6 +93,29 @@ func (p *jobCache) Get(key string, namespace string) *common.K8sObject {
 	return nil
 }
 
+// GetByIdentity returns the jobs of the identity by namespace
+func (p *jobCache) GetByIdentity(key string) map[string]*common.K8sObject {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	jce := p.cache[key]
+	if jce != nil {
+		return jce.Jobs
+	}
+	return nil
+}
+
+// HasWorkloads returns true when the cache holds a job of any identity
+func (p *jobCache) HasWorkloads() bool {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for _, jce := range p.cache {
+		if len(jce.Jobs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *jobCache) GetJobProcessStatus(job *v12.Job) (string, error) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -254,5 +277,5 @@ func (j *JobController) Get(ctx context.Context, isRetry bool, obj interface{})
 	if ok && j.K8sClient != nil {
 		return j.K8sClient.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -0,0 +1,154 @@
+package admiral
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+)
+
+// MaintenanceState is the state of the maintenance mode admiral is in
+type MaintenanceState string
+
+const (
+	// MaintenanceOff is the state in which events are processed as they are received
+	MaintenanceOff MaintenanceState = "Off"
+	// MaintenancePaused is the state in which events are buffered and writes are stopped
+	MaintenancePaused MaintenanceState = "Paused"
+	// MaintenanceResuming is the state in which the buffered events are replayed. Writes are
+	// allowed, and events received in the meantime are buffered behind the ones being replayed
+	MaintenanceResuming MaintenanceState = "Resuming"
+
+	taskBufferEventForMaintenance = "bufferEventForMaintenance"
+	taskReplayEventForMaintenance = "replayEventForMaintenance"
+)
+
+// MaintenanceStatus is the maintenance mode state along with the events in the journal
+type MaintenanceStatus struct {
+	State          MaintenanceState `json:"state"`
+	BufferedEvents int              `json:"bufferedEvents"`
+	DroppedEvents  int              `json:"droppedEvents"`
+}
+
+type journalEntry struct {
+	controller *Controller
+	item       InformerCacheObj
+}
+
+// eventJournal buffers the events received by every controller while admiral is in maintenance
+// mode. The journal is bounded, an event replaces the event buffered earlier for the same object
+// and the oldest event is dropped when the journal is full
+type eventJournal struct {
+	lock    sync.Mutex
+	state   MaintenanceState
+	entries []journalEntry
+	dropped int
+}
+
+var maintenanceJournal = &eventJournal{state: MaintenanceOff}
+
+// EnterMaintenanceMode stops the controllers from processing events, which are buffered
+// until ExitMaintenanceMode is called
+func EnterMaintenanceMode() {
+	maintenanceJournal.lock.Lock()
+	defer maintenanceJournal.lock.Unlock()
+	if maintenanceJournal.state == MaintenancePaused {
+		return
+	}
+	maintenanceJournal.state = MaintenancePaused
+	log.Infof(ControllerLogFormat, "enterMaintenanceMode", len(maintenanceJournal.entries), "admiral is in maintenance mode, events will be buffered")
+}
+
+// ExitMaintenanceMode allows writes again and calls onResume, then replays the buffered events in the
+// order they were received, including the events received while the replay is in progress, and
+// returns once the journal is empty
+func ExitMaintenanceMode(onResume func()) {
+	maintenanceJournal.lock.Lock()
+	if maintenanceJournal.state != MaintenancePaused {
+		maintenanceJournal.lock.Unlock()
+		return
+	}
+	maintenanceJournal.state = MaintenanceResuming
+	log.Infof(ControllerLogFormat, "exitMaintenanceMode", len(maintenanceJournal.entries),
+		fmt.Sprintf("replaying buffered events, dropped=%d", maintenanceJournal.dropped))
+	maintenanceJournal.lock.Unlock()
+
+	if onResume != nil {
+		onResume()
+	}
+	for {
+		maintenanceJournal.lock.Lock()
+		if len(maintenanceJournal.entries) == 0 {
+			maintenanceJournal.state = MaintenanceOff
+			maintenanceJournal.dropped = 0
+			maintenanceJournal.lock.Unlock()
+			log.Infof(ControllerLogFormat, "exitMaintenanceMode", 0, "admiral is out of maintenance mode")
+			return
+		}
+		entry := maintenanceJournal.entries[0]
+		maintenanceJournal.entries = maintenanceJournal.entries[1:]
+		maintenanceJournal.lock.Unlock()
+		entry.controller.replay(entry.item)
+	}
+}
+
+// GetMaintenanceState returns the state of the maintenance mode
+func GetMaintenanceState() MaintenanceState {
+	maintenanceJournal.lock.Lock()
+	defer maintenanceJournal.lock.Unlock()
+	return maintenanceJournal.state
+}
+
+// IsWriteStoppedForMaintenance checks if writes are stopped because admiral is in maintenance mode
+func IsWriteStoppedForMaintenance() bool {
+	return GetMaintenanceState() == MaintenancePaused
+}
+
+// GetMaintenanceStatus returns the state of the maintenance mode and the events in the journal
+func GetMaintenanceStatus() MaintenanceStatus {
+	maintenanceJournal.lock.Lock()
+	defer maintenanceJournal.lock.Unlock()
+	return MaintenanceStatus{
+		State:          maintenanceJournal.state,
+		BufferedEvents: len(maintenanceJournal.entries),
+		DroppedEvents:  maintenanceJournal.dropped,
+	}
+}
+
+// bufferForMaintenance buffers the event when admiral is in maintenance mode, and returns
+// whether it was buffered
+func (c *Controller) bufferForMaintenance(item InformerCacheObj) bool {
+	maintenanceJournal.lock.Lock()
+	defer maintenanceJournal.lock.Unlock()
+	if maintenanceJournal.state == MaintenanceOff {
+		return false
+	}
+	entries := maintenanceJournal.entries
+	for i := range entries {
+		if entries[i].controller == c && entries[i].item.key == item.key {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	if size := common.GetMaintenanceJournalSize(); size > 0 && len(entries) >= size {
+		dropped := entries[0]
+		entries = entries[1:]
+		maintenanceJournal.dropped++
+		dropped.item.ctxLogger.Warnf(ControllerLogFormat, taskBufferEventForMaintenance, len(entries),
+			fmt.Sprintf("journal is full, dropped %s event for key=%s controller=%s", dropped.item.eventType, dropped.item.key, dropped.controller.name))
+	}
+	maintenanceJournal.entries = append(entries, journalEntry{controller: c, item: item})
+	item.ctxLogger.Infof(ControllerLogFormat, taskBufferEventForMaintenance, len(maintenanceJournal.entries),
+		fmt.Sprintf("buffered %s event while in maintenance mode", item.eventType))
+	return true
+}
+
+// replay processes a buffered event, and adds it to the queue to be retried when processing fails
+func (c *Controller) replay(item InformerCacheObj) {
+	item.ctxLogger.Infof(ControllerLogFormat, taskReplayEventForMaintenance, c.queue.Len(), "replaying "+string(item.eventType)+" event")
+	if err := c.processItem(item); err != nil {
+		item.ctxLogger.Errorf(ControllerLogFormat, taskReplayEventForMaintenance, c.queue.Len(), "requeueing event. error="+err.Error())
+		c.queue.AddRateLimited(item)
+	}
+}
@@ -0,0 +1,94 @@
+package admiral
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/util/workqueue"
+)
+
+type recordingDelegator struct {
+	MockDelegator
+	events []string
+}
+
+func (r *recordingDelegator) Added(ctx context.Context, obj interface{}) error {
+	r.events = append(r.events, string(Add)+"/"+obj.(string))
+	return nil
+}
+
+func (r *recordingDelegator) Updated(ctx context.Context, obj interface{}, oldObj interface{}) error {
+	r.events = append(r.events, string(Update)+"/"+obj.(string))
+	return nil
+}
+
+func (r *recordingDelegator) Deleted(ctx context.Context, obj interface{}) error {
+	r.events = append(r.events, string(Delete)+"/"+obj.(string))
+	return nil
+}
+
+func newMaintenanceTestController(name string, delegator Delegator) *Controller {
+	return &Controller{
+		name:      name,
+		cluster:   "test-cluster",
+		delegator: delegator,
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+}
+
+func addMaintenanceTestEvent(c *Controller, eventType EventType, key string) {
+	c.queue.Add(InformerCacheObj{
+		key:       key,
+		eventType: eventType,
+		obj:       key,
+		ctxLogger: log.WithFields(log.Fields{"controller": c.name}),
+	})
+	c.processNextItem()
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:               &common.LabelSet{},
+		MaintenanceJournalSize: 3,
+	})
+	fooDelegator, barDelegator := &recordingDelegator{}, &recordingDelegator{}
+	fooController := newMaintenanceTestController("foo-ctrl", fooDelegator)
+	barController := newMaintenanceTestController("bar-ctrl", barDelegator)
+
+	EnterMaintenanceMode()
+	assert.True(t, IsWriteStoppedForMaintenance())
+	addMaintenanceTestEvent(fooController, Add, "ns/foo1")
+	addMaintenanceTestEvent(barController, Add, "ns/bar1")
+	addMaintenanceTestEvent(fooController, Add, "ns/foo2")
+	// replaces the event buffered earlier for ns/foo1
+	addMaintenanceTestEvent(fooController, Update, "ns/foo1")
+	// the journal is full, the oldest event is dropped
+	addMaintenanceTestEvent(barController, Delete, "ns/bar2")
+
+	assert.Empty(t, fooDelegator.events)
+	assert.Empty(t, barDelegator.events)
+	assert.Equal(t, MaintenanceStatus{State: MaintenancePaused, BufferedEvents: 3, DroppedEvents: 1}, GetMaintenanceStatus())
+
+	var statusOnResume MaintenanceStatus
+	ExitMaintenanceMode(func() { statusOnResume = GetMaintenanceStatus() })
+	assert.Equal(t, MaintenanceResuming, statusOnResume.State)
+	assert.False(t, IsWriteStoppedForMaintenance())
+	assert.Equal(t, []string{"Add/ns/foo2", "Update/ns/foo1"}, fooDelegator.events)
+	assert.Equal(t, []string{"Delete/ns/bar2"}, barDelegator.events)
+	assert.Equal(t, MaintenanceStatus{State: MaintenanceOff}, GetMaintenanceStatus())
+
+	// events are processed as they are received once out of maintenance mode
+	addMaintenanceTestEvent(fooController, Delete, "ns/foo2")
+	assert.Equal(t, []string{"Add/ns/foo2", "Update/ns/foo1", "Delete/ns/foo2"}, fooDelegator.events)
+}
+
+func TestExitMaintenanceModeWhenNotInMaintenanceMode(t *testing.T) {
+	resumed := false
+	ExitMaintenanceMode(func() { resumed = true })
+	assert.False(t, resumed)
+	assert.Equal(t, MaintenanceOff, GetMaintenanceState())
+}
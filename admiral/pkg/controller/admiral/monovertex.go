@@ -99,6 +99,29 @@ func (p *monoVertexCache) Get(key string, namespace string) *common.K8sObject {
 	return nil
 }
 
+// GetByIdentity returns the mono vertices of the identity by namespace
+func (p *monoVertexCache) GetByIdentity(key string) map[string]*common.K8sObject {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	mvce := p.cache[key]
+	if mvce != nil {
+		return mvce.MonoVertices
+	}
+	return nil
+}
+
+// HasWorkloads returns true when the cache holds a mono vertex of any identity
+func (p *monoVertexCache) HasWorkloads() bool {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for _, mvce := range p.cache {
+		if len(mvce.MonoVertices) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *monoVertexCache) GetMonoVertexProcessStatus(monoVertex *v1alpha1.MonoVertex) (string, error) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -262,5 +285,5 @@ func (j *MonoVertexController) Get(ctx context.Context, isRetry bool, obj interf
 	if ok && j.NumaflowClient != nil {
 		return j.NumaflowClient.NumaflowV1alpha1().MonoVertices(monoVertex.Namespace).Get(ctx, monoVertex.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
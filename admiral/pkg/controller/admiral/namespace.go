@@ -0,0 +1,56 @@
+package admiral
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	k8sV1 "k8s.io/api/core/v1"
+	k8sV1Informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	k8sV1Listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NamespaceController keeps the namespaces of a cluster in a cache, so that their labels can be read
+// without a request to the API server for every event
+type NamespaceController struct {
+	K8sClient kubernetes.Interface
+	informer  cache.SharedIndexInformer
+	lister    k8sV1Listers.NamespaceLister
+}
+
+func NewNamespaceController(stopCh <-chan struct{}, config *rest.Config, resyncPeriod time.Duration, clientLoader loader.ClientLoader) (*NamespaceController, error) {
+	namespaceController := NamespaceController{}
+
+	var err error
+	namespaceController.K8sClient, err = clientLoader.LoadKubeClientFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create namespace controller k8s client: %v", err)
+	}
+
+	namespaceController.informer = k8sV1Informers.NewNamespaceInformer(
+		namespaceController.K8sClient,
+		resyncPeriod,
+		cache.Indexers{},
+	)
+	namespaceController.lister = k8sV1Listers.NewNamespaceLister(namespaceController.informer.GetIndexer())
+
+	go namespaceController.informer.Run(stopCh)
+
+	return &namespaceController, nil
+}
+
+// HasSynced returns true once the namespaces of the cluster have been listed
+func (n *NamespaceController) HasSynced() bool {
+	return n.informer.HasSynced()
+}
+
+// Get returns the namespace from the cache, or an error when it is not found
+func (n *NamespaceController) Get(name string) (*k8sV1.Namespace, error) {
+	if !n.informer.HasSynced() {
+		return nil, fmt.Errorf("namespace cache is not synced yet")
+	}
+	return n.lister.Get(name)
+}
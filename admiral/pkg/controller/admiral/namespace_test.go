@@ -0,0 +1,33 @@
+package admiral
+
+import (
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
+	"github.com/stretchr/testify/assert"
+	k8sV1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sFake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestNamespaceController(t *testing.T) {
+	config := &rest.Config{Host: "namespace-controller.cluster"}
+	loader.FakeKubeClientMap[config.Host] = k8sFake.NewSimpleClientset(
+		&k8sV1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "payments"}}},
+	)
+	defer delete(loader.FakeKubeClientMap, config.Host)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	namespaceController, err := NewNamespaceController(stop, config, 0, loader.GetFakeClientLoader())
+	assert.Nil(t, err)
+	assert.Eventually(t, namespaceController.HasSynced, time.Second, 10*time.Millisecond)
+
+	namespace, err := namespaceController.Get("payments")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "payments"}, namespace.Labels)
+	_, err = namespaceController.Get("missing")
+	assert.NotNil(t, err)
+}
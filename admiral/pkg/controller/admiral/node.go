@@ -117,5 +117,5 @@ func (d *NodeController) Get(ctx context.Context, isRetry bool, obj interface{})
 	if ok && d.K8sClient != nil {
 		return d.K8sClient.CoreV1().Nodes().Get(ctx, node.Name, meta_v1.GetOptions{})
 	}*/
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -311,5 +311,5 @@ func (o *OutlierDetectionController) Get(ctx context.Context, isRetry bool, obj
 	if ok && o.crdclient != nil {
 		return o.crdclient.AdmiralV1alpha1().OutlierDetections(od.Namespace).Get(ctx, od.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("crd client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("crd client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -0,0 +1,370 @@
+package admiral
+
+import (
+	"sync"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/monitoring"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	priorityLane = "priority"
+	defaultLane  = "default"
+)
+
+var (
+	queueDepthMetric     common.GaugeVec
+	queueDepthMetricOnce sync.Once
+)
+
+func getQueueDepthMetric() common.GaugeVec {
+	queueDepthMetricOnce.Do(func() {
+		queueDepthMetric = common.NewGaugeVecFrom(common.EventQueueDepthMetricName,
+			"Gauge for the events waiting in the queue of a controller", "controller", "cluster", "lane")
+	})
+	return queueDepthMetric
+}
+
+var tenantEventThrottles = monitoring.NewCounter(
+	"tenant_event_throttles",
+	"total number of times the events of a tenant were held back in the queue of a controller as the tenant exceeded its event rate")
+
+// priorityQueue is a rate limiting work queue with two lanes, the Delete events are put in the
+// priority lane which is always drained before the default lane holding the Add and Update events,
+// so a flood of updates does not delay the processing of a delete. The tenants of the events of the
+// default lane are served in turns, each within its event rate, so one tenant churning its workloads
+// does not starve the others.
+// Like the client-go work queue an item is never processed concurrently, and an item added
+// while it is being processed is queued again once it is done
+type priorityQueue struct {
+	cond         *sync.Cond
+	priority     []interface{}
+	normal       []interface{}
+	dirty        map[interface{}]struct{}
+	processing   map[interface{}]struct{}
+	shuttingDown bool
+	drain        bool
+	rateLimiter  workqueue.RateLimiter
+	depth        map[string]common.Gauge
+	// tenants are the tenants of the events waiting in the default lane, in the order they are served
+	tenants   []string
+	limiters  map[string]*rate.Limiter
+	throttled map[string]bool
+	wakeup    *time.Timer
+	name      string
+	cluster   string
+}
+
+func newPriorityQueue(name, cluster string, rateLimiter workqueue.RateLimiter) *priorityQueue {
+	metric := getQueueDepthMetric()
+	return &priorityQueue{
+		cond:        sync.NewCond(&sync.Mutex{}),
+		dirty:       make(map[interface{}]struct{}),
+		processing:  make(map[interface{}]struct{}),
+		rateLimiter: rateLimiter,
+		limiters:    make(map[string]*rate.Limiter),
+		throttled:   make(map[string]bool),
+		name:        name,
+		cluster:     cluster,
+		depth: map[string]common.Gauge{
+			priorityLane: metric.With(name, cluster, priorityLane),
+			defaultLane:  metric.With(name, cluster, defaultLane),
+		},
+	}
+}
+
+func isPriorityItem(item interface{}) bool {
+	informerCacheObj, ok := item.(InformerCacheObj)
+	return ok && informerCacheObj.eventType == Delete
+}
+
+// tenantOf returns the team of the identity prefix of the workload the object is for, the events of the
+// objects whose identity has no team are not part of any tenant
+func tenantOf(obj interface{}) string {
+	var identity string
+	switch o := obj.(type) {
+	case *k8sAppsV1.Deployment:
+		if o != nil {
+			identity = common.GetDeploymentGlobalIdentifier(o)
+		}
+	case *argo.Rollout:
+		if o != nil {
+			identity = common.GetRolloutGlobalIdentifier(o)
+		}
+	case metav1.Object:
+		identity = common.GetGlobalIdentifier(o.GetAnnotations(), o.GetLabels())
+	}
+	if identity == "" {
+		return ""
+	}
+	return common.GetIdentityTeamByPrefix(identity)
+}
+
+func itemTenant(item interface{}) string {
+	informerCacheObj, _ := item.(InformerCacheObj)
+	return informerCacheObj.tenant
+}
+
+// push must be called with the lock held
+func (q *priorityQueue) push(item interface{}) {
+	if isPriorityItem(item) {
+		q.priority = append(q.priority, item)
+		q.supersede(item.(InformerCacheObj).key)
+	} else {
+		q.normal = append(q.normal, item)
+		if !q.hasTenant(itemTenant(item)) {
+			q.tenants = append(q.tenants, itemTenant(item))
+		}
+	}
+	q.updateDepth()
+	q.cond.Signal()
+}
+
+// supersede drops the Add and Update events of the key waiting in the default lane, they would
+// otherwise be processed after the Delete event of the key which preempted them
+func (q *priorityQueue) supersede(key string) {
+	normal := q.normal[:0]
+	for _, item := range q.normal {
+		if informerCacheObj, ok := item.(InformerCacheObj); ok && informerCacheObj.key == key {
+			delete(q.dirty, item)
+			continue
+		}
+		normal = append(normal, item)
+	}
+	for i := len(normal); i < len(q.normal); i++ {
+		q.normal[i] = nil
+	}
+	q.normal = normal
+	tenants := q.tenants[:0]
+	for _, tenant := range q.tenants {
+		if q.isWaiting(tenant) {
+			tenants = append(tenants, tenant)
+		}
+	}
+	q.tenants = tenants
+}
+
+func (q *priorityQueue) hasTenant(tenant string) bool {
+	for _, t := range q.tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// isWaiting checks if an event of the tenant waits in the default lane
+func (q *priorityQueue) isWaiting(tenant string) bool {
+	for _, item := range q.normal {
+		if itemTenant(item) == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// pop must be called with the lock held. It takes the first event of the priority lane, else the first
+// event of the first tenant in turn which is within its event rate, the tenant then waits for its next
+// turn behind the others. When every tenant is throttled nothing is taken until the earliest of them can
+// be served again, unless the queue is shutting down
+func (q *priorityQueue) pop() interface{} {
+	if len(q.priority) > 0 {
+		item := q.priority[0]
+		q.priority[0] = nil
+		q.priority = q.priority[1:]
+		return item
+	}
+	now := time.Now()
+	var wait time.Duration
+	for i, tenant := range q.tenants {
+		if !q.shuttingDown {
+			if delay := q.reserve(tenant, now); delay > 0 {
+				if wait == 0 || delay < wait {
+					wait = delay
+				}
+				continue
+			}
+		}
+		item := q.take(tenant)
+		q.tenants = append(q.tenants[:i], q.tenants[i+1:]...)
+		if q.isWaiting(tenant) {
+			q.tenants = append(q.tenants, tenant)
+		}
+		return item
+	}
+	if wait > 0 {
+		q.wakeAfter(wait)
+	}
+	return nil
+}
+
+// take removes the first event of the tenant from the default lane
+func (q *priorityQueue) take(tenant string) interface{} {
+	for i, item := range q.normal {
+		if itemTenant(item) != tenant {
+			continue
+		}
+		copy(q.normal[i:], q.normal[i+1:])
+		q.normal[len(q.normal)-1] = nil
+		q.normal = q.normal[:len(q.normal)-1]
+		return item
+	}
+	return nil
+}
+
+// reserve takes a token of the event rate of the tenant, it returns how long the tenant has to wait for
+// one when it has none left
+func (q *priorityQueue) reserve(tenant string, now time.Time) time.Duration {
+	limit := common.GetTenantEventRateLimit()
+	if tenant == "" || limit <= 0 {
+		return 0
+	}
+	limiter, ok := q.limiters[tenant]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), common.GetTenantEventBurst())
+		q.limiters[tenant] = limiter
+	}
+	reservation := limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay <= 0 {
+		delete(q.throttled, tenant)
+		return 0
+	}
+	reservation.CancelAt(now)
+	if !q.throttled[tenant] {
+		q.throttled[tenant] = true
+		tenantEventThrottles.Increment(api.WithAttributes(
+			attribute.Key("controller").String(q.name),
+			attribute.Key("cluster").String(q.cluster),
+			attribute.Key("tenant").String(tenant),
+		))
+	}
+	return delay
+}
+
+// wakeAfter wakes the callers of Get up once the duration elapsed, for them to serve the throttled tenants
+func (q *priorityQueue) wakeAfter(duration time.Duration) {
+	if q.wakeup != nil {
+		q.wakeup.Stop()
+	}
+	q.wakeup = time.AfterFunc(duration, func() {
+		q.cond.L.Lock()
+		defer q.cond.L.Unlock()
+		q.cond.Broadcast()
+	})
+}
+
+func (q *priorityQueue) updateDepth() {
+	q.depth[priorityLane].Set(float64(len(q.priority)))
+	q.depth[defaultLane].Set(float64(len(q.normal)))
+}
+
+func (q *priorityQueue) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+	q.dirty[item] = struct{}{}
+	if _, ok := q.processing[item]; ok {
+		return
+	}
+	q.push(item)
+}
+
+func (q *priorityQueue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return len(q.priority) + len(q.normal)
+}
+
+// Get blocks until an item can be processed, taking it from the priority lane first
+func (q *priorityQueue) Get() (interface{}, bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for {
+		if item := q.pop(); item != nil {
+			q.updateDepth()
+			q.processing[item] = struct{}{}
+			delete(q.dirty, item)
+			return item, false
+		}
+		if q.shuttingDown {
+			return nil, true
+		}
+		q.cond.Wait()
+	}
+}
+
+func (q *priorityQueue) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.push(item)
+	} else if len(q.processing) == 0 {
+		q.cond.Broadcast()
+	}
+}
+
+func (q *priorityQueue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.drain = false
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain stops accepting items and blocks until the items being processed are done
+func (q *priorityQueue) ShutDownWithDrain() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.drain = true
+	q.shuttingDown = true
+	q.cond.Broadcast()
+	for len(q.processing) != 0 && q.drain {
+		q.cond.Wait()
+	}
+}
+
+func (q *priorityQueue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.shuttingDown
+}
+
+func (q *priorityQueue) AddAfter(item interface{}, duration time.Duration) {
+	if q.ShuttingDown() {
+		return
+	}
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(duration, func() {
+		q.Add(item)
+	})
+}
+
+func (q *priorityQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+func (q *priorityQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+func (q *priorityQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
@@ -0,0 +1,209 @@
+package admiral
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestPriorityQueueGet(t *testing.T) {
+	testCases := []struct {
+		name          string
+		items         []InformerCacheObj
+		expectedItems []InformerCacheObj
+	}{
+		{
+			name: "Given Add and Update events only, " +
+				"When Get is called, " +
+				"Then the events should be returned in the order they were added",
+			items: []InformerCacheObj{
+				{key: "ns/a", eventType: Add},
+				{key: "ns/b", eventType: Update},
+			},
+			expectedItems: []InformerCacheObj{
+				{key: "ns/a", eventType: Add},
+				{key: "ns/b", eventType: Update},
+			},
+		},
+		{
+			name: "Given a Delete event added after Add and Update events, " +
+				"When Get is called, " +
+				"Then the Delete event should be returned first",
+			items: []InformerCacheObj{
+				{key: "ns/a", eventType: Add},
+				{key: "ns/b", eventType: Update},
+				{key: "ns/c", eventType: Delete},
+				{key: "ns/d", eventType: Delete},
+			},
+			expectedItems: []InformerCacheObj{
+				{key: "ns/c", eventType: Delete},
+				{key: "ns/d", eventType: Delete},
+				{key: "ns/a", eventType: Add},
+				{key: "ns/b", eventType: Update},
+			},
+		},
+		{
+			name: "Given a Delete event of a key with waiting Add and Update events, " +
+				"When Get is called, " +
+				"Then the Add and Update events of the key should be dropped",
+			items: []InformerCacheObj{
+				{key: "ns/a", eventType: Add},
+				{key: "ns/b", eventType: Add},
+				{key: "ns/a", eventType: Update},
+				{key: "ns/a", eventType: Delete},
+			},
+			expectedItems: []InformerCacheObj{
+				{key: "ns/a", eventType: Delete},
+				{key: "ns/b", eventType: Add},
+			},
+		},
+		{
+			name: "Given the same event added twice, " +
+				"When Get is called, " +
+				"Then the event should be returned once",
+			items: []InformerCacheObj{
+				{key: "ns/a", eventType: Add},
+				{key: "ns/a", eventType: Add},
+			},
+			expectedItems: []InformerCacheObj{
+				{key: "ns/a", eventType: Add},
+			},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			q := newPriorityQueue("test-controller", "cluster1", workqueue.DefaultControllerRateLimiter())
+			for _, item := range c.items {
+				q.Add(item)
+			}
+			assert.Equal(t, len(c.expectedItems), q.Len())
+			items := make([]InformerCacheObj, 0)
+			for q.Len() > 0 {
+				item, quit := q.Get()
+				assert.False(t, quit)
+				items = append(items, item.(InformerCacheObj))
+				q.Done(item)
+			}
+			assert.Equal(t, c.expectedItems, items)
+		})
+	}
+}
+
+func TestPriorityQueueItemAddedWhileProcessing(t *testing.T) {
+	q := newPriorityQueue("test-controller", "cluster1", workqueue.DefaultControllerRateLimiter())
+	item := InformerCacheObj{key: "ns/a", eventType: Update}
+	q.Add(item)
+	got, _ := q.Get()
+	q.Add(item)
+	assert.Equal(t, 0, q.Len())
+	q.Done(got)
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestPriorityQueueShutDown(t *testing.T) {
+	q := newPriorityQueue("test-controller", "cluster1", workqueue.DefaultControllerRateLimiter())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		item, quit := q.Get()
+		assert.Nil(t, item)
+		assert.True(t, quit)
+	}()
+	q.ShutDown()
+	wg.Wait()
+	assert.True(t, q.ShuttingDown())
+	q.Add(InformerCacheObj{key: "ns/a", eventType: Add})
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestPriorityQueueAddRateLimited(t *testing.T) {
+	q := newPriorityQueue("test-controller", "cluster1", workqueue.DefaultControllerRateLimiter())
+	item := InformerCacheObj{key: "ns/a", eventType: Delete}
+	q.AddRateLimited(item)
+	assert.Equal(t, 1, q.NumRequeues(item))
+	assert.Eventually(t, func() bool { return q.Len() == 1 }, time.Second, 5*time.Millisecond)
+	q.Forget(item)
+	assert.Equal(t, 0, q.NumRequeues(item))
+}
+
+func TestPriorityQueueTenantTurns(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	defer common.ResetSync()
+	q := newPriorityQueue("test-controller", "cluster1", workqueue.DefaultControllerRateLimiter())
+	items := []InformerCacheObj{
+		{key: "ns/a1", eventType: Update, tenant: "payments"},
+		{key: "ns/a2", eventType: Update, tenant: "payments"},
+		{key: "ns/a3", eventType: Update, tenant: "payments"},
+		{key: "ns/b1", eventType: Update, tenant: "orders"},
+		{key: "ns/c1", eventType: Add},
+		{key: "ns/b2", eventType: Update, tenant: "orders"},
+	}
+	for _, item := range items {
+		q.Add(item)
+	}
+	keys := make([]string, 0)
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		keys = append(keys, item.(InformerCacheObj).key)
+		q.Done(item)
+	}
+	assert.Equal(t, []string{"ns/a1", "ns/b1", "ns/c1", "ns/a2", "ns/b2", "ns/a3"}, keys)
+}
+
+func TestPriorityQueueTenantRateLimit(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, TenantEventRateLimit: 5, TenantEventBurst: 1})
+	defer common.ResetSync()
+	q := newPriorityQueue("test-controller", "cluster1", workqueue.DefaultControllerRateLimiter())
+	q.Add(InformerCacheObj{key: "ns/a1", eventType: Update, tenant: "payments"})
+	q.Add(InformerCacheObj{key: "ns/a2", eventType: Update, tenant: "payments"})
+	q.Add(InformerCacheObj{key: "ns/b1", eventType: Update, tenant: "orders"})
+	q.Add(InformerCacheObj{key: "ns/c1", eventType: Update})
+	q.Add(InformerCacheObj{key: "ns/c2", eventType: Update})
+
+	keys := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		item, _ := q.Get()
+		keys = append(keys, item.(InformerCacheObj).key)
+		q.Done(item)
+	}
+	// the second event of payments waits for its token while the events without a tenant are not limited
+	assert.Equal(t, []string{"ns/a1", "ns/b1", "ns/c1", "ns/c2"}, keys)
+	assert.True(t, q.throttled["payments"])
+
+	start := time.Now()
+	item, _ := q.Get()
+	assert.Equal(t, "ns/a2", item.(InformerCacheObj).key)
+	assert.Greater(t, time.Since(start), 100*time.Millisecond)
+	q.Done(item)
+	assert.False(t, q.throttled["payments"])
+}
+
+func TestTenantOf(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:             &common.LabelSet{WorkloadIdentityKey: "identity"},
+		TeamIdentityPrefixes: map[string]string{"payments.": "payments"},
+	})
+	defer common.ResetSync()
+	deployment := &k8sAppsV1.Deployment{Spec: k8sAppsV1.DeploymentSpec{Template: v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"identity": "payments.checkout"}}}}}
+	assert.Equal(t, "payments", tenantOf(deployment))
+	rollout := &argo.Rollout{Spec: argo.RolloutSpec{Template: v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"identity": "orders.cart"}}}}}
+	assert.Equal(t, "", tenantOf(rollout))
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"identity": "Payments.ledger"}}}
+	assert.Equal(t, "payments", tenantOf(service))
+	assert.Equal(t, "", tenantOf(cache.DeletedFinalStateUnknown{Key: "ns/a"}))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -45,6 +47,9 @@ type RolloutItem struct {
 type RolloutClusterEntry struct {
 	Identity string
 	Rollouts map[string]*RolloutItem
+	// Variants holds every rollout of the identity per env, keyed by namespace/name,
+	// as an identity can run more than one rollout behind the same env
+	Variants map[string]map[string]*argo.Rollout
 }
 
 type IIdentityArgoVSCache interface {
@@ -89,7 +94,7 @@ func (rc *RolloutController) DoesGenerationMatch(ctxLogger *log.Entry, obj inter
 	if !ok {
 		return false, fmt.Errorf("type assertion failed, %v is not of type *argo.Rollout", oldObj)
 	}
-	if rolloutNew.Generation == rolloutOld.Generation {
+	if rolloutNew.Generation == rolloutOld.Generation && !haveCanaryTrafficWeightsChanged(rolloutNew, rolloutOld) {
 		ctxLogger.Infof(ControllerLogFormat, "DoesGenerationMatch", "",
 			fmt.Sprintf("old and new generation matched for rollout %s", rolloutNew.Name))
 		return true, nil
@@ -119,7 +124,7 @@ func (rc *RolloutController) IsOnlyReplicaCountChanged(ctxLogger *log.Entry, obj
 	rolloutNew.Spec.Replicas = nil
 	rolloutOld.Spec.Replicas = nil
 
-	if reflect.DeepEqual(rolloutOld.Spec, rolloutNew.Spec) {
+	if reflect.DeepEqual(rolloutOld.Spec, rolloutNew.Spec) && !haveCanaryTrafficWeightsChanged(rolloutNew, rolloutOld) {
 		ctxLogger.Infof(ControllerLogFormat, "IsOnlyReplicaCountChanged", "",
 			fmt.Sprintf("old and new spec matched for rollout excluding replica count %s", rolloutNew.Name))
 		rolloutNew.Spec.Replicas = newReplicaCount
@@ -132,6 +137,12 @@ func (rc *RolloutController) IsOnlyReplicaCountChanged(ctxLogger *log.Entry, obj
 	return false, nil
 }
 
+// haveCanaryTrafficWeightsChanged checks if the traffic router of a rollout using ALB or SMI traffic routing changed
+// the weights recorded in the status of the rollout, which changes neither the generation nor the spec of the rollout
+func haveCanaryTrafficWeightsChanged(rolloutNew *argo.Rollout, rolloutOld *argo.Rollout) bool {
+	return common.IsCanaryALBOrSMIStrategy(rolloutNew) && !reflect.DeepEqual(rolloutNew.Status.Canary.Weights, rolloutOld.Status.Canary.Weights)
+}
+
 type rolloutCache struct {
 	//map of dependencies key=identity value array of onboarded identities
 	cache map[string]*RolloutClusterEntry
@@ -202,6 +213,18 @@ func (p *rolloutCache) Delete(pod *RolloutClusterEntry) {
 	delete(p.cache, pod.Identity)
 }
 
+// HasWorkloads returns true when the cache holds a rollout of any identity
+func (p *rolloutCache) HasWorkloads() bool {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for _, rce := range p.cache {
+		if len(rce.Rollouts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *rolloutCache) UpdateRolloutToClusterCache(key string, rollout *argo.Rollout) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -220,10 +243,51 @@ func (p *rolloutCache) UpdateRolloutToClusterCache(key string, rollout *argo.Rol
 		Rollout: rollout,
 		Status:  common.ProcessingInProgress,
 	}
+	if rce.Variants == nil {
+		rce.Variants = make(map[string]map[string]*argo.Rollout)
+	}
+	if rce.Variants[env] == nil {
+		rce.Variants[env] = make(map[string]*argo.Rollout)
+	}
+	rce.Variants[env][getRolloutVariantKey(rollout)] = rollout
 
 	p.cache[rce.Identity] = rce
 }
 
+// GetVariants returns the rollouts of the identity in the env, sorted by namespace and name
+func (p *rolloutCache) GetVariants(key string, env string) []*argo.Rollout {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	rce := p.cache[key]
+	if rce == nil {
+		return nil
+	}
+	if len(rce.Variants[env]) == 0 {
+		if rce.Rollouts[env] != nil && rce.Rollouts[env].Rollout != nil {
+			return []*argo.Rollout{rce.Rollouts[env].Rollout}
+		}
+		return nil
+	}
+	return getSortedRolloutVariants(rce.Variants[env])
+}
+
+func getRolloutVariantKey(rollout *argo.Rollout) string {
+	return rollout.Namespace + "/" + rollout.Name
+}
+
+func getSortedRolloutVariants(variants map[string]*argo.Rollout) []*argo.Rollout {
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	rollouts := make([]*argo.Rollout, 0, len(keys))
+	for _, key := range keys {
+		rollouts = append(rollouts, variants[key])
+	}
+	return rollouts
+}
+
 func (p *rolloutCache) DeleteFromRolloutToClusterCache(key string, rollout *argo.Rollout) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -233,7 +297,17 @@ func (p *rolloutCache) DeleteFromRolloutToClusterCache(key string, rollout *argo
 	rce := p.cache[key]
 
 	if rce != nil {
-		delete(rce.Rollouts, env)
+		delete(rce.Variants[env], getRolloutVariantKey(rollout))
+		// another rollout of the identity keeps serving the env
+		if variants := getSortedRolloutVariants(rce.Variants[env]); len(variants) > 0 {
+			status := common.ProcessingInProgress
+			if rce.Rollouts[env] != nil {
+				status = rce.Rollouts[env].Status
+			}
+			rce.Rollouts[env] = &RolloutItem{Rollout: variants[0], Status: status}
+		} else {
+			delete(rce.Rollouts, env)
+		}
 	}
 }
 
@@ -338,6 +412,11 @@ func NewRolloutsController(stopCh <-chan struct{}, handler RolloutHandler, confi
 	//Initialize informer
 	controller.informer = argoRolloutsInformerFactory.Argoproj().V1alpha1().Rollouts().Informer()
 
+	err = setSpiffeIdentityTransform(controller.informer, handler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set rollouts controller spiffe identity transform: %v", err)
+	}
+
 	NewController(rolloutControllerPrefix, config.Host, stopCh, &controller, controller.informer)
 	return &controller, nil
 }
@@ -406,6 +485,14 @@ func (roc *RolloutController) Deleted(ctx context.Context, obj interface{}) erro
 		return nil
 	}
 	key := roc.Cache.getKey(rollout)
+	env := common.GetEnvForRollout(rollout)
+	if len(key) > 0 && len(roc.Cache.GetVariants(key, env)) > 1 {
+		// the other rollouts of the identity keep serving the env, so its
+		// resources are updated to leave out the deleted rollout
+		roc.Cache.DeleteFromRolloutToClusterCache(key, rollout)
+		roc.Cache.DeleteFromRolloutToClusterCache(common.GetRolloutOriginalIdentifier(rollout), rollout)
+		return roc.RolloutHandler.Added(ctx, roc.Cache.Get(key, env))
+	}
 	err = roc.RolloutHandler.Deleted(ctx, rollout)
 	if err == nil && len(key) > 0 {
 		roc.Cache.DeleteFromRolloutToClusterCache(key, rollout)
@@ -478,7 +565,7 @@ func (d *RolloutController) Get(ctx context.Context, isRetry bool, obj interface
 	if ok && d.RolloutClient != nil {
 		return d.RolloutClient.Rollouts(rollout.Namespace).Get(ctx, rollout.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("rollout client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("rollout client is not initialized, txId=%s", common.GetTxId(ctx))
 }
 
 // populateIdentityArgoVSCache populates the IdentityArgoVSCache with the Argo Virtual Service names
@@ -504,16 +591,13 @@ func (r *RolloutController) populateIdentityArgoVSCache(
 	return r.IdentityArgoVSCache.Put(rollout, oldRollout)
 }
 
-func getArgoVSFromRollout(rollout *argo.Rollout) string {
-	rolloutStrategy := rollout.Spec.Strategy
-	if rolloutStrategy.Canary != nil &&
-		rolloutStrategy.Canary.TrafficRouting != nil &&
-		rolloutStrategy.Canary.TrafficRouting.Istio != nil &&
-		rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService != nil &&
-		rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Name != "" {
-		return rolloutStrategy.Canary.TrafficRouting.Istio.VirtualService.Name
+// getArgoVSNamesFromRollout returns the names of the VirtualServices the canary strategy of the rollout shapes traffic with
+func getArgoVSNamesFromRollout(rollout *argo.Rollout) []string {
+	argoVSNames := make([]string, 0)
+	for _, virtualService := range common.GetCanaryIstioVirtualServices(rollout.Spec.Strategy) {
+		argoVSNames = append(argoVSNames, virtualService.Name)
 	}
-	return ""
+	return argoVSNames
 }
 
 func (i *IdentityArgoVSCache) Get(identity string) map[string]bool {
@@ -527,29 +611,31 @@ func (i *IdentityArgoVSCache) Get(identity string) map[string]bool {
 func (i *IdentityArgoVSCache) Put(newRolloutObj *argo.Rollout, oldRolloutObj *argo.Rollout) error {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
-	oldArgoVSName := ""
+	var oldArgoVSNames []string
 	if oldRolloutObj != nil {
-		oldArgoVSName = getArgoVSFromRollout(oldRolloutObj)
+		oldArgoVSNames = getArgoVSNamesFromRollout(oldRolloutObj)
 	}
 
 	identity := common.GetRolloutGlobalIdentifier(newRolloutObj)
-	argoVSName := getArgoVSFromRollout(newRolloutObj)
+	argoVSNames := getArgoVSNamesFromRollout(newRolloutObj)
 
-	// If the Argo VS was removed/renamed from rollout object
-	if oldArgoVSName != "" && oldArgoVSName != argoVSName {
-		if _, exists := i.cache[identity]; exists {
+	// If an Argo VS was removed/renamed from rollout object
+	for _, oldArgoVSName := range oldArgoVSNames {
+		if _, exists := i.cache[identity]; exists && !slices.Contains(argoVSNames, oldArgoVSName) {
 			delete(i.cache[identity], oldArgoVSName)
 		}
 	}
 
-	if argoVSName == "" {
+	if len(argoVSNames) == 0 {
 		return nil
 	}
 
 	if _, exists := i.cache[identity]; !exists {
 		i.cache[identity] = make(map[string]bool)
 	}
-	i.cache[identity][argoVSName] = true
+	for _, argoVSName := range argoVSNames {
+		i.cache[identity][argoVSName] = true
+	}
 	return nil
 }
 
@@ -559,10 +645,10 @@ func (i *IdentityArgoVSCache) Delete(rolloutObj *argo.Rollout) error {
 	i.mutex.Lock()
 	defer i.mutex.Unlock()
 	identity := common.GetRolloutGlobalIdentifier(rolloutObj)
-	argoVSName := getArgoVSFromRollout(rolloutObj)
-
 	if _, exists := i.cache[identity]; exists {
-		delete(i.cache[identity], argoVSName)
+		for _, argoVSName := range getArgoVSNamesFromRollout(rolloutObj) {
+			delete(i.cache[identity], argoVSName)
+		}
 	}
 	return nil
 }
@@ -123,6 +123,30 @@ func TestRolloutController_DoesGenerationMatch(t *testing.T) {
 			enableGenerationCheck: true,
 			expectedError:         nil,
 		},
+		{
+			name: "Given context, new rollout and old rollout object " +
+				"When rollout generation check is enabled and the generation is equal but the ALB traffic router changed the weights " +
+				"Then func should return false",
+			rolloutNew: &argo.Rollout{
+				ObjectMeta: v1.ObjectMeta{
+					Generation: 2,
+				},
+				Spec: argo.RolloutSpec{Strategy: argo.RolloutStrategy{Canary: &argo.CanaryStrategy{
+					TrafficRouting: &argo.RolloutTrafficRouting{ALB: &argo.ALBTrafficRouting{Ingress: "ingress"}},
+				}}},
+				Status: argo.RolloutStatus{Canary: argo.CanaryStatus{Weights: &argo.TrafficWeights{
+					Canary: argo.WeightDestination{Weight: 20},
+					Stable: argo.WeightDestination{Weight: 80},
+				}}},
+			},
+			rolloutOld: &argo.Rollout{
+				ObjectMeta: v1.ObjectMeta{
+					Generation: 2,
+				},
+			},
+			enableGenerationCheck: true,
+			expectedError:         nil,
+		},
 	}
 
 	ctxLogger := log.WithFields(log.Fields{
@@ -1167,6 +1191,38 @@ func TestRolloutCache_List(t *testing.T) {
 	assert.Equal(t, 1, len(actual))
 }
 
+func TestRolloutCache_Variants(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{WorkloadIdentityKey: "identity", EnvKey: "env"},
+	})
+	rollout := func(name string) *argo.Rollout {
+		return &argo.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+			Spec: argo.RolloutSpec{
+				Template: coreV1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"identity": "app1", "env": "prd"}},
+				},
+			},
+		}
+	}
+	east, west := rollout("app1-east"), rollout("app1-west")
+	rc := NewRolloutCache()
+	rc.UpdateRolloutToClusterCache("app1", west)
+	rc.UpdateRolloutToClusterCache("app1", east)
+	assert.Equal(t, []*argo.Rollout{east, west}, rc.GetVariants("app1", "prd"))
+	assert.Equal(t, east, rc.Get("app1", "prd"))
+
+	// the remaining variant keeps serving the env once the latest one is deleted
+	rc.DeleteFromRolloutToClusterCache("app1", east)
+	assert.Equal(t, []*argo.Rollout{west}, rc.GetVariants("app1", "prd"))
+	assert.Equal(t, west, rc.Get("app1", "prd"))
+
+	rc.DeleteFromRolloutToClusterCache("app1", west)
+	assert.Empty(t, rc.GetVariants("app1", "prd"))
+	assert.Nil(t, rc.Get("app1", "prd"))
+}
+
 func TestRolloutCache_Delete(t *testing.T) {
 	rce := &RolloutClusterEntry{
 		Identity: "test",
@@ -1191,3 +1247,35 @@ func TestRolloutCache_Delete(t *testing.T) {
 
 	assert.Equal(t, 0, len(rc.cache))
 }
+
+func TestIdentityArgoVSCachePut(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet: &common.LabelSet{WorkloadIdentityKey: "identity"},
+	})
+	rollout := func(virtualServices ...string) *argo.Rollout {
+		istio := &argo.IstioTrafficRouting{}
+		for _, virtualService := range virtualServices {
+			istio.VirtualServices = append(istio.VirtualServices, argo.IstioVirtualService{Name: virtualService})
+		}
+		return &argo.Rollout{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "foo-ns"},
+			Spec: argo.RolloutSpec{
+				Template: coreV1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"identity": "foo"}}},
+				Strategy: argo.RolloutStrategy{Canary: &argo.CanaryStrategy{
+					TrafficRouting: &argo.RolloutTrafficRouting{Istio: istio},
+				}},
+			},
+		}
+	}
+	cache := NewIdentityArgoVSCache()
+
+	assert.Nil(t, cache.Put(rollout("vs1", "vs2"), nil))
+	assert.Equal(t, map[string]bool{"vs1": true, "vs2": true}, cache.Get("foo"))
+
+	assert.Nil(t, cache.Put(rollout("vs2", "vs3"), rollout("vs1", "vs2")))
+	assert.Equal(t, map[string]bool{"vs2": true, "vs3": true}, cache.Get("foo"))
+
+	assert.Nil(t, cache.Delete(rollout("vs2", "vs3")))
+	assert.Equal(t, map[string]bool{}, cache.Get("foo"))
+}
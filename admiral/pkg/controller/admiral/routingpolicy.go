@@ -140,5 +140,5 @@ func (t *RoutingPolicyController) Get(ctx context.Context, isRetry bool, obj int
 	if ok && t.CrdClient != nil {
 		return t.CrdClient.AdmiralV1().RoutingPolicies(rp.Namespace).Get(ctx, rp.Name, meta_v1.GetOptions{})
 	}*/
-	return nil, fmt.Errorf("crd client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("crd client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -320,5 +320,5 @@ func (sec *ServiceController) Get(ctx context.Context, isRetry bool, obj interfa
 	if ok && sec.K8sClient != nil {
 		return sec.K8sClient.CoreV1().Services(service.Namespace).Get(ctx, service.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -245,5 +245,5 @@ func (d *ShardController) Get(ctx context.Context, isRetry bool, obj interface{}
 	if ok && d.CrdClient != nil {
 		return d.CrdClient.AdmiralV1().Shards(shard.Namespace).Get(ctx, shard.Name, metav1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -0,0 +1,40 @@
+package admiral
+
+import (
+	argo "github.com/argoproj/argo-rollouts/pkg/apis/rollouts/v1alpha1"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ClusterHandler is implemented by the handlers which process the resources of a single cluster
+type ClusterHandler interface {
+	GetClusterID() string
+}
+
+// setSpiffeIdentityTransform resolves the identity of the workloads without the workload identifier label
+// from their SPIFFE ID before they are stored in the informer cache, when enabled for the cluster of the handler
+func setSpiffeIdentityTransform(informer cache.SharedIndexInformer, handler interface{}) error {
+	clusterHandler, ok := handler.(ClusterHandler)
+	if !ok {
+		return nil
+	}
+	cluster := clusterHandler.GetClusterID()
+	return informer.SetTransform(func(obj interface{}) (interface{}, error) {
+		if !common.IsSpiffeIdentityEnabledForCluster(cluster) {
+			return obj, nil
+		}
+		var resolved bool
+		switch workload := obj.(type) {
+		case *k8sAppsV1.Deployment:
+			resolved = common.SetSpiffeIdentity(workload.Namespace, &workload.Spec.Template)
+		case *argo.Rollout:
+			resolved = common.SetSpiffeIdentity(workload.Namespace, &workload.Spec.Template)
+		}
+		if resolved {
+			log.Debugf(LogCacheFormat, "Transform", "SpiffeIdentity", "", "", cluster, "resolved workload identity from its SPIFFE ID")
+		}
+		return obj, nil
+	})
+}
@@ -0,0 +1,85 @@
+package admiral
+
+import (
+	"testing"
+	"time"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/test"
+	"github.com/stretchr/testify/assert"
+	k8sAppsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sAppsInformers "k8s.io/client-go/informers/apps/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+type mockClusterDeploymentHandler struct {
+	test.MockDeploymentHandler
+	clusterID string
+}
+
+func (m *mockClusterDeploymentHandler) GetClusterID() string {
+	return m.clusterID
+}
+
+func TestSetSpiffeIdentityTransform(t *testing.T) {
+	p := common.AdmiralParams{
+		LabelSet:               &common.LabelSet{WorkloadIdentityKey: "identity"},
+		SANPrefix:              "prefix",
+		SpiffeIdentityClusters: []string{"cluster1"},
+	}
+	common.ResetSync()
+	common.InitializeConfig(p)
+
+	newDeployment := func(name string) *k8sAppsV1.Deployment {
+		return &k8sAppsV1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec: k8sAppsV1.DeploymentSpec{Template: coreV1.PodTemplateSpec{
+				Spec: coreV1.PodSpec{ServiceAccountName: "foo"},
+			}},
+		}
+	}
+	testCases := []struct {
+		name             string
+		handler          interface{}
+		expectedIdentity string
+	}{
+		{
+			name: "Given a handler of a cluster with SPIFFE identity resolution, " +
+				"When a deployment without the workload identifier label is stored, " +
+				"Then its identity should be resolved from its service account",
+			handler:          &mockClusterDeploymentHandler{clusterID: "cluster1"},
+			expectedIdentity: "foo",
+		},
+		{
+			name: "Given a handler of a cluster without SPIFFE identity resolution, " +
+				"When a deployment without the workload identifier label is stored, " +
+				"Then its identity should not be resolved",
+			handler: &mockClusterDeploymentHandler{clusterID: "cluster2"},
+		},
+		{
+			name: "Given a handler which does not know its cluster, " +
+				"When a deployment without the workload identifier label is stored, " +
+				"Then its identity should not be resolved",
+			handler: &test.MockDeploymentHandler{},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			stop := make(chan struct{})
+			defer close(stop)
+			informer := k8sAppsInformers.NewDeploymentInformer(fake.NewSimpleClientset(newDeployment("foo")),
+				metav1.NamespaceAll, time.Minute, cache.Indexers{})
+			assert.Nil(t, setSpiffeIdentityTransform(informer, c.handler))
+			go informer.Run(stop)
+			assert.True(t, cache.WaitForCacheSync(stop, informer.HasSynced))
+
+			obj, exists, err := informer.GetStore().GetByKey("ns1/foo")
+			assert.Nil(t, err)
+			assert.True(t, exists)
+			assert.Equal(t, c.expectedIdentity, common.GetDeploymentGlobalIdentifier(obj.(*k8sAppsV1.Deployment)))
+		})
+	}
+}
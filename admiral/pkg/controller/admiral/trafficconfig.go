@@ -219,5 +219,5 @@ func (c *TrafficConfigController) Get(ctx context.Context, isRetry bool, obj int
 	if ok && c.CrdClient != nil {
 		return c.CrdClient.AdmiralV1alpha1().TrafficConfigs(trafficConfig.Namespace).Get(ctx, trafficConfig.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("crd client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("crd client is not initialized, txId=%s", common.GetTxId(ctx))
 }
@@ -100,6 +100,29 @@ func (p *vertexCache) Get(key string, namespace string) *common.K8sObject {
 	return nil
 }
 
+// GetByIdentity returns the vertices of the identity by namespace
+func (p *vertexCache) GetByIdentity(key string) map[string]*common.K8sObject {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	vce := p.cache[key]
+	if vce != nil {
+		return vce.Vertices
+	}
+	return nil
+}
+
+// HasWorkloads returns true when the cache holds a vertex of any identity
+func (p *vertexCache) HasWorkloads() bool {
+	defer p.mutex.Unlock()
+	p.mutex.Lock()
+	for _, vce := range p.cache {
+		if len(vce.Vertices) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *vertexCache) GetVertexProcessStatus(vertex *v1alpha1.Vertex) (string, error) {
 	defer p.mutex.Unlock()
 	p.mutex.Lock()
@@ -263,5 +286,5 @@ func (j *VertexController) Get(ctx context.Context, isRetry bool, obj interface{
 	if ok && j.NumaflowClient != nil {
 		return j.NumaflowClient.NumaflowV1alpha1().Vertices(vertex.Namespace).Get(ctx, vertex.Name, meta_v1.GetOptions{})
 	}
-	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("kubernetes client is not initialized, txId=%s", common.GetTxId(ctx))
 }
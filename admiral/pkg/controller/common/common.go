@@ -57,12 +57,43 @@ const (
 	LocalAddressPrefix               = "240.0"
 	NodeRegionLabel                  = "failure-domain.beta.kubernetes.io/region"
 	SpiffePrefix                     = "spiffe://"
+	DefaultTrustDomain               = "cluster.local"
 	SidecarEnabledPorts              = "traffic.sidecar.istio.io/includeInboundPorts"
 	Default                          = "default"
 	SidecarInjectAnnotation          = "sidecar.istio.io/inject"
 	AdmiralIgnoreAnnotation          = "admiral.io/ignore"
 	AdmiralEnvAnnotation             = "admiral.io/env"
 	AdmiralCnameCaseSensitive        = "admiral.io/cname-case-sensitive"
+	AdmiralSyncNamespaceAnnotation   = "admiral.io/sync-namespace"
+	AdmiralSyncNamespaceOfLabel      = "admiral.io/sync-namespace-of"
+	AdmiralSyncNamespaceOfCluster    = "cluster"
+	AdmiralSyncNamespaceOfIdentity   = "identity"
+	AdmiralWritePausedAnnotation     = "admiral.io/write-paused"
+	AdmiralSyncAnnotation            = "admiral.io/sync"
+	AdmiralSyncPaused                = "paused"
+	AdmiralManagedByLabel            = "admiral.io/managed-by"
+	AdmiralSourceClusterLabel        = "admiral.io/source-cluster"
+	AdmiralSourceIdentityLabel       = "admiral.io/source-identity"
+	AdmiralAdoptAnnotation           = "admiral.io/adopt"
+	AdmiralCleanupFinalizer          = "admiral.io/cleanup"
+	AdmiralDeleteAfterAnnotation     = "admiral.io/delete-after"
+	AdmiralRenderedByAnnotation      = "admiral.io/rendered-by"
+	ClusterWarmupPriorityLabel       = "admiral.io/warmup-priority"
+	AdmiralSourceVSAnnotation        = "admiral.io/source-virtualservice"
+	AdmiralTxIdAnnotation            = "admiral.io/txId"
+	AdmiralTLSModeAnnotation         = "admiral.io/tls-mode"
+	AdmiralTLSSniAnnotation          = "admiral.io/tls-sni"
+	AdmiralTLSCredentialAnnotation   = "admiral.io/tls-credential-name"
+	AdmiralWarmupDurationAnnotation  = "admiral.io/warmup-duration"
+	FailoverPriorityAnnotation       = "admiral.io/failover-priority"
+	AdmiralIngressWeightAnnotation   = "admiral.io/ingress-weight"
+	AdmiralIngressLocalityAnnotation = "admiral.io/ingress-locality"
+	SpiffeIdAnnotation               = "spiffe.io/spiffe-id"
+	AdmiralFederatedMeshLabel        = "admiral.io/federated-mesh"
+	AdmiralDiscoverySourceLabel      = "admiral.io/discovery-source"
+	DestinationSelectorAnnotation    = "admiral.io/destination-selector"
+	DependencyStatusLabel            = "admiral.io/dependency-status"
+	DependencyStatusPending          = "pending"
 	BlueGreenRolloutPreviewPrefix    = "preview"
 	RolloutPodHashLabel              = "rollouts-pod-template-hash"
 	RolloutActiveServiceSuffix       = "active-service"
@@ -101,6 +132,7 @@ const (
 	Deployment               = "deployment"
 	Rollout                  = "rollout"
 	Job                      = "job"
+	CronJob                  = "cronjob"
 	Vertex                   = "vertex"
 	MonoVertex               = "monovertex"
 	GTP                      = "gtp"
@@ -127,14 +159,83 @@ const (
 	LastUpdatedAt = "lastUpdatedAt"
 	IntuitTID     = "intuit_tid"
 	GTPCtrl       = "gtp-ctrl"
+	TxId          = "txId"
 
 	App                       = "app"
 	DynamicConfigUpdate       = "DynamicConfigUpdate"
 	LBUpdateProcessor         = "LBUpdateProcessor"
+	IngressHealthCheck        = "IngressHealthCheck"
+	MeshFederation            = "MeshFederation"
+	DiscoverySourceSync       = "DiscoverySourceSync"
+	DNSRecordSync             = "DNSRecordSync"
+	DestinationResolution     = "DestinationResolution"
+	DependencyDiscovery       = "DependencyDiscovery"
+	EventCoalescing           = "EventCoalescing"
 	ClientInitiatedProcessing = "ClientInitiatedProcessing"
+	SyncNamespaceMigration    = "SyncNamespaceMigration"
+	VSNameMigration           = "VSNameMigration"
+	StartupBarrier            = "StartupBarrier"
+	CacheMetrics              = "CacheMetrics"
+	ReplayWrites              = "ReplayWrites"
+	VirtualServiceSync        = "VirtualServiceSync"
+	LazyIstioControllers      = "LazyIstioControllers"
+	DeadClusterProbe          = "DeadClusterProbe"
+	ClusterWriteRepair        = "ClusterWriteRepair"
+	FullRefresh               = "FullRefresh"
+	DeferredDeletion          = "DeferredDeletion"
+	OrphanPruning             = "OrphanPruning"
+	GitOpsCommit              = "GitOpsCommit"
+	ConfigHistoryPersistence  = "ConfigHistoryPersistence"
+	IdentityLockWatchdog      = "IdentityLockWatchdog"
+	StaleCacheEviction        = "StaleCacheEviction"
+	DependentClusterPersist   = "DependentClusterPersist"
+	CacheConsistencyCheck     = "CacheConsistencyCheck"
+	TrafficShift              = "TrafficShift"
+	GtpActivationWindow       = "GtpActivationWindow"
+	DefaultTrafficPolicy      = "DefaultTrafficPolicy"
+
+	// Annotations of the GlobalTrafficPolicies describing a gradual shift of their traffic, along with
+	// the progress of the shift admiral checkpoints on them
+	TrafficShiftTargetAnnotation   = "admiral.io/traffic-shift-target"
+	TrafficShiftDurationAnnotation = "admiral.io/traffic-shift-duration"
+	TrafficShiftIntervalAnnotation = "admiral.io/traffic-shift-interval"
+	TrafficShiftProgressAnnotation = "admiral.io/traffic-shift-progress"
+
+	// Annotations of the GlobalTrafficPolicies which only apply within an activation window, between two
+	// times or for a duration after each time a cron schedule fires
+	GtpActiveFromAnnotation     = "admiral.io/active-from"
+	GtpActiveUntilAnnotation    = "admiral.io/active-until"
+	GtpActiveScheduleAnnotation = "admiral.io/active-schedule"
+	GtpActiveDurationAnnotation = "admiral.io/active-duration"
+
+	// Annotation of the GlobalTrafficPolicies which are the default traffic policy of their namespace or of the
+	// org, and annotation of the ServiceEntries listing the default traffic policies their identity inherited
+	TrafficPolicyScopeAnnotation  = "admiral.io/traffic-policy-scope"
+	TrafficPolicySourceAnnotation = "admiral.io/traffic-policy-source"
+	TrafficPolicyScopeNamespace   = "namespace"
+	TrafficPolicyScopeOrg         = "org"
+
+	// Modes of the discovery of the dependencies from the mesh telemetry
+	DependencyDiscoveryModeReport  = "report"
+	DependencyDiscoveryModePending = "pending"
 
 	DummyAdmiralGlobal = "dummy.admiral.global"
 
+	// Strategies used to name the VirtualServices synced to other clusters
+	VSNameStrategyLegacy     = "legacy"
+	VSNameStrategyHashSuffix = "hash-suffix"
+	VSNameStrategyIdentity   = "identity"
+	maxVSNameLength          = 250
+	vsNameHashLength         = 10
+
+	// UnassignedTeam is the team of the identities neither the team label nor an identity prefix assigns to a team
+	UnassignedTeam = "unassigned"
+
+	// StableClusterCohort is the cohort of clusters which are not part of any other cohort
+	StableClusterCohort    = "stable"
+	CohortFeatureExportTo  = "exportTo"
+	CohortFeatureVSRouting = "vsRouting"
+
 	VSRoutingLabel = "admiral.io/vs-routing"
 	// VSRoutingType This label has been added in order to make the API call efficient
 	VSRoutingType                      = "admiral.io/vs-routing-type"
@@ -150,6 +251,10 @@ const (
 	TrafficConfigIdentity              = "trafficConfigIdentity"
 	RoutingDRSuffix                    = "routing-dr"
 	InclusterDRSuffix                  = "incluster-dr"
+	// CustomVSPriorityAnnotation orders the custom VirtualServices merged for an identity and env, the highest first
+	CustomVSPriorityAnnotation = "admiral.io/custom-vs-priority"
+	// MergedRouteOrderAnnotation exposes the order of the routes of an in-cluster VirtualService merged with custom VirtualServices
+	MergedRouteOrderAnnotation = "admiral.io/merged-route-order"
 )
 
 type Event string
@@ -171,6 +276,7 @@ const (
 	SecretResourceType     ResourceType = "Secret"
 	NodeResourceType       ResourceType = "Node"
 	JobResourceType        ResourceType = "Job"
+	CronJobResourceType    ResourceType = "CronJob"
 
 	// Admiral Resource Types
 	DependencyResourceType          ResourceType = "Dependency"
@@ -212,6 +318,55 @@ func GetDeploymentGlobalIdentifier(deployment *k8sAppsV1.Deployment) string {
 	return identity
 }
 
+// GetIdentityFromSpiffeID returns the identity of a workload from its SPIFFE ID, the last segment
+// of its path, e.g. foo for spiffe://cluster.local/ns/bar/sa/foo
+func GetIdentityFromSpiffeID(spiffeID string) string {
+	path, ok := strings.CutPrefix(spiffeID, SpiffePrefix)
+	if !ok {
+		return ""
+	}
+	segments := strings.Split(path, Slash)
+	if len(segments) < 2 {
+		return ""
+	}
+	return segments[len(segments)-1]
+}
+
+// SetSpiffeIdentity sets the workload identifier label of a pod template without one to the identity
+// resolved from its SPIFFE ID: the spiffe.io/spiffe-id annotation of the template, or else the istio
+// SPIFFE ID of its service account, which is then set as the annotation. It returns false when the
+// template already has an identity or no identity could be resolved
+func SetSpiffeIdentity(namespace string, template *k8sV1.PodTemplateSpec) bool {
+	if template == nil || GetGlobalIdentifier(template.Annotations, template.Labels) != "" {
+		return false
+	}
+	spiffeID := template.Annotations[SpiffeIdAnnotation]
+	if spiffeID == "" {
+		serviceAccount := template.Spec.ServiceAccountName
+		if serviceAccount == "" || serviceAccount == Default {
+			return false
+		}
+		trustDomain := GetSANPrefix()
+		if trustDomain == "" {
+			trustDomain = DefaultTrustDomain
+		}
+		spiffeID = SpiffePrefix + trustDomain + Slash + "ns" + Slash + namespace + Slash + "sa" + Slash + serviceAccount
+	}
+	identity := GetIdentityFromSpiffeID(spiffeID)
+	if identity == "" {
+		return false
+	}
+	if template.Labels == nil {
+		template.Labels = make(map[string]string)
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Labels[GetWorkloadIdentifier()] = identity
+	template.Annotations[SpiffeIdAnnotation] = spiffeID
+	return true
+}
+
 func GetDeploymentOriginalIdentifier(deployment *k8sAppsV1.Deployment) string {
 	identity := deployment.Spec.Template.Labels[GetWorkloadIdentifier()]
 	if len(identity) == 0 {
@@ -525,8 +680,8 @@ func IsIstioIngressGatewayService(svc *k8sV1.Service, key string) bool {
 }
 
 func FetchTxIdOrGenNew(ctx context.Context) string {
-	txId, ok := ctx.Value("txId").(string)
-	if !ok {
+	txId := GetTxId(ctx)
+	if txId == "" {
 		log.Errorf("unable to fetch txId from context, will recreate one")
 		id := uuid.New()
 		txId = id.String()
@@ -534,6 +689,29 @@ func FetchTxIdOrGenNew(ctx context.Context) string {
 	return txId
 }
 
+// WithTxId returns a copy of the context carrying the transaction id of the event being processed
+func WithTxId(ctx context.Context, txId string) context.Context {
+	return context.WithValue(ctx, TxId, txId)
+}
+
+// WithNewTxId returns a copy of the context carrying a new transaction id, unless it carries one already.
+// It is used by the event sources other than the informers, e.g. the periodic tasks
+func WithNewTxId(ctx context.Context) context.Context {
+	if GetTxId(ctx) != "" {
+		return ctx
+	}
+	return WithTxId(ctx, uuid.NewString())
+}
+
+// GetTxId returns the transaction id carried by the context, or an empty string when it carries none
+func GetTxId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	txId, _ := ctx.Value(TxId).(string)
+	return txId
+}
+
 func GetCtxLogger(ctx context.Context, identity, env string) *log.Entry {
 	controllerName, ok := ctx.Value("controller").(string)
 	if ok {
@@ -626,7 +804,7 @@ func RetryWithBackOff(ctx context.Context, callback func() error, retryCount int
 	var err error
 	for i := 0; i < retryCount; i++ {
 		if i > 0 {
-			log.Infof("retrying after sleeping %v, txId=%v", sleep, ctx.Value("txId"))
+			log.Infof("retrying after sleeping %v, txId=%v", sleep, GetTxId(ctx))
 			time.Sleep(sleep)
 			sleep *= 2
 		}
@@ -634,7 +812,7 @@ func RetryWithBackOff(ctx context.Context, callback func() error, retryCount int
 		if err == nil {
 			break
 		}
-		log.Infof("retrying with error %v, txId=%v", err, ctx.Value("txId"))
+		log.Infof("retrying with error %v, txId=%v", err, GetTxId(ctx))
 	}
 	return err
 }
@@ -726,6 +904,9 @@ func GetMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterNam
 	if destService == nil {
 		return ports
 	}
+	if EnableMultiPortServiceEntry() {
+		return getAllMeshPorts(meshPorts, destService, clusterName)
+	}
 	if len(meshPorts) == 0 {
 		logrus.Infof(LogFormatAdv, "GetMeshPorts", "service", destService.Name, destService.Namespace,
 			clusterName, "No mesh ports present, defaulting to first port")
@@ -755,22 +936,7 @@ func GetMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterNam
 	for _, servicePort := range destService.Spec.Ports {
 		//handling relevant protocols from here:
 		// https://istio.io/latest/docs/ops/configuration/traffic-management/protocol-selection/#manual-protocol-selection
-		//use target port if present to match the annotated mesh port
-		targetPort := uint32(servicePort.Port)
-		if servicePort.TargetPort.StrVal != "" {
-			port, err := strconv.Atoi(servicePort.TargetPort.StrVal)
-			if err != nil {
-				logrus.Warnf(LogErrFormat, "GetMeshPorts", "Failed to parse TargetPort", destService.Name, clusterName, err)
-			}
-			if port > 0 {
-				targetPort = uint32(port)
-			}
-
-		}
-		if servicePort.TargetPort.IntVal != 0 {
-			targetPort = uint32(servicePort.TargetPort.IntVal)
-		}
-		if _, ok := meshPortMap[targetPort]; ok {
+		if _, ok := meshPortMap[getServiceTargetPort(servicePort, destService, clusterName)]; ok {
 			var protocol = util.GetPortProtocol(servicePort.Name)
 			logrus.Infof(LogFormatAdv, "MeshPort", servicePort.Port, destService.Name, destService.Namespace,
 				clusterName, "Protocol: "+protocol)
@@ -781,6 +947,76 @@ func GetMeshPortsHelper(meshPorts string, destService *k8sV1.Service, clusterNam
 	return ports
 }
 
+// getServiceTargetPort returns the target port of the service port if present, which is
+// matched against the annotated mesh ports
+func getServiceTargetPort(servicePort k8sV1.ServicePort, destService *k8sV1.Service, clusterName string) uint32 {
+	targetPort := uint32(servicePort.Port)
+	if servicePort.TargetPort.StrVal != "" {
+		port, err := strconv.Atoi(servicePort.TargetPort.StrVal)
+		if err != nil {
+			logrus.Warnf(LogErrFormat, "GetMeshPorts", "Failed to parse TargetPort", destService.Name, clusterName, err)
+		}
+		if port > 0 {
+			targetPort = uint32(port)
+		}
+
+	}
+	if servicePort.TargetPort.IntVal != 0 {
+		targetPort = uint32(servicePort.TargetPort.IntVal)
+	}
+	return targetPort
+}
+
+// getAllMeshPorts returns all the mesh ports of the service, or all its ports when no mesh port is annotated.
+// The first port is keyed by its protocol like a single mesh port, and the other ports by their protocol
+// and port number, so that their protocol is still inferred from their name
+func getAllMeshPorts(meshPorts string, destService *k8sV1.Service, clusterName string) map[string]uint32 {
+	var ports = make(map[string]uint32)
+	var servicePorts []k8sV1.ServicePort
+	if len(meshPorts) == 0 {
+		servicePorts = destService.Spec.Ports
+	} else {
+		for _, meshPort := range strings.Split(meshPorts, ",") {
+			port, err := strconv.ParseUint(strings.TrimSpace(meshPort), 10, 32)
+			if err != nil {
+				continue
+			}
+			for _, servicePort := range destService.Spec.Ports {
+				if getServiceTargetPort(servicePort, destService, clusterName) == uint32(port) {
+					servicePorts = append(servicePorts, servicePort)
+					break
+				}
+			}
+		}
+	}
+	for i, servicePort := range servicePorts {
+		protocol := util.GetPortProtocol(servicePort.Name)
+		if i == 0 {
+			ports[protocol] = uint32(servicePort.Port)
+			continue
+		}
+		// the first port is served on the default service entry port
+		if servicePort.Port == DefaultServiceEntryPort {
+			logrus.Warnf(LogErrFormat, "Get", "MeshPorts", destService.Name, clusterName,
+				"skipped mesh port "+strconv.Itoa(DefaultServiceEntryPort)+" as it is used by the first mesh port")
+			continue
+		}
+		ports[GetAdditionalMeshPortName(protocol, uint32(servicePort.Port))] = uint32(servicePort.Port)
+	}
+	return ports
+}
+
+// GetAdditionalMeshPortName returns the name of a mesh port other than the first one of a service
+func GetAdditionalMeshPortName(protocol string, port uint32) string {
+	return protocol + "-" + strconv.FormatUint(uint64(port), 10)
+}
+
+// IsAdditionalMeshPort checks if the mesh port is not the first one of its service, in which
+// case it is served on its own port number instead of the default service entry port
+func IsAdditionalMeshPort(name string, port uint32) bool {
+	return name == GetAdditionalMeshPortName(util.GetPortProtocol(name), port)
+}
+
 func ShouldIgnore(annotations map[string]string, labels map[string]string) bool {
 	labelSet := GetLabelSet()
 
@@ -846,8 +1082,8 @@ func GenerateUniqueNameForVS(originNamespace string, vsName string) string {
 	}
 
 	newVSName := originNamespace + "-" + vsName
-	if len(newVSName) > 250 {
-		newVSName = newVSName[:250]
+	if len(newVSName) > maxVSNameLength {
+		newVSName = newVSName[:maxVSNameLength]
 	}
 	//"op=%v type=%v name=%v namespace=%s cluster=%s message=%v"
 	logrus.Debugf(LogFormatAdv, "VirtualService", newVSName, originNamespace, "", "New VS name generated")
@@ -856,6 +1092,34 @@ func GenerateUniqueNameForVS(originNamespace string, vsName string) string {
 
 }
 
+// GenerateNameForVS returns the name a VirtualService from originNamespace is synced with, following
+// the naming strategy. The identity based strategy falls back to the legacy name when the
+// VirtualService has no identity
+func GenerateNameForVS(strategy, originNamespace, vsName, identity string) string {
+	switch strategy {
+	case VSNameStrategyHashSuffix:
+		if vsName == "" {
+			return GenerateUniqueNameForVS(originNamespace, vsName)
+		}
+		hash, err := GetSha1(originNamespace + "/" + vsName)
+		if err != nil {
+			return GenerateUniqueNameForVS(originNamespace, vsName)
+		}
+		hash = hash[:vsNameHashLength]
+		if len(vsName) > maxVSNameLength-len(hash)-1 {
+			vsName = vsName[:maxVSNameLength-len(hash)-1]
+		}
+		return vsName + "-" + hash
+	case VSNameStrategyIdentity:
+		if identity == "" {
+			return GenerateUniqueNameForVS(originNamespace, vsName)
+		}
+		return GenerateUniqueNameForVS(strings.ToLower(identity), vsName)
+	default:
+		return GenerateUniqueNameForVS(originNamespace, vsName)
+	}
+}
+
 func IsAGateway(item string) bool {
 	gwAssetAliases := GetGatewayAssetAliases()
 	for _, gw := range gwAssetAliases {
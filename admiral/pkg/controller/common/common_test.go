@@ -1148,6 +1148,86 @@ func TestGetMeshPorts(t *testing.T) {
 	}
 }
 
+func TestGetMeshPortsWithMultiPortServiceEntry(t *testing.T) {
+	ResetSync()
+	InitializeConfig(AdmiralParams{LabelSet: &LabelSet{}, EnableMultiPortServiceEntry: true})
+	defer ResetSync()
+	var (
+		service = k8sCoreV1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "server"},
+			Spec: k8sCoreV1.ServiceSpec{Ports: []k8sCoreV1.ServicePort{
+				{Name: "http", Port: 8080, TargetPort: intstr.FromInt(8090)},
+				{Name: "grpc-api", Port: 9090},
+				{Name: "http-admin", Port: 80, TargetPort: intstr.FromInt(8081)},
+			}},
+		}
+		deploymentWithMeshPorts = func(meshPorts string) *k8sAppsV1.Deployment {
+			return &k8sAppsV1.Deployment{
+				Spec: k8sAppsV1.DeploymentSpec{Template: k8sCoreV1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SidecarEnabledPorts: meshPorts}},
+				}}}
+		}
+	)
+
+	testCases := []struct {
+		name       string
+		deployment *k8sAppsV1.Deployment
+		expected   map[string]uint32
+	}{
+		{
+			name:       "should return all the annotated mesh ports in the order of the annotation",
+			deployment: deploymentWithMeshPorts("9090,8090"),
+			expected:   map[string]uint32{"grpc": 9090, "http-8080": 8080},
+		},
+		{
+			name:       "should return the single annotated mesh port keyed by its protocol",
+			deployment: deploymentWithMeshPorts("8090"),
+			expected:   map[string]uint32{"http": 8080},
+		},
+		{
+			name:       "should skip an additional mesh port served on the default service entry port",
+			deployment: deploymentWithMeshPorts("8090,8081"),
+			expected:   map[string]uint32{"http": 8080},
+		},
+		{
+			name:       "should return all the service ports when no mesh port is annotated",
+			deployment: deploymentWithMeshPorts(""),
+			expected:   map[string]uint32{"http": 8080, "grpc-9090": 9090},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			meshPorts := GetMeshPortsForDeployments("cluster1", &service, c.deployment)
+			if !reflect.DeepEqual(meshPorts, c.expected) {
+				t.Errorf("Wanted meshPorts: %v, got: %v", c.expected, meshPorts)
+			}
+		})
+	}
+}
+
+func TestIsAdditionalMeshPort(t *testing.T) {
+	testCases := []struct {
+		name     string
+		portName string
+		port     uint32
+		expected bool
+	}{
+		{name: "should return false for the first mesh port", portName: "http", port: 8080},
+		{name: "should return false for the first grpc-web mesh port", portName: "grpc-web", port: 8080},
+		{name: "should return true for an additional mesh port", portName: "grpc-9090", port: 9090, expected: true},
+		{name: "should return true for an additional grpc-web mesh port", portName: "grpc-web-9090", port: 9090, expected: true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := IsAdditionalMeshPort(c.portName, c.port); actual != c.expected {
+				t.Errorf("Wanted: %v, got: %v", c.expected, actual)
+			}
+		})
+	}
+}
+
 func TestGetGtpIdentityPartition(t *testing.T) {
 	initConfig(true, true)
 	partitionIdentifier := "admiral.io/identityPartition"
@@ -1423,6 +1503,73 @@ func TestGenerateUniqueNameForVS(t *testing.T) {
 	}
 }
 
+func TestGenerateNameForVS(t *testing.T) {
+	initConfig(true, true)
+
+	testCases := []struct {
+		name     string
+		strategy string
+		vsName   string
+		nsName   string
+		identity string
+		expected string
+	}{
+		{
+			name: "Given the legacy strategy, " +
+				"When GenerateNameForVS is called, " +
+				"Then it should prefix the virtual service name with the namespace",
+			strategy: VSNameStrategyLegacy,
+			vsName:   "test-vs",
+			nsName:   "test-ns",
+			identity: "Test.Identity",
+			expected: "test-ns-test-vs",
+		},
+		{
+			name: "Given an unknown strategy, " +
+				"When GenerateNameForVS is called, " +
+				"Then it should fall back to the legacy strategy",
+			strategy: "unknown",
+			vsName:   "test-vs",
+			nsName:   "test-ns",
+			expected: "test-ns-test-vs",
+		},
+		{
+			name: "Given the hash-suffix strategy, " +
+				"When GenerateNameForVS is called, " +
+				"Then it should suffix the virtual service name with the hash of the namespace and name",
+			strategy: VSNameStrategyHashSuffix,
+			vsName:   "test-vs",
+			nsName:   "test-ns",
+			expected: "test-vs-5d2e12c22e",
+		},
+		{
+			name: "Given the identity strategy, " +
+				"When GenerateNameForVS is called, " +
+				"Then it should prefix the virtual service name with the lower cased identity",
+			strategy: VSNameStrategyIdentity,
+			vsName:   "test-vs",
+			nsName:   "test-ns",
+			identity: "Test.Identity",
+			expected: "test.identity-test-vs",
+		},
+		{
+			name: "Given the identity strategy and a virtual service without identity, " +
+				"When GenerateNameForVS is called, " +
+				"Then it should fall back to the legacy strategy",
+			strategy: VSNameStrategyIdentity,
+			vsName:   "test-vs",
+			nsName:   "test-ns",
+			expected: "test-ns-test-vs",
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, GenerateNameForVS(c.strategy, c.nsName, c.vsName, c.identity))
+		})
+	}
+}
+
 func TestIsAGateway(t *testing.T) {
 	initConfig(true, true)
 
@@ -2063,3 +2210,114 @@ func TestIsVSRoutingInClusterVirtualService(t *testing.T) {
 	}
 
 }
+
+func TestSetSpiffeIdentity(t *testing.T) {
+	initConfig(false, false)
+	testCases := []struct {
+		name             string
+		template         *k8sCoreV1.PodTemplateSpec
+		expectedResolved bool
+		expectedIdentity string
+		expectedSpiffeID string
+	}{
+		{
+			name: "Given a pod template with the workload identifier label, " +
+				"When SetSpiffeIdentity is called, " +
+				"Then the identity should not be changed",
+			template: &k8sCoreV1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"identity": "foo"}},
+				Spec:       k8sCoreV1.PodSpec{ServiceAccountName: "bar"},
+			},
+			expectedIdentity: "foo",
+		},
+		{
+			name: "Given a pod template with a SPIFFE ID annotation, " +
+				"When SetSpiffeIdentity is called, " +
+				"Then the identity should be resolved from the annotation",
+			template: &k8sCoreV1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SpiffeIdAnnotation: "spiffe://example.org/workloads/foo"}},
+				Spec:       k8sCoreV1.PodSpec{ServiceAccountName: "bar"},
+			},
+			expectedResolved: true,
+			expectedIdentity: "foo",
+			expectedSpiffeID: "spiffe://example.org/workloads/foo",
+		},
+		{
+			name: "Given a pod template with a service account, " +
+				"When SetSpiffeIdentity is called, " +
+				"Then the identity should be resolved from the istio SPIFFE ID of the service account",
+			template: &k8sCoreV1.PodTemplateSpec{
+				Spec: k8sCoreV1.PodSpec{ServiceAccountName: "bar"},
+			},
+			expectedResolved: true,
+			expectedIdentity: "bar",
+			expectedSpiffeID: "spiffe://prefix/ns/ns1/sa/bar",
+		},
+		{
+			name: "Given a pod template with the default service account, " +
+				"When SetSpiffeIdentity is called, " +
+				"Then no identity should be resolved",
+			template: &k8sCoreV1.PodTemplateSpec{
+				Spec: k8sCoreV1.PodSpec{ServiceAccountName: "default"},
+			},
+		},
+		{
+			name: "Given a pod template with an invalid SPIFFE ID annotation, " +
+				"When SetSpiffeIdentity is called, " +
+				"Then no identity should be resolved",
+			template: &k8sCoreV1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{SpiffeIdAnnotation: "example.org/foo"}},
+			},
+			expectedSpiffeID: "example.org/foo",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedResolved, SetSpiffeIdentity("ns1", c.template))
+			assert.Equal(t, c.expectedIdentity, c.template.Labels["identity"])
+			assert.Equal(t, c.expectedSpiffeID, c.template.Annotations[SpiffeIdAnnotation])
+		})
+	}
+}
+
+func TestGetTxId(t *testing.T) {
+	testCases := []struct {
+		name         string
+		ctx          context.Context
+		expectedTxId string
+	}{
+		{
+			name: "Given a context carrying a transaction id, " +
+				"When GetTxId is called, " +
+				"Then the transaction id should be returned",
+			ctx:          WithTxId(context.Background(), "tx-1"),
+			expectedTxId: "tx-1",
+		},
+		{
+			name: "Given a context carrying a transaction id which is not a string, " +
+				"When GetTxId is called, " +
+				"Then an empty string should be returned",
+			ctx:          context.WithValue(context.Background(), TxId, 1),
+			expectedTxId: "",
+		},
+		{
+			name: "Given a context without a transaction id, " +
+				"When GetTxId is called, " +
+				"Then an empty string should be returned",
+			ctx:          context.Background(),
+			expectedTxId: "",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedTxId, GetTxId(c.ctx))
+		})
+	}
+}
+
+func TestWithNewTxId(t *testing.T) {
+	ctx := WithNewTxId(context.Background())
+	assert.NotEmpty(t, GetTxId(ctx))
+	assert.NotEqual(t, GetTxId(ctx), GetTxId(WithNewTxId(context.Background())))
+	assert.Equal(t, "tx-1", GetTxId(WithNewTxId(WithTxId(context.Background(), "tx-1"))))
+}
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -96,6 +98,13 @@ func GetClusterRegistriesNamespace() string {
 	return wrapper.params.ClusterRegistriesNamespace
 }
 
+// GetClusterWarmupParallelism returns the number of cluster secrets processed in parallel
+func GetClusterWarmupParallelism() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ClusterWarmupParallelism
+}
+
 func GetDependenciesNamespace() string {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -108,6 +117,80 @@ func GetSyncNamespace() string {
 	return wrapper.params.SyncNamespace
 }
 
+// GetClusterSyncNamespace returns the sync namespace the cluster is explicitly mapped to,
+// or an empty string when the cluster uses the global sync namespace
+func GetClusterSyncNamespace(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ClusterSyncNamespaces[cluster]
+}
+
+// GetSyncNamespaceForCluster returns the namespace admiral writes generated resources to in the cluster
+func GetSyncNamespaceForCluster(cluster string) string {
+	if syncNamespace := GetClusterSyncNamespace(cluster); syncNamespace != "" {
+		return syncNamespace
+	}
+	return GetSyncNamespace()
+}
+
+// EnableIdentitySyncNamespace returns true when identities are allowed to request the namespace
+// their generated resources are written to through the admiral.io/sync-namespace annotation
+func EnableIdentitySyncNamespace() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableIdentitySyncNamespace
+}
+
+// IsIdentitySyncNamespaceAllowed checks if identities are allowed to request the namespace as the
+// namespace their generated resources are written to
+func IsIdentitySyncNamespaceAllowed(namespace string) bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return namespace != "" && slices.Contains(wrapper.params.AllowedIdentitySyncNamespaces, namespace)
+}
+
+// EnableIdentityClientTLSSettings returns true when identities are allowed to override the TLS
+// settings of their DestinationRules through the admiral.io/tls-mode annotation
+func EnableIdentityClientTLSSettings() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableIdentityClientTLSSettings
+}
+
+// EnableIdentityWarmupDuration returns true when identities are allowed to override the warmup
+// duration of their DestinationRules per env through the admiral.io/warmup-duration annotation
+func EnableIdentityWarmupDuration() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableIdentityWarmupDuration
+}
+
+// EnableFailoverPriority returns true when the regions the DestinationRules of an identity fail over
+// to can be ordered through the admiral.io/failover-priority annotation of its GTP or of its workloads
+func EnableFailoverPriority() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableFailoverPriority
+}
+
+// GetSyncNamespaces returns the global sync namespace along with all the namespaces clusters are mapped to
+func GetSyncNamespaces() []string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	syncNamespaces := []string{wrapper.params.SyncNamespace}
+	for _, syncNamespace := range wrapper.params.ClusterSyncNamespaces {
+		if syncNamespace != "" && !slices.Contains(syncNamespaces, syncNamespace) {
+			syncNamespaces = append(syncNamespaces, syncNamespace)
+		}
+	}
+	return syncNamespaces
+}
+
+// IsSyncNamespace checks if the namespace is the sync namespace of any cluster
+func IsSyncNamespace(namespace string) bool {
+	return slices.Contains(GetSyncNamespaces(), namespace)
+}
+
 func GetEnableSAN() bool {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -240,6 +323,14 @@ func GetEnvKey() string {
 	return wrapper.params.LabelSet.EnvKey
 }
 
+// GetCacheMetricsInterval returns the interval at which the size and composition of the
+// admiral caches are exported
+func GetCacheMetricsInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.CacheMetricsInterval
+}
+
 func GetMetricsEnabled() bool {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -430,6 +521,12 @@ func EnableExportTo(identityOrCname string) bool {
 	return false
 }
 
+// EnableExportToForCluster checks if the exportTo field should be written for the identity
+// or cname, in resources generated for the cluster
+func EnableExportToForCluster(identityOrCname, cluster string) bool {
+	return EnableExportTo(identityOrCname) && IsFeatureEnabledForCluster(CohortFeatureExportTo, cluster)
+}
+
 func EnableSWAwareNSCaches() bool {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -476,6 +573,9 @@ func DoVSRoutingForCluster(cluster string) bool {
 	if !wrapper.params.EnableVSRouting {
 		return false
 	}
+	if !isCohortFeatureEnabled(&wrapper.params, CohortFeatureVSRouting, cluster) {
+		return false
+	}
 	for _, c := range wrapper.params.VSRoutingDisabledClusters {
 		if c == "*" {
 			return false
@@ -574,6 +674,31 @@ func GetVSRoutingGateways() []string {
 	return wrapper.params.VSRoutingGateways
 }
 
+// GetVSRoutingGatewaysForClusterAndIdentity returns the gateways of the routing VirtualServices of the identity
+// in the cluster. The gateways set for the identity take precedence over the ones set for the cluster, which
+// take precedence over vs_routing_gateways
+func GetVSRoutingGatewaysForClusterAndIdentity(cluster, identity string) []string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if gateways := splitGateways(wrapper.params.VSRoutingIdentityGateways[identity]); len(gateways) > 0 {
+		return gateways
+	}
+	if gateways := splitGateways(wrapper.params.VSRoutingClusterGateways[cluster]); len(gateways) > 0 {
+		return gateways
+	}
+	return wrapper.params.VSRoutingGateways
+}
+
+func splitGateways(gateways string) []string {
+	var result []string
+	for _, gateway := range strings.Split(gateways, "|") {
+		if gateway = strings.TrimSpace(gateway); gateway != "" {
+			result = append(result, gateway)
+		}
+	}
+	return result
+}
+
 func DoGenerationCheck() bool {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -596,8 +721,16 @@ func GetResyncIntervals() util.ResyncIntervals {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
 	return util.ResyncIntervals{
-		UniversalReconcileInterval: wrapper.params.CacheReconcileDuration,
-		SeAndDrReconcileInterval:   wrapper.params.SeAndDrCacheReconcileDuration,
+		UniversalReconcileInterval:       wrapper.params.CacheReconcileDuration,
+		SeAndDrReconcileInterval:         wrapper.params.SeAndDrCacheReconcileDuration,
+		DeploymentReconcileInterval:      wrapper.params.DeploymentReconcileDuration,
+		RolloutReconcileInterval:         wrapper.params.RolloutReconcileDuration,
+		NodeReconcileInterval:            wrapper.params.NodeReconcileDuration,
+		ServiceReconcileInterval:         wrapper.params.ServiceReconcileDuration,
+		ServiceEntryReconcileInterval:    wrapper.params.ServiceEntryReconcileDuration,
+		DestinationRuleReconcileInterval: wrapper.params.DestinationRuleReconcileDuration,
+		VirtualServiceReconcileInterval:  wrapper.params.VirtualServiceReconcileDuration,
+		SidecarReconcileInterval:         wrapper.params.SidecarReconcileDuration,
 	}
 }
 
@@ -607,6 +740,691 @@ func GetExportToMaxNamespaces() int {
 	return wrapper.params.ExportToMaxNamespaces
 }
 
+// GetExportToFallbackNamespace returns the namespace exportTo is replaced with when the
+// number of dependent namespaces exceeds ExportToMaxNamespaces, * is used when it is not set
+func GetExportToFallbackNamespace() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ExportToFallbackNamespace
+}
+
+// EnableK8sEvents returns true when kubernetes events are emitted against the source resources
+// for sync failures, dead cluster skips and exportTo truncation
+func EnableK8sEvents() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableK8sEvents
+}
+
+// ReadinessRequiresCacheSync returns true when the readiness check fails until the informer
+// caches of all the registered clusters have synced
+func ReadinessRequiresCacheSync() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ReadinessRequiresCacheSync
+}
+
+// GetStartupBarrierTimeout returns the maximum time the writes to the clusters are held at startup
+// until the caches are built, the barrier is disabled when it is not set
+func GetStartupBarrierTimeout() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.StartupBarrierTimeout
+}
+
+// EnableSpecInterning returns true when the identical ServiceEntry, VirtualService and DestinationRule
+// specs of the clusters share a single copy in the informer caches
+func EnableSpecInterning() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableSpecInterning
+}
+
+// EnableMergePatchUpdates returns true when the existing ServiceEntries, VirtualServices and
+// DestinationRules are updated with a JSON merge patch of the changes
+func EnableMergePatchUpdates() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableMergePatchUpdates
+}
+
+// EnableLazyIstioControllers returns true when the istio controllers of a cluster are started only
+// once the first workload with an identity appears in it
+func EnableLazyIstioControllers() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableLazyIstioControllers
+}
+
+// GetIstioControllersIdleTimeout returns the time after which the istio controllers of a cluster with
+// no workloads with an identity are stopped
+func GetIstioControllersIdleTimeout() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IstioControllersIdleTimeout
+}
+
+// GetDeadClusterProbeInterval returns the interval at which the unreachable clusters the writes to were skipped for are probed
+func GetDeadClusterProbeInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DeadClusterProbeInterval
+}
+
+// EnableOwnershipLabels checks if the resources admiral writes are labeled with their owner, and
+// VirtualServices owned by something else are left alone unless they are marked for adoption
+func EnableOwnershipLabels() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableOwnershipLabels
+}
+
+// GetAdmiralInstanceName returns the name of the admiral instance the resources it writes are labeled with
+func GetAdmiralInstanceName() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.AdmiralInstanceName
+}
+
+// EnableSourceFinalizers checks if the source VirtualServices and GlobalTrafficPolicies are given a finalizer,
+// so that their deletion waits until admiral has removed what it synced from them
+func EnableSourceFinalizers() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableSourceFinalizers
+}
+
+// GetServiceEntryDeletionGracePeriod returns the time the ServiceEntry of a workload which is gone is kept
+// for before it is deleted
+func GetServiceEntryDeletionGracePeriod() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ServiceEntryDeletionGracePeriod
+}
+
+// GetOrphanPruningInterval returns the interval at which the generated resources which no longer have a source are pruned
+func GetOrphanPruningInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.OrphanPruningInterval
+}
+
+// IsOrphanPruningDryRun checks if the orphaned resources are only logged instead of being deleted
+func IsOrphanPruningDryRun() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.OrphanPruningDryRun
+}
+
+// IsGitOpsOutputMode checks if the generated resources are rendered to a GitOps repository instead of being applied
+func IsGitOpsOutputMode() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.GitOpsRepoPath != ""
+}
+
+// GetGitOpsRepoPath returns the path of the local clone of the GitOps repository the resources are rendered to
+func GetGitOpsRepoPath() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.GitOpsRepoPath
+}
+
+// GetGitOpsCommitInterval returns the interval at which the rendered resources are committed
+func GetGitOpsCommitInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if wrapper.params.GitOpsCommitInterval <= 0 {
+		return time.Minute
+	}
+	return wrapper.params.GitOpsCommitInterval
+}
+
+// GetGitOpsPushBranch returns the branch the commits are pushed to, they are not pushed when empty
+func GetGitOpsPushBranch() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.GitOpsPushBranch
+}
+
+// GetGeneratedResourceAnnotations returns the annotations stamped on every resource admiral generates
+func GetGeneratedResourceAnnotations() map[string]string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.GeneratedResourceAnnotations
+}
+
+// GetExternalRevertThreshold returns the number of times a generated resource can be reverted by
+// something other than admiral before a notification is sent, the reverts are not tracked when 0
+func GetExternalRevertThreshold() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ExternalRevertThreshold
+}
+
+// IsDiffOnlyMode checks if the changes to the generated resources are only recorded instead of being applied
+func IsDiffOnlyMode() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DiffOnlyMode
+}
+
+// GetConfigHistorySize returns the number of snapshots of the configuration generated for each identity which
+// are kept to roll it back, no history is kept when 0
+func GetConfigHistorySize() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ConfigHistorySize
+}
+
+// IsConfigHistoryPersisted checks if the history of the configuration of the identities is persisted to the registry
+func IsConfigHistoryPersisted() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.PersistConfigHistory
+}
+
+// GetIdentityChangelogSize returns the number of the latest changes kept for each identity
+func GetIdentityChangelogSize() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IdentityChangelogSize
+}
+
+// EnableIdentityLock returns true when the processings of an identity wait for each other instead of
+// running concurrently
+func EnableIdentityLock() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableIdentityLock
+}
+
+// GetIdentityLockHoldThreshold returns the time the lock of an identity can be held before the watchdog
+// reports it as stuck, the watchdog is disabled when 0
+func GetIdentityLockHoldThreshold() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IdentityLockHoldThreshold
+}
+
+// GetFullRefreshInterval returns the interval at which the resources of every identity are refreshed,
+// the periodic full refresh is disabled when 0
+func GetFullRefreshInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.FullRefreshInterval
+}
+
+// GetFullRefreshConcurrency returns the number of groups of related identities processed in parallel
+// when the resources of a cluster are refreshed
+func GetFullRefreshConcurrency() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.FullRefreshConcurrency
+}
+
+// GetCacheEntryTTL returns the time the cache entries of an identity gone from all the clusters are kept
+// before they are evicted, the entries are never evicted when 0
+func GetCacheEntryTTL() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.CacheEntryTTL
+}
+
+// GetResourceCacheMaxSize returns the maximum number of resources the ServiceEntry, DestinationRule and
+// VirtualService caches of a cluster hold, they are not bounded when 0
+func GetResourceCacheMaxSize() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ResourceCacheMaxSize
+}
+
+// GetDependentClusterPersistInterval returns the interval at which the dependent clusters of the cnames are
+// written to the registry in state syncer mode, they are not written when 0
+func GetDependentClusterPersistInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DependentClusterPersistInterval
+}
+
+// GetCacheConsistencyCheckInterval returns the interval at which the admiral cache is cross checked against
+// the informer stores of the clusters, it is not checked when 0
+func GetCacheConsistencyCheckInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.CacheConsistencyCheckInterval
+}
+
+// IsCacheConsistencyRepairEnabled checks if the periodic consistency check drops the cache entries the
+// informer stores do not back, rather than only reporting them
+func IsCacheConsistencyRepairEnabled() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.CacheConsistencyRepair
+}
+
+// GetTenantTokenFile returns the file of the tenants allowed to read the cache state of their identities
+func GetTenantTokenFile() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.TenantTokenFile
+}
+
+// GetTeamLabel returns the label, or annotation, of the workloads naming the team their identity belongs to
+func GetTeamLabel() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.TeamLabel
+}
+
+// GetIdentityTeamByPrefix returns the team of the longest identity prefix the identity starts with, ignoring
+// case, or an empty string when it starts with none
+func GetIdentityTeamByPrefix(identity string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	identity = strings.ToLower(identity)
+	var team, longest string
+	for prefix, prefixTeam := range wrapper.params.TeamIdentityPrefixes {
+		prefix = strings.ToLower(prefix)
+		if prefix == "" || !strings.HasPrefix(identity, prefix) || len(prefix) < len(longest) {
+			continue
+		}
+		if len(prefix) == len(longest) && prefixTeam > team {
+			continue
+		}
+		team, longest = prefixTeam, prefix
+	}
+	return team
+}
+
+// GetQuotaMaxHostsPerIdentity returns the max hosts generated for an identity across its environments,
+// the hosts are not limited when it is not positive
+func GetQuotaMaxHostsPerIdentity() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.QuotaMaxHostsPerIdentity
+}
+
+// GetQuotaMaxCustomVirtualServices returns the max custom VirtualServices merged for an identity and environment,
+// the custom VirtualServices are not limited when it is not positive
+func GetQuotaMaxCustomVirtualServices() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.QuotaMaxCustomVirtualServices
+}
+
+// GetTenantEventRateLimit returns the events per second each tenant can have processed by the queue of a
+// controller, the events are not rate limited when it is not positive
+func GetTenantEventRateLimit() float64 {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.TenantEventRateLimit
+}
+
+// GetTenantEventBurst returns the events each tenant can have processed at once beyond its rate limit
+func GetTenantEventBurst() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if wrapper.params.TenantEventBurst < 1 {
+		return 1
+	}
+	return wrapper.params.TenantEventBurst
+}
+
+// EnableGTPWeightedEndpoints checks if the endpoints of the ServiceEntries are weighted by the weights of the
+// regions of the GTP, on top of the locality distribution of the DestinationRules
+func EnableGTPWeightedEndpoints() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableGTPWeightedEndpoints
+}
+
+// GetTrafficShiftCheckInterval returns the interval at which the traffic shifts of the GTPs are stepped,
+// the traffic is not shifted when 0
+func GetTrafficShiftCheckInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.TrafficShiftCheckInterval
+}
+
+// GetTrafficShiftStepInterval returns the interval between the steps of a traffic shift whose GTP
+// does not set its own
+func GetTrafficShiftStepInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.TrafficShiftStepInterval
+}
+
+// GetGtpWindowCheckInterval returns the interval at which the activation windows of the GTPs are checked,
+// the GTPs are then only selected or dropped on their next event when 0
+func GetGtpWindowCheckInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.GtpWindowCheckInterval
+}
+
+// EnableGTPAdmissionDryRun checks if the admission webhook of the GTPs warns about the resources
+// the admitted GTP changes in each cluster
+func EnableGTPAdmissionDryRun() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableGTPAdmissionDryRun
+}
+
+// GetOrgTrafficPolicyNamespace returns the namespace of the default traffic policies of the org,
+// the identities only inherit the default traffic policies of their namespace when empty
+func GetOrgTrafficPolicyNamespace() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.OrgTrafficPolicyNamespace
+}
+
+// GetWriteRetryPolicy returns the policy of the retries of the writes to the clusters. The attempts include
+// the first write, so the writes are not retried with a single attempt, and default when not set
+func GetWriteRetryPolicy() RetryPolicy {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	attempts := wrapper.params.WriteRetryAttempts
+	if attempts == 0 {
+		attempts = defaultWriteRetryAttempts
+	}
+	return RetryPolicy{
+		Attempts:       attempts,
+		InitialBackoff: wrapper.params.WriteRetryInitialBackoff,
+		MaxBackoff:     wrapper.params.WriteRetryMaxBackoff,
+		Jitter:         retryJitter,
+		Retryable:      IsRetryableWriteError,
+	}
+}
+
+// GetClusterUnreachableAlertDuration returns how long a cluster has to be unreachable before a notification is sent
+func GetClusterUnreachableAlertDuration() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ClusterUnreachableAlertDuration
+}
+
+// GetGarbageCollectionAlertThreshold returns the number of resources admiral can delete from a
+// cluster in one clean up before a notification is sent
+func GetGarbageCollectionAlertThreshold() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.GarbageCollectionAlertThreshold
+}
+
+// GetMaintenanceJournalSize returns the max number of events buffered while admiral is in maintenance mode
+func GetMaintenanceJournalSize() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.MaintenanceJournalSize
+}
+
+// GetWorkloadStabilizationWindow returns how long the service entries of a workload being rolled out
+// are left unchanged while it is not fully available. The window is disabled when zero
+func GetWorkloadStabilizationWindow() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.WorkloadStabilizationWindow
+}
+
+// EnableMultiPortServiceEntry returns true when the service entries carry all the mesh ports
+// of a service instead of only the first one
+func EnableMultiPortServiceEntry() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableMultiPortServiceEntry
+}
+
+// EnableMultipleIngressEndpoints returns true when the service entries point at all the load
+// balancers of the cluster ingress instead of a single one
+func EnableMultipleIngressEndpoints() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableMultipleIngressEndpoints
+}
+
+// GetEastWestGatewaySelector returns the labels of the east-west gateway service whose load balancer
+// is used as the endpoint of the service entries. The discovery is disabled when empty
+func GetEastWestGatewaySelector() map[string]string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EastWestGatewaySelector
+}
+
+// GetEastWestGatewayNamespace returns the namespace the east-west gateway service is discovered in
+func GetEastWestGatewayNamespace() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if wrapper.params.EastWestGatewayNamespace == "" {
+		return NamespaceIstioSystem
+	}
+	return wrapper.params.EastWestGatewayNamespace
+}
+
+// GetEastWestGatewayCacheTTL returns how long a discovered east-west gateway is used before it is discovered again
+func GetEastWestGatewayCacheTTL() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EastWestGatewayCacheTTL
+}
+
+// GetLBMigrationSoakDuration returns how long the old load balancer of a cluster is kept in the
+// service entries after the cluster switched to a new load balancer
+func GetLBMigrationSoakDuration() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.LBMigrationSoakDuration
+}
+
+// IsTrustDomainFederationEnabled returns true when clusters with different trust domains are federated
+func IsTrustDomainFederationEnabled() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return len(wrapper.params.ClusterTrustDomains) > 0 || len(wrapper.params.TrustDomainMappings) > 0
+}
+
+// GetClusterTrustDomains returns the trust domain of each cluster
+func GetClusterTrustDomains() map[string]string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ClusterTrustDomains
+}
+
+// GetTrustDomainMappings returns the trust domain of each cluster pair, keyed by clientCluster:serverCluster
+func GetTrustDomainMappings() map[string]string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.TrustDomainMappings
+}
+
+// IsSpiffeIdentityEnabledForCluster returns true when the identity of the workloads of the cluster
+// without the workload identifier label is resolved from their SPIFFE ID
+func IsSpiffeIdentityEnabledForCluster(cluster string) bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	for _, c := range wrapper.params.SpiffeIdentityClusters {
+		if c == "*" || c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTrustDomainForClusterPair returns the trust domain the client cluster verifies the workloads of the
+// server cluster with: the mapping configured for the cluster pair, or else the trust domain of the server
+// cluster, or else the san prefix
+func GetTrustDomainForClusterPair(clientCluster, serverCluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if trustDomain := wrapper.params.TrustDomainMappings[clientCluster+":"+serverCluster]; trustDomain != "" {
+		return trustDomain
+	}
+	if trustDomain := wrapper.params.ClusterTrustDomains[serverCluster]; trustDomain != "" {
+		return trustDomain
+	}
+	return wrapper.params.SANPrefix
+}
+
+// GetIngressHealthCheckInterval returns the interval at which the ingress load balancer of each cluster
+// is probed, the health checks are disabled when 0
+func GetIngressHealthCheckInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IngressHealthCheckInterval
+}
+
+func GetIngressHealthCheckTimeout() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IngressHealthCheckTimeout
+}
+
+// GetIngressHealthCheckFailureThreshold returns the number of consecutive probes needed to fail over
+// to the secondary ingress endpoint of a cluster, and to fail back from it
+func GetIngressHealthCheckFailureThreshold() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if wrapper.params.IngressHealthCheckFailureThreshold <= 0 {
+		return 1
+	}
+	return wrapper.params.IngressHealthCheckFailureThreshold
+}
+
+func IsIngressHealthCheckTLSEnabled() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IngressHealthCheckTLS
+}
+
+// GetIngressSecondaryEndpoint returns the host[:port] the dependent clusters are pointed at
+// while the ingress load balancer of the cluster is unhealthy
+func GetIngressSecondaryEndpoint(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IngressSecondaryEndpoints[cluster]
+}
+
+// GetMeshFederationName returns the name of the mesh in the services exported to the other meshes
+func GetMeshFederationName() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.MeshFederationName
+}
+
+// GetMeshFederationImports returns the federation endpoint of each mesh the services are imported from
+func GetMeshFederationImports() map[string]string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.MeshFederationImports
+}
+
+// GetMeshFederationImportInterval returns the interval at which the services of the other meshes are imported
+func GetMeshFederationImportInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.MeshFederationImportInterval
+}
+
+// GetDiscoverySourceType returns the type of the source the services outside of the mesh clusters are discovered from
+func GetDiscoverySourceType() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DiscoverySourceType
+}
+
+// GetDiscoverySourceSyncInterval returns the interval at which the services of the discovery source are synced
+func GetDiscoverySourceSyncInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DiscoverySourceSyncInterval
+}
+
+// GetDNSRecordTTL returns the ttl in seconds of the dns records of the generated cnames
+func GetDNSRecordTTL() int64 {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DNSRecordTTL
+}
+
+// GetDNSRecordSyncInterval returns the interval at which the dns records of the generated cnames are synced
+func GetDNSRecordSyncInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DNSRecordSyncInterval
+}
+
+// GetMaxDestinationExpansion returns the maximum number of identities a wildcard or selector
+// destination of a dependency is expanded to
+func GetMaxDestinationExpansion() int {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.MaxDestinationExpansion
+}
+
+// GetDestinationResolutionInterval returns the interval at which the wildcard and selector
+// destinations of the dependencies are resolved again
+func GetDestinationResolutionInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DestinationResolutionInterval
+}
+
+// GetDependencyDiscoveryMode returns whether the dependencies discovered from the mesh telemetry
+// are only reported, or also written as pending dependency records
+func GetDependencyDiscoveryMode() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DependencyDiscoveryMode
+}
+
+// GetDependencyDiscoveryInterval returns the interval at which the dependencies are discovered
+// from the mesh telemetry
+func GetDependencyDiscoveryInterval() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.DependencyDiscoveryInterval
+}
+
+// GetEventCoalescingWindow returns the window during which the workload events of an identity
+// and environment are collapsed into a single processing pass
+func GetEventCoalescingWindow() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EventCoalescingWindow
+}
+
+// GetIdentityBatchWindow returns the window the first workload event of an identity and
+// environment is deferred by, to batch the events received from several clusters
+func GetIdentityBatchWindow() time.Duration {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.IdentityBatchWindow
+}
+
+// GetResourcePolicyEvaluator returns the name of the policy evaluator generated resources
+// are passed to before they are applied
+func GetResourcePolicyEvaluator() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ResourcePolicyEvaluator
+}
+
+// GetWildcardExportToDeniedClusters returns the clusters generated resources with a * exportTo
+// are rejected in by the wildcard exportTo policy
+func GetWildcardExportToDeniedClusters() []string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.WildcardExportToDeniedClusters
+}
+
+// EnableGeneratedResourceValidation checks if the generated ServiceEntries, VirtualServices and
+// DestinationRules are validated before they are applied, and rejected when they are invalid
+func EnableGeneratedResourceValidation() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableGeneratedResourceValidation
+}
+
 func IsClientDiscoveryEnabled() bool {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -667,6 +1485,17 @@ func GetOperatorSecretFilterTags() string {
 	return wrapper.params.OperatorSecretFilterTags
 }
 
+// GetVSNameStrategy returns the strategy used to name the VirtualServices synced to other
+// clusters, which defaults to the legacy namespace-name strategy
+func GetVSNameStrategy() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	if wrapper.params.VSNameStrategy == "" {
+		return VSNameStrategyLegacy
+	}
+	return wrapper.params.VSNameStrategy
+}
+
 func GetIgnoreLabelsAnnotationsVSCopy() []string {
 	wrapper.RLock()
 	defer wrapper.RUnlock()
@@ -703,3 +1532,159 @@ func IsTrafficConfigProcessingEnabledForSlowStart() bool {
 	defer wrapper.RUnlock()
 	return wrapper.params.EnableTrafficConfigProcessingForSlowStart
 }
+
+// IsTrafficConfigThrottlingEnabled returns true when the quotas of the TrafficConfigs are rendered into
+// rate limit filters of the workloads of their identity
+func IsTrafficConfigThrottlingEnabled() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableTrafficConfigThrottling
+}
+
+// GetThrottlingClientIdentityHeader returns the request header carrying the identity of the client the quotas
+// of the app quota groups are matched against, the app quota groups are skipped when empty
+func GetThrottlingClientIdentityHeader() string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.ThrottlingClientIdentityHeader
+}
+
+func IsClusterTierEnforcementEnabled() bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return wrapper.params.EnableClusterTierEnforcement
+}
+
+// GetClusterTier returns the tier the cluster is tagged with, falling back to the
+// tier configured for "*". An empty string means the cluster is not tagged.
+func GetClusterTier(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return lookupTier(wrapper.params.ClusterTiers, cluster)
+}
+
+// GetEnvironmentTier returns the tier an environment belongs to, falling back to the
+// tier configured for "*". An empty string means the environment is not tagged.
+func GetEnvironmentTier(env string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	tier := lookupTier(wrapper.params.EnvironmentTiers, env)
+	if tier == "" && strings.HasSuffix(env, AIREnvSuffix) {
+		tier = lookupTier(wrapper.params.EnvironmentTiers, strings.TrimSuffix(env, AIREnvSuffix))
+	}
+	return tier
+}
+
+// GetClusterCohort returns the cohort the cluster belongs to, falling back to the cohort
+// configured for "*". Clusters which are not part of any cohort belong to the stable cohort.
+func GetClusterCohort(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return lookupClusterCohort(wrapper.params.ClusterCohorts, cluster)
+}
+
+// IsFeatureEnabledForCluster checks if a feature which is rolled out progressively is enabled
+// for the cohort the cluster belongs to. Features which are not rolled out per cohort are
+// enabled for every cluster
+func IsFeatureEnabledForCluster(feature, cluster string) bool {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return isCohortFeatureEnabled(&wrapper.params, feature, cluster)
+}
+
+func lookupClusterCohort(cohorts map[string]string, cluster string) string {
+	if cohort := strings.ToLower(lookupClusterValue(cohorts, cluster)); cohort != "" {
+		return cohort
+	}
+	return StableClusterCohort
+}
+
+func isCohortFeatureEnabled(params *AdmiralParams, feature, cluster string) bool {
+	enabledCohorts, ok := params.CohortFeatures[feature]
+	if !ok {
+		return true
+	}
+	cohort := lookupClusterCohort(params.ClusterCohorts, cluster)
+	for _, enabledCohort := range strings.Split(enabledCohorts, "|") {
+		enabledCohort = strings.TrimSpace(enabledCohort)
+		if enabledCohort == "*" || strings.EqualFold(enabledCohort, cohort) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupTier(tiers map[string]string, key string) string {
+	if tiers == nil {
+		return ""
+	}
+	if tier, ok := tiers[key]; ok {
+		return strings.ToLower(tier)
+	}
+	return strings.ToLower(tiers["*"])
+}
+
+// GetNamespaceAllowList returns the regex of namespaces admiral should process for the cluster,
+// falling back to the regex configured for "*". An empty string means all namespaces are allowed.
+func GetNamespaceAllowList(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return lookupClusterValue(wrapper.params.NamespaceAllowList, cluster)
+}
+
+// GetNamespaceDenyList returns the regex of namespaces admiral should ignore for the cluster,
+// falling back to the regex configured for "*"
+func GetNamespaceDenyList(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return lookupClusterValue(wrapper.params.NamespaceDenyList, cluster)
+}
+
+// GetNamespaceDenySelector returns the label selector matching the namespaces admiral should
+// ignore for the cluster, falling back to the selector configured for "*"
+func GetNamespaceDenySelector(cluster string) string {
+	wrapper.RLock()
+	defer wrapper.RUnlock()
+	return lookupClusterValue(wrapper.params.NamespaceDenySelectors, cluster)
+}
+
+// IsNamespaceFilteredByName checks the namespace against the allow and deny lists configured
+// for the cluster. The deny list takes precedence over the allow list, and invalid patterns are ignored.
+func IsNamespaceFilteredByName(cluster, namespace string) bool {
+	if denyList := compileNamespacePattern(GetNamespaceDenyList(cluster)); denyList != nil && denyList.MatchString(namespace) {
+		return true
+	}
+	if allowList := compileNamespacePattern(GetNamespaceAllowList(cluster)); allowList != nil && !allowList.MatchString(namespace) {
+		return true
+	}
+	return false
+}
+
+func lookupClusterValue(values map[string]string, cluster string) string {
+	if values == nil {
+		return ""
+	}
+	if value, ok := values[cluster]; ok {
+		return value
+	}
+	return values["*"]
+}
+
+var namespacePatterns sync.Map
+
+// compileNamespacePattern compiles a pattern matching the whole of a namespace name. Compiled
+// patterns are cached as the lists can be reloaded at runtime.
+func compileNamespacePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	if compiled, ok := namespacePatterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		log.Errorf("invalid namespace filter pattern=%s, err: %v", pattern, err)
+	}
+	namespacePatterns.Store(pattern, re)
+	return re
+}
@@ -309,6 +309,24 @@ func TestIsSlowStartEnabledForCluster(t *testing.T) {
 
 }
 
+func TestGetTrustDomainForClusterPair(t *testing.T) {
+	ResetSync()
+	InitializeConfig(AdmiralParams{SANPrefix: "prefix"})
+	assert.False(t, IsTrustDomainFederationEnabled())
+	assert.Equal(t, "prefix", GetTrustDomainForClusterPair("cluster1", "cluster2"))
+
+	ResetSync()
+	InitializeConfig(AdmiralParams{
+		SANPrefix:           "prefix",
+		ClusterTrustDomains: map[string]string{"cluster2": "cluster2.example.com"},
+		TrustDomainMappings: map[string]string{"cluster1:cluster2": "mapped.example.com"},
+	})
+	assert.True(t, IsTrustDomainFederationEnabled())
+	assert.Equal(t, "mapped.example.com", GetTrustDomainForClusterPair("cluster1", "cluster2"))
+	assert.Equal(t, "cluster2.example.com", GetTrustDomainForClusterPair("cluster3", "cluster2"))
+	assert.Equal(t, "prefix", GetTrustDomainForClusterPair("cluster2", "cluster1"))
+}
+
 func TestConfigManagement(t *testing.T) {
 	setupForConfigTests()
 
@@ -618,6 +636,8 @@ func TestGetResyncIntervals(t *testing.T) {
 	p := AdmiralParams{}
 	p.CacheReconcileDuration = time.Minute
 	p.SeAndDrCacheReconcileDuration = time.Minute
+	p.DeploymentReconcileDuration = time.Hour
+	p.VirtualServiceReconcileDuration = -1
 	ResetSync()
 	InitializeConfig(p)
 
@@ -625,6 +645,9 @@ func TestGetResyncIntervals(t *testing.T) {
 
 	assert.Equal(t, time.Minute, actual.SeAndDrReconcileInterval)
 	assert.Equal(t, time.Minute, actual.UniversalReconcileInterval)
+	assert.Equal(t, time.Hour, actual.DeploymentReconcileInterval)
+	assert.Equal(t, time.Duration(-1), actual.VirtualServiceReconcileInterval)
+	assert.Equal(t, time.Duration(0), actual.RolloutReconcileInterval)
 }
 
 func TestShouldPerformRollback(t *testing.T) {
@@ -775,3 +798,97 @@ func TestGetProcessVSCreatedBy(t *testing.T) {
 	}
 
 }
+
+func TestGetClusterAndEnvironmentTier(t *testing.T) {
+	p := AdmiralParams{
+		ClusterTiers:     map[string]string{"cluster1": "PROD", "*": "nonprod"},
+		EnvironmentTiers: map[string]string{"prd": "prod", "e2e": "nonprod"},
+	}
+	ResetSync()
+	InitializeConfig(p)
+
+	assert.Equal(t, "prod", GetClusterTier("cluster1"))
+	assert.Equal(t, "nonprod", GetClusterTier("cluster2"))
+	assert.Equal(t, "prod", GetEnvironmentTier("prd"))
+	assert.Equal(t, "prod", GetEnvironmentTier("prd-air"))
+	assert.Equal(t, "", GetEnvironmentTier("stage"))
+}
+
+func TestIsFeatureEnabledForCluster(t *testing.T) {
+	p := AdmiralParams{
+		ClusterCohorts:        map[string]string{"cluster1": "Canary", "cluster2": "canary"},
+		CohortFeatures:        map[string]string{CohortFeatureExportTo: "canary", CohortFeatureVSRouting: "canary|stable", "newFeature": "*"},
+		EnableVSRouting:       true,
+		EnableSWAwareNSCaches: true,
+		ExportToIdentityList:  []string{"*"},
+	}
+	ResetSync()
+	InitializeConfig(p)
+
+	assert.Equal(t, "canary", GetClusterCohort("cluster1"))
+	assert.Equal(t, StableClusterCohort, GetClusterCohort("cluster3"))
+	assert.True(t, IsFeatureEnabledForCluster(CohortFeatureExportTo, "cluster1"))
+	assert.False(t, IsFeatureEnabledForCluster(CohortFeatureExportTo, "cluster3"))
+	assert.True(t, IsFeatureEnabledForCluster(CohortFeatureVSRouting, "cluster3"))
+	assert.True(t, IsFeatureEnabledForCluster("newFeature", "cluster3"))
+	assert.True(t, IsFeatureEnabledForCluster("notRolledOut", "cluster3"))
+	assert.True(t, EnableExportToForCluster("stage.foo.global", "cluster2"))
+	assert.False(t, EnableExportToForCluster("stage.foo.global", "cluster3"))
+	assert.True(t, DoVSRoutingForCluster("cluster3"))
+
+	p.CohortFeatures = map[string]string{CohortFeatureVSRouting: "canary"}
+	ResetSync()
+	InitializeConfig(p)
+	assert.True(t, DoVSRoutingForCluster("cluster1"))
+	assert.False(t, DoVSRoutingForCluster("cluster3"))
+	assert.True(t, EnableExportToForCluster("stage.foo.global", "cluster3"))
+}
+
+func TestIsNamespaceFilteredByName(t *testing.T) {
+	p := AdmiralParams{
+		NamespaceAllowList: map[string]string{"cluster1": "payments-.*|orders", "cluster3": "("},
+		NamespaceDenyList:  map[string]string{"*": "test-.*|.*-perf", "cluster1": "payments-sandbox"},
+	}
+	ResetSync()
+	InitializeConfig(p)
+
+	assert.False(t, IsNamespaceFilteredByName("cluster1", "payments-api"))
+	assert.False(t, IsNamespaceFilteredByName("cluster1", "orders"))
+	assert.True(t, IsNamespaceFilteredByName("cluster1", "orders-api"))
+	assert.True(t, IsNamespaceFilteredByName("cluster1", "payments-sandbox"))
+	assert.False(t, IsNamespaceFilteredByName("cluster1", "payments-perf"))
+	assert.True(t, IsNamespaceFilteredByName("cluster2", "test-payments"))
+	assert.True(t, IsNamespaceFilteredByName("cluster2", "orders-perf"))
+	assert.False(t, IsNamespaceFilteredByName("cluster2", "orders"))
+	assert.False(t, IsNamespaceFilteredByName("cluster3", "orders"))
+}
+
+func TestGetSyncNamespaceForCluster(t *testing.T) {
+	p := AdmiralParams{
+		SyncNamespace:         "admiral-sync",
+		ClusterSyncNamespaces: map[string]string{"cluster1": "mesh-sync", "cluster2": "admiral-sync"},
+	}
+	ResetSync()
+	InitializeConfig(p)
+
+	assert.Equal(t, "mesh-sync", GetSyncNamespaceForCluster("cluster1"))
+	assert.Equal(t, "admiral-sync", GetSyncNamespaceForCluster("cluster3"))
+	assert.Equal(t, "", GetClusterSyncNamespace("cluster3"))
+	assert.ElementsMatch(t, []string{"admiral-sync", "mesh-sync"}, GetSyncNamespaces())
+	assert.True(t, IsSyncNamespace("mesh-sync"))
+	assert.False(t, IsSyncNamespace("payments"))
+}
+
+func TestGetVSRoutingGatewaysForClusterAndIdentity(t *testing.T) {
+	p := AdmiralParams{
+		VSRoutingGateways:         []string{"istio-system/passthrough-gateway"},
+		VSRoutingClusterGateways:  map[string]string{"cluster1": "istio-system/cluster1-gateway"},
+		VSRoutingIdentityGateways: map[string]string{"identity1": "tenant-a/east-west-gateway | tenant-a/backup-gateway"},
+	}
+	ResetSync()
+	InitializeConfig(p)
+
+	assert.Equal(t, []string{"tenant-a/east-west-gateway", "tenant-a/backup-gateway"}, GetVSRoutingGatewaysForClusterAndIdentity("cluster1", "identity1"))
+	assert.Equal(t, []string{"istio-system/cluster1-gateway"}, GetVSRoutingGatewaysForClusterAndIdentity("cluster1", "identity2"))
+	assert.Equal(t, []string{"istio-system/passthrough-gateway"}, GetVSRoutingGatewaysForClusterAndIdentity("cluster2", "identity2"))
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"container/list"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/monitoring"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+var cacheOverflowEvictions = monitoring.NewCounter(
+	"cache_overflow_evictions",
+	"total number of least recently used cache entries evicted as the cache was full")
+
+// LRUPolicy tracks the order in which the keys of a bounded cache were used, and tells the cache which keys
+// to evict once it holds more than its maximum size. It is not safe for concurrent use, the cache calls it
+// under its own lock. A nil policy bounds nothing
+type LRUPolicy struct {
+	name     string
+	maxSize  int
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+// NewLRUPolicy returns the policy of the cache named name, holding up to maxSize keys. It returns nil, which
+// bounds nothing, when maxSize is not positive
+func NewLRUPolicy(name string, maxSize int) *LRUPolicy {
+	if maxSize <= 0 {
+		return nil
+	}
+	return &LRUPolicy{
+		name:     name,
+		maxSize:  maxSize,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// Touch marks the key as the most recently used one, adding it when new, and returns the least recently
+// used keys the cache has to evict to stay within its maximum size
+func (p *LRUPolicy) Touch(key string) []string {
+	if p == nil {
+		return nil
+	}
+	if element, ok := p.elements[key]; ok {
+		p.order.MoveToFront(element)
+		return nil
+	}
+	p.elements[key] = p.order.PushFront(key)
+	var evicted []string
+	for p.order.Len() > p.maxSize {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.elements, oldest.Value.(string))
+		evicted = append(evicted, oldest.Value.(string))
+		cacheOverflowEvictions.Increment(api.WithAttributes(
+			attribute.Key("cache").String(p.name),
+		))
+	}
+	return evicted
+}
+
+// Used marks the key as the most recently used one when it is tracked
+func (p *LRUPolicy) Used(key string) {
+	if p == nil {
+		return
+	}
+	if element, ok := p.elements[key]; ok {
+		p.order.MoveToFront(element)
+	}
+}
+
+// Remove stops tracking the key
+func (p *LRUPolicy) Remove(key string) {
+	if p == nil {
+		return
+	}
+	if element, ok := p.elements[key]; ok {
+		p.order.Remove(element)
+		delete(p.elements, key)
+	}
+}
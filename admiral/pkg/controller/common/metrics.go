@@ -4,6 +4,10 @@ import "github.com/prometheus/client_golang/prometheus"
 
 const ClustersMonitoredMetricName = "clusters_monitored"
 const DependencyProxyServiceCacheSizeMetricName = "dependency_proxy_service_cache_size"
+const EventQueueDepthMetricName = "event_queue_depth"
+const CacheSizeMetricName = "cache_size"
+const CnameDependentClustersMetricName = "cache_cname_dependent_clusters"
+const ClusterIdentitiesMetricName = "cache_cluster_identities"
 
 type Gauge interface {
 	Set(value float64)
@@ -19,6 +23,21 @@ func NewGaugeFrom(name string, help string) Gauge {
 	return &PromGauge{g}
 }
 
+// GaugeVec is a set of gauges partitioned by the values of its labels
+type GaugeVec interface {
+	With(labelValues ...string) Gauge
+}
+
+func NewGaugeVecFrom(name string, help string, labelNames ...string) GaugeVec {
+	if !GetMetricsEnabled() {
+		return &Noop{}
+	}
+	opts := prometheus.GaugeOpts{Name: name, Help: help}
+	g := prometheus.NewGaugeVec(opts, labelNames)
+	prometheus.MustRegister(g)
+	return &PromGaugeVec{g}
+}
+
 type Noop struct{}
 
 type PromGauge struct {
@@ -29,4 +48,16 @@ func (g *PromGauge) Set(value float64) {
 	g.g.Set(value)
 }
 
+type PromGaugeVec struct {
+	g *prometheus.GaugeVec
+}
+
+func (g *PromGaugeVec) With(labelValues ...string) Gauge {
+	return &PromGauge{g.g.WithLabelValues(labelValues...)}
+}
+
 func (g *Noop) Set(value float64) {}
+
+func (g *Noop) With(labelValues ...string) Gauge {
+	return g
+}
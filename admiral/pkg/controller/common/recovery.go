@@ -0,0 +1,42 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/monitoring"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+var panicsRecovered = monitoring.NewCounter(
+	"panics_recovered",
+	"total number of panics recovered in the event handlers and the background goroutines")
+
+// RecoverPanic recovers from a panic of the calling goroutine, logs it along with its stack and the
+// txId of the context, and sets it as the error pointed to by err, when err is not nil, so the caller
+// can retry. It must be deferred directly
+func RecoverPanic(ctx context.Context, task string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	cluster := ""
+	if ctx != nil {
+		cluster, _ = ctx.Value(ClusterName).(string)
+	}
+	log.WithFields(log.Fields{"txId": GetTxId(ctx)}).Errorf(CtxLogFormat, task, "", "", cluster,
+		fmt.Sprintf("recovered from panic: %v\n%s", r, debug.Stack()))
+	panicsRecovered.Increment(api.WithAttributes(attribute.Key("task").String(task)))
+	if err != nil {
+		*err = fmt.Errorf("recovered from panic in task=%s: %v", task, r)
+	}
+}
+
+// CallWithRecovery calls fn and returns its error, or the panic it raised as an error
+func CallWithRecovery(ctx context.Context, task string, fn func() error) (err error) {
+	defer RecoverPanic(ctx, task, &err)
+	return fn()
+}
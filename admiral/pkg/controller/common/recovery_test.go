@@ -0,0 +1,61 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallWithRecovery(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fn            func() error
+		expectedError string
+	}{
+		{
+			name: "Given a function which succeeds, " +
+				"When CallWithRecovery is called, " +
+				"Then no error should be returned",
+			fn:            func() error { return nil },
+			expectedError: "",
+		},
+		{
+			name: "Given a function which fails, " +
+				"When CallWithRecovery is called, " +
+				"Then its error should be returned",
+			fn:            func() error { return errors.New("conflict") },
+			expectedError: "conflict",
+		},
+		{
+			name: "Given a function which panics, " +
+				"When CallWithRecovery is called, " +
+				"Then the panic should be returned as an error",
+			fn: func() error {
+				var m map[string]string
+				m["foo"] = "bar"
+				return nil
+			},
+			expectedError: "recovered from panic in task=test: assignment to entry in nil map",
+		},
+	}
+	ctx := WithTxId(context.WithValue(context.Background(), ClusterName, "cluster1"), "abc")
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			err := CallWithRecovery(ctx, "test", c.fn)
+			if c.expectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, c.expectedError)
+			}
+		})
+	}
+}
+
+func TestRecoverPanicWithoutError(t *testing.T) {
+	assert.NotPanics(t, func() {
+		defer RecoverPanic(nil, "test", nil)
+		panic("boom")
+	})
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	defaultWriteRetryAttempts = 5
+	// retryJitter is the fraction of the backoff added at random to it, so that the retries of the
+	// writes which failed together, such as those conflicting with each other, are spread out
+	retryJitter = 0.5
+)
+
+// RetryPolicy is the number of attempts of an operation, the backoff between them, and the
+// errors on which the operation is retried
+type RetryPolicy struct {
+	// Attempts is the maximum number of times the operation is called
+	Attempts int
+	// InitialBackoff is the delay before the second attempt, doubled before every following
+	// attempt up to MaxBackoff. There is no delay when it is 0
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter is the fraction of the backoff added at random to it
+	Jitter float64
+	// Retryable returns true when the operation is retried after the error, every error is
+	// retried when it is nil
+	Retryable func(error) bool
+}
+
+// IsRetryableWriteError returns true when a write to a cluster failed with a conflict, or an error
+// which is likely to be transient
+func IsRetryableWriteError(err error) bool {
+	return k8sErrors.IsConflict(err) ||
+		k8sErrors.IsServerTimeout(err) ||
+		k8sErrors.IsTimeout(err) ||
+		k8sErrors.IsTooManyRequests(err) ||
+		k8sErrors.IsServiceUnavailable(err)
+}
+
+// RetryWithBackoff calls fn, with the number of the attempt, until it succeeds, fails with an error which
+// is not retryable, the attempts of the policy are exhausted or the context is done, and returns
+// the error of the last attempt
+func RetryWithBackoff(ctx context.Context, policy RetryPolicy, fn func(attempt int) error) error {
+	if policy.Attempts < 1 {
+		return nil
+	}
+	return RetryAfterFirstAttempt(ctx, policy, fn(1), fn)
+}
+
+// RetryAfterFirstAttempt retries the operation whose first attempt, made by the caller, failed with err.
+// The first attempt counts in the attempts of the policy, so the operation is not retried when the policy
+// has a single attempt, and every retry waits for the backoff of the policy first
+func RetryAfterFirstAttempt(ctx context.Context, policy RetryPolicy, err error, fn func(attempt int) error) error {
+	for attempt := 2; attempt <= policy.Attempts; attempt++ {
+		if err == nil || (policy.Retryable != nil && !policy.Retryable(err)) {
+			return err
+		}
+		if backoff := policy.backoff(attempt - 1); backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+		}
+		err = fn(attempt)
+	}
+	return err
+}
+
+// backoff returns the delay before the retry, with the jitter added
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		backoff += time.Duration(rand.Float64() * p.Jitter * float64(backoff))
+	}
+	return backoff
+}
@@ -0,0 +1,160 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRetryWithBackoff(t *testing.T) {
+	conflictErr := k8sErrors.NewConflict(schema.GroupResource{}, "", nil)
+	otherErr := errors.New("some other error")
+	policy := RetryPolicy{Attempts: 3, Retryable: IsRetryableWriteError}
+
+	testCases := []struct {
+		name             string
+		errs             []error
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			name:             "Given an operation which succeeds, When it is retried, Then it is called once",
+			errs:             []error{nil},
+			expectedErr:      nil,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Given an operation which conflicts once, When it is retried, Then it is called until it succeeds",
+			errs:             []error{conflictErr, nil},
+			expectedErr:      nil,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "Given an operation which always conflicts, When it is retried, Then the attempts are exhausted and the error is returned",
+			errs:             []error{conflictErr, conflictErr, conflictErr},
+			expectedErr:      conflictErr,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "Given an operation which fails with an error which is not retryable, When it is retried, Then it is not called again",
+			errs:             []error{conflictErr, otherErr, nil},
+			expectedErr:      otherErr,
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			attempts := 0
+			err := RetryWithBackoff(context.Background(), policy, func(attempt int) error {
+				attempts++
+				assert.Equal(t, attempts, attempt)
+				return c.errs[attempt-1]
+			})
+			assert.Equal(t, c.expectedErr, err)
+			assert.Equal(t, c.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestRetryWithBackoffStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{Attempts: 3, InitialBackoff: time.Hour}
+	attempts := 0
+	err := RetryWithBackoff(ctx, policy, func(int) error {
+		attempts++
+		cancel()
+		return errors.New("some error")
+	})
+	assert.EqualError(t, err, "some error")
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryAfterFirstAttempt(t *testing.T) {
+	conflictErr := k8sErrors.NewConflict(schema.GroupResource{}, "", nil)
+
+	testCases := []struct {
+		name            string
+		attempts        int
+		firstErr        error
+		expectedRetries int
+	}{
+		{
+			name:            "Given a first attempt which succeeded, When it is retried, Then the operation is not called",
+			attempts:        3,
+			firstErr:        nil,
+			expectedRetries: 0,
+		},
+		{
+			name:            "Given a policy with a single attempt, When the first attempt conflicted, Then the operation is not called",
+			attempts:        1,
+			firstErr:        conflictErr,
+			expectedRetries: 0,
+		},
+		{
+			name:            "Given a policy with 3 attempts, When the operation always conflicts, Then it is called twice after the first attempt",
+			attempts:        3,
+			firstErr:        conflictErr,
+			expectedRetries: 2,
+		},
+		{
+			name:            "Given a first attempt which failed with an error which is not retryable, When it is retried, Then the operation is not called",
+			attempts:        3,
+			firstErr:        errors.New("some other error"),
+			expectedRetries: 0,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			policy := RetryPolicy{Attempts: c.attempts, InitialBackoff: 10 * time.Millisecond, Retryable: IsRetryableWriteError}
+			var calls []time.Time
+			start := time.Now()
+			err := RetryAfterFirstAttempt(context.Background(), policy, c.firstErr, func(attempt int) error {
+				calls = append(calls, time.Now())
+				assert.Equal(t, len(calls)+1, attempt)
+				return conflictErr
+			})
+			assert.Len(t, calls, c.expectedRetries)
+			if c.expectedRetries == 0 {
+				assert.Equal(t, c.firstErr, err)
+			}
+			// every retry waits for the backoff first
+			previous := start
+			for i, call := range calls {
+				assert.GreaterOrEqual(t, call.Sub(previous), policy.backoff(i+1))
+				previous = call
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(50))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		backoff := policy.backoff(2)
+		assert.GreaterOrEqual(t, backoff, 200*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 300*time.Millisecond)
+	}
+}
+
+func TestIsRetryableWriteError(t *testing.T) {
+	assert.True(t, IsRetryableWriteError(k8sErrors.NewConflict(schema.GroupResource{}, "", nil)))
+	assert.True(t, IsRetryableWriteError(k8sErrors.NewTooManyRequests("", 1)))
+	assert.True(t, IsRetryableWriteError(k8sErrors.NewServiceUnavailable("")))
+	assert.False(t, IsRetryableWriteError(k8sErrors.NewNotFound(schema.GroupResource{}, "")))
+	assert.False(t, IsRetryableWriteError(errors.New("some error")))
+	assert.False(t, IsRetryableWriteError(nil))
+}
@@ -199,3 +199,34 @@ func GetEnvForRollout(rollout *argo.Rollout) string {
 	}
 	return environment
 }
+
+// GetCanaryIstioVirtualServices returns the VirtualServices the canary strategy shapes traffic with, the
+// one referenced by virtualService followed by the ones referenced by virtualServices
+func GetCanaryIstioVirtualServices(rolloutStrategy argo.RolloutStrategy) []argo.IstioVirtualService {
+	if rolloutStrategy.Canary == nil ||
+		rolloutStrategy.Canary.TrafficRouting == nil ||
+		rolloutStrategy.Canary.TrafficRouting.Istio == nil {
+		return nil
+	}
+	istio := rolloutStrategy.Canary.TrafficRouting.Istio
+	virtualServices := make([]argo.IstioVirtualService, 0, len(istio.VirtualServices)+1)
+	if istio.VirtualService != nil && istio.VirtualService.Name != "" {
+		virtualServices = append(virtualServices, *istio.VirtualService)
+	}
+	for _, virtualService := range istio.VirtualServices {
+		if virtualService.Name != "" {
+			virtualServices = append(virtualServices, virtualService)
+		}
+	}
+	return virtualServices
+}
+
+// IsCanaryALBOrSMIStrategy checks if the canary strategy of the rollout splits traffic with an ALB ingress or
+// an SMI TrafficSplit, the weights of which are only recorded in the status of the rollout
+func IsCanaryALBOrSMIStrategy(rollout *argo.Rollout) bool {
+	if rollout == nil || rollout.Spec.Strategy.Canary == nil || rollout.Spec.Strategy.Canary.TrafficRouting == nil {
+		return false
+	}
+	trafficRouting := rollout.Spec.Strategy.Canary.TrafficRouting
+	return trafficRouting.Istio == nil && (trafficRouting.ALB != nil || trafficRouting.SMI != nil)
+}
@@ -565,3 +565,88 @@ func TestGetCnameForRollout(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCanaryIstioVirtualServices(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy argo.RolloutStrategy
+		expected []string
+	}{
+		{
+			name:     "should return nothing for a blue-green strategy",
+			strategy: argo.RolloutStrategy{BlueGreen: &argo.BlueGreenStrategy{}},
+		},
+		{
+			name: "should return nothing for a canary strategy without istio traffic routing",
+			strategy: argo.RolloutStrategy{Canary: &argo.CanaryStrategy{
+				TrafficRouting: &argo.RolloutTrafficRouting{},
+			}},
+		},
+		{
+			name: "should return the virtualService followed by the virtualServices",
+			strategy: argo.RolloutStrategy{Canary: &argo.CanaryStrategy{
+				TrafficRouting: &argo.RolloutTrafficRouting{Istio: &argo.IstioTrafficRouting{
+					VirtualService:  &argo.IstioVirtualService{Name: "vs1"},
+					VirtualServices: []argo.IstioVirtualService{{Name: "vs2", Routes: []string{"primary"}}, {Name: ""}, {Name: "vs3"}},
+				}},
+			}},
+			expected: []string{"vs1", "vs2", "vs3"},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			var names []string
+			for _, virtualService := range GetCanaryIstioVirtualServices(c.strategy) {
+				names = append(names, virtualService.Name)
+			}
+			if !reflect.DeepEqual(names, c.expected) {
+				t.Errorf("Wanted VirtualServices: %v, got: %v", c.expected, names)
+			}
+		})
+	}
+}
+
+func TestIsCanaryALBOrSMIStrategy(t *testing.T) {
+	canary := func(trafficRouting *argo.RolloutTrafficRouting) *argo.Rollout {
+		return &argo.Rollout{Spec: argo.RolloutSpec{Strategy: argo.RolloutStrategy{
+			Canary: &argo.CanaryStrategy{TrafficRouting: trafficRouting},
+		}}}
+	}
+	testCases := []struct {
+		name     string
+		rollout  *argo.Rollout
+		expected bool
+	}{
+		{
+			name:    "should return false for a nil rollout",
+			rollout: nil,
+		},
+		{
+			name:    "should return false for a canary without traffic routing",
+			rollout: canary(nil),
+		},
+		{
+			name:    "should return false for istio traffic routing",
+			rollout: canary(&argo.RolloutTrafficRouting{Istio: &argo.IstioTrafficRouting{}}),
+		},
+		{
+			name:     "should return true for alb traffic routing",
+			rollout:  canary(&argo.RolloutTrafficRouting{ALB: &argo.ALBTrafficRouting{Ingress: "foo-ingress"}}),
+			expected: true,
+		},
+		{
+			name:     "should return true for smi traffic routing",
+			rollout:  canary(&argo.RolloutTrafficRouting{SMI: &argo.SMITrafficRouting{}}),
+			expected: true,
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := IsCanaryALBOrSMIStrategy(c.rollout); got != c.expected {
+				t.Errorf("Wanted: %v, got: %v", c.expected, got)
+			}
+		})
+	}
+}
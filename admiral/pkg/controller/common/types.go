@@ -49,7 +49,16 @@ type AdmiralParams struct {
 	SecretFilterTags                                 string
 	CacheReconcileDuration                           time.Duration
 	SeAndDrCacheReconcileDuration                    time.Duration
+	DeploymentReconcileDuration                      time.Duration
+	RolloutReconcileDuration                         time.Duration
+	NodeReconcileDuration                            time.Duration
+	ServiceReconcileDuration                         time.Duration
+	ServiceEntryReconcileDuration                    time.Duration
+	DestinationRuleReconcileDuration                 time.Duration
+	VirtualServiceReconcileDuration                  time.Duration
+	SidecarReconcileDuration                         time.Duration
 	ClusterRegistriesNamespace                       string
+	ClusterWarmupParallelism                         int
 	DependenciesNamespace                            string
 	DnsConfigFile                                    string
 	DNSTimeoutMs                                     int
@@ -62,6 +71,9 @@ type AdmiralParams struct {
 	Profile                                          string
 	LabelSet                                         *LabelSet
 	LogLevel                                         int
+	ModuleLogLevels                                  map[string]string
+	LogSampling                                      map[string]string
+	CacheMetricsInterval                             time.Duration
 	HostnameSuffix                                   string
 	PreviewHostnamePrefix                            string
 	MetricsEnabled                                   bool
@@ -105,6 +117,7 @@ type AdmiralParams struct {
 	InitiateClientInitiatedProcessingFor             []string
 	ExportToIdentityList                             []string
 	ExportToMaxNamespaces                            int
+	ExportToFallbackNamespace                        string
 	EnableSyncIstioResourcesToSourceClusters         bool
 	DefaultWarmupDurationSecs                        int64
 	EnableGenerationCheck                            bool
@@ -141,6 +154,8 @@ type AdmiralParams struct {
 	// VS Based Routing
 	EnableVSRouting                   bool
 	VSRoutingGateways                 []string
+	VSRoutingClusterGateways          map[string]string
+	VSRoutingIdentityGateways         map[string]string
 	IngressVSExportToNamespaces       []string
 	IngressLBPolicy                   string
 	VSRoutingDisabledClusters         []string
@@ -168,9 +183,258 @@ type AdmiralParams struct {
 	NLBEnabledIdentityList []string
 	CLBEnabledClusters     []string
 	NLBIngressLabel        string
+	// LBMigrationSoakDuration is how long the endpoints of both the old and the new load balancer
+	// are kept in the service entries when a cluster switches load balancers
+	LBMigrationSoakDuration time.Duration
+
+	// Cross trust domain federation
+	ClusterTrustDomains map[string]string
+	TrustDomainMappings map[string]string
+	// SpiffeIdentityClusters are the clusters where the identity of the workloads without the
+	// workload identifier label is resolved from their SPIFFE ID
+	SpiffeIdentityClusters []string
+
+	// Ingress health checks
+	IngressHealthCheckInterval         time.Duration
+	IngressHealthCheckTimeout          time.Duration
+	IngressHealthCheckFailureThreshold int
+	IngressHealthCheckTLS              bool
+	IngressSecondaryEndpoints          map[string]string
+
+	// Federation with other meshes
+	MeshFederationName           string
+	MeshFederationImports        map[string]string
+	MeshFederationImportInterval time.Duration
+
+	// Discovery sources outside of the mesh clusters
+	DiscoverySourceType         string
+	DiscoverySourceAddress      string
+	DiscoverySourceSyncInterval time.Duration
+
+	// DNS records of the generated cnames
+	DNSProvider            string
+	DNSRoute53HostedZoneID string
+	DNSRoute53Role         string
+	DNSRoute53Region       string
+	DNSEndpointNamespace   string
+	DNSRecordTTL           int64
+	DNSRecordSyncInterval  time.Duration
+
+	// Wildcard and selector based dependency destinations
+	MaxDestinationExpansion       int
+	DestinationResolutionInterval time.Duration
+
+	// Dependency discovery from the mesh telemetry
+	DependencyDiscoverySourceType string
+	DependencyDiscoveryAddress    string
+	DependencyDiscoveryMode       string
+	DependencyDiscoveryWindow     time.Duration
+	DependencyDiscoveryInterval   time.Duration
+
+	// Coalescing of the workload events of an identity
+	EventCoalescingWindow time.Duration
+	IdentityBatchWindow   time.Duration
 
 	// Slow Start
 	EnableTrafficConfigProcessingForSlowStart bool
+
+	// Throttling of the clients from the quotas of the TrafficConfigs
+	EnableTrafficConfigThrottling  bool
+	ThrottlingClientIdentityHeader string
+
+	// Cluster tier enforcement
+	EnableClusterTierEnforcement bool
+	ClusterTiers                 map[string]string
+	EnvironmentTiers             map[string]string
+
+	// Naming of the VirtualServices synced to other clusters
+	VSNameStrategy string
+
+	// Progressive rollout of features per cluster cohort
+	ClusterCohorts map[string]string
+	CohortFeatures map[string]string
+
+	// Namespace filtering
+	NamespaceAllowList     map[string]string
+	NamespaceDenyList      map[string]string
+	NamespaceDenySelectors map[string]string
+
+	// Per cluster sync namespaces
+	ClusterSyncNamespaces map[string]string
+
+	// Per identity sync namespaces
+	EnableIdentitySyncNamespace   bool
+	AllowedIdentitySyncNamespaces []string
+
+	// Per identity TLS settings of DestinationRules
+	EnableIdentityClientTLSSettings bool
+
+	// Per identity warmup duration of DestinationRules
+	EnableIdentityWarmupDuration bool
+
+	// Locality failover priority lists of DestinationRules
+	EnableFailoverPriority bool
+
+	// Admission webhooks
+	EnableAdmissionWebhook   bool
+	AdmissionWebhookPort     int
+	AdmissionWebhookCertFile string
+	AdmissionWebhookKeyFile  string
+
+	// Policies evaluated before applying generated resources
+	ResourcePolicyEvaluator           string
+	WildcardExportToDeniedClusters    []string
+	EnableGeneratedResourceValidation bool
+
+	// Audit log of the mutations admiral performs
+	AuditSink string
+
+	// Kubernetes events for sync failures and decisions
+	EnableK8sEvents bool
+
+	// Readiness of the informer caches of the registered clusters
+	ReadinessRequiresCacheSync bool
+
+	// Barrier holding the writes at startup until the caches are built
+	StartupBarrierTimeout time.Duration
+
+	// Sharing of the identical specs of the resources of the clusters
+	EnableSpecInterning bool
+
+	// Merge patches instead of updates for the synced resources
+	EnableMergePatchUpdates bool
+
+	// Retries of the writes to the clusters
+	WriteRetryAttempts       int
+	WriteRetryInitialBackoff time.Duration
+	WriteRetryMaxBackoff     time.Duration
+
+	// Lazy start and idle teardown of the istio controllers of the clusters
+	EnableLazyIstioControllers  bool
+	IstioControllersIdleTimeout time.Duration
+
+	// Probing of the unreachable clusters the writes to were skipped for
+	DeadClusterProbeInterval time.Duration
+
+	// Ownership of the resources admiral writes
+	EnableOwnershipLabels bool
+	AdmiralInstanceName   string
+
+	// Finalizers holding the deletion of the source resources until their copies are removed
+	EnableSourceFinalizers bool
+
+	// Deferred deletion of the ServiceEntries of the workloads which are gone
+	ServiceEntryDeletionGracePeriod time.Duration
+
+	// Pruning of the generated resources which no longer have a source
+	OrphanPruningInterval time.Duration
+	OrphanPruningDryRun   bool
+
+	// Output of the generated resources to a GitOps repository instead of the clusters
+	GitOpsRepoPath       string
+	GitOpsCommitInterval time.Duration
+	GitOpsPushBranch     string
+
+	// Coexistence with the GitOps controllers managing the sync namespaces
+	GeneratedResourceAnnotations map[string]string
+	ExternalRevertThreshold      int
+
+	// Diff-only mode, recording the changes instead of applying them
+	DiffOnlyMode bool
+
+	// History of the configuration generated per identity, to roll it back
+	ConfigHistorySize    int
+	PersistConfigHistory bool
+
+	// Locks serializing the processing of each identity, and their watchdog
+	EnableIdentityLock        bool
+	IdentityLockHoldThreshold time.Duration
+
+	// Periodic full refreshes of the resources of the identities, and their parallelism
+	FullRefreshInterval    time.Duration
+	FullRefreshConcurrency int
+
+	// Eviction of the cache entries of the identities gone from all the clusters
+	CacheEntryTTL time.Duration
+
+	// Bound of the resource caches of the clusters, the least recently used entries being evicted beyond
+	ResourceCacheMaxSize int
+
+	// Persistence of the dependent clusters of the cnames to the registry in state syncer mode
+	DependentClusterPersistInterval time.Duration
+
+	// Consistency checks of the admiral cache against the informer stores
+	CacheConsistencyCheckInterval time.Duration
+	CacheConsistencyRepair        bool
+
+	// Read API of the cache state scoped by tenant
+	TenantTokenFile string
+
+	// Tenancy of the identities to teams
+	TeamLabel            string
+	TeamIdentityPrefixes map[string]string
+
+	// Quotas on the resources generated per identity
+	QuotaMaxHostsPerIdentity      int
+	QuotaMaxCustomVirtualServices int
+
+	// Per tenant rate limits of the event processing
+	TenantEventRateLimit float64
+	TenantEventBurst     int
+
+	// Authentication and authorization of the callers of the APIs
+	AuthMethods      []string
+	AuthRoleBindings map[string]string
+	APICertFile      string
+	APIKeyFile       string
+	APIClientCAFile  string
+
+	// Weighted distribution of the traffic between the regions of the GTPs
+	EnableGTPWeightedEndpoints bool
+
+	// Gradual shifts of the traffic of the GTPs
+	TrafficShiftCheckInterval time.Duration
+	TrafficShiftStepInterval  time.Duration
+
+	// Activation windows of the GTPs
+	GtpWindowCheckInterval time.Duration
+
+	// Dry-run of the GTPs on admission
+	EnableGTPAdmissionDryRun bool
+
+	// Default traffic policies of the org
+	OrgTrafficPolicyNamespace string
+
+	// Notifications for high severity conditions
+	NotifierType                    string
+	NotifierEndpoint                string
+	NotificationInterval            time.Duration
+	ClusterUnreachableAlertDuration time.Duration
+	GarbageCollectionAlertThreshold int
+
+	// gRPC introspection API
+	EnableIntrospectionServer bool
+	IntrospectionPort         int
+	IntrospectionTokenFile    string
+	IntrospectionCertFile     string
+	IntrospectionKeyFile      string
+	IdentityChangelogSize     int
+
+	// Maintenance mode
+	MaintenanceJournalSize int
+
+	// Stabilization window for the endpoints of workloads being rolled out
+	WorkloadStabilizationWindow time.Duration
+
+	// Service entries with all the mesh ports of a service
+	EnableMultiPortServiceEntry bool
+	// Service entries with an endpoint per ingress load balancer of a cluster
+	EnableMultipleIngressEndpoints bool
+
+	// East-west gateway discovery
+	EastWestGatewaySelector  map[string]string
+	EastWestGatewayNamespace string
+	EastWestGatewayCacheTTL  time.Duration
 }
 
 func (b AdmiralParams) String() string {
@@ -178,6 +442,7 @@ func (b AdmiralParams) String() string {
 		fmt.Sprintf("CacheRefreshDuration=%v ", b.CacheReconcileDuration) +
 		fmt.Sprintf("SEAndDRCacheRefreshDuration=%v ", b.SeAndDrCacheReconcileDuration) +
 		fmt.Sprintf("ClusterRegistriesNamespace=%v ", b.ClusterRegistriesNamespace) +
+		fmt.Sprintf("ClusterWarmupParallelism=%v ", b.ClusterWarmupParallelism) +
 		fmt.Sprintf("DependenciesNamespace=%v ", b.DependenciesNamespace) +
 		fmt.Sprintf("EnableSAN=%v ", b.EnableSAN) +
 		fmt.Sprintf("SANPrefix=%v ", b.SANPrefix) +
@@ -416,6 +681,12 @@ func (s *MapOfMapOfMaps) Get(key string) *MapOfMaps {
 	return val
 }
 
+func (s *MapOfMapOfMaps) Delete(key string) {
+	defer s.mutex.Unlock()
+	s.mutex.Lock()
+	delete(s.cache, key)
+}
+
 func (s *MapOfMapOfMaps) Len() int {
 	defer s.mutex.RUnlock()
 	s.mutex.RLock()
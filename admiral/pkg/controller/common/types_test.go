@@ -415,3 +415,22 @@ func TestProxiedServiceEnvironment_String(t *testing.T) {
 		t.Errorf("expected=%v, got=%v", expected, pse.String())
 	}
 }
+
+func TestLRUPolicy(t *testing.T) {
+	lru := NewLRUPolicy("test", 2)
+	assert.Empty(t, lru.Touch("a"))
+	assert.Empty(t, lru.Touch("b"))
+	// a is used, so b is the least recently used one when c is added
+	lru.Used("a")
+	assert.Equal(t, []string{"b"}, lru.Touch("c"))
+
+	// a removed key frees its room
+	lru.Remove("a")
+	assert.Empty(t, lru.Touch("d"))
+	assert.Equal(t, []string{"c"}, lru.Touch("e"))
+
+	// not bounded when the maximum size is not positive
+	assert.Nil(t, NewLRUPolicy("test", 0))
+	var unbounded *LRUPolicy
+	assert.Empty(t, unbounded.Touch("a"))
+}
@@ -55,12 +55,14 @@ type DestinationRuleItem struct {
 type DestinationRuleCache struct {
 	cache map[string]*DestinationRuleItem
 	mutex *sync.RWMutex
+	lru   *common.LRUPolicy
 }
 
 func NewDestinationRuleCache() *DestinationRuleCache {
 	return &DestinationRuleCache{
 		cache: map[string]*DestinationRuleItem{},
 		mutex: &sync.RWMutex{},
+		lru:   common.NewLRUPolicy("destinationRule", common.GetResourceCacheMaxSize()),
 	}
 }
 
@@ -82,6 +84,9 @@ func (d *DestinationRuleCache) Put(dr *networking.DestinationRule) {
 		DestinationRule: dr,
 		Status:          common.ProcessingInProgress,
 	}
+	for _, evicted := range d.lru.Touch(key) {
+		delete(d.cache, evicted)
+	}
 }
 
 func (d *DestinationRuleCache) Get(identity string, namespace string) *networking.DestinationRule {
@@ -90,6 +95,7 @@ func (d *DestinationRuleCache) Get(identity string, namespace string) *networkin
 
 	drItem, ok := d.cache[makeKey(identity, namespace)]
 	if ok {
+		d.lru.Used(makeKey(identity, namespace))
 		return drItem.DestinationRule
 	}
 
@@ -106,6 +112,7 @@ func (d *DestinationRuleCache) Delete(dr *networking.DestinationRule) {
 	if ok {
 		delete(d.cache, key)
 	}
+	d.lru.Remove(key)
 }
 
 func (d *DestinationRuleCache) GetDRProcessStatus(dr *networking.DestinationRule) string {
@@ -147,6 +154,7 @@ func NewDestinationRuleController(stopCh <-chan struct{}, handler DestinationRul
 	drCache := DestinationRuleCache{}
 	drCache.cache = make(map[string]*DestinationRuleItem)
 	drCache.mutex = &sync.RWMutex{}
+	drCache.lru = common.NewLRUPolicy("destinationRule", common.GetResourceCacheMaxSize())
 	drController.Cache = &drCache
 
 	drController.Cluster = clusterID
@@ -161,6 +169,10 @@ func NewDestinationRuleController(stopCh <-chan struct{}, handler DestinationRul
 	drController.IstioClient = ic
 
 	drController.informer = informers.NewDestinationRuleInformer(ic, k8sV1.NamespaceAll, resyncPeriod, cache.Indexers{})
+	err = setSpecInterningTransform(drController.informer, config.Host, common.DestinationRuleResourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set the spec interning of the destination rule informer: %v", err)
+	}
 
 	admiral.NewController("destinationrule-ctrl", config.Host, stopCh, &drController, drController.informer)
 
@@ -225,5 +237,5 @@ func (drc *DestinationRuleController) Get(ctx context.Context, isRetry bool, obj
 	if ok && d.IstioClient != nil {
 		return d.IstioClient.NetworkingV1alpha3().DestinationRules(dr.Namespace).Get(ctx, dr.Name, meta_v1.GetOptions{})
 	}*/
-	return nil, fmt.Errorf("istio client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("istio client is not initialized, txId=%s", common.GetTxId(ctx))
 }
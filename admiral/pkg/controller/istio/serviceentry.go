@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"strings"
 	"sync"
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/client/loader"
@@ -51,12 +52,14 @@ type ServiceEntryItem struct {
 type ServiceEntryCache struct {
 	cache map[string]map[string]*ServiceEntryItem
 	mutex *sync.RWMutex
+	lru   *common.LRUPolicy
 }
 
 func NewServiceEntryCache() *ServiceEntryCache {
 	return &ServiceEntryCache{
 		cache: map[string]map[string]*ServiceEntryItem{},
 		mutex: &sync.RWMutex{},
+		lru:   common.NewLRUPolicy("serviceEntry", common.GetResourceCacheMaxSize()),
 	}
 }
 
@@ -85,6 +88,10 @@ func (d *ServiceEntryCache) Put(se *networking.ServiceEntry, cluster string) {
 	}
 
 	d.cache[cluster] = seInCluster
+	for _, evicted := range d.lru.Touch(makeKey(cluster, key)) {
+		separator := strings.LastIndex(evicted, "/")
+		delete(d.cache[evicted[:separator]], evicted[separator+1:])
+	}
 }
 
 func (d *ServiceEntryCache) Get(identity string, cluster string) *networking.ServiceEntry {
@@ -95,6 +102,7 @@ func (d *ServiceEntryCache) Get(identity string, cluster string) *networking.Ser
 	if ok {
 		se, ok := seInCluster[identity]
 		if ok {
+			d.lru.Used(makeKey(cluster, identity))
 			return se.ServiceEntry
 		}
 	}
@@ -109,6 +117,7 @@ func (d *ServiceEntryCache) Delete(se *networking.ServiceEntry, cluster string)
 	if ok {
 		delete(seInCluster, d.getKey(se))
 	}
+	d.lru.Remove(makeKey(cluster, d.getKey(se)))
 }
 
 func (d *ServiceEntryCache) GetSEProcessStatus(se *networking.ServiceEntry, cluster string) string {
@@ -153,6 +162,7 @@ func NewServiceEntryController(stopCh <-chan struct{}, handler ServiceEntryHandl
 	seCache := ServiceEntryCache{}
 	seCache.cache = make(map[string]map[string]*ServiceEntryItem)
 	seCache.mutex = &sync.RWMutex{}
+	seCache.lru = common.NewLRUPolicy("serviceEntry", common.GetResourceCacheMaxSize())
 	seController.Cache = &seCache
 
 	seController.Cluster = clusterID
@@ -167,6 +177,10 @@ func NewServiceEntryController(stopCh <-chan struct{}, handler ServiceEntryHandl
 	seController.IstioClient = ic
 
 	seController.informer = informers.NewServiceEntryInformer(ic, k8sV1.NamespaceAll, resyncPeriod, cache.Indexers{})
+	err = setSpecInterningTransform(seController.informer, config.Host, common.ServiceEntryResourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set the spec interning of the service entry informer: %v", err)
+	}
 
 	admiral.NewController("serviceentry-ctrl", config.Host, stopCh, &seController, seController.informer)
 
@@ -232,5 +246,5 @@ func (sec *ServiceEntryController) Get(ctx context.Context, isRetry bool, obj in
 	if ok && sec.IstioClient != nil {
 		return sec.IstioClient.NetworkingV1alpha3().ServiceEntries(se.Namespace).Get(ctx, se.Name, meta_v1.GetOptions{})
 	}*/
-	return nil, fmt.Errorf("istio client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("istio client is not initialized, txId=%s", common.GetTxId(ctx))
 }
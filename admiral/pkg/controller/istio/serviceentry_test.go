@@ -402,3 +402,27 @@ func TestServiceEntryLogValueOfAdmiralIoIgnore(t *testing.T) {
 	sec.LogValueOfAdmiralIoIgnore(se)
 	// No error should occur
 }
+
+func TestBoundedServiceEntryCache(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, ResourceCacheMaxSize: 2})
+	defer common.ResetSync()
+	seCache := NewServiceEntryCache()
+	seA := &networking.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "a"}}
+	seB := &networking.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "b"}}
+	seC := &networking.ServiceEntry{ObjectMeta: v1.ObjectMeta{Name: "c"}}
+	seCache.Put(seA, "cluster1")
+	seCache.Put(seB, "cluster2")
+	// a is used, so b is the least recently used one when c is added
+	assert.NotNil(t, seCache.Get("a", "cluster1"))
+	seCache.Put(seC, "cluster1")
+	assert.Nil(t, seCache.Get("b", "cluster2"))
+	assert.NotNil(t, seCache.Get("a", "cluster1"))
+	assert.NotNil(t, seCache.Get("c", "cluster1"))
+
+	// a deleted service entry frees its room
+	seCache.Delete(seA, "cluster1")
+	seCache.Put(seB, "cluster2")
+	assert.NotNil(t, seCache.Get("b", "cluster2"))
+	assert.NotNil(t, seCache.Get("c", "cluster1"))
+}
@@ -105,5 +105,5 @@ func (sec *SidecarController) Get(ctx context.Context, isRetry bool, obj interfa
 	if ok && sec.IstioClient != nil {
 		return sec.IstioClient.NetworkingV1alpha3().Sidecars(sidecar.Namespace).Get(ctx, sidecar.Name, meta_v1.GetOptions{})
 	}*/
-	return nil, fmt.Errorf("istio client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("istio client is not initialized, txId=%s", common.GetTxId(ctx))
 }
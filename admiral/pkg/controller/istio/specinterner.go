@@ -0,0 +1,153 @@
+package istio
+
+import (
+	"crypto/sha256"
+	"reflect"
+	"sync"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	networking "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/client-go/tools/cache"
+)
+
+// specInterner hash-conses the specs of the istio resources, so that the specs which are byte identical
+// across the clusters share a single copy in the informer caches, and the caches built from them,
+// instead of holding one copy per cluster. The specs of the cached resources are never modified in place,
+// which is what makes it safe for the resources of several clusters to share them
+type specInterner struct {
+	mutex  sync.Mutex
+	specs  map[[sha256.Size]byte]*internedSpec
+	owners map[string]specOwner
+}
+
+// internedSpec is the spec shared by the resources holding an identical spec, along with their number
+type internedSpec struct {
+	spec proto.Message
+	refs int
+}
+
+// specOwner is the interned spec of a resource, and the spec the resource held when it was interned
+type specOwner struct {
+	hash [sha256.Size]byte
+	spec proto.Message
+}
+
+func newSpecInterner() *specInterner {
+	return &specInterner{
+		specs:  make(map[[sha256.Size]byte]*internedSpec),
+		owners: make(map[string]specOwner),
+	}
+}
+
+// interner is shared by the controllers of every cluster
+var interner = newSpecInterner()
+
+// intern points the fields of the spec of the owner to those of the identical spec interned earlier, or
+// interns the spec when there is none
+func (s *specInterner) intern(owner string, spec proto.Message) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(spec)
+	if err != nil {
+		log.Warnf(common.CtxLogFormat, "InternSpec", owner, "", "", "failed to hash the spec: "+err.Error())
+		return
+	}
+	hash := sha256.Sum256(append([]byte(proto.MessageName(spec)), data...))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if previous, ok := s.owners[owner]; ok {
+		// the same resource is passed again when the informer relists or resyncs
+		if previous.spec == spec {
+			return
+		}
+		s.releaseLocked(owner)
+	}
+	entry, ok := s.specs[hash]
+	if ok {
+		shareFields(spec, entry.spec)
+	} else {
+		entry = &internedSpec{spec: spec}
+		s.specs[hash] = entry
+	}
+	entry.refs++
+	s.owners[owner] = specOwner{hash: hash, spec: spec}
+}
+
+// release forgets the spec of the owner, the interned spec is dropped once no resource holds it
+func (s *specInterner) release(owner string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.releaseLocked(owner)
+}
+
+func (s *specInterner) releaseLocked(owner string) {
+	previous, ok := s.owners[owner]
+	if !ok {
+		return
+	}
+	delete(s.owners, owner)
+	entry := s.specs[previous.hash]
+	entry.refs--
+	if entry.refs <= 0 {
+		delete(s.specs, previous.hash)
+	}
+}
+
+// len returns the number of distinct specs interned
+func (s *specInterner) len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.specs)
+}
+
+// shareFields points the exported fields of dst to those of src, which holds an identical spec, so that
+// the memory of the fields of dst can be reclaimed
+func shareFields(dst, src proto.Message) {
+	dstValue, srcValue := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < dstValue.NumField(); i++ {
+		if dstValue.Type().Field(i).IsExported() {
+			dstValue.Field(i).Set(srcValue.Field(i))
+		}
+	}
+}
+
+// setSpecInterningTransform interns the specs of the resources of the cluster before they are stored in the
+// informer cache, when spec interning is enabled
+func setSpecInterningTransform(informer cache.SharedIndexInformer, cluster string, resourceType common.ResourceType) error {
+	if !common.EnableSpecInterning() {
+		return nil
+	}
+	ownerOf := func(obj interface{}) (string, bool) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return "", false
+		}
+		return cluster + "/" + string(resourceType) + "/" + key, true
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if owner, ok := ownerOf(obj); ok {
+				interner.release(owner)
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return informer.SetTransform(func(obj interface{}) (interface{}, error) {
+		owner, ok := ownerOf(obj)
+		if !ok {
+			return obj, nil
+		}
+		switch resource := obj.(type) {
+		case *networking.ServiceEntry:
+			interner.intern(owner, &resource.Spec)
+		case *networking.VirtualService:
+			interner.intern(owner, &resource.Spec)
+		case *networking.DestinationRule:
+			interner.intern(owner, &resource.Spec)
+		}
+		return obj, nil
+	})
+}
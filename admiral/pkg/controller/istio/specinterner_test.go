@@ -0,0 +1,87 @@
+package istio
+
+import (
+	"context"
+	"testing"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	"istio.io/api/networking/v1alpha3"
+	networking "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istioFake "istio.io/client-go/pkg/clientset/versioned/fake"
+	informers "istio.io/client-go/pkg/informers/externalversions/networking/v1alpha3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newInternTestServiceEntry(hosts ...string) *networking.ServiceEntry {
+	return &networking.ServiceEntry{
+		ObjectMeta: v1.ObjectMeta{Name: "stage.foo.global-se", Namespace: "sync"},
+		Spec: v1alpha3.ServiceEntry{
+			Hosts:     hosts,
+			Addresses: []string{"240.0.10.1"},
+			Ports:     []*v1alpha3.ServicePort{{Number: 80, Protocol: "http", Name: "http"}},
+		},
+	}
+}
+
+func TestSpecInterner(t *testing.T) {
+	s := newSpecInterner()
+	se1 := newInternTestServiceEntry("stage.foo.global")
+	se2 := newInternTestServiceEntry("stage.foo.global")
+	se3 := newInternTestServiceEntry("stage.bar.global")
+
+	s.intern("cluster1/ServiceEntry/sync/stage.foo.global-se", &se1.Spec)
+	s.intern("cluster2/ServiceEntry/sync/stage.foo.global-se", &se2.Spec)
+	// the identical specs share their fields
+	assert.Equal(t, 1, s.len())
+	assert.Same(t, se1.Spec.Ports[0], se2.Spec.Ports[0])
+	assert.Same(t, &se1.Spec.Hosts[0], &se2.Spec.Hosts[0])
+
+	s.intern("cluster3/ServiceEntry/sync/stage.foo.global-se", &se3.Spec)
+	assert.Equal(t, 2, s.len())
+	assert.NotSame(t, se1.Spec.Ports[0], se3.Spec.Ports[0])
+
+	// passing the same resource again does not change the interned specs
+	s.intern("cluster3/ServiceEntry/sync/stage.foo.global-se", &se3.Spec)
+	assert.Equal(t, 2, s.len())
+
+	// the interned spec is dropped once no resource holds it
+	s.release("cluster3/ServiceEntry/sync/stage.foo.global-se")
+	assert.Equal(t, 1, s.len())
+	s.release("cluster1/ServiceEntry/sync/stage.foo.global-se")
+	assert.Equal(t, 1, s.len())
+	s.release("cluster2/ServiceEntry/sync/stage.foo.global-se")
+	assert.Equal(t, 0, s.len())
+	assert.Empty(t, s.owners)
+}
+
+func TestSetSpecInterningTransform(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:            &common.LabelSet{},
+		EnableSpecInterning: true,
+	})
+	defer func() { interner = newSpecInterner() }()
+
+	ctx := context.Background()
+	stop := make(chan struct{})
+	defer close(stop)
+	var stores []cache.Store
+	for _, cluster := range []string{"cluster1", "cluster2"} {
+		client := istioFake.NewSimpleClientset()
+		_, err := client.NetworkingV1alpha3().ServiceEntries("sync").Create(ctx, newInternTestServiceEntry("stage.foo.global"), v1.CreateOptions{})
+		assert.Nil(t, err)
+		informer := informers.NewServiceEntryInformer(client, "", 0, cache.Indexers{})
+		assert.Nil(t, setSpecInterningTransform(informer, cluster, common.ServiceEntryResourceType))
+		go informer.Run(stop)
+		assert.True(t, cache.WaitForCacheSync(stop, informer.HasSynced))
+		stores = append(stores, informer.GetStore())
+	}
+
+	se1, _, _ := stores[0].GetByKey("sync/stage.foo.global-se")
+	se2, _, _ := stores[1].GetByKey("sync/stage.foo.global-se")
+	assert.Same(t, se1.(*networking.ServiceEntry).Spec.Ports[0], se2.(*networking.ServiceEntry).Spec.Ports[0])
+	assert.Equal(t, 1, interner.len())
+	assert.Len(t, interner.owners, 2)
+}
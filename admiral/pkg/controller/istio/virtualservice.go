@@ -61,12 +61,14 @@ type VirtualServiceItem struct {
 type VirtualServiceCache struct {
 	cache map[string]*VirtualServiceItem
 	mutex *sync.RWMutex
+	lru   *common.LRUPolicy
 }
 
 func NewVirtualServiceCache() *VirtualServiceCache {
 	return &VirtualServiceCache{
 		cache: make(map[string]*VirtualServiceItem),
 		mutex: &sync.RWMutex{},
+		lru:   common.NewLRUPolicy("virtualService", common.GetResourceCacheMaxSize()),
 	}
 }
 
@@ -112,7 +114,7 @@ func (i *IdentityVirtualServiceCache) Put(vs *networking.VirtualService) error {
 		return fmt.Errorf(
 			"failed to put virtualService in IdentityVirtualServiceCache as vs namespace is empty")
 	}
-	if namespace == common.GetSyncNamespace() || namespace == common.NamespaceIstioSystem {
+	if common.IsSyncNamespace(namespace) || namespace == common.NamespaceIstioSystem {
 		return nil
 	}
 	name := vs.Name
@@ -161,7 +163,7 @@ func (i *IdentityVirtualServiceCache) Delete(vs *networking.VirtualService) erro
 		return fmt.Errorf(
 			"failed to delete virtualService in IdentityVirtualServiceCache as vs namespace is empty")
 	}
-	if namespace == common.GetSyncNamespace() || namespace == common.NamespaceIstioSystem {
+	if common.IsSyncNamespace(namespace) || namespace == common.NamespaceIstioSystem {
 		return nil
 	}
 	name := vs.Name
@@ -226,15 +228,19 @@ func (v *VirtualServiceCache) Put(vs *networking.VirtualService) error {
 		VirtualService: vs,
 		Status:         common.ProcessingInProgress,
 	}
+	for _, evicted := range v.lru.Touch(key) {
+		delete(v.cache, evicted)
+	}
 	return nil
 }
 
 func (v *VirtualServiceCache) Get(vsName string) *networking.VirtualService {
-	defer v.mutex.RUnlock()
-	v.mutex.RLock()
+	defer v.mutex.Unlock()
+	v.mutex.Lock()
 
 	vsItem, ok := v.cache[vsName]
 	if ok {
+		v.lru.Used(vsName)
 		return vsItem.VirtualService
 	}
 
@@ -252,6 +258,7 @@ func (v *VirtualServiceCache) Delete(vs *networking.VirtualService) {
 	defer v.mutex.Unlock()
 	v.mutex.Lock()
 	key := vs.Name
+	v.lru.Remove(key)
 	if v.cache[key] == nil {
 		return
 	}
@@ -287,6 +294,10 @@ func NewVirtualServiceController(stopCh <-chan struct{}, handler VirtualServiceH
 
 	vsController.IstioClient = ic
 	vsController.informer = informers.NewVirtualServiceInformer(ic, k8sV1.NamespaceAll, resyncPeriod, cache.Indexers{})
+	err = setSpecInterningTransform(vsController.informer, config.Host, common.VirtualServiceResourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set the spec interning of the virtual service informer: %v", err)
+	}
 
 	admiral.NewController("virtualservice-ctrl", config.Host, stopCh, &vsController, vsController.informer)
 
@@ -363,7 +374,7 @@ func (sec *VirtualServiceController) Get(ctx context.Context, isRetry bool, obj
 	if ok && sec.IstioClient != nil {
 		return sec.IstioClient.NetworkingV1alpha3().VirtualServices(vs.Namespace).Get(ctx, vs.Name, meta_v1.GetOptions{})
 	}*/
-	return nil, fmt.Errorf("istio client is not initialized, txId=%s", ctx.Value("txId"))
+	return nil, fmt.Errorf("istio client is not initialized, txId=%s", common.GetTxId(ctx))
 }
 
 func (v *VirtualServiceCache) GetVSProcessStatus(vs *networking.VirtualService) string {
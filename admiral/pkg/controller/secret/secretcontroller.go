@@ -18,6 +18,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
@@ -58,6 +61,9 @@ type updateSecretCallback func(config *rest.Config, dataKey string, resyncPeriod
 // removeSecretCallback prototype for the remove secret callback function.
 type removeSecretCallback func(dataKey string) error
 
+// writePauseSecretCallback prototype for the callback function called with the write pause state of a cluster.
+type writePauseSecretCallback func(dataKey string, paused bool)
+
 // Controller is the controller implementation for Secret resources
 type Controller struct {
 	kubeclientset  kubernetes.Interface
@@ -68,7 +74,12 @@ type Controller struct {
 	addCallback    addSecretCallback
 	updateCallback updateSecretCallback
 	removeCallback removeSecretCallback
-	secretResolver resolver.SecretResolver
+	// writePauseCallback is optional and called with the write pause state of every cluster added or updated
+	writePauseCallback writePauseSecretCallback
+	secretResolver     resolver.SecretResolver
+	// clustersMutex guards the remote clusters of the store, as the secrets are processed by several workers
+	// when the cluster warm-up parallelism is more than one
+	clustersMutex sync.Mutex
 }
 
 // RemoteCluster defines cluster structZZ
@@ -97,6 +108,7 @@ func NewController(
 	addCallback addSecretCallback,
 	updateCallback updateSecretCallback,
 	removeCallback removeSecretCallback,
+	writePauseCallback writePauseSecretCallback,
 	admiralProfile string,
 	secretResolverConfig string) *Controller {
 
@@ -133,15 +145,16 @@ func NewController(
 	}
 
 	controller := &Controller{
-		kubeclientset:  kubeclientset,
-		namespace:      namespace,
-		Cs:             cs,
-		informer:       secretsInformer,
-		queue:          queue,
-		addCallback:    addCallback,
-		updateCallback: updateCallback,
-		removeCallback: removeCallback,
-		secretResolver: secretResolver,
+		kubeclientset:      kubeclientset,
+		namespace:          namespace,
+		Cs:                 cs,
+		informer:           secretsInformer,
+		queue:              queue,
+		addCallback:        addCallback,
+		updateCallback:     updateCallback,
+		removeCallback:     removeCallback,
+		writePauseCallback: writePauseCallback,
+		secretResolver:     secretResolver,
 	}
 
 	log.Info("Setting up event handlers")
@@ -189,7 +202,58 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 	}
 
 	log.Info("secret informer caches synced")
-	wait.Until(c.runWorker, 5*time.Second, stopCh)
+	c.sortQueueByWarmupPriority()
+
+	workers := common.GetClusterWarmupParallelism()
+	if workers < 1 {
+		workers = 1
+	}
+	log.Infof("Starting %d secret workers", workers)
+	for i := 0; i < workers; i++ {
+		go wait.Until(c.runWorker, 5*time.Second, stopCh)
+	}
+	<-stopCh
+}
+
+// sortQueueByWarmupPriority re-queues the secrets listed at startup so that the clusters with the highest
+// warm-up priority set on their secret are added first, the secrets of the same priority keep their order
+func (c *Controller) sortQueueByWarmupPriority() {
+	keys := make([]string, 0, c.queue.Len())
+	for c.queue.Len() > 0 {
+		key, quit := c.queue.Get()
+		if quit {
+			return
+		}
+		keys = append(keys, key.(string))
+		c.queue.Done(key)
+	}
+	priorities := make(map[string]int, len(keys))
+	for _, key := range keys {
+		priorities[key] = c.getWarmupPriority(key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return priorities[keys[i]] > priorities[keys[j]]
+	})
+	for _, key := range keys {
+		c.queue.Add(key)
+	}
+}
+
+// getWarmupPriority returns the warm-up priority set with the warmup-priority label on the secret, or 0
+func (c *Controller) getWarmupPriority(key string) int {
+	obj, exists, err := c.informer.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		return 0
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return 0
+	}
+	priority, err := strconv.Atoi(secret.GetLabels()[common.ClusterWarmupPriorityLabel])
+	if err != nil {
+		return 0
+	}
+	return priority
 }
 
 // StartSecretController creates the secret controller.
@@ -199,12 +263,13 @@ func StartSecretController(
 	addCallback addSecretCallback,
 	updateCallback updateSecretCallback,
 	removeCallback removeSecretCallback,
+	writePauseCallback writePauseSecretCallback,
 	namespace string,
 	admiralProfile string,
 	secretResolverConfig string) (*Controller, error) {
 
 	clusterStore := newClustersStore()
-	controller := NewController(k8s, namespace, clusterStore, addCallback, updateCallback, removeCallback, admiralProfile, secretResolverConfig)
+	controller := NewController(k8s, namespace, clusterStore, addCallback, updateCallback, removeCallback, writePauseCallback, admiralProfile, secretResolverConfig)
 
 	go controller.Run(ctx.Done())
 
@@ -298,7 +363,10 @@ func (c *Controller) createRemoteCluster(kubeConfig []byte, secretName string, c
 func (c *Controller) addMemberCluster(secretName string, s *corev1.Secret) {
 	for clusterID, kubeConfig := range s.Data {
 		// clusterID must be unique even across multiple secrets
-		if prev, ok := c.Cs.RemoteClusters[clusterID]; !ok {
+		c.clustersMutex.Lock()
+		prev, ok := c.Cs.RemoteClusters[clusterID]
+		c.clustersMutex.Unlock()
+		if !ok {
 			log.Infof("Adding cluster_id=%v from secret=%v", clusterID, secretName)
 
 			remoteCluster, restConfig, err := c.createRemoteCluster(kubeConfig, secretName, clusterID, s.ObjectMeta.Namespace)
@@ -309,14 +377,17 @@ func (c *Controller) addMemberCluster(secretName string, s *corev1.Secret) {
 				continue
 			}
 
+			c.clustersMutex.Lock()
 			c.Cs.RemoteClusters[clusterID] = remoteCluster
+			c.clustersMutex.Unlock()
 
 			if err := c.addCallback(restConfig, clusterID, common.GetResyncIntervals()); err != nil {
 				log.Errorf("error during secret loading for clusterID: %s %v", clusterID, err)
 				continue
 			}
 
-			log.Infof("Secret loaded for cluster %s in the secret %s in namespace %s.", clusterID, c.Cs.RemoteClusters[clusterID].secretName, s.ObjectMeta.Namespace)
+			log.Infof("Secret loaded for cluster %s in the secret %s in namespace %s.", clusterID, remoteCluster.secretName, s.ObjectMeta.Namespace)
+			c.updateWritePause(clusterID, s)
 
 		} else {
 			if prev.secretName != secretName {
@@ -334,18 +405,25 @@ func (c *Controller) addMemberCluster(secretName string, s *corev1.Secret) {
 				continue
 			}
 
+			c.clustersMutex.Lock()
 			c.Cs.RemoteClusters[clusterID] = remoteCluster
+			c.clustersMutex.Unlock()
 			if err := c.updateCallback(restConfig, clusterID, common.GetResyncIntervals()); err != nil {
 				log.Errorf("Error updating cluster_id from secret=%v: %s %v",
 					clusterID, secretName, err)
 			}
+			c.updateWritePause(clusterID, s)
 		}
 	}
+	c.clustersMutex.Lock()
+	defer c.clustersMutex.Unlock()
 	remoteClustersMetric.Set(float64(len(c.Cs.RemoteClusters)))
 	log.Infof("Number of remote clusters: %d", len(c.Cs.RemoteClusters))
 }
 
 func (c *Controller) deleteMemberCluster(secretName string) {
+	c.clustersMutex.Lock()
+	defer c.clustersMutex.Unlock()
 	for clusterID, cluster := range c.Cs.RemoteClusters {
 		if cluster.secretName == secretName {
 			log.Infof("Deleting cluster member: %s", clusterID)
@@ -365,6 +443,28 @@ func (c *Controller) deleteMemberCluster(secretName string) {
 	log.Infof("Number of remote clusters: %d", len(c.Cs.RemoteClusters))
 }
 
+// updateWritePause passes the write pause state set with the write-paused or sync annotation on the secret to the callback
+func (c *Controller) updateWritePause(clusterID string, s *corev1.Secret) {
+	if c.writePauseCallback == nil {
+		return
+	}
+	c.writePauseCallback(clusterID, isWritePaused(s))
+}
+
+// isWritePaused checks if the secret pauses the writes to its clusters, either with the write-paused
+// annotation set to true or with the sync annotation set to paused
+func isWritePaused(secret *corev1.Secret) bool {
+	if secret == nil {
+		return false
+	}
+	annotations := secret.GetAnnotations()
+	if annotations[common.AdmiralSyncAnnotation] == common.AdmiralSyncPaused {
+		return true
+	}
+	paused, err := strconv.ParseBool(annotations[common.AdmiralWritePausedAnnotation])
+	return err == nil && paused
+}
+
 func getShardNameFromClusterSecret(secret *corev1.Secret) (string, error) {
 	if !common.IsAdmiralStateSyncerMode() {
 		return "", nil
@@ -248,7 +248,7 @@ func Test_SecretFilterTagsMismatch(t *testing.T) {
 		// The assertion ShouldNot(BeNil()) make sure that start secret controller return a not nil controller and nil error
 		registry := prometheus.DefaultGatherer
 		g.Expect(
-			StartSecretController(context.TODO(), clientset, addCallback, updateCallback, deleteCallback, secretNameSpace, common.AdmiralProfileDefault, "")).
+			StartSecretController(context.TODO(), clientset, addCallback, updateCallback, deleteCallback, nil, secretNameSpace, common.AdmiralProfileDefault, "")).
 			ShouldNot(BeNil())
 
 		ctx := context.Background()
@@ -389,6 +389,80 @@ func TestGetShardNameFromClusterSecret(t *testing.T) {
 	}
 }
 
+func TestIsWritePaused(t *testing.T) {
+	cases := []struct {
+		name   string
+		secret *corev1.Secret
+		want   bool
+	}{
+		{
+			name: "Given secret is nil, " +
+				"When isWritePaused is invoked, " +
+				"It should return false",
+			secret: nil,
+			want:   false,
+		},
+		{
+			name: "Given secret does not have the write-paused annotation, " +
+				"When isWritePaused is invoked, " +
+				"It should return false",
+			secret: &coreV1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: secretNameSpace}},
+			want:   false,
+		},
+		{
+			name: "Given secret has the write-paused annotation set to true, " +
+				"When isWritePaused is invoked, " +
+				"It should return true",
+			secret: &coreV1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        secretName,
+				Namespace:   secretNameSpace,
+				Annotations: map[string]string{common.AdmiralWritePausedAnnotation: "true"},
+			}},
+			want: true,
+		},
+		{
+			name: "Given secret has the write-paused annotation set to an invalid value, " +
+				"When isWritePaused is invoked, " +
+				"It should return false",
+			secret: &coreV1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        secretName,
+				Namespace:   secretNameSpace,
+				Annotations: map[string]string{common.AdmiralWritePausedAnnotation: "yes"},
+			}},
+			want: false,
+		},
+		{
+			name: "Given secret has the sync annotation set to paused, " +
+				"When isWritePaused is invoked, " +
+				"It should return true",
+			secret: &coreV1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        secretName,
+				Namespace:   secretNameSpace,
+				Annotations: map[string]string{common.AdmiralSyncAnnotation: common.AdmiralSyncPaused},
+			}},
+			want: true,
+		},
+		{
+			name: "Given secret has the sync annotation set to another value, " +
+				"When isWritePaused is invoked, " +
+				"It should return false",
+			secret: &coreV1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        secretName,
+				Namespace:   secretNameSpace,
+				Annotations: map[string]string{common.AdmiralSyncAnnotation: "enabled"},
+			}},
+			want: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isWritePaused(c.secret); got != c.want {
+				t.Errorf("want=%v, got=%v", c.want, got)
+			}
+		})
+	}
+}
+
 func TestNewClustersStore(t *testing.T) {
 	t.Parallel()
 	store := newClustersStore()
@@ -406,7 +480,7 @@ func TestNewControllerWithDefaultProfile(t *testing.T) {
 	admiralProfile := common.AdmiralProfileDefault
 	secretResolverConfig := ""
 
-	controller := NewController(kubeclientset, namespace, cs, addCallback, updateCallback, removeCallback, admiralProfile, secretResolverConfig)
+	controller := NewController(kubeclientset, namespace, cs, addCallback, updateCallback, removeCallback, nil, admiralProfile, secretResolverConfig)
 
 	if controller == nil {
 		t.Fatalf("Expected controller to be initialized, got nil")
@@ -428,7 +502,7 @@ func TestNewControllerWithUnrecognizedProfile(t *testing.T) {
 	admiralProfile := "unknown-profile"
 	secretResolverConfig := ""
 
-	controller := NewController(kubeclientset, namespace, cs, addCallback, updateCallback, removeCallback, admiralProfile, secretResolverConfig)
+	controller := NewController(kubeclientset, namespace, cs, addCallback, updateCallback, removeCallback, nil, admiralProfile, secretResolverConfig)
 
 	if controller != nil {
 		t.Fatalf("Expected controller to be nil for unrecognized profile, got non-nil")
@@ -448,7 +522,7 @@ func TestStartSecretControllerInitialization(t *testing.T) {
 	admiralProfile := common.AdmiralProfileDefault
 	secretResolverConfig := ""
 
-	controller, err := StartSecretController(ctx, mockK8s, addCallback, updateCallback, removeCallback, namespace, admiralProfile, secretResolverConfig)
+	controller, err := StartSecretController(ctx, mockK8s, addCallback, updateCallback, removeCallback, nil, namespace, admiralProfile, secretResolverConfig)
 
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
@@ -473,3 +547,79 @@ func TestCreateRemoteClusterWithEmptyKubeConfig(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "kubeconfig is empty", err.Error())
 }
+
+func TestSortQueueByWarmupPriority(t *testing.T) {
+	controller := NewController(fake.NewSimpleClientset(), secretNameSpace, newClustersStore(), addCallback, updateCallback, deleteCallback, nil, common.AdmiralProfileDefault, "")
+	defer controller.queue.ShutDown()
+	secrets := []struct {
+		name     string
+		priority string
+	}{
+		{name: "s0"},
+		{name: "s1", priority: "10"},
+		{name: "s2", priority: "invalid"},
+		{name: "s3", priority: "20"},
+		{name: "s4", priority: "10"},
+	}
+	for _, s := range secrets {
+		secret := makeSecret(s.name, "c-"+s.name, []byte("kubeconfig"))
+		if s.priority != "" {
+			secret.Labels[common.ClusterWarmupPriorityLabel] = s.priority
+		}
+		assert.Nil(t, controller.informer.GetIndexer().Add(secret))
+		controller.queue.Add(secretNameSpace + "/" + s.name)
+	}
+	controller.queue.Add(secretNameSpace + "/deleted")
+
+	controller.sortQueueByWarmupPriority()
+
+	keys := make([]string, 0)
+	for controller.queue.Len() > 0 {
+		key, _ := controller.queue.Get()
+		keys = append(keys, key.(string))
+		controller.queue.Done(key)
+	}
+	assert.Equal(t, []string{
+		secretNameSpace + "/s3",
+		secretNameSpace + "/s1",
+		secretNameSpace + "/s4",
+		secretNameSpace + "/s0",
+		secretNameSpace + "/s2",
+		secretNameSpace + "/deleted",
+	}, keys)
+}
+
+func TestRunWithClusterWarmupParallelism(t *testing.T) {
+	LoadKubeConfig = mockLoadKubeConfig
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{ClusterWarmupParallelism: 3, SecretFilterTags: "admiral/sync"})
+	defer common.ResetSync()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset := fake.NewSimpleClientset()
+	for i := 0; i < 6; i++ {
+		_, err := clientset.CoreV1().Secrets(secretNameSpace).Create(ctx,
+			makeSecret(fmt.Sprintf("s%d", i), fmt.Sprintf("c%d", i), []byte("kubeconfig")), metav1.CreateOptions{})
+		assert.Nil(t, err)
+	}
+	var addedMutex sync.Mutex
+	addedClusters := make(map[string]bool)
+	add := func(config *rest.Config, id string, resyncPeriod util.ResyncIntervals) error {
+		addedMutex.Lock()
+		defer addedMutex.Unlock()
+		addedClusters[id] = true
+		return nil
+	}
+
+	controller, err := StartSecretController(ctx, clientset, add, updateCallback, deleteCallback, nil, secretNameSpace, common.AdmiralProfileDefault, "")
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		addedMutex.Lock()
+		defer addedMutex.Unlock()
+		return len(addedClusters) == 6
+	}, 10*time.Second, 10*time.Millisecond)
+	controller.clustersMutex.Lock()
+	defer controller.clustersMutex.Unlock()
+	assert.Len(t, controller.Cs.RemoteClusters, 6)
+}
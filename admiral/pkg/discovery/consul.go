@@ -0,0 +1,109 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	consulTimeout     = 30 * time.Second
+	consulTokenEnv    = "CONSUL_HTTP_TOKEN"
+	consulTokenHeader = "X-Consul-Token"
+)
+
+// ConsulSource discovers the services registered in the catalog of a Consul agent. The identity of a
+// service is its name, and its endpoints are the addresses of its instances passing their health checks
+type ConsulSource struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address    string
+		Datacenter string
+	}
+	Service struct {
+		Service string
+		Address string
+		Port    int
+		Meta    map[string]string
+	}
+}
+
+func NewConsulSource(address string) *ConsulSource {
+	return &ConsulSource{
+		address: strings.TrimSuffix(address, "/"),
+		token:   os.Getenv(consulTokenEnv),
+		client:  &http.Client{Timeout: consulTimeout},
+	}
+}
+
+func (c *ConsulSource) Name() string {
+	return consulSource
+}
+
+func (c *ConsulSource) Services(ctx context.Context) ([]Service, error) {
+	var catalog map[string][]string
+	if err := c.get(ctx, "/v1/catalog/services", &catalog); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		if name != consulSource {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	services := newServiceSet()
+	for _, name := range names {
+		var entries []consulServiceEntry
+		if err := c.get(ctx, "/v1/health/service/"+url.PathEscape(name)+"?passing=true", &entries); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			services.add(newConsulService(entry))
+		}
+	}
+	return services.services, nil
+}
+
+func newConsulService(entry consulServiceEntry) Service {
+	service := newService(entry.Service.Service, entry.Service.Meta)
+	address := entry.Service.Address
+	if address == "" {
+		address = entry.Node.Address
+	}
+	if address != "" && entry.Service.Port > 0 {
+		service.Endpoints = []Endpoint{{Address: address, Port: uint32(entry.Service.Port), Locality: entry.Node.Datacenter}}
+	}
+	return service
+}
+
+func (c *ConsulSource) get(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set(consulTokenHeader, c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("consul returned %d for %s", resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsulSourceServices(t *testing.T) {
+	responses := map[string]string{
+		"/v1/catalog/services": `{"consul":[],"payments-vm":["v1"],"ledger":[]}`,
+		"/v1/health/service/payments-vm": `[` +
+			`{"Node":{"Address":"10.0.0.1","Datacenter":"us-west-2"},"Service":{"Service":"payments-vm","Port":8080,` +
+			`"Meta":{"admiral_identity":"payments","admiral_env":"qa","admiral_dependencies":"ledger, orders"}}},` +
+			`{"Node":{"Address":"10.0.0.2","Datacenter":"us-east-2"},"Service":{"Service":"payments-vm","Address":"10.1.0.2","Port":8080,` +
+			`"Meta":{"admiral_identity":"payments","admiral_env":"qa","admiral_dependencies":"ledger,users"}}}]`,
+		"/v1/health/service/ledger": `[{"Node":{"Address":"ledger.vm.com"},"Service":{"Service":"ledger","Port":9090,"Meta":{"admiral_protocol":"grpc"}}}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get(consulTokenHeader))
+		if r.URL.Path != "/v1/catalog/services" {
+			assert.Equal(t, "true", r.URL.Query().Get("passing"))
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+	t.Setenv(consulTokenEnv, "token")
+
+	services, err := NewConsulSource(server.URL + "/").Services(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []Service{
+		{
+			Identity:  "ledger",
+			Env:       "default",
+			Protocol:  "grpc",
+			Endpoints: []Endpoint{{Address: "ledger.vm.com", Port: 9090}},
+		},
+		{
+			Identity: "payments",
+			Env:      "qa",
+			Protocol: "http",
+			Endpoints: []Endpoint{
+				{Address: "10.0.0.1", Port: 8080, Locality: "us-west-2"},
+				{Address: "10.1.0.2", Port: 8080, Locality: "us-east-2"},
+			},
+			Dependencies: []string{"ledger", "orders", "users"},
+		},
+	}, services)
+
+	delete(responses, "/v1/health/service/ledger")
+	_, err = NewConsulSource(server.URL).Services(context.Background())
+	assert.NotNil(t, err)
+}
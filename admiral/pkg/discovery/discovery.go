@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	consulSource = "consul"
+	eurekaSource = "eureka"
+
+	// the metadata of a registered service overriding its identity, env, protocol and dependencies,
+	// the keys only use characters allowed in the metadata of every source
+	IdentityMeta     = "admiral_identity"
+	EnvMeta          = "admiral_env"
+	ProtocolMeta     = "admiral_protocol"
+	DependenciesMeta = "admiral_dependencies"
+
+	defaultEnv      = "default"
+	defaultProtocol = "http"
+)
+
+// Service is a workload registered in a discovery source outside of the mesh clusters, e.g. a VM
+// workload registered in Consul, along with the endpoints it is reached through
+type Service struct {
+	Identity     string
+	Env          string
+	Protocol     string
+	Endpoints    []Endpoint
+	Dependencies []string
+}
+
+type Endpoint struct {
+	Address  string
+	Port     uint32
+	Locality string
+}
+
+// Source discovers the services registered outside of the mesh clusters
+type Source interface {
+	// Name returns the name of the source, the service entries generated for its services are labeled with it
+	Name() string
+	// Services returns the healthy services registered in the source
+	Services(ctx context.Context) ([]Service, error)
+}
+
+// NewSource returns the built-in discovery source of the type which reads the services from the address
+func NewSource(sourceType, address string) (Source, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address of %s discovery source is empty", sourceType)
+	}
+	switch strings.ToLower(sourceType) {
+	// Add entries for your custom discovery sources below
+	case consulSource:
+		return NewConsulSource(address), nil
+	case eurekaSource:
+		return NewEurekaSource(address), nil
+	default:
+		return nil, fmt.Errorf("unsupported discovery source type %q", sourceType)
+	}
+}
+
+// newService returns the service of an instance registered with the name and metadata, without endpoints
+func newService(name string, meta map[string]string) Service {
+	service := Service{
+		Identity: name,
+		Env:      defaultEnv,
+		Protocol: defaultProtocol,
+	}
+	if meta[IdentityMeta] != "" {
+		service.Identity = meta[IdentityMeta]
+	}
+	if meta[EnvMeta] != "" {
+		service.Env = meta[EnvMeta]
+	}
+	if meta[ProtocolMeta] != "" {
+		service.Protocol = meta[ProtocolMeta]
+	}
+	for _, dependency := range strings.Split(meta[DependenciesMeta], ",") {
+		if dependency = strings.TrimSpace(dependency); dependency != "" {
+			service.Dependencies = append(service.Dependencies, dependency)
+		}
+	}
+	return service
+}
+
+// serviceSet merges the instances of the same identity and env into a single service
+type serviceSet struct {
+	services []Service
+	byKey    map[string]int
+}
+
+func newServiceSet() *serviceSet {
+	return &serviceSet{services: make([]Service, 0), byKey: make(map[string]int)}
+}
+
+func (s *serviceSet) add(service Service) {
+	key := service.Env + "/" + service.Identity
+	index, ok := s.byKey[key]
+	if !ok {
+		s.byKey[key] = len(s.services)
+		s.services = append(s.services, service)
+		return
+	}
+	s.services[index].Endpoints = append(s.services[index].Endpoints, service.Endpoints...)
+	for _, dependency := range service.Dependencies {
+		if !contains(s.services[index].Dependencies, dependency) {
+			s.services[index].Dependencies = append(s.services[index].Dependencies, dependency)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSource(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sourceType   string
+		address      string
+		expectedName string
+		expectedErr  bool
+	}{
+		{
+			name: "Given a consul source type, " +
+				"When NewSource is called, " +
+				"Then a consul source should be returned",
+			sourceType:   "Consul",
+			address:      "http://localhost:8500",
+			expectedName: "consul",
+		},
+		{
+			name: "Given a eureka source type, " +
+				"When NewSource is called, " +
+				"Then a eureka source should be returned",
+			sourceType:   "eureka",
+			address:      "http://localhost:8761/eureka",
+			expectedName: "eureka",
+		},
+		{
+			name: "Given an unsupported source type, " +
+				"When NewSource is called, " +
+				"Then an error should be returned",
+			sourceType:  "zookeeper",
+			address:     "http://localhost:2181",
+			expectedErr: true,
+		},
+		{
+			name: "Given an empty address, " +
+				"When NewSource is called, " +
+				"Then an error should be returned",
+			sourceType:  "consul",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			source, err := NewSource(c.sourceType, c.address)
+			assert.Equal(t, c.expectedErr, err != nil)
+			if !c.expectedErr {
+				assert.Equal(t, c.expectedName, source.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	eurekaTimeout     = 30 * time.Second
+	eurekaStatusUp    = "UP"
+	eurekaZoneDCMeta  = "availability-zone"
+	eurekaPortEnabled = "true"
+)
+
+// EurekaSource discovers the applications registered in a Eureka server. The identity of an application
+// is its lower cased name, and its endpoints are the addresses of its instances which are UP. The secure
+// port of an instance is only used when its non secure port is disabled
+type EurekaSource struct {
+	address string
+	client  *http.Client
+}
+
+type eurekaApplications struct {
+	Applications struct {
+		Application []eurekaApplication `json:"application"`
+	} `json:"applications"`
+}
+
+type eurekaApplication struct {
+	Name     string           `json:"name"`
+	Instance []eurekaInstance `json:"instance"`
+}
+
+type eurekaInstance struct {
+	HostName       string            `json:"hostName"`
+	IPAddr         string            `json:"ipAddr"`
+	Status         string            `json:"status"`
+	Port           eurekaPort        `json:"port"`
+	SecurePort     eurekaPort        `json:"securePort"`
+	Metadata       map[string]string `json:"metadata"`
+	DataCenterInfo struct {
+		Metadata map[string]string `json:"metadata"`
+	} `json:"dataCenterInfo"`
+}
+
+type eurekaPort struct {
+	Port    int    `json:"$"`
+	Enabled string `json:"@enabled"`
+}
+
+// NewEurekaSource returns the source of the Eureka server at the address, the base url of its REST api
+// e.g. http://eureka:8761/eureka
+func NewEurekaSource(address string) *EurekaSource {
+	return &EurekaSource{
+		address: strings.TrimSuffix(address, "/"),
+		client:  &http.Client{Timeout: eurekaTimeout},
+	}
+}
+
+func (e *EurekaSource) Name() string {
+	return eurekaSource
+}
+
+func (e *EurekaSource) Services(ctx context.Context) ([]Service, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.address+"/apps", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eureka returned %d for /apps", resp.StatusCode)
+	}
+	var apps eurekaApplications
+	if err := json.NewDecoder(resp.Body).Decode(&apps); err != nil {
+		return nil, err
+	}
+
+	services := newServiceSet()
+	for _, app := range apps.Applications.Application {
+		for _, instance := range app.Instance {
+			if instance.Status != eurekaStatusUp {
+				continue
+			}
+			services.add(newEurekaService(app.Name, instance))
+		}
+	}
+	return services.services, nil
+}
+
+func newEurekaService(name string, instance eurekaInstance) Service {
+	service := newService(strings.ToLower(name), instance.Metadata)
+	address := instance.IPAddr
+	if address == "" {
+		address = instance.HostName
+	}
+	port := 0
+	if instance.Port.Enabled == eurekaPortEnabled {
+		port = instance.Port.Port
+	} else if instance.SecurePort.Enabled == eurekaPortEnabled {
+		port = instance.SecurePort.Port
+	}
+	if address != "" && port > 0 {
+		service.Endpoints = []Endpoint{{Address: address, Port: uint32(port), Locality: instance.DataCenterInfo.Metadata[eurekaZoneDCMeta]}}
+	}
+	return service
+}
@@ -0,0 +1,55 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEurekaSourceServices(t *testing.T) {
+	response := `{"applications":{"application":[` +
+		`{"name":"PAYMENTS","instance":[` +
+		`{"hostName":"payments-1.vm.com","ipAddr":"10.0.0.1","status":"UP","port":{"$":8080,"@enabled":"false"},"securePort":{"$":8443,"@enabled":"true"},` +
+		`"metadata":{"admiral_env":"qa","admiral_dependencies":"ledger"},"dataCenterInfo":{"metadata":{"availability-zone":"us-west-2a"}}},` +
+		`{"hostName":"payments-2.vm.com","status":"UP","port":{"$":8080,"@enabled":"true"},"securePort":{"$":8443,"@enabled":"false"},` +
+		`"metadata":{"admiral_env":"qa"}},` +
+		`{"hostName":"payments-3.vm.com","ipAddr":"10.0.0.3","status":"DOWN","port":{"$":8080,"@enabled":"true"},"metadata":{"admiral_env":"qa"}}]},` +
+		`{"name":"LEDGER","instance":[{"hostName":"ledger.vm.com","ipAddr":"10.0.1.1","status":"UP","port":{"$":9090,"@enabled":"true"},` +
+		`"metadata":{"admiral_identity":"Ledger.Core","admiral_protocol":"grpc"}}]}]}}`
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/eureka/apps", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	services, err := NewEurekaSource(server.URL + "/eureka/").Services(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []Service{
+		{
+			Identity: "payments",
+			Env:      "qa",
+			Protocol: "http",
+			Endpoints: []Endpoint{
+				{Address: "10.0.0.1", Port: 8443, Locality: "us-west-2a"},
+				{Address: "payments-2.vm.com", Port: 8080},
+			},
+			Dependencies: []string{"ledger"},
+		},
+		{
+			Identity:  "Ledger.Core",
+			Env:       "default",
+			Protocol:  "grpc",
+			Endpoints: []Endpoint{{Address: "10.0.1.1", Port: 9090}},
+		},
+	}, services)
+
+	status = http.StatusServiceUnavailable
+	_, err = NewEurekaSource(server.URL + "/eureka").Services(context.Background())
+	assert.NotNil(t, err)
+}
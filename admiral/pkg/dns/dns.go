@@ -0,0 +1,160 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	route53Provider     = "route53"
+	externalDNSProvider = "external-dns"
+
+	recordTypeA     = "A"
+	recordTypeCNAME = "CNAME"
+	recordTypeTXT   = "TXT"
+
+	// ownerRecord marks the records created by admiral, the records without it are never updated or deleted
+	ownerRecord = "heritage=admiral"
+)
+
+// Record is the dns record of a cname generated by admiral, resolving to the ingress load balancers of
+// the clusters the cname is served from
+type Record struct {
+	Name    string
+	TTL     int64
+	Targets []Target
+}
+
+// Target is the ingress load balancer of a cluster, along with the region of the cluster
+type Target struct {
+	Address string
+	Region  string
+}
+
+// Provider manages the dns records of the cnames generated by admiral
+type Provider interface {
+	// Name returns the name of the provider
+	Name() string
+	// Records returns the names of the records created by admiral
+	Records(ctx context.Context) ([]string, error)
+	// Upsert creates or updates the record
+	Upsert(ctx context.Context, record Record) error
+	// Delete deletes the record with the name
+	Delete(ctx context.Context, name string) error
+}
+
+// Config holds the settings of the built-in providers, only those of the configured provider are used
+type Config struct {
+	// HostedZoneID is the Route53 hosted zone the records are written to
+	HostedZoneID string
+	// Role is the arn of the role assumed to write to the hosted zone, the default credentials are used when empty
+	Role string
+	// Region is the aws region of the Route53 client
+	Region string
+	// Namespace is the namespace the DNSEndpoint resources of external-dns are written to
+	Namespace string
+	// Client is the client of the cluster external-dns watches the DNSEndpoint resources of
+	Client dynamic.Interface
+}
+
+// NewProvider returns the built-in provider of the type
+func NewProvider(providerType string, config Config) (Provider, error) {
+	switch strings.ToLower(providerType) {
+	// Add entries for your custom dns providers below
+	case route53Provider:
+		if config.HostedZoneID == "" {
+			return nil, fmt.Errorf("hosted zone of %s dns provider is empty", providerType)
+		}
+		return NewRoute53Provider(config.HostedZoneID, config.Role, config.Region)
+	case externalDNSProvider:
+		if config.Client == nil || config.Namespace == "" {
+			return nil, fmt.Errorf("client and namespace of %s dns provider are required", providerType)
+		}
+		return NewExternalDNSProvider(config.Client, config.Namespace), nil
+	default:
+		return nil, fmt.Errorf("unsupported dns provider type %q", providerType)
+	}
+}
+
+// recordSet is the set of targets the record resolves to for the clients of a region
+type recordSet struct {
+	identifier string
+	region     string
+	recordType string
+	targets    []string
+}
+
+// getRecordSets returns the record sets of the record. When the targets are spread over several regions, and
+// the region of each of them is known, a latency based record set is returned per region so that the clients
+// resolve the ingress of the closest region, otherwise a single record set is returned. A record set of ip
+// addresses is an A record, and a CNAME record of the first hostname otherwise, as a CNAME has a single target
+func getRecordSets(record Record) []recordSet {
+	byRegion := make(map[string][]string)
+	regional := true
+	for _, target := range record.Targets {
+		if target.Address == "" {
+			continue
+		}
+		if target.Region == "" {
+			regional = false
+		}
+		if !contains(byRegion[target.Region], target.Address) {
+			byRegion[target.Region] = append(byRegion[target.Region], target.Address)
+		}
+	}
+	if len(byRegion) == 0 {
+		return nil
+	}
+	if len(byRegion) == 1 || !regional {
+		addresses := make([]string, 0)
+		for _, regionAddresses := range byRegion {
+			for _, address := range regionAddresses {
+				if !contains(addresses, address) {
+					addresses = append(addresses, address)
+				}
+			}
+		}
+		return []recordSet{newRecordSet("", "", addresses)}
+	}
+	regions := make([]string, 0, len(byRegion))
+	for region := range byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	recordSets := make([]recordSet, 0, len(regions))
+	for _, region := range regions {
+		recordSets = append(recordSets, newRecordSet(region, region, byRegion[region]))
+	}
+	return recordSets
+}
+
+func newRecordSet(identifier, region string, addresses []string) recordSet {
+	sort.Strings(addresses)
+	ips := make([]string, 0, len(addresses))
+	hostnames := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if net.ParseIP(address) != nil {
+			ips = append(ips, address)
+		} else {
+			hostnames = append(hostnames, address)
+		}
+	}
+	if len(hostnames) > 0 {
+		return recordSet{identifier: identifier, region: region, recordType: recordTypeCNAME, targets: hostnames[:1]}
+	}
+	return recordSet{identifier: identifier, region: region, recordType: recordTypeA, targets: ips}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
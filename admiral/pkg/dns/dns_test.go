@@ -0,0 +1,106 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestNewProvider(t *testing.T) {
+	testCases := []struct {
+		name         string
+		providerType string
+		config       Config
+		expectedName string
+		expectedErr  bool
+	}{
+		{
+			name: "Given an external-dns provider type, " +
+				"When NewProvider is called, " +
+				"Then an external-dns provider should be returned",
+			providerType: "external-dns",
+			config:       Config{Namespace: "admiral", Client: dynamicFake.NewSimpleDynamicClient(runtime.NewScheme())},
+			expectedName: "external-dns",
+		},
+		{
+			name: "Given an external-dns provider type without a namespace, " +
+				"When NewProvider is called, " +
+				"Then an error should be returned",
+			providerType: "external-dns",
+			config:       Config{Client: dynamicFake.NewSimpleDynamicClient(runtime.NewScheme())},
+			expectedErr:  true,
+		},
+		{
+			name: "Given a route53 provider type without a hosted zone, " +
+				"When NewProvider is called, " +
+				"Then an error should be returned",
+			providerType: "route53",
+			expectedErr:  true,
+		},
+		{
+			name: "Given an unsupported provider type, " +
+				"When NewProvider is called, " +
+				"Then an error should be returned",
+			providerType: "cloud-dns",
+			expectedErr:  true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			provider, err := NewProvider(c.providerType, c.config)
+			assert.Equal(t, c.expectedErr, err != nil)
+			if !c.expectedErr {
+				assert.Equal(t, c.expectedName, provider.Name())
+			}
+		})
+	}
+}
+
+func TestGetRecordSets(t *testing.T) {
+	testCases := []struct {
+		name               string
+		targets            []Target
+		expectedRecordSets []recordSet
+	}{
+		{
+			name: "Given targets in a single region, " +
+				"When getRecordSets is called, " +
+				"Then a single CNAME record set of the first hostname should be returned",
+			targets: []Target{{Address: "lb2.example.com", Region: "us-west-2"}, {Address: "lb1.example.com", Region: "us-west-2"}},
+			expectedRecordSets: []recordSet{
+				{recordType: "CNAME", targets: []string{"lb1.example.com"}},
+			},
+		},
+		{
+			name: "Given ip targets in several regions, " +
+				"When getRecordSets is called, " +
+				"Then an A record set per region should be returned",
+			targets: []Target{{Address: "10.0.0.2", Region: "us-west-2"}, {Address: "10.0.1.1", Region: "us-east-2"}, {Address: "10.0.0.1", Region: "us-west-2"}},
+			expectedRecordSets: []recordSet{
+				{identifier: "us-east-2", region: "us-east-2", recordType: "A", targets: []string{"10.0.1.1"}},
+				{identifier: "us-west-2", region: "us-west-2", recordType: "A", targets: []string{"10.0.0.1", "10.0.0.2"}},
+			},
+		},
+		{
+			name: "Given targets of which one has no region, " +
+				"When getRecordSets is called, " +
+				"Then a single record set should be returned",
+			targets: []Target{{Address: "10.0.0.1", Region: "us-west-2"}, {Address: "10.0.1.1"}},
+			expectedRecordSets: []recordSet{
+				{recordType: "A", targets: []string{"10.0.0.1", "10.0.1.1"}},
+			},
+		},
+		{
+			name: "Given no targets, " +
+				"When getRecordSets is called, " +
+				"Then no record sets should be returned",
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedRecordSets, getRecordSets(Record{Name: "qa.foo.global", Targets: c.targets}))
+		})
+	}
+}
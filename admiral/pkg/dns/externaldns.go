@@ -0,0 +1,102 @@
+package dns
+
+import (
+	"context"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	managedByLabel    = "app.kubernetes.io/managed-by"
+	managedByAdmiral  = "admiral"
+	awsRegionProperty = "aws/region"
+)
+
+var DNSEndpointResource = schema.GroupVersionResource{Group: "externaldns.k8s.io", Version: "v1alpha1", Resource: "dnsendpoints"}
+
+// ExternalDNSProvider writes a DNSEndpoint resource per record, for external-dns to write the record to
+// the dns provider it is configured with. The regional record sets are written with the set identifier
+// and the aws region of latency based routing
+type ExternalDNSProvider struct {
+	client    dynamic.Interface
+	namespace string
+}
+
+func NewExternalDNSProvider(client dynamic.Interface, namespace string) *ExternalDNSProvider {
+	return &ExternalDNSProvider{client: client, namespace: namespace}
+}
+
+func (e *ExternalDNSProvider) Name() string {
+	return externalDNSProvider
+}
+
+func (e *ExternalDNSProvider) Records(ctx context.Context) ([]string, error) {
+	list, err := e.client.Resource(DNSEndpointResource).Namespace(e.namespace).List(ctx, metaV1.ListOptions{
+		LabelSelector: managedByLabel + "=" + managedByAdmiral,
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+	return names, nil
+}
+
+func (e *ExternalDNSProvider) Upsert(ctx context.Context, record Record) error {
+	endpoints := make([]interface{}, 0)
+	for _, rs := range getRecordSets(record) {
+		targets := make([]interface{}, 0, len(rs.targets))
+		for _, target := range rs.targets {
+			targets = append(targets, target)
+		}
+		endpoint := map[string]interface{}{
+			"dnsName":    record.Name,
+			"recordType": rs.recordType,
+			"recordTTL":  record.TTL,
+			"targets":    targets,
+		}
+		if rs.identifier != "" {
+			endpoint["setIdentifier"] = rs.identifier
+			endpoint["providerSpecific"] = []interface{}{map[string]interface{}{"name": awsRegionProperty, "value": rs.region}}
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	resource := e.client.Resource(DNSEndpointResource).Namespace(e.namespace)
+	existing, err := resource.Get(ctx, record.Name, metaV1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": DNSEndpointResource.GroupVersion().String(),
+			"kind":       "DNSEndpoint",
+			"metadata": map[string]interface{}{
+				"name":      record.Name,
+				"namespace": e.namespace,
+				"labels":    map[string]interface{}{managedByLabel: managedByAdmiral},
+			},
+			"spec": map[string]interface{}{"endpoints": endpoints},
+		}}
+		_, err = resource.Create(ctx, obj, metaV1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedSlice(existing.Object, endpoints, "spec", "endpoints"); err != nil {
+		return err
+	}
+	_, err = resource.Update(ctx, existing, metaV1.UpdateOptions{})
+	return err
+}
+
+func (e *ExternalDNSProvider) Delete(ctx context.Context, name string) error {
+	err := e.client.Resource(DNSEndpointResource).Namespace(e.namespace).Delete(ctx, name, metaV1.DeleteOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
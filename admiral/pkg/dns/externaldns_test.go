@@ -0,0 +1,56 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestExternalDNSProvider(t *testing.T) {
+	ctx := context.Background()
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{DNSEndpointResource: "DNSEndpointList"})
+	provider := NewExternalDNSProvider(client, "admiral")
+
+	err := provider.Upsert(ctx, Record{Name: "qa.foo.global", TTL: 60, Targets: []Target{
+		{Address: "lb1.example.com", Region: "us-west-2"},
+		{Address: "lb2.example.com", Region: "us-east-2"},
+	}})
+	assert.Nil(t, err)
+	obj, err := client.Resource(DNSEndpointResource).Namespace("admiral").Get(ctx, "qa.foo.global", metaV1.GetOptions{})
+	assert.Nil(t, err)
+	endpoints, _, _ := unstructured.NestedSlice(obj.Object, "spec", "endpoints")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"dnsName": "qa.foo.global", "recordType": "CNAME", "recordTTL": int64(60), "targets": []interface{}{"lb2.example.com"},
+			"setIdentifier": "us-east-2", "providerSpecific": []interface{}{map[string]interface{}{"name": "aws/region", "value": "us-east-2"}},
+		},
+		map[string]interface{}{
+			"dnsName": "qa.foo.global", "recordType": "CNAME", "recordTTL": int64(60), "targets": []interface{}{"lb1.example.com"},
+			"setIdentifier": "us-west-2", "providerSpecific": []interface{}{map[string]interface{}{"name": "aws/region", "value": "us-west-2"}},
+		},
+	}, endpoints)
+
+	err = provider.Upsert(ctx, Record{Name: "qa.foo.global", TTL: 60, Targets: []Target{{Address: "10.0.0.1"}}})
+	assert.Nil(t, err)
+	obj, _ = client.Resource(DNSEndpointResource).Namespace("admiral").Get(ctx, "qa.foo.global", metaV1.GetOptions{})
+	endpoints, _, _ = unstructured.NestedSlice(obj.Object, "spec", "endpoints")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"dnsName": "qa.foo.global", "recordType": "A", "recordTTL": int64(60), "targets": []interface{}{"10.0.0.1"}},
+	}, endpoints)
+
+	names, err := provider.Records(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"qa.foo.global"}, names)
+
+	assert.Nil(t, provider.Delete(ctx, "qa.foo.global"))
+	assert.Nil(t, provider.Delete(ctx, "qa.foo.global"), "deleting a missing record should not fail")
+	names, _ = provider.Records(ctx)
+	assert.Empty(t, names)
+}
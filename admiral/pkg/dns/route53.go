@@ -0,0 +1,185 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	awsSession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+)
+
+// Route53Provider writes the records to a Route53 hosted zone. A TXT record is written along with each
+// record to mark it as created by admiral, the records of a name without it are left untouched
+type Route53Provider struct {
+	svc    route53iface.Route53API
+	zoneID string
+}
+
+func NewRoute53Provider(zoneID, role, region string) (*Route53Provider, error) {
+	session, err := awsSession.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	config := &aws.Config{}
+	if region != "" {
+		config.Region = aws.String(region)
+	}
+	if role != "" {
+		creds := stscreds.NewCredentials(session, role)
+		if _, err := creds.Get(); err != nil {
+			return nil, fmt.Errorf("aws credentials are invalid: %v", err)
+		}
+		config.Credentials = creds
+	}
+	return &Route53Provider{svc: route53.New(session, config), zoneID: zoneID}, nil
+}
+
+func (r *Route53Provider) Name() string {
+	return route53Provider
+}
+
+func (r *Route53Provider) Records(ctx context.Context) ([]string, error) {
+	names := make([]string, 0)
+	err := r.svc.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{HostedZoneId: aws.String(r.zoneID)},
+		func(output *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+			for _, rrs := range output.ResourceRecordSets {
+				if isOwnerRecordSet(rrs) {
+					names = append(names, trimDot(aws.StringValue(rrs.Name)))
+				}
+			}
+			return true
+		})
+	return names, err
+}
+
+func (r *Route53Provider) Upsert(ctx context.Context, record Record) error {
+	existing, err := r.getRecordSets(ctx, record.Name)
+	if err != nil {
+		return err
+	}
+	if !isOwned(existing) {
+		return fmt.Errorf("record %s is not owned by admiral", record.Name)
+	}
+	desired := []*route53.ResourceRecordSet{newRoute53RecordSet(record.Name, record.TTL, recordSet{recordType: recordTypeTXT, targets: []string{quote(ownerRecord)}})}
+	for _, rs := range getRecordSets(record) {
+		desired = append(desired, newRoute53RecordSet(record.Name, record.TTL, rs))
+	}
+	changes := make([]*route53.Change, 0)
+	for _, rrs := range existing {
+		if !containsRecordSet(desired, rrs) {
+			changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: rrs})
+		}
+	}
+	for _, rrs := range desired {
+		changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionUpsert), ResourceRecordSet: rrs})
+	}
+	return r.change(ctx, changes)
+}
+
+func (r *Route53Provider) Delete(ctx context.Context, name string) error {
+	existing, err := r.getRecordSets(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+	if !isOwned(existing) {
+		return fmt.Errorf("record %s is not owned by admiral", name)
+	}
+	changes := make([]*route53.Change, 0, len(existing))
+	for _, rrs := range existing {
+		changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: rrs})
+	}
+	return r.change(ctx, changes)
+}
+
+// getRecordSets returns the A, CNAME and TXT record sets of the name
+func (r *Route53Provider) getRecordSets(ctx context.Context, name string) ([]*route53.ResourceRecordSet, error) {
+	recordSets := make([]*route53.ResourceRecordSet, 0)
+	input := &route53.ListResourceRecordSetsInput{HostedZoneId: aws.String(r.zoneID), StartRecordName: aws.String(name)}
+	err := r.svc.ListResourceRecordSetsPagesWithContext(ctx, input, func(output *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+		for _, rrs := range output.ResourceRecordSets {
+			if !strings.EqualFold(trimDot(aws.StringValue(rrs.Name)), trimDot(name)) {
+				return false
+			}
+			switch aws.StringValue(rrs.Type) {
+			case recordTypeA, recordTypeCNAME, recordTypeTXT:
+				recordSets = append(recordSets, rrs)
+			}
+		}
+		return true
+	})
+	return recordSets, err
+}
+
+func (r *Route53Provider) change(ctx context.Context, changes []*route53.Change) error {
+	_, err := r.svc.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(r.zoneID),
+		ChangeBatch:  &route53.ChangeBatch{Changes: changes},
+	})
+	return err
+}
+
+func newRoute53RecordSet(name string, ttl int64, rs recordSet) *route53.ResourceRecordSet {
+	rrs := &route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: aws.String(rs.recordType),
+		TTL:  aws.Int64(ttl),
+	}
+	if rs.identifier != "" {
+		rrs.SetIdentifier = aws.String(rs.identifier)
+		rrs.Region = aws.String(rs.region)
+	}
+	for _, target := range rs.targets {
+		rrs.ResourceRecords = append(rrs.ResourceRecords, &route53.ResourceRecord{Value: aws.String(target)})
+	}
+	return rrs
+}
+
+// isOwned returns true when the name has no record sets yet, or they are marked as created by admiral
+func isOwned(recordSets []*route53.ResourceRecordSet) bool {
+	if len(recordSets) == 0 {
+		return true
+	}
+	for _, rrs := range recordSets {
+		if isOwnerRecordSet(rrs) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOwnerRecordSet(rrs *route53.ResourceRecordSet) bool {
+	if aws.StringValue(rrs.Type) != recordTypeTXT {
+		return false
+	}
+	for _, rr := range rrs.ResourceRecords {
+		if aws.StringValue(rr.Value) == quote(ownerRecord) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsRecordSet(recordSets []*route53.ResourceRecordSet, rrs *route53.ResourceRecordSet) bool {
+	for _, recordSet := range recordSets {
+		if aws.StringValue(recordSet.Type) == aws.StringValue(rrs.Type) &&
+			aws.StringValue(recordSet.SetIdentifier) == aws.StringValue(rrs.SetIdentifier) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimDot(name string) string {
+	return strings.TrimSuffix(name, ".")
+}
+
+func quote(value string) string {
+	return "\"" + value + "\""
+}
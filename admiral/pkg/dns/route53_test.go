@@ -0,0 +1,90 @@
+package dns
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRoute53 holds the record sets of a hosted zone, keyed by name, type and set identifier
+type fakeRoute53 struct {
+	route53iface.Route53API
+	recordSets map[string]*route53.ResourceRecordSet
+}
+
+func recordSetKey(rrs *route53.ResourceRecordSet) string {
+	return aws.StringValue(rrs.Name) + "/" + aws.StringValue(rrs.Type) + "/" + aws.StringValue(rrs.SetIdentifier)
+}
+
+func (f *fakeRoute53) ListResourceRecordSetsPagesWithContext(ctx aws.Context, input *route53.ListResourceRecordSetsInput,
+	fn func(*route53.ListResourceRecordSetsOutput, bool) bool, opts ...request.Option) error {
+	keys := make([]string, 0, len(f.recordSets))
+	for key := range f.recordSets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	output := &route53.ListResourceRecordSetsOutput{}
+	for _, key := range keys {
+		if key >= aws.StringValue(input.StartRecordName) {
+			output.ResourceRecordSets = append(output.ResourceRecordSets, f.recordSets[key])
+		}
+	}
+	fn(output, true)
+	return nil
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSetsWithContext(ctx aws.Context, input *route53.ChangeResourceRecordSetsInput,
+	opts ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	for _, change := range input.ChangeBatch.Changes {
+		if aws.StringValue(change.Action) == route53.ChangeActionDelete {
+			delete(f.recordSets, recordSetKey(change.ResourceRecordSet))
+		} else {
+			f.recordSets[recordSetKey(change.ResourceRecordSet)] = change.ResourceRecordSet
+		}
+	}
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+func TestRoute53Provider(t *testing.T) {
+	ctx := context.Background()
+	external := &route53.ResourceRecordSet{Name: aws.String("qa.bar.global."), Type: aws.String("CNAME"), TTL: aws.Int64(300),
+		ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("bar.example.com")}}}
+	fake := &fakeRoute53{recordSets: map[string]*route53.ResourceRecordSet{recordSetKey(external): external}}
+	provider := &Route53Provider{svc: fake, zoneID: "zone"}
+
+	err := provider.Upsert(ctx, Record{Name: "qa.foo.global", TTL: 60, Targets: []Target{
+		{Address: "10.0.0.1", Region: "us-west-2"},
+		{Address: "10.0.1.1", Region: "us-east-2"},
+	}})
+	assert.Nil(t, err)
+	westRecordSet := fake.recordSets["qa.foo.global/A/us-west-2"]
+	assert.NotNil(t, westRecordSet)
+	assert.Equal(t, "us-west-2", aws.StringValue(westRecordSet.Region))
+	assert.Equal(t, "10.0.0.1", aws.StringValue(westRecordSet.ResourceRecords[0].Value))
+	assert.NotNil(t, fake.recordSets["qa.foo.global/A/us-east-2"])
+	assert.NotNil(t, fake.recordSets["qa.foo.global/TXT/"])
+
+	err = provider.Upsert(ctx, Record{Name: "qa.foo.global", TTL: 60, Targets: []Target{{Address: "lb.example.com", Region: "us-west-2"}}})
+	assert.Nil(t, err)
+	assert.Nil(t, fake.recordSets["qa.foo.global/A/us-west-2"], "the record sets of the regions no longer served should be deleted")
+	assert.Nil(t, fake.recordSets["qa.foo.global/A/us-east-2"])
+	assert.Equal(t, "lb.example.com", aws.StringValue(fake.recordSets["qa.foo.global/CNAME/"].ResourceRecords[0].Value))
+
+	names, err := provider.Records(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"qa.foo.global"}, names)
+
+	assert.NotNil(t, provider.Upsert(ctx, Record{Name: "qa.bar.global", TTL: 60, Targets: []Target{{Address: "lb.example.com"}}}),
+		"the records not created by admiral should not be updated")
+	assert.NotNil(t, provider.Delete(ctx, "qa.bar.global"), "the records not created by admiral should not be deleted")
+	assert.Equal(t, "bar.example.com", aws.StringValue(fake.recordSets["qa.bar.global./CNAME/"].ResourceRecords[0].Value))
+
+	assert.Nil(t, provider.Delete(ctx, "qa.foo.global"))
+	assert.Len(t, fake.recordSets, 1)
+}
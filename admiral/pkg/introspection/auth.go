@@ -0,0 +1,168 @@
+package introspection
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcCredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "Bearer "
+)
+
+type callerContextKey struct{}
+
+// apiToken is a token allowed to call the API. A token restricted to teams is only served the state of the
+// identities of those teams
+type apiToken struct {
+	value string
+	teams []string
+}
+
+// restricted checks if the token is restricted to teams
+func (t *apiToken) restricted() bool {
+	return t != nil && len(t.teams) > 0
+}
+
+// allows checks if the caller with the token is served the state of the identity
+func (t *apiToken) allows(identity string) bool {
+	if !t.restricted() {
+		return true
+	}
+	team := clusters.GetIdentityTeam(identity)
+	for _, allowed := range t.teams {
+		if strings.EqualFold(allowed, team) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseToken returns the token of a line of the token file, which is the token optionally followed by the comma
+// separated teams it is restricted to
+func parseToken(line string) apiToken {
+	fields := strings.Fields(line)
+	token := apiToken{value: fields[0]}
+	if len(fields) > 1 {
+		for _, team := range strings.Split(strings.Join(fields[1:], ","), ",") {
+			if team = strings.TrimSpace(team); team != "" {
+				token.teams = append(token.teams, team)
+			}
+		}
+	}
+	return token
+}
+
+// loadTokens reads the tokens allowed to call the API, one per line, from the file. A token followed by comma
+// separated teams is restricted to the identities of those teams
+func loadTokens(tokenFile string) ([]apiToken, error) {
+	if tokenFile == "" {
+		return nil, fmt.Errorf("introspection token file is required")
+	}
+	content, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read introspection token file %s: %v", tokenFile, err)
+	}
+	var tokens []apiToken
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			tokens = append(tokens, parseToken(line))
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("introspection token file %s has no tokens", tokenFile)
+	}
+	return tokens, nil
+}
+
+// authenticate returns the token of the tokens which is the bearer token in the authorization metadata of the call.
+// When it is none of them, the call is authenticated with the auth, as a caller bound to the viewer role which is not
+// restricted to teams
+func authenticate(ctx context.Context, tokens []apiToken, authn *auth.Auth) (*apiToken, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	credentials := auth.Credentials{}
+	for _, value := range md.Get(authorizationHeader) {
+		if !strings.HasPrefix(value, bearerPrefix) {
+			continue
+		}
+		presented := []byte(strings.TrimPrefix(value, bearerPrefix))
+		for i := range tokens {
+			if subtle.ConstantTimeCompare(presented, []byte(tokens[i].value)) == 1 {
+				return &tokens[i], nil
+			}
+		}
+		credentials.BearerToken = string(presented)
+	}
+	if authn == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(grpcCredentials.TLSInfo); ok {
+			credentials.VerifiedChains = tlsInfo.State.VerifiedChains
+		}
+	}
+	principal, err := authn.Authenticate(ctx, credentials)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !principal.HasRole(auth.RoleViewer) {
+		return nil, status.Errorf(codes.PermissionDenied, "user %s is not bound to the %s role", principal.User, auth.RoleViewer)
+	}
+	return &apiToken{}, nil
+}
+
+// callerFromContext returns the token the call was authenticated with, nil when it was not authenticated
+func callerFromContext(ctx context.Context) *apiToken {
+	caller, _ := ctx.Value(callerContextKey{}).(*apiToken)
+	return caller
+}
+
+// authorize checks the caller of the call is served the state of the identity
+func authorize(ctx context.Context, identity string) error {
+	if !callerFromContext(ctx).allows(identity) {
+		return status.Errorf(codes.PermissionDenied, "identity %s does not belong to the teams of the caller", identity)
+	}
+	return nil
+}
+
+// authenticatedStream is a server stream whose context carries the token the call was authenticated with
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func unaryAuthInterceptor(tokens []apiToken, authn *auth.Auth) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		caller, err := authenticate(ctx, tokens, authn)
+		if err != nil {
+			return nil, err
+		}
+		return handler(context.WithValue(ctx, callerContextKey{}, caller), req)
+	}
+}
+
+func streamAuthInterceptor(tokens []apiToken, authn *auth.Auth) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		caller, err := authenticate(ss.Context(), tokens, authn)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), callerContextKey{}, caller)})
+	}
+}
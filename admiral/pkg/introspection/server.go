@@ -0,0 +1,468 @@
+package introspection
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	api "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/introspection"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	streamBufferSize = 100
+
+	SyncEventSucceeded = "Succeeded"
+	SyncEventFailed    = "Failed"
+)
+
+// Server serves the introspection API from the remote registry and the results of the
+// creates, updates and deletes admiral performs. The latest changes of each identity are kept in its changelog,
+// along with the identity of each resource, as the deletes of the VirtualServices only know their name
+type Server struct {
+	api.UnimplementedIntrospectionServer
+	remoteRegistry *clusters.RemoteRegistry
+	lock           sync.RWMutex
+	lastResults    map[string]*api.SyncResult
+	resultOwners   map[string]string
+	subscribers    map[chan *api.SyncResult]syncResultSubscription
+	eventStreams   map[chan *api.SyncEvent]syncEventSubscription
+	changelogs     map[string][]*api.IdentityChange
+	owners         map[string]string
+}
+
+// syncResultSubscription is a stream of sync results along with the caller which opened it
+type syncResultSubscription struct {
+	req    *api.StreamSyncEventsRequest
+	caller *apiToken
+}
+
+// syncEventSubscription is a stream of sync events along with the caller which opened it
+type syncEventSubscription struct {
+	req    *api.StreamEventsRequest
+	caller *apiToken
+}
+
+// NewServer returns a server which keeps track of the sync results admiral records from now on
+func NewServer(remoteRegistry *clusters.RemoteRegistry) *Server {
+	s := &Server{
+		remoteRegistry: remoteRegistry,
+		lastResults:    make(map[string]*api.SyncResult),
+		resultOwners:   make(map[string]string),
+		subscribers:    make(map[chan *api.SyncResult]syncResultSubscription),
+		eventStreams:   make(map[chan *api.SyncEvent]syncEventSubscription),
+		changelogs:     make(map[string][]*api.IdentityChange),
+		owners:         make(map[string]string),
+	}
+	audit.AddListener(s.onRecord)
+	return s
+}
+
+// Start serves the introspection API on the port until the context is done. Every call has
+// to carry one of the tokens in the token file, and the calls with a token restricted to teams are only served the
+// state of the identities of those teams. The other calls are authenticated with the auth when it is set. The API is
+// served over TLS when a certificate is set, verifying the client certificates against the client CAs when set.
+func Start(ctx context.Context, port int, s *Server, tokenFile, certFile, keyFile, clientCAFile string, authn *auth.Auth) error {
+	tokens, err := loadTokens(tokenFile)
+	if err != nil {
+		return err
+	}
+	var opts []grpc.ServerOption
+	if certFile != "" {
+		creds, err := newServerCredentials(certFile, keyFile, clientCAFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	grpcServer := newGRPCServer(s, tokens, authn, opts...)
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+	log.Infof("Starting introspection server on port=%d", port)
+	return grpcServer.Serve(listener)
+}
+
+// newServerCredentials returns the TLS credentials of the server certificate, verifying the client certificates
+// presented against the CAs of the client CA file when set
+func newServerCredentials(certFile, keyFile, clientCAFile string) (credentials.TransportCredentials, error) {
+	if clientCAFile == "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load introspection server certificate: %v", err)
+		}
+		return creds, nil
+	}
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load introspection server certificate: %v", err)
+	}
+	clientCAPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file %s: %v", clientCAFile, err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(clientCAPEM) {
+		return nil, fmt.Errorf("client CA file %s has no certificates", clientCAFile)
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+// newGRPCServer returns a grpc server serving the introspection API to callers with one of the tokens, or
+// authenticated with the auth
+func newGRPCServer(s *Server, tokens []apiToken, authn *auth.Auth, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.UnaryInterceptor(unaryAuthInterceptor(tokens, authn)),
+		grpc.StreamInterceptor(streamAuthInterceptor(tokens, authn)))
+	grpcServer := grpc.NewServer(opts...)
+	api.RegisterIntrospectionServer(grpcServer, s)
+	return grpcServer
+}
+
+func (s *Server) GetIdentityState(ctx context.Context, req *api.GetIdentityStateRequest) (*api.IdentityState, error) {
+	if req.GetIdentity() == "" {
+		return nil, status.Error(codes.InvalidArgument, "identity is required")
+	}
+	if err := authorize(ctx, req.GetIdentity()); err != nil {
+		return nil, err
+	}
+	state := clusters.GetIdentityState(s.remoteRegistry, req.GetIdentity())
+	return &api.IdentityState{
+		Identity:      req.GetIdentity(),
+		Clusters:      state.Clusters,
+		Envs:          state.Envs,
+		Regions:       state.Regions,
+		Dependents:    state.Dependents,
+		SyncNamespace: state.SyncNamespace,
+	}, nil
+}
+
+func (s *Server) ListDependentClusters(ctx context.Context, req *api.ListDependentClustersRequest) (*api.ListDependentClustersResponse, error) {
+	if req.GetHost() == "" {
+		return nil, status.Error(codes.InvalidArgument, "host is required")
+	}
+	if err := authorize(ctx, clusters.GetHostIdentity(s.remoteRegistry, req.GetHost())); err != nil {
+		return nil, err
+	}
+	return &api.ListDependentClustersResponse{Clusters: clusters.GetDependentClusters(s.remoteRegistry, req.GetHost())}, nil
+}
+
+func (s *Server) GetLastSyncResult(ctx context.Context, req *api.GetLastSyncResultRequest) (*api.SyncResult, error) {
+	if req.GetKind() == "" || req.GetName() == "" || req.GetCluster() == "" {
+		return nil, status.Error(codes.InvalidArgument, "kind, name and cluster are required")
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	key := syncResultKey(req.GetKind(), req.GetName(), req.GetCluster())
+	result, ok := s.lastResults[key]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no sync result for %s %s in cluster %s", req.GetKind(), req.GetName(), req.GetCluster())
+	}
+	if err := authorize(ctx, s.resultOwners[key]); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Server) StreamSyncEvents(req *api.StreamSyncEventsRequest, stream api.Introspection_StreamSyncEventsServer) error {
+	results := make(chan *api.SyncResult, streamBufferSize)
+	s.lock.Lock()
+	s.subscribers[results] = syncResultSubscription{req: req, caller: callerFromContext(stream.Context())}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.subscribers, results)
+		s.lock.Unlock()
+	}()
+	// the headers tell the client the stream receives the results from now on
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case result := <-results:
+			if err := stream.Send(result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) StreamEvents(req *api.StreamEventsRequest, stream api.Introspection_StreamEventsServer) error {
+	if err := validateStreamEventsRequest(req); err != nil {
+		return err
+	}
+	events := make(chan *api.SyncEvent, streamBufferSize)
+	s.lock.Lock()
+	s.eventStreams[events] = syncEventSubscription{req: req, caller: callerFromContext(stream.Context())}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		delete(s.eventStreams, events)
+		s.lock.Unlock()
+	}()
+	// the headers tell the client the stream receives the events from now on
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) GetDependencyGraph(ctx context.Context, req *api.GetDependencyGraphRequest) (*api.DependencyGraph, error) {
+	// a caller restricted to teams is only served the graph walked from an identity of its teams
+	if req.GetIdentity() == "" && callerFromContext(ctx).restricted() {
+		return nil, status.Error(codes.PermissionDenied, "identity is required for a caller restricted to teams")
+	}
+	if err := authorize(ctx, req.GetIdentity()); err != nil {
+		return nil, err
+	}
+	graph, err := clusters.GetDependencyGraph(s.remoteRegistry, clusters.DependencyGraphQuery{
+		Identity:  req.GetIdentity(),
+		Direction: req.GetDirection(),
+		Depth:     int(req.GetDepth()),
+		Cluster:   req.GetCluster(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	response := &api.DependencyGraph{}
+	for _, node := range graph.Nodes {
+		response.Nodes = append(response.Nodes, &api.DependencyGraphNode{Identity: node.Identity, Clusters: node.Clusters})
+	}
+	for _, edge := range graph.Edges {
+		response.Edges = append(response.Edges, &api.DependencyGraphEdge{Source: edge.Source, Destination: edge.Destination})
+	}
+	return response, nil
+}
+
+func (s *Server) GetIdentityChangelog(ctx context.Context, req *api.GetIdentityChangelogRequest) (*api.IdentityChangelog, error) {
+	if req.GetIdentity() == "" {
+		return nil, status.Error(codes.InvalidArgument, "identity is required")
+	}
+	if err := authorize(ctx, req.GetIdentity()); err != nil {
+		return nil, err
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	changes := s.changelogs[strings.ToLower(req.GetIdentity())]
+	response := &api.IdentityChangelog{}
+	for i := len(changes) - 1; i >= 0; i-- {
+		timestamp := changes[i].GetTimestamp().AsTime()
+		if req.GetSince() != nil && timestamp.Before(req.GetSince().AsTime()) {
+			continue
+		}
+		if req.GetUntil() != nil && !timestamp.Before(req.GetUntil().AsTime()) {
+			continue
+		}
+		response.Changes = append(response.Changes, changes[i])
+	}
+	return response, nil
+}
+
+// onRecord keeps the result of the record as the last one of the resource and passes it
+// on to the streams it matches and whose caller is served the identity of the resource, dropping
+// it for streams which cannot keep up
+func (s *Server) onRecord(record audit.Record) {
+	result := &api.SyncResult{
+		Kind:      record.Kind,
+		Name:      record.Name,
+		Namespace: record.Namespace,
+		Cluster:   record.Cluster,
+		Operation: record.Operation,
+		Error:     record.Error,
+		TxId:      record.TxId,
+		Timestamp: timestamppb.New(record.Timestamp),
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// the owner of the resource is looked up before the change of a delete forgets it
+	identity := record.Identity
+	if identity == "" {
+		identity = s.owners[resourceKey(record)]
+	}
+	key := syncResultKey(record.Kind, record.Name, record.Cluster)
+	s.lastResults[key] = result
+	s.resultOwners[key] = identity
+	s.recordChange(record)
+	s.sendEvent(record, identity)
+	for results, subscription := range s.subscribers {
+		req := subscription.req
+		if (req.GetCluster() != "" && req.GetCluster() != result.Cluster) || (req.GetKind() != "" && req.GetKind() != result.Kind) {
+			continue
+		}
+		if !subscription.caller.allows(identity) {
+			continue
+		}
+		select {
+		case results <- result:
+		default:
+			log.Warnf("introspection stream buffer is full, dropping sync result for %s %s in cluster %s",
+				result.Kind, result.Name, result.Cluster)
+		}
+	}
+}
+
+// recordChange adds the change of the record to the changelog of the identity the resource belongs to, dropping
+// the oldest change once the changelog is full. The records of the diff-only mode are left out, as nothing changed
+func (s *Server) recordChange(record audit.Record) {
+	size := common.GetIdentityChangelogSize()
+	if size <= 0 || record.DryRun {
+		return
+	}
+	key := resourceKey(record)
+	identity := strings.ToLower(record.Identity)
+	if identity == "" {
+		identity = s.owners[key]
+	}
+	if record.Operation == audit.OperationDelete && record.Error == "" {
+		delete(s.owners, key)
+	} else if identity != "" {
+		s.owners[key] = identity
+	}
+	if identity == "" {
+		return
+	}
+	changes := append(s.changelogs[identity], &api.IdentityChange{
+		Kind:            record.Kind,
+		Name:            record.Name,
+		Namespace:       record.Namespace,
+		Cluster:         record.Cluster,
+		Operation:       record.Operation,
+		Diff:            record.Diff,
+		TriggeringEvent: record.TriggeringEvent,
+		TriggeringKind:  record.TriggeringKind,
+		Error:           record.Error,
+		TxId:            record.TxId,
+		Timestamp:       timestamppb.New(record.Timestamp),
+	})
+	if len(changes) > size {
+		changes = changes[len(changes)-size:]
+	}
+	s.changelogs[identity] = changes
+}
+
+// sendEvent passes the record on, as an event of the resource of the identity, to the event streams whose
+// filters it matches and whose caller is served the identity, dropping it for streams which cannot keep up
+func (s *Server) sendEvent(record audit.Record, identity string) {
+	if len(s.eventStreams) == 0 {
+		return
+	}
+	event := &api.SyncEvent{
+		Kind:            record.Kind,
+		Name:            record.Name,
+		Namespace:       record.Namespace,
+		Cluster:         record.Cluster,
+		Identity:        identity,
+		Operation:       record.Operation,
+		Result:          SyncEventSucceeded,
+		Error:           record.Error,
+		TxId:            record.TxId,
+		TriggeringEvent: record.TriggeringEvent,
+		TriggeringKind:  record.TriggeringKind,
+		DryRun:          record.DryRun,
+		Timestamp:       timestamppb.New(record.Timestamp),
+	}
+	if record.Error != "" {
+		event.Result = SyncEventFailed
+	}
+	for events, subscription := range s.eventStreams {
+		if !matchesEvent(subscription.req, event) || !subscription.caller.allows(identity) {
+			continue
+		}
+		select {
+		case events <- event:
+		default:
+			log.Warnf("introspection event stream buffer is full, dropping sync event for %s %s in cluster %s",
+				event.Kind, event.Name, event.Cluster)
+		}
+	}
+}
+
+// validateStreamEventsRequest checks the operations and the result the request filters on are known
+func validateStreamEventsRequest(req *api.StreamEventsRequest) error {
+	for _, operation := range req.GetOperations() {
+		if operation != audit.OperationCreate && operation != audit.OperationUpdate && operation != audit.OperationDelete {
+			return status.Errorf(codes.InvalidArgument, "operation %s is not one of %s, %s or %s",
+				operation, audit.OperationCreate, audit.OperationUpdate, audit.OperationDelete)
+		}
+	}
+	if result := req.GetResult(); result != "" && result != SyncEventSucceeded && result != SyncEventFailed {
+		return status.Errorf(codes.InvalidArgument, "result %s is not one of %s or %s", result, SyncEventSucceeded, SyncEventFailed)
+	}
+	return nil
+}
+
+// matchesEvent checks the event passes every filter of the request
+func matchesEvent(req *api.StreamEventsRequest, event *api.SyncEvent) bool {
+	if event.DryRun && !req.GetIncludeDryRun() {
+		return false
+	}
+	if !matchesAny(req.GetClusters(), event.Cluster) || !matchesAny(req.GetKinds(), event.Kind) || !matchesAny(req.GetOperations(), event.Operation) {
+		return false
+	}
+	if req.GetIdentity() != "" && !strings.EqualFold(req.GetIdentity(), event.Identity) {
+		return false
+	}
+	if req.GetNamespace() != "" && req.GetNamespace() != event.Namespace {
+		return false
+	}
+	return req.GetResult() == "" || req.GetResult() == event.Result
+}
+
+// matchesAny checks the value is one of the values, any value matches when there are none
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceKey(record audit.Record) string {
+	return record.Cluster + "/" + record.Kind + "/" + record.Namespace + "/" + strings.ToLower(record.Name)
+}
+
+func syncResultKey(kind, name, cluster string) string {
+	return kind + "/" + name + "/" + cluster
+}
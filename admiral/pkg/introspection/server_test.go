@@ -0,0 +1,484 @@
+package introspection
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/introspection"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/audit"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/auth"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/clusters"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/common"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newTestClient(t *testing.T, s *Server, tokens []string) api.IntrospectionClient {
+	listener := bufconn.Listen(1024 * 1024)
+	var apiTokens []apiToken
+	for _, token := range tokens {
+		apiTokens = append(apiTokens, parseToken(token))
+	}
+	grpcServer := newGRPCServer(s, apiTokens, nil)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return api.NewIntrospectionClient(conn)
+}
+
+func TestLoadTokens(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "tokens")
+	assert.Nil(t, os.WriteFile(tokenFile, []byte("token1\n\n token2 team1, team2 \n"), 0600))
+	emptyFile := filepath.Join(dir, "empty")
+	assert.Nil(t, os.WriteFile(emptyFile, []byte("\n"), 0600))
+
+	testCases := []struct {
+		name           string
+		tokenFile      string
+		expectedTokens []apiToken
+		expectedErr    bool
+	}{
+		{
+			name: "Given a token file with a token per line, " +
+				"When loadTokens is called, " +
+				"Then it should return the tokens, along with the teams they are restricted to",
+			tokenFile:      tokenFile,
+			expectedTokens: []apiToken{{value: "token1"}, {value: "token2", teams: []string{"team1", "team2"}}},
+		},
+		{
+			name: "Given no token file, " +
+				"When loadTokens is called, " +
+				"Then it should return an error",
+			expectedErr: true,
+		},
+		{
+			name: "Given a token file without tokens, " +
+				"When loadTokens is called, " +
+				"Then it should return an error",
+			tokenFile:   emptyFile,
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens, err := loadTokens(c.tokenFile)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expectedTokens, tokens)
+		})
+	}
+}
+
+func TestIntrospectionServer(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	rr := clusters.NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster2", "cluster2")
+	rr.AdmiralCache.IdentityClusterCache.Put("foo", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityDependencyCache.Put("foo", "bar", "bar")
+	rr.AdmiralCache.CnameDependentClusterCache.Put("stage.foo.global", "cluster3", "cluster3")
+	client := newTestClient(t, NewServer(rr), []string{"secret"})
+	authCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	testCases := []struct {
+		name         string
+		ctx          context.Context
+		call         func(ctx context.Context) (interface{}, error)
+		expected     interface{}
+		expectedCode codes.Code
+	}{
+		{
+			name: "Given a call without a bearer token, " +
+				"When GetIdentityState is called, " +
+				"Then it should be rejected as unauthenticated",
+			ctx: context.Background(),
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "foo"})
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name: "Given a call with an unknown bearer token, " +
+				"When GetIdentityState is called, " +
+				"Then it should be rejected as unauthenticated",
+			ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer guess"),
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "foo"})
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name: "Given an identity known to admiral, " +
+				"When GetIdentityState is called, " +
+				"Then it should return its clusters and dependents",
+			ctx: authCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				state, err := client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "foo"})
+				if err != nil {
+					return nil, err
+				}
+				return []interface{}{state.Clusters, state.Dependents}, nil
+			},
+			expected: []interface{}{[]string{"cluster1", "cluster2"}, []string{"bar"}},
+		},
+		{
+			name: "Given a request without an identity, " +
+				"When GetIdentityState is called, " +
+				"Then it should return invalid argument",
+			ctx: authCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityState(ctx, &api.GetIdentityStateRequest{})
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Given a host synced to a cluster, " +
+				"When ListDependentClusters is called, " +
+				"Then it should return the cluster",
+			ctx: authCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				resp, err := client.ListDependentClusters(ctx, &api.ListDependentClustersRequest{Host: "stage.foo.global"})
+				if err != nil {
+					return nil, err
+				}
+				return resp.Clusters, nil
+			},
+			expected: []string{"cluster3"},
+		},
+		{
+			name: "Given a resource admiral has not synced, " +
+				"When GetLastSyncResult is called, " +
+				"Then it should return not found",
+			ctx: authCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetLastSyncResult(ctx, &api.GetLastSyncResultRequest{Kind: "ServiceEntry", Name: "unknown-se", Cluster: "cluster1"})
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "Given an identity with a dependent, " +
+				"When GetDependencyGraph is called, " +
+				"Then it should return the identities and the dependency between them",
+			ctx: authCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				graph, err := client.GetDependencyGraph(ctx, &api.GetDependencyGraphRequest{Identity: "foo", Depth: 1})
+				if err != nil {
+					return nil, err
+				}
+				return []interface{}{len(graph.Nodes), graph.Nodes[1].Clusters, graph.Edges[0].Source, graph.Edges[0].Destination}, nil
+			},
+			expected: []interface{}{2, []string{"cluster1", "cluster2"}, "bar", "foo"},
+		},
+		{
+			name: "Given an unknown direction, " +
+				"When GetDependencyGraph is called, " +
+				"Then it should return invalid argument",
+			ctx: authCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetDependencyGraph(ctx, &api.GetDependencyGraphRequest{Direction: "sideways"})
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			actual, err := c.call(c.ctx)
+			if c.expectedCode != codes.OK {
+				assert.Equal(t, c.expectedCode, status.Code(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestSyncResults(t *testing.T) {
+	client := newTestClient(t, NewServer(nil), []string{"secret"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	stream, err := client.StreamSyncEvents(ctx, &api.StreamSyncEventsRequest{Cluster: "cluster1"})
+	assert.Nil(t, err)
+	// the stream is registered once its headers are received
+	_, err = stream.Header()
+	assert.Nil(t, err)
+	audit.Log(audit.Record{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "other-se", Cluster: "cluster2"})
+	audit.Log(audit.Record{Operation: audit.OperationUpdate, Kind: "ServiceEntry", Name: "foo-se", Cluster: "cluster1", TxId: "abc"})
+
+	result, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo-se", result.Name)
+	assert.Equal(t, "cluster1", result.Cluster)
+
+	last, err := client.GetLastSyncResult(ctx, &api.GetLastSyncResultRequest{Kind: "ServiceEntry", Name: "foo-se", Cluster: "cluster1"})
+	assert.Nil(t, err)
+	assert.Equal(t, audit.OperationUpdate, last.Operation)
+	assert.Equal(t, "abc", last.TxId)
+}
+
+func TestStreamEvents(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, IdentityChangelogSize: 10})
+	s := NewServer(nil)
+	client := newTestClient(t, s, []string{"secret"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	invalid, err := client.StreamEvents(ctx, &api.StreamEventsRequest{Operations: []string{"Patch"}})
+	assert.Nil(t, err)
+	_, err = invalid.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err := client.StreamEvents(ctx, &api.StreamEventsRequest{
+		Clusters:   []string{"cluster1"},
+		Operations: []string{audit.OperationCreate, audit.OperationDelete},
+		Identity:   "foo",
+	})
+	assert.Nil(t, err)
+	// the stream is registered once its headers are received
+	_, err = stream.Header()
+	assert.Nil(t, err)
+	failed, err := client.StreamEvents(ctx, &api.StreamEventsRequest{Result: SyncEventFailed, IncludeDryRun: true})
+	assert.Nil(t, err)
+	_, err = failed.Header()
+	assert.Nil(t, err)
+
+	s.onRecord(audit.Record{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "foo-se", Cluster: "cluster2", Identity: "foo"})
+	s.onRecord(audit.Record{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "bar-se", Cluster: "cluster1", Identity: "bar"})
+	s.onRecord(audit.Record{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "foo-se", Cluster: "cluster1", Identity: "foo", DryRun: true})
+	s.onRecord(audit.Record{Operation: audit.OperationCreate, Kind: "VirtualService", Name: "foo-vs", Namespace: "ns", Cluster: "cluster1",
+		Identity: "Foo", TriggeringEvent: "Add", TriggeringKind: "Deployment", TxId: "tx1"})
+	s.onRecord(audit.Record{Operation: audit.OperationUpdate, Kind: "VirtualService", Name: "foo-vs", Namespace: "ns", Cluster: "cluster1", Identity: "foo"})
+	// the deletes of the VirtualServices only know their name
+	s.onRecord(audit.Record{Operation: audit.OperationDelete, Kind: "VirtualService", Name: "foo-vs", Namespace: "ns", Cluster: "cluster1",
+		Error: "forbidden", TxId: "tx2"})
+
+	event, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "foo-vs", event.Name)
+	assert.Equal(t, audit.OperationCreate, event.Operation)
+	assert.Equal(t, SyncEventSucceeded, event.Result)
+	assert.Equal(t, "Foo", event.Identity)
+	assert.Equal(t, "Deployment", event.TriggeringKind)
+	assert.Equal(t, "tx1", event.TxId)
+	event, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, audit.OperationDelete, event.Operation)
+	assert.Equal(t, SyncEventFailed, event.Result)
+	assert.Equal(t, "foo", event.Identity)
+	assert.Equal(t, "tx2", event.TxId)
+
+	event, err = failed.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "forbidden", event.Error)
+	assert.Equal(t, "cluster1", event.Cluster)
+}
+
+func TestTeamRestrictedCaller(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{
+		LabelSet:              &common.LabelSet{},
+		TeamIdentityPrefixes:  map[string]string{"payments.": "payments"},
+		IdentityChangelogSize: 10,
+	})
+	rr := clusters.NewRemoteRegistry(context.Background(), common.AdmiralParams{})
+	rr.AdmiralCache.IdentityClusterCache.Put("payments.checkout", "cluster1", "cluster1")
+	rr.AdmiralCache.IdentityClusterCache.Put("orders.cart", "cluster1", "cluster1")
+	rr.AdmiralCache.CnameIdentityCache.Store("stage.orders.cart.global", "orders.cart")
+	s := NewServer(rr)
+	client := newTestClient(t, s, []string{"platform", "restricted payments"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	restrictedCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer restricted")
+	platformCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer platform")
+
+	testCases := []struct {
+		name         string
+		ctx          context.Context
+		call         func(ctx context.Context) (interface{}, error)
+		expectedCode codes.Code
+	}{
+		{
+			name: "Given a caller restricted to a team, When the state of an identity of the team is requested, Then it is served",
+			ctx:  restrictedCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "payments.checkout"})
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "Given a caller restricted to a team, When the state of an identity of another team is requested, Then it is denied",
+			ctx:  restrictedCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "orders.cart"})
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name: "Given a caller restricted to a team, When the dependent clusters of a host of another team are requested, Then it is denied",
+			ctx:  restrictedCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.ListDependentClusters(ctx, &api.ListDependentClustersRequest{Host: "stage.orders.cart.global"})
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name: "Given a caller restricted to a team, When the whole dependency graph is requested, Then it is denied",
+			ctx:  restrictedCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetDependencyGraph(ctx, &api.GetDependencyGraphRequest{})
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name: "Given a caller restricted to a team, When the changelog of an identity of another team is requested, Then it is denied",
+			ctx:  restrictedCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityChangelog(ctx, &api.GetIdentityChangelogRequest{Identity: "orders.cart"})
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name: "Given a caller not restricted to teams, When the state of any identity is requested, Then it is served",
+			ctx:  platformCtx,
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "orders.cart"})
+			},
+			expectedCode: codes.OK,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.call(c.ctx)
+			assert.Equal(t, c.expectedCode, status.Code(err))
+		})
+	}
+
+	stream, err := client.StreamEvents(restrictedCtx, &api.StreamEventsRequest{})
+	assert.Nil(t, err)
+	_, err = stream.Header()
+	assert.Nil(t, err)
+	s.onRecord(audit.Record{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "cart-se", Cluster: "cluster1", Identity: "orders.cart"})
+	s.onRecord(audit.Record{Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "checkout-se", Cluster: "cluster1", Identity: "payments.checkout"})
+	event, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "checkout-se", event.Name)
+
+	_, err = client.GetLastSyncResult(restrictedCtx, &api.GetLastSyncResultRequest{Kind: "ServiceEntry", Name: "cart-se", Cluster: "cluster1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.GetLastSyncResult(restrictedCtx, &api.GetLastSyncResultRequest{Kind: "ServiceEntry", Name: "checkout-se", Cluster: "cluster1"})
+	assert.Nil(t, err)
+}
+
+// tokenAuthenticator authenticates the bearer tokens as the users they are mapped to
+type tokenAuthenticator map[string]string
+
+func (a tokenAuthenticator) Authenticate(ctx context.Context, credentials auth.Credentials) (*auth.Principal, error) {
+	if user, ok := a[credentials.BearerToken]; ok {
+		return &auth.Principal{User: user}, nil
+	}
+	return nil, nil
+}
+
+func TestAuthFallback(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}})
+	authn := auth.NewWithAuthenticators([]auth.Authenticator{tokenAuthenticator{"reviewed": "alice", "unbound": "eve"}},
+		map[string]string{"user:alice": auth.RoleViewer})
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := newGRPCServer(NewServer(nil), []apiToken{{value: "secret"}}, authn)
+	go func() { _ = grpcServer.Serve(listener) }()
+	defer grpcServer.Stop()
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+	client := api.NewIntrospectionClient(conn)
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedCode codes.Code
+	}{
+		{name: "Given one of the introspection tokens, Then the call is served", token: "secret", expectedCode: codes.OK},
+		{name: "Given a token the auth authenticates as a viewer, Then the call is served", token: "reviewed", expectedCode: codes.OK},
+		{name: "Given a token the auth authenticates without a role, Then the call is denied", token: "unbound", expectedCode: codes.PermissionDenied},
+		{name: "Given a token neither accepts, Then the call is unauthenticated", token: "unknown", expectedCode: codes.Unauthenticated},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+c.token)
+			_, err := client.GetIdentityState(ctx, &api.GetIdentityStateRequest{Identity: "foo"})
+			assert.Equal(t, c.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestIdentityChangelog(t *testing.T) {
+	common.ResetSync()
+	common.InitializeConfig(common.AdmiralParams{LabelSet: &common.LabelSet{}, IdentityChangelogSize: 2})
+	s := NewServer(nil)
+	client := newTestClient(t, s, []string{"secret"})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	start := time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC)
+
+	s.onRecord(audit.Record{Timestamp: start, Operation: audit.OperationCreate, Kind: "VirtualService", Name: "foo-vs",
+		Namespace: "ns", Cluster: "cluster1", Identity: "Foo", TriggeringEvent: "Add", TriggeringKind: "Deployment", TxId: "tx1"})
+	s.onRecord(audit.Record{Timestamp: start.Add(time.Minute), Operation: audit.OperationUpdate, Kind: "ServiceEntry", Name: "bar-se",
+		Namespace: "ns", Cluster: "cluster1", Identity: "bar", Diff: "spec"})
+	s.onRecord(audit.Record{Timestamp: start.Add(2 * time.Minute), Operation: audit.OperationUpdate, Kind: "VirtualService", Name: "foo-vs",
+		Namespace: "ns", Cluster: "cluster1", Identity: "foo", Diff: "spec", TxId: "tx2"})
+	// the deletes of the VirtualServices only know their name
+	s.onRecord(audit.Record{Timestamp: start.Add(3 * time.Minute), Operation: audit.OperationDelete, Kind: "VirtualService", Name: "foo-vs",
+		Namespace: "ns", Cluster: "cluster1", TxId: "tx3"})
+	// nothing changed in the diff-only mode
+	s.onRecord(audit.Record{Timestamp: start.Add(4 * time.Minute), Operation: audit.OperationCreate, Kind: "ServiceEntry", Name: "foo-se",
+		Namespace: "ns", Cluster: "cluster1", Identity: "foo", DryRun: true})
+
+	changelog, err := client.GetIdentityChangelog(ctx, &api.GetIdentityChangelogRequest{Identity: "foo"})
+	assert.Nil(t, err)
+	// the oldest change was dropped once the changelog was full, the latest change comes first
+	assert.Len(t, changelog.Changes, 2)
+	assert.Equal(t, "tx3", changelog.Changes[0].TxId)
+	assert.Equal(t, audit.OperationDelete, changelog.Changes[0].Operation)
+	assert.Equal(t, "tx2", changelog.Changes[1].TxId)
+	assert.Equal(t, "spec", changelog.Changes[1].Diff)
+
+	changelog, err = client.GetIdentityChangelog(ctx, &api.GetIdentityChangelogRequest{
+		Identity: "FOO",
+		Since:    timestamppb.New(start.Add(2 * time.Minute)),
+		Until:    timestamppb.New(start.Add(3 * time.Minute)),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, changelog.Changes, 1)
+	assert.Equal(t, "tx2", changelog.Changes[0].TxId)
+
+	changelog, err = client.GetIdentityChangelog(ctx, &api.GetIdentityChangelogRequest{Identity: "unknown"})
+	assert.Nil(t, err)
+	assert.Empty(t, changelog.Changes)
+
+	_, err = client.GetIdentityChangelog(ctx, &api.GetIdentityChangelogRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
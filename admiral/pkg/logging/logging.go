@@ -0,0 +1,164 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// modulePathPrefix precedes the module in the path of the source files, the module of
+// pkg/clusters/virtualservice_handler.go is clusters/virtualservice_handler
+const modulePathPrefix = "/pkg/"
+
+var (
+	mutex        sync.RWMutex
+	baseLevel    = log.InfoLevel
+	moduleLevels = make(map[string]log.Level)
+	installOnce  sync.Once
+)
+
+// Levels are the log levels in effect, the default level applies to the modules without a level
+type Levels struct {
+	Default string            `json:"default"`
+	Modules map[string]string `json:"modules"`
+}
+
+// moduleFormatter drops the entries logged from a module below the level of the module, and the
+// entries suppressed by the sampling of the module. The level of the logger is lowered to the lowest
+// module level, and the caller of the entries is reported to find their module, when a module has a
+// level or is sampled
+type moduleFormatter struct {
+	log.Formatter
+}
+
+func (f *moduleFormatter) Format(entry *log.Entry) ([]byte, error) {
+	if entry.Caller == nil {
+		return f.Formatter.Format(entry)
+	}
+	module := moduleOf(entry.Caller.File)
+	if entry.Level > levelOf(module) {
+		return nil, nil
+	}
+	emit, suppressed := sample(module, entry)
+	if !emit {
+		return nil, nil
+	}
+	// the caller is only reported to find the module, it is left out of the entry
+	withoutCaller := *entry
+	withoutCaller.Caller = nil
+	if suppressed > 0 {
+		withoutCaller.Data = make(log.Fields, len(entry.Data)+1)
+		for k, v := range entry.Data {
+			withoutCaller.Data[k] = v
+		}
+		withoutCaller.Data[suppressedField] = suppressed
+	}
+	return f.Formatter.Format(&withoutCaller)
+}
+
+// Init sets the default log level and the levels of the modules, e.g. clusters=debug or
+// clusters/virtualservice_handler=debug
+func Init(level log.Level, modules map[string]string) error {
+	installFormatter()
+	levels := make(map[string]log.Level, len(modules))
+	for module, value := range modules {
+		moduleLevel, err := log.ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("invalid log level of module %s: %v", module, err)
+		}
+		levels[normalizeModule(module)] = moduleLevel
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	baseLevel = level
+	moduleLevels = levels
+	apply()
+	return nil
+}
+
+// SetModuleLevel changes the log level of the module at runtime
+func SetModuleLevel(module, level string) error {
+	moduleLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	module = normalizeModule(module)
+	if module == "" {
+		return fmt.Errorf("module is empty")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	moduleLevels[module] = moduleLevel
+	apply()
+	return nil
+}
+
+// ResetModuleLevel removes the log level of the module, the default level applies to it again
+func ResetModuleLevel(module string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	delete(moduleLevels, normalizeModule(module))
+	apply()
+}
+
+// GetLevels returns the default log level and the levels of the modules
+func GetLevels() Levels {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	levels := Levels{Default: baseLevel.String(), Modules: make(map[string]string, len(moduleLevels))}
+	for module, level := range moduleLevels {
+		levels.Modules[module] = level.String()
+	}
+	return levels
+}
+
+func installFormatter() {
+	installOnce.Do(func() {
+		log.SetFormatter(&moduleFormatter{log.StandardLogger().Formatter})
+	})
+}
+
+// apply must be called with the lock held
+func apply() {
+	level := baseLevel
+	for _, moduleLevel := range moduleLevels {
+		if moduleLevel > level {
+			level = moduleLevel
+		}
+	}
+	log.SetLevel(level)
+	log.SetReportCaller(len(moduleLevels) > 0 || isSampling())
+}
+
+// moduleOf returns the module of the source file
+func moduleOf(file string) string {
+	module := file
+	if i := strings.LastIndex(file, modulePathPrefix); i >= 0 {
+		module = file[i+len(modulePathPrefix):]
+	}
+	return strings.TrimSuffix(module, ".go")
+}
+
+// belongsTo returns whether the module is the candidate module or belongs to it
+func belongsTo(module, candidate string) bool {
+	return module == candidate || strings.HasPrefix(module, candidate+"/")
+}
+
+// levelOf returns the level of the most specific module the module belongs to
+func levelOf(module string) log.Level {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	level, matched := baseLevel, ""
+	for candidate, candidateLevel := range moduleLevels {
+		if belongsTo(module, candidate) && len(candidate) > len(matched) {
+			level, matched = candidateLevel, candidate
+		}
+	}
+	return level
+}
+
+func normalizeModule(module string) string {
+	return strings.TrimSuffix(strings.Trim(strings.TrimSpace(module), "/"), ".go")
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelOf(t *testing.T) {
+	err := Init(log.InfoLevel, map[string]string{
+		"clusters":                         "debug",
+		"clusters/serviceentry":            "warn",
+		"controller/admiral/deployment.go": "trace",
+	})
+	assert.Nil(t, err)
+	defer Init(log.InfoLevel, nil)
+
+	testCases := []struct {
+		name          string
+		file          string
+		expectedLevel log.Level
+	}{
+		{
+			name: "Given a file of a package with a level, " +
+				"When levelOf is called, " +
+				"Then the level of the package should be returned",
+			file:          "/go/src/admiral/pkg/clusters/virtualservice_handler.go",
+			expectedLevel: log.DebugLevel,
+		},
+		{
+			name: "Given a file with a level in a package with a level, " +
+				"When levelOf is called, " +
+				"Then the level of the file should be returned",
+			file:          "/go/src/admiral/pkg/clusters/serviceentry.go",
+			expectedLevel: log.WarnLevel,
+		},
+		{
+			name: "Given a file whose name starts with the name of a file with a level, " +
+				"When levelOf is called, " +
+				"Then the level of the package should be returned",
+			file:          "/go/src/admiral/pkg/clusters/serviceentry_handler.go",
+			expectedLevel: log.DebugLevel,
+		},
+		{
+			name: "Given a file set with its extension, " +
+				"When levelOf is called, " +
+				"Then the level of the file should be returned",
+			file:          "/go/src/admiral/pkg/controller/admiral/deployment.go",
+			expectedLevel: log.TraceLevel,
+		},
+		{
+			name: "Given a file of a package without a level, " +
+				"When levelOf is called, " +
+				"Then the default level should be returned",
+			file:          "/go/src/admiral/pkg/controller/admiral/rollouts.go",
+			expectedLevel: log.InfoLevel,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expectedLevel, levelOf(moduleOf(c.file)))
+		})
+	}
+	assert.Equal(t, log.TraceLevel, log.GetLevel())
+}
+
+func TestModuleLevels(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	assert.Nil(t, Init(log.InfoLevel, nil))
+	defer Init(log.InfoLevel, nil)
+
+	log.Debug("debug before")
+	assert.Empty(t, buf.String())
+	assert.False(t, log.StandardLogger().ReportCaller)
+
+	assert.Nil(t, SetModuleLevel("logging", "debug"))
+	assert.Equal(t, Levels{Default: "info", Modules: map[string]string{"logging": "debug"}}, GetLevels())
+	log.Debug("debug while set")
+	assert.Contains(t, buf.String(), "debug while set")
+	assert.NotContains(t, buf.String(), "func=")
+
+	buf.Reset()
+	assert.Nil(t, SetModuleLevel("clusters", "debug"))
+	assert.Nil(t, SetModuleLevel("logging/logging_test", "warn"))
+	log.Info("info while warn")
+	assert.Empty(t, buf.String())
+
+	ResetModuleLevel("logging/logging_test")
+	ResetModuleLevel("logging")
+	ResetModuleLevel("clusters")
+	assert.Equal(t, log.InfoLevel, log.GetLevel())
+	assert.False(t, log.StandardLogger().ReportCaller)
+
+	assert.NotNil(t, SetModuleLevel("clusters", "verbose"))
+	assert.NotNil(t, Init(log.InfoLevel, map[string]string{"clusters": "verbose"}))
+}
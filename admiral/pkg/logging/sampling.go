@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// suppressedField counts the entries of the log site suppressed since the previous entry logged
+const suppressedField = "suppressed"
+
+var (
+	samplingMutex sync.Mutex
+	samplingRules = make(map[string]samplingRule)
+	sites         = make(map[string]*siteState)
+)
+
+// samplingRule logs one of every N entries of a log site, or one entry of a log site per window
+type samplingRule struct {
+	every  int
+	window time.Duration
+}
+
+type siteState struct {
+	count      int
+	suppressed int
+	last       time.Time
+}
+
+func parseSamplingRule(value string) (samplingRule, error) {
+	if every, err := strconv.Atoi(value); err == nil {
+		if every <= 0 {
+			return samplingRule{}, fmt.Errorf("sampling rate %d must be positive", every)
+		}
+		return samplingRule{every: every}, nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return samplingRule{}, fmt.Errorf("sampling rule %q is neither a positive count nor a positive duration", value)
+	}
+	return samplingRule{window: window}, nil
+}
+
+// InitSampling sets the sampling of the info, debug and trace entries of the modules, e.g. clusters=100
+// logs one of every 100 entries of each log site of the clusters package, and
+// clusters/serviceentry_handler=1m one entry of each of its log sites per minute. Warnings and errors
+// are never suppressed
+func InitSampling(modules map[string]string) error {
+	rules := make(map[string]samplingRule, len(modules))
+	for module, value := range modules {
+		rule, err := parseSamplingRule(value)
+		if err != nil {
+			return fmt.Errorf("invalid log sampling of module %s: %v", module, err)
+		}
+		rules[normalizeModule(module)] = rule
+	}
+	samplingMutex.Lock()
+	samplingRules = rules
+	sites = make(map[string]*siteState)
+	samplingMutex.Unlock()
+
+	installFormatter()
+	mutex.Lock()
+	defer mutex.Unlock()
+	apply()
+	return nil
+}
+
+func isSampling() bool {
+	samplingMutex.Lock()
+	defer samplingMutex.Unlock()
+	return len(samplingRules) > 0
+}
+
+// sample returns whether the entry is logged under the sampling rule of the most specific module
+// the module belongs to, along with the number of entries of its log site suppressed before it
+func sample(module string, entry *log.Entry) (bool, int) {
+	if entry.Level < log.InfoLevel {
+		return true, 0
+	}
+	samplingMutex.Lock()
+	defer samplingMutex.Unlock()
+	var (
+		rule    samplingRule
+		matched string
+	)
+	for candidate, candidateRule := range samplingRules {
+		if belongsTo(module, candidate) && len(candidate) > len(matched) {
+			rule, matched = candidateRule, candidate
+		}
+	}
+	if matched == "" {
+		return true, 0
+	}
+
+	site := entry.Caller.File + ":" + strconv.Itoa(entry.Caller.Line)
+	state, ok := sites[site]
+	if !ok {
+		state = &siteState{}
+		sites[site] = state
+	}
+	state.count++
+	emit := false
+	if rule.every > 0 {
+		emit = (state.count-1)%rule.every == 0
+	} else {
+		emit = state.last.IsZero() || entry.Time.Sub(state.last) >= rule.window
+	}
+	if !emit {
+		state.suppressed++
+		return false, 0
+	}
+	suppressed := state.suppressed
+	state.suppressed = 0
+	state.last = entry.Time
+	return true, suppressed
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSamplingRule(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expectedRule  samplingRule
+		expectedError bool
+	}{
+		{
+			name: "Given a count, " +
+				"When parseSamplingRule is called, " +
+				"Then one of every count entries should be logged",
+			value:        "100",
+			expectedRule: samplingRule{every: 100},
+		},
+		{
+			name: "Given a duration, " +
+				"When parseSamplingRule is called, " +
+				"Then one entry per duration should be logged",
+			value:        "1m",
+			expectedRule: samplingRule{window: time.Minute},
+		},
+		{
+			name: "Given a count which is not positive, " +
+				"When parseSamplingRule is called, " +
+				"Then an error should be returned",
+			value:         "0",
+			expectedError: true,
+		},
+		{
+			name: "Given a value which is neither a count nor a duration, " +
+				"When parseSamplingRule is called, " +
+				"Then an error should be returned",
+			value:         "often",
+			expectedError: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := parseSamplingRule(c.value)
+			assert.Equal(t, c.expectedError, err != nil)
+			assert.Equal(t, c.expectedRule, rule)
+		})
+	}
+}
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	assert.Nil(t, Init(log.InfoLevel, nil))
+	assert.Nil(t, InitSampling(map[string]string{"logging": "3"}))
+	defer InitSampling(nil)
+	assert.True(t, log.StandardLogger().ReportCaller)
+
+	for i := 0; i < 7; i++ {
+		log.Info("processing")
+		log.Warn("failing")
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var processing, failing []string
+	for _, line := range lines {
+		if strings.Contains(line, "processing") {
+			processing = append(processing, line)
+		} else if strings.Contains(line, "failing") {
+			failing = append(failing, line)
+		}
+	}
+	assert.Len(t, failing, 7)
+	assert.Len(t, processing, 3)
+	assert.NotContains(t, processing[0], suppressedField)
+	assert.Contains(t, processing[1], suppressedField+"=2")
+	assert.Contains(t, processing[2], suppressedField+"=2")
+
+	assert.NotNil(t, InitSampling(map[string]string{"logging": "often"}))
+}
+
+func TestSamplingWithWindow(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	assert.Nil(t, Init(log.InfoLevel, nil))
+	assert.Nil(t, InitSampling(map[string]string{"logging/sampling_test": "50ms"}))
+	defer InitSampling(nil)
+
+	logProcessing := func() {
+		log.Info("processing")
+	}
+	for i := 0; i < 5; i++ {
+		logProcessing()
+	}
+	assert.Equal(t, 1, strings.Count(buf.String(), "processing"))
+	time.Sleep(60 * time.Millisecond)
+	logProcessing()
+	assert.Equal(t, 2, strings.Count(buf.String(), "processing"))
+	assert.Contains(t, buf.String(), suppressedField+"=4")
+}
@@ -59,3 +59,53 @@ func newFloat64Counter(name, description string, opts *options) *counter {
 		int64Counter: int64Counter,
 	}
 }
+
+// Histogram records the distribution of values, e.g. latencies
+type Histogram interface {
+	Record(value float64, attributes api.MeasurementOption)
+	Name() string
+}
+
+// NewHistogram returns a new histogram
+func NewHistogram(name, description, unit string, opts ...Options) Histogram {
+	o := createOptions(opts...)
+	return newFloat64Histogram(name, description, unit, o)
+}
+
+type histogram struct {
+	name             string
+	description      string
+	ctx              context.Context
+	float64Histogram api.Float64Histogram
+}
+
+// Record adds the value to the histogram, along with the provided attributes
+func (h *histogram) Record(value float64, attributes api.MeasurementOption) {
+	h.float64Histogram.Record(h.ctx, value, attributes)
+}
+
+// Name returns the name of the metric
+func (h *histogram) Name() string {
+	return h.name
+}
+
+func newFloat64Histogram(name, description, unit string, opts *options) *histogram {
+	meter := defaultMeter
+	if reflect.ValueOf(opts.meter).IsValid() {
+		meter = opts.meter
+	}
+	float64Histogram, err := meter.Float64Histogram(
+		name,
+		api.WithUnit(unit),
+		api.WithDescription(description),
+	)
+	if err != nil {
+		log.Fatalf("error creating float64 histogram: %v", err)
+	}
+	return &histogram{
+		name:             name,
+		description:      description,
+		ctx:              context.TODO(),
+		float64Histogram: float64Histogram,
+	}
+}
@@ -0,0 +1,196 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+
+	slackNotifier   = "slack"
+	webhookNotifier = "webhook"
+
+	notificationBufferSize = 100
+	notifierTimeout        = 10 * time.Second
+)
+
+// Notification describes a high severity condition operators should be alerted about.
+// Notifications with the same key are rate limited together.
+type Notification struct {
+	Key       string    `json:"key"`
+	Severity  string    `json:"severity"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier delivers notifications to an alerting system
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// NewNotifier returns the built-in notifier of the type which delivers notifications to the endpoint
+func NewNotifier(notifierType, endpoint string) (Notifier, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint of %s notifier is empty", notifierType)
+	}
+	client := &http.Client{Timeout: notifierTimeout}
+	switch strings.ToLower(notifierType) {
+	// Add entries for your custom notifiers, such as PagerDuty, below
+	case slackNotifier:
+		return &SlackNotifier{webhookURL: endpoint, client: client}, nil
+	case webhookNotifier:
+		return &WebhookNotifier{url: endpoint, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported notifier type %q", notifierType)
+	}
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, s.client, s.webhookURL, map[string]string{
+		"text": fmt.Sprintf("[%s] %s: %s", strings.ToUpper(notification.Severity), notification.Title, notification.Message),
+	})
+}
+
+// WebhookNotifier posts notifications as JSON to a generic endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, w.client, w.url, notification)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("notification endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RateLimitedNotifier drops notifications whose key was already notified within the interval
+type RateLimitedNotifier struct {
+	notifier Notifier
+	interval time.Duration
+	lock     sync.Mutex
+	lastSent map[string]time.Time
+	now      func() time.Time
+}
+
+func NewRateLimitedNotifier(notifier Notifier, interval time.Duration) *RateLimitedNotifier {
+	return &RateLimitedNotifier{
+		notifier: notifier,
+		interval: interval,
+		lastSent: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (r *RateLimitedNotifier) Notify(ctx context.Context, notification Notification) error {
+	if !r.allow(notification.Key) {
+		log.Debugf("rate limited notification key=%s title=%s", notification.Key, notification.Title)
+		return nil
+	}
+	return r.notifier.Notify(ctx, notification)
+}
+
+func (r *RateLimitedNotifier) allow(key string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := r.now()
+	if lastSent, ok := r.lastSent[key]; ok && now.Sub(lastSent) < r.interval {
+		return false
+	}
+	r.lastSent[key] = now
+	return true
+}
+
+var (
+	notifierLock  sync.RWMutex
+	notifications chan Notification
+)
+
+// Initialize starts delivering the notifications passed to Send with the built-in notifier of
+// the type, at most once per interval for each key. Notifications are disabled when the type is empty.
+func Initialize(notifierType, endpoint string, interval time.Duration) error {
+	if notifierType == "" {
+		start(nil)
+		return nil
+	}
+	n, err := NewNotifier(notifierType, endpoint)
+	if err != nil {
+		return err
+	}
+	start(NewRateLimitedNotifier(n, interval))
+	return nil
+}
+
+// start delivers the notifications with the notifier, replacing the one started before
+func start(n Notifier) {
+	notifierLock.Lock()
+	defer notifierLock.Unlock()
+	if notifications != nil {
+		close(notifications)
+		notifications = nil
+	}
+	if n == nil {
+		return
+	}
+	notifications = make(chan Notification, notificationBufferSize)
+	go func(notifications <-chan Notification) {
+		for notification := range notifications {
+			if err := n.Notify(context.Background(), notification); err != nil {
+				log.Errorf("failed to send notification key=%s title=%s: %v", notification.Key, notification.Title, err)
+			}
+		}
+	}(notifications)
+}
+
+// Send queues the notification without blocking the caller. Notifications are dropped
+// when notifications are disabled or the notifier cannot keep up.
+func Send(notification Notification) {
+	notifierLock.RLock()
+	defer notifierLock.RUnlock()
+	if notifications == nil {
+		return
+	}
+	if notification.Timestamp.IsZero() {
+		notification.Timestamp = time.Now().UTC()
+	}
+	select {
+	case notifications <- notification:
+	default:
+		log.Warnf("notification buffer is full, dropping notification key=%s title=%s", notification.Key, notification.Title)
+	}
+}
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingNotifier struct {
+	count int
+}
+
+func (c *countingNotifier) Notify(ctx context.Context, notification Notification) error {
+	c.count++
+	return nil
+}
+
+func TestNewNotifier(t *testing.T) {
+	testCases := []struct {
+		name         string
+		notifierType string
+		endpoint     string
+		expectedErr  bool
+	}{
+		{
+			name: "Given the slack notifier type, " +
+				"When NewNotifier is called, " +
+				"Then it should return a slack notifier",
+			notifierType: "slack",
+			endpoint:     "https://hooks.slack.com/services/abc",
+		},
+		{
+			name: "Given the webhook notifier type, " +
+				"When NewNotifier is called, " +
+				"Then it should return a webhook notifier",
+			notifierType: "Webhook",
+			endpoint:     "https://alerts.example.com",
+		},
+		{
+			name: "Given a notifier without an endpoint, " +
+				"When NewNotifier is called, " +
+				"Then it should return an error",
+			notifierType: "slack",
+			expectedErr:  true,
+		},
+		{
+			name: "Given an unsupported notifier type, " +
+				"When NewNotifier is called, " +
+				"Then it should return an error",
+			notifierType: "carrier-pigeon",
+			endpoint:     "https://alerts.example.com",
+			expectedErr:  true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			n, err := NewNotifier(c.notifierType, c.endpoint)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.NotNil(t, n)
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	received := make(chan map[string]interface{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		payload := map[string]interface{}{}
+		_ = json.Unmarshal(body, &payload)
+		received <- payload
+	}))
+	defer server.Close()
+	notification := Notification{Key: "cluster-unreachable/cluster1", Severity: SeverityCritical, Title: "Cluster unreachable", Message: "cluster1"}
+
+	slack, _ := NewNotifier("slack", server.URL)
+	assert.Nil(t, slack.Notify(context.Background(), notification))
+	assert.Equal(t, "[CRITICAL] Cluster unreachable: cluster1", (<-received)["text"])
+
+	webhook, _ := NewNotifier("webhook", server.URL)
+	assert.Nil(t, webhook.Notify(context.Background(), notification))
+	assert.Equal(t, "cluster-unreachable/cluster1", (<-received)["key"])
+}
+
+func TestRateLimitedNotifier(t *testing.T) {
+	counter := &countingNotifier{}
+	now := time.Now()
+	rateLimited := NewRateLimitedNotifier(counter, time.Minute)
+	rateLimited.now = func() time.Time { return now }
+
+	_ = rateLimited.Notify(context.Background(), Notification{Key: "a"})
+	_ = rateLimited.Notify(context.Background(), Notification{Key: "a"})
+	_ = rateLimited.Notify(context.Background(), Notification{Key: "b"})
+	assert.Equal(t, 2, counter.count)
+
+	now = now.Add(2 * time.Minute)
+	_ = rateLimited.Notify(context.Background(), Notification{Key: "a"})
+	assert.Equal(t, 3, counter.count)
+}
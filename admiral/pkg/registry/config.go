@@ -1,78 +1,26 @@
 package registry
 
 import (
-	admiralV1Alpha1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
-	"github.com/istio-ecosystem/admiral/admiral/pkg/controller/admiral"
-	networking "istio.io/api/networking/v1alpha3"
+	"github.com/istio-ecosystem/admiral/admiral/pkg/sdk"
 )
 
-type IdentityConfig struct {
-	IdentityName string                            `json:"identityName"`
-	Clusters     map[string]*IdentityConfigCluster `json:"clusters"`
-	ClientAssets map[string]string                 `json:"clientAssets"`
-}
+// The registry data model lives in the sdk package so that it can be used without depending
+// on the controllers. The aliases below keep it available under its original names.
 
-func (config *IdentityConfig) PutClusterConfig(name string, clusterConfig IdentityConfigCluster) error {
-	return nil
-}
+type IdentityConfig = sdk.IdentityConfig
 
-type IdentityConfigCluster struct {
-	Name            string `json:"name"`
-	Locality        string `json:"locality"`
-	IngressEndpoint string `json:"ingressEndpoint"`
-	IngressPort     string `json:"ingressPort"`
-	IngressPortName string `json:"ingressPortName"`
-	// env -> rollout/deploy -> IdentityConfigEnvironment
-	Environment map[string]*IdentityConfigEnvironment `json:"environment"`
-}
+type IdentityConfigCluster = sdk.IdentityConfigCluster
 
-func (config *IdentityConfigCluster) PutEnvironment(name string, environmentConfig IdentityConfigEnvironment) error {
-	return nil
-}
+type RegistryServiceConfig = sdk.RegistryServiceConfig
 
-func (config *IdentityConfigCluster) PutClientAssets(clientAssets []string) error {
-	return nil
-}
+type TrafficPolicy = sdk.TrafficPolicy
 
-type RegistryServiceConfig struct {
-	Name      string            `json:"name"`
-	Weight    int               `json:"weight,omitempty,default=-1"`
-	Ports     map[string]uint32 `json:"ports"`
-	Selectors map[string]string `json:"selectors"`
-}
+type TypeConfig = sdk.TypeConfig
 
-type TrafficPolicy struct {
-	GlobalTrafficPolicy    admiralV1Alpha1.GlobalTrafficPolicy    `json:"globaltrafficpolicy"`
-	OutlierDetection       admiralV1Alpha1.OutlierDetection       `json:"outlierdetection"`
-	ClientConnectionConfig admiralV1Alpha1.ClientConnectionConfig `json:"clientconnectionconfig"`
-}
+type IdentityConfigEnvironment = sdk.IdentityConfigEnvironment
 
-type TypeConfig struct {
-	Strategy  string            `json:"strategy"`
-	Selectors map[string]string `json:"selectors"`
-}
+type ClientTLSSettings = sdk.ClientTLSSettings
 
-type IdentityConfigEnvironment struct {
-	Name          string                              `json:"name"`
-	Namespace     string                              `json:"namespace"`
-	Services      map[string][]*RegistryServiceConfig `json:"services"`
-	ServiceName   string                              `json:"serviceName"`
-	Type          map[string]*TypeConfig              `json:"type"`
-	Ports         []*networking.ServicePort           `json:"ports"`
-	TrafficPolicy TrafficPolicy                       `json:"trafficPolicy"`
-	Event         admiral.EventType                   `json:"event"`
-}
+type IngressEndpoint = sdk.IngressEndpoint
 
-type RegistryServiceConfigSorted []*RegistryServiceConfig
-
-func (r RegistryServiceConfigSorted) Len() int {
-	return len(r)
-}
-
-func (r RegistryServiceConfigSorted) Less(i, j int) bool {
-	return r[i].Name < r[j].Name
-}
-
-func (r RegistryServiceConfigSorted) Swap(i, j int) {
-	r[i], r[j] = r[j], r[i]
-}
+type RegistryServiceConfigSorted = sdk.RegistryServiceConfigSorted
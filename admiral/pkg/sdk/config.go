@@ -0,0 +1,115 @@
+package sdk
+
+import (
+	admiralV1Alpha1 "github.com/istio-ecosystem/admiral/admiral/pkg/apis/admiral/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// The types below describe an identity as stored in the registry, and are the input to the
+// rendering functions of this package
+
+type IdentityConfig struct {
+	IdentityName string                            `json:"identityName"`
+	Clusters     map[string]*IdentityConfigCluster `json:"clusters"`
+	ClientAssets map[string]string                 `json:"clientAssets"`
+	// SyncNamespace is the namespace the identity requested its generated resources to be
+	// written to instead of the sync namespace
+	SyncNamespace string `json:"syncNamespace,omitempty"`
+	// ClientTLSSettings are the TLS settings the identity requested for its DestinationRules
+	// instead of ISTIO_MUTUAL, for identities which terminate TLS themselves
+	ClientTLSSettings *ClientTLSSettings `json:"clientTLSSettings,omitempty"`
+	// FailoverPriority are the regions the DestinationRules of the identity fail over to, in
+	// order of preference, e.g. us-west-2, us-east-2, eu-west-1
+	FailoverPriority []string `json:"failoverPriority,omitempty"`
+}
+
+// ClientTLSSettings describes the TLS origination requested by an identity
+type ClientTLSSettings struct {
+	// Mode is one of the istio TLS modes, e.g. SIMPLE or ISTIO_MUTUAL
+	Mode           string `json:"mode"`
+	Sni            string `json:"sni,omitempty"`
+	CredentialName string `json:"credentialName,omitempty"`
+}
+
+func (config *IdentityConfig) PutClusterConfig(name string, clusterConfig IdentityConfigCluster) error {
+	return nil
+}
+
+type IdentityConfigCluster struct {
+	Name            string `json:"name"`
+	Locality        string `json:"locality"`
+	IngressEndpoint string `json:"ingressEndpoint"`
+	IngressPort     string `json:"ingressPort"`
+	IngressPortName string `json:"ingressPortName"`
+	// IngressEndpoints are the load balancers of the cluster ingress when it exposes more than
+	// one, e.g. an NLB per availability zone. IngressEndpoint is used when it is empty
+	IngressEndpoints []*IngressEndpoint `json:"ingressEndpoints,omitempty"`
+	// env -> rollout/deploy -> IdentityConfigEnvironment
+	Environment map[string]*IdentityConfigEnvironment `json:"environment"`
+}
+
+// IngressEndpoint is one of the load balancers of a cluster ingress, all of them share the
+// ingress port of the cluster
+type IngressEndpoint struct {
+	Address string `json:"address"`
+	// Locality defaults to the locality of the cluster
+	Locality string `json:"locality,omitempty"`
+	Weight   uint32 `json:"weight,omitempty"`
+}
+
+func (config *IdentityConfigCluster) PutEnvironment(name string, environmentConfig IdentityConfigEnvironment) error {
+	return nil
+}
+
+func (config *IdentityConfigCluster) PutClientAssets(clientAssets []string) error {
+	return nil
+}
+
+type RegistryServiceConfig struct {
+	Name      string            `json:"name"`
+	Weight    int               `json:"weight,omitempty,default=-1"`
+	Ports     map[string]uint32 `json:"ports"`
+	Selectors map[string]string `json:"selectors"`
+}
+
+type TrafficPolicy struct {
+	GlobalTrafficPolicy    admiralV1Alpha1.GlobalTrafficPolicy    `json:"globaltrafficpolicy"`
+	OutlierDetection       admiralV1Alpha1.OutlierDetection       `json:"outlierdetection"`
+	ClientConnectionConfig admiralV1Alpha1.ClientConnectionConfig `json:"clientconnectionconfig"`
+}
+
+type TypeConfig struct {
+	Strategy  string            `json:"strategy"`
+	Selectors map[string]string `json:"selectors"`
+}
+
+// EventType is the event which last changed an IdentityConfigEnvironment
+type EventType string
+
+type IdentityConfigEnvironment struct {
+	Name          string                              `json:"name"`
+	Namespace     string                              `json:"namespace"`
+	Services      map[string][]*RegistryServiceConfig `json:"services"`
+	ServiceName   string                              `json:"serviceName"`
+	Type          map[string]*TypeConfig              `json:"type"`
+	Ports         []*networking.ServicePort           `json:"ports"`
+	TrafficPolicy TrafficPolicy                       `json:"trafficPolicy"`
+	Event         EventType                           `json:"event"`
+	// WarmupDurationSecs is the warmup duration the identity requested for the DestinationRules
+	// of the environment instead of the default one
+	WarmupDurationSecs *int64 `json:"warmupDurationSecs,omitempty"`
+}
+
+type RegistryServiceConfigSorted []*RegistryServiceConfig
+
+func (r RegistryServiceConfigSorted) Len() int {
+	return len(r)
+}
+
+func (r RegistryServiceConfigSorted) Less(i, j int) bool {
+	return r[i].Name < r[j].Name
+}
+
+func (r RegistryServiceConfigSorted) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
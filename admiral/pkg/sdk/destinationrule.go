@@ -0,0 +1,128 @@
+package sdk
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+// BuildDestinationRule builds the default DestinationRule for a ServiceEntry. It enables
+// mutual TLS and least request load balancing with slow start, and is exported to the same
+// namespaces as the ServiceEntry. The subject alt names of the ServiceEntry are verified when
+// trust domains are federated.
+func BuildDestinationRule(se *networking.ServiceEntry, opts Options) *networking.DestinationRule {
+	tls := &networking.ClientTLSSettings{
+		Mode: networking.ClientTLSSettings_ISTIO_MUTUAL,
+	}
+	// the workloads of federated trust domains are verified with the subject alt names of the ServiceEntry
+	if isTrustDomainFederationEnabled(opts) {
+		tls.SubjectAltNames = append([]string{}, se.SubjectAltNames...)
+	}
+	return &networking.DestinationRule{
+		Host:     se.Hosts[0],
+		ExportTo: se.ExportTo,
+		TrafficPolicy: &networking.TrafficPolicy{
+			Tls: tls,
+			LoadBalancer: &networking.LoadBalancerSettings{
+				LbPolicy: &networking.LoadBalancerSettings_Simple{
+					Simple: networking.LoadBalancerSettings_LEAST_REQUEST,
+				},
+				WarmupDurationSecs: &duration.Duration{Seconds: opts.WarmupDurationSecs},
+			},
+		},
+	}
+}
+
+// GetClientTLSSettings returns the TLS settings the identity requested for its DestinationRules,
+// or nil when it did not request any or the override is disabled
+func GetClientTLSSettings(identityConfig IdentityConfig, opts Options) *networking.ClientTLSSettings {
+	if !opts.EnableIdentityClientTLSSettings {
+		return nil
+	}
+	return BuildClientTLSSettings(identityConfig.ClientTLSSettings)
+}
+
+// GetWarmupDurations returns the warmup durations the identity requested for the DestinationRules of
+// its hosts, keyed by lower case host. It is empty when the override is disabled
+func GetWarmupDurations(identityConfig IdentityConfig, opts Options) map[string]int64 {
+	warmupDurations := make(map[string]int64)
+	if !opts.EnableIdentityWarmupDuration {
+		return warmupDurations
+	}
+	clusters := make([]string, 0, len(identityConfig.Clusters))
+	for cluster := range identityConfig.Clusters {
+		clusters = append(clusters, cluster)
+	}
+	// the clusters are visited in order so that the same duration is picked when they disagree
+	sort.Strings(clusters)
+	for _, cluster := range clusters {
+		for _, environment := range identityConfig.Clusters[cluster].Environment {
+			if environment == nil || environment.WarmupDurationSecs == nil || *environment.WarmupDurationSecs < 0 {
+				continue
+			}
+			for _, host := range GetMeshHosts(identityConfig.IdentityName, environment, opts.HostnameSuffix) {
+				if _, ok := warmupDurations[strings.ToLower(host)]; !ok {
+					warmupDurations[strings.ToLower(host)] = *environment.WarmupDurationSecs
+				}
+			}
+		}
+	}
+	return warmupDurations
+}
+
+// BuildLocalityFailover converts the regions of a failover priority list, in order of preference,
+// to the locality failover of a DestinationRule. The endpoints of each region fail over to the
+// first region of the list other than itself. It returns nil for lists of less than two regions.
+func BuildLocalityFailover(failoverPriority []string) []*networking.LocalityLoadBalancerSetting_Failover {
+	if len(failoverPriority) < 2 {
+		return nil
+	}
+	failover := make([]*networking.LocalityLoadBalancerSetting_Failover, 0, len(failoverPriority))
+	for i, region := range failoverPriority {
+		to := failoverPriority[0]
+		if i == 0 {
+			to = failoverPriority[1]
+		}
+		failover = append(failover, &networking.LocalityLoadBalancerSetting_Failover{From: region, To: to})
+	}
+	return failover
+}
+
+// ApplyFailoverPriority sets the locality failover of the DestinationRule from the failover
+// priority list. DestinationRules which already distribute their traffic between localities,
+// e.g. with the weights of a GTP, are left unchanged as istio only allows one of the two.
+func ApplyFailoverPriority(dr *networking.DestinationRule, failoverPriority []string) {
+	failover := BuildLocalityFailover(failoverPriority)
+	loadBalancer := dr.GetTrafficPolicy().GetLoadBalancer()
+	if len(failover) == 0 || loadBalancer == nil {
+		return
+	}
+	if localityLbSetting := loadBalancer.LocalityLbSetting; localityLbSetting != nil {
+		if len(localityLbSetting.Distribute) > 0 || len(localityLbSetting.FailoverPriority) > 0 ||
+			(localityLbSetting.Enabled != nil && !localityLbSetting.Enabled.Value) {
+			return
+		}
+		localityLbSetting.Failover = failover
+		return
+	}
+	loadBalancer.LocalityLbSetting = &networking.LocalityLoadBalancerSetting{Failover: failover}
+}
+
+// BuildClientTLSSettings converts the TLS settings requested by an identity to the istio
+// ClientTLSSettings. It returns nil when no settings were requested or the mode is unknown.
+func BuildClientTLSSettings(settings *ClientTLSSettings) *networking.ClientTLSSettings {
+	if settings == nil {
+		return nil
+	}
+	mode, ok := networking.ClientTLSSettings_TLSmode_value[strings.ToUpper(strings.TrimSpace(settings.Mode))]
+	if !ok {
+		return nil
+	}
+	return &networking.ClientTLSSettings{
+		Mode:           networking.ClientTLSSettings_TLSmode(mode),
+		Sni:            settings.Sni,
+		CredentialName: settings.CredentialName,
+	}
+}
@@ -0,0 +1,214 @@
+// Package sdk computes the Istio configuration Admiral generates for an identity from its
+// IdentityConfig, without running any controllers or talking to a cluster. It is meant for
+// tools such as CI checks and validating pipelines which need to know the expected mesh config.
+//
+// Everything Admiral otherwise reads from its startup parameters is passed in through Options,
+// so the package does not depend on Admiral's global configuration.
+//
+// The ServiceEntries, the default DestinationRules and, when VS based routing is enabled, the
+// ingress routing VirtualServices are rendered. Traffic policies (GlobalTrafficPolicy,
+// OutlierDetection, ClientConnectionConfig) are not applied yet.
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	serviceEntrySuffix    = "-se"
+	destinationRuleSuffix = "-default-dr"
+	virtualServiceSuffix  = "-routing-vs"
+	createdByAnnotation   = "app.kubernetes.io/created-by"
+	createdByAdmiral      = "admiral"
+	vsRoutingLabel        = "admiral.io/vs-routing"
+)
+
+// Options holds the settings which are read from Admiral's startup parameters when
+// running as a controller
+type Options struct {
+	// HostnameSuffix is the suffix of the generated hosts, e.g. global
+	HostnameSuffix string
+	// SANPrefix is the trust domain used in the subject alt names of the ServiceEntries
+	SANPrefix string
+	// LocalDomainSuffix is the suffix of the in-cluster service addresses
+	LocalDomainSuffix string
+	// SyncNamespace is the namespace the generated resources are written to
+	SyncNamespace string
+	// EnableIdentitySyncNamespace allows an IdentityConfig to override the SyncNamespace
+	EnableIdentitySyncNamespace bool
+	// EnableIdentityClientTLSSettings allows an IdentityConfig to override the TLS settings of its DestinationRules
+	EnableIdentityClientTLSSettings bool
+	// EnableIdentityWarmupDuration allows an IdentityConfig to override the WarmupDurationSecs per environment
+	EnableIdentityWarmupDuration bool
+	// EnableFailoverPriority allows an IdentityConfig to order the regions its DestinationRules fail over to
+	EnableFailoverPriority bool
+	// ExportToMaxNamespaces is the number of namespaces after which exportTo is replaced with *
+	ExportToMaxNamespaces int
+	// WarmupDurationSecs is the warmup duration set on the DestinationRules
+	WarmupDurationSecs int64
+	// ClusterTrustDomains is the trust domain of each cluster, clusters without an entry use SANPrefix
+	ClusterTrustDomains map[string]string
+	// TrustDomainMappings is the trust domain a client cluster verifies the workloads of a server
+	// cluster with, keyed by clientCluster:serverCluster. It takes precedence over ClusterTrustDomains
+	TrustDomainMappings map[string]string
+	// VSRoutingGateways are the PASSTHROUGH gateways of the ingress routing VirtualServices, VS based
+	// routing is disabled when there are none
+	VSRoutingGateways []string
+	// IngressVSExportToNamespaces are the namespaces the ingress routing VirtualServices are exported to
+	IngressVSExportToNamespaces []string
+}
+
+// DefaultOptions returns the Options matching the defaults of Admiral's startup parameters
+func DefaultOptions() Options {
+	return Options{
+		HostnameSuffix:              "global",
+		LocalDomainSuffix:           ".svc.cluster.local",
+		SyncNamespace:               "admiral-sync",
+		ExportToMaxNamespaces:       35,
+		WarmupDurationSecs:          45,
+		IngressVSExportToNamespaces: []string{istioSystemNamespace},
+	}
+}
+
+// Input holds everything needed to render the config of an identity for a client cluster
+type Input struct {
+	// Identity is the IdentityConfig of the identity to render the config for
+	Identity IdentityConfig
+	// ClientCluster is the cluster the rendered config would be written to
+	ClientCluster string
+	// ClientIdentities are the IdentityConfigs of the identity's client assets, which
+	// decide the namespaces the config is exported to
+	ClientIdentities []IdentityConfig
+}
+
+// Output holds the rendered config, sorted by name
+type Output struct {
+	ServiceEntries   []*v1alpha3.ServiceEntry
+	DestinationRules []*v1alpha3.DestinationRule
+	VirtualServices  []*v1alpha3.VirtualService
+}
+
+// Render computes the ServiceEntries, DestinationRules and VirtualServices Admiral would write
+// to the client cluster for the identity. The ingress routing VirtualServices are only written
+// when the identity is deployed on the client cluster.
+func Render(input Input, opts Options) (*Output, error) {
+	if input.Identity.IdentityName == "" {
+		return nil, fmt.Errorf("identity name is empty")
+	}
+	if input.ClientCluster == "" {
+		return nil, fmt.Errorf("client cluster is empty")
+	}
+	_, isServerOnClientCluster := input.Identity.Clusters[input.ClientCluster]
+	exportTo := GetExportTo(input.ClientCluster, isServerOnClientCluster, input.ClientIdentities, opts.ExportToMaxNamespaces)
+	syncNamespace := GetSyncNamespace(input.Identity, opts)
+	if syncNamespace != opts.SyncNamespace {
+		exportTo = AddNamespaceToExportTo(exportTo, syncNamespace)
+	}
+	serviceEntries, err := BuildServiceEntries(input.Identity, input.ClientCluster, exportTo, opts)
+	if err != nil {
+		return nil, err
+	}
+	warmupDurations := GetWarmupDurations(input.Identity, opts)
+	output := &Output{}
+	for _, se := range serviceEntries {
+		host := strings.ToLower(se.Hosts[0])
+		serviceEntry := &v1alpha3.ServiceEntry{ObjectMeta: newObjectMeta(host+serviceEntrySuffix, syncNamespace)}
+		se.DeepCopyInto(&serviceEntry.Spec)
+		output.ServiceEntries = append(output.ServiceEntries, serviceEntry)
+
+		destinationRule := &v1alpha3.DestinationRule{ObjectMeta: newObjectMeta(host+destinationRuleSuffix, syncNamespace)}
+		BuildDestinationRule(se, opts).DeepCopyInto(&destinationRule.Spec)
+		if tlsSettings := GetClientTLSSettings(input.Identity, opts); tlsSettings != nil {
+			destinationRule.Spec.TrafficPolicy.Tls = tlsSettings
+		}
+		if warmupDurationSecs, ok := warmupDurations[host]; ok {
+			destinationRule.Spec.TrafficPolicy.LoadBalancer.WarmupDurationSecs = &duration.Duration{Seconds: warmupDurationSecs}
+		}
+		if opts.EnableFailoverPriority {
+			ApplyFailoverPriority(&destinationRule.Spec, input.Identity.FailoverPriority)
+		}
+		output.DestinationRules = append(output.DestinationRules, destinationRule)
+	}
+	if isServerOnClientCluster && len(opts.VSRoutingGateways) > 0 {
+		for _, identityConfigEnvironment := range input.Identity.Clusters[input.ClientCluster].Environment {
+			vs, err := BuildIngressVirtualService(input.Identity.IdentityName, identityConfigEnvironment, opts.VSRoutingGateways, opts)
+			if err != nil {
+				return nil, err
+			}
+			// the VirtualService is named after the default host of the environment
+			host := GetMeshHosts(input.Identity.IdentityName, identityConfigEnvironment, opts.HostnameSuffix)[0]
+			virtualService := &v1alpha3.VirtualService{ObjectMeta: newObjectMeta(host+virtualServiceSuffix, istioSystemNamespace)}
+			virtualService.Labels = map[string]string{vsRoutingLabel: "enabled"}
+			vs.DeepCopyInto(&virtualService.Spec)
+			output.VirtualServices = append(output.VirtualServices, virtualService)
+		}
+	}
+	sort.Slice(output.ServiceEntries, func(i, j int) bool {
+		return output.ServiceEntries[i].Name < output.ServiceEntries[j].Name
+	})
+	sort.Slice(output.DestinationRules, func(i, j int) bool {
+		return output.DestinationRules[i].Name < output.DestinationRules[j].Name
+	})
+	sort.Slice(output.VirtualServices, func(i, j int) bool {
+		return output.VirtualServices[i].Name < output.VirtualServices[j].Name
+	})
+	return output, nil
+}
+
+// GetTrustDomain returns the trust domain the client cluster verifies the workloads of the server cluster with
+func GetTrustDomain(clientCluster, serverCluster string, opts Options) string {
+	if trustDomain := opts.TrustDomainMappings[clientCluster+":"+serverCluster]; trustDomain != "" {
+		return trustDomain
+	}
+	if trustDomain := opts.ClusterTrustDomains[serverCluster]; trustDomain != "" {
+		return trustDomain
+	}
+	return opts.SANPrefix
+}
+
+// isTrustDomainFederationEnabled returns true when clusters with different trust domains are federated
+func isTrustDomainFederationEnabled(opts Options) bool {
+	return len(opts.ClusterTrustDomains) > 0 || len(opts.TrustDomainMappings) > 0
+}
+
+// GetSyncNamespace returns the namespace the generated resources of the identity are written to,
+// which is the namespace requested in the IdentityConfig when the override is enabled
+func GetSyncNamespace(identityConfig IdentityConfig, opts Options) string {
+	if opts.EnableIdentitySyncNamespace && identityConfig.SyncNamespace != "" {
+		return identityConfig.SyncNamespace
+	}
+	return opts.SyncNamespace
+}
+
+// AddNamespaceToExportTo adds the namespace to a restricted exportTo list, so that resources
+// written to a namespace owned by a team stay visible to the workloads of that namespace.
+// An empty exportTo or one containing * already exports to every namespace and is returned as is.
+func AddNamespaceToExportTo(exportTo []string, namespace string) []string {
+	if len(exportTo) == 0 || namespace == "" {
+		return exportTo
+	}
+	for _, ns := range exportTo {
+		if ns == "*" || ns == namespace {
+			return exportTo
+		}
+	}
+	exportToWithNamespace := make([]string, 0, len(exportTo)+1)
+	exportToWithNamespace = append(exportToWithNamespace, exportTo...)
+	exportToWithNamespace = append(exportToWithNamespace, namespace)
+	sort.Strings(exportToWithNamespace)
+	return exportToWithNamespace
+}
+
+func newObjectMeta(name, namespace string) metaV1.ObjectMeta {
+	return metaV1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Annotations: map[string]string{createdByAnnotation: createdByAdmiral},
+	}
+}
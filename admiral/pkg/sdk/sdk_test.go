@@ -0,0 +1,383 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+func getTestIdentityConfig() IdentityConfig {
+	return IdentityConfig{
+		IdentityName: "Sample",
+		Clusters: map[string]*IdentityConfigCluster{
+			"cluster1": {
+				Name:            "cluster1",
+				Locality:        "us-west-2",
+				IngressEndpoint: "internal-lb-west.com",
+				IngressPort:     "15443",
+				IngressPortName: "http",
+				Environment: map[string]*IdentityConfigEnvironment{
+					"prf": {
+						Name:      "prf",
+						Namespace: "sample-ns",
+						Services: map[string][]*RegistryServiceConfig{
+							DefaultServiceKey: {{Name: "sample-svc", Ports: map[string]uint32{"http": 8090}}},
+						},
+						Type:  map[string]*TypeConfig{Deployment: {}},
+						Ports: []*networking.ServicePort{{Name: "http", Number: 80, Protocol: "http"}},
+					},
+				},
+			},
+		},
+		ClientAssets: map[string]string{"client": "client"},
+	}
+}
+
+func getTestClientIdentityConfig() IdentityConfig {
+	return IdentityConfig{
+		IdentityName: "client",
+		Clusters: map[string]*IdentityConfigCluster{
+			"cluster2": {
+				Name: "cluster2",
+				Environment: map[string]*IdentityConfigEnvironment{
+					"prf": {Name: "prf", Namespace: "client-ns"},
+				},
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	opts := DefaultOptions()
+	opts.SANPrefix = "prefix"
+	opts.EnableIdentitySyncNamespace = true
+	identityWithSyncNamespace := getTestIdentityConfig()
+	identityWithSyncNamespace.SyncNamespace = "sample-sync"
+
+	testCases := []struct {
+		name              string
+		input             Input
+		expectedErr       bool
+		expectedSEName    string
+		expectedNamespace string
+		expectedAddress   string
+		expectedExportTo  []string
+	}{
+		{
+			name: "Given an identity with no name, " +
+				"When Render is called, " +
+				"Then an error should be returned",
+			input:       Input{ClientCluster: "cluster1"},
+			expectedErr: true,
+		},
+		{
+			name: "Given an identity deployed on a remote cluster, " +
+				"When Render is called for a client cluster, " +
+				"Then the SE should point to the remote ingress and be exported to the client namespaces",
+			input: Input{
+				Identity:         getTestIdentityConfig(),
+				ClientCluster:    "cluster2",
+				ClientIdentities: []IdentityConfig{getTestClientIdentityConfig()},
+			},
+			expectedSEName:    "prf.sample.global-se",
+			expectedNamespace: "admiral-sync",
+			expectedAddress:   "internal-lb-west.com",
+			expectedExportTo:  []string{"client-ns"},
+		},
+		{
+			name: "Given an identity deployed on the client cluster, " +
+				"When Render is called for the client cluster, " +
+				"Then the SE should point to the local service and be exported to istio-system",
+			input: Input{
+				Identity:      getTestIdentityConfig(),
+				ClientCluster: "cluster1",
+			},
+			expectedSEName:    "prf.sample.global-se",
+			expectedNamespace: "admiral-sync",
+			expectedAddress:   "sample-svc.sample-ns.svc.cluster.local",
+			expectedExportTo:  []string{"istio-system"},
+		},
+		{
+			name: "Given an identity which requested a sync namespace, " +
+				"When Render is called for a client cluster, " +
+				"Then the SE should be written to the requested namespace and be exported to it",
+			input: Input{
+				Identity:         identityWithSyncNamespace,
+				ClientCluster:    "cluster2",
+				ClientIdentities: []IdentityConfig{getTestClientIdentityConfig()},
+			},
+			expectedSEName:    "prf.sample.global-se",
+			expectedNamespace: "sample-sync",
+			expectedAddress:   "internal-lb-west.com",
+			expectedExportTo:  []string{"client-ns", "sample-sync"},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			output, err := Render(c.input, opts)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Len(t, output.ServiceEntries, 1)
+			assert.Len(t, output.DestinationRules, 1)
+			se := output.ServiceEntries[0]
+			assert.Equal(t, c.expectedSEName, se.Name)
+			assert.Equal(t, c.expectedNamespace, se.Namespace)
+			assert.Equal(t, []string{"prf.sample.global"}, se.Spec.Hosts)
+			assert.Equal(t, []string{"spiffe://prefix/Sample"}, se.Spec.SubjectAltNames)
+			assert.Equal(t, c.expectedAddress, se.Spec.Endpoints[0].Address)
+			assert.Equal(t, c.expectedExportTo, se.Spec.ExportTo)
+			dr := output.DestinationRules[0]
+			assert.Equal(t, "prf.sample.global-default-dr", dr.Name)
+			assert.Equal(t, c.expectedNamespace, dr.Namespace)
+			assert.Equal(t, "prf.sample.global", dr.Spec.Host)
+			assert.Equal(t, c.expectedExportTo, dr.Spec.ExportTo)
+			assert.Equal(t, networking.ClientTLSSettings_ISTIO_MUTUAL, dr.Spec.TrafficPolicy.Tls.Mode)
+			assert.Equal(t, int64(45), dr.Spec.TrafficPolicy.LoadBalancer.WarmupDurationSecs.Seconds)
+		})
+	}
+}
+
+func TestGetExportTo(t *testing.T) {
+	clients := []IdentityConfig{getTestClientIdentityConfig()}
+	assert.Equal(t, []string{"client-ns"}, GetExportTo("cluster2", false, clients, 35))
+	assert.Equal(t, []string{"client-ns", "istio-system"}, GetExportTo("cluster2", true, clients, 35))
+	assert.Equal(t, []string{"*"}, GetExportTo("cluster2", true, clients, 1))
+	assert.Equal(t, []string{}, GetExportTo("cluster3", false, clients, 35))
+}
+
+func TestGetSyncNamespace(t *testing.T) {
+	opts := DefaultOptions()
+	identityConfig := getTestIdentityConfig()
+	identityConfig.SyncNamespace = "sample-sync"
+	assert.Equal(t, "admiral-sync", GetSyncNamespace(identityConfig, opts))
+	opts.EnableIdentitySyncNamespace = true
+	assert.Equal(t, "sample-sync", GetSyncNamespace(identityConfig, opts))
+	assert.Equal(t, "admiral-sync", GetSyncNamespace(getTestIdentityConfig(), opts))
+}
+
+func TestGetClientTLSSettings(t *testing.T) {
+	opts := DefaultOptions()
+	identityConfig := getTestIdentityConfig()
+	identityConfig.ClientTLSSettings = &ClientTLSSettings{Mode: "simple", Sni: "sample.example.com", CredentialName: "sample-cert"}
+	assert.Nil(t, GetClientTLSSettings(identityConfig, opts))
+	opts.EnableIdentityClientTLSSettings = true
+	assert.Equal(t, &networking.ClientTLSSettings{
+		Mode:           networking.ClientTLSSettings_SIMPLE,
+		Sni:            "sample.example.com",
+		CredentialName: "sample-cert",
+	}, GetClientTLSSettings(identityConfig, opts))
+	assert.Nil(t, GetClientTLSSettings(getTestIdentityConfig(), opts))
+	identityConfig.ClientTLSSettings.Mode = "unknown"
+	assert.Nil(t, GetClientTLSSettings(identityConfig, opts))
+}
+
+func TestGetWarmupDurations(t *testing.T) {
+	opts := DefaultOptions()
+	identityConfig := getTestIdentityConfig()
+	warmupDurationSecs := int64(120)
+	identityConfig.Clusters["cluster1"].Environment["prf"].WarmupDurationSecs = &warmupDurationSecs
+	assert.Empty(t, GetWarmupDurations(identityConfig, opts))
+	opts.EnableIdentityWarmupDuration = true
+	assert.Equal(t, map[string]int64{"prf.sample.global": 120}, GetWarmupDurations(identityConfig, opts))
+	assert.Empty(t, GetWarmupDurations(getTestIdentityConfig(), opts))
+
+	output, err := Render(Input{Identity: identityConfig, ClientCluster: "cluster2"}, opts)
+	assert.Nil(t, err)
+	assert.Len(t, output.DestinationRules, 1)
+	assert.Equal(t, int64(120), output.DestinationRules[0].Spec.TrafficPolicy.LoadBalancer.WarmupDurationSecs.Seconds)
+}
+
+func TestBuildLocalityFailover(t *testing.T) {
+	assert.Nil(t, BuildLocalityFailover([]string{"us-west-2"}))
+	assert.Equal(t, []*networking.LocalityLoadBalancerSetting_Failover{
+		{From: "us-west-2", To: "us-east-2"},
+		{From: "us-east-2", To: "us-west-2"},
+		{From: "eu-west-1", To: "us-west-2"},
+	}, BuildLocalityFailover([]string{"us-west-2", "us-east-2", "eu-west-1"}))
+}
+
+func TestApplyFailoverPriority(t *testing.T) {
+	failoverPriority := []string{"us-west-2", "us-east-2"}
+	newDR := func(localityLbSetting *networking.LocalityLoadBalancerSetting) *networking.DestinationRule {
+		return &networking.DestinationRule{TrafficPolicy: &networking.TrafficPolicy{
+			LoadBalancer: &networking.LoadBalancerSettings{LocalityLbSetting: localityLbSetting},
+		}}
+	}
+
+	dr := newDR(nil)
+	ApplyFailoverPriority(dr, failoverPriority)
+	assert.Len(t, dr.TrafficPolicy.LoadBalancer.LocalityLbSetting.Failover, 2)
+
+	dr = newDR(&networking.LocalityLoadBalancerSetting{Enabled: wrapperspb.Bool(true)})
+	ApplyFailoverPriority(dr, failoverPriority)
+	assert.Len(t, dr.TrafficPolicy.LoadBalancer.LocalityLbSetting.Failover, 2)
+
+	dr = newDR(&networking.LocalityLoadBalancerSetting{Distribute: []*networking.LocalityLoadBalancerSetting_Distribute{
+		{From: "*", To: map[string]uint32{"us-west-2": 100}},
+	}})
+	ApplyFailoverPriority(dr, failoverPriority)
+	assert.Empty(t, dr.TrafficPolicy.LoadBalancer.LocalityLbSetting.Failover)
+
+	dr = newDR(&networking.LocalityLoadBalancerSetting{Enabled: wrapperspb.Bool(false)})
+	ApplyFailoverPriority(dr, failoverPriority)
+	assert.Empty(t, dr.TrafficPolicy.LoadBalancer.LocalityLbSetting.Failover)
+
+	dr = &networking.DestinationRule{}
+	ApplyFailoverPriority(dr, failoverPriority)
+	assert.Nil(t, dr.TrafficPolicy)
+
+	opts := DefaultOptions()
+	opts.EnableFailoverPriority = true
+	identityConfig := getTestIdentityConfig()
+	identityConfig.FailoverPriority = failoverPriority
+	output, err := Render(Input{Identity: identityConfig, ClientCluster: "cluster2"}, opts)
+	assert.Nil(t, err)
+	assert.Len(t, output.DestinationRules, 1)
+	assert.Equal(t, BuildLocalityFailover(failoverPriority), output.DestinationRules[0].Spec.TrafficPolicy.LoadBalancer.LocalityLbSetting.Failover)
+}
+
+func TestBuildServiceEntriesWithWeightedIngressEndpoints(t *testing.T) {
+	identityConfig := getTestIdentityConfig()
+	identityConfig.Clusters["cluster1"].IngressEndpoints = []*IngressEndpoint{
+		{Address: "internal-lb-west-b.com", Locality: "us-west-2/us-west-2b", Weight: 30},
+		{Address: "internal-lb-west-a.com", Weight: 70},
+	}
+	serviceEntries, err := BuildServiceEntries(identityConfig, "cluster2", []string{"client-ns"}, DefaultOptions())
+	assert.Nil(t, err)
+	assert.Len(t, serviceEntries, 1)
+	labels := map[string]string{tlsModeLabel: "istio"}
+	expectedEndpoints := []*networking.WorkloadEntry{
+		{Address: "internal-lb-west-a.com", Locality: "us-west-2", Weight: 70, Ports: map[string]uint32{"http": 15443}, Labels: labels},
+		{Address: "internal-lb-west-b.com", Locality: "us-west-2/us-west-2b", Weight: 30, Ports: map[string]uint32{"http": 15443}, Labels: labels},
+	}
+	assert.Empty(t, cmp.Diff(expectedEndpoints, serviceEntries[0].Endpoints, protocmp.Transform()))
+
+	serviceEntries, err = BuildServiceEntries(identityConfig, "cluster1", []string{"client-ns"}, DefaultOptions())
+	assert.Nil(t, err)
+	assert.Len(t, serviceEntries[0].Endpoints, 1, "the source cluster should only get the local endpoint")
+	assert.Equal(t, "sample-svc.sample-ns.svc.cluster.local", serviceEntries[0].Endpoints[0].Address)
+}
+
+func TestBuildServiceEntriesWithTrustDomains(t *testing.T) {
+	identityConfig := getTestIdentityConfig()
+	identityConfig.Clusters["cluster3"] = &IdentityConfigCluster{
+		Name:            "cluster3",
+		Locality:        "us-east-2",
+		IngressEndpoint: "internal-lb-east.com",
+		IngressPort:     "15443",
+		IngressPortName: "http",
+		Environment:     identityConfig.Clusters["cluster1"].Environment,
+	}
+	opts := DefaultOptions()
+	opts.SANPrefix = "prefix"
+	serviceEntries, err := BuildServiceEntries(identityConfig, "cluster2", []string{"client-ns"}, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"spiffe://prefix/Sample"}, serviceEntries[0].SubjectAltNames)
+	assert.Nil(t, BuildDestinationRule(serviceEntries[0], opts).TrafficPolicy.Tls.SubjectAltNames,
+		"the subject alt names should not be verified when trust domains are not federated")
+
+	opts.ClusterTrustDomains = map[string]string{"cluster3": "east.example.com"}
+	opts.TrustDomainMappings = map[string]string{"cluster2:cluster1": "west.example.com"}
+	assert.Equal(t, "west.example.com", GetTrustDomain("cluster2", "cluster1", opts))
+	assert.Equal(t, "east.example.com", GetTrustDomain("cluster2", "cluster3", opts))
+	assert.Equal(t, "prefix", GetTrustDomain("cluster1", "cluster1", opts))
+
+	serviceEntries, err = BuildServiceEntries(identityConfig, "cluster2", []string{"client-ns"}, opts)
+	assert.Nil(t, err)
+	expectedSans := []string{"spiffe://east.example.com/Sample", "spiffe://west.example.com/Sample"}
+	assert.Equal(t, expectedSans, serviceEntries[0].SubjectAltNames)
+	assert.Equal(t, expectedSans, BuildDestinationRule(serviceEntries[0], opts).TrafficPolicy.Tls.SubjectAltNames)
+}
+
+func TestBuildServiceEntriesWithSeveralHostsPerEnvironment(t *testing.T) {
+	identityConfig := getTestIdentityConfig()
+	identityConfig.Clusters["cluster1"].Environment["prf"].Type = map[string]*TypeConfig{Rollout: {Strategy: CanaryStrategy}}
+	serviceEntries, err := BuildServiceEntries(identityConfig, "cluster2", []string{"client-ns"}, DefaultOptions())
+	assert.Nil(t, err)
+	assert.Len(t, serviceEntries, 2)
+	assert.Equal(t, []string{"canary.sample.global"}, serviceEntries[0].Hosts)
+	assert.Equal(t, []string{"prf.sample.global"}, serviceEntries[1].Hosts)
+	for _, se := range serviceEntries {
+		assert.Len(t, se.Endpoints, 1)
+		assert.Equal(t, "internal-lb-west.com", se.Endpoints[0].Address)
+	}
+}
+
+func TestAddNamespaceToExportTo(t *testing.T) {
+	assert.Equal(t, []string{"a-ns", "b-ns", "c-ns"}, AddNamespaceToExportTo([]string{"a-ns", "c-ns"}, "b-ns"))
+	assert.Equal(t, []string{"a-ns", "b-ns"}, AddNamespaceToExportTo([]string{"a-ns", "b-ns"}, "b-ns"))
+	assert.Equal(t, []string{"*"}, AddNamespaceToExportTo([]string{"*"}, "b-ns"))
+	assert.Nil(t, AddNamespaceToExportTo(nil, "b-ns"))
+}
+
+func TestBuildIngressVirtualService(t *testing.T) {
+	opts := DefaultOptions()
+	identityConfig := getTestIdentityConfig()
+	environment := identityConfig.Clusters["cluster1"].Environment["prf"]
+	_, err := BuildIngressVirtualService(identityConfig.IdentityName, environment, nil, opts)
+	assert.NotNil(t, err, "the virtualservice should not be built without gateways")
+
+	environment.Type = map[string]*TypeConfig{Rollout: {Strategy: CanaryStrategy}}
+	environment.Services = map[string][]*RegistryServiceConfig{
+		DefaultServiceKey: {
+			{Name: "sample-stable", Weight: 90, Ports: map[string]uint32{"http": 8090}},
+			{Name: "sample-canary", Weight: 10, Ports: map[string]uint32{"http": 8090}},
+		},
+		TestServiceKey: {{Name: "sample-canary", Ports: map[string]uint32{"http": 8090}}},
+	}
+	vs, err := BuildIngressVirtualService(identityConfig.IdentityName, environment, []string{"istio-system/passthrough-gateway"}, opts)
+	assert.Nil(t, err)
+	expected := &networking.VirtualService{
+		Hosts:    []string{"outbound_.80_._.canary.sample.global", "outbound_.80_._.prf.sample.global"},
+		Gateways: []string{"istio-system/passthrough-gateway"},
+		ExportTo: []string{"istio-system"},
+		Tls: []*networking.TLSRoute{
+			{
+				Match: []*networking.TLSMatchAttributes{{Port: 15443, SniHosts: []string{"outbound_.80_._.canary.sample.global"}}},
+				Route: []*networking.RouteDestination{
+					{Destination: &networking.Destination{Host: "sample-canary.sample-ns.svc.cluster.local", Port: &networking.PortSelector{Number: 8090}}},
+				},
+			},
+			{
+				Match: []*networking.TLSMatchAttributes{{Port: 15443, SniHosts: []string{"outbound_.80_._.prf.sample.global"}}},
+				Route: []*networking.RouteDestination{
+					{Destination: &networking.Destination{Host: "sample-canary.sample-ns.svc.cluster.local", Port: &networking.PortSelector{Number: 8090}}, Weight: 10},
+					{Destination: &networking.Destination{Host: "sample-stable.sample-ns.svc.cluster.local", Port: &networking.PortSelector{Number: 8090}}, Weight: 90},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expected, vs, protocmp.Transform()); diff != "" {
+		t.Errorf("unexpected virtualservice (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderVirtualServices(t *testing.T) {
+	opts := DefaultOptions()
+	input := Input{Identity: getTestIdentityConfig(), ClientCluster: "cluster1"}
+	output, err := Render(input, opts)
+	assert.Nil(t, err)
+	assert.Empty(t, output.VirtualServices, "the virtualservices should not be rendered when VS based routing is disabled")
+
+	opts.VSRoutingGateways = []string{"istio-system/passthrough-gateway"}
+	output, err = Render(input, opts)
+	assert.Nil(t, err)
+	assert.Len(t, output.VirtualServices, 1)
+	vs := output.VirtualServices[0]
+	assert.Equal(t, "prf.sample.global-routing-vs", vs.Name)
+	assert.Equal(t, "istio-system", vs.Namespace)
+	assert.Equal(t, "enabled", vs.Labels["admiral.io/vs-routing"])
+	assert.Equal(t, []string{"outbound_.80_._.prf.sample.global"}, vs.Spec.Hosts)
+
+	input.ClientCluster = "cluster2"
+	output, err = Render(input, opts)
+	assert.Nil(t, err)
+	assert.Empty(t, output.VirtualServices, "the virtualservices should only be rendered on the clusters of the identity")
+}
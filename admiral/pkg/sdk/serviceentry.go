@@ -0,0 +1,260 @@
+package sdk
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+const (
+	// Deployment and Rollout are the keys of IdentityConfigEnvironment.Type
+	Deployment = "deployment"
+	Rollout    = "rollout"
+
+	// BlueGreenStrategy and CanaryStrategy are the supported rollout strategies
+	BlueGreenStrategy = "bluegreen"
+	CanaryStrategy    = "canary"
+
+	// DefaultServiceKey and TestServiceKey are the keys of IdentityConfigEnvironment.Services
+	DefaultServiceKey = "default"
+	TestServiceKey    = "test"
+
+	canaryPrefix         = "canary"
+	previewPrefix        = "preview"
+	istioSystemNamespace = "istio-system"
+	spiffePrefix         = "spiffe://"
+	tlsModeLabel         = "security.istio.io/tlsMode"
+)
+
+// BuildServiceEntries builds the ServiceEntries to write to the client cluster by looping
+// through the IdentityConfig clusters and environments. It builds one ServiceEntry per
+// environment per host, with an endpoint for each cluster the identity is deployed in.
+func BuildServiceEntries(identityConfig IdentityConfig, clientCluster string, exportTo []string, opts Options) ([]*networking.ServiceEntry, error) {
+	var (
+		identity       = identityConfig.IdentityName
+		seMap          = map[string]map[string]*networking.ServiceEntry{}
+		serviceEntries = []*networking.ServiceEntry{}
+	)
+	ingressEndpoints, err := GetIngressEndpoints(identityConfig.Clusters)
+	if err != nil || len(ingressEndpoints) == 0 {
+		return serviceEntries, err
+	}
+	for _, identityConfigCluster := range identityConfig.Clusters {
+		serverCluster := identityConfigCluster.Name
+		for _, identityConfigEnvironment := range identityConfigCluster.Environment {
+			env := identityConfigEnvironment.Name
+			if len(identityConfigEnvironment.Services) == 0 {
+				return serviceEntries, fmt.Errorf("there were no services for the asset in namespace %s on cluster %s", identityConfigEnvironment.Namespace, serverCluster)
+			}
+			for _, host := range GetMeshHosts(identity, identityConfigEnvironment, opts.HostnameSuffix) {
+				var tmpSe *networking.ServiceEntry
+				endpoints, err := GetServiceEntryEndpoints(clientCluster, serverCluster, host, ingressEndpoints, identityConfigEnvironment, opts.LocalDomainSuffix)
+				if len(endpoints) == 0 || err != nil {
+					return serviceEntries, err
+				}
+				if clientCluster != serverCluster {
+					endpoints = GetWeightedIngressEndpoints(endpoints[0], identityConfigCluster.IngressEndpoints)
+				}
+				san := GetSubjectAltName(identity, GetTrustDomain(clientCluster, serverCluster, opts))
+				if se, ok := seMap[env][host]; !ok {
+					tmpSe = &networking.ServiceEntry{
+						Hosts:           []string{host},
+						Ports:           identityConfigEnvironment.Ports,
+						Location:        networking.ServiceEntry_MESH_INTERNAL,
+						Resolution:      networking.ServiceEntry_DNS,
+						SubjectAltNames: []string{san},
+						Endpoints:       endpoints,
+						ExportTo:        exportTo,
+					}
+				} else {
+					tmpSe = se
+					tmpSe.Endpoints = append(tmpSe.Endpoints, endpoints...)
+					// the server clusters of a federated identity can be in different trust domains
+					if !slices.Contains(tmpSe.SubjectAltNames, san) {
+						tmpSe.SubjectAltNames = append(tmpSe.SubjectAltNames, san)
+						sort.Strings(tmpSe.SubjectAltNames)
+					}
+				}
+				sortWorkloadEntries(tmpSe.Endpoints)
+				if seMap[env] == nil {
+					seMap[env] = map[string]*networking.ServiceEntry{}
+				}
+				seMap[env][host] = tmpSe
+			}
+		}
+	}
+	for _, seForEnv := range seMap {
+		for _, se := range seForEnv {
+			serviceEntries = append(serviceEntries, se)
+		}
+	}
+	sort.Slice(serviceEntries, func(i, j int) bool {
+		return serviceEntries[i].Hosts[0] < serviceEntries[j].Hosts[0]
+	})
+	return serviceEntries, err
+}
+
+// GetSubjectAltName returns the SPIFFE subject alt name of the identity
+func GetSubjectAltName(identity, sanPrefix string) string {
+	return spiffePrefix + sanPrefix + "/" + identity
+}
+
+// GetMeshHosts returns the hosts generated for an environment of the identity. Rollouts
+// using the bluegreen or canary strategy get an additional preview or canary host.
+func GetMeshHosts(identity string, identityConfigEnvironment *IdentityConfigEnvironment, hostnameSuffix string) []string {
+	meshHosts := []string{}
+	meshHosts = append(meshHosts, strings.Join([]string{identityConfigEnvironment.Name, strings.ToLower(identity), hostnameSuffix}, "."))
+	if identityConfigEnvironment.Type[Rollout] != nil {
+		strategy := identityConfigEnvironment.Type[Rollout].Strategy
+		if strategy == BlueGreenStrategy {
+			meshHosts = append(meshHosts, strings.Join([]string{previewPrefix, strings.ToLower(identity), hostnameSuffix}, "."))
+		}
+		if strategy == CanaryStrategy {
+			meshHosts = append(meshHosts, strings.Join([]string{canaryPrefix, strings.ToLower(identity), hostnameSuffix}, "."))
+		}
+	}
+	return meshHosts
+}
+
+// GetIngressEndpoints constructs the endpoint of the ingress gateway/remote endpoint for an identity
+// by reading the information directly from the IdentityConfigCluster.
+func GetIngressEndpoints(clusters map[string]*IdentityConfigCluster) (map[string]*networking.WorkloadEntry, error) {
+	ingressEndpoints := map[string]*networking.WorkloadEntry{}
+	var err error
+	for _, cluster := range clusters {
+		portNumber, err := strconv.ParseInt(cluster.IngressPort, 10, 64)
+		if err != nil {
+			return ingressEndpoints, err
+		}
+		ingressEndpoint := &networking.WorkloadEntry{
+			Address:  cluster.IngressEndpoint,
+			Locality: cluster.Locality,
+			Ports:    map[string]uint32{cluster.IngressPortName: uint32(portNumber)},
+			Labels:   map[string]string{tlsModeLabel: "istio"},
+		}
+		ingressEndpoints[cluster.Name] = ingressEndpoint
+	}
+	return ingressEndpoints, err
+}
+
+// GetWeightedIngressEndpoints returns the remote endpoints of the service entry, one per load balancer
+// of the cluster ingress. The ingress endpoint is returned as is when the cluster exposes a single
+// load balancer.
+func GetWeightedIngressEndpoints(ingressEndpoint *networking.WorkloadEntry, weightedEndpoints []*IngressEndpoint) []*networking.WorkloadEntry {
+	if len(weightedEndpoints) == 0 {
+		return []*networking.WorkloadEntry{ingressEndpoint}
+	}
+	endpoints := make([]*networking.WorkloadEntry, 0, len(weightedEndpoints))
+	for _, weightedEndpoint := range weightedEndpoints {
+		ep := ingressEndpoint.DeepCopy()
+		ep.Address = weightedEndpoint.Address
+		if weightedEndpoint.Locality != "" {
+			ep.Locality = weightedEndpoint.Locality
+		}
+		ep.Weight = weightedEndpoint.Weight
+		endpoints = append(endpoints, ep)
+	}
+	sortWorkloadEntries(endpoints)
+	return endpoints
+}
+
+// GetServiceEntryEndpoints constructs the remote or local endpoints of the service entry that
+// should be built for the given identityConfigEnvironment.
+//
+// Services will have 2 keys at max - default and test
+// a. Non istio canary rollout will have only default - which will have root svc
+// b. Istio canary rollout:
+// 1. If weights present - Default key will have stable, canary svc with weights. The latter can be part of test key as well
+// 2. If no weights present - Default key will have stable svc, test will have canary svc
+// c. Blue green rollout will have default key with stable svc, test with preview svc
+// d. Deployment will have default key with root svc
+func GetServiceEntryEndpoints(
+	clientCluster string,
+	serverCluster string,
+	host string,
+	ingressEndpoints map[string]*networking.WorkloadEntry,
+	identityConfigEnvironment *IdentityConfigEnvironment,
+	localDomainSuffix string) ([]*networking.WorkloadEntry, error) {
+	var err error
+	services := identityConfigEnvironment.Services
+	endpoint := ingressEndpoints[serverCluster]
+	endpoints := []*networking.WorkloadEntry{}
+
+	if services == nil {
+		return endpoints, fmt.Errorf("services are nil for identityConfigEnvironment %s", identityConfigEnvironment.Name)
+	}
+
+	ep := endpoint.DeepCopy()
+	if ep.Labels == nil {
+		ep.Labels = make(map[string]string)
+	}
+	ep.Labels[tlsModeLabel] = "istio"
+	if clientCluster == serverCluster {
+		if strings.HasPrefix(host, canaryPrefix) || strings.HasPrefix(host, previewPrefix) {
+			if services[TestServiceKey] != nil {
+				ep.Address = services[TestServiceKey][0].Name + "." + identityConfigEnvironment.Namespace + localDomainSuffix
+				ep.Ports = services[TestServiceKey][0].Ports
+				endpoints = append(endpoints, ep)
+			}
+		} else {
+			for _, service := range services[DefaultServiceKey] {
+				tempEp := ep.DeepCopy()
+				tempEp.Address = service.Name + "." + identityConfigEnvironment.Namespace + localDomainSuffix
+				tempEp.Ports = service.Ports
+				if service.Weight > 0 {
+					tempEp.Weight = uint32(service.Weight)
+				}
+				endpoints = append(endpoints, tempEp)
+			}
+			for _, service := range services[TestServiceKey] {
+				if service.Weight > 0 {
+					tempEp := ep.DeepCopy()
+					tempEp.Address = service.Name + "." + identityConfigEnvironment.Namespace + localDomainSuffix
+					tempEp.Ports = service.Ports
+					tempEp.Weight = uint32(service.Weight)
+					endpoints = append(endpoints, tempEp)
+				}
+			}
+		}
+	} else {
+		endpoints = append(endpoints, ep)
+	}
+	sortWorkloadEntries(endpoints)
+	return endpoints, err
+}
+
+// GetExportTo constructs a sorted list of namespaces on the client cluster where the passed client
+// identities are deployed. If the identity is also deployed on the client cluster then the
+// istio-system namespace is also in the list. The list is replaced with * when it has more than
+// maxNamespaces entries.
+func GetExportTo(clientCluster string, isServerOnClientCluster bool, clientIdentities []IdentityConfig, maxNamespaces int) []string {
+	clientNamespaces := []string{}
+	for _, clientIdentityConfig := range clientIdentities {
+		for _, clientIdentityConfigCluster := range clientIdentityConfig.Clusters {
+			// For each cluster the client asset is deployed on, we check if that cluster is the client cluster we are writing to
+			if clientCluster == clientIdentityConfigCluster.Name {
+				for _, clientIdentityConfigEnvironment := range clientIdentityConfigCluster.Environment {
+					clientNamespaces = append(clientNamespaces, clientIdentityConfigEnvironment.Namespace)
+				}
+			}
+		}
+	}
+	if isServerOnClientCluster {
+		clientNamespaces = append(clientNamespaces, istioSystemNamespace)
+	}
+	if len(clientNamespaces) > maxNamespaces {
+		clientNamespaces = []string{"*"}
+	}
+	sort.Strings(clientNamespaces)
+	return clientNamespaces
+}
+
+func sortWorkloadEntries(endpoints []*networking.WorkloadEntry) {
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Address < endpoints[j].Address
+	})
+}
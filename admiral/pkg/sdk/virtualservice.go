@@ -0,0 +1,102 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networking "istio.io/api/networking/v1alpha3"
+)
+
+const (
+	// mtlsPort is the port of the ingress gateway the cross cluster traffic is received on
+	mtlsPort = 15443
+	// sniPort is the port of the mesh hosts used in the SNI of the cross cluster traffic
+	sniPort = 80
+)
+
+// BuildIngressVirtualService builds the routing VirtualService Admiral writes to a server cluster
+// of the identity for an environment when VS based routing is enabled. It routes the SNI of each mesh
+// host of the environment received on the ingress gateways to the in-cluster services of the environment.
+func BuildIngressVirtualService(identity string, identityConfigEnvironment *IdentityConfigEnvironment, gateways []string, opts Options) (*networking.VirtualService, error) {
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("no gateways configured for ingress virtual service")
+	}
+	vs := &networking.VirtualService{
+		Gateways: gateways,
+		ExportTo: opts.IngressVSExportToNamespaces,
+	}
+	for _, host := range GetMeshHosts(identity, identityConfigEnvironment, opts.HostnameSuffix) {
+		routeDestinations := GetRouteDestinations(host, identityConfigEnvironment, opts.LocalDomainSuffix)
+		if len(routeDestinations) == 0 {
+			continue
+		}
+		sniHost := GetSNIHost(host)
+		vs.Hosts = append(vs.Hosts, sniHost)
+		vs.Tls = append(vs.Tls, &networking.TLSRoute{
+			Match: []*networking.TLSMatchAttributes{{Port: mtlsPort, SniHosts: []string{sniHost}}},
+			Route: routeDestinations,
+		})
+	}
+	if len(vs.Hosts) == 0 {
+		return nil, fmt.Errorf("there were no services to route to for the asset in namespace %s", identityConfigEnvironment.Namespace)
+	}
+	sort.Strings(vs.Hosts)
+	sort.Slice(vs.Tls, func(i, j int) bool {
+		return vs.Tls[i].Match[0].SniHosts[0] < vs.Tls[j].Match[0].SniHosts[0]
+	})
+	return vs, nil
+}
+
+// GetSNIHost returns the SNI of the cross cluster traffic to a mesh host
+//
+// Example: outbound_.80_._.stage.greeting.global
+func GetSNIHost(host string) string {
+	return fmt.Sprintf("outbound_.%d_._.%s", sniPort, host)
+}
+
+// GetRouteDestinations returns the in-cluster services the traffic to a mesh host is routed to. The
+// canary and preview hosts are routed to the test service, the default host is routed to the default
+// services and to the weighted test services, in the same way as the local ServiceEntry endpoints.
+func GetRouteDestinations(host string, identityConfigEnvironment *IdentityConfigEnvironment, localDomainSuffix string) []*networking.RouteDestination {
+	var (
+		services          = identityConfigEnvironment.Services
+		routeDestinations = []*networking.RouteDestination{}
+	)
+	if strings.HasPrefix(host, canaryPrefix) || strings.HasPrefix(host, previewPrefix) {
+		if len(services[TestServiceKey]) > 0 {
+			routeDestinations = append(routeDestinations, getRouteDestination(services[TestServiceKey][0], identityConfigEnvironment, localDomainSuffix, 0))
+		}
+		return routeDestinations
+	}
+	for _, service := range services[DefaultServiceKey] {
+		routeDestinations = append(routeDestinations, getRouteDestination(service, identityConfigEnvironment, localDomainSuffix, service.Weight))
+	}
+	for _, service := range services[TestServiceKey] {
+		if service.Weight > 0 {
+			routeDestinations = append(routeDestinations, getRouteDestination(service, identityConfigEnvironment, localDomainSuffix, service.Weight))
+		}
+	}
+	sort.Slice(routeDestinations, func(i, j int) bool {
+		return routeDestinations[i].Destination.Host < routeDestinations[j].Destination.Host
+	})
+	return routeDestinations
+}
+
+// getRouteDestination routes to the port of the service matching the first port of the environment
+func getRouteDestination(service *RegistryServiceConfig, identityConfigEnvironment *IdentityConfigEnvironment, localDomainSuffix string, weight int) *networking.RouteDestination {
+	routeDestination := &networking.RouteDestination{
+		Destination: &networking.Destination{
+			Host: service.Name + "." + identityConfigEnvironment.Namespace + localDomainSuffix,
+		},
+	}
+	if len(identityConfigEnvironment.Ports) > 0 {
+		if port, ok := service.Ports[identityConfigEnvironment.Ports[0].Name]; ok {
+			routeDestination.Destination.Port = &networking.PortSelector{Number: port}
+		}
+	}
+	if weight > 0 {
+		routeDestination.Weight = int32(weight)
+	}
+	return routeDestination
+}
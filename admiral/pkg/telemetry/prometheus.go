@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	prometheusTimeout = 30 * time.Second
+
+	// requestsQuery returns the requests reported by the client sidecars, per calling workload and called host
+	requestsQuery = `sum by (source_workload, source_workload_namespace, destination_service) ` +
+		`(increase(istio_requests_total{reporter="source"}[%s])) > 0`
+)
+
+// PrometheusSource reads the calls observed during the window from the istio_requests_total metric
+// of the Istio standard metrics scraped by a Prometheus server
+type PrometheusSource struct {
+	address string
+	window  time.Duration
+	client  *http.Client
+}
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func NewPrometheusSource(address string, window time.Duration) *PrometheusSource {
+	return &PrometheusSource{
+		address: strings.TrimSuffix(address, "/"),
+		window:  window,
+		client:  &http.Client{Timeout: prometheusTimeout},
+	}
+}
+
+func (p *PrometheusSource) Name() string {
+	return prometheusSource
+}
+
+func (p *PrometheusSource) Edges(ctx context.Context) ([]Edge, error) {
+	query := fmt.Sprintf(requestsQuery, model.Duration(p.window).String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+"/api/v1/query?query="+url.QueryEscape(query), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("prometheus returned %d: %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Status != "success" {
+		return nil, fmt.Errorf("prometheus returned %d: %s", resp.StatusCode, result.Error)
+	}
+
+	edges := make([]Edge, 0, len(result.Data.Result))
+	for _, sample := range result.Data.Result {
+		edge := Edge{
+			SourceWorkload:  sample.Metric["source_workload"],
+			SourceNamespace: sample.Metric["source_workload_namespace"],
+			DestinationHost: sample.Metric["destination_service"],
+		}
+		if edge.SourceWorkload == "" || edge.SourceWorkload == "unknown" || edge.DestinationHost == "" || edge.DestinationHost == "unknown" {
+			continue
+		}
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].SourceNamespace != edges[j].SourceNamespace {
+			return edges[i].SourceNamespace < edges[j].SourceNamespace
+		}
+		if edges[i].SourceWorkload != edges[j].SourceWorkload {
+			return edges[i].SourceWorkload < edges[j].SourceWorkload
+		}
+		return edges[i].DestinationHost < edges[j].DestinationHost
+	})
+	return edges, nil
+}
@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusSourceEdges(t *testing.T) {
+	response := `{"status":"success","data":{"resultType":"vector","result":[` +
+		`{"metric":{"source_workload":"payments","source_workload_namespace":"payments-ns","destination_service":"qa.ledger.global"},"value":[0,"12"]},` +
+		`{"metric":{"source_workload":"unknown","source_workload_namespace":"unknown","destination_service":"qa.ledger.global"},"value":[0,"3"]},` +
+		`{"metric":{"source_workload":"billing","source_workload_namespace":"billing-ns","destination_service":"qa.payments.global"},"value":[0,"1"]}]}}`
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Contains(t, r.URL.Query().Get("query"), `istio_requests_total{reporter="source"}[1h]`)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	edges, err := NewPrometheusSource(server.URL+"/", time.Hour).Edges(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []Edge{
+		{SourceWorkload: "billing", SourceNamespace: "billing-ns", DestinationHost: "qa.payments.global"},
+		{SourceWorkload: "payments", SourceNamespace: "payments-ns", DestinationHost: "qa.ledger.global"},
+	}, edges)
+
+	status = http.StatusBadRequest
+	response = `{"status":"error","error":"parse error"}`
+	_, err = NewPrometheusSource(server.URL, time.Hour).Edges(context.Background())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	prometheusSource = "prometheus"
+)
+
+// Edge is a call observed in the mesh telemetry, from a workload to the host of the service it called
+type Edge struct {
+	SourceWorkload  string
+	SourceNamespace string
+	DestinationHost string
+}
+
+// Source reads the calls observed between the workloads of the mesh
+type Source interface {
+	// Name returns the name of the source
+	Name() string
+	// Edges returns the calls observed during the window of the source
+	Edges(ctx context.Context) ([]Edge, error)
+}
+
+// NewSource returns the built-in telemetry source of the type which reads the calls observed
+// during the window from the address
+func NewSource(sourceType, address string, window time.Duration) (Source, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address of %s telemetry source is empty", sourceType)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("window of %s telemetry source must be positive", sourceType)
+	}
+	switch strings.ToLower(sourceType) {
+	// Add entries for your custom telemetry sources below, e.g. one reading the envoy access logs
+	case prometheusSource:
+		return NewPrometheusSource(address, window), nil
+	default:
+		return nil, fmt.Errorf("unsupported telemetry source type %q", sourceType)
+	}
+}
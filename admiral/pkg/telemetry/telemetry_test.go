@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSource(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sourceType  string
+		address     string
+		window      time.Duration
+		expectedErr bool
+	}{
+		{
+			name: "Given a prometheus source type, " +
+				"When NewSource is called, " +
+				"Then a prometheus source should be returned",
+			sourceType: "Prometheus",
+			address:    "http://localhost:9090",
+			window:     time.Hour,
+		},
+		{
+			name: "Given an unsupported source type, " +
+				"When NewSource is called, " +
+				"Then an error should be returned",
+			sourceType:  "accesslog",
+			address:     "http://localhost:9090",
+			window:      time.Hour,
+			expectedErr: true,
+		},
+		{
+			name: "Given an empty address, " +
+				"When NewSource is called, " +
+				"Then an error should be returned",
+			sourceType:  "prometheus",
+			window:      time.Hour,
+			expectedErr: true,
+		},
+		{
+			name: "Given an empty window, " +
+				"When NewSource is called, " +
+				"Then an error should be returned",
+			sourceType:  "prometheus",
+			address:     "http://localhost:9090",
+			expectedErr: true,
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			source, err := NewSource(c.sourceType, c.address, c.window)
+			if c.expectedErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "prometheus", source.Name())
+		})
+	}
+}
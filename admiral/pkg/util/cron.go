@@ -0,0 +1,111 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a standard five fields cron schedule, minute hour day-of-month month day-of-week,
+// evaluated in UTC. The fields support *, lists, ranges and steps, the day of the week is 0 for Sunday.
+// Like cron, a time matches the days of the month or of the week when both are restricted
+type CronSchedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	anyDOM      bool
+	anyDOW      bool
+}
+
+// ParseCronSchedule parses a five fields cron schedule
+func ParseCronSchedule(spec string) (*CronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron schedule %q does not have 5 fields", spec)
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	values := make([]map[int]bool, 5)
+	for i, field := range fields {
+		parsed, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron schedule %q: %v", spec, err)
+		}
+		values[i] = parsed
+	}
+	return &CronSchedule{
+		minutes:     values[0],
+		hours:       values[1],
+		daysOfMonth: values[2],
+		months:      values[3],
+		daysOfWeek:  values[4],
+		anyDOM:      fields[2] == "*",
+		anyDOW:      fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("step %q is not valid", stepPart)
+			}
+		}
+		low, high := min, max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowPart); err != nil {
+				return nil, fmt.Errorf("value %q is not valid", lowPart)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return nil, fmt.Errorf("value %q is not valid", highPart)
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("%q is not within %d-%d", part, min, max)
+		}
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// Matches checks if the minute of the time is one the schedule fires at
+func (s *CronSchedule) Matches(t time.Time) bool {
+	t = t.UTC()
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+	dom, dow := s.daysOfMonth[t.Day()], s.daysOfWeek[int(t.Weekday())]
+	if s.anyDOM || s.anyDOW {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// LastFiredWithin returns the last time the schedule fired at or before the time, looking back no
+// further than the duration
+func (s *CronSchedule) LastFiredWithin(t time.Time, within time.Duration) (time.Time, bool) {
+	minute := t.UTC().Truncate(time.Minute)
+	earliest := t.Add(-within)
+	for ; minute.After(earliest); minute = minute.Add(-time.Minute) {
+		if s.Matches(minute) {
+			return minute, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	cases := []struct {
+		name        string
+		spec        string
+		expectedErr bool
+	}{
+		{
+			name: "Given a schedule with lists, ranges and steps, " +
+				"When ParseCronSchedule is called, " +
+				"Then it should be parsed",
+			spec: "*/15 8-17 1,15 * 1-5",
+		},
+		{
+			name: "Given a schedule with 4 fields, " +
+				"When ParseCronSchedule is called, " +
+				"Then an error should be returned",
+			spec:        "0 8 * *",
+			expectedErr: true,
+		},
+		{
+			name: "Given a schedule with an hour out of range, " +
+				"When ParseCronSchedule is called, " +
+				"Then an error should be returned",
+			spec:        "0 24 * * *",
+			expectedErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseCronSchedule(c.spec)
+			if (err != nil) != c.expectedErr {
+				t.Errorf("expected error %v, got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCronScheduleLastFiredWithin(t *testing.T) {
+	// every weekday at 08:00 UTC
+	schedule, err := ParseCronSchedule("0 8 * * 1-5")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		at       time.Time
+		expected bool
+	}{
+		{
+			name: "Given a time within the duration after the schedule fired, " +
+				"When LastFiredWithin is called, " +
+				"Then the time it fired at should be returned",
+			at:       monday.Add(10 * time.Hour),
+			expected: true,
+		},
+		{
+			name: "Given a time beyond the duration after the schedule fired, " +
+				"When LastFiredWithin is called, " +
+				"Then nothing should be returned",
+			at:       monday.Add(17 * time.Hour),
+			expected: false,
+		},
+		{
+			name: "Given a time on a day the schedule does not fire, " +
+				"When LastFiredWithin is called, " +
+				"Then nothing should be returned",
+			at:       monday.Add(-14 * time.Hour),
+			expected: false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fired, ok := schedule.LastFiredWithin(c.at, 8*time.Hour)
+			if ok != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, ok)
+			}
+			if ok && !fired.Equal(monday.Add(8*time.Hour)) {
+				t.Errorf("expected to fire at %v, got %v", monday.Add(8*time.Hour), fired)
+			}
+		})
+	}
+}
@@ -19,10 +19,32 @@ func IsAdmiralReadOnly() bool {
 }
 
 // ResyncIntervals defines the different reconciliation intervals
-// for kubernetes operators
+// for kubernetes operators. The intervals of a resource type override
+// the universal or se and dr interval when set, see ResyncInterval
 type ResyncIntervals struct {
-	UniversalReconcileInterval time.Duration
-	SeAndDrReconcileInterval   time.Duration
+	UniversalReconcileInterval       time.Duration
+	SeAndDrReconcileInterval         time.Duration
+	DeploymentReconcileInterval      time.Duration
+	RolloutReconcileInterval         time.Duration
+	NodeReconcileInterval            time.Duration
+	ServiceReconcileInterval         time.Duration
+	ServiceEntryReconcileInterval    time.Duration
+	DestinationRuleReconcileInterval time.Duration
+	VirtualServiceReconcileInterval  time.Duration
+	SidecarReconcileInterval         time.Duration
+}
+
+// ResyncInterval returns the resync interval of the informer of a resource type, which is
+// the default interval when the interval of the resource type is not set, and 0, disabling
+// the resync, when it is negative
+func ResyncInterval(interval, defaultInterval time.Duration) time.Duration {
+	if interval == 0 {
+		return defaultInterval
+	}
+	if interval < 0 {
+		return 0
+	}
+	return interval
 }
 
 func GetPortProtocol(name string) string {
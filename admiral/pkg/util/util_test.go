@@ -1,6 +1,9 @@
 package util
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestGetPortProtocol(t *testing.T) {
 	cases := []struct {
@@ -46,3 +49,43 @@ func TestGetPortProtocol(t *testing.T) {
 		})
 	}
 }
+
+func TestResyncInterval(t *testing.T) {
+	cases := []struct {
+		name            string
+		interval        time.Duration
+		defaultInterval time.Duration
+		expected        time.Duration
+	}{
+		{
+			name: "Given the interval of the resource type is not set, " +
+				"When ResyncInterval is called, " +
+				"Then the default interval should be returned",
+			defaultInterval: 5 * time.Minute,
+			expected:        5 * time.Minute,
+		},
+		{
+			name: "Given the interval of the resource type is set, " +
+				"When ResyncInterval is called, " +
+				"Then the interval should be returned",
+			interval:        time.Hour,
+			defaultInterval: 5 * time.Minute,
+			expected:        time.Hour,
+		},
+		{
+			name: "Given the interval of the resource type is negative, " +
+				"When ResyncInterval is called, " +
+				"Then the resync should be disabled",
+			interval:        -1,
+			defaultInterval: 5 * time.Minute,
+			expected:        0,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := ResyncInterval(c.interval, c.defaultInterval); actual != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}